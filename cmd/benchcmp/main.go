@@ -0,0 +1,178 @@
+// Command benchcmp compares two sets of `go test -bench` results and fails
+// when a benchmark got slower or allocates more than a threshold allows.
+// Repeated runs of a benchmark (-count) are reduced to their median.
+//
+// Usage:
+//
+//	benchcmp [-threshold 10] [-metrics ns/op,allocs/op] old.txt new.txt
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func main() {
+	threshold := flag.Float64("threshold", 10, "Largest allowed increase, in percent")
+	metrics := flag.String("metrics", "ns/op,allocs/op", "Comma-separated units checked against the threshold")
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: benchcmp [-threshold 10] [-metrics ns/op,allocs/op] old.txt new.txt")
+		os.Exit(2)
+	}
+	old, err := parseFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", flag.Arg(0), err)
+		os.Exit(2)
+	}
+	cur, err := parseFile(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", flag.Arg(1), err)
+		os.Exit(2)
+	}
+
+	deltas := compare(old, cur, strings.Split(*metrics, ","))
+	regressions := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "benchmark\tmetric\told\tnew\tdelta\t")
+	for _, d := range deltas {
+		mark := ""
+		if d.worse() > *threshold {
+			mark = "REGRESSION"
+			regressions++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%+.1f%%\t%s\n", d.Name, d.Unit, formatValue(d.Old), formatValue(d.New), d.Percent, mark)
+	}
+	w.Flush()
+
+	if regressions > 0 {
+		fmt.Fprintf(os.Stderr, "%d regression(s) over %.1f%%\n", regressions, *threshold)
+		os.Exit(1)
+	}
+}
+
+// results maps a benchmark name to its samples by unit, e.g. "ns/op"
+type results map[string]map[string][]float64
+
+// delta is the change in one metric of a benchmark present in both runs
+type delta struct {
+	Name    string
+	Unit    string
+	Old     float64
+	New     float64
+	Percent float64
+}
+
+// worse returns how much worse the benchmark got, in percent. Rates such as
+// MB/s are better when higher; every other unit is better when lower.
+func (d delta) worse() float64 {
+	if strings.HasSuffix(d.Unit, "/s") {
+		return -d.Percent
+	}
+	return d.Percent
+}
+
+// procSuffix is the -GOMAXPROCS suffix go test appends to benchmark names
+var procSuffix = regexp.MustCompile(`-\d+$`)
+
+func parseFile(path string) (results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// parse reads benchmark result lines, ignoring everything else go test prints
+func parse(r io.Reader) (results, error) {
+	res := results{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+
+		name := procSuffix.ReplaceAllString(fields[0], "")
+		if res[name] == nil {
+			res[name] = map[string][]float64{}
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			unit := fields[i+1]
+			res[name][unit] = append(res[name][unit], value)
+		}
+	}
+	return res, scanner.Err()
+}
+
+// compare returns the change in each of units for benchmarks in both runs,
+// sorted by name
+func compare(old, cur results, units []string) []delta {
+	var deltas []delta
+	for name, curSamples := range cur {
+		oldSamples, ok := old[name]
+		if !ok {
+			continue
+		}
+		for _, unit := range units {
+			if len(oldSamples[unit]) == 0 || len(curSamples[unit]) == 0 {
+				continue
+			}
+			o, n := median(oldSamples[unit]), median(curSamples[unit])
+			d := delta{Name: name, Unit: unit, Old: o, New: n}
+			switch {
+			case o != 0:
+				d.Percent = (n - o) / o * 100
+			case n != 0:
+				d.Percent = 100
+			}
+			deltas = append(deltas, d)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Name != deltas[j].Name {
+			return deltas[i].Name < deltas[j].Name
+		}
+		return deltas[i].Unit < deltas[j].Unit
+	})
+	return deltas
+}
+
+// formatValue prints large values as integers and small ones, such as MB/s,
+// with two decimals
+func formatValue(v float64) string {
+	if v >= 100 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func median(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
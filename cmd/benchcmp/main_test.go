@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const oldRun = `goos: linux
+BenchmarkPing/InMemory-8   	   20000	     50000 ns/op	    4000 B/op	     100 allocs/op
+BenchmarkPing/InMemory-8   	   20000	     70000 ns/op	    4000 B/op	     100 allocs/op
+BenchmarkPing/InMemory-8   	   20000	     52000 ns/op	    4000 B/op	     100 allocs/op
+BenchmarkRead/1KB-8        	    1000	    100000 ns/op	  10.00 MB/s	   18000 B/op	     160 allocs/op
+BenchmarkGone-8            	    1000	       100 ns/op
+PASS
+ok  	github.com/dwrtz/mcp-go/pkg/mcp	1.2s
+`
+
+const newRun = `BenchmarkPing/InMemory-4   	   20000	     51000 ns/op	    3000 B/op	     120 allocs/op
+BenchmarkRead/1KB-4        	    1000	     90000 ns/op	  11.00 MB/s	   18000 B/op	     160 allocs/op
+BenchmarkAdded-4           	    1000	       100 ns/op
+`
+
+func TestCompare(t *testing.T) {
+	old, err := parse(strings.NewReader(oldRun))
+	if err != nil {
+		t.Fatalf("parse() error: %v", err)
+	}
+	if got := old["BenchmarkPing/InMemory"]["ns/op"]; len(got) != 3 {
+		t.Fatalf("Expected 3 ns/op samples, got %v", got)
+	}
+	cur, err := parse(strings.NewReader(newRun))
+	if err != nil {
+		t.Fatalf("parse() error: %v", err)
+	}
+
+	deltas := compare(old, cur, []string{"ns/op", "allocs/op", "MB/s"})
+	want := []delta{
+		{Name: "BenchmarkPing/InMemory", Unit: "allocs/op", Old: 100, New: 120, Percent: 20},
+		{Name: "BenchmarkPing/InMemory", Unit: "ns/op", Old: 52000, New: 51000, Percent: -1000.0 / 52000 * 100},
+		{Name: "BenchmarkRead/1KB", Unit: "MB/s", Old: 10, New: 11, Percent: 10},
+		{Name: "BenchmarkRead/1KB", Unit: "allocs/op", Old: 160, New: 160},
+		{Name: "BenchmarkRead/1KB", Unit: "ns/op", Old: 100000, New: 90000, Percent: -10},
+	}
+	if len(deltas) != len(want) {
+		t.Fatalf("Expected %d deltas, got %+v", len(want), deltas)
+	}
+	for i := range want {
+		d := deltas[i]
+		if d.Name != want[i].Name || d.Unit != want[i].Unit || d.Old != want[i].Old || d.New != want[i].New ||
+			d.Percent-want[i].Percent > 1e-9 || want[i].Percent-d.Percent > 1e-9 {
+			t.Errorf("delta %d = %+v, want %+v", i, d, want[i])
+		}
+	}
+
+	// Throughput going up is an improvement
+	if w := deltas[2].worse(); w != -10 {
+		t.Errorf("Expected MB/s increase to be 10%% better, got worse by %v", w)
+	}
+	if w := deltas[0].worse(); w != 20 {
+		t.Errorf("Expected allocs/op increase to be 20%% worse, got %v", w)
+	}
+}
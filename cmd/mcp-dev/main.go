@@ -0,0 +1,208 @@
+// Command mcp-dev builds a stdio MCP server and relaunches it whenever its
+// Go sources change. Hosts connect over SSE to mcp-dev, which bridges to the
+// current server process, so they stay connected across restarts.
+//
+// Usage:
+//
+//	mcp-dev [-addr :8080] [-watch .] [-interval 500ms] [package] [-- server args]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/bridge"
+	"github.com/dwrtz/mcp-go/internal/transport/sse"
+	"github.com/dwrtz/mcp-go/internal/transport/stdio"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+)
+
+func main() {
+	listenAddr := flag.String("addr", ":8080", "Address to listen for SSE connections (e.g. :8080)")
+	watchDir := flag.String("watch", ".", "Directory whose Go sources trigger a rebuild")
+	interval := flag.Duration("interval", 500*time.Millisecond, "How often to check for source changes")
+	flag.Parse()
+
+	pkg := "."
+	args := flag.Args()
+	if len(args) > 0 && args[0] != "--" {
+		pkg, args = args[0], args[1:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	lg := logger.NewStderrLogger("MCP-DEV")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	binDir, err := os.MkdirTemp("", "mcp-dev")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create build directory: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(binDir)
+
+	host := sse.NewSSEServer(*listenAddr)
+	b := bridge.New(host)
+	b.SetLogger(lg)
+	if err := b.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Bridge start error: %v\n", err)
+		os.Exit(1)
+	}
+	defer b.Close()
+	lg.Logf("Listening for SSE connections on %s", host.BoundAddr())
+
+	r := &runner{bridge: b, logger: lg, pkg: pkg, args: args, binDir: binDir}
+	defer r.stop()
+
+	sources, err := snapshot(*watchDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to scan %s: %v\n", *watchDir, err)
+		os.Exit(1)
+	}
+	if err := r.restart(ctx); err != nil {
+		lg.Logf("%v; waiting for changes", err)
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			lg.Logf("Shutting down")
+			return
+		case <-ticker.C:
+		}
+
+		current, err := snapshot(*watchDir)
+		if err != nil {
+			lg.Logf("Failed to scan %s: %v", *watchDir, err)
+			continue
+		}
+		if current == sources {
+			continue
+		}
+		sources = current
+		lg.Logf("Sources changed, rebuilding")
+		if err := r.restart(ctx); err != nil {
+			lg.Logf("%v; keeping the running server", err)
+		}
+	}
+}
+
+// runner builds and runs the server, one process at a time
+type runner struct {
+	bridge *bridge.Bridge
+	logger logger.Logger
+	pkg    string
+	args   []string
+	binDir string
+
+	builds int
+	cmd    *exec.Cmd
+}
+
+// restart builds the server and swaps the new process in behind the bridge.
+// If the build or startup fails, the running process is kept.
+func (r *runner) restart(ctx context.Context) error {
+	r.builds++
+	bin := filepath.Join(r.binDir, fmt.Sprintf("server-%d", r.builds))
+	build := exec.CommandContext(ctx, "go", "build", "-o", bin, r.pkg)
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+
+	cmd := exec.Command(bin, r.args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	t := stdio.NewTransport(stdout, stdin)
+	t.SetLogger(r.logger)
+	if err := r.bridge.Attach(ctx, t); err != nil {
+		kill(cmd)
+		return err
+	}
+
+	prev := r.cmd
+	r.cmd = cmd
+	if prev != nil {
+		kill(prev)
+		os.Remove(prev.Path)
+	}
+	r.logger.Logf("Server running (pid %d)", cmd.Process.Pid)
+	return nil
+}
+
+// stop ends the running process
+func (r *runner) stop() {
+	if r.cmd != nil {
+		kill(r.cmd)
+	}
+}
+
+// kill ends a server process and reaps it
+func kill(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+}
+
+// sourceState summarizes the Go sources under a directory
+type sourceState struct {
+	files   int
+	size    int64
+	modTime time.Time
+}
+
+// snapshot summarizes the .go files and go.mod/go.sum under dir, skipping
+// hidden directories
+func snapshot(dir string) (sourceState, error) {
+	var state sourceState
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		state.files++
+		state.size += info.Size()
+		if info.ModTime().After(state.modTime) {
+			state.modTime = info.ModTime()
+		}
+		return nil
+	})
+	return state, err
+}
@@ -0,0 +1,200 @@
+// Command mcp-smoke is an MCP server with one of each feature, for automated
+// end-to-end tests of hosts. Its behavior is fixed, so tests can assert on
+// exact results:
+//
+//   - tool "echo" returns its text argument unchanged
+//   - tool "increment" adds one to smoke://counter and notifies subscribers
+//   - tool "sample" asks the client's model to reply to its prompt argument
+//   - tool "log" sends a log message of the given level to the client
+//   - prompt "greeting" greets its name argument
+//   - resource smoke://counter holds the counter, starting at 0
+//
+// It serves stdio by default, or SSE with -addr.
+//
+// Usage:
+//
+//	mcp-smoke [-addr :8080]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
+)
+
+// counterURI is the smoke server's subscribable resource
+const counterURI = "smoke://counter"
+
+// EchoInput is the input of the echo tool
+type EchoInput struct {
+	Text string `json:"text" jsonschema:"description=Text to echo back,required"`
+}
+
+// IncrementInput is the input of the increment tool
+type IncrementInput struct{}
+
+// SampleInput is the input of the sample tool
+type SampleInput struct {
+	Prompt string `json:"prompt" jsonschema:"description=Message to send to the client's model,required"`
+}
+
+// LogInput is the input of the log tool
+type LogInput struct {
+	Level   string `json:"level" jsonschema:"description=Logging level such as info or error,required"`
+	Message string `json:"message" jsonschema:"description=Message to log,required"`
+}
+
+// smoke is the state behind the smoke server's features
+type smoke struct {
+	server *server.Server
+
+	mu      sync.Mutex
+	counter int
+}
+
+// newSmoke builds the smoke server with newServer, which picks the transport
+func newSmoke(newServer func(opts ...server.Option) *server.Server, lg logger.Logger) *smoke {
+	sm := &smoke{}
+	sm.server = newServer(
+		server.WithLogger(lg),
+		server.WithURISchemes(urischeme.Scheme{Name: "smoke"}),
+		server.WithTools(
+			types.NewTool("echo", "Returns the text argument unchanged", sm.echo),
+			types.NewTool("increment", "Adds one to "+counterURI+" and notifies its subscribers", sm.increment),
+			types.NewTool("sample", "Asks the client's model to reply to the prompt argument", sm.sample),
+			types.NewTool("log", "Sends a log message of the given level to the client", sm.log),
+		),
+		server.WithPrompts([]types.Prompt{{
+			Name:        "greeting",
+			Description: "Greets the name argument",
+			Arguments:   []types.PromptArgument{{Name: "name", Description: "Who to greet", Required: true}},
+		}}),
+		server.WithResources([]types.Resource{{
+			URI:      counterURI,
+			Name:     "Counter",
+			MimeType: "text/plain",
+		}}, nil),
+		server.WithLogging(),
+	)
+	sm.server.RegisterPromptGetter("greeting", sm.greeting)
+	sm.server.RegisterContentHandler("smoke://", sm.read)
+	return sm
+}
+
+func (sm *smoke) echo(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+	return textResult(input.Text), nil
+}
+
+func (sm *smoke) increment(ctx context.Context, _ IncrementInput) (*types.CallToolResult, error) {
+	sm.mu.Lock()
+	sm.counter++
+	n := sm.counter
+	sm.mu.Unlock()
+	if err := sm.server.NotifyResourceUpdated(ctx, counterURI); err != nil {
+		return nil, err
+	}
+	return textResult(strconv.Itoa(n)), nil
+}
+
+func (sm *smoke) sample(ctx context.Context, input SampleInput) (*types.CallToolResult, error) {
+	result, err := sm.server.CreateMessage(ctx, &types.CreateMessageRequest{
+		Messages: []types.SamplingMessage{{
+			Role:    types.RoleUser,
+			Content: types.TextContent{Type: "text", Text: input.Prompt},
+		}},
+		MaxTokens: 100,
+	})
+	if err != nil {
+		return nil, err
+	}
+	text, ok := result.Content.(types.TextContent)
+	if !ok {
+		return nil, fmt.Errorf("model replied with non-text content")
+	}
+	return textResult(text.Text), nil
+}
+
+func (sm *smoke) log(ctx context.Context, input LogInput) (*types.CallToolResult, error) {
+	level := types.LoggingLevel(input.Level)
+	if level.Severity() < 0 {
+		return nil, types.NewError(types.InvalidParams, "unknown logging level: "+input.Level)
+	}
+	if err := sm.server.LogMessage(ctx, level, "mcp-smoke", input.Message); err != nil {
+		return nil, err
+	}
+	return textResult("logged"), nil
+}
+
+func (sm *smoke) greeting(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+	name, ok := args["name"]
+	if !ok {
+		return nil, types.NewError(types.InvalidParams, "missing argument: name")
+	}
+	return &types.GetPromptResult{
+		Description: "Greeting",
+		Messages: []types.PromptMessage{{
+			Role:    types.RoleUser,
+			Content: types.TextContent{Type: "text", Text: "Hello, " + name + "!"},
+		}},
+	}, nil
+}
+
+func (sm *smoke) read(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+	if uri != counterURI {
+		return nil, types.NewError(types.InvalidParams, "resource not found: "+uri)
+	}
+	sm.mu.Lock()
+	n := sm.counter
+	sm.mu.Unlock()
+	return []types.ResourceContent{types.TextResourceContents{
+		ResourceContents: types.ResourceContents{URI: uri, MimeType: "text/plain"},
+		Text:             strconv.Itoa(n),
+	}}, nil
+}
+
+func textResult(text string) *types.CallToolResult {
+	return &types.CallToolResult{
+		Content: []interface{}{types.TextContent{Type: "text", Text: text}},
+	}
+}
+
+func main() {
+	listenAddr := flag.String("addr", "", "Address to listen for SSE connections (e.g. :8080); stdio if empty")
+	flag.Parse()
+
+	lg := logger.NewStderrLogger("MCP-SMOKE")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sm := newSmoke(func(opts ...server.Option) *server.Server {
+		if *listenAddr == "" {
+			return server.NewDefaultServer(opts...)
+		}
+		return server.NewSseServer(*listenAddr, opts...)
+	}, lg)
+
+	if err := sm.server.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Server start error: %v\n", err)
+		os.Exit(1)
+	}
+	if *listenAddr != "" {
+		lg.Logf("Listening on %s", sm.server.BoundAddr())
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-sm.server.Done():
+	}
+	sm.server.Close()
+}
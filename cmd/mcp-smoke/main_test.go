@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/mock"
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp/client"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestSmoke(t *testing.T) {
+	lg := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(lg)
+	sm := newSmoke(func(opts ...server.Option) *server.Server {
+		return server.NewServer(serverTransport, opts...)
+	}, lg)
+
+	c := client.NewClient(clientTransport,
+		client.WithLogger(lg),
+		client.WithSampling(func(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
+			prompt := req.Messages[0].Content.(types.TextContent).Text
+			return &types.CreateMessageResult{
+				Role:    types.RoleAssistant,
+				Content: types.TextContent{Type: "text", Text: "reply to " + prompt},
+				Model:   "test-model",
+			}, nil
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := sm.server.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer sm.server.Close()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer c.Close()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	callText := func(name string, args map[string]interface{}) string {
+		t.Helper()
+		result, err := c.CallTool(ctx, name, args)
+		if err != nil {
+			t.Fatalf("CallTool(%s) error: %v", name, err)
+		}
+		if result.IsError || len(result.Content) != 1 {
+			t.Fatalf("CallTool(%s) = %+v", name, result)
+		}
+		text, _ := result.Content[0].(map[string]interface{})["text"].(string)
+		return text
+	}
+
+	if got := callText("echo", map[string]interface{}{"text": "ping"}); got != "ping" {
+		t.Errorf("echo = %q, want %q", got, "ping")
+	}
+	if got := callText("sample", map[string]interface{}{"prompt": "hi"}); got != "reply to hi" {
+		t.Errorf("sample = %q, want %q", got, "reply to hi")
+	}
+
+	prompt, err := c.GetPrompt(ctx, "greeting", map[string]string{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("GetPrompt error: %v", err)
+	}
+	if got := prompt.Messages[0].Content.(types.TextContent).Text; got != "Hello, Ada!" {
+		t.Errorf("greeting = %q, want %q", got, "Hello, Ada!")
+	}
+
+	updated := make(chan string, 1)
+	c.OnResourceUpdated(func(uri string) { updated <- uri })
+	if err := c.SubscribeResource(ctx, counterURI); err != nil {
+		t.Fatalf("SubscribeResource error: %v", err)
+	}
+	if got := callText("increment", nil); got != "1" {
+		t.Errorf("increment = %q, want %q", got, "1")
+	}
+	select {
+	case uri := <-updated:
+		if uri != counterURI {
+			t.Errorf("Update for %q, want %q", uri, counterURI)
+		}
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for the counter update")
+	}
+	contents, err := c.ReadResource(ctx, counterURI)
+	if err != nil {
+		t.Fatalf("ReadResource error: %v", err)
+	}
+	if got := contents[0].(types.TextResourceContents).Text; got != "1" {
+		t.Errorf("Counter = %q, want %q", got, "1")
+	}
+
+	logged := make(chan types.LoggingMessageNotification, 2)
+	c.OnLogMessage(func(n types.LoggingMessageNotification) { logged <- n })
+	if err := c.SetLogLevel(ctx, types.LoggingWarning); err != nil {
+		t.Fatalf("SetLogLevel error: %v", err)
+	}
+	callText("log", map[string]interface{}{"level": "info", "message": "dropped"})
+	callText("log", map[string]interface{}{"level": "error", "message": "kept"})
+	select {
+	case n := <-logged:
+		if n.Level != types.LoggingError || n.Logger != "mcp-smoke" || n.Data != "kept" {
+			t.Errorf("Log message = %+v, want the error message", n)
+		}
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for the log message")
+	}
+}
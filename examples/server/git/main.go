@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+)
+
+func main() {
+	repoDir := flag.String("repo", ".", "Path to the git repository to expose")
+	flag.Parse()
+
+	lg := logger.NewStderrLogger("GIT-SERVER")
+
+	dir, err := filepath.Abs(*repoDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid repository path: %v\n", err)
+		os.Exit(1)
+	}
+
+	repo, err := NewRepoProvider(filepath.Base(dir), dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// Create a server exposing the repository as resources plus log/blame/diff tools
+	s := server.NewDefaultServer(
+		server.WithLogger(lg),
+		server.WithResources(nil, nil),
+		server.WithTools(repo.Tools()...),
+	)
+
+	// Create a context that can be canceled when the server is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := s.AddResourceProvider(ctx, repo.Prefix(), repo); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to add repository provider: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := s.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Server start error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Set up OS signal handling for graceful shutdown (e.g. Ctrl+C)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	// Wait until either a termination signal is received or the transport is closed.
+	select {
+	case sig := <-sigCh:
+		fmt.Fprintf(os.Stderr, "Received signal %v. Shutting down...\n", sig)
+	case <-s.Done():
+		fmt.Fprintln(os.Stderr, "Client disconnected. Shutting down server...")
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "Context canceled. Shutting down server...")
+	}
+
+	lg.Logf("Exiting...")
+}
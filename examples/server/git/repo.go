@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/dwrtz/mcp-go/pkg/mimetype"
+	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
+)
+
+// maxListedFiles caps the number of files advertised in resources/list
+const maxListedFiles = 500
+
+// RepoProvider exposes a git repository as resources:
+//
+//	git://{name}/branches             list of branches
+//	git://{name}/tree/{ref}/{path}    file contents at a revision
+//	git://{name}/diff/{from}..{to}    unified diff between two revisions
+type RepoProvider struct {
+	name string
+	repo *git.Repository
+}
+
+// NewRepoProvider opens the repository at dir, served under the host name,
+// see hostName
+func NewRepoProvider(name, dir string) (*RepoProvider, error) {
+	host, err := hostName(name)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository %s: %w", dir, err)
+	}
+	return &RepoProvider{name: host, repo: repo}, nil
+}
+
+// hostName returns name as the host of git URIs in the form the server
+// normalizes them to, lowercased and with characters not allowed in a host
+// replaced by "-", so that requests match the provider's prefix
+func hostName(name string) (string, error) {
+	host := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.', r == '_', r == '~':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	prefix := "git://" + host + "/"
+	if normalized, err := urischeme.Normalize(prefix); host == "" || err != nil || normalized != prefix {
+		return "", fmt.Errorf("repository name %q cannot be used as a URI host", name)
+	}
+	return host, nil
+}
+
+// Prefix returns the URI prefix served by the provider
+func (p *RepoProvider) Prefix() string {
+	return "git://" + p.name + "/"
+}
+
+// List implements types.ResourceProvider, listing the branches resource and
+// the files at HEAD
+func (p *RepoProvider) List(ctx context.Context) ([]types.Resource, error) {
+	resources := []types.Resource{
+		{
+			URI:         p.Prefix() + "branches",
+			Name:        "branches",
+			Description: "Branches of the repository",
+			MimeType:    "text/plain",
+		},
+	}
+
+	commit, err := p.commit("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if len(resources) > maxListedFiles {
+			return errStop
+		}
+		resources = append(resources, types.Resource{
+			URI:  p.Prefix() + "tree/HEAD/" + f.Name,
+			Name: f.Name,
+		})
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// Templates implements types.ResourceProvider
+func (p *RepoProvider) Templates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	return []types.ResourceTemplate{
+		{
+			URITemplate: p.Prefix() + "tree/{ref}/{+path}",
+			Name:        "File at revision",
+			Description: "Contents of a file at a branch, tag, or commit",
+		},
+		{
+			URITemplate: p.Prefix() + "diff/{from}..{to}",
+			Name:        "Diff between revisions",
+			MimeType:    "text/x-diff",
+		},
+	}, nil
+}
+
+// Read implements types.ResourceProvider
+func (p *RepoProvider) Read(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+	rest := strings.TrimPrefix(uri, p.Prefix())
+	if rest == uri {
+		return nil, types.NewError(types.InvalidParams, "resource not found: "+uri)
+	}
+
+	var (
+		text     string
+		mimeType = "text/plain"
+		err      error
+	)
+	switch {
+	case rest == "branches":
+		text, err = p.branches()
+	case strings.HasPrefix(rest, "tree/"):
+		ref, file, ok := strings.Cut(strings.TrimPrefix(rest, "tree/"), "/")
+		if !ok {
+			return nil, types.NewError(types.InvalidParams, "expected tree/{ref}/{path}: "+uri)
+		}
+		return p.readFile(uri, ref, file)
+	case strings.HasPrefix(rest, "diff/"):
+		from, to, ok := strings.Cut(strings.TrimPrefix(rest, "diff/"), "..")
+		if !ok {
+			return nil, types.NewError(types.InvalidParams, "expected diff/{from}..{to}: "+uri)
+		}
+		mimeType = "text/x-diff"
+		text, err = p.diff(from, to, "")
+	default:
+		return nil, types.NewError(types.InvalidParams, "resource not found: "+uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []types.ResourceContent{
+		types.TextResourceContents{
+			ResourceContents: types.ResourceContents{URI: uri, MimeType: mimeType},
+			Text:             text,
+		},
+	}, nil
+}
+
+// Subscribe implements types.ResourceProvider. Repository changes are not
+// watched, so the returned cancel function is a no-op.
+func (p *RepoProvider) Subscribe(ctx context.Context, uri string, notify types.ResourceUpdateFunc) (func(), error) {
+	return func() {}, nil
+}
+
+// errStop ends a ForEach iteration early
+var errStop = fmt.Errorf("stop iteration")
+
+func (p *RepoProvider) commit(rev string) (*object.Commit, error) {
+	hash, err := p.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, types.NewError(types.InvalidParams, fmt.Sprintf("unknown revision %q: %v", rev, err))
+	}
+	return p.repo.CommitObject(*hash)
+}
+
+func (p *RepoProvider) branches() (string, error) {
+	iter, err := p.repo.Branches()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		fmt.Fprintf(&sb, "%s %s\n", ref.Hash().String()[:7], ref.Name().Short())
+		return nil
+	})
+	return sb.String(), err
+}
+
+func (p *RepoProvider) readFile(uri, ref, file string) ([]types.ResourceContent, error) {
+	commit, err := p.commit(ref)
+	if err != nil {
+		return nil, err
+	}
+	f, err := commit.File(path.Clean(file))
+	if err != nil {
+		return nil, types.NewError(types.InvalidParams, fmt.Sprintf("file %s not found at %s", file, ref))
+	}
+
+	binary, err := f.IsBinary()
+	if err != nil {
+		return nil, err
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := mimetype.Detect(file, []byte(contents))
+	if binary {
+		return []types.ResourceContent{types.NewBlobContents(uri, mimeType, []byte(contents))}, nil
+	}
+	return []types.ResourceContent{
+		types.TextResourceContents{
+			ResourceContents: types.ResourceContents{URI: uri, MimeType: mimeType},
+			Text:             contents,
+		},
+	}, nil
+}
+
+// diff returns the unified diff between two revisions, optionally limited to
+// a single path
+func (p *RepoProvider) diff(from, to, file string) (string, error) {
+	fromCommit, err := p.commit(from)
+	if err != nil {
+		return "", err
+	}
+	toCommit, err := p.commit(to)
+	if err != nil {
+		return "", err
+	}
+	fromTree, err := fromCommit.Tree()
+	if err != nil {
+		return "", err
+	}
+	toTree, err := toCommit.Tree()
+	if err != nil {
+		return "", err
+	}
+
+	changes, err := fromTree.Diff(toTree)
+	if err != nil {
+		return "", err
+	}
+	if file != "" {
+		var filtered object.Changes
+		for _, c := range changes {
+			if c.From.Name == file || c.To.Name == file {
+				filtered = append(filtered, c)
+			}
+		}
+		changes = filtered
+	}
+
+	patch, err := changes.Patch()
+	if err != nil {
+		return "", err
+	}
+	return patch.String(), nil
+}
+
+// LogInput is the input of the git_log tool
+type LogInput struct {
+	Ref   string `json:"ref,omitempty" jsonschema:"description=Revision to start from (default HEAD)"`
+	Path  string `json:"path,omitempty" jsonschema:"description=Only show commits touching this path"`
+	Limit int    `json:"limit,omitempty" jsonschema:"description=Maximum number of commits (default 20)"`
+}
+
+// BlameInput is the input of the git_blame tool
+type BlameInput struct {
+	Path string `json:"path" jsonschema:"description=File to blame,required"`
+	Ref  string `json:"ref,omitempty" jsonschema:"description=Revision to blame at (default HEAD)"`
+}
+
+// DiffInput is the input of the git_diff tool
+type DiffInput struct {
+	From string `json:"from" jsonschema:"description=Base revision,required"`
+	To   string `json:"to,omitempty" jsonschema:"description=Target revision (default HEAD)"`
+	Path string `json:"path,omitempty" jsonschema:"description=Only diff this path"`
+}
+
+// Tools returns the git_log, git_blame, and git_diff tools for the repository
+func (p *RepoProvider) Tools() []types.McpTool {
+	return []types.McpTool{
+		types.NewTool("git_log", "Show the commit log of the repository",
+			func(ctx context.Context, input LogInput) (*types.CallToolResult, error) {
+				return textResult(p.log(input))
+			}),
+		types.NewTool("git_blame", "Show which commit last changed each line of a file",
+			func(ctx context.Context, input BlameInput) (*types.CallToolResult, error) {
+				return textResult(p.blame(input))
+			}),
+		types.NewTool("git_diff", "Show the unified diff between two revisions",
+			func(ctx context.Context, input DiffInput) (*types.CallToolResult, error) {
+				to := input.To
+				if to == "" {
+					to = "HEAD"
+				}
+				return textResult(p.diff(input.From, to, input.Path))
+			}),
+	}
+}
+
+func (p *RepoProvider) log(input LogInput) (string, error) {
+	ref := input.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	hash, err := p.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q: %w", ref, err)
+	}
+	opts := &git.LogOptions{From: *hash}
+	if input.Path != "" {
+		opts.FileName = &input.Path
+	}
+
+	iter, err := p.repo.Log(opts)
+	if err != nil {
+		return "", err
+	}
+	defer iter.Close()
+
+	var sb strings.Builder
+	count := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if count >= limit {
+			return errStop
+		}
+		count++
+		summary, _, _ := strings.Cut(c.Message, "\n")
+		fmt.Fprintf(&sb, "%s %s %s %s\n", c.Hash.String()[:7], c.Author.When.Format("2006-01-02"), c.Author.Name, summary)
+		return nil
+	})
+	if err != nil && err != errStop {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func (p *RepoProvider) blame(input BlameInput) (string, error) {
+	ref := input.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commit, err := p.commit(ref)
+	if err != nil {
+		return "", err
+	}
+	result, err := git.Blame(commit, input.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, line := range result.Lines {
+		fmt.Fprintf(&sb, "%s %-20s %4d| %s\n", line.Hash.String()[:7], line.AuthorName, i+1, line.Text)
+	}
+	return sb.String(), nil
+}
+
+// textResult converts tool output into a CallToolResult, reporting failures as
+// tool errors so the model can see them
+func textResult(text string, err error) (*types.CallToolResult, error) {
+	if err != nil {
+		return &types.CallToolResult{
+			Content: []interface{}{types.TextContent{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}, nil
+	}
+	return &types.CallToolResult{
+		Content: []interface{}{types.TextContent{Type: "text", Text: text}},
+	}, nil
+}
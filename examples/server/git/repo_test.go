@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
+)
+
+// newRepo creates a repository with one commit of README.md
+func newRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestHostName(t *testing.T) {
+	for name, want := range map[string]string{
+		"mcp-go":      "mcp-go",
+		"MyRepo":      "myrepo",
+		"my repo":     "my-repo",
+		"a/b@c:d":     "a-b-c-d",
+		"repo.v2_old": "repo.v2_old",
+		"café":        "caf-",
+	} {
+		got, err := hostName(name)
+		if err != nil || got != want {
+			t.Errorf("hostName(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := hostName(""); err == nil {
+		t.Error("hostName of an empty name succeeded")
+	}
+}
+
+func TestRepoProvider(t *testing.T) {
+	p, err := NewRepoProvider("My Repo", newRepo(t))
+	if err != nil {
+		t.Fatalf("NewRepoProvider error: %v", err)
+	}
+	if p.Prefix() != "git://my-repo/" {
+		t.Errorf("Prefix() = %q", p.Prefix())
+	}
+
+	ctx := context.Background()
+	resources, err := p.List(ctx)
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if len(resources) != 2 || resources[1].URI != "git://my-repo/tree/HEAD/README.md" {
+		t.Fatalf("List = %+v", resources)
+	}
+
+	// URIs reach the provider normalized, as the server does; the listed
+	// ones are unchanged by it and read
+	for _, r := range resources {
+		normalized, err := urischeme.Normalize(r.URI)
+		if err != nil || normalized != r.URI {
+			t.Errorf("Normalize(%q) = %q, %v", r.URI, normalized, err)
+		}
+	}
+	uri, err := urischeme.Normalize("GIT://My-Repo/tree/HEAD/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := p.Read(ctx, uri)
+	if err != nil {
+		t.Fatalf("Read(%q) error: %v", uri, err)
+	}
+	if text, ok := contents[0].(types.TextResourceContents); !ok || text.Text != "hello\n" {
+		t.Errorf("Read(%q) = %+v", uri, contents)
+	}
+}
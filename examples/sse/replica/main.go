@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/subscriptions"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func main() {
+	// Run several replicas on different addresses with the same store; a
+	// client subscribed through one keeps its subscriptions on the others
+	listenAddr := flag.String("addr", ":8080", "Address to listen for SSE connections (e.g. :8080)")
+	store := flag.String("store", "memory", "Subscription store: memory, file, or redis")
+	file := flag.String("file", "subscriptions.json", "Subscriptions file for -store file")
+	redisAddr := flag.String("redis", "localhost:6379", "Redis address for -store redis")
+	flag.Parse()
+
+	lg := logger.NewStderrLogger("REPLICA")
+
+	var subs types.SubscriptionStore
+	switch *store {
+	case "memory":
+		subs = subscriptions.NewMemoryStore()
+	case "file":
+		subs = subscriptions.NewFileStore(*file)
+	case "redis":
+		rs, err := dialRedis(*redisAddr, "mcp:")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Redis error: %v\n", err)
+			os.Exit(1)
+		}
+		defer rs.Close()
+		subs = rs
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown store %q\n", *store)
+		os.Exit(1)
+	}
+
+	s := server.NewSseServer(
+		*listenAddr,
+		server.WithLogger(lg),
+		server.WithResources([]types.Resource{
+			{URI: "file:///clock.txt", Name: "Clock", MimeType: "text/plain"},
+		}, nil),
+		server.WithSubscriptionStore(subs),
+	)
+	s.RegisterContentHandler("file:///clock.txt", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+		return []types.ResourceContent{types.TextResourceContents{
+			ResourceContents: types.ResourceContents{URI: uri, MimeType: "text/plain"},
+			Text:             time.Now().Format(time.RFC3339),
+		}}, nil
+	})
+
+	// The clock changes every second; only subscribed clients are notified
+	s.Watch(server.Every(time.Second, func(ctx context.Context, changes *server.Changes) {
+		changes.ResourceUpdated("file:///clock.txt")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := s.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Server start error: %v\n", err)
+		os.Exit(1)
+	}
+
+	lg.Logf("Replica listening on %s with %s subscription store...", *listenAddr, *store)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigCh
+	fmt.Printf("Received signal %v. Shutting down...\n", sig)
+	s.Close()
+}
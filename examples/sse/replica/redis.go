@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// redisStore is a types.SubscriptionStore kept in Redis, shared by every
+// replica connected to the same Redis server. The subscribed URIs are kept
+// in a set, and the subscribers of each URI in a set of their own.
+type redisStore struct {
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialRedis connects to the Redis server at addr, keeping keys under prefix
+func dialRedis(addr, prefix string) (*redisStore, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &redisStore{prefix: prefix, conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Close closes the connection to Redis
+func (s *redisStore) Close() error {
+	return s.conn.Close()
+}
+
+func (s *redisStore) urisKey() string                  { return s.prefix + "uris" }
+func (s *redisStore) subscribersKey(uri string) string { return s.prefix + "subscribers:" + uri }
+
+func (s *redisStore) Add(ctx context.Context, uri, subscriber string) error {
+	if _, err := s.do(ctx, "SADD", s.subscribersKey(uri), subscriber); err != nil {
+		return err
+	}
+	_, err := s.do(ctx, "SADD", s.urisKey(), uri)
+	return err
+}
+
+// Remove removes subscriber from the subscribers of uri, and uri from the
+// subscribed URIs once it has none. A replica subscribing to uri between the
+// two steps may find it missing from URIs until it subscribes again.
+func (s *redisStore) Remove(ctx context.Context, uri, subscriber string) error {
+	if _, err := s.do(ctx, "SREM", s.subscribersKey(uri), subscriber); err != nil {
+		return err
+	}
+	reply, err := s.do(ctx, "SCARD", s.subscribersKey(uri))
+	if err != nil {
+		return err
+	}
+	if n, ok := reply.(int64); !ok || n > 0 {
+		return nil
+	}
+	_, err = s.do(ctx, "SREM", s.urisKey(), uri)
+	return err
+}
+
+func (s *redisStore) Subscribers(ctx context.Context, uri string) ([]string, error) {
+	reply, err := s.do(ctx, "SMEMBERS", s.subscribersKey(uri))
+	if err != nil {
+		return nil, err
+	}
+	subscribers, err := toStrings(reply)
+	sort.Strings(subscribers)
+	return subscribers, err
+}
+
+func (s *redisStore) URIs(ctx context.Context) ([]string, error) {
+	reply, err := s.do(ctx, "SMEMBERS", s.urisKey())
+	if err != nil {
+		return nil, err
+	}
+	uris, err := toStrings(reply)
+	sort.Strings(uris)
+	return uris, err
+}
+
+// do sends a command and reads its reply
+func (s *redisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetDeadline(deadline)
+		defer s.conn.SetDeadline(time.Time{})
+	}
+
+	cmd := "*" + strconv.Itoa(len(args)) + "\r\n"
+	for _, arg := range args {
+		cmd += "$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n"
+	}
+	if _, err := s.conn.Write([]byte(cmd)); err != nil {
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+	reply, err := s.read()
+	if err != nil {
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+	return reply, nil
+}
+
+// read reads a RESP reply: a string, an integer, nil, or a slice of replies
+func (s *redisStore) read() (interface{}, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, errors.New("malformed reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, errors.New(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(s.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = s.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply type %q", kind)
+}
+
+func toStrings(reply interface{}) ([]string, error) {
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply %v", reply)
+	}
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected reply item %v", item)
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// EchoInput defines the input type for the echo tool
+type EchoInput struct {
+	Value string `json:"value" jsonschema:"description=Input to echo,required"`
+}
+
+func main() {
+	// Command-line flag for specifying the TCP listen address. Containers
+	// must listen on all interfaces to be reachable from other containers.
+	listenAddr := flag.String("addr", ":9000", "Address to listen for TCP connections (e.g. :9000)")
+	flag.Parse()
+
+	lg := logger.NewStderrLogger("TCP-SERVER")
+
+	// Create an echo tool using the typed NewTool constructor
+	echoTool := types.NewTool(
+		"echo_tool",
+		"Echoes back the input in 'value' argument",
+		func(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+			return &types.CallToolResult{
+				Content: []interface{}{
+					types.TextContent{
+						Type: "text",
+						Text: "[TCP-SERVER] Echo: " + input.Value,
+					},
+				},
+				IsError: false,
+			}, nil
+		},
+	)
+
+	// Create server with tools and TCP transport
+	s := server.NewTCPServer(
+		*listenAddr,
+		server.WithLogger(lg),
+		server.WithTools(echoTool),
+	)
+
+	// Stop on SIGTERM too, which container runtimes send to stop a container
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := s.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Server start error: %v\n", err)
+		os.Exit(1)
+	}
+
+	lg.Logf("TCP server listening on %s...", s.BoundAddr())
+
+	// Unlike stdio servers, the server outlives each client and runs until
+	// it is stopped
+	<-ctx.Done()
+	lg.Logf("Shutting down...")
+	s.Close()
+}
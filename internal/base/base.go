@@ -1,15 +1,26 @@
 package base
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
-	"github.com/dwrtz/mcp-go/internal/transport"
+	"github.com/dwrtz/mcp-go/internal/goroutine"
 	"github.com/dwrtz/mcp-go/internal/transport/sse"
+	"github.com/dwrtz/mcp-go/internal/transport/tcp"
+	"github.com/dwrtz/mcp-go/pkg/auth"
 	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
@@ -27,22 +38,127 @@ type Base struct {
 	// Message handling
 	requestHandlers      map[string]RequestHandler
 	notificationHandlers map[string]NotificationHandler
-	handlerMu            sync.RWMutex // Protects notificationHandlers
-
-	// Lifecycle management
-	startOnce sync.Once
+	handlerMu            sync.RWMutex // Protects the handler maps
+
+	// Runs notification callbacks; inline by default
+	execute func(func())
+
+	// Requests awaiting a response, keyed by ID
+	pendingMu sync.Mutex
+	pending   map[uint64]*PendingRequest
+
+	// Requests whose callers gave up waiting; their late responses are
+	// dropped. The last maxAbandoned are remembered, in abandonedRing in the
+	// order abandoned, the oldest at abandonedNext once it is full.
+	abandonedMu    sync.Mutex
+	abandoned      map[uint64]struct{}
+	abandonedRing  []uint64
+	abandonedNext  int
+	onLateResponse func(*types.Message)
+
+	// Sees every notification received, see SetNotificationObserver
+	observeNotification func(*types.Message)
+
+	// Received notifications not yet handled, in arrival order
+	notifyMu    sync.Mutex
+	notifyQueue []*types.Message
+	notifyReady chan struct{}
+
+	// Receive the errors reported on the router, see OnError
+	errorSubs *Subscribers[error]
+
+	// Consulted before each request is handled, see SetRequestGate
+	gate RequestGate
+
+	// Notifications held for a batch, see SetNotificationBatching
+	batchMu     sync.Mutex
+	batchWindow time.Duration
+	batch       []json.RawMessage
+	batchTimer  *time.Timer
+	flushMu     sync.Mutex
+
+	// Lifecycle, see Start and CloseWithError. running is set once the run
+	// group started; closeReq is closed, with closeArg set, when a close is
+	// requested.
+	runMu     sync.Mutex
+	running   bool
 	closeOnce sync.Once
-	Started   bool
+	closeReq  chan struct{}
+	closeArg  error
+	// closed records why a transport that is not a CloseReporter closed
+	closed  transport.CloseState
+	Started bool
+
+	// Shutdown, see CloseWithError. closing refuses requests once set; done
+	// closes once shutdown completes, with runErr and closeErr set.
+	closing       atomic.Bool
+	shutdownOnce  sync.Once
+	shutdownMu    sync.Mutex
+	shutdownHooks []func()
+	hooksRun      bool
+	runErr        error
+	closeErr      error
+	done          chan struct{}
 }
 
+// errCloseRequested ends a run on a call to CloseWithError
+var errCloseRequested = errors.New("close requested")
+
 // NewBase creates a new base instance
 func NewBase(t transport.Transport) *Base {
-	return &Base{
+	b := &Base{
 		transport:            t,
 		requestHandlers:      make(map[string]RequestHandler),
 		notificationHandlers: make(map[string]NotificationHandler),
+		pending:              make(map[uint64]*PendingRequest),
+		abandoned:            make(map[uint64]struct{}),
+		execute:              func(f func()) { f() },
+		notifyReady:          make(chan struct{}, 1),
 		Started:              false,
+		closeReq:             make(chan struct{}),
+		done:                 make(chan struct{}),
+	}
+	b.errorSubs = NewSubscribers[error](b)
+	// Responses and notifications are dispatched in the order they arrive
+	t.GetRouter().EnableOrdering()
+
+	// Both sides must answer pings; the result is empty
+	HandleRequest(b, methods.Ping, func(ctx context.Context, _ struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	return b
+}
+
+// Ping sends a ping request and waits for the peer's response
+func (b *Base) Ping(ctx context.Context) error {
+	resp, err := b.SendRequest(ctx, methods.Ping, nil)
+	if err != nil {
+		return err
 	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	return nil
+}
+
+// SetCallbackExecutor sets the function used to run notification callbacks.
+// It must be called before Start.
+func (b *Base) SetCallbackExecutor(execute func(func())) {
+	b.execute = execute
+}
+
+// SetLateResponseHandler sets a function called with responses that arrive
+// after their request's context was done. Such responses are dropped either way.
+// It must be called before Start.
+func (b *Base) SetLateResponseHandler(handler func(*types.Message)) {
+	b.onLateResponse = handler
+}
+
+// SetNotificationObserver sets a function called with every notification
+// received, handled or not, before its handler. Notifications are observed
+// one at a time, in order. It must be called before Start.
+func (b *Base) SetNotificationObserver(observe func(*types.Message)) {
+	b.observeNotification = observe
 }
 
 // RegisterRequestHandler registers a handler for a request method
@@ -59,38 +175,192 @@ func (b *Base) RegisterNotificationHandler(method string, handler NotificationHa
 	b.notificationHandlers[method] = handler
 }
 
-// Start begins processing messages
+// Start starts the transport and begins processing messages in the
+// background until shutdown, see Wait. Starting a started base does nothing;
+// one that was closed or has shut down cannot be started again and returns
+// transport.ErrClosed. A ctx already done starts nothing and is returned.
 func (b *Base) Start(ctx context.Context) error {
-	var startErr error
-	b.startOnce.Do(func() {
-		// Start message handling
-		go b.handleMessages(ctx)
-
-		// Start transport
-		if err := b.transport.Start(ctx); err != nil {
-			startErr = err
-			return
-		}
+	b.runMu.Lock()
+	defer b.runMu.Unlock()
+	select {
+	case <-b.closeReq:
+		return transport.ErrClosed
+	case <-b.done:
+		return transport.ErrClosed
+	default:
+	}
+	if b.running {
+		return nil
+	}
+	// Nothing is started for a context already done
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// The base runs as one group: whichever of a close request, the
+	// transport closing or ctx being done comes first ends the run and
+	// says why, and the handlers' context is canceled with it
+	g := newGroup(ctx)
+	g.Go("base/messages", func(ctx context.Context) error {
+		b.handleMessages(ctx)
+		return nil
+	})
+	// Not waited for, as a notification handler may itself close the base
+	goroutine.Go("base/notifications", func() { b.runNotifications(g.ctx) })
 
-		b.Started = true
+	if err := b.transport.Start(ctx); err != nil {
+		g.stop(err)
+		g.Wait()
+		return err
+	}
 
+	g.Go("base/run", func(runCtx context.Context) error {
+		select {
+		case <-b.closeReq:
+			return errCloseRequested
+		case <-b.transport.Done():
+			if err := b.Err(); err != nil {
+				return err
+			}
+			return transport.ErrPeerClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-runCtx.Done():
+			return nil
+		}
 	})
-	return startErr
+	goroutine.Go("base/shutdown", func() {
+		b.shutdown(g.Wait())
+	})
+
+	b.running = true
+	b.Started = true
+	return nil
+}
+
+// Wait waits for shutdown to complete and returns why the base stopped:
+// the error given to CloseWithError, nil for Close, ctx.Err() if the context
+// given to Start was done, or the transport's Err if it closed first, such
+// as transport.ErrPeerClosed.
+func (b *Base) Wait() error {
+	<-b.done
+	return b.runErr
+}
+
+// Run starts the base and waits for it to stop, see Start and Wait
+func (b *Base) Run(ctx context.Context) error {
+	if err := b.Start(ctx); err != nil {
+		return err
+	}
+	return b.Wait()
+}
+
+// OnShutdown registers a function run at shutdown, after the handlers'
+// context is canceled, so feature modules stop their own work. A hook
+// registered once the hooks have run is run at once.
+func (b *Base) OnShutdown(hook func()) {
+	b.shutdownMu.Lock()
+	if !b.hooksRun {
+		b.shutdownHooks = append(b.shutdownHooks, hook)
+		b.shutdownMu.Unlock()
+		return
+	}
+	b.shutdownMu.Unlock()
+	hook()
 }
 
 // Close shuts down the client
 func (b *Base) Close() error {
-	var closeErr error
+	return b.CloseWithError(nil)
+}
+
+// CloseWithError shuts down, recording err as the reason the transport
+// closed. A nil err is a clean close. Shutdown runs once, whether started
+// here, by the peer closing the transport or by the context given to Start,
+// in this order:
+//
+//  1. the handlers' context is canceled and requests arriving are refused
+//  2. the OnShutdown hooks run and the requests awaiting responses are
+//     canceled
+//  3. notifications held for a batch are sent
+//  4. the transport is closed
+//  5. Done is closed
+//
+// Calls return once shutdown completes, with the error closing the
+// transport.
+func (b *Base) CloseWithError(err error) error {
 	b.closeOnce.Do(func() {
-		closeErr = b.transport.Close()
+		b.closeArg = err
+		close(b.closeReq)
+	})
+	b.runMu.Lock()
+	running := b.running
+	b.runMu.Unlock()
+	if !running {
+		// Nothing runs to end, so shut down here
+		b.shutdown(errCloseRequested)
+	}
+	<-b.done
+	return b.closeErr
+}
+
+// shutdown runs the steps of CloseWithError after a run ended with err
+func (b *Base) shutdown(err error) {
+	b.shutdownOnce.Do(func() {
+		if err == errCloseRequested {
+			err = b.closeArg
+		}
+		b.closing.Store(true)
+
+		b.shutdownMu.Lock()
+		hooks := b.shutdownHooks
+		b.shutdownHooks, b.hooksRun = nil, true
+		b.shutdownMu.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+		b.CancelAll()
+
+		b.flushBeforeSend(context.Background())
+
+		if cr, ok := b.transport.(transport.CloseReporter); ok {
+			b.closeErr = cr.CloseWithError(err)
+		} else {
+			// A transport already closed was closed by the peer
+			select {
+			case <-b.transport.Done():
+			default:
+				b.closed.Set(transport.ClosedLocally, err)
+			}
+			b.closeErr = b.transport.Close()
+		}
+		b.runErr = err
 		b.Started = false
+		close(b.done)
 	})
-	return closeErr
 }
 
-// Done returns a channel that is closed when the transport is closed
+// Done returns a channel that is closed once shutdown completes, after the
+// transport closed, see CloseWithError
 func (b *Base) Done() <-chan struct{} {
-	return b.transport.Done()
+	return b.done
+}
+
+// DoneReason says which side closed the transport, or NotClosed
+func (b *Base) DoneReason() transport.CloseReason {
+	if cr, ok := b.transport.(transport.CloseReporter); ok {
+		return cr.DoneReason()
+	}
+	return b.closed.Reason(b.transport.Done())
+}
+
+// Err returns why the transport closed, or nil while it is open. See
+// transport.CloseReporter.
+func (b *Base) Err() error {
+	if cr, ok := b.transport.(transport.CloseReporter); ok {
+		return cr.Err()
+	}
+	return b.closed.Err(b.transport.Done())
 }
 
 // GetRouter returns the message router
@@ -103,16 +373,69 @@ func (b *Base) Logf(format string, args ...interface{}) {
 	b.transport.Logf(format, args...)
 }
 
+// Log logs a formatted message at level through the transport. ctx is that
+// of the request or notification being handled, if any, so a leveled logger
+// can tell which session the message concerns.
+func (b *Base) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
+	logger.Log(ctx, b.transport, level, format, args...)
+}
+
 // SetLogger sets the logger for the base
 func (b *Base) SetLogger(l logger.Logger) {
 	b.transport.SetLogger(l)
 }
 
+// PendingRequest describes an outgoing request awaiting its response
+type PendingRequest struct {
+	ID      uint64
+	Method  string
+	Started time.Time
+
+	cancel context.CancelFunc
+	resp   chan *types.Message
+}
+
+// PendingRequests returns the outgoing requests awaiting a response, oldest first
+func (b *Base) PendingRequests() []PendingRequest {
+	b.pendingMu.Lock()
+	reqs := make([]PendingRequest, 0, len(b.pending))
+	for _, req := range b.pending {
+		reqs = append(reqs, PendingRequest{ID: req.ID, Method: req.Method, Started: req.Started})
+	}
+	b.pendingMu.Unlock()
+
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].ID < reqs[j].ID })
+	return reqs
+}
+
+// CancelAll cancels every outgoing request awaiting a response. Their
+// SendRequest calls return context.Canceled.
+func (b *Base) CancelAll() {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	for _, req := range b.pending {
+		req.cancel()
+	}
+}
+
 // SendRequest sends a request and waits for the response
 func (b *Base) SendRequest(ctx context.Context, method string, params interface{}) (*types.Message, error) {
 	// Generate request ID
 	id := atomic.AddUint64(&b.nextID, 1)
 
+	// Track the request until it completes
+	ctx, cancel := context.WithCancel(ctx)
+	req := &PendingRequest{ID: id, Method: method, Started: time.Now(), cancel: cancel, resp: make(chan *types.Message, 1)}
+	b.pendingMu.Lock()
+	b.pending[id] = req
+	b.pendingMu.Unlock()
+	defer func() {
+		b.pendingMu.Lock()
+		delete(b.pending, id)
+		b.pendingMu.Unlock()
+		cancel()
+	}()
+
 	// Create request message
 	msg := &types.Message{
 		JSONRPC: types.JSONRPCVersion,
@@ -120,39 +443,161 @@ func (b *Base) SendRequest(ctx context.Context, method string, params interface{
 		Method:  method,
 	}
 
-	if params != nil {
+	if params != nil || len(mcp.Meta(ctx)) > 0 {
 		data, err := json.Marshal(params)
 		if err != nil {
 			return nil, err
 		}
+		if data, err = addMeta(data, mcp.Meta(ctx)); err != nil {
+			return nil, err
+		}
 		raw := json.RawMessage(data)
 		msg.Params = &raw
 	}
 
 	// Send the request
+	b.flushBeforeSend(ctx)
 	if err := b.transport.Send(ctx, msg); err != nil {
 		return nil, err
 	}
 
-	// Wait for response
-	router := b.transport.GetRouter()
-	for {
-		select {
-		case resp := <-router.Responses:
-			if resp.ID != nil && resp.ID.Num == id {
-				return resp, nil
-			}
-			// Not our response, put it back
-			select {
-			case router.Responses <- resp:
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-router.Done():
-			return nil, types.NewError(types.InternalError, "client closed")
+	// Wait for handleMessages to deliver the response
+	select {
+	case resp := <-req.resp:
+		return resp, nil
+	case <-ctx.Done():
+		b.abandon(req)
+		return nil, ctx.Err()
+	case <-b.transport.GetRouter().Done():
+		return nil, types.NewError(types.InternalError, "client closed")
+	}
+}
+
+// addMeta merges meta into the _meta object of the encoded params. Fields
+// already present in the params win.
+func addMeta(params []byte, meta map[string]interface{}) ([]byte, error) {
+	if len(meta) == 0 {
+		return params, nil
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if string(params) != "null" {
+		if err := json.Unmarshal(params, &fields); err != nil {
+			return nil, fmt.Errorf("cannot add _meta to non-object params: %w", err)
+		}
+	}
+
+	merged := make(map[string]json.RawMessage, len(meta))
+	for k, v := range meta {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid _meta value for %q: %w", k, err)
+		}
+		merged[k] = data
+	}
+	if existing, ok := fields["_meta"]; ok && string(existing) != "null" {
+		var own map[string]json.RawMessage
+		if err := json.Unmarshal(existing, &own); err != nil {
+			return nil, fmt.Errorf("invalid _meta in params: %w", err)
+		}
+		for k, v := range own {
+			merged[k] = v
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	fields["_meta"] = data
+	return json.Marshal(fields)
+}
+
+// peerMeta extracts the _meta object from request params, if any
+func peerMeta(params *json.RawMessage) map[string]interface{} {
+	if params == nil {
+		return nil
+	}
+	var envelope struct {
+		Meta map[string]interface{} `json:"_meta"`
+	}
+	if err := json.Unmarshal(*params, &envelope); err != nil {
+		return nil
+	}
+	return envelope.Meta
+}
+
+// maxAbandoned is how many abandoned requests are remembered. Responses to
+// older ones, which may never come, are dropped as unknown.
+const maxAbandoned = 1024
+
+// abandon records that nobody is waiting for the response to req. If the
+// response was delivered meanwhile, it is dropped as late.
+func (b *Base) abandon(req *PendingRequest) {
+	b.pendingMu.Lock()
+	_, waiting := b.pending[req.ID]
+	if waiting {
+		delete(b.pending, req.ID)
+		b.abandonedMu.Lock()
+		b.abandoned[req.ID] = struct{}{}
+		if len(b.abandonedRing) < maxAbandoned {
+			b.abandonedRing = append(b.abandonedRing, req.ID)
+		} else {
+			delete(b.abandoned, b.abandonedRing[b.abandonedNext])
+			b.abandonedRing[b.abandonedNext] = req.ID
+			b.abandonedNext = (b.abandonedNext + 1) % maxAbandoned
 		}
+		b.abandonedMu.Unlock()
+	}
+	b.pendingMu.Unlock()
+
+	if !waiting {
+		b.dropLate(<-req.resp)
+	}
+}
+
+// deliverResponse hands resp to the request waiting for it. Responses to
+// abandoned requests go to the late response handler; others are dropped.
+func (b *Base) deliverResponse(resp *types.Message) {
+	if resp.ID == nil {
+		b.Log(context.Background(), logger.LevelWarn, "Dropping response without ID")
+		return
+	}
+	b.pendingMu.Lock()
+	req, ok := b.pending[resp.ID.Num]
+	delete(b.pending, resp.ID.Num)
+	b.pendingMu.Unlock()
+
+	switch {
+	case ok:
+		// Buffered and removed from pending, so this never blocks
+		req.resp <- resp
+	case b.dropAbandoned(resp):
+	default:
+		b.Log(context.Background(), logger.LevelWarn, "Dropping response to unknown request %v", resp.ID)
+	}
+}
+
+// dropAbandoned reports whether resp answers an abandoned request, in which
+// case it is passed to the late response handler
+func (b *Base) dropAbandoned(resp *types.Message) bool {
+	b.abandonedMu.Lock()
+	_, ok := b.abandoned[resp.ID.Num]
+	delete(b.abandoned, resp.ID.Num)
+	b.abandonedMu.Unlock()
+	if !ok {
+		return false
+	}
+	b.dropLate(resp)
+	return true
+}
+
+// dropLate drops a response whose request was abandoned, passing it to the
+// late response handler
+func (b *Base) dropLate(resp *types.Message) {
+	b.Log(context.Background(), logger.LevelDebug, "Dropping late response to abandoned request %d", resp.ID.Num)
+	if b.onLateResponse != nil {
+		b.onLateResponse(resp)
 	}
 }
 
@@ -164,7 +609,8 @@ func (b *Base) SendResponse(ctx context.Context, reqID types.ID, result interfac
 	}
 
 	if err != nil {
-		if mcpErr, ok := err.(*types.ErrorResponse); ok {
+		var mcpErr *types.ErrorResponse
+		if errors.As(err, &mcpErr) {
 			msg.Error = mcpErr
 		} else {
 			msg.Error = types.NewError(types.InternalError, err.Error())
@@ -178,29 +624,61 @@ func (b *Base) SendResponse(ctx context.Context, reqID types.ID, result interfac
 		msg.Result = &raw
 	}
 
+	b.flushBeforeSend(ctx)
 	return b.transport.Send(ctx, msg)
 }
 
 // SendNotification sends a notification (no response expected)
 func (b *Base) SendNotification(ctx context.Context, method string, params interface{}) error {
-	msg := &types.Message{
-		JSONRPC: types.JSONRPCVersion,
-		Method:  method,
-	}
-
+	var raw json.RawMessage
 	if params != nil {
-		data, err := json.Marshal(params)
-		if err != nil {
+		buf := paramsPool.Get().(*bytes.Buffer)
+		defer func() {
+			if buf.Cap() <= maxPooledParams {
+				buf.Reset()
+				paramsPool.Put(buf)
+			}
+		}()
+		if err := json.NewEncoder(buf).Encode(params); err != nil {
 			return err
 		}
-		raw := json.RawMessage(data)
-		msg.Params = &raw
+		raw = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+
+	if held, err := b.holdNotification(ctx, method, raw); held {
+		return err
+	}
+
+	// Transports that frame the encoded params themselves are done with them
+	// on return, so the pooled buffer is not copied
+	if w, ok := b.transport.(transport.NotificationWriter); ok {
+		return w.WriteNotification(ctx, method, raw)
 	}
 
+	msg := &types.Message{
+		JSONRPC: types.JSONRPCVersion,
+		Method:  method,
+	}
+	if raw != nil {
+		params := append(json.RawMessage(nil), raw...)
+		msg.Params = &params
+	}
 	return b.transport.Send(ctx, msg)
 }
 
-// handleMessages processes incoming messages from the transport
+// maxPooledParams is the largest params buffer kept for reuse
+const maxPooledParams = 64 << 10
+
+// paramsPool holds buffers for encoding notification params
+var paramsPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// handleMessages processes incoming messages from the transport. Responses
+// and notifications come in the order the peer sent them and are dispatched
+// in that order: a response reaches its waiter only after every notification
+// sent before it has been queued, and notifications are handled one at a
+// time by runNotifications. Requests are handled concurrently.
 func (b *Base) handleMessages(ctx context.Context) {
 	router := b.transport.GetRouter()
 	for {
@@ -210,25 +688,115 @@ func (b *Base) handleMessages(ctx context.Context) {
 				return
 			}
 			// Handle request in a goroutine
-			go b.handleRequest(ctx, req)
-		case notif, ok := <-router.Notifications:
+			goroutine.Go("base/request", func() { b.handleRequest(ctx, req) })
+		case msg := <-router.Inbound:
+			if msg.Method == "" {
+				b.deliverResponse(msg)
+				continue
+			}
+			// Queued rather than handled here, so a handler can wait for a
+			// response without blocking its delivery
+			b.notifyMu.Lock()
+			b.notifyQueue = append(b.notifyQueue, msg)
+			b.notifyMu.Unlock()
+			select {
+			case b.notifyReady <- struct{}{}:
+			default:
+			}
+		case err, ok := <-router.Errors:
 			if !ok {
 				return
 			}
-			// Handle notification in a goroutine
-			go b.handleNotification(ctx, notif)
+			b.errorSubs.Publish(err)
+		case <-ctx.Done():
+			return
+		case <-router.Done():
+			return
+		}
+	}
+}
+
+// OnError registers a callback for errors that have no caller to return
+// them to, such as undecodable messages, panicking handlers, and responses
+// that could not be sent. See the error types of package transport.
+func (b *Base) OnError(callback func(error)) (unsubscribe func()) {
+	return b.errorSubs.Add(callback)
+}
+
+// reportError logs err and reports it to the OnError callbacks
+func (b *Base) reportError(err error) {
+	b.Log(context.Background(), logger.LevelError, "%v", err)
+	b.GetRouter().ReportError(err)
+}
+
+// recoverHandler recovers a panic in the handler for method and reports it.
+// For requests, err is set to the internal error to answer with.
+func (b *Base) recoverHandler(method string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	b.reportError(&transport.HandlerPanicError{Method: method, Value: r, Stack: debug.Stack()})
+	if err != nil {
+		*err = types.NewError(types.InternalError, "internal error")
+	}
+}
+
+// respond sends the response to request id, reporting a failure to send it
+func (b *Base) respond(ctx context.Context, id types.ID, result interface{}, err error) {
+	if sendErr := b.SendResponse(ctx, id, result, err); sendErr != nil {
+		if b.closing.Load() {
+			// Expected for handlers still running when the transport closed
+			b.Log(ctx, logger.LevelDebug, "Dropping response %v after shutdown: %v", id, sendErr)
+			return
+		}
+		b.reportError(&transport.SendError{ID: &id, Err: sendErr})
+	}
+}
+
+// runNotifications handles queued notifications one at a time, in order
+func (b *Base) runNotifications(ctx context.Context) {
+	router := b.transport.GetRouter()
+	for {
+		select {
+		case <-b.notifyReady:
 		case <-ctx.Done():
 			return
 		case <-router.Done():
 			return
 		}
+		for {
+			b.notifyMu.Lock()
+			if len(b.notifyQueue) == 0 {
+				b.notifyQueue = nil
+				b.notifyMu.Unlock()
+				break
+			}
+			msg := b.notifyQueue[0]
+			b.notifyQueue[0] = nil
+			b.notifyQueue = b.notifyQueue[1:]
+			b.notifyMu.Unlock()
+
+			b.handleNotification(ctx, msg)
+		}
 	}
 }
 
+// RequestGate decides whether a request from the peer may be handled. ctx
+// carries the session it arrived on. A non-nil error is sent as the response
+// instead of calling the handler.
+type RequestGate func(ctx context.Context, method string) error
+
+// SetRequestGate sets the gate consulted before each request is handled. It
+// must be called before Start.
+func (b *Base) SetRequestGate(gate RequestGate) {
+	b.gate = gate
+}
+
 // handleRequest handles incoming requests
 func (b *Base) handleRequest(ctx context.Context, msg *types.Message) {
 	if msg.ID == nil {
-		b.Logf("Received request without ID: %s", msg.Method)
+		b.Log(ctx, logger.LevelWarn, "Received request without ID: %s", msg.Method)
 		return
 	}
 
@@ -240,27 +808,45 @@ func (b *Base) handleRequest(ctx context.Context, msg *types.Message) {
 	// 	return
 	// }
 
+	if b.closing.Load() || ctx.Err() != nil {
+		b.respond(ctx, *msg.ID, nil, types.NewError(types.InternalError, "shutting down"))
+		return
+	}
+
+	if b.gate != nil {
+		if err := b.gate(b.withSession(ctx), msg.Method); err != nil {
+			b.respond(ctx, *msg.ID, nil, err)
+			return
+		}
+	}
+
 	b.handlerMu.RLock()
 	handler, ok := b.requestHandlers[msg.Method]
 	b.handlerMu.RUnlock()
 
 	if ok {
-		result, err := handler(ctx, msg.Params)
-		_ = b.SendResponse(ctx, *msg.ID, result, err)
+		params := msg.Params
+		if meta := peerMeta(params); meta != nil {
+			ctx = mcp.WithPeerMeta(ctx, meta)
+		}
+		result, err := func() (result interface{}, err error) {
+			defer b.recoverHandler(msg.Method, &err)
+			return handler(b.withSession(ctx), params)
+		}()
+		b.respond(ctx, *msg.ID, result, err)
 		return
 	}
 
 	// Method not found
 	respErr := types.NewError(types.MethodNotFound,
 		fmt.Sprintf("method not found: %q (requestID=%v)", msg.Method, *msg.ID))
-	_ = b.SendResponse(ctx, *msg.ID, nil, respErr)
+	b.respond(ctx, *msg.ID, nil, respErr)
 }
 
 // handleNotification handles incoming notifications
 func (b *Base) handleNotification(ctx context.Context, msg *types.Message) {
-	if msg.Params == nil {
-		b.Logf("Received notification without params: %s", msg.Method)
-		return
+	if b.observeNotification != nil {
+		b.observeNotification(msg)
 	}
 
 	b.handlerMu.RLock()
@@ -268,16 +854,167 @@ func (b *Base) handleNotification(ctx context.Context, msg *types.Message) {
 	b.handlerMu.RUnlock()
 
 	if ok {
-		handler(ctx, *msg.Params)
+		// Params are optional; list_changed notifications usually omit them
+		var params json.RawMessage
+		if msg.Params != nil {
+			params = *msg.Params
+		}
+		defer b.recoverHandler(msg.Method, nil)
+		handler(b.withSession(ctx), params)
 	} else {
-		b.Logf("No handler registered for notification method: %s", msg.Method)
+		b.Log(ctx, logger.LevelDebug, "No handler registered for notification method: %s", msg.Method)
+	}
+}
+
+// withSession attaches the transport's current session, if any, to ctx
+func (b *Base) withSession(ctx context.Context) context.Context {
+	if session := b.Session(); session != nil {
+		return mcp.WithSession(ctx, session)
+	}
+	return ctx
+}
+
+// Session returns the transport's current session, or nil for transports
+// without sessions
+func (b *Base) Session() *mcp.Session {
+	if src, ok := b.transport.(transport.SessionSource); ok {
+		return src.Session()
+	}
+	return nil
+}
+
+// Stats returns the traffic the transport has counted over all its sessions,
+// or zero stats for transports that do not count it
+func (b *Base) Stats() mcp.SessionStats {
+	if src, ok := b.transport.(transport.StatsSource); ok {
+		return src.Stats()
+	}
+	return mcp.SessionStats{}
+}
+
+// OnSessionEnd registers a callback invoked when a session of the transport
+// ends. Transports whose sessions last as long as they do never invoke it.
+func (b *Base) OnSessionEnd(callback func(*mcp.Session)) {
+	if n, ok := b.transport.(transport.SessionEndNotifier); ok {
+		n.OnSessionEnd(callback)
+	}
+}
+
+// SetKeepAlive sets the keep-alive interval of transports that support it,
+// such as SSE; others have no idle stream to keep open and ignore it
+func (b *Base) SetKeepAlive(d time.Duration) {
+	if ks, ok := b.transport.(transport.KeepAliveSetter); ok {
+		ks.SetKeepAlive(d)
+	}
+}
+
+// SetWriteTimeout limits how long writing a message may block on transports
+// that support it; others ignore it
+func (b *Base) SetWriteTimeout(timeout time.Duration) {
+	if ws, ok := b.transport.(transport.WriteTimeoutSetter); ok {
+		ws.SetWriteTimeout(timeout)
+	}
+}
+
+// WaitForConnection waits until a transport that connects after starting,
+// such as an SSE client, is connected, returning the error that failed it if
+// it did; other transports are connected once started
+func (b *Base) WaitForConnection(ctx context.Context) error {
+	if cw, ok := b.transport.(transport.ConnectionWaiter); ok {
+		return cw.WaitForConnection(ctx)
+	}
+	return nil
+}
+
+// SetBufferUntilConnected makes the sends of a transport that connects after
+// starting, such as an SSE client, wait for it to be connected; other
+// transports ignore it
+func (b *Base) SetBufferUntilConnected(buffer bool) {
+	if cw, ok := b.transport.(transport.ConnectionWaiter); ok {
+		cw.SetBufferUntilConnected(buffer)
+	}
+}
+
+// SetHTTPTimeouts tunes the HTTP timeouts of transports over HTTP, such as
+// SSE; other transports ignore it
+func (b *Base) SetHTTPTimeouts(timeouts transport.HTTPTimeouts) {
+	if ts, ok := b.transport.(transport.HTTPTimeoutsSetter); ok {
+		ts.SetHTTPTimeouts(timeouts)
+	}
+}
+
+// SetCompression turns on compression in transports that support it, such
+// as SSE, for its event stream and posted messages; other transports ignore
+// it
+func (b *Base) SetCompression(enabled bool) {
+	if cs, ok := b.transport.(transport.CompressionSetter); ok {
+		cs.SetCompression(enabled)
+	}
+}
+
+// SetReconnect sets how transports that reconnect on their own, such as an
+// SSE client, do so; other transports ignore it
+func (b *Base) SetReconnect(retries int, backoff time.Duration) {
+	if r, ok := b.transport.(transport.Reconnector); ok {
+		r.SetReconnect(retries, backoff)
+	}
+}
+
+// SetSlowClientHook sets how transports queuing messages, such as SSE,
+// handle clients that fall behind; other transports ignore it
+func (b *Base) SetSlowClientHook(highWater int, hook transport.SlowClientHook) {
+	if ss, ok := b.transport.(transport.SlowClientHookSetter); ok {
+		ss.SetSlowClientHook(highWater, hook)
+	}
+}
+
+// UseHTTPMiddleware adds middleware around the HTTP handlers of transports
+// serving HTTP, such as SSE; other transports ignore it
+func (b *Base) UseHTTPMiddleware(mw ...func(http.Handler) http.Handler) {
+	if mu, ok := b.transport.(transport.HTTPMiddlewareUser); ok {
+		mu.Use(mw...)
+	}
+}
+
+// SetAllowedOrigins adds browser origins a transport checking them, such as
+// SSE, accepts besides loopback ones; other transports ignore it
+func (b *Base) SetAllowedOrigins(origins ...string) {
+	if oc, ok := b.transport.(transport.OriginChecker); ok {
+		oc.SetAllowedOrigins(origins...)
+	}
+}
+
+// DisableOriginCheck makes a transport checking origins, such as SSE, accept
+// requests from any origin; other transports ignore it
+func (b *Base) DisableOriginCheck() {
+	if oc, ok := b.transport.(transport.OriginChecker); ok {
+		oc.DisableOriginCheck()
+	}
+}
+
+// SetBearerToken sets the shared secret of transports that support one,
+// such as SSE; others have no requests to authorize and ignore it
+func (b *Base) SetBearerToken(token string) {
+	if bs, ok := b.transport.(transport.BearerTokenSetter); ok {
+		bs.SetBearerToken(token)
+	}
+}
+
+// SetAuthenticator makes transports that support it, such as SSE,
+// authenticate requests; other transports ignore it
+func (b *Base) SetAuthenticator(a auth.Authenticator) {
+	if as, ok := b.transport.(transport.AuthenticatorSetter); ok {
+		as.SetAuthenticator(a)
 	}
 }
 
 // BoundAddr returns the actual address the transport is listening on
 func (b *Base) BoundAddr() string {
-	if st, ok := b.transport.(*sse.SSETransport); ok {
-		return st.BoundAddr()
+	switch t := b.transport.(type) {
+	case *sse.SSETransport:
+		return t.BoundAddr()
+	case *tcp.Transport:
+		return t.BoundAddr()
 	}
 	return ""
 }
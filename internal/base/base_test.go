@@ -3,14 +3,26 @@ package base
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/dwrtz/mcp-go/internal/mock"
 	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/auth"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/transport"
+	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
+func TestMain(m *testing.M) {
+	testutil.VerifyTestMain(m)
+}
+
 func setupTest(t *testing.T) (context.Context, *Base, *Base, func()) {
 	logger := testutil.NewTestLogger(t)
 	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
@@ -119,3 +131,628 @@ func TestNotifications(t *testing.T) {
 	}
 
 }
+
+func TestHandleNotification(t *testing.T) {
+	ctx, srv, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	type greeting struct {
+		Name string `json:"name"`
+	}
+	received := make(chan greeting, 2)
+	HandleNotification(cli, "test/greeting", func(ctx context.Context, params greeting) {
+		received <- params
+	})
+
+	// Params that do not decode are dropped
+	if err := srv.SendNotification(ctx, "test/greeting", "not an object"); err != nil {
+		t.Fatalf("SendNotification error: %v", err)
+	}
+	if err := srv.SendNotification(ctx, "test/greeting", greeting{Name: "world"}); err != nil {
+		t.Fatalf("SendNotification error: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got.Name != "world" {
+			t.Errorf("Expected name 'world', got %q", got.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for notification")
+	}
+	select {
+	case got := <-received:
+		t.Errorf("Handler called with undecodable params: %+v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNotificationWithoutParams(t *testing.T) {
+	ctx, _, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	received := make(chan struct{}, 1)
+	HandleNotification(cli, methods.ToolsChanged, func(ctx context.Context, _ types.ToolListChangedNotification) {
+		received <- struct{}{}
+	})
+
+	// Transports such as SSE deliver omitted params as nil rather than null
+	cli.transport.GetRouter().Handle(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, Method: methods.ToolsChanged})
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("Notification without params was dropped")
+	}
+}
+
+type echoRequest struct {
+	Text string `json:"text"`
+}
+
+func (r *echoRequest) Validate() error {
+	if r.Text == "" {
+		return errors.New("text is required")
+	}
+	return nil
+}
+
+func TestHandleRequest(t *testing.T) {
+	ctx, srv, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	HandleRequest(srv, "test/echo", func(ctx context.Context, req echoRequest) (*echoRequest, error) {
+		if req.Text == "fail" {
+			return nil, fmt.Errorf("wrapped: %w", types.NewError(types.MethodNotFound, "no such thing"))
+		}
+		return &req, nil
+	})
+
+	tests := []struct {
+		name     string
+		params   interface{}
+		wantText string
+		wantCode int
+	}{
+		{name: "valid params", params: echoRequest{Text: "hello"}, wantText: "hello"},
+		{name: "missing params", params: nil, wantCode: types.InvalidParams},
+		{name: "undecodable params", params: "not an object", wantCode: types.InvalidParams},
+		{name: "invalid params", params: echoRequest{}, wantCode: types.InvalidParams},
+		{name: "handler error", params: echoRequest{Text: "fail"}, wantCode: types.MethodNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := cli.SendRequest(ctx, "test/echo", tt.params)
+			if tt.wantCode != 0 {
+				var mcpErr *types.ErrorResponse
+				if !errors.As(err, &mcpErr) {
+					t.Fatalf("Expected error code %d, got %v", tt.wantCode, err)
+				}
+				if mcpErr.Code != tt.wantCode {
+					t.Errorf("Expected error code %d, got %d (%s)", tt.wantCode, mcpErr.Code, mcpErr.Message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SendRequest error: %v", err)
+			}
+			var got echoRequest
+			if err := json.Unmarshal(*resp.Result, &got); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("Expected text %q, got %q", tt.wantText, got.Text)
+			}
+		})
+	}
+}
+
+func TestHandleRequestWithoutParams(t *testing.T) {
+	ctx, srv, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	// Methods whose params are all optional, which many clients omit
+	// entirely, get the zero request
+	type listRequest struct {
+		Cursor string `json:"cursor,omitempty"`
+	}
+	HandleRequest(srv, "test/list", func(ctx context.Context, req listRequest) (listRequest, error) {
+		return req, nil
+	})
+
+	for _, params := range []interface{}{nil, listRequest{}} {
+		resp, err := cli.SendRequest(ctx, "test/list", params)
+		if err != nil {
+			t.Fatalf("SendRequest(%v) error: %v", params, err)
+		}
+		if string(*resp.Result) != "{}" {
+			t.Errorf("SendRequest(%v) result = %s, want {}", params, *resp.Result)
+		}
+	}
+}
+
+func TestLateResponsesDropped(t *testing.T) {
+	ctx, _, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	late := make(chan *types.Message, 1)
+	cli.SetLateResponseHandler(func(resp *types.Message) {
+		late <- resp
+	})
+
+	// Simulate a request whose caller gave up before the response arrived
+	req := &PendingRequest{ID: 999, resp: make(chan *types.Message, 1)}
+	cli.pendingMu.Lock()
+	cli.pending[req.ID] = req
+	cli.pendingMu.Unlock()
+	cli.abandon(req)
+	router := cli.transport.GetRouter()
+	result := json.RawMessage(`{}`)
+	router.Handle(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, ID: &types.ID{Num: 999}, Result: &result})
+
+	if err := cli.Ping(ctx); err != nil {
+		t.Fatalf("Ping error: %v", err)
+	}
+
+	select {
+	case resp := <-late:
+		if resp.ID.Num != 999 {
+			t.Errorf("Expected late response 999, got %d", resp.ID.Num)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Late response handler not called")
+	}
+	cli.abandonedMu.Lock()
+	defer cli.abandonedMu.Unlock()
+	if len(cli.abandoned) != 0 {
+		t.Errorf("Expected abandoned IDs to be cleared, got %v", cli.abandoned)
+	}
+}
+
+func TestAbandonedRequestsBounded(t *testing.T) {
+	_, _, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	// Abandon more requests than are remembered, none ever answered
+	for id := uint64(1); id <= maxAbandoned+10; id++ {
+		req := &PendingRequest{ID: id, resp: make(chan *types.Message, 1)}
+		cli.pendingMu.Lock()
+		cli.pending[id] = req
+		cli.pendingMu.Unlock()
+		cli.abandon(req)
+	}
+
+	cli.abandonedMu.Lock()
+	defer cli.abandonedMu.Unlock()
+	if len(cli.abandoned) != maxAbandoned || len(cli.abandonedRing) != maxAbandoned {
+		t.Errorf("Remembered %d abandoned requests in a ring of %d, want %d", len(cli.abandoned), len(cli.abandonedRing), maxAbandoned)
+	}
+	for _, id := range []uint64{1, 10} {
+		if _, ok := cli.abandoned[id]; ok {
+			t.Errorf("Oldest abandoned request %d still remembered", id)
+		}
+	}
+	for _, id := range []uint64{11, maxAbandoned + 10} {
+		if _, ok := cli.abandoned[id]; !ok {
+			t.Errorf("Abandoned request %d forgotten", id)
+		}
+	}
+}
+
+func TestMessageOrdering(t *testing.T) {
+	ctx, srv, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	const streams, perStream = 8, 100
+
+	// Each request streams numbered notifications before it is answered,
+	// more than the router's channels hold
+	srv.RegisterRequestHandler("test/stream", func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		var p struct{ Stream int }
+		if err := json.Unmarshal(*params, &p); err != nil {
+			return nil, err
+		}
+		for n := 0; n < perStream; n++ {
+			if err := srv.SendNotification(ctx, "test/tick", map[string]int{"stream": p.Stream, "n": n}); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]int{"stream": p.Stream}, nil
+	})
+
+	var mu sync.Mutex
+	seen := make(map[int][]int)
+	cli.RegisterNotificationHandler("test/tick", func(ctx context.Context, params json.RawMessage) {
+		var tick struct{ Stream, N int }
+		if err := json.Unmarshal(params, &tick); err != nil {
+			t.Errorf("Invalid tick: %v", err)
+			return
+		}
+		// Give handlers running concurrently a chance to overtake
+		runtime.Gosched()
+		mu.Lock()
+		seen[tick.Stream] = append(seen[tick.Stream], tick.N)
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < streams; i++ {
+		wg.Add(1)
+		go func(stream int) {
+			defer wg.Done()
+			if _, err := cli.SendRequest(ctx, "test/stream", map[string]int{"stream": stream}); err != nil {
+				t.Errorf("Stream %d: SendRequest error: %v", stream, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every notification was queued before the last response was delivered;
+	// wait for them to be handled
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		total := 0
+		for _, ns := range seen {
+			total += len(ns)
+		}
+		mu.Unlock()
+		if total == streams*perStream || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for stream := 0; stream < streams; stream++ {
+		ns := seen[stream]
+		if len(ns) != perStream {
+			t.Errorf("Stream %d: expected %d notifications, got %d", stream, perStream, len(ns))
+			continue
+		}
+		for i, n := range ns {
+			if n != i {
+				t.Errorf("Stream %d: notification %d handled in position %d", stream, n, i)
+				break
+			}
+		}
+	}
+}
+
+func TestOnError(t *testing.T) {
+	ctx, srv, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	errs := make(chan error, 4)
+	srv.OnError(func(err error) {
+		errs <- err
+	})
+	next := func() error {
+		t.Helper()
+		select {
+		case err := <-errs:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for error")
+		}
+		return nil
+	}
+
+	// A panicking handler answers with an internal error
+	srv.RegisterRequestHandler("test/panic", func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		panic("boom")
+	})
+	_, err := cli.SendRequest(ctx, "test/panic", nil)
+	var respErr *types.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Code != types.InternalError {
+		t.Errorf("Expected internal error response, got %v", err)
+	}
+	var panicErr *transport.HandlerPanicError
+	if err := next(); !errors.As(err, &panicErr) || panicErr.Method != "test/panic" || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Errorf("Expected HandlerPanicError for test/panic, got %v", err)
+	}
+
+	// So does a notification handler, without stopping later notifications
+	handled := make(chan struct{})
+	srv.RegisterNotificationHandler("test/panic", func(ctx context.Context, params json.RawMessage) {
+		panic("boom")
+	})
+	srv.RegisterNotificationHandler("test/after", func(ctx context.Context, params json.RawMessage) {
+		close(handled)
+	})
+	if err := cli.SendNotification(ctx, "test/panic", nil); err != nil {
+		t.Fatalf("SendNotification error: %v", err)
+	}
+	if err := cli.SendNotification(ctx, "test/after", nil); err != nil {
+		t.Fatalf("SendNotification error: %v", err)
+	}
+	if err := next(); !errors.As(err, &panicErr) {
+		t.Errorf("Expected HandlerPanicError, got %v", err)
+	}
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("Notification after a panic not handled")
+	}
+
+	// Invalid messages are reported as they are dropped
+	srv.transport.GetRouter().Handle(ctx, &types.Message{JSONRPC: "1.0", Method: "test/after"})
+	var decodeErr *transport.DecodeError
+	if err := next(); !errors.As(err, &decodeErr) {
+		t.Errorf("Expected DecodeError, got %v", err)
+	}
+}
+
+func TestAddMeta(t *testing.T) {
+	meta := map[string]interface{}{"traceId": "t1", "progressToken": "mine"}
+	tests := []struct {
+		name   string
+		params string
+		want   string
+	}{
+		{"no params", `null`, `{"_meta":{"progressToken":"mine","traceId":"t1"}}`},
+		{"object params", `{"name":"x"}`, `{"_meta":{"progressToken":"mine","traceId":"t1"},"name":"x"}`},
+		{"existing meta wins", `{"_meta":{"progressToken":7}}`, `{"_meta":{"progressToken":7,"traceId":"t1"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addMeta([]byte(tt.params), meta)
+			if err != nil {
+				t.Fatalf("addMeta() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("addMeta() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := addMeta([]byte(`["not", "an", "object"]`), meta); err == nil {
+		t.Error("Expected error for non-object params")
+	}
+}
+
+// sessionTransport adds a fixed session to a transport
+type sessionTransport struct {
+	transport.Transport
+	session *mcp.Session
+}
+
+func (t sessionTransport) Session() *mcp.Session {
+	return t.session
+}
+
+func TestSessionInContext(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	session := &mcp.Session{ID: "s1", Principal: &auth.Principal{ID: "alice"}}
+	srv := NewBase(sessionTransport{Transport: serverTransport, session: session})
+	cli := NewBase(clientTransport)
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Close()
+	if err := cli.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer cli.Close()
+
+	got := make(chan *mcp.Session, 1)
+	srv.RegisterRequestHandler("test/whoami", func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		got <- mcp.SessionFromContext(ctx)
+		return struct{}{}, nil
+	})
+	if _, err := cli.SendRequest(ctx, "test/whoami", struct{}{}); err != nil {
+		t.Fatalf("SendRequest error: %v", err)
+	}
+	if s := <-got; s != session {
+		t.Errorf("Expected session %+v in handler context, got %+v", session, s)
+	}
+}
+
+// batchTransport records the batches and messages sent through a transport,
+// passing each message of a batch on alone
+type batchTransport struct {
+	transport.Transport
+	mu   sync.Mutex
+	sent []string
+}
+
+func (t *batchTransport) Send(ctx context.Context, msg *types.Message) error {
+	t.record(fmt.Sprintf("send %s", msg.Method))
+	return t.Transport.Send(ctx, msg)
+}
+
+func (t *batchTransport) WriteBatch(ctx context.Context, msgs []json.RawMessage) error {
+	t.record(fmt.Sprintf("batch of %d", len(msgs)))
+	for _, raw := range msgs {
+		var msg types.Message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return err
+		}
+		if err := t.Transport.Send(ctx, &msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *batchTransport) record(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, s)
+}
+
+func (t *batchTransport) log() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.sent...)
+}
+
+func TestNotificationBatching(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	bt := &batchTransport{Transport: serverTransport}
+	srv := NewBase(bt)
+	cli := NewBase(clientTransport)
+	srv.SetNotificationBatching(20 * time.Millisecond)
+
+	received := make(chan string, 10)
+	cli.RegisterNotificationHandler("test/note", func(ctx context.Context, params json.RawMessage) {
+		received <- string(params)
+	})
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Close()
+	if err := cli.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer cli.Close()
+
+	// A burst goes out as one batch once the window ends
+	for i := 0; i < 3; i++ {
+		if err := srv.SendNotification(ctx, "test/note", i); err != nil {
+			t.Fatalf("SendNotification error: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case got := <-received:
+			if got != fmt.Sprint(i) {
+				t.Errorf("Notification %d = %s", i, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the batch")
+		}
+	}
+
+	// A notification held is sent ahead of a later request
+	srv.SetNotificationBatching(time.Hour)
+	if err := srv.SendNotification(ctx, "test/note", 3); err != nil {
+		t.Fatalf("SendNotification error: %v", err)
+	}
+	if err := srv.Ping(ctx); err != nil {
+		t.Fatalf("Ping error: %v", err)
+	}
+	want := []string{"batch of 3", "batch of 1", "send ping"}
+	if got := bt.log(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Sent %v, want %v", got, want)
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	ctx, srv, cli, cleanup := setupTest(t)
+	defer cleanup()
+
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	srv.RegisterRequestHandler("test/block", func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+	go cli.SendRequest(ctx, "test/block", struct{}{})
+	<-started
+
+	var steps []string
+	srv.OnShutdown(func() {
+		select {
+		case <-canceled:
+			steps = append(steps, "handler canceled")
+		case <-time.After(5 * time.Second):
+			steps = append(steps, "handler still running")
+		}
+		select {
+		case <-srv.Done():
+			steps = append(steps, "done early")
+		default:
+		}
+	})
+
+	if err := srv.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	select {
+	case <-srv.Done():
+	default:
+		t.Error("Done is not closed after Close returned")
+	}
+	if fmt.Sprint(steps) != "[handler canceled]" {
+		t.Errorf("Shutdown steps = %v", steps)
+	}
+
+	// The peer's shutdown follows its transport closing
+	select {
+	case <-cli.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("Client did not shut down after the server closed")
+	}
+}
+
+func TestRunReasons(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	errCrash := errors.New("crash")
+
+	run := func(stop func(ctx context.Context, cancel context.CancelFunc, srv, cli *Base)) (srvErr, cliErr error) {
+		serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+		srv, cli := NewBase(serverTransport), NewBase(clientTransport)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		srvDone, cliDone := make(chan error, 1), make(chan error, 1)
+		if err := srv.Start(ctx); err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		if err := cli.Start(context.Background()); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		go func() { srvDone <- srv.Wait() }()
+		go func() { cliDone <- cli.Wait() }()
+		if err := cli.Ping(ctx); err != nil {
+			t.Fatalf("Ping error: %v", err)
+		}
+
+		stop(ctx, cancel, srv, cli)
+		for _, done := range []chan error{srvDone, cliDone} {
+			select {
+			case err := <-done:
+				if done == srvDone {
+					srvErr = err
+				} else {
+					cliErr = err
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run did not return")
+			}
+		}
+		return srvErr, cliErr
+	}
+
+	srvErr, cliErr := run(func(ctx context.Context, cancel context.CancelFunc, srv, cli *Base) {
+		srv.Close()
+	})
+	if srvErr != nil || !errors.Is(cliErr, transport.ErrPeerClosed) {
+		t.Errorf("Close: server %v, client %v", srvErr, cliErr)
+	}
+
+	srvErr, _ = run(func(ctx context.Context, cancel context.CancelFunc, srv, cli *Base) {
+		srv.CloseWithError(errCrash)
+	})
+	if srvErr != errCrash {
+		t.Errorf("CloseWithError: server %v", srvErr)
+	}
+
+	srvErr, cliErr = run(func(ctx context.Context, cancel context.CancelFunc, srv, cli *Base) {
+		cancel()
+	})
+	if srvErr != context.Canceled || !errors.Is(cliErr, transport.ErrPeerClosed) {
+		t.Errorf("Canceled: server %v, client %v", srvErr, cliErr)
+	}
+}
@@ -0,0 +1,89 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/transport"
+)
+
+// maxBatch is the most notifications held for one batch; the one reaching it
+// sends the batch at once
+const maxBatch = 64
+
+// SetNotificationBatching holds notifications for up to window after the
+// first, sending those sent meanwhile as one JSON-RPC batch, on transports
+// that are a transport.BatchWriter. Notifications held are sent before any
+// request or response, so the peer sees messages in the order they were
+// sent. 0, the default, sends each notification at once.
+func (b *Base) SetNotificationBatching(window time.Duration) {
+	b.batchMu.Lock()
+	defer b.batchMu.Unlock()
+	b.batchWindow = window
+}
+
+// holdNotification adds the notification for method and params to the
+// batch, returning false if notifications are not batched. Sending errors
+// are returned for a full batch, and reported for a batch sent later.
+func (b *Base) holdNotification(ctx context.Context, method string, params json.RawMessage) (bool, error) {
+	if _, ok := b.transport.(transport.BatchWriter); !ok {
+		return false, nil
+	}
+	b.batchMu.Lock()
+	if b.batchWindow <= 0 {
+		b.batchMu.Unlock()
+		return false, nil
+	}
+	// params may be pooled, so the notification is copied
+	data, err := transport.AppendNotification(nil, method, params)
+	if err != nil {
+		b.batchMu.Unlock()
+		return true, err
+	}
+	b.batch = append(b.batch, data)
+	full := len(b.batch) >= maxBatch
+	if !full && b.batchTimer == nil {
+		b.batchTimer = time.AfterFunc(b.batchWindow, func() {
+			if err := b.flushNotifications(context.Background()); err != nil {
+				b.reportError(&transport.SendError{Method: "notification batch", Err: err})
+			}
+		})
+	}
+	b.batchMu.Unlock()
+
+	if full {
+		return true, b.flushNotifications(ctx)
+	}
+	return true, nil
+}
+
+// flushNotifications sends the notifications held, if any
+func (b *Base) flushNotifications(ctx context.Context) error {
+	// flushMu keeps a message sent after a flush from overtaking a batch
+	// another flush is still writing
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.batchMu.Lock()
+	batch := b.batch
+	b.batch = nil
+	if b.batchTimer != nil {
+		b.batchTimer.Stop()
+		b.batchTimer = nil
+	}
+	b.batchMu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return b.transport.(transport.BatchWriter).WriteBatch(ctx, batch)
+}
+
+// flushBeforeSend sends the notifications held ahead of another message,
+// reporting a failure rather than failing that message
+func (b *Base) flushBeforeSend(ctx context.Context) {
+	if err := b.flushNotifications(ctx); err != nil {
+		b.reportError(&transport.SendError{Method: "notification batch", Err: err})
+	}
+}
@@ -0,0 +1,51 @@
+package base
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+)
+
+// group runs functions until the first one fails, which cancels the context
+// of the others, like golang.org/x/sync/errgroup
+type group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// newGroup creates a group whose functions run in a context derived from ctx
+func newGroup(ctx context.Context) *group {
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{ctx: ctx, cancel: cancel}
+}
+
+// Go runs f in the group in a goroutine with role, see goroutine.Go. A
+// non-nil error stops the group.
+func (g *group) Go(role string, f func(ctx context.Context) error) {
+	g.wg.Add(1)
+	goroutine.Go(role, func() {
+		defer g.wg.Done()
+		if err := f(g.ctx); err != nil {
+			g.stop(err)
+		}
+	})
+}
+
+// stop cancels the group's context, recording err if it is the first error
+func (g *group) stop(err error) {
+	g.once.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
+
+// Wait waits for the functions to return and returns the first error
+func (g *group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
@@ -0,0 +1,53 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// HandleNotification registers a handler for a notification method whose
+// params are decoded into T. Params that fail to decode are logged and the
+// handler is not called; absent or null params yield the zero T.
+func HandleNotification[T any](b *Base, method string, handler func(ctx context.Context, params T)) {
+	b.RegisterNotificationHandler(method, func(ctx context.Context, raw json.RawMessage) {
+		var params T
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &params); err != nil {
+				b.Log(ctx, logger.LevelWarn, "Failed to parse %s notification: %v", method, err)
+				return
+			}
+		}
+		handler(ctx, params)
+	})
+}
+
+// Validator is implemented by request params that check their own required
+// fields, such as types.ReadResourceRequest
+type Validator interface {
+	Validate() error
+}
+
+// HandleRequest registers a handler for a request method whose params are
+// decoded into Req. Absent or null params yield the zero Req, so methods with
+// optional params need no special casing; methods with required params should
+// use a Req implementing Validator. Decoding and validation failures are
+// answered with InvalidParams before the handler runs.
+func HandleRequest[Req, Res any](b *Base, method string, handler func(ctx context.Context, req Req) (Res, error)) {
+	b.RegisterRequestHandler(method, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		var req Req
+		if params != nil && len(*params) > 0 && string(*params) != "null" {
+			if err := json.Unmarshal(*params, &req); err != nil {
+				return nil, types.InvalidParamsError(method, err)
+			}
+		}
+		if v, ok := any(&req).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, types.InvalidParamsError(method, err)
+			}
+		}
+		return handler(ctx, req)
+	})
+}
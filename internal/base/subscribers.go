@@ -0,0 +1,78 @@
+package base
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+)
+
+// Subscribers fans a notification out to any number of independent callbacks.
+// A panicking callback is recovered and logged so it cannot affect the others.
+type Subscribers[T any] struct {
+	base *Base
+
+	mu     sync.Mutex
+	nextID int
+	subs   map[int]func(T)
+}
+
+// NewSubscribers creates an empty set of subscribers that logs through b
+func NewSubscribers[T any](b *Base) *Subscribers[T] {
+	return &Subscribers[T]{
+		base: b,
+		subs: make(map[int]func(T)),
+	}
+}
+
+// Add registers a callback and returns a function that removes it
+func (s *Subscribers[T]) Add(callback func(T)) (unsubscribe func()) {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.subs[id] = callback
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.subs, id)
+		s.mu.Unlock()
+	}
+}
+
+// Len returns the number of registered callbacks
+func (s *Subscribers[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs)
+}
+
+// Publish invokes every registered callback with v through the base's
+// callback executor. Callbacks are submitted in registration order.
+func (s *Subscribers[T]) Publish(v T) {
+	s.mu.Lock()
+	ids := make([]int, 0, len(s.subs))
+	for id := range s.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	callbacks := make([]func(T), len(ids))
+	for i, id := range ids {
+		callbacks[i] = s.subs[id]
+	}
+	s.mu.Unlock()
+
+	for _, callback := range callbacks {
+		s.base.execute(func() { s.invoke(callback, v) })
+	}
+}
+
+func (s *Subscribers[T]) invoke(callback func(T), v T) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.base.Log(context.Background(), logger.LevelError, "Recovered from panic in notification callback: %v", r)
+		}
+	}()
+	callback(v)
+}
@@ -0,0 +1,279 @@
+// Package bridge relays MCP messages between a host-facing transport and a
+// server transport that can be replaced while the host stays connected.
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/transport"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// replayTimeout bounds how long a replacement server may take to initialize
+var replayTimeout = 30 * time.Second
+
+// Bridge forwards requests, responses, and notifications in both directions.
+// When a server is replaced, the host's initialization is replayed to the new
+// one and the host is told that its tools, prompts, and resources changed.
+type Bridge struct {
+	host transport.Transport
+	ctx  context.Context
+
+	mu          sync.Mutex
+	server      transport.Transport
+	initialize  *types.Message // the host's initialize request
+	initialized bool
+	replays     map[string]chan *types.Message
+	nextReplay  int
+
+	logger logger.Logger
+}
+
+// New creates a Bridge for the host-facing transport
+func New(host transport.Transport) *Bridge {
+	return &Bridge{
+		host:    host,
+		replays: make(map[string]chan *types.Message),
+	}
+}
+
+// SetLogger sets the logger for the bridge
+func (b *Bridge) SetLogger(l logger.Logger) {
+	b.logger = l
+}
+
+// Logf logs a formatted message
+func (b *Bridge) Logf(format string, args ...interface{}) {
+	b.Log(context.Background(), logger.LevelInfo, format, args...)
+}
+
+// Log logs a formatted message at level
+func (b *Bridge) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
+	logger.Log(ctx, b.logger, level, format, args...)
+}
+
+// Start starts the host transport and begins relaying its messages
+func (b *Bridge) Start(ctx context.Context) error {
+	b.ctx = ctx
+	if err := b.host.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start host transport: %w", err)
+	}
+
+	router := b.host.GetRouter()
+	b.relay(router.Requests, b.fromHostRequest)
+	b.relay(router.Notifications, b.fromHostNotification)
+	b.relay(router.Responses, func(msg *types.Message) {
+		b.toServer(msg)
+	})
+	return nil
+}
+
+// Attach starts server and makes it the bridge's server, closing the previous
+// one. If the host has already initialized, the initialization is replayed
+// to server first and the host is sent list_changed notifications.
+func (b *Bridge) Attach(ctx context.Context, server transport.Transport) error {
+	if err := server.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start server transport: %w", err)
+	}
+
+	router := server.GetRouter()
+	b.relay(router.Responses, b.fromServerResponse)
+	b.relay(router.Requests, func(msg *types.Message) {
+		go b.fromServerRequest(server, msg)
+	})
+	b.relay(router.Notifications, func(msg *types.Message) {
+		b.toHost(msg)
+	})
+
+	b.mu.Lock()
+	initialize, initialized := b.initialize, b.initialized
+	b.mu.Unlock()
+
+	if initialize != nil {
+		if err := b.replay(ctx, server, initialize, initialized); err != nil {
+			server.Close()
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	prev := b.server
+	b.server = server
+	b.mu.Unlock()
+	if prev != nil {
+		prev.Close()
+	}
+
+	if initialized {
+		for _, method := range []string{methods.ToolsChanged, methods.PromptsChanged, methods.ResourceListChanged} {
+			b.toHost(&types.Message{JSONRPC: types.JSONRPCVersion, Method: method})
+		}
+	}
+	return nil
+}
+
+// Close closes the host and server transports
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	server := b.server
+	b.server = nil
+	b.mu.Unlock()
+
+	if server != nil {
+		server.Close()
+	}
+	return b.host.Close()
+}
+
+// replay sends the host's initialize request, and the initialized
+// notification if the host sent it, to a replacement server
+func (b *Bridge) replay(ctx context.Context, server transport.Transport, initialize *types.Message, initialized bool) error {
+	b.mu.Lock()
+	b.nextReplay++
+	id := types.ID{Str: fmt.Sprintf("bridge-replay-%d", b.nextReplay), IsString: true}
+	done := make(chan *types.Message, 1)
+	b.replays[id.Str] = done
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.replays, id.Str)
+		b.mu.Unlock()
+	}()
+
+	req := *initialize
+	req.ID = &id
+	if err := server.Send(ctx, &req); err != nil {
+		return fmt.Errorf("failed to initialize replacement server: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+	select {
+	case resp := <-done:
+		if resp.Error != nil {
+			return fmt.Errorf("failed to initialize replacement server: %w", resp.Error)
+		}
+	case <-ctx.Done():
+		return fmt.Errorf("failed to initialize replacement server: %w", ctx.Err())
+	}
+
+	if initialized {
+		notif := &types.Message{JSONRPC: types.JSONRPCVersion, Method: methods.Initialized}
+		if err := server.Send(ctx, notif); err != nil {
+			return fmt.Errorf("failed to initialize replacement server: %w", err)
+		}
+	}
+	return nil
+}
+
+// relay calls handle for each message until ch is closed, in a goroutine of
+// its own
+func (b *Bridge) relay(ch <-chan *types.Message, handle func(*types.Message)) {
+	goroutine.Go("bridge/relay", func() {
+		for msg := range ch {
+			handle(msg)
+		}
+	})
+}
+
+func (b *Bridge) fromHostRequest(msg *types.Message) {
+	if msg.Method == methods.Initialize {
+		b.mu.Lock()
+		b.initialize = msg
+		b.initialized = false
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	server := b.server
+	b.mu.Unlock()
+	if server == nil {
+		b.replyError(b.host, msg, types.NewError(types.InternalError, "server not running"))
+		return
+	}
+
+	// Senders may wait for the response, so don't hold up other messages
+	go func() {
+		if err := server.Send(b.ctx, msg); err != nil {
+			b.replyError(b.host, msg, err)
+		}
+	}()
+}
+
+func (b *Bridge) fromHostNotification(msg *types.Message) {
+	if msg.Method == methods.Initialized {
+		b.mu.Lock()
+		b.initialized = true
+		b.mu.Unlock()
+	}
+	b.toServer(msg)
+}
+
+func (b *Bridge) fromServerResponse(msg *types.Message) {
+	if msg.ID != nil && msg.ID.IsString {
+		b.mu.Lock()
+		done, ok := b.replays[msg.ID.Str]
+		b.mu.Unlock()
+		if ok {
+			done <- msg
+			return
+		}
+	}
+	b.toHost(msg)
+}
+
+func (b *Bridge) fromServerRequest(server transport.Transport, msg *types.Message) {
+	if err := b.host.Send(b.ctx, msg); err != nil {
+		b.replyError(server, msg, err)
+	}
+}
+
+// toServer forwards a message to the current server, if any
+func (b *Bridge) toServer(msg *types.Message) {
+	b.mu.Lock()
+	server := b.server
+	b.mu.Unlock()
+	if server == nil {
+		b.Log(b.ctx, logger.LevelWarn, "No server running, dropping %s message", describe(msg))
+		return
+	}
+	if err := server.Send(b.ctx, msg); err != nil {
+		b.Log(b.ctx, logger.LevelWarn, "Failed to forward %s to server: %v", describe(msg), err)
+	}
+}
+
+// toHost forwards a message to the host
+func (b *Bridge) toHost(msg *types.Message) {
+	if err := b.host.Send(b.ctx, msg); err != nil {
+		b.Log(b.ctx, logger.LevelWarn, "Failed to forward %s to host: %v", describe(msg), err)
+	}
+}
+
+// replyError answers a request through t with err
+func (b *Bridge) replyError(t transport.Transport, req *types.Message, err error) {
+	var mcpErr *types.ErrorResponse
+	if !errors.As(err, &mcpErr) {
+		mcpErr = types.NewError(types.InternalError, err.Error())
+	}
+	resp := &types.Message{JSONRPC: types.JSONRPCVersion, ID: req.ID, Error: mcpErr}
+	if err := t.Send(b.ctx, resp); err != nil {
+		b.Log(b.ctx, logger.LevelWarn, "Failed to send error response for %s: %v", req.Method, err)
+	}
+}
+
+// describe names a message for logs
+func describe(msg *types.Message) string {
+	if msg.Method != "" {
+		return msg.Method
+	}
+	id, _ := json.Marshal(msg.ID)
+	return "response " + string(id)
+}
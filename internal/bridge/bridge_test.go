@@ -0,0 +1,89 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/mock"
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp/client"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func newTools(names ...string) []types.McpTool {
+	var tools []types.McpTool
+	for _, name := range names {
+		tools = append(tools, types.NewTool[struct{}](name, "A test tool", func(ctx context.Context, _ struct{}) (*types.CallToolResult, error) {
+			return &types.CallToolResult{}, nil
+		}))
+	}
+	return tools
+}
+
+func TestBridgeReplaceServer(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hostSide, clientSide := mock.NewMockPipeTransports(logger)
+	b := New(hostSide)
+	b.SetLogger(logger)
+	if err := b.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer b.Close()
+
+	startServer := func(tools ...types.McpTool) {
+		t.Helper()
+		serverSide, bridgeSide := mock.NewMockPipeTransports(logger)
+		s := server.NewServer(serverSide, server.WithLogger(logger), server.WithTools(tools...))
+		if err := s.Start(ctx); err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		if err := b.Attach(ctx, bridgeSide); err != nil {
+			t.Fatalf("Attach() error: %v", err)
+		}
+	}
+
+	c := client.NewClient(clientSide, client.WithLogger(logger))
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer c.Close()
+
+	// Requests before a server is attached fail rather than hang
+	if err := c.Initialize(ctx); err == nil {
+		t.Fatal("Initialize() without a server succeeded")
+	}
+
+	startServer(newTools("first")...)
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("ListTools() = %+v, %v; want one tool", tools, err)
+	}
+
+	changed := make(chan struct{}, 1)
+	c.OnToolListChanged(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	// The replacement is initialized by the bridge, without the client
+	startServer(newTools("first", "second")...)
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for tools list_changed")
+	}
+	tools, err = c.ListTools(ctx)
+	if err != nil || len(tools) != 2 {
+		t.Fatalf("ListTools() after replacement = %+v, %v; want two tools", tools, err)
+	}
+}
@@ -4,29 +4,66 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/paging"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
 // Client provides client-side prompt functionality
 type Client struct {
-	base *base.Base
+	base        *base.Base
+	listChanged *base.Subscribers[struct{}]
+
+	// streams receive the partial results of Stream calls by progress token
+	mu        sync.Mutex
+	streams   map[string]func(types.PromptPartialResultNotification)
+	nextToken atomic.Int64
 }
 
 // NewClient creates a new Client
-func NewClient(base *base.Base) *Client {
-	return &Client{base: base}
+func NewClient(b *base.Base) *Client {
+	c := &Client{
+		base:        b,
+		listChanged: base.NewSubscribers[struct{}](b),
+		streams:     make(map[string]func(types.PromptPartialResultNotification)),
+	}
+	base.HandleNotification(b, methods.PromptsChanged, func(ctx context.Context, _ types.PromptListChangedNotification) {
+		c.listChanged.Publish(struct{}{})
+	})
+	// Partial results are handled inline, so all are in before the result
+	base.HandleNotification(b, methods.PromptPartialResult, func(ctx context.Context, notif types.PromptPartialResultNotification) {
+		c.mu.Lock()
+		stream := c.streams[fmt.Sprint(notif.ProgressToken)]
+		c.mu.Unlock()
+		if stream != nil {
+			stream(notif)
+		}
+	})
+	return c
 }
 
-// List requests the list of available prompts
+// List requests the list of available prompts, following
+// pagination cursors until the last page
 func (c *Client) List(ctx context.Context) ([]types.Prompt, error) {
-	req := &types.ListPromptsRequest{
-		Method: methods.ListPrompts,
+	return paging.Collect(ctx, c.base, methods.ListPrompts, func(r *types.ListPromptsResult) ([]types.Prompt, *types.Cursor) {
+		return r.Prompts, r.NextCursor
+	})
+}
+
+// Get requests a specific prompt
+func (c *Client) Get(ctx context.Context, name string, arguments map[string]string) (*types.GetPromptResult, error) {
+	req := &types.GetPromptRequest{
+		Method:    methods.GetPrompt,
+		Name:      name,
+		Arguments: arguments,
 	}
 
-	resp, err := c.base.SendRequest(ctx, methods.ListPrompts, req)
+	resp, err := c.base.SendRequest(ctx, methods.GetPrompt, req)
 	if err != nil {
 		return nil, err
 	}
@@ -41,48 +78,111 @@ func (c *Client) List(ctx context.Context) ([]types.Prompt, error) {
 		return nil, fmt.Errorf("empty response from server")
 	}
 
-	var result types.ListPromptsResult
+	var result types.GetPromptResult
 	if err := json.Unmarshal(*resp.Result, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse prompt result: %w", err)
 	}
 
-	return result.Prompts, nil
+	return &result, nil
 }
 
-// Get requests a specific prompt
-func (c *Client) Get(ctx context.Context, name string, arguments map[string]string) (*types.GetPromptResult, error) {
-	req := &types.GetPromptRequest{
-		Method:    methods.GetPrompt,
-		Name:      name,
-		Arguments: arguments,
+// Stream requests a specific prompt, passing its messages to onMessages as
+// they arrive from servers streaming them, and the rest once the result is
+// in. The returned result holds all messages, none counted as streamed.
+// onMessages may run on the goroutine handling incoming notifications, so it
+// must not block or make requests.
+func (c *Client) Stream(ctx context.Context, name string, arguments map[string]string, onMessages func([]types.PromptMessage)) (*types.GetPromptResult, error) {
+	token := fmt.Sprintf("prompt-%d", c.nextToken.Add(1))
+	var mu sync.Mutex
+	var messages []types.PromptMessage
+	lost := false
+	arrived := make(chan struct{}, 1)
+	c.mu.Lock()
+	c.streams[token] = func(notif types.PromptPartialResultNotification) {
+		mu.Lock()
+		defer mu.Unlock()
+		if notif.Offset != len(messages) {
+			lost = true
+		} else {
+			messages = append(messages, notif.Messages...)
+			onMessages(notif.Messages)
+		}
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.streams, token)
+		c.mu.Unlock()
+	}()
+
+	result, err := c.Get(mcp.WithMeta(ctx, "progressToken", token), name, arguments)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := c.base.SendRequest(ctx, methods.GetPrompt, req)
+	// Notifications are handled apart from responses, so streamed messages
+	// may still be on their way
+	for {
+		mu.Lock()
+		if lost {
+			mu.Unlock()
+			return nil, fmt.Errorf("messages of prompt %s lost while streaming", name)
+		}
+		if len(messages) >= result.Streamed {
+			break
+		}
+		mu.Unlock()
+		select {
+		case <-arrived:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	defer mu.Unlock()
+	if len(result.Messages) > 0 {
+		onMessages(result.Messages)
+	}
+	result.Messages = append(messages, result.Messages...)
+	result.Streamed = 0
+	return result, nil
+}
+
+// Complete requests suggested values for an argument of a prompt, given its
+// value so far
+func (c *Client) Complete(ctx context.Context, name, argument, value string) (*types.Completion, error) {
+	req := &types.CompleteRequest{
+		Method:   methods.Complete,
+		Ref:      types.CompletionReference{Type: types.PromptReference, Name: name},
+		Argument: types.CompletionArgument{Name: argument, Value: value},
+	}
+
+	resp, err := c.base.SendRequest(ctx, methods.Complete, req)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check for error response
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
 
-	// Check for nil result
 	if resp.Result == nil {
 		return nil, fmt.Errorf("empty response from server")
 	}
 
-	var result types.GetPromptResult
+	var result types.CompleteResult
 	if err := json.Unmarshal(*resp.Result, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse prompt result: %w", err)
+		return nil, fmt.Errorf("failed to parse completion result: %w", err)
 	}
 
-	return &result, nil
+	return &result.Completion, nil
 }
 
-// OnPromptListChanged registers a callback for prompt list change notifications
-func (c *Client) OnPromptListChanged(callback func()) {
-	c.base.RegisterNotificationHandler(methods.PromptsChanged, func(ctx context.Context, params json.RawMessage) {
-		callback()
-	})
+// OnPromptListChanged registers a callback for prompt list change notifications. Any
+// number of callbacks may be registered; the returned function removes this one.
+func (c *Client) OnPromptListChanged(callback func()) (unsubscribe func()) {
+	return c.listChanged.Add(func(struct{}) { callback() })
 }
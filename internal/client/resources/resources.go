@@ -6,47 +6,43 @@ import (
 	"fmt"
 
 	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/paging"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
 // Client provides client-side resource functionality
 type Client struct {
-	base *base.Base
+	base        *base.Base
+	updated     *base.Subscribers[string]
+	updates     *base.Subscribers[types.ResourceUpdatedNotification]
+	listChanged *base.Subscribers[struct{}]
 }
 
 // NewClient creates a new Client
-func NewClient(base *base.Base) *Client {
-	return &Client{base: base}
+func NewClient(b *base.Base) *Client {
+	c := &Client{
+		base:        b,
+		updated:     base.NewSubscribers[string](b),
+		updates:     base.NewSubscribers[types.ResourceUpdatedNotification](b),
+		listChanged: base.NewSubscribers[struct{}](b),
+	}
+	base.HandleNotification(b, methods.ResourceUpdated, func(ctx context.Context, notif types.ResourceUpdatedNotification) {
+		c.updated.Publish(notif.URI)
+		c.updates.Publish(notif)
+	})
+	base.HandleNotification(b, methods.ResourceListChanged, func(ctx context.Context, _ types.ResourceListChangedNotification) {
+		c.listChanged.Publish(struct{}{})
+	})
+	return c
 }
 
-// List requests the list of available resources
+// List requests the list of available resources, following
+// pagination cursors until the last page
 func (c *Client) List(ctx context.Context) ([]types.Resource, error) {
-	req := &types.ListResourcesRequest{
-		Method: methods.ListResources,
-	}
-
-	resp, err := c.base.SendRequest(ctx, methods.ListResources, req)
-	if err != nil {
-		return nil, err
-	}
-
-	// Check for error response
-	if resp.Error != nil {
-		return nil, resp.Error
-	}
-
-	// Check for nil result
-	if resp.Result == nil {
-		return nil, fmt.Errorf("empty response from server")
-	}
-
-	var result types.ListResourcesResult
-	if err := json.Unmarshal(*resp.Result, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return result.Resources, nil
+	return paging.Collect(ctx, c.base, methods.ListResources, func(r *types.ListResourcesResult) ([]types.Resource, *types.Cursor) {
+		return r.Resources, r.NextCursor
+	})
 }
 
 // Read requests the contents of a specific resource
@@ -77,31 +73,23 @@ func (c *Client) Read(ctx context.Context, uri string) ([]types.ResourceContent,
 	return result.Contents, nil
 }
 
-// ListTemplates requests the list of available resource templates
-func (c *Client) ListTemplates(ctx context.Context) ([]types.ResourceTemplate, error) {
-	req := &types.ListResourceTemplatesRequest{
-		Method: methods.ListResourceTemplates,
-	}
-
-	resp, err := c.base.SendRequest(ctx, methods.ListResourceTemplates, req)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.Error != nil {
-		return nil, resp.Error
-	}
-
-	if resp.Result == nil {
-		return nil, fmt.Errorf("empty response from server")
-	}
-
-	var result types.ListResourceTemplatesResult
-	if err := json.Unmarshal(*resp.Result, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// Search requests the resources passing filter, following pagination
+// cursors until the last page. The server must honor the filter.
+func (c *Client) Search(ctx context.Context, filter types.ResourceFilter) ([]types.Resource, error) {
+	request := func(cursor *types.Cursor) interface{} {
+		return &types.ListResourcesRequest{Method: methods.ListResources, Cursor: cursor, Filter: &filter}
 	}
+	return paging.CollectWith(ctx, c.base, methods.ListResources, request, func(r *types.ListResourcesResult) ([]types.Resource, *types.Cursor) {
+		return r.Resources, r.NextCursor
+	})
+}
 
-	return result.ResourceTemplates, nil
+// ListTemplates requests the list of available resource templates, following
+// pagination cursors until the last page
+func (c *Client) ListTemplates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	return paging.Collect(ctx, c.base, methods.ListResourceTemplates, func(r *types.ListResourceTemplatesResult) ([]types.ResourceTemplate, *types.Cursor) {
+		return r.ResourceTemplates, r.NextCursor
+	})
 }
 
 // Subscribe subscribes to updates for a specific resource
@@ -142,21 +130,21 @@ func (c *Client) Unsubscribe(ctx context.Context, uri string) error {
 	return nil
 }
 
-// OnResourceUpdated registers a callback for resource update notifications
-func (c *Client) OnResourceUpdated(callback func(uri string)) {
-	c.base.RegisterNotificationHandler(methods.ResourceUpdated, func(ctx context.Context, params json.RawMessage) {
-		var notif types.ResourceUpdatedNotification
-		if err := json.Unmarshal(params, &notif); err != nil {
-			c.base.Logf("Failed to parse resource updated notification: %v", err)
-			return
-		}
-		callback(notif.URI)
-	})
+// OnResourceUpdated registers a callback for resource update notifications.
+// Any number of callbacks may be registered; the returned function removes this one.
+func (c *Client) OnResourceUpdated(callback func(uri string)) (unsubscribe func()) {
+	return c.updated.Add(callback)
 }
 
-// OnResourceListChanged registers a callback for resource list change notifications
-func (c *Client) OnResourceListChanged(callback func()) {
-	c.base.RegisterNotificationHandler(methods.ResourceListChanged, func(ctx context.Context, params json.RawMessage) {
-		callback()
-	})
+// OnResourceUpdateNotification registers a callback that receives the full
+// update notification, including any pushed contents or hash.
+// Any number of callbacks may be registered; the returned function removes this one.
+func (c *Client) OnResourceUpdateNotification(callback func(types.ResourceUpdatedNotification)) (unsubscribe func()) {
+	return c.updates.Add(callback)
+}
+
+// OnResourceListChanged registers a callback for resource list change notifications.
+// Any number of callbacks may be registered; the returned function removes this one.
+func (c *Client) OnResourceListChanged(callback func()) (unsubscribe func()) {
+	return c.listChanged.Add(func(struct{}) { callback() })
 }
@@ -281,3 +281,56 @@ func TestClient_OnResourceListChanged(t *testing.T) {
 		t.Error("Callback not called within timeout")
 	}
 }
+
+func TestClient_NotificationFanOut(t *testing.T) {
+	ctx, client, server, cleanup := setupTest(t)
+	defer cleanup()
+
+	first := make(chan string, 2)
+	second := make(chan string, 2)
+
+	// A panicking subscriber must not prevent delivery to the others
+	client.OnResourceUpdated(func(uri string) {
+		panic("subscriber failure")
+	})
+	client.OnResourceUpdated(func(uri string) {
+		first <- uri
+	})
+	unsubscribe := client.OnResourceUpdated(func(uri string) {
+		second <- uri
+	})
+
+	send := func(uri string) {
+		notification := types.ResourceUpdatedNotification{
+			Method: methods.ResourceUpdated,
+			URI:    uri,
+		}
+		if err := server.SendNotification(ctx, methods.ResourceUpdated, notification); err != nil {
+			t.Fatalf("Failed to send notification: %v", err)
+		}
+	}
+	receive := func(ch chan string, want string) {
+		t.Helper()
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Errorf("Expected URI %s, got %s", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Callback not called with %s within timeout", want)
+		}
+	}
+
+	send("file:///a.txt")
+	receive(first, "file:///a.txt")
+	receive(second, "file:///a.txt")
+
+	unsubscribe()
+	send("file:///b.txt")
+	receive(first, "file:///b.txt")
+	select {
+	case uri := <-second:
+		t.Errorf("Unsubscribed callback called with %s", uri)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
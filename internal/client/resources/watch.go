@@ -0,0 +1,142 @@
+package resources
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// watch keeps the contents of one subscribed resource current
+type watch struct {
+	c        *Client
+	uri      string
+	callback func([]types.ResourceContent)
+
+	mu sync.Mutex
+	// pending are the update notifications not yet applied, in order
+	pending []types.ResourceUpdatedNotification
+	wake    chan struct{}
+	done    chan struct{}
+
+	// contents and hash are only used by the run goroutine once it starts
+	contents []types.ResourceContent
+	hash     string
+}
+
+// Watch subscribes to a resource and calls callback with its contents, then
+// with the updated contents after each update notification. Pushed contents
+// and patches are applied without reading the resource; it is read again
+// when a notification carries neither or a patch does not apply. The
+// returned function stops watching and unsubscribes.
+func (c *Client) Watch(ctx context.Context, uri string, callback func([]types.ResourceContent)) (stop func(), err error) {
+	w := &watch{
+		c:        c,
+		uri:      uri,
+		callback: callback,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	// Queue notifications from the start so none are missed while reading
+	remove := c.updates.Add(w.notify)
+	if err := c.Subscribe(ctx, uri); err != nil {
+		remove()
+		return nil, err
+	}
+	if err := w.read(ctx); err != nil {
+		remove()
+		_ = c.Unsubscribe(ctx, uri)
+		return nil, err
+	}
+	goroutine.Go("client/resource-watch", w.run)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			remove()
+			close(w.done)
+			if err := c.Unsubscribe(context.Background(), uri); err != nil {
+				c.base.Log(context.Background(), logger.LevelWarn, "Failed to unsubscribe from %s: %v", uri, err)
+			}
+		})
+	}, nil
+}
+
+// notify queues notif for the run goroutine. Reading the resource here
+// would block the handling of the response.
+func (w *watch) notify(notif types.ResourceUpdatedNotification) {
+	if notif.URI != w.uri {
+		return
+	}
+	w.mu.Lock()
+	w.pending = append(w.pending, notif)
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run applies queued notifications until the watch stops
+func (w *watch) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		}
+		for {
+			w.mu.Lock()
+			if len(w.pending) == 0 {
+				w.mu.Unlock()
+				break
+			}
+			notif := w.pending[0]
+			w.pending = w.pending[1:]
+			w.mu.Unlock()
+			w.update(notif)
+		}
+	}
+}
+
+// update brings the contents up to date with notif
+func (w *watch) update(notif types.ResourceUpdatedNotification) {
+	ctx := context.Background()
+	switch {
+	case notif.Contents != nil:
+		w.set(notif.Contents)
+		return
+	case notif.Patch != nil:
+		patched, err := notif.Patch.Apply(w.contents)
+		if err == nil {
+			if hash, err := types.HashResourceContents(patched); err == nil && (notif.Hash == "" || hash == notif.Hash) {
+				w.set(patched)
+				return
+			}
+		}
+		w.c.base.Log(ctx, logger.LevelDebug, "Patch for %s does not apply, reading it: %v", w.uri, err)
+	case notif.Hash != "" && notif.Hash == w.hash:
+		return
+	}
+	if err := w.read(ctx); err != nil {
+		w.c.base.Log(ctx, logger.LevelWarn, "Failed to read updated %s: %v", w.uri, err)
+	}
+}
+
+func (w *watch) read(ctx context.Context) error {
+	contents, err := w.c.Read(ctx, w.uri)
+	if err != nil {
+		return err
+	}
+	w.set(contents)
+	return nil
+}
+
+// set makes contents current and passes them to the callback
+func (w *watch) set(contents []types.ResourceContent) {
+	w.contents = contents
+	w.hash, _ = types.HashResourceContents(contents)
+	w.callback(contents)
+}
@@ -2,7 +2,6 @@ package roots
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sync"
 
@@ -13,26 +12,39 @@ import (
 
 // Client provides client-side roots functionality
 type Client struct {
-	base  *base.Base
-	mu    sync.RWMutex
-	roots []types.Root
+	base     *base.Base
+	mu       sync.RWMutex
+	roots    []types.Root
+	validate types.RootValidator
 }
 
 // NewClient creates a new Client
-func NewClient(base *base.Base, initialRoots []types.Root) *Client {
+func NewClient(b *base.Base, initialRoots []types.Root) *Client {
 	c := &Client{
-		base:  base,
-		roots: initialRoots,
+		base:     b,
+		roots:    initialRoots,
+		validate: types.DefaultRootValidator,
 	}
-	base.RegisterRequestHandler(methods.ListRoots, c.handleListRoots)
+	base.HandleRequest(b, methods.ListRoots, c.handleListRoots)
 	return c
 }
 
+// SetValidator replaces the validator applied to roots passed to SetRoots
+func (c *Client) SetValidator(validate types.RootValidator) {
+	c.mu.Lock()
+	c.validate = validate
+	c.mu.Unlock()
+}
+
 // SetRoots sets the roots for the client
 func (c *Client) SetRoots(ctx context.Context, roots []types.Root) error {
+	c.mu.RLock()
+	validate := c.validate
+	c.mu.RUnlock()
+
 	// Validate all roots before setting
 	for _, root := range roots {
-		if err := root.Validate(); err != nil {
+		if err := validate(root); err != nil {
 			return fmt.Errorf("invalid root %s: %w", root.URI, err)
 		}
 	}
@@ -48,7 +60,7 @@ func (c *Client) SetRoots(ctx context.Context, roots []types.Root) error {
 }
 
 // handleListRoots handles the roots/list request
-func (c *Client) handleListRoots(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+func (c *Client) handleListRoots(ctx context.Context, _ types.ListRootsRequest) (*types.ListRootsResult, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return &types.ListRootsResult{
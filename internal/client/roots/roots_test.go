@@ -14,6 +14,7 @@ import (
 	"github.com/dwrtz/mcp-go/internal/testutil"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
 )
 
 func setupTest(t *testing.T) (context.Context, *Client, *base.Base, func()) {
@@ -159,6 +160,28 @@ func TestClient_HandleInvalidRoots(t *testing.T) {
 	}
 }
 
+func TestClient_CustomValidator(t *testing.T) {
+	ctx, client, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	client.SetValidator(types.RootSchemes(urischeme.File, urischeme.Scheme{Name: "workspace"}))
+
+	if err := client.SetRoots(ctx, []types.Root{
+		{URI: "file:///project", Name: "Project"},
+		{URI: "workspace://frontend", Name: "Frontend"},
+	}); err != nil {
+		t.Fatalf("SetRoots() with allowed schemes error: %v", err)
+	}
+
+	err := client.SetRoots(ctx, []types.Root{{URI: "git://example.com/repo.git", Name: "Repo"}})
+	if err == nil {
+		t.Fatal("Expected error for scheme outside the allowed list")
+	}
+	if !strings.Contains(err.Error(), "root URI must start with file:// or workspace://") {
+		t.Errorf("Expected error listing allowed schemes, got: %v", err)
+	}
+}
+
 func TestClient_ConcurrentRootUpdates(t *testing.T) {
 	ctx, client, server, cleanup := setupTest(t)
 	defer cleanup()
@@ -2,7 +2,8 @@ package sampling
 
 import (
 	"context"
-	"encoding/json"
+	"sync"
+	"time"
 
 	"github.com/dwrtz/mcp-go/internal/base"
 	"github.com/dwrtz/mcp-go/pkg/methods"
@@ -13,28 +14,102 @@ import (
 type Client struct {
 	base    *base.Base
 	handler types.SamplingHandler
+
+	mu        sync.Mutex
+	totals    types.SamplingTotals
+	usageHook func(types.SamplingRecord)
+
+	// Attaches context to requests that ask for it; nil leaves them as sent
+	assembleContext ContextAssembler
 }
 
+// ContextAssembler adds the context a request's includeContext asks for,
+// typically to its system prompt, before the handler sees it
+type ContextAssembler func(ctx context.Context, req *types.CreateMessageRequest) error
+
 // NewClient creates a new Client
-func NewClient(base *base.Base, handler types.SamplingHandler) *Client {
+func NewClient(b *base.Base, handler types.SamplingHandler) *Client {
 	c := &Client{
-		base:    base,
+		base:    b,
 		handler: handler,
+		totals:  types.SamplingTotals{ByModel: make(map[string]types.ModelUsage)},
 	}
 
 	// Register request handler for sampling/createMessage
-	base.RegisterRequestHandler(methods.SampleCreate, c.handleCreateMessage)
+	base.HandleRequest(b, methods.SampleCreate, c.handleCreateMessage)
 
 	return c
 }
 
-func (c *Client) handleCreateMessage(ctx context.Context, params *json.RawMessage) (interface{}, error) {
-	var req types.CreateMessageRequest
-	if params == nil {
-		return nil, types.NewError(types.InvalidParams, "missing params")
+// SetUsageHook sets a function called after each sampling request is handled
+func (c *Client) SetUsageHook(hook func(types.SamplingRecord)) {
+	c.mu.Lock()
+	c.usageHook = hook
+	c.mu.Unlock()
+}
+
+// SetContextAssembler sets the function that honors includeContext
+func (c *Client) SetContextAssembler(assemble ContextAssembler) {
+	c.mu.Lock()
+	c.assembleContext = assemble
+	c.mu.Unlock()
+}
+
+// Usage returns the aggregate usage of the sampling requests handled so far
+func (c *Client) Usage() types.SamplingTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	totals := c.totals
+	totals.ByModel = make(map[string]types.ModelUsage, len(c.totals.ByModel))
+	for model, usage := range c.totals.ByModel {
+		totals.ByModel[model] = usage
 	}
-	if err := json.Unmarshal(*params, &req); err != nil {
-		return nil, err
+	return totals
+}
+
+func (c *Client) handleCreateMessage(ctx context.Context, req types.CreateMessageRequest) (*types.CreateMessageResult, error) {
+	c.mu.Lock()
+	assemble := c.assembleContext
+	c.mu.Unlock()
+	if assemble != nil && req.IncludeContext != "" && req.IncludeContext != "none" {
+		if err := assemble(ctx, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	result, err := c.handler(ctx, &req)
+	c.record(result, err, time.Since(start))
+	return result, err
+}
+
+// record adds a handled request to the totals and reports it to the hook
+func (c *Client) record(result *types.CreateMessageResult, err error, elapsed time.Duration) {
+	rec := types.SamplingRecord{Duration: elapsed, Err: err}
+	if err == nil && result != nil {
+		rec.Model = result.Model
+		if result.Usage != nil {
+			rec.Usage = *result.Usage
+		}
+	}
+
+	c.mu.Lock()
+	c.totals.Requests++
+	if err != nil {
+		c.totals.Failures++
+	} else {
+		c.totals.PromptTokens += rec.Usage.PromptTokens
+		c.totals.CompletionTokens += rec.Usage.CompletionTokens
+		model := c.totals.ByModel[rec.Model]
+		model.Requests++
+		model.PromptTokens += rec.Usage.PromptTokens
+		model.CompletionTokens += rec.Usage.CompletionTokens
+		c.totals.ByModel[rec.Model] = model
+	}
+	hook := c.usageHook
+	c.mu.Unlock()
+
+	if hook != nil {
+		hook(rec)
 	}
-	return c.handler(ctx, &req)
 }
@@ -21,14 +21,10 @@ func setupTest(t *testing.T) (context.Context, *base.Base, *Client, func()) {
 	baseClient := base.NewBase(clientTransport)
 
 	handler := func(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
-		// Validate request
-		if len(req.Messages) == 0 {
-			return nil, types.NewError(types.InvalidParams, "messages array cannot be empty")
+		// Requests are validated before the handler is called
+		if req.SystemPrompt == "fail" {
+			return nil, types.NewError(types.InternalError, "model unavailable")
 		}
-		if req.MaxTokens <= 0 {
-			return nil, types.NewError(types.InvalidParams, "maxTokens must be positive")
-		}
-
 		return &types.CreateMessageResult{
 			Role: types.RoleAssistant,
 			Content: types.TextContent{
@@ -37,6 +33,7 @@ func setupTest(t *testing.T) (context.Context, *base.Base, *Client, func()) {
 			},
 			Model:      "sample-model",
 			StopReason: "endTurn",
+			Usage:      &types.SamplingUsage{PromptTokens: 10, CompletionTokens: 5},
 		}, nil
 	}
 
@@ -212,3 +209,46 @@ func TestClient_HandleCreateMessageRequest_WithContext(t *testing.T) {
 	}
 
 }
+
+func TestClient_UsageAccounting(t *testing.T) {
+	ctx, baseServer, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	records := make(chan types.SamplingRecord, 3)
+	client.SetUsageHook(func(rec types.SamplingRecord) {
+		records <- rec
+	})
+
+	messages := []types.SamplingMessage{
+		{Role: types.RoleUser, Content: types.TextContent{Type: "text", Text: "Hello!"}},
+	}
+	for _, systemPrompt := range []string{"", "", "fail"} {
+		req := &types.CreateMessageRequest{Messages: messages, MaxTokens: 100, SystemPrompt: systemPrompt}
+		_, _ = baseServer.SendRequest(ctx, methods.SampleCreate, req)
+	}
+
+	// Invalid requests never reach the handler and are not counted
+	_, _ = baseServer.SendRequest(ctx, methods.SampleCreate, &types.CreateMessageRequest{Messages: messages})
+
+	for i := 0; i < 3; i++ {
+		select {
+		case rec := <-records:
+			if rec.Err == nil && (rec.Model != "sample-model" || rec.Usage.PromptTokens != 10) {
+				t.Errorf("Unexpected record: %+v", rec)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for usage record")
+		}
+	}
+
+	totals := client.Usage()
+	if totals.Requests != 3 || totals.Failures != 1 {
+		t.Errorf("Expected 3 requests with 1 failure, got %+v", totals)
+	}
+	if totals.PromptTokens != 20 || totals.CompletionTokens != 10 {
+		t.Errorf("Expected 20 prompt and 10 completion tokens, got %+v", totals.SamplingUsage)
+	}
+	if got := totals.ByModel["sample-model"]; got.Requests != 2 || got.CompletionTokens != 10 {
+		t.Errorf("Unexpected sample-model usage: %+v", got)
+	}
+}
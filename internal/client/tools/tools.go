@@ -6,47 +6,46 @@ import (
 	"fmt"
 
 	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/paging"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
 // Client provides client-side tool functionality
 type Client struct {
-	base *base.Base
+	base        *base.Base
+	listChanged *base.Subscribers[struct{}]
 }
 
 // NewClient creates a new Client
-func NewClient(base *base.Base) *Client {
-	return &Client{base: base}
+func NewClient(b *base.Base) *Client {
+	c := &Client{
+		base:        b,
+		listChanged: base.NewSubscribers[struct{}](b),
+	}
+	base.HandleNotification(b, methods.ToolsChanged, func(ctx context.Context, _ types.ToolListChangedNotification) {
+		c.listChanged.Publish(struct{}{})
+	})
+	return c
 }
 
-// List requests the list of available tools
+// List requests the list of available tools, following
+// pagination cursors until the last page
 func (c *Client) List(ctx context.Context) ([]types.Tool, error) {
-	req := &types.ListToolsRequest{
-		Method: methods.ListTools,
-	}
-
-	resp, err := c.base.SendRequest(ctx, methods.ListTools, req)
-	if err != nil {
-		return nil, err
-	}
-
-	// Check for error response
-	if resp.Error != nil {
-		return nil, resp.Error
-	}
-
-	// Check for nil result
-	if resp.Result == nil {
-		return nil, fmt.Errorf("empty response from server")
-	}
+	return paging.Collect(ctx, c.base, methods.ListTools, func(r *types.ListToolsResult) ([]types.Tool, *types.Cursor) {
+		return r.Tools, r.NextCursor
+	})
+}
 
-	var result types.ListToolsResult
-	if err := json.Unmarshal(*resp.Result, &result); err != nil {
-		return nil, err
+// ListFiltered requests the tools passing filter, following pagination
+// cursors until the last page. The server must honor the filter.
+func (c *Client) ListFiltered(ctx context.Context, filter types.ToolFilter) ([]types.Tool, error) {
+	request := func(cursor *types.Cursor) interface{} {
+		return &types.ListToolsRequest{Method: methods.ListTools, Cursor: cursor, Filter: &filter}
 	}
-
-	return result.Tools, nil
+	return paging.CollectWith(ctx, c.base, methods.ListTools, request, func(r *types.ListToolsResult) ([]types.Tool, *types.Cursor) {
+		return r.Tools, r.NextCursor
+	})
 }
 
 // Call invokes a specific tool
@@ -80,9 +79,8 @@ func (c *Client) Call(ctx context.Context, name string, arguments map[string]int
 	return &result, nil
 }
 
-// OnToolListChanged registers a callback for tool list change notifications
-func (c *Client) OnToolListChanged(callback func()) {
-	c.base.RegisterNotificationHandler(methods.ToolsChanged, func(ctx context.Context, params json.RawMessage) {
-		callback()
-	})
+// OnToolListChanged registers a callback for tool list change notifications. Any
+// number of callbacks may be registered; the returned function removes this one.
+func (c *Client) OnToolListChanged(callback func()) (unsubscribe func()) {
+	return c.listChanged.Add(func(struct{}) { callback() })
 }
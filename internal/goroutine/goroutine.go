@@ -0,0 +1,106 @@
+// Package goroutine starts the library's goroutines with a role, a pprof
+// label naming what each does, so a dump of the live ones can tell them
+// apart, see mcp.DebugGoroutines
+package goroutine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/pprof"
+	"sort"
+	"strings"
+)
+
+// LabelKey is the pprof label holding a goroutine's role
+const LabelKey = "mcp.role"
+
+// Go runs f in a new goroutine with role. Goroutines f starts inherit the
+// role unless given their own.
+func Go(role string, f func()) {
+	go Do(role, f)
+}
+
+// Do runs f in the calling goroutine with role, restoring its labels after.
+// It labels goroutines started by others, such as HTTP handlers.
+func Do(role string, f func()) {
+	pprof.Do(context.Background(), pprof.Labels(LabelKey, role), func(context.Context) {
+		f()
+	})
+}
+
+// Group is goroutines sharing a role and a stack
+type Group struct {
+	Role  string
+	Count int
+	// Stack holds a line per frame, innermost first: the function, then
+	// its file and line. The frames of Go and Do are left out.
+	Stack []string
+}
+
+// Live returns the live goroutines with a role, grouped by role and stack,
+// largest groups first within each role
+func Live() ([]Group, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil, err
+	}
+	groups := parse(buf.Bytes())
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Role != groups[j].Role {
+			return groups[i].Role < groups[j].Role
+		}
+		return groups[i].Count > groups[j].Count
+	})
+	return groups, nil
+}
+
+// parse reads the groups with a role from a goroutine profile written with
+// debug 1: a blank line between groups, each a "count @ addresses" line,
+// then "# labels: {...}" if labeled and a "#\taddress\tfunction\tfile:line"
+// line per frame
+func parse(profile []byte) []Group {
+	var groups []Group
+	var g *Group
+	scanner := bufio.NewScanner(bytes.NewReader(profile))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			g = nil
+		case !strings.HasPrefix(line, "#"):
+			g = &Group{}
+			fmt.Sscanf(line, "%d @", &g.Count)
+		case g == nil:
+		case strings.HasPrefix(line, "# labels: "):
+			var labels map[string]string
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "# labels: ")), &labels) == nil && labels[LabelKey] != "" {
+				g.Role = labels[LabelKey]
+				groups = append(groups, *g)
+				g = &groups[len(groups)-1]
+			} else {
+				g = nil
+			}
+		case g.Role != "":
+			fields := strings.Fields(strings.TrimPrefix(line, "#"))
+			if len(fields) >= 3 {
+				function, _, _ := strings.Cut(fields[1], "+0x")
+				if !wrapper(function) {
+					g.Stack = append(g.Stack, function+" "+fields[2])
+				}
+			}
+		}
+	}
+	return groups
+}
+
+// wrapper reports whether function is one of the frames Go and Do add
+func wrapper(function string) bool {
+	switch function {
+	case "runtime/pprof.Do", "github.com/dwrtz/mcp-go/internal/goroutine.Do", "github.com/dwrtz/mcp-go/internal/goroutine.Do.func1":
+		return true
+	}
+	return false
+}
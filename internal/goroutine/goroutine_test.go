@@ -0,0 +1,53 @@
+package goroutine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLive(t *testing.T) {
+	stop := make(chan struct{})
+	running := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		Go("test/parked", func() {
+			running <- struct{}{}
+			<-stop
+		})
+		<-running
+	}
+	defer close(stop)
+
+	groups, err := Live()
+	if err != nil {
+		t.Fatalf("Live error: %v", err)
+	}
+	var found *Group
+	for i := range groups {
+		if groups[i].Role == "test/parked" {
+			found = &groups[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("Live = %+v, want the parked goroutines", groups)
+	}
+	if found.Count != 2 || len(found.Stack) == 0 || !strings.HasPrefix(found.Stack[0], "github.com/dwrtz/mcp-go/internal/goroutine.TestLive.func1 ") {
+		t.Errorf("Group = %+v", found)
+	}
+	for _, frame := range found.Stack {
+		if wrapper(strings.Fields(frame)[0]) {
+			t.Errorf("Stack holds wrapper frame %s", frame)
+		}
+	}
+}
+
+func TestDoRestoresLabels(t *testing.T) {
+	Do("test/outer", func() {
+		Do("test/inner", func() {})
+		groups, _ := Live()
+		for _, g := range groups {
+			if g.Role == "test/inner" {
+				t.Errorf("Role still set after Do: %+v", g)
+			}
+		}
+	})
+}
@@ -3,9 +3,9 @@ package mock
 import (
 	"io"
 
-	"github.com/dwrtz/mcp-go/internal/transport"
 	"github.com/dwrtz/mcp-go/internal/transport/stdio"
 	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 )
 
 // NewMockPipeTransports returns two separate transports
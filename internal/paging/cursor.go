@@ -0,0 +1,83 @@
+package paging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Signer makes cursors tamper-evident and expiring. A signed cursor is the
+// HMAC-SHA256 of its list, issue time and key, followed by the time and key,
+// in base64.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+	// list names the list whose cursors are signed, so that a cursor of one
+	// list is rejected by another; see For
+	list string
+}
+
+// NewSigner creates a Signer whose cursors are signed with key and expire
+// ttl after they are issued; ttl <= 0 never expires them
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	return &Signer{key: key, ttl: ttl, now: time.Now}
+}
+
+// For returns a Signer for the cursors of the named list, such as the
+// method listing it. It returns nil if s is nil.
+func (s *Signer) For(list string) *Signer {
+	if s == nil {
+		return nil
+	}
+	scoped := *s
+	scoped.list = list
+	return &scoped
+}
+
+// encode returns the cursor naming the item with key, signed unless s is nil
+func (s *Signer) encode(key string) types.Cursor {
+	if s == nil {
+		return types.Cursor(base64.RawURLEncoding.EncodeToString([]byte(key)))
+	}
+	payload := binary.BigEndian.AppendUint64(nil, uint64(s.now().Unix()))
+	payload = append(payload, key...)
+	return types.Cursor(base64.RawURLEncoding.EncodeToString(append(s.mac(payload), payload...)))
+}
+
+// decode returns the key named by cursor. Malformed, tampered, and expired
+// cursors are rejected as invalid params.
+func (s *Signer) decode(cursor types.Cursor) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(string(cursor))
+	if err != nil {
+		return "", types.NewError(types.InvalidParams, "invalid cursor")
+	}
+	if s == nil {
+		return string(data), nil
+	}
+
+	if len(data) < sha256.Size+8 {
+		return "", types.NewError(types.InvalidParams, "invalid cursor")
+	}
+	mac, payload := data[:sha256.Size], data[sha256.Size:]
+	if !hmac.Equal(mac, s.mac(payload)) {
+		return "", types.NewError(types.InvalidParams, "invalid cursor")
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	if s.ttl > 0 && s.now().Sub(issued) > s.ttl {
+		return "", types.NewError(types.InvalidParams, "cursor expired; list again from the start")
+	}
+	return string(payload[8:]), nil
+}
+
+func (s *Signer) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(s.list))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)
+}
@@ -0,0 +1,129 @@
+// Package paging serves list results in pages and collects them on the
+// receiving side. Cursors name the last item of a page by its key, so they
+// stay valid while the list changes.
+package paging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Index is an immutable list with a position lookup by key. Build a new index
+// when the list changes instead of modifying one.
+type Index[T any] struct {
+	items []T
+	keys  []string
+	pos   map[string]int
+}
+
+// NewIndex indexes items by key. items must not be modified afterwards; of
+// items with the same key, the last is found by cursors.
+func NewIndex[T any](items []T, key func(T) string) *Index[T] {
+	ix := &Index[T]{items: items, keys: make([]string, len(items)), pos: make(map[string]int, len(items))}
+	for i, item := range items {
+		ix.keys[i] = key(item)
+		ix.pos[ix.keys[i]] = i
+	}
+	return ix
+}
+
+// Items returns all items in order. The result must not be modified.
+func (ix *Index[T]) Items() []T {
+	if ix == nil {
+		return nil
+	}
+	return ix.items
+}
+
+// Len returns the number of items
+func (ix *Index[T]) Len() int {
+	if ix == nil {
+		return 0
+	}
+	return len(ix.items)
+}
+
+// Page returns up to size items following cursor, or all following items if
+// size <= 0, along with the cursor of the next page if there is one. Cursors
+// are signed with signer, or plain if it is nil. The page shares memory with
+// the index and must not be modified. A cursor whose item has since been
+// removed is rejected as invalid params, so the caller starts over.
+func (ix *Index[T]) Page(cursor *types.Cursor, size int, signer *Signer) ([]T, *types.Cursor, error) {
+	start := 0
+	if cursor != nil {
+		key, err := signer.decode(*cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		i, ok := ix.pos[key]
+		if !ok {
+			return nil, nil, types.NewError(types.InvalidParams, "cursor refers to a removed item; list again from the start")
+		}
+		start = i + 1
+	}
+
+	end := len(ix.items)
+	if size > 0 && start+size < end {
+		end = start + size
+	}
+	page := ix.items[start:end:end]
+	if end == len(ix.items) {
+		return page, nil, nil
+	}
+	next := signer.encode(ix.keys[end-1])
+	return page, &next, nil
+}
+
+// listRequest is the params of every paginated list method
+type listRequest struct {
+	Method string        `json:"method"`
+	Cursor *types.Cursor `json:"cursor,omitempty"`
+}
+
+// Collect sends a list request and follows nextCursor until the last page,
+// decoding each page into R and gathering the items page returns from it
+func Collect[R any, T any](ctx context.Context, b *base.Base, method string, page func(*R) ([]T, *types.Cursor)) ([]T, error) {
+	return CollectWith(ctx, b, method, func(cursor *types.Cursor) interface{} {
+		return &listRequest{Method: method, Cursor: cursor}
+	}, page)
+}
+
+// CollectWith is Collect for list methods with further params: request
+// builds the params for the page at cursor
+func CollectWith[R any, T any](ctx context.Context, b *base.Base, method string, request func(cursor *types.Cursor) interface{}, page func(*R) ([]T, *types.Cursor)) ([]T, error) {
+	var all []T
+	seen := make(map[types.Cursor]bool)
+	var cursor *types.Cursor
+	for {
+		resp, err := b.SendRequest(ctx, method, request(cursor))
+		if err != nil {
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		if resp.Result == nil {
+			return nil, fmt.Errorf("empty response from server")
+		}
+
+		var result R
+		if err := json.Unmarshal(*resp.Result, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		items, next := page(&result)
+		all = append(all, items...)
+
+		if next == nil {
+			return all, nil
+		}
+		if seen[*next] {
+			return nil, fmt.Errorf("server repeated cursor %q", *next)
+		}
+		seen[*next] = true
+		cursor = next
+	}
+}
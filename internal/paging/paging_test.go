@@ -0,0 +1,140 @@
+package paging
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func identity(s string) string { return s }
+
+func collect(t *testing.T, ix *Index[string], size int) []string {
+	t.Helper()
+	var all []string
+	var cursor *types.Cursor
+	for pages := 0; ; pages++ {
+		if pages > ix.Len() {
+			t.Fatal("Paging did not terminate")
+		}
+		page, next, err := ix.Page(cursor, size, nil)
+		if err != nil {
+			t.Fatalf("Page() error: %v", err)
+		}
+		if size > 0 && len(page) > size {
+			t.Fatalf("Page has %d items, want at most %d", len(page), size)
+		}
+		all = append(all, page...)
+		if next == nil {
+			return all
+		}
+		cursor = next
+	}
+}
+
+func TestIndex_Page(t *testing.T) {
+	items := make([]string, 7)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", i)
+	}
+	ix := NewIndex(items, identity)
+
+	for _, size := range []int{0, 1, 3, 7, 10} {
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			got := collect(t, ix, size)
+			if fmt.Sprint(got) != fmt.Sprint(items) {
+				t.Errorf("Collected %v, want %v", got, items)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		page, next, err := NewIndex[string](nil, identity).Page(nil, 5, nil)
+		if err != nil || len(page) != 0 || next != nil {
+			t.Errorf("Page() = %v, %v, %v; want empty last page", page, next, err)
+		}
+	})
+}
+
+func TestIndex_StableCursor(t *testing.T) {
+	ix := NewIndex([]string{"a", "b", "c", "d"}, identity)
+	page, next, err := ix.Page(nil, 2, nil)
+	if err != nil || fmt.Sprint(page) != "[a b]" {
+		t.Fatalf("Page() = %v, %v", page, err)
+	}
+
+	// The list changes between pages: an entry is inserted before the cursor
+	ix = NewIndex([]string{"z", "a", "b", "c", "d"}, identity)
+	page, _, err = ix.Page(next, 2, nil)
+	if err != nil || fmt.Sprint(page) != "[c d]" {
+		t.Errorf("Page() after insert = %v, %v; want [c d]", page, err)
+	}
+
+	// The entry the cursor names is removed
+	ix = NewIndex([]string{"a", "c", "d"}, identity)
+	_, _, err = ix.Page(next, 2, nil)
+	var resp *types.ErrorResponse
+	if !errors.As(err, &resp) || resp.Code != types.InvalidParams {
+		t.Errorf("Page() with removed cursor error = %v, want InvalidParams", err)
+	}
+
+	bad := types.Cursor("not base64!")
+	if _, _, err := ix.Page(&bad, 2, nil); !errors.As(err, &resp) || resp.Code != types.InvalidParams {
+		t.Errorf("Page() with malformed cursor error = %v, want InvalidParams", err)
+	}
+}
+
+func TestSigner(t *testing.T) {
+	ix := NewIndex([]string{"a", "b", "c", "d"}, identity)
+	signer := NewSigner([]byte("secret"), time.Minute)
+	now := time.Unix(1700000000, 0)
+	signer.now = func() time.Time { return now }
+
+	page, next, err := ix.Page(nil, 2, signer)
+	if err != nil || fmt.Sprint(page) != "[a b]" || next == nil {
+		t.Fatalf("Page() = %v, %v, %v", page, next, err)
+	}
+	if page, _, err := ix.Page(next, 2, signer); err != nil || fmt.Sprint(page) != "[c d]" {
+		t.Errorf("Page() with signed cursor = %v, %v; want [c d]", page, err)
+	}
+
+	var resp *types.ErrorResponse
+	// Plain cursors and cursors signed with another key are rejected
+	for name, signed := range map[string]*Signer{"plain": nil, "other key": NewSigner([]byte("other"), time.Minute)} {
+		_, other, _ := ix.Page(nil, 2, signed)
+		if _, _, err := ix.Page(other, 2, signer); !errors.As(err, &resp) || resp.Code != types.InvalidParams {
+			t.Errorf("Page() with %s cursor error = %v, want InvalidParams", name, err)
+		}
+	}
+
+	// Cursors of one list are rejected by another
+	_, toolsNext, _ := ix.Page(nil, 2, signer.For("tools/list"))
+	if _, _, err := ix.Page(toolsNext, 2, signer.For("tools/list")); err != nil {
+		t.Errorf("Page() with cursor of the same list error = %v", err)
+	}
+	if _, _, err := ix.Page(toolsNext, 2, signer.For("prompts/list")); !errors.As(err, &resp) || resp.Message != "invalid cursor" {
+		t.Errorf("Page() with cursor of another list error = %v, want invalid cursor", err)
+	}
+	if (*Signer)(nil).For("tools/list") != nil {
+		t.Error("For() of a nil Signer is not nil")
+	}
+
+	// Tampering with the key breaks the signature
+	data, _ := base64.RawURLEncoding.DecodeString(string(*next))
+	data[len(data)-1] = 'c'
+	tampered := types.Cursor(base64.RawURLEncoding.EncodeToString(data))
+	if _, _, err := ix.Page(&tampered, 2, signer); !errors.As(err, &resp) || resp.Message != "invalid cursor" {
+		t.Errorf("Page() with tampered cursor error = %v, want invalid cursor", err)
+	}
+
+	// Cursors expire after the TTL
+	now = now.Add(2 * time.Minute)
+	_, _, err = ix.Page(next, 2, signer)
+	if !errors.As(err, &resp) || resp.Code != types.InvalidParams || !strings.Contains(resp.Message, "expired") {
+		t.Errorf("Page() with expired cursor error = %v, want InvalidParams for expiry", err)
+	}
+}
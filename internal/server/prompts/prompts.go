@@ -2,11 +2,14 @@ package prompts
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/paging"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
@@ -16,29 +19,43 @@ type Server struct {
 	base *base.Base
 	mu   sync.RWMutex
 
-	prompts       []types.Prompt
+	prompts       *paging.Index[types.Prompt]
 	promptGetters map[string]PromptGetter
+	completions   map[string]map[string]types.CompletionFunc // prompt -> argument -> completion
+	pageSize      int
+	signer        *paging.Signer
+	// streaming reports whether to send the messages of streamed prompts as
+	// they come, to requests with a progress token; nil never does
+	streaming func(ctx context.Context) bool
 }
 
 // PromptGetter is a function that returns a prompt result
 type PromptGetter func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error)
 
+// PromptStreamer is a variant of PromptGetter that generates a prompt's
+// messages incrementally. It returns a channel on which it sends them and
+// which it closes when done; a chunk with Err set ends the prompt with that
+// error. It must stop sending once ctx is done.
+type PromptStreamer func(ctx context.Context, args map[string]string) (<-chan types.PromptChunk, error)
+
 // NewServer creates a new Server
-func NewServer(base *base.Base, initialPrompts []types.Prompt) *Server {
+func NewServer(b *base.Base, initialPrompts []types.Prompt) *Server {
 	s := &Server{
-		base:          base,
-		prompts:       initialPrompts,
+		base:          b,
+		prompts:       paging.NewIndex(initialPrompts, promptName),
 		promptGetters: make(map[string]PromptGetter),
+		completions:   make(map[string]map[string]types.CompletionFunc),
 	}
-	base.RegisterRequestHandler(methods.ListPrompts, s.handleListPrompts)
-	base.RegisterRequestHandler(methods.GetPrompt, s.handleGetPrompt)
+	base.HandleRequest(b, methods.ListPrompts, s.handleListPrompts)
+	base.HandleRequest(b, methods.GetPrompt, s.handleGetPrompt)
+	base.HandleRequest(b, methods.Complete, s.handleComplete)
 	return s
 }
 
 // SetPrompts updates the list of available prompts
 func (s *Server) SetPrompts(ctx context.Context, prompts []types.Prompt) error {
 	s.mu.Lock()
-	s.prompts = prompts
+	s.prompts = paging.NewIndex(prompts, promptName)
 	s.mu.Unlock()
 
 	if s.base.Started {
@@ -51,35 +68,248 @@ func (s *Server) SetPrompts(ctx context.Context, prompts []types.Prompt) error {
 func (s *Server) RegisterPromptGetter(name string, getter PromptGetter) {
 	s.mu.Lock()
 	s.promptGetters[name] = getter
+	delete(s.completions, name)
+	s.mu.Unlock()
+}
+
+// RegisterPromptGetterWithCompletions registers a handler for getting prompt
+// contents along with completion functions for its arguments, keyed by
+// argument name
+func (s *Server) RegisterPromptGetterWithCompletions(name string, getter PromptGetter, completions map[string]types.CompletionFunc) {
+	s.mu.Lock()
+	s.promptGetters[name] = getter
+	s.completions[name] = completions
+	s.mu.Unlock()
+}
+
+// RegisterVersions lists and registers the versions of a prompt, oldest
+// first, each with its getter. The newest is served under the prompt's name,
+// and every version also under its versioned name (see
+// types.VersionedPromptName). Older versions not marked deprecated are marked
+// as superseded. Prompts listed under the name or its versions before are
+// replaced.
+func (s *Server) RegisterVersions(ctx context.Context, versions []types.Prompt, getters []PromptGetter) error {
+	if len(versions) == 0 || len(versions) != len(getters) {
+		return errors.New("each prompt version needs a getter")
+	}
+	latest := versions[len(versions)-1]
+	listed := []types.Prompt{latest}
+	named := map[string]PromptGetter{latest.Name: getters[len(getters)-1]}
+	for i, p := range versions {
+		if p.Name != latest.Name || p.Version() == "" {
+			return fmt.Errorf("prompt versions must share the name %s and set a version", latest.Name)
+		}
+		if i < len(versions)-1 && p.Deprecated() == "" {
+			meta := *p.Meta
+			meta.Deprecated = "superseded by version " + latest.Version()
+			p.Meta = &meta
+		}
+		p.Name = types.VersionedPromptName(latest.Name, p.Version())
+		if _, dup := named[p.Name]; dup {
+			return fmt.Errorf("prompt %s has version %s more than once", latest.Name, p.Version())
+		}
+		listed = append(listed, p)
+		named[p.Name] = getters[i]
+	}
+
+	s.mu.Lock()
+	var prompts []types.Prompt
+	for _, p := range s.prompts.Items() {
+		if !types.IsPromptVersion(p.Name, latest.Name) {
+			prompts = append(prompts, p)
+		}
+	}
+	s.prompts = paging.NewIndex(append(prompts, listed...), promptName)
+	for name, getter := range named {
+		s.promptGetters[name] = getter
+	}
+	s.mu.Unlock()
+
+	if s.base.Started {
+		return s.base.SendNotification(ctx, methods.PromptsChanged, nil)
+	}
+	return nil
+}
+
+// RegisterPromptStreamer registers a handler generating prompt contents
+// incrementally. With streaming enabled, messages are sent to the client as
+// they come; otherwise the result is assembled first.
+func (s *Server) RegisterPromptStreamer(name string, streamer PromptStreamer) {
+	s.RegisterPromptGetter(name, s.streamed(streamer))
+}
+
+// SetStreaming enables sending the messages of streamed prompts as they
+// come, as partial results of requests with a progress token, for the
+// requests enabled reports true for, as those of clients that opted in. Nil
+// disables streaming.
+func (s *Server) SetStreaming(enabled func(ctx context.Context) bool) {
+	s.mu.Lock()
+	s.streaming = enabled
 	s.mu.Unlock()
 }
 
-func (s *Server) handleListPrompts(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+// Prompts returns the currently registered prompts
+func (s *Server) Prompts() []types.Prompt {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return append([]types.Prompt{}, s.prompts.Items()...)
+}
 
-	return &types.ListPromptsResult{
-		Prompts: s.prompts,
-	}, nil
+// SetPageSize sets the number of prompts per prompts/list page; n <= 0 lists
+// all prompts at once
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	s.pageSize = n
+	s.mu.Unlock()
 }
 
-func (s *Server) handleGetPrompt(ctx context.Context, params *json.RawMessage) (interface{}, error) {
-	if params == nil {
-		return nil, types.NewError(types.InvalidParams, "missing params")
-	}
+// SetCursorSigner signs the cursors of prompts/list pages with signer; nil
+// leaves them plain
+func (s *Server) SetCursorSigner(signer *paging.Signer) {
+	s.mu.Lock()
+	s.signer = signer
+	s.mu.Unlock()
+}
 
-	var req types.GetPromptRequest
-	if err := json.Unmarshal(*params, &req); err != nil {
+func (s *Server) handleListPrompts(ctx context.Context, req types.ListPromptsRequest) (*types.ListPromptsResult, error) {
+	s.mu.RLock()
+	prompts, size, signer := s.prompts, s.pageSize, s.signer
+	s.mu.RUnlock()
+
+	page, next, err := prompts.Page(req.Cursor, size, signer.For(methods.ListPrompts))
+	if err != nil {
 		return nil, err
 	}
+	return &types.ListPromptsResult{
+		Prompts:    page,
+		NextCursor: next,
+	}, nil
+}
 
+func promptName(p types.Prompt) string { return p.Name }
+
+func (s *Server) handleGetPrompt(ctx context.Context, req types.GetPromptRequest) (*types.GetPromptResult, error) {
 	s.mu.RLock()
 	getter, exists := s.promptGetters[req.Name]
 	s.mu.RUnlock()
 
 	if !exists {
-		return nil, fmt.Errorf("no prompt found with name: %s", req.Name)
+		return nil, types.NewError(types.InvalidParams, fmt.Sprintf("no prompt found with name: %s", req.Name))
 	}
 
 	return getter(ctx, req.Arguments)
 }
+
+// handleComplete suggests values for prompt arguments. Arguments without a
+// completion function, and resource references, get no suggestions.
+func (s *Server) handleComplete(ctx context.Context, req types.CompleteRequest) (*types.CompleteResult, error) {
+	result := &types.CompleteResult{Completion: types.Completion{Values: []string{}}}
+	if req.Ref.Type != types.PromptReference {
+		return result, nil
+	}
+
+	s.mu.RLock()
+	_, exists := s.promptGetters[req.Ref.Name]
+	complete := s.completions[req.Ref.Name][req.Argument.Name]
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, types.NewError(types.InvalidParams, fmt.Sprintf("no prompt found with name: %s", req.Ref.Name))
+	}
+	if complete == nil {
+		return result, nil
+	}
+
+	values, err := complete(ctx, req.Argument.Value)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > types.MaxCompletionValues {
+		result.Completion.Total = len(values)
+		result.Completion.HasMore = true
+		values = values[:types.MaxCompletionValues]
+	}
+	if values != nil {
+		result.Completion.Values = values
+	}
+	return result, nil
+}
+
+// streamed adapts streamer to a PromptGetter. Messages ready together are
+// sent in one partial result; those that cannot be sent are returned in the
+// result.
+func (s *Server) streamed(streamer PromptStreamer) PromptGetter {
+	return func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+		chunks, err := streamer(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.RLock()
+		streaming := s.streaming
+		s.mu.RUnlock()
+		var token types.ProgressToken
+		if streaming != nil && streaming(ctx) {
+			token = mcp.PeerMeta(ctx)["progressToken"]
+		}
+
+		result := &types.GetPromptResult{Messages: []types.PromptMessage{}}
+		sent := 0
+		for done := false; !done; {
+			var batch []types.PromptMessage
+			batch, done, err = nextBatch(ctx, chunks)
+			if err != nil {
+				return nil, err
+			}
+			if len(batch) == 0 {
+				continue
+			}
+
+			if token == nil {
+				result.Messages = append(result.Messages, batch...)
+				continue
+			}
+			notif := types.PromptPartialResultNotification{ProgressToken: token, Offset: sent, Messages: batch}
+			if err := s.base.SendNotification(ctx, methods.PromptPartialResult, notif); err != nil {
+				s.base.Log(ctx, logger.LevelWarn, "Failed to stream prompt messages, returning them instead: %v", err)
+				token = nil
+				result.Messages = append(result.Messages, batch...)
+				continue
+			}
+			sent += len(batch)
+			result.Streamed = sent
+		}
+		return result, nil
+	}
+}
+
+// nextBatch waits for the next message of a streamed prompt and takes those
+// ready after it. done reports the end of the stream.
+func nextBatch(ctx context.Context, chunks <-chan types.PromptChunk) (batch []types.PromptMessage, done bool, err error) {
+	select {
+	case chunk, ok := <-chunks:
+		if !ok {
+			return nil, true, nil
+		}
+		if chunk.Err != nil {
+			return nil, true, chunk.Err
+		}
+		batch = append(batch, chunk.Message)
+	case <-ctx.Done():
+		return nil, true, ctx.Err()
+	}
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return batch, true, nil
+			}
+			if chunk.Err != nil {
+				return nil, true, chunk.Err
+			}
+			batch = append(batch, chunk.Message)
+		default:
+			return batch, false, nil
+		}
+	}
+}
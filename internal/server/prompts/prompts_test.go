@@ -3,6 +3,7 @@ package prompts
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -289,3 +290,48 @@ func TestServer_PromptsChanged(t *testing.T) {
 		t.Error("Timeout waiting for prompts changed notification")
 	}
 }
+
+func TestServer_Complete(t *testing.T) {
+	ctx, server, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	server.RegisterPromptGetterWithCompletions("test_prompt",
+		func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+			return &types.GetPromptResult{}, nil
+		},
+		map[string]types.CompletionFunc{
+			"arg1": func(ctx context.Context, value string) ([]string, error) {
+				values := make([]string, 150)
+				for i := range values {
+					values[i] = fmt.Sprintf("%s%d", value, i)
+				}
+				return values, nil
+			},
+		},
+	)
+
+	resp, err := client.SendRequest(ctx, methods.Complete, &types.CompleteRequest{
+		Method:   methods.Complete,
+		Ref:      types.CompletionReference{Type: types.PromptReference, Name: "test_prompt"},
+		Argument: types.CompletionArgument{Name: "arg1", Value: "v"},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("Complete() error response = %v", resp.Error)
+	}
+	var result types.CompleteResult
+	if err := json.Unmarshal(*resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+
+	// Suggestions are capped, reporting how many there are
+	got := result.Completion
+	if len(got.Values) != types.MaxCompletionValues || got.Values[0] != "v0" {
+		t.Errorf("Completion has %d values, want the first %d", len(got.Values), types.MaxCompletionValues)
+	}
+	if got.Total != 150 || !got.HasMore {
+		t.Errorf("Completion total = %d, hasMore = %v; want 150 and more", got.Total, got.HasMore)
+	}
+}
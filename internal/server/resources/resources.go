@@ -2,13 +2,18 @@ package resources
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/paging"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/subscriptions"
 	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
 )
 
 // Server provides server-side resource functionality
@@ -16,31 +21,60 @@ type Server struct {
 	base *base.Base
 	mu   sync.RWMutex
 
-	resources       []types.Resource
-	templates       []types.ResourceTemplate
-	subscriptions   map[string][]string // URI -> subscriber IDs
+	resources       *paging.Index[types.Resource]
+	templates       *paging.Index[types.ResourceTemplate]
+	pageSize        int
+	signer          *paging.Signer
+	subscriptions   types.SubscriptionStore
+	allowPatterns   bool
+	allowFilter     bool
 	contentHandlers map[string]ContentHandler
+	providers       []mountedProvider
+	watches         map[string]func() // URI -> provider cancel func
+	schemes         *urischeme.Registry
+
+	// Converts text contents to UTF-8; nil leaves them as read
+	text *textNormalizer
+
+	// Content push for update notifications, to the sessions pushTo accepts;
+	// disabled when pushMode is empty
+	pushMode    types.ContentPushMode
+	pushMaxSize int
+	pushTo      func(ctx context.Context) bool
+	// pushed holds the contents last pushed for each URI, the bases of
+	// types.PushDiff patches
+	pushed map[string][]types.ResourceContent
 }
 
 // ContentHandler is a function that returns the contents of a resource
 type ContentHandler func(ctx context.Context, uri string) ([]types.ResourceContent, error)
 
+// mountedProvider is a resource provider serving URIs under a prefix
+type mountedProvider struct {
+	prefix   string
+	provider types.ResourceProvider
+}
+
 // NewServer creates a new Server
-func NewServer(base *base.Base, initialResources []types.Resource, initialTemplates []types.ResourceTemplate) *Server {
+func NewServer(b *base.Base, initialResources []types.Resource, initialTemplates []types.ResourceTemplate) *Server {
 	s := &Server{
-		base:            base,
-		resources:       initialResources,
-		templates:       initialTemplates,
-		subscriptions:   make(map[string][]string),
+		base:            b,
+		resources:       paging.NewIndex(initialResources, resourceURI),
+		templates:       paging.NewIndex(initialTemplates, templateURI),
+		subscriptions:   subscriptions.NewMemoryStore(),
 		contentHandlers: make(map[string]ContentHandler),
+		watches:         make(map[string]func()),
+		pushed:          make(map[string][]types.ResourceContent),
+		schemes:         urischeme.Default.Clone(),
 	}
 
 	// Register request handlers
-	base.RegisterRequestHandler(methods.ListResources, s.handleListResources)
-	base.RegisterRequestHandler(methods.ReadResource, s.handleReadResource)
-	base.RegisterRequestHandler(methods.ListResourceTemplates, s.handleListTemplates)
-	base.RegisterRequestHandler(methods.SubscribeResource, s.handleSubscribe)
-	base.RegisterRequestHandler(methods.UnsubscribeResource, s.handleUnsubscribe)
+	base.HandleRequest(b, methods.ListResources, s.handleListResources)
+	base.HandleRequest(b, methods.ReadResource, s.handleReadResource)
+	base.HandleRequest(b, methods.ListResourceTemplates, s.handleListTemplates)
+	base.HandleRequest(b, methods.SubscribeResource, s.handleSubscribe)
+	base.HandleRequest(b, methods.UnsubscribeResource, s.handleUnsubscribe)
+	b.OnSessionEnd(s.endSession)
 
 	return s
 }
@@ -48,7 +82,7 @@ func NewServer(base *base.Base, initialResources []types.Resource, initialTempla
 // SetResources updates the list of available resources
 func (s *Server) SetResources(ctx context.Context, resources []types.Resource) error {
 	s.mu.Lock()
-	s.resources = resources
+	s.resources = paging.NewIndex(resources, resourceURI)
 	s.mu.Unlock()
 
 	if s.base.Started {
@@ -60,109 +94,589 @@ func (s *Server) SetResources(ctx context.Context, resources []types.Resource) e
 // SetTemplates updates the list of resource templates
 func (s *Server) SetTemplates(ctx context.Context, templates []types.ResourceTemplate) {
 	s.mu.Lock()
-	s.templates = templates
+	s.templates = paging.NewIndex(templates, templateURI)
 	s.mu.Unlock()
 }
 
-// RegisterContentHandler registers a handler for reading resource contents
+// SetSchemeRegistry sets the registry used to validate and normalize URIs
+func (s *Server) SetSchemeRegistry(r *urischeme.Registry) {
+	s.mu.Lock()
+	s.schemes = r
+	s.mu.Unlock()
+}
+
+// RegisterContentHandler registers a handler for reading resource contents.
+// The scheme of uriPrefix is allowed on the server's registry if it is not
+// already registered.
 func (s *Server) RegisterContentHandler(uriPrefix string, handler ContentHandler) {
 	s.mu.Lock()
 	s.contentHandlers[uriPrefix] = handler
+	if scheme := urischeme.SchemeOf(uriPrefix); scheme != "" {
+		s.schemes.Allow(scheme)
+	}
 	s.mu.Unlock()
 }
 
+// AddProvider mounts a resource provider at the given URI prefix. Resources
+// and templates from all providers are merged into list results, and reads and
+// subscriptions for URIs under the prefix are routed to the provider. As with
+// content handlers, the prefix's scheme is allowed on the server's registry.
+func (s *Server) AddProvider(ctx context.Context, uriPrefix string, provider types.ResourceProvider) error {
+	s.mu.Lock()
+	s.providers = append(s.providers, mountedProvider{prefix: uriPrefix, provider: provider})
+	if scheme := urischeme.SchemeOf(uriPrefix); scheme != "" {
+		s.schemes.Allow(scheme)
+	}
+	s.mu.Unlock()
+
+	if s.base.Started {
+		return s.base.SendNotification(ctx, methods.ResourceListChanged, nil)
+	}
+	return nil
+}
+
 // NotifyResourceUpdated notifies subscribers that a resource has changed
 func (s *Server) NotifyResourceUpdated(ctx context.Context, uri string) error {
+	uri, err := s.normalizeURI(uri)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.subscribed(ctx, uri)
+	if err != nil || !exists {
+		return err
+	}
+
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	mode, maxSize, pushTo := s.pushMode, s.pushMaxSize, s.pushTo
+	s.mu.RUnlock()
+	var handler ContentHandler
+	if mode != "" && (pushTo == nil || pushTo(ctx)) {
+		s.mu.RLock()
+		handler = s.findContentHandler(uri)
+		s.mu.RUnlock()
+	}
 
-	if _, exists := s.subscriptions[uri]; exists {
-		notif := &types.ResourceUpdatedNotification{
-			Method: methods.ResourceUpdated,
-			URI:    uri,
-		}
-		return s.base.SendNotification(ctx, methods.ResourceUpdated, notif)
+	notif := &types.ResourceUpdatedNotification{
+		Method: methods.ResourceUpdated,
+		URI:    uri,
 	}
-	return nil
+	if handler != nil {
+		s.addPushedContents(ctx, notif, handler, mode, maxSize)
+	}
+	return s.base.SendNotification(ctx, methods.ResourceUpdated, notif)
+}
+
+// SetSubscriptionPatterns makes subscriptions to URIs containing wildcards
+// cover every matching resource (see urischeme.Match). When disabled, such
+// URIs are subscribed to literally.
+func (s *Server) SetSubscriptionPatterns(enabled bool) {
+	s.mu.Lock()
+	s.allowPatterns = enabled
+	s.mu.Unlock()
+}
+
+// SetFiltering makes resources/list honor the filter of requests. When
+// disabled, filters are ignored and every resource is listed.
+func (s *Server) SetFiltering(enabled bool) {
+	s.mu.Lock()
+	s.allowFilter = enabled
+	s.mu.Unlock()
+}
+
+// SetSubscriptionStore sets the store holding the server's subscriptions,
+// replacing the in-memory default. It must be called before the server
+// starts.
+func (s *Server) SetSubscriptionStore(store types.SubscriptionStore) {
+	s.mu.Lock()
+	s.subscriptions = store
+	s.mu.Unlock()
 }
 
-func (s *Server) handleListResources(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+// subscribed reports whether uri is subscribed to, directly or, if enabled,
+// through a pattern
+func (s *Server) subscribed(ctx context.Context, uri string) (bool, error) {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	store, allowPatterns := s.subscriptions, s.allowPatterns
+	s.mu.RUnlock()
 
-	return &types.ListResourcesResult{
-		Resources: s.resources,
-	}, nil
+	subscribers, err := store.Subscribers(ctx, uri)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up subscribers of %s: %w", uri, err)
+	}
+	if len(subscribers) > 0 || !allowPatterns {
+		return len(subscribers) > 0, nil
+	}
+
+	uris, err := store.URIs(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	for _, pattern := range uris {
+		if urischeme.IsPattern(pattern) && urischeme.Match(pattern, uri) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func (s *Server) handleReadResource(ctx context.Context, params *json.RawMessage) (interface{}, error) {
-	if params == nil {
-		return nil, types.NewError(types.InvalidParams, "missing params")
+// SetTextNormalization converts the text contents of every read to UTF-8,
+// see mimetype.DecodeText. Text is taken to be in the charset its MIME type
+// declares, if any. Text that cannot be decoded is returned as a blob if
+// blobFallback is set, and with invalid bytes replaced otherwise.
+func (s *Server) SetTextNormalization(blobFallback bool) {
+	s.mu.Lock()
+	s.text = &textNormalizer{blobFallback: blobFallback}
+	s.mu.Unlock()
+}
+
+// SetContentPush makes update notifications include the resource's contents
+// (up to maxSize bytes in total, or any size if maxSize <= 0), a hash of
+// them, or a patch against the previously pushed contents. Contents are
+// pushed to the session of a notification's context if pushTo reports true
+// for it, as for clients that opted in, or to every session if pushTo is
+// nil. An empty mode disables content push.
+func (s *Server) SetContentPush(mode types.ContentPushMode, maxSize int, pushTo func(ctx context.Context) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushMode = mode
+	s.pushMaxSize = maxSize
+	s.pushTo = pushTo
+}
+
+// addPushedContents reads the resource and adds its contents or hash to notif.
+// On failure the notification is sent without them and the client falls back
+// to reading the resource.
+func (s *Server) addPushedContents(ctx context.Context, notif *types.ResourceUpdatedNotification, handler ContentHandler, mode types.ContentPushMode, maxSize int) {
+	contents, err := handler(ctx, notif.URI)
+	if err != nil {
+		s.base.Log(ctx, logger.LevelWarn, "Failed to read %s for update notification: %v", notif.URI, err)
+		return
+	}
+
+	switch mode {
+	case types.PushHash:
+		hash, err := types.HashResourceContents(contents)
+		if err != nil {
+			s.base.Log(ctx, logger.LevelWarn, "Failed to hash %s for update notification: %v", notif.URI, err)
+			return
+		}
+		notif.Hash = hash
+	case types.PushContents:
+		if maxSize > 0 && contentsSize(contents) > maxSize {
+			return
+		}
+		notif.Contents = contents
+	case types.PushDiff:
+		hash, err := types.HashResourceContents(contents)
+		if err != nil {
+			s.base.Log(ctx, logger.LevelWarn, "Failed to hash %s for update notification: %v", notif.URI, err)
+			return
+		}
+		notif.Hash = hash
+
+		s.mu.Lock()
+		base := s.pushed[notif.URI]
+		s.pushed[notif.URI] = contents
+		s.mu.Unlock()
+
+		// Clients holding other contents than the base read the resource
+		size := contentsSize(contents)
+		if patch, ok := types.DiffResourceContents(base, contents); ok && patchSize(patch) < size {
+			notif.Patch = patch
+			return
+		}
+		if maxSize <= 0 || size <= maxSize {
+			notif.Contents = contents
+		}
+	}
+}
+
+// patchSize returns the total size of the lines inserted by patch
+func patchSize(patch *types.ResourcePatch) int {
+	size := 0
+	for _, e := range patch.Edits {
+		for _, line := range e.Insert {
+			size += len(line)
+		}
+	}
+	return size
+}
+
+// contentsSize returns the total size of the text and blob data in contents
+func contentsSize(contents []types.ResourceContent) int {
+	size := 0
+	for _, c := range contents {
+		switch c := c.(type) {
+		case types.TextResourceContents:
+			size += len(c.Text)
+		case types.BlobResourceContents:
+			size += len(c.Blob)
+		}
+	}
+	return size
+}
+
+// SetPageSize sets the number of entries per resources/list and
+// resources/templates/list page; n <= 0 lists everything at once
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	s.pageSize = n
+	s.mu.Unlock()
+}
+
+// SetCursorSigner signs the cursors of resources/list and
+// resources/templates/list pages with signer; nil leaves them plain
+func (s *Server) SetCursorSigner(signer *paging.Signer) {
+	s.mu.Lock()
+	s.signer = signer
+	s.mu.Unlock()
+}
+
+// Resources returns the static resources followed by those of each provider
+func (s *Server) Resources(ctx context.Context) ([]types.Resource, error) {
+	index, err := s.resourceIndex(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return append([]types.Resource{}, index.Items()...), nil
+}
 
-	var req types.ReadResourceRequest
-	if err := json.Unmarshal(*params, &req); err != nil {
+// Templates returns the static resource templates followed by those of each provider
+func (s *Server) Templates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	index, err := s.templateIndex(ctx)
+	if err != nil {
 		return nil, err
 	}
+	return append([]types.ResourceTemplate{}, index.Items()...), nil
+}
 
+// resourceIndex returns the static resources index, or a merged one including
+// the resources of each provider
+func (s *Server) resourceIndex(ctx context.Context) (*paging.Index[types.Resource], error) {
+	// Snapshot under the lock and call providers outside it
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	static, providers := s.resources, s.providers
+	s.mu.RUnlock()
+
+	if len(providers) == 0 {
+		return static, nil
+	}
+	resources := append([]types.Resource{}, static.Items()...)
+	for _, m := range providers {
+		provided, err := m.provider.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources for %s: %w", m.prefix, err)
+		}
+		resources = append(resources, provided...)
+	}
+	return paging.NewIndex(resources, resourceURI), nil
+}
+
+// templateIndex returns the static templates index, or a merged one including
+// the templates of each provider
+func (s *Server) templateIndex(ctx context.Context) (*paging.Index[types.ResourceTemplate], error) {
+	// Snapshot under the lock and call providers outside it
+	s.mu.RLock()
+	static, providers := s.templates, s.providers
+	s.mu.RUnlock()
+
+	if len(providers) == 0 {
+		return static, nil
+	}
+	templates := append([]types.ResourceTemplate{}, static.Items()...)
+	for _, m := range providers {
+		provided, err := m.provider.Templates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resource templates for %s: %w", m.prefix, err)
+		}
+		templates = append(templates, provided...)
+	}
+	return paging.NewIndex(templates, templateURI), nil
+}
 
-	// Find matching content handler
-	for prefix, handler := range s.contentHandlers {
-		if len(req.URI) >= len(prefix) && req.URI[:len(prefix)] == prefix {
-			contents, err := handler(ctx, req.URI)
-			if err != nil {
-				return nil, err
+func (s *Server) handleListResources(ctx context.Context, req types.ListResourcesRequest) (*types.ListResourcesResult, error) {
+	index, err := s.resourceIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	size, signer, filter := s.pageSize, s.signer, s.allowFilter
+	s.mu.RUnlock()
+
+	if filter && req.Filter != nil {
+		var matched []types.Resource
+		for _, r := range index.Items() {
+			if req.Filter.Matches(r) {
+				matched = append(matched, r)
 			}
-			return &types.ReadResourceResult{
-				Contents: contents,
-			}, nil
 		}
+		index = paging.NewIndex(matched, resourceURI)
 	}
 
-	return nil, fmt.Errorf("no handler found for URI: %s", req.URI)
+	page, next, err := index.Page(req.Cursor, size, signer.For(methods.ListResources))
+	if err != nil {
+		return nil, err
+	}
+	return &types.ListResourcesResult{
+		Resources:  page,
+		NextCursor: next,
+	}, nil
 }
 
-func (s *Server) handleListTemplates(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+func (s *Server) handleReadResource(ctx context.Context, req types.ReadResourceRequest) (*types.ReadResourceResult, error) {
+	uri, err := s.normalizeURI(req.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run the handler outside the lock so a slow read doesn't hold up updates
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	handler := s.findContentHandler(uri)
+	s.mu.RUnlock()
 
-	return &types.ListResourceTemplatesResult{
-		ResourceTemplates: s.templates,
+	if handler == nil {
+		return nil, types.NewError(types.ResourceNotFound, fmt.Sprintf("no handler found for URI: %s", req.URI))
+	}
+
+	contents, err := handler(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ReadResourceResult{
+		Contents: contents,
 	}, nil
 }
 
-func (s *Server) handleSubscribe(ctx context.Context, params *json.RawMessage) (interface{}, error) {
-	if params == nil {
-		return nil, types.NewError(types.InvalidParams, "missing params")
+func (s *Server) handleListTemplates(ctx context.Context, req types.ListResourceTemplatesRequest) (*types.ListResourceTemplatesResult, error) {
+	index, err := s.templateIndex(ctx)
+	if err != nil {
+		return nil, err
 	}
+	s.mu.RLock()
+	size, signer := s.pageSize, s.signer
+	s.mu.RUnlock()
 
-	var req types.SubscribeRequest
-	if err := json.Unmarshal(*params, &req); err != nil {
+	page, next, err := index.Page(req.Cursor, size, signer.For(methods.ListResourceTemplates))
+	if err != nil {
 		return nil, err
 	}
+	return &types.ListResourceTemplatesResult{
+		ResourceTemplates: page,
+		NextCursor:        next,
+	}, nil
+}
+
+func resourceURI(r types.Resource) string         { return r.URI }
+func templateURI(t types.ResourceTemplate) string { return t.URITemplate }
+
+func (s *Server) handleSubscribe(ctx context.Context, req types.SubscribeRequest) (*struct{}, error) {
+	uri, err := s.normalizeURI(req.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a provider watch outside the lock so providers may notify right
+	// away. Patterns are matched here, not watched by providers.
+	s.mu.RLock()
+	isPattern := s.allowPatterns && urischeme.IsPattern(uri)
+	var provider types.ResourceProvider
+	if !isPattern {
+		provider = s.findProvider(uri)
+	}
+	_, watching := s.watches[uri]
+	s.mu.RUnlock()
+
+	// The watch lasts as long as the URI is subscribed, not as the request:
+	// its context keeps the request's values and is cancelled once the last
+	// subscriber unsubscribes or its session ends
+	var stop func()
+	if provider != nil && !watching {
+		watchCtx, stopCtx := context.WithCancel(context.WithoutCancel(ctx))
+		cancel, err := provider.Subscribe(watchCtx, uri, func(uri string) {
+			if err := s.NotifyResourceUpdated(context.Background(), uri); err != nil {
+				s.base.Log(context.Background(), logger.LevelWarn, "Failed to notify resource update for %s: %v", uri, err)
+			}
+		})
+		if err != nil {
+			stopCtx()
+			return nil, err
+		}
+		stop = func() {
+			cancel()
+			stopCtx()
+		}
+	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	if stop != nil {
+		if _, watching := s.watches[uri]; watching {
+			// A concurrent subscribe already started a watch
+			stop()
+		} else {
+			s.watches[uri] = stop
+		}
+	}
+	store := s.subscriptions
+	s.mu.Unlock()
 
-	s.subscriptions[req.URI] = append(s.subscriptions[req.URI], "client-id") // TODO: Implement proper client ID tracking
+	if err := store.Add(ctx, uri, s.sessionID(ctx)); err != nil {
+		return nil, fmt.Errorf("failed to store subscription to %s: %w", uri, err)
+	}
 	return &struct{}{}, nil
 }
 
-func (s *Server) handleUnsubscribe(ctx context.Context, params *json.RawMessage) (interface{}, error) {
-	if params == nil {
-		return nil, types.NewError(types.InvalidParams, "missing params")
+func (s *Server) handleUnsubscribe(ctx context.Context, req types.UnsubscribeRequest) (*struct{}, error) {
+	uri, err := s.normalizeURI(req.URI)
+	if err != nil {
+		return nil, err
 	}
 
-	var req types.UnsubscribeRequest
-	if err := json.Unmarshal(*params, &req); err != nil {
-		return nil, err
+	s.mu.RLock()
+	store := s.subscriptions
+	s.mu.RUnlock()
+
+	if err := store.Remove(ctx, uri, s.sessionID(ctx)); err != nil {
+		return nil, fmt.Errorf("failed to remove subscription to %s: %w", uri, err)
+	}
+	return &struct{}{}, s.unwatch(ctx, store, uri)
+}
+
+// unwatch stops the provider watch of uri once it has no subscribers left
+func (s *Server) unwatch(ctx context.Context, store types.SubscriptionStore, uri string) error {
+	subscribers, err := store.Subscribers(ctx, uri)
+	if err != nil {
+		return fmt.Errorf("failed to look up subscribers of %s: %w", uri, err)
+	}
+	if len(subscribers) > 0 {
+		return nil
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	stop, watching := s.watches[uri]
+	delete(s.watches, uri)
+	delete(s.pushed, uri)
+	s.mu.Unlock()
 
-	delete(s.subscriptions, req.URI)
-	return &struct{}{}, nil
+	if watching {
+		stop()
+	}
+	return nil
+}
+
+// sessionID returns the ID of the session in ctx, or of the transport's
+// current session when ctx has none
+func (s *Server) sessionID(ctx context.Context) string {
+	session := mcp.SessionFromContext(ctx)
+	if session == nil {
+		session = s.base.Session()
+	}
+	if session == nil {
+		return ""
+	}
+	return session.ID
+}
+
+// endSession removes the subscriptions of a session that ended, stopping
+// the provider watches of URIs no one else subscribes to
+func (s *Server) endSession(session *mcp.Session) {
+	ctx := context.Background()
+	s.mu.RLock()
+	store := s.subscriptions
+	s.mu.RUnlock()
+
+	uris, err := store.URIs(ctx)
+	if err != nil {
+		s.base.Log(ctx, logger.LevelWarn, "Failed to remove the subscriptions of session %s: %v", session.ID, err)
+		return
+	}
+	for _, uri := range uris {
+		subscribers, err := store.Subscribers(ctx, uri)
+		if err == nil && slices.Contains(subscribers, session.ID) {
+			if err = store.Remove(ctx, uri, session.ID); err == nil {
+				err = s.unwatch(ctx, store, uri)
+			}
+		}
+		if err != nil {
+			s.base.Log(ctx, logger.LevelWarn, "Failed to remove the subscription of session %s to %s: %v", session.ID, uri, err)
+		}
+	}
+}
+
+// findContentHandler returns the content handler or provider with the longest
+// prefix matching uri. The caller must hold s.mu.
+func (s *Server) findContentHandler(uri string) ContentHandler {
+	var handler ContentHandler
+	matched := -1
+	for prefix, h := range s.contentHandlers {
+		if len(prefix) > matched && urischeme.HasPrefix(uri, prefix) {
+			handler = h
+			matched = len(prefix)
+		}
+	}
+	for _, m := range s.providers {
+		if len(m.prefix) > matched && urischeme.HasPrefix(uri, m.prefix) {
+			handler = m.provider.Read
+			matched = len(m.prefix)
+		}
+	}
+	if handler != nil && s.text != nil {
+		handler = s.text.wrap(handler)
+	}
+	return handler
+}
+
+// findProvider returns the provider with the longest prefix matching uri,
+// or nil if none matches. The caller must hold s.mu.
+func (s *Server) findProvider(uri string) types.ResourceProvider {
+	var provider types.ResourceProvider
+	matched := -1
+	for _, m := range s.providers {
+		if len(m.prefix) > matched && urischeme.HasPrefix(uri, m.prefix) {
+			provider = m.provider
+			matched = len(m.prefix)
+		}
+	}
+	return provider
+}
+
+// normalizeURI validates a URI against the server's scheme registry and
+// returns its canonical form
+func (s *Server) normalizeURI(uri string) (string, error) {
+	s.mu.RLock()
+	schemes := s.schemes
+	s.mu.RUnlock()
+
+	normalized, err := schemes.Normalize(uri)
+	if err != nil {
+		return "", types.NewError(types.InvalidParams, err.Error())
+	}
+	return normalized, nil
+}
+
+// Static returns the resources and templates set on the server, without those
+// of providers
+func (s *Server) Static() ([]types.Resource, []types.ResourceTemplate) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]types.Resource{}, s.resources.Items()...), append([]types.ResourceTemplate{}, s.templates.Items()...)
+}
+
+// Subscriptions returns the subscribed URIs and patterns, sorted
+func (s *Server) Subscriptions(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	store := s.subscriptions
+	s.mu.RUnlock()
+	return store.URIs(ctx)
+}
+
+// Resubscribe subscribes to each of uris as if a client had, starting
+// provider watches again. It stops at the first URI that fails.
+func (s *Server) Resubscribe(ctx context.Context, uris []string) error {
+	for _, uri := range uris {
+		if _, err := s.handleSubscribe(ctx, types.SubscribeRequest{URI: uri}); err != nil {
+			return fmt.Errorf("failed to resubscribe to %s: %w", uri, err)
+		}
+	}
+	return nil
 }
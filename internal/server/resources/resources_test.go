@@ -3,13 +3,16 @@ package resources
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/dwrtz/mcp-go/internal/base"
 	"github.com/dwrtz/mcp-go/internal/mock"
 	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/providers/memory"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
@@ -130,6 +133,52 @@ func TestServer_ListResources(t *testing.T) {
 	}
 }
 
+func TestServer_ListResourcesFilter(t *testing.T) {
+	ctx, server, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := server.SetResources(ctx, []types.Resource{
+		{URI: "file:///project/src/main.rs", Name: "main.rs", MimeType: "text/x-rust"},
+		{URI: "file:///project/README.md", Name: "README.md", MimeType: "text/markdown"},
+		{URI: "file:///other/logo.png", Name: "logo.png", MimeType: "image/png"},
+	}); err != nil {
+		t.Fatalf("Failed to set resources: %v", err)
+	}
+
+	list := func(filter *types.ResourceFilter) []string {
+		t.Helper()
+		resp, err := client.SendRequest(ctx, methods.ListResources, &types.ListResourcesRequest{
+			Method: methods.ListResources,
+			Filter: filter,
+		})
+		if err != nil {
+			t.Fatalf("ListResources error: %v", err)
+		}
+		var result types.ListResourcesResult
+		if err := json.Unmarshal(*resp.Result, &result); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		var uris []string
+		for _, r := range result.Resources {
+			uris = append(uris, r.URI)
+		}
+		return uris
+	}
+
+	filter := &types.ResourceFilter{MimeType: "text/*", URIPrefix: "file:///project/", Query: "read"}
+	if got := list(filter); len(got) != 3 {
+		t.Errorf("Filter applied while disabled: got %v", got)
+	}
+
+	server.SetFiltering(true)
+	if got := list(filter); len(got) != 1 || got[0] != "file:///project/README.md" {
+		t.Errorf("Filtered resources = %v, want [file:///project/README.md]", got)
+	}
+	if got := list(&types.ResourceFilter{MimeType: "image/png"}); len(got) != 1 || got[0] != "file:///other/logo.png" {
+		t.Errorf("Filtered resources = %v, want [file:///other/logo.png]", got)
+	}
+}
+
 func TestServer_ReadResource(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -259,6 +308,57 @@ func TestServer_ReadResource(t *testing.T) {
 	}
 }
 
+func TestServer_SlowReadDoesNotBlockUpdates(t *testing.T) {
+	ctx, server, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server.RegisterContentHandler("file://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+		close(entered)
+		<-release
+		return []types.ResourceContent{
+			types.TextResourceContents{
+				ResourceContents: types.ResourceContents{URI: uri, MimeType: "text/plain"},
+				Text:             "slow",
+			},
+		}, nil
+	})
+
+	readDone := make(chan error, 1)
+	go func() {
+		_, err := client.SendRequest(ctx, methods.ReadResource, &types.ReadResourceRequest{
+			Method: methods.ReadResource,
+			URI:    "file:///test.txt",
+		})
+		readDone <- err
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for content handler")
+	}
+
+	updated := make(chan error, 1)
+	go func() {
+		updated <- server.SetResources(ctx, []types.Resource{{URI: "file:///other.txt", Name: "Other"}})
+	}()
+	select {
+	case err := <-updated:
+		if err != nil {
+			t.Errorf("SetResources() error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("SetResources blocked behind a running content handler")
+	}
+
+	close(release)
+	if err := <-readDone; err != nil {
+		t.Errorf("ReadResource error: %v", err)
+	}
+}
+
 func TestServer_ResourceNotifications(t *testing.T) {
 	ctx, server, client, cleanup := setupTest(t)
 	defer cleanup()
@@ -398,3 +498,320 @@ func TestServer_ListTemplates(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_ReadResourceURIValidation(t *testing.T) {
+	ctx, server, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	server.RegisterContentHandler("file://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+		return []types.ResourceContent{
+			types.TextResourceContents{
+				ResourceContents: types.ResourceContents{URI: uri},
+				Text:             "generic",
+			},
+		}, nil
+	})
+	server.RegisterContentHandler("file:///docs/", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+		return []types.ResourceContent{
+			types.TextResourceContents{
+				ResourceContents: types.ResourceContents{URI: uri},
+				Text:             "docs",
+			},
+		}, nil
+	})
+	server.RegisterContentHandler("memo://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+		return []types.ResourceContent{
+			types.TextResourceContents{
+				ResourceContents: types.ResourceContents{URI: uri},
+				Text:             "memo",
+			},
+		}, nil
+	})
+
+	tests := []struct {
+		name     string
+		uri      string
+		wantURI  string
+		wantText string
+		wantErr  bool
+	}{
+		{
+			name:     "longest prefix wins",
+			uri:      "file:///docs/readme.md",
+			wantURI:  "file:///docs/readme.md",
+			wantText: "docs",
+		},
+		{
+			name:     "URI is normalized before dispatch",
+			uri:      "file:///docs/../src/main.go",
+			wantURI:  "file:///src/main.go",
+			wantText: "generic",
+		},
+		{
+			name:     "content handler prefix allows its scheme",
+			uri:      "memo://notes/1",
+			wantURI:  "memo://notes/1",
+			wantText: "memo",
+		},
+		{
+			name:    "unknown scheme is rejected",
+			uri:     "invalid:///path",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &types.ReadResourceRequest{
+				Method: methods.ReadResource,
+				URI:    tt.uri,
+			}
+			resp, err := client.SendRequest(ctx, methods.ReadResource, req)
+			if tt.wantErr {
+				mcpErr, ok := err.(*types.ErrorResponse)
+				if !ok {
+					t.Fatalf("Expected MCP error, got %v", err)
+				}
+				if mcpErr.Code != types.InvalidParams {
+					t.Errorf("Expected error code %d, got %d", types.InvalidParams, mcpErr.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadResource error: %v", err)
+			}
+
+			var result types.ReadResourceResult
+			if err := json.Unmarshal(*resp.Result, &result); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			got, ok := result.Contents[0].(types.TextResourceContents)
+			if !ok {
+				t.Fatalf("Expected TextResourceContents, got %T", result.Contents[0])
+			}
+			if got.URI != tt.wantURI {
+				t.Errorf("URI mismatch: got %s, want %s", got.URI, tt.wantURI)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("Text mismatch: got %s, want %s", got.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestServer_ResourceProviders(t *testing.T) {
+	ctx, server, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	notes := memory.NewProvider()
+	notes.SetText(types.Resource{URI: "mem://notes/a", Name: "Note A", MimeType: "text/plain"}, "first")
+	notes.SetTemplates([]types.ResourceTemplate{
+		{URITemplate: "mem://notes/{id}", Name: "Note"},
+	})
+	if err := server.AddProvider(ctx, "mem://notes/", notes); err != nil {
+		t.Fatalf("AddProvider() error: %v", err)
+	}
+
+	// List merges static resources and provider resources
+	resp, err := client.SendRequest(ctx, methods.ListResources, &types.ListResourcesRequest{Method: methods.ListResources})
+	if err != nil {
+		t.Fatalf("ListResources error: %v", err)
+	}
+	var list types.ListResourcesResult
+	if err := json.Unmarshal(*resp.Result, &list); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(list.Resources) != 2 || list.Resources[1].URI != "mem://notes/a" {
+		t.Errorf("Unexpected resources: %+v", list.Resources)
+	}
+
+	resp, err = client.SendRequest(ctx, methods.ListResourceTemplates, &types.ListResourceTemplatesRequest{Method: methods.ListResourceTemplates})
+	if err != nil {
+		t.Fatalf("ListResourceTemplates error: %v", err)
+	}
+	var templates types.ListResourceTemplatesResult
+	if err := json.Unmarshal(*resp.Result, &templates); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(templates.ResourceTemplates) != 2 {
+		t.Errorf("Expected 2 templates, got %d", len(templates.ResourceTemplates))
+	}
+
+	// Reads are routed to the provider
+	resp, err = client.SendRequest(ctx, methods.ReadResource, &types.ReadResourceRequest{Method: methods.ReadResource, URI: "mem://notes/a"})
+	if err != nil {
+		t.Fatalf("ReadResource error: %v", err)
+	}
+	var read types.ReadResourceResult
+	if err := json.Unmarshal(*resp.Result, &read); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if txt, ok := read.Contents[0].(types.TextResourceContents); !ok || txt.Text != "first" {
+		t.Errorf("Unexpected contents: %+v", read.Contents)
+	}
+
+	// Subscriptions start a provider watch that drives update notifications
+	updated := make(chan string, 1)
+	client.RegisterNotificationHandler(methods.ResourceUpdated, func(ctx context.Context, params json.RawMessage) {
+		var notif types.ResourceUpdatedNotification
+		if err := json.Unmarshal(params, &notif); err != nil {
+			t.Errorf("Failed to unmarshal notification: %v", err)
+			return
+		}
+		updated <- notif.URI
+	})
+
+	if _, err := client.SendRequest(ctx, methods.SubscribeResource, &types.SubscribeRequest{Method: methods.SubscribeResource, URI: "mem://notes/a"}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	notes.SetText(types.Resource{URI: "mem://notes/a", Name: "Note A", MimeType: "text/plain"}, "second")
+
+	select {
+	case uri := <-updated:
+		if uri != "mem://notes/a" {
+			t.Errorf("Expected notification for mem://notes/a, got %s", uri)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for provider notification")
+	}
+
+	// Deleting a resource is an update too
+	notes.Delete("mem://notes/a")
+	select {
+	case uri := <-updated:
+		if uri != "mem://notes/a" {
+			t.Errorf("Expected notification for mem://notes/a, got %s", uri)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for delete notification")
+	}
+
+	// Unsubscribing cancels the provider watch
+	if _, err := client.SendRequest(ctx, methods.UnsubscribeResource, &types.UnsubscribeRequest{Method: methods.UnsubscribeResource, URI: "mem://notes/a"}); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	notes.SetText(types.Resource{URI: "mem://notes/a", Name: "Note A", MimeType: "text/plain"}, "third")
+
+	select {
+	case uri := <-updated:
+		t.Errorf("Received unexpected notification for %s after unsubscribe", uri)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// watchProvider is a provider recording the contexts of its watches
+type watchProvider struct {
+	watches chan context.Context
+}
+
+func (p *watchProvider) List(ctx context.Context) ([]types.Resource, error) { return nil, nil }
+
+func (p *watchProvider) Templates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	return nil, nil
+}
+
+func (p *watchProvider) Read(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+	return nil, types.NewError(types.InvalidParams, "resource not found: "+uri)
+}
+
+func (p *watchProvider) Subscribe(ctx context.Context, uri string, notify types.ResourceUpdateFunc) (func(), error) {
+	p.watches <- ctx
+	return func() {}, nil
+}
+
+func TestServer_ProviderWatchLifetime(t *testing.T) {
+	ctx, server, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	provider := &watchProvider{watches: make(chan context.Context, 1)}
+	if err := server.AddProvider(ctx, "mem://watch/", provider); err != nil {
+		t.Fatalf("AddProvider() error: %v", err)
+	}
+	subscribe := func() context.Context {
+		t.Helper()
+		if _, err := client.SendRequest(ctx, methods.SubscribeResource, &types.SubscribeRequest{Method: methods.SubscribeResource, URI: "mem://watch/a"}); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+		return <-provider.watches
+	}
+
+	// The watch outlives the subscribe request, until unsubscribe
+	watchCtx := subscribe()
+	if err := watchCtx.Err(); err != nil {
+		t.Fatalf("Watch context done after subscribing: %v", err)
+	}
+	if _, err := client.SendRequest(ctx, methods.UnsubscribeResource, &types.UnsubscribeRequest{Method: methods.UnsubscribeResource, URI: "mem://watch/a"}); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if watchCtx.Err() == nil {
+		t.Error("Watch context not done after unsubscribing")
+	}
+
+	// Or until the session that subscribed ends
+	watchCtx = subscribe()
+	server.endSession(&mcp.Session{ID: "other"})
+	if err := watchCtx.Err(); err != nil {
+		t.Fatalf("Watch context done after another session ended: %v", err)
+	}
+	server.endSession(&mcp.Session{ID: server.sessionID(ctx)})
+	if watchCtx.Err() == nil {
+		t.Error("Watch context not done after its session ended")
+	}
+
+	// Subscribing again starts a new watch
+	if watchCtx = subscribe(); watchCtx.Err() != nil {
+		t.Errorf("Watch context done after subscribing again: %v", watchCtx.Err())
+	}
+}
+
+func TestServer_SubscriptionsPerSession(t *testing.T) {
+	ctx, server, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	provider := &watchProvider{watches: make(chan context.Context, 1)}
+	if err := server.AddProvider(ctx, "mem://watch/", provider); err != nil {
+		t.Fatalf("AddProvider() error: %v", err)
+	}
+	one := mcp.WithSession(ctx, &mcp.Session{ID: "one"})
+	two := mcp.WithSession(ctx, &mcp.Session{ID: "two"})
+	subscribers := func() []string {
+		t.Helper()
+		subs, err := server.subscriptions.Subscribers(ctx, "mem://watch/a")
+		if err != nil {
+			t.Fatalf("Subscribers() error: %v", err)
+		}
+		return subs
+	}
+
+	// Subscribing twice from one session stores it once
+	for _, sessionCtx := range []context.Context{one, one, two} {
+		if _, err := server.handleSubscribe(sessionCtx, types.SubscribeRequest{URI: "mem://watch/a"}); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+	watchCtx := <-provider.watches
+	if subs := subscribers(); fmt.Sprint(subs) != "[one two]" {
+		t.Errorf("Subscribers() = %v, want [one two]", subs)
+	}
+
+	// Unsubscribing from another session keeps the first one's subscription
+	// and watch
+	if _, err := server.handleUnsubscribe(two, types.UnsubscribeRequest{URI: "mem://watch/a"}); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if subs := subscribers(); fmt.Sprint(subs) != "[one]" {
+		t.Errorf("Subscribers() after unsubscribing two = %v, want [one]", subs)
+	}
+	if err := watchCtx.Err(); err != nil {
+		t.Errorf("Watch context done while subscribed: %v", err)
+	}
+
+	// The end of the last subscriber's session removes its subscription
+	server.endSession(&mcp.Session{ID: "one"})
+	if subs := subscribers(); len(subs) != 0 {
+		t.Errorf("Subscribers() after session one ended = %v, want none", subs)
+	}
+	if watchCtx.Err() == nil {
+		t.Error("Watch context not done after the last subscriber's session ended")
+	}
+}
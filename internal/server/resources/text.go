@@ -0,0 +1,58 @@
+package resources
+
+import (
+	"context"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dwrtz/mcp-go/pkg/mimetype"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// textNormalizer converts text contents to UTF-8, see SetTextNormalization
+type textNormalizer struct {
+	blobFallback bool
+}
+
+// wrap returns a handler that normalizes the text contents handler returns
+func (n *textNormalizer) wrap(handler ContentHandler) ContentHandler {
+	return func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+		contents, err := handler(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range contents {
+			if text, ok := c.(types.TextResourceContents); ok {
+				contents[i] = n.normalize(text)
+			}
+		}
+		return contents, nil
+	}
+}
+
+// normalize returns text with its Text decoded to UTF-8 from the charset of
+// its MIME type or a byte order mark. Valid UTF-8 without a BOM or another
+// declared charset is kept.
+func (n *textNormalizer) normalize(text types.TextResourceContents) types.ResourceContent {
+	charset := ""
+	if _, params, err := mime.ParseMediaType(text.MimeType); err == nil {
+		charset = strings.ToLower(params["charset"])
+	}
+	if (charset == "" || charset == "utf-8") && utf8.ValidString(text.Text) && !strings.HasPrefix(text.Text, "\uFEFF") {
+		return text
+	}
+	data := []byte(text.Text)
+	decoded, err := mimetype.DecodeText(data, text.MimeType)
+	switch {
+	case err == nil:
+		text.Text = decoded
+		text.MimeType = mimetype.WithUTF8Charset(text.MimeType)
+		return text
+	case n.blobFallback:
+		return types.NewBlobContents(text.URI, text.MimeType, data)
+	default:
+		text.Text = strings.ToValidUTF8(text.Text, "\uFFFD")
+		return text
+	}
+}
@@ -12,7 +12,8 @@ import (
 
 // Server provides server-side roots functionality
 type Server struct {
-	base *base.Base
+	base     *base.Base
+	validate types.RootValidator
 }
 
 // NewServer creates a new Server
@@ -20,6 +21,12 @@ func NewServer(base *base.Base) *Server {
 	return &Server{base: base}
 }
 
+// SetValidator sets a validator applied to roots listed by the client. Roots
+// are not validated by default.
+func (s *Server) SetValidator(validate types.RootValidator) {
+	s.validate = validate
+}
+
 // ListRoots requests the list of available roots from the client
 func (s *Server) ListRoots(ctx context.Context) ([]types.Root, error) {
 	req := &types.ListRootsRequest{
@@ -42,12 +49,20 @@ func (s *Server) ListRoots(ctx context.Context) ([]types.Root, error) {
 		return nil, fmt.Errorf("failed to parse roots list response: %w", err)
 	}
 
+	if s.validate != nil {
+		for _, root := range result.Roots {
+			if err := s.validate(root); err != nil {
+				return nil, fmt.Errorf("client listed invalid root %s: %w", root.URI, err)
+			}
+		}
+	}
+
 	return result.Roots, nil
 }
 
 // OnRootsChanged registers a callback to be called when the roots list changes
 func (s *Server) OnRootsChanged(callback func()) {
-	s.base.RegisterNotificationHandler(methods.RootsChanged, func(ctx context.Context, params json.RawMessage) {
+	base.HandleNotification(s.base, methods.RootsChanged, func(ctx context.Context, _ types.RootsListChangedNotification) {
 		callback()
 	})
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/dwrtz/mcp-go/internal/testutil"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
 )
 
 func setupTest(t *testing.T) (context.Context, *Server, *base.Base, func()) {
@@ -41,6 +42,7 @@ func TestServer_List(t *testing.T) {
 	tests := []struct {
 		name           string
 		clientResponse *types.ListRootsResult
+		validate       types.RootValidator
 		wantErr        bool
 	}{
 		{
@@ -66,12 +68,39 @@ func TestServer_List(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "non-file roots accepted without validator",
+			clientResponse: &types.ListRootsResult{
+				Roots: []types.Root{{URI: "git://example.com/repo.git", Name: "Repo"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "non-file roots rejected by validator",
+			clientResponse: &types.ListRootsResult{
+				Roots: []types.Root{{URI: "git://example.com/repo.git", Name: "Repo"}},
+			},
+			validate: types.DefaultRootValidator,
+			wantErr:  true,
+		},
+		{
+			name: "roots in allowed schemes",
+			clientResponse: &types.ListRootsResult{
+				Roots: []types.Root{
+					{URI: "file:///project", Name: "Project"},
+					{URI: "git://example.com/repo.git", Name: "Repo"},
+				},
+			},
+			validate: types.RootSchemes(urischeme.File, urischeme.Git),
+			wantErr:  false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, server, clientBase, cleanup := setupTest(t)
 			defer cleanup()
+			server.SetValidator(tt.validate)
 
 			// Register handler on client side to respond to server's list request
 			clientBase.RegisterRequestHandler(methods.ListRoots, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
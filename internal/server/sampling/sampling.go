@@ -22,6 +22,10 @@ func NewServer(base *base.Base) *Server {
 
 // CreateMessage requests a sample from the language model
 func (s *Server) CreateMessage(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, types.InvalidParamsError(methods.SampleCreate, err)
+	}
+
 	resp, err := s.base.SendRequest(ctx, methods.SampleCreate, req)
 	if err != nil {
 		return nil, err
@@ -38,14 +38,6 @@ func setupTest(t *testing.T) (context.Context, *Server, *base.Base, func()) {
 
 // mockSamplingHandler provides a basic mock implementation for testing
 func mockSamplingHandler(_ context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
-	// Validate basic requirements
-	if len(req.Messages) == 0 {
-		return nil, types.NewError(types.InvalidParams, "messages array cannot be empty")
-	}
-	if req.MaxTokens <= 0 {
-		return nil, types.NewError(types.InvalidParams, "maxTokens must be positive")
-	}
-
 	// Create a standard response
 	return &types.CreateMessageResult{
 		Role: types.RoleAssistant,
@@ -65,7 +57,7 @@ func TestServer_CreateMessage(t *testing.T) {
 		modelPref *types.ModelPreferences
 		maxTokens int
 		wantErr   bool
-		errMsg    string
+		errField  string
 	}{
 		{
 			name: "successful message creation",
@@ -93,7 +85,7 @@ func TestServer_CreateMessage(t *testing.T) {
 			messages:  []types.SamplingMessage{},
 			maxTokens: 100,
 			wantErr:   true,
-			errMsg:    "messages array cannot be empty",
+			errField:  "messages",
 		},
 		{
 			name: "invalid max tokens",
@@ -108,7 +100,7 @@ func TestServer_CreateMessage(t *testing.T) {
 			},
 			maxTokens: 0,
 			wantErr:   true,
-			errMsg:    "maxTokens must be positive",
+			errField:  "maxTokens",
 		},
 	}
 
@@ -146,12 +138,16 @@ func TestServer_CreateMessage(t *testing.T) {
 			}
 
 			if tt.wantErr {
-				if mcpErr, ok := err.(*types.ErrorResponse); ok {
-					if mcpErr.Message != tt.errMsg {
-						t.Errorf("Expected error message %q, got %q", tt.errMsg, mcpErr.Message)
-					}
-				} else {
-					t.Errorf("Expected MCP error, got %T", err)
+				mcpErr, ok := err.(*types.ErrorResponse)
+				if !ok {
+					t.Fatalf("Expected MCP error, got %T", err)
+				}
+				if mcpErr.Code != types.InvalidParams {
+					t.Errorf("Expected InvalidParams, got code %d", mcpErr.Code)
+				}
+				fields, _ := mcpErr.Data.([]types.FieldError)
+				if len(fields) != 1 || fields[0].Field != tt.errField {
+					t.Errorf("Expected invalid field %q, got %+v", tt.errField, mcpErr.Data)
 				}
 				return
 			}
@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// ResultCache memoizes the results of tools annotated as idempotent, keyed by
+// tool name and arguments. Error results are not cached. The least recently
+// used results are evicted to stay within the size limits.
+type ResultCache struct {
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries, most recently used first
+	order *list.List
+	size  int
+}
+
+type cacheEntry struct {
+	key     string
+	result  *types.CallToolResult
+	size    int
+	expires time.Time
+}
+
+// NewResultCache creates a cache whose results expire after ttl. It holds at
+// most maxEntries results of at most maxBytes of content in total; limits
+// <= 0 are unbounded.
+func NewResultCache(ttl time.Duration, maxEntries, maxBytes int) *ResultCache {
+	return &ResultCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// cacheKey canonicalizes a call; encoding/json sorts map keys, so equal
+// arguments encode the same
+func cacheKey(tool string, arguments map[string]interface{}) (string, bool) {
+	if len(arguments) == 0 {
+		return tool + "\x00{}", true
+	}
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return "", false
+	}
+	return tool + "\x00" + string(data), true
+}
+
+// get returns the unexpired result cached under key
+func (c *ResultCache) get(key string) (*types.CallToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.result, true
+}
+
+// put caches result under key, evicting the least recently used results
+// beyond the limits
+func (c *ResultCache) put(key string, result *types.CallToolResult) {
+	size := 0
+	for _, content := range result.Content {
+		size += contentSize(content)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	entry := &cacheEntry{key: key, result: result, size: size, expires: c.now().Add(c.ttl)}
+	c.entries[key] = c.order.PushFront(entry)
+	c.size += size
+	for (c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.size > c.maxBytes) {
+		c.remove(c.order.Back())
+	}
+}
+
+// clear drops every cached result
+func (c *ResultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.size = 0
+}
+
+func (c *ResultCache) remove(el *list.Element) {
+	entry := c.order.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// call returns the cached result of the call or runs handler, caching its
+// result. Requests whose _meta sets types.NoCacheMetaKey run handler and
+// replace the cached result.
+func (c *ResultCache) call(ctx context.Context, req types.CallToolRequest, handler types.ToolHandler) (*types.CallToolResult, error) {
+	key, ok := cacheKey(req.Name, req.Arguments)
+	if !ok {
+		return handler(ctx, req.Arguments)
+	}
+	if noCache, _ := mcp.PeerMeta(ctx)[types.NoCacheMetaKey].(bool); !noCache {
+		if result, ok := c.get(key); ok {
+			return result, nil
+		}
+	}
+	result, err := handler(ctx, req.Arguments)
+	if err == nil && result != nil && !result.IsError {
+		c.put(key, result)
+	}
+	return result, err
+}
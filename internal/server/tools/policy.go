@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// policyOf returns the execution policy of tool, or nil
+func policyOf(tool types.McpTool) *types.ToolExecutionPolicy {
+	if pt, ok := tool.(types.PolicyTool); ok {
+		return pt.ExecutionPolicy()
+	}
+	return nil
+}
+
+// enforcePolicy wraps the handler of the named tool to run under policy.
+// Calls get the policy in their context, for package sandbox. With a
+// timeout, a call that runs out of time gets an error result, even if the
+// handler does not return; its context is canceled, so it can stop.
+func enforcePolicy(name string, policy types.ToolExecutionPolicy, handler types.ToolHandler) types.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*types.CallToolResult, error) {
+		ctx = types.WithExecutionPolicy(ctx, policy)
+		if policy.Timeout <= 0 {
+			return handler(ctx, arguments)
+		}
+
+		parent := ctx
+		ctx, cancel := context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+		type outcome struct {
+			result *types.CallToolResult
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			defer func() {
+				// The request's own recovery does not cover this goroutine
+				if r := recover(); r != nil {
+					done <- outcome{err: fmt.Errorf("tool %s panicked: %v", name, r)}
+				}
+			}()
+			result, err := handler(ctx, arguments)
+			done <- outcome{result, err}
+		}()
+
+		select {
+		case o := <-done:
+			return o.result, o.err
+		case <-ctx.Done():
+			if err := parent.Err(); err != nil {
+				return nil, err
+			}
+			return &types.CallToolResult{
+				Content: []interface{}{types.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("tool %s timed out after %s", name, policy.Timeout),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/paging"
+	"github.com/dwrtz/mcp-go/pkg/logger"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
@@ -16,77 +20,402 @@ type Server struct {
 	base *base.Base
 	mu   sync.RWMutex
 
-	tools        []types.Tool
+	tools        *paging.Index[types.Tool]
 	toolHandlers map[string]types.ToolHandler
+	pageSize     int
+	signer       *paging.Signer
+	allowFilter  bool
+
+	// Bounds the size of call results; MaxBytes <= 0 means no limit
+	limit ResultLimit
+
+	// Memoizes the results of the tools in idempotent; nil disables it
+	cache      *ResultCache
+	idempotent map[string]bool
+
+	// The deprecated tools, whose calls are logged and, with warn, get a
+	// warning added to their results
+	deprecated map[string]types.Tool
+	warn       bool
+
+	// The tools last set, and the builtins listed after them
+	userTools []types.McpTool
+	builtins  []types.McpTool
+
+	// Prepares the context of each tool call; nil leaves it as is
+	callContext func(ctx context.Context, tool string) context.Context
 }
 
-// NewServer creates a new Server
-func NewServer(base *base.Base, initialTools []types.McpTool) *Server {
-	var newTools []types.Tool
-	newToolHandlers := make(map[string]types.ToolHandler)
+// ResultLimit bounds the size of the content in tool call results
+type ResultLimit struct {
+	// MaxBytes is the most content a result may carry
+	MaxBytes int
 
-	for _, tool := range initialTools {
-		newTools = append(newTools, tool.GetDefinition())
-		newToolHandlers[tool.GetName()] = tool.GetHandler()
-	}
+	// Truncation selects how oversized results are handled
+	Truncation types.ResultTruncation
 
-	s := &Server{
-		base:         base,
-		tools:        newTools,
-		toolHandlers: newToolHandlers,
-	}
-	base.RegisterRequestHandler(methods.ListTools, s.handleListTools)
-	base.RegisterRequestHandler(methods.CallTool, s.handleCallTool)
+	// Spill stores the full text of an oversized result and returns the URI
+	// it can be read from. Used with types.SpillResult; if nil or failing,
+	// the result is truncated instead.
+	Spill func(ctx context.Context, tool string, text string) (string, error)
+}
+
+// NewServer creates a new Server
+func NewServer(b *base.Base, initialTools []types.McpTool) *Server {
+	s := &Server{base: b}
+	s.install(initialTools)
+	base.HandleRequest(b, methods.ListTools, s.handleListTools)
+	base.HandleRequest(b, methods.CallTool, s.handleCallTool)
 	return s
 }
 
 // SetTools updates the list of available tools
 func (s *Server) SetTools(ctx context.Context, tools []types.McpTool) error {
+	s.mu.Lock()
+	s.install(tools)
+	cache := s.cache
+	s.mu.Unlock()
+	if cache != nil {
+		cache.clear()
+	}
+
+	if s.base.Started {
+		return s.base.SendNotification(ctx, methods.ToolsChanged, nil)
+	}
+	return nil
+}
+
+// SetDefinitions replaces the definitions of the tools last set with those
+// in defs of the same name, keeping their handlers. Definitions of tools
+// that are not set are ignored.
+func (s *Server) SetDefinitions(ctx context.Context, defs []types.Tool) error {
+	byName := make(map[string]types.Tool, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+
+	s.mu.RLock()
+	tools := make([]types.McpTool, len(s.userTools))
+	for i, tool := range s.userTools {
+		if def, ok := byName[tool.GetName()]; ok {
+			tool = definedTool{McpTool: tool, def: def}
+		}
+		tools[i] = tool
+	}
+	s.mu.RUnlock()
+	return s.SetTools(ctx, tools)
+}
+
+// definedTool is a tool with a replaced definition
+type definedTool struct {
+	types.McpTool
+	def types.Tool
+}
+
+func (t definedTool) GetDescription() string    { return t.def.Description }
+func (t definedTool) GetDefinition() types.Tool { return t.def }
+
+// ExecutionPolicy keeps the policy of the tool, see policy.go
+func (t definedTool) ExecutionPolicy() *types.ToolExecutionPolicy { return policyOf(t.McpTool) }
+
+// SetResultLimit sets the size limit applied to tool call results
+func (s *Server) SetResultLimit(limit ResultLimit) {
+	s.mu.Lock()
+	s.limit = limit
+	s.mu.Unlock()
+}
+
+// SetBuiltins sets tools listed after those passed to SetTools, which keeps
+// them. It must be called before the server starts.
+func (s *Server) SetBuiltins(builtins []types.McpTool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.builtins = builtins
+	s.install(s.userTools)
+}
+
+// install makes tools and the builtins the available tools. The caller holds
+// mu or has not shared s yet.
+func (s *Server) install(tools []types.McpTool) {
 	var newTools []types.Tool
 	newToolHandlers := make(map[string]types.ToolHandler)
 
-	for _, tool := range tools {
+	for _, tool := range append(append([]types.McpTool{}, tools...), s.builtins...) {
 		newTools = append(newTools, tool.GetDefinition())
-		newToolHandlers[tool.GetName()] = tool.GetHandler()
+		handler := tool.GetHandler()
+		if policy := policyOf(tool); policy != nil {
+			handler = enforcePolicy(tool.GetName(), *policy, handler)
+		}
+		newToolHandlers[tool.GetName()] = handler
 	}
 
-	s.mu.Lock()
-	s.tools = newTools
+	s.userTools = tools
+	s.tools = paging.NewIndex(newTools, toolName)
 	s.toolHandlers = newToolHandlers
+	s.idempotent = idempotentTools(newTools)
+	s.deprecated = deprecatedTools(newTools)
+}
+
+// SetCallContext sets a function that prepares the context of each call to
+// the named tool
+func (s *Server) SetCallContext(prepare func(ctx context.Context, tool string) context.Context) {
+	s.mu.Lock()
+	s.callContext = prepare
 	s.mu.Unlock()
+}
 
-	if s.base.Started {
-		return s.base.SendNotification(ctx, methods.ToolsChanged, nil)
+// SetCache memoizes the results of tools annotated as idempotent in cache;
+// nil disables caching
+func (s *Server) SetCache(cache *ResultCache) {
+	s.mu.Lock()
+	s.cache = cache
+	s.mu.Unlock()
+}
+
+// idempotentTools returns the names of the tools annotated as idempotent
+func idempotentTools(tools []types.Tool) map[string]bool {
+	names := make(map[string]bool)
+	for _, t := range tools {
+		if t.Annotations.Idempotent() {
+			names[t.Name] = true
+		}
 	}
-	return nil
+	return names
+}
+
+// SetDeprecationWarnings adds a warning to the results of deprecated tools,
+// naming their replacement, so models move off them
+func (s *Server) SetDeprecationWarnings(enabled bool) {
+	s.mu.Lock()
+	s.warn = enabled
+	s.mu.Unlock()
+}
+
+// deprecatedTools returns the tools marked as deprecated by name
+func deprecatedTools(tools []types.Tool) map[string]types.Tool {
+	deprecated := make(map[string]types.Tool)
+	for _, t := range tools {
+		if t.Deprecated() != "" {
+			deprecated[t.Name] = t
+		}
+	}
+	return deprecated
 }
 
-func (s *Server) handleListTools(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+// deprecationWarning describes the deprecation of tool
+func deprecationWarning(tool types.Tool) string {
+	warning := fmt.Sprintf("Warning: tool %s is deprecated: %s.", tool.Name, tool.Deprecated())
+	if replacement := tool.ReplacedBy(); replacement != "" {
+		warning += fmt.Sprintf(" Use %s instead.", replacement)
+	}
+	return warning
+}
+
+// Tools returns the currently registered tool definitions
+func (s *Server) Tools() []types.Tool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return append([]types.Tool{}, s.tools.Items()...)
+}
 
-	return &types.ListToolsResult{
-		Tools: s.tools,
-	}, nil
+// SetPageSize sets the number of tools per tools/list page; n <= 0 lists
+// all tools at once
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	s.pageSize = n
+	s.mu.Unlock()
 }
 
-func (s *Server) handleCallTool(ctx context.Context, params *json.RawMessage) (interface{}, error) {
-	if params == nil {
-		return nil, types.NewError(types.InvalidParams, "missing params")
+// SetCursorSigner signs the cursors of tools/list pages with signer; nil
+// leaves them plain
+func (s *Server) SetCursorSigner(signer *paging.Signer) {
+	s.mu.Lock()
+	s.signer = signer
+	s.mu.Unlock()
+}
+
+// SetFiltering makes tools/list honor the filter of requests. When disabled,
+// filters are ignored and every tool is listed.
+func (s *Server) SetFiltering(enabled bool) {
+	s.mu.Lock()
+	s.allowFilter = enabled
+	s.mu.Unlock()
+}
+
+func (s *Server) handleListTools(ctx context.Context, req types.ListToolsRequest) (*types.ListToolsResult, error) {
+	s.mu.RLock()
+	tools, size, signer, filter := s.tools, s.pageSize, s.signer, s.allowFilter
+	s.mu.RUnlock()
+
+	if filter && req.Filter != nil {
+		var matched []types.Tool
+		for _, t := range tools.Items() {
+			if req.Filter.Matches(t) {
+				matched = append(matched, t)
+			}
+		}
+		tools = paging.NewIndex(matched, toolName)
 	}
 
-	var req types.CallToolRequest
-	if err := json.Unmarshal(*params, &req); err != nil {
+	page, next, err := tools.Page(req.Cursor, size, signer.For(methods.ListTools))
+	if err != nil {
 		return nil, err
 	}
+	return &types.ListToolsResult{
+		Tools:      page,
+		NextCursor: next,
+	}, nil
+}
 
+func toolName(t types.Tool) string { return t.Name }
+
+func (s *Server) handleCallTool(ctx context.Context, req types.CallToolRequest) (*types.CallToolResult, error) {
 	s.mu.RLock()
 	handler, exists := s.toolHandlers[req.Name]
+	limit := s.limit
+	cache := s.cache
+	if !s.idempotent[req.Name] {
+		cache = nil
+	}
+	prepare := s.callContext
+	deprecated, isDeprecated := s.deprecated[req.Name]
+	warn := s.warn && isDeprecated
 	s.mu.RUnlock()
 
 	if !exists {
-		return nil, fmt.Errorf("no handler found for tool: %s", req.Name)
+		return nil, types.NewError(types.InvalidParams, fmt.Sprintf("no handler found for tool: %s", req.Name))
+	}
+	if isDeprecated {
+		s.base.Log(ctx, logger.LevelWarn, "Deprecated tool called: %s", deprecationWarning(deprecated))
 	}
+	if prepare != nil {
+		ctx = prepare(ctx, req.Name)
+	}
+
+	var result *types.CallToolResult
+	var err error
+	if cache != nil {
+		result, err = cache.call(ctx, req, handler)
+	} else {
+		result, err = handler(ctx, req.Arguments)
+	}
+	if err != nil || result == nil {
+		return result, err
+	}
+	if limit.MaxBytes > 0 {
+		result = limit.apply(ctx, req.Name, result)
+	}
+	if warn {
+		// Copied, as the result may be cached
+		warned := *result
+		warned.Content = append([]interface{}{types.TextContent{Type: "text", Text: deprecationWarning(deprecated)}}, result.Content...)
+		result = &warned
+	}
+	return result, nil
+}
+
+// apply enforces the limit on the result of the named tool
+func (l ResultLimit) apply(ctx context.Context, tool string, result *types.CallToolResult) *types.CallToolResult {
+	size := 0
+	for _, c := range result.Content {
+		size += contentSize(c)
+	}
+	if size <= l.MaxBytes {
+		return result
+	}
+
+	switch l.Truncation {
+	case types.RejectResult:
+		return &types.CallToolResult{
+			Content: []interface{}{types.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("tool %s returned %d bytes of content, over the limit of %d", tool, size, l.MaxBytes),
+			}},
+			IsError: true,
+		}
+	case types.SpillResult:
+		if l.Spill != nil {
+			uri, err := l.Spill(ctx, tool, resultText(result))
+			if err == nil {
+				return l.truncate(result, size, fmt.Sprintf("; full output at %s", uri))
+			}
+		}
+	}
+	return l.truncate(result, size, "")
+}
+
+// truncate keeps content up to the limit, cutting the text that crosses it,
+// and appends a marker noting how much was left out. Structured content
+// cannot be cut, so it is left out too.
+func (l ResultLimit) truncate(result *types.CallToolResult, size int, note string) *types.CallToolResult {
+	budget := l.MaxBytes
+	var kept []interface{}
+	for _, c := range result.Content {
+		n := contentSize(c)
+		if n <= budget {
+			kept = append(kept, c)
+			budget -= n
+			continue
+		}
+		if text, ok := textOf(c); ok && budget > 0 {
+			kept = append(kept, types.TextContent{Type: "text", Text: cutText(text, budget)})
+		}
+		budget = 0
+	}
+
+	omitted := size - (l.MaxBytes - budget)
+	kept = append(kept, types.TextContent{
+		Type: "text",
+		Text: fmt.Sprintf("[truncated %d bytes%s]", omitted, note),
+	})
+	return &types.CallToolResult{Content: kept, IsError: result.IsError, Meta: result.Meta}
+}
+
+// contentSize is the number of bytes a content item contributes
+func contentSize(c interface{}) int {
+	switch c := c.(type) {
+	case types.TextContent:
+		return len(c.Text)
+	case *types.TextContent:
+		return len(c.Text)
+	case types.ImageContent:
+		return len(c.Data)
+	case *types.ImageContent:
+		return len(c.Data)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
 
-	return handler(ctx, req.Arguments)
+// textOf returns the text of a text content item
+func textOf(c interface{}) (string, bool) {
+	switch c := c.(type) {
+	case types.TextContent:
+		return c.Text, true
+	case *types.TextContent:
+		return c.Text, true
+	}
+	return "", false
+}
+
+// resultText joins the text content of a result
+func resultText(result *types.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := textOf(c); ok {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// cutText shortens text to at most n bytes without splitting a rune
+func cutText(text string, n int) string {
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
 }
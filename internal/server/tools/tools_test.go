@@ -9,6 +9,7 @@ import (
 	"github.com/dwrtz/mcp-go/internal/base"
 	"github.com/dwrtz/mcp-go/internal/mock"
 	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/methods"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
@@ -238,3 +239,124 @@ func TestServer_CallTool_NotFound(t *testing.T) {
 		t.Errorf("Unexpected error message: %v", mcpErr.Message)
 	}
 }
+
+func TestServer_CallTool_Cache(t *testing.T) {
+	ctx, toolsServer, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	calls := map[string]int{}
+	newTool := func(name string, idempotent bool) types.McpTool {
+		tool := types.NewTool[EchoInput](name, "Counts its calls",
+			func(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+				calls[name]++
+				return &types.CallToolResult{
+					Content: []interface{}{types.TextContent{Type: "text", Text: input.Value}},
+					IsError: input.Value == "fail",
+				}, nil
+			})
+		return tool.WithAnnotations(types.ToolAnnotations{IdempotentHint: &idempotent})
+	}
+	if err := toolsServer.SetTools(ctx, []types.McpTool{newTool("search", true), newTool("post", false)}); err != nil {
+		t.Fatalf("Failed to set tools: %v", err)
+	}
+	now := time.Now()
+	cache := NewResultCache(time.Minute, 2, 0)
+	cache.now = func() time.Time { return now }
+	toolsServer.SetCache(cache)
+
+	call := func(ctx context.Context, name string, args map[string]interface{}) {
+		t.Helper()
+		if _, err := toolsServer.handleCallTool(ctx, types.CallToolRequest{Name: name, Arguments: args}); err != nil {
+			t.Fatalf("Failed to call %s: %v", name, err)
+		}
+	}
+	expectCalls := func(name string, want int) {
+		t.Helper()
+		if calls[name] != want {
+			t.Errorf("%s ran %d times, want %d", name, calls[name], want)
+		}
+	}
+
+	call(ctx, "search", map[string]interface{}{"value": "a", "extra": 1})
+	call(ctx, "search", map[string]interface{}{"extra": 1, "value": "a"})
+	expectCalls("search", 1)
+
+	// Only idempotent tools are cached
+	call(ctx, "post", map[string]interface{}{"value": "a"})
+	call(ctx, "post", map[string]interface{}{"value": "a"})
+	expectCalls("post", 2)
+
+	// Error results are not cached
+	call(ctx, "search", map[string]interface{}{"value": "fail"})
+	call(ctx, "search", map[string]interface{}{"value": "fail"})
+	expectCalls("search", 3)
+
+	// _meta can bypass the cache
+	call(mcp.WithPeerMeta(ctx, map[string]interface{}{types.NoCacheMetaKey: true}), "search", map[string]interface{}{"value": "a", "extra": 1})
+	expectCalls("search", 4)
+
+	// Results expire
+	now = now.Add(time.Minute)
+	call(ctx, "search", map[string]interface{}{"value": "a", "extra": 1})
+	expectCalls("search", 5)
+
+	// The least recently used result is evicted beyond two
+	call(ctx, "search", map[string]interface{}{"value": "b"})
+	call(ctx, "search", map[string]interface{}{"value": "c"})
+	call(ctx, "search", map[string]interface{}{"value": "c"})
+	call(ctx, "search", map[string]interface{}{"value": "a", "extra": 1})
+	expectCalls("search", 8)
+
+	// Setting the tools clears the cache
+	if err := toolsServer.SetTools(ctx, []types.McpTool{newTool("search", true)}); err != nil {
+		t.Fatalf("Failed to set tools: %v", err)
+	}
+	call(ctx, "search", map[string]interface{}{"value": "c"})
+	expectCalls("search", 9)
+}
+
+func TestServer_CallTool_Policy(t *testing.T) {
+	ctx, toolsServer, client, cleanup := setupTest(t)
+	defer cleanup()
+
+	policy := types.ToolExecutionPolicy{Timeout: 50 * time.Millisecond, Network: types.NetworkDenied}
+	gotPolicy := make(chan types.ToolExecutionPolicy, 1)
+	slowTool := types.NewTool[EchoInput](
+		"slow_tool",
+		"Waits for its context to end",
+		func(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+			p, _ := types.ExecutionPolicyFromContext(ctx)
+			gotPolicy <- p
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	).WithExecutionPolicy(policy)
+
+	if err := toolsServer.SetTools(ctx, []types.McpTool{slowTool}); err != nil {
+		t.Fatalf("Failed to set tools: %v", err)
+	}
+
+	callReq := &types.CallToolRequest{
+		Method:    methods.CallTool,
+		Name:      "slow_tool",
+		Arguments: map[string]interface{}{"value": "x"},
+	}
+	callResp, err := client.SendRequest(ctx, methods.CallTool, callReq)
+	if err != nil {
+		t.Fatalf("Failed to call tool: %v", err)
+	}
+	if p := <-gotPolicy; p != policy {
+		t.Errorf("Handler got policy %+v, want %+v", p, policy)
+	}
+
+	var callResult types.CallToolResult
+	if err := json.Unmarshal(*callResp.Result, &callResult); err != nil {
+		t.Fatalf("Failed to unmarshal call result: %v", err)
+	}
+	if !callResult.IsError || len(callResult.Content) != 1 {
+		t.Fatalf("Expected a timeout error result, got %+v", callResult)
+	}
+	if text := callResult.Content[0].(map[string]interface{})["text"]; text != "tool slow_tool timed out after 50ms" {
+		t.Errorf("Expected timeout text, got '%v'", text)
+	}
+}
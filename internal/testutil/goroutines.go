@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakTimeout is how long goroutines are given to exit after a test
+const leakTimeout = 2 * time.Second
+
+// VerifyTestMain runs the tests of m, then fails them if goroutines they
+// started are still running, listing their stacks, and exits. Call it from
+// TestMain.
+func VerifyTestMain(m *testing.M) {
+	before := make(map[string]bool)
+	for _, g := range goroutines() {
+		before[goroutineID(g)] = true
+	}
+	code := m.Run()
+	if code == 0 {
+		if leaked := waitLeaked(before); len(leaked) > 0 {
+			fmt.Fprintf(os.Stderr, "%d goroutines leaked:\n\n%s\n", len(leaked), strings.Join(leaked, "\n\n"))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// CheckGoroutines fails t if goroutines started during the test are still
+// running once it and its cleanups are done, listing their stacks. Call it
+// first, so the check runs after the other cleanups. Tests using it must not
+// run in parallel with others.
+func CheckGoroutines(t *testing.T) {
+	t.Helper()
+	before := make(map[string]bool)
+	for _, g := range goroutines() {
+		before[goroutineID(g)] = true
+	}
+	t.Cleanup(func() {
+		if leaked := waitLeaked(before); len(leaked) > 0 {
+			t.Errorf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	})
+}
+
+// waitLeaked waits up to leakTimeout for the goroutines not in before to
+// exit, returning the stacks of those still running
+func waitLeaked(before map[string]bool) []string {
+	deadline := time.Now().Add(leakTimeout)
+	for {
+		var leaked []string
+		for _, g := range goroutines() {
+			if !before[goroutineID(g)] {
+				leaked = append(leaked, g)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// goroutines returns the stacks of all goroutines but the caller's
+func goroutines() []string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := strings.Split(string(bytes.TrimSpace(buf)), "\n\n")
+	// The first stack is the caller's
+	return stacks[1:]
+}
+
+// goroutineID returns the ID of the goroutine whose stack is g
+func goroutineID(g string) string {
+	header, _, _ := strings.Cut(g, " [")
+	return strings.TrimPrefix(header, "goroutine ")
+}
@@ -2,9 +2,13 @@ package testutil
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
 )
 
 // TestLogger provides a thread-safe logging buffer for tests
@@ -24,9 +28,18 @@ func NewTestLogger(t *testing.T) *TestLogger {
 
 // Logf writes a formatted log message to the buffer
 func (l *TestLogger) Logf(format string, args ...interface{}) {
+	l.Log(context.Background(), logger.LevelInfo, format, args...)
+}
+
+// Log writes a formatted log message to the buffer, marked with its level
+// unless it is info
+func (l *TestLogger) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	msg := fmt.Sprintf(format+"\n", args...)
+	if level != logger.LevelInfo {
+		msg = strings.ToUpper(level.String()) + ": " + msg
+	}
 	l.buf.WriteString(msg)
 	l.t.Log(msg) // Also write to test log
 }
@@ -0,0 +1,95 @@
+package sse
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/dwrtz/mcp-go/pkg/auth"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+)
+
+// SetBearerToken sets a shared secret. In server mode, requests must carry it
+// as "Authorization: Bearer <token>" or are refused with 401; in client mode,
+// it is sent with every request. An empty token turns this off. It must be
+// called before Start.
+func (t *SSETransport) SetBearerToken(token string) {
+	t.bearerToken = token
+}
+
+// authorize attaches the bearer token, if any, to a client request
+func (t *SSETransport) authorize(req *http.Request) {
+	if t.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.bearerToken)
+	}
+}
+
+// checkBearer refuses requests that do not carry the server's bearer token
+func (t *SSETransport) checkBearer(next http.Handler) http.Handler {
+	if t.bearerToken == "" {
+		return next
+	}
+	want := []byte(t.bearerToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(token), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetAuthenticator makes the server authenticate every request with a. The
+// principal the event stream authenticates as is kept on its session, and
+// messages sent on behalf of a different principal are refused with 403. It
+// must be called before Start.
+func (t *SSETransport) SetAuthenticator(a auth.Authenticator) {
+	t.authenticator = a
+}
+
+// Session returns the session of the connected event stream, or nil if no
+// client is connected. It implements transport.SessionSource.
+func (t *SSETransport) Session() *mcp.Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.session
+}
+
+// OnSessionEnd registers a callback invoked with the session of each event
+// stream that ends. It implements transport.SessionEndNotifier.
+func (t *SSETransport) OnSessionEnd(callback func(*mcp.Session)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionEnd = append(t.sessionEnd, callback)
+}
+
+// authenticate returns the principal behind r, or nil with ok true if the
+// server has no authenticator. On failure it writes a 401 and returns false.
+func (t *SSETransport) authenticate(w http.ResponseWriter, r *http.Request) (principal *auth.Principal, ok bool) {
+	if t.authenticator == nil {
+		return nil, true
+	}
+	principal, err := t.authenticator.Authenticate(r)
+	if err == nil && principal == nil {
+		err = auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		t.Log(r.Context(), logger.LevelWarn, "Authentication failed: %v", err)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return principal, true
+}
+
+// newSessionID returns a random session ID
+func newSessionID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,166 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/auth"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestCheckBearer(t *testing.T) {
+	tr := NewSSEServer("127.0.0.1:0")
+	tr.SetBearerToken("secret")
+	h := tr.checkBearer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for header, want := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer secret": http.StatusOK,
+		"bearer secret": http.StatusOK,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Basic secret":  http.StatusUnauthorized,
+		"Bearer":        http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("POST", "/send", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Authorization %q: expected status %d, got %d", header, want, rec.Code)
+		}
+		if want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: expected WWW-Authenticate header", header)
+		}
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger := testutil.NewTestLogger(t)
+
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetLogger(logger)
+	serverTransport.SetBearerToken("secret")
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	connect := func(token string) *SSETransport {
+		clientTransport := NewSSEClient(serverTransport.BoundAddr())
+		clientTransport.SetLogger(logger)
+		clientTransport.SetBearerToken(token)
+		if err := clientTransport.Start(ctx); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		return clientTransport
+	}
+
+	// Without the token the event stream is refused
+	anonymous := connect("")
+	msg := testutil.CreateTestMessage(t, &types.ID{Num: 1}, "test", nil)
+	if err := anonymous.Send(ctx, msg); err == nil {
+		t.Error("Expected error sending without token, got none")
+	}
+	anonymous.Close()
+
+	// With it, messages flow both ways
+	client := connect("secret")
+	defer client.Close()
+	if err := client.Send(ctx, msg); err != nil {
+		t.Fatalf("Failed to send with token: %v", err)
+	}
+	select {
+	case <-serverTransport.GetRouter().Requests:
+	case <-ctx.Done():
+		t.Fatal("Timeout waiting for request on server")
+	}
+	if err := serverTransport.Send(ctx, msg); err != nil {
+		t.Fatalf("Failed to send to authorized client: %v", err)
+	}
+	select {
+	case <-client.GetRouter().Requests:
+	case <-ctx.Done():
+		t.Fatal("Timeout waiting for request on client")
+	}
+}
+
+func TestAuthenticatorSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger := testutil.NewTestLogger(t)
+
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetLogger(logger)
+	serverTransport.SetAuthenticator(auth.StaticKeys(map[string]string{"k-alice": "alice", "k-bob": "bob"}))
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	// The event stream lives as long as the context passed to Start
+	clientCtx, disconnect := context.WithCancel(ctx)
+	defer disconnect()
+	clientTransport := NewSSEClient(serverTransport.BoundAddr())
+	clientTransport.SetLogger(logger)
+	clientTransport.SetBearerToken("k-alice")
+	if err := clientTransport.Start(clientCtx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer clientTransport.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	session := serverTransport.Session()
+	if session == nil || session.ID == "" || session.Principal == nil || session.Principal.ID != "alice" {
+		t.Fatalf("Expected session for alice, got %+v", session)
+	}
+
+	post := func(key string) int {
+		body := strings.NewReader(`{"jsonrpc":"2.0","method":"test"}`)
+		req, err := http.NewRequestWithContext(ctx, "POST", "http://"+serverTransport.BoundAddr()+"/send", body)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to post: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post(""); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without key, got %d", code)
+	}
+	if code := post("k-bob"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another principal, got %d", code)
+	}
+	if code := post("k-alice"); code != http.StatusOK {
+		t.Errorf("Expected 200 for the session's principal, got %d", code)
+	}
+
+	disconnect()
+	deadline := time.Now().Add(time.Second)
+	for serverTransport.Session() != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if serverTransport.Session() != nil {
+		t.Error("Expected session to end with the event stream")
+	}
+}
@@ -0,0 +1,145 @@
+package sse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content codings the transport speaks, preferred first. "deflate" is the
+// zlib format, as HTTP defines it.
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// acceptedEncodings is the Accept-Encoding of a transport with compression
+const acceptedEncodings = encodingGzip + ", " + encodingDeflate
+
+// minCompressSize is the smallest message body a client compresses; smaller
+// ones gain little
+const minCompressSize = 1024
+
+// SetCompression turns on compressing the event stream and the bodies of
+// messages posted to the server, with gzip or deflate as negotiated through
+// Accept-Encoding and Content-Encoding. Messages that carry large resource
+// contents shrink several times over slow links. In server mode it
+// compresses the event stream of clients that accept it and tells them it
+// accepts compressed bodies; in client mode it asks for a compressed stream
+// and compresses bodies of at least 1KiB once the server says it accepts
+// them. Servers decode compressed bodies either way. It must be called
+// before Start.
+func (t *SSETransport) SetCompression(enabled bool) {
+	t.compression = enabled
+}
+
+// negotiateEncoding returns the coding to use for a peer sending
+// acceptEncoding, the first of ours it accepts, or "" for none
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		// A quality of zero refuses the coding
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		accepted[coding] = true
+	}
+	for _, coding := range []string{encodingGzip, encodingDeflate} {
+		if accepted[coding] {
+			return coding
+		}
+	}
+	return ""
+}
+
+// compressor compresses what is written to it, flushing on demand
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// newCompressor returns a compressor writing encoding to w
+func newCompressor(w io.Writer, encoding string) compressor {
+	if encoding == encodingDeflate {
+		return zlib.NewWriter(w)
+	}
+	return gzip.NewWriter(w)
+}
+
+// compress returns data compressed with encoding
+func compress(data []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	z := newCompressor(&buf, encoding)
+	if _, err := z.Write(data); err != nil {
+		return nil, err
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// errUnsupportedEncoding is returned for a body in a coding not spoken here
+type errUnsupportedEncoding string
+
+func (e errUnsupportedEncoding) Error() string {
+	return fmt.Sprintf("unsupported content encoding %q", string(e))
+}
+
+// decompress returns a reader of body, decoded from encoding, the value of
+// its Content-Encoding header
+func decompress(body io.ReadCloser, encoding string) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case encodingGzip:
+		z, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return readCloser{z, body}, nil
+	case encodingDeflate:
+		z, err := zlib.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return readCloser{z, body}, nil
+	}
+	return nil, errUnsupportedEncoding(encoding)
+}
+
+// readCloser reads a decompressor and closes the body under it
+type readCloser struct {
+	io.Reader
+	body io.Closer
+}
+
+func (r readCloser) Close() error {
+	return r.body.Close()
+}
+
+// decompressRequest replaces the body of r with its decoded content,
+// answering the request with an error if it cannot be decoded
+func decompressRequest(w http.ResponseWriter, r *http.Request) bool {
+	body, err := decompress(r.Body, r.Header.Get("Content-Encoding"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if _, ok := err.(errUnsupportedEncoding); ok {
+			status = http.StatusUnsupportedMediaType
+			w.Header().Set("Accept-Encoding", acceptedEncodings)
+		}
+		http.Error(w, err.Error(), status)
+		return false
+	}
+	r.Body = body
+	return true
+}
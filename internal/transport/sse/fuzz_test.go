@@ -0,0 +1,152 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func collectEvents(t *testing.T, stream []byte) []string {
+	t.Helper()
+	return readAll(t, bytes.NewReader(stream))
+}
+
+func readAll(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	var events []string
+	err := readEvents(r, func(data []byte) {
+		events = append(events, string(data))
+	})
+	if err != nil {
+		t.Fatalf("readEvents() error: %v", err)
+	}
+	return events
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keep-alive\n" +
+		"data: {\"a\":1}\n\n" +
+		"data:{\"b\":2}\r\n\r\n" +
+		"event: message\nid: 7\ndata: one\ndata:  two\n\n" +
+		"data\n\n" +
+		"data: cr\rdata: only\r\r" +
+		"\n\n" +
+		"data: unterminated\n"
+
+	got := collectEvents(t, []byte(stream))
+	want := []string{`{"a":1}`, `{"b":2}`, "one\n two", "", "cr\nonly"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %q, got %q", want, got)
+	}
+}
+
+func TestReadEventsLarge(t *testing.T) {
+	payload := strings.Repeat("x", 1<<20)
+	got := collectEvents(t, []byte("data: "+payload+"\n\n"))
+	if len(got) != 1 || got[0] != payload {
+		t.Errorf("Expected one %d-byte event, got %d events", len(payload), len(got))
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	var stream bytes.Buffer
+	w := bufio.NewWriter(&stream)
+	for _, data := range []string{`{"a":1}`, "one\n two", "cr\rlf\r\n", ""} {
+		if err := writeEvent(w, []byte(data)); err != nil {
+			t.Fatalf("writeEvent() error: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	got := collectEvents(t, stream.Bytes())
+	want := []string{`{"a":1}`, "one\n two", "cr\nlf\n", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %q, got %q", want, got)
+	}
+}
+
+func FuzzReadEvents(f *testing.F) {
+	seeds := []string{
+		"data: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n\n",
+		"data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/tools/list_changed\"}\n\n",
+		"data:{\"jsonrpc\":\"2.0\"}\r\n\r\n",
+		": comment\nevent: message\ndata: one\ndata: two\n\n",
+		"data\n\ndata:\n\n",
+		"data: partial",
+		"\n\n\n",
+		"data: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":null}\n\n",
+		"data: x\r\rdata: a\r\r\ndata: b\r\n\r\n",
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, stream []byte) {
+		events := collectEvents(t, stream)
+
+		// Line endings split across reads must not change the events
+		if bytewise := readAll(t, iotest.OneByteReader(bytes.NewReader(stream))); !reflect.DeepEqual(bytewise, events) {
+			t.Fatalf("Reading %q a byte at a time gave %q, want %q", stream, bytewise, events)
+		}
+
+		for _, data := range events {
+			var msg types.Message
+			if err := json.Unmarshal([]byte(data), &msg); err == nil {
+				_ = msg.Validate()
+			}
+		}
+
+		// Writing the events back out as a stream must yield the same events
+		var out bytes.Buffer
+		for _, data := range events {
+			for _, line := range strings.Split(data, "\n") {
+				out.WriteString("data: " + line + "\n")
+			}
+			out.WriteString("\n")
+		}
+		again := collectEvents(t, out.Bytes())
+		if len(again) != len(events) {
+			t.Fatalf("Re-encoding %q gave %d events, want %d", stream, len(again), len(events))
+		}
+		for i := range events {
+			if again[i] != events[i] {
+				t.Fatalf("Re-encoding %q changed event %d from %q to %q", stream, i, events[i], again[i])
+			}
+		}
+	})
+}
+
+func FuzzDecodeMessage(f *testing.F) {
+	seeds := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n",
+		`{"jsonrpc":"2.0","id":1,"method":"ping"}{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"} trailing`,
+		`{"jsonrpc":"2.0","id":"x","error":{"code":-32600,"message":"bad"}}`,
+		``,
+		`null`,
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		msg, err := decodeMessage(bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		if !json.Valid(body) {
+			t.Fatalf("decodeMessage accepted invalid JSON %q", body)
+		}
+		_ = msg.Validate()
+	})
+}
@@ -0,0 +1,90 @@
+package sse
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+)
+
+// originPolicy decides which browser origins may reach the server. Requests
+// without an Origin header come from non-browser clients and are always
+// allowed; so are origins on a loopback host, as served by local tools. A web
+// page on any other origin is refused, which keeps a page whose name was
+// rebound to a local address (DNS rebinding) from driving the server.
+type originPolicy struct {
+	disabled bool
+	allowed  map[string]bool // normalized origins
+}
+
+// allow reports whether a request with the given Origin header may proceed
+func (p *originPolicy) allow(origin string) bool {
+	if p.disabled || origin == "" {
+		return true
+	}
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	if p.allowed[origin] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return isLoopbackHost(u.Hostname())
+}
+
+// setAllowOrigin sets the Access-Control-Allow-Origin header of a response
+// to a request with the given Origin header, which the policy allowed. With
+// the check enabled only that origin is named, so a response cached for one
+// origin varies by it; with it disabled, any origin is.
+func (p *originPolicy) setAllowOrigin(h http.Header, origin string) {
+	switch {
+	case p.disabled:
+		h.Set("Access-Control-Allow-Origin", "*")
+	case origin != "":
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+	}
+}
+
+// isLoopbackHost reports whether host names the local machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// SetAllowedOrigins adds origins, e.g. "https://app.example.com", that may
+// reach the server in addition to loopback ones. It must be called before
+// Start.
+func (t *SSETransport) SetAllowedOrigins(origins ...string) {
+	if t.origins.allowed == nil {
+		t.origins.allowed = make(map[string]bool)
+	}
+	for _, o := range origins {
+		t.origins.allowed[strings.ToLower(strings.TrimSuffix(o, "/"))] = true
+	}
+}
+
+// DisableOriginCheck lets requests from any origin through, for deployments
+// where something in front of the server already vets them. It must be
+// called before Start.
+func (t *SSETransport) DisableOriginCheck() {
+	t.origins.disabled = true
+}
+
+// checkOrigin refuses requests from origins the policy does not allow
+func (t *SSETransport) checkOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); !t.origins.allow(origin) {
+			t.Log(r.Context(), logger.LevelWarn, "Rejected request from origin %q", origin)
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,86 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginPolicy(t *testing.T) {
+	tr := NewSSEServer("127.0.0.1:0")
+	tr.SetAllowedOrigins("https://App.example.com/")
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://localhost:6274", true},
+		{"http://app.localhost", true},
+		{"http://127.0.0.1:8080", true},
+		{"http://[::1]:3000", true},
+		{"https://app.example.com", true},
+		{"https://APP.example.com/", true},
+		{"http://app.example.com", false},
+		{"http://evil.example", false},
+		{"http://localhost.evil.example", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		if got := tr.origins.allow(tt.origin); got != tt.want {
+			t.Errorf("allow(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	tr.DisableOriginCheck()
+	if !tr.origins.allow("http://evil.example") {
+		t.Error("Expected any origin to be allowed with the check disabled")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tr := NewSSEServer("127.0.0.1:0")
+	h := tr.checkOrigin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for origin, want := range map[string]int{
+		"":                      http.StatusOK,
+		"http://localhost:5173": http.StatusOK,
+		"http://evil.example":   http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("POST", "/send", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Origin %q: expected status %d, got %d", origin, want, rec.Code)
+		}
+	}
+}
+
+func TestSetAllowOrigin(t *testing.T) {
+	tr := NewSSEServer("127.0.0.1:0")
+	for origin, want := range map[string]string{
+		"":                      "",
+		"http://localhost:5173": "http://localhost:5173",
+	} {
+		h := http.Header{}
+		tr.origins.setAllowOrigin(h, origin)
+		if got := h.Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("Origin %q: Access-Control-Allow-Origin = %q, want %q", origin, got, want)
+		}
+		if vary := h.Get("Vary"); (want != "") != (vary == "Origin") {
+			t.Errorf("Origin %q: Vary = %q", origin, vary)
+		}
+	}
+
+	tr.DisableOriginCheck()
+	h := http.Header{}
+	tr.origins.setAllowOrigin(h, "http://evil.example")
+	if got := h.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin with the check disabled = %q, want *", got)
+	}
+}
@@ -0,0 +1,178 @@
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/pkg/methods"
+)
+
+// defaultQueueLimit is how many messages may wait for a session's event
+// stream before senders wait, unless a high-water mark is set
+const defaultQueueLimit = 32
+
+// errSlowClient is returned by sends to a session ended for falling behind
+var errSlowClient = errors.New("client disconnected for falling behind")
+
+// outbox queues the messages waiting for one session's event stream
+type outbox struct {
+	// limit is how many messages may be queued before senders wait
+	limit int
+	// highWater is the queue length that runs the slow client hook; 0
+	// disables it
+	highWater int
+
+	mu    sync.Mutex
+	items [][]byte
+	// keys are the coalescing keys of items while coalescing, "" for
+	// messages that are never coalesced
+	keys       []string
+	coalescing bool
+	// signalled is set once the queue reached the high-water mark, until
+	// it drains
+	signalled bool
+	// ready wakes the event stream when messages are queued
+	ready chan struct{}
+	// room is closed when the event stream takes the queued messages
+	room chan struct{}
+	// ended is closed, with reason set, when the session is dropped
+	ended  chan struct{}
+	reason string
+}
+
+func newOutbox(highWater int) *outbox {
+	limit := defaultQueueLimit
+	if highWater > 0 {
+		limit = 2 * highWater
+	}
+	return &outbox{
+		limit:     limit,
+		highWater: highWater,
+		ready:     make(chan struct{}, 1),
+		room:      make(chan struct{}),
+		ended:     make(chan struct{}),
+	}
+}
+
+// push queues data. If the queue is full it returns a channel closed once
+// there may be room instead. crossed reports that the queue just reached
+// its high-water mark.
+func (o *outbox) push(data []byte) (queued int, wait <-chan struct{}, crossed bool, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	select {
+	case <-o.ended:
+		return 0, nil, false, errSlowClient
+	default:
+	}
+
+	key := ""
+	if o.coalescing {
+		key = coalesceKey(data)
+		if i := o.find(key); i >= 0 {
+			o.items[i] = data
+			return len(o.items), nil, false, nil
+		}
+	}
+	if len(o.items) >= o.limit {
+		return len(o.items), o.room, false, nil
+	}
+	o.items = append(o.items, data)
+	if o.coalescing {
+		o.keys = append(o.keys, key)
+	}
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+
+	if o.highWater > 0 && !o.signalled && len(o.items) >= o.highWater {
+		o.signalled = true
+		crossed = true
+	}
+	return len(o.items), nil, crossed, nil
+}
+
+// find returns the index of the queued message with key, or -1. The caller
+// must hold o.mu.
+func (o *outbox) find(key string) int {
+	if key == "" {
+		return -1
+	}
+	for i, k := range o.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// take removes and returns the queued messages, ending any coalescing
+func (o *outbox) take() [][]byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	items := o.items
+	o.items, o.keys = nil, nil
+	o.coalescing, o.signalled = false, false
+	close(o.room)
+	o.room = make(chan struct{})
+	return items
+}
+
+// coalesce makes later notifications replace queued ones of the same kind,
+// and coalesces those already queued, until the queue drains
+func (o *outbox) coalesce() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.coalescing {
+		return
+	}
+	o.coalescing = true
+	items := o.items
+	o.items, o.keys = nil, nil
+	for _, data := range items {
+		key := coalesceKey(data)
+		if i := o.find(key); i >= 0 {
+			o.items[i] = data
+			continue
+		}
+		o.items = append(o.items, data)
+		o.keys = append(o.keys, key)
+	}
+}
+
+// end drops the session for reason; later pushes fail
+func (o *outbox) end(reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	select {
+	case <-o.ended:
+	default:
+		o.reason = reason
+		close(o.ended)
+	}
+}
+
+// coalesceKey returns the key under which a notification replaces an
+// earlier one: its method and params, or for progress its method and token.
+// Requests and responses get "" and are never coalesced.
+func coalesceKey(data []byte) string {
+	var frame struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(data, &frame); err != nil || frame.ID != nil || frame.Method == "" {
+		return ""
+	}
+	if frame.Method == methods.Progress {
+		var progress struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		}
+		if err := json.Unmarshal(frame.Params, &progress); err == nil {
+			return frame.Method + "\x00" + string(progress.ProgressToken)
+		}
+	}
+	return frame.Method + "\x00" + string(frame.Params)
+}
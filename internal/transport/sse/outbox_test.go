@@ -0,0 +1,71 @@
+package sse
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOutbox(t *testing.T) {
+	box := newOutbox(3)
+	frames := []string{
+		`{"jsonrpc":"2.0","id":1,"result":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///a"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"t","progress":1}}`,
+	}
+	for i, frame := range frames {
+		_, wait, crossed, err := box.push([]byte(frame))
+		if err != nil || wait != nil {
+			t.Fatalf("push() = %v, %v", wait, err)
+		}
+		if crossed != (i == 2) {
+			t.Errorf("push() of message %d crossed = %v", i+1, crossed)
+		}
+	}
+
+	// While coalescing, notifications of the same kind replace queued ones
+	// and others are appended; responses are never coalesced
+	box.coalesce()
+	for _, frame := range []string{
+		`{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///a"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"t","progress":2}}`,
+		`{"jsonrpc":"2.0","id":1,"result":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///b"}}`,
+	} {
+		if _, wait, _, err := box.push([]byte(frame)); err != nil || wait != nil {
+			t.Fatalf("push() while coalescing = %v, %v", wait, err)
+		}
+	}
+	got := fmt.Sprintf("%s", box.take())
+	want := fmt.Sprintf("%s", [][]byte{
+		[]byte(frames[0]),
+		[]byte(frames[1]),
+		[]byte(`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"t","progress":2}}`),
+		[]byte(frames[0]),
+		[]byte(`{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///b"}}`),
+	})
+	if got != want {
+		t.Errorf("take() = %s, want %s", got, want)
+	}
+
+	// Draining ends coalescing; a full queue makes senders wait for room
+	for i := 0; i < 6; i++ {
+		if _, wait, _, _ := box.push([]byte(frames[1])); wait != nil {
+			t.Fatalf("push() %d waited below the limit", i+1)
+		}
+	}
+	_, wait, _, _ := box.push([]byte(frames[1]))
+	if wait == nil {
+		t.Fatal("push() over the limit did not wait")
+	}
+	box.take()
+	select {
+	case <-wait:
+	default:
+		t.Error("take() did not make room")
+	}
+
+	box.end("test")
+	if _, _, _, err := box.push([]byte(frames[0])); err != errSlowClient {
+		t.Errorf("push() after end() error = %v, want errSlowClient", err)
+	}
+}
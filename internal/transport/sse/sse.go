@@ -5,15 +5,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/dwrtz/mcp-go/internal/transport"
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/pkg/auth"
 	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
@@ -26,16 +31,68 @@ type SSETransport struct {
 
 	// We hold our net.Listener if we're in server mode
 	listener  net.Listener
-	client    chan []byte
 	mu        sync.Mutex
 	connected bool
+	// outbox queues messages for the connected client's event stream
+	outbox *outbox
+	// gone is closed when the connected client's event stream ends
+	gone chan struct{}
 
 	endpoint      string
 	connectionErr error // non-nil if client SSE connection fails
+	// streamReady is closed in client mode once the event stream is
+	// connected or failed to, see WaitForConnection
+	streamReady chan struct{}
+	readyOnce   sync.Once
+	// bufferSends makes sends in client mode wait for the event stream
+	bufferSends bool
+	// reconnectRetries and reconnectBackoff say how the event stream is
+	// reconnected in client mode, see SetReconnect
+	reconnectRetries int
+	reconnectBackoff time.Duration
+	// cancelStream ends the event stream in client mode, guarded by mu
+	cancelStream context.CancelFunc
+	// timeouts tunes the HTTP server or client, see SetHTTPTimeouts
+	timeouts transport.HTTPTimeouts
+	// httpClient makes the requests in client mode
+	httpClient *http.Client
+	// compression turns on compressing, see SetCompression; in client mode
+	// postEncoding is the coding the server accepts bodies in, guarded by mu
+	compression  bool
+	postEncoding string
 
 	logger logger.Logger
 	// Actual address we ended up listening on (for ephemeral port usage)
 	boundAddr string
+
+	// writeTimeout bounds each message sent and each write to the event
+	// stream in server mode, see SetWriteTimeout
+	writeTimeout time.Duration
+	// middleware wraps the HTTP handlers in server mode, first outermost
+	middleware []func(http.Handler) http.Handler
+	// origins decides which browser origins may reach the server
+	origins originPolicy
+	// bearerToken is required of requests in server mode and sent with
+	// them in client mode; empty disables it
+	bearerToken string
+	// authenticator maps request credentials to principals in server mode;
+	// nil accepts anyone
+	authenticator auth.Authenticator
+	// session describes the connected event stream, guarded by mu
+	session *mcp.Session
+	// sessionEnd is called with each session that ends, guarded by mu
+	sessionEnd []func(*mcp.Session)
+	// keepAlive is the idle time before a keep-alive comment is sent on the
+	// event stream; <= 0 disables them
+	keepAlive time.Duration
+	// closed records why the transport closed
+	closed transport.CloseState
+	// stats counts the traffic of every session
+	stats mcp.Counters
+	// highWater is the outbox length at which slowClient decides what to
+	// do about the session; 0 disables it
+	highWater  int
+	slowClient transport.SlowClientHook
 }
 
 // NewSSEServer creates a new SSE transport in server mode.
@@ -43,24 +100,28 @@ type SSETransport struct {
 func NewSSEServer(addr string) *SSETransport {
 	router := transport.NewMessageRouter()
 	doneCh := make(chan struct{})
-	clientCh := make(chan []byte, 32) // small buffer
 
 	return &SSETransport{
 		router: router,
 		done:   doneCh,
-		client: clientCh,
 		// We'll set up httpServer + net.Listener in Start()
-		httpServer: &http.Server{},
-		boundAddr:  addr, // store the desired address (may be ":0")
+		httpServer:   &http.Server{},
+		boundAddr:    addr, // store the desired address (may be ":0")
+		writeTimeout: eventWriteTimeout,
+		keepAlive:    DefaultKeepAlive,
 	}
 }
 
 // NewSSEClient creates a new SSE transport in client mode
 func NewSSEClient(serverAddr string) *SSETransport {
 	return &SSETransport{
-		router:   transport.NewMessageRouter(),
-		done:     make(chan struct{}),
-		endpoint: fmt.Sprintf("http://%s/send", serverAddr),
+		router:           transport.NewMessageRouter(),
+		done:             make(chan struct{}),
+		endpoint:         fmt.Sprintf("http://%s/send", serverAddr),
+		streamReady:      make(chan struct{}),
+		reconnectRetries: DefaultReconnectRetries,
+		reconnectBackoff: DefaultReconnectBackoff,
+		httpClient:       http.DefaultClient,
 	}
 }
 
@@ -70,9 +131,22 @@ func (t *SSETransport) Start(ctx context.Context) error {
 	if t.httpServer != nil {
 		// SERVER MODE
 		mux := http.NewServeMux()
-		mux.HandleFunc("/events", t.handleSSE)
+		// Streams last as long as their session, so get a role of their own
+		mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+			goroutine.Do("sse/events", func() { t.handleSSE(w, r) })
+		})
 		mux.HandleFunc("/send", t.handleSend)
-		t.httpServer.Handler = mux
+		// The origin and token checks sit inside any middleware, which sees
+		// refused requests too
+		handler := t.checkOrigin(t.checkBearer(mux))
+		for i := len(t.middleware) - 1; i >= 0; i-- {
+			handler = t.middleware[i](handler)
+		}
+		t.httpServer.Handler = handler
+		t.httpServer.ReadTimeout = t.timeouts.ReadTimeout
+		t.httpServer.ReadHeaderTimeout = t.timeouts.ReadHeaderTimeout
+		t.httpServer.WriteTimeout = t.timeouts.WriteTimeout
+		t.httpServer.IdleTimeout = t.timeouts.IdleTimeout
 
 		// 1) Create a listener (this picks an ephemeral port if boundAddr == ":0")
 		ln, err := net.Listen("tcp", t.boundAddr)
@@ -83,83 +157,375 @@ func (t *SSETransport) Start(ctx context.Context) error {
 		t.boundAddr = ln.Addr().String() // store the actual address/port
 
 		// 2) Start serving
-		go func() {
+		goroutine.Go("sse/serve", func() {
 			if err := t.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
-				t.Logf("HTTP server error: %v", err)
+				t.Log(ctx, logger.LevelError, "HTTP server error: %v", err)
 			}
-		}()
+		})
 		return nil
 	}
 
 	// CLIENT MODE...
-	go t.connectSSE(ctx)
+	t.httpClient = newHTTPClient(t.timeouts)
+	ctx, cancel := context.WithCancel(ctx)
+	t.mu.Lock()
+	t.cancelStream = cancel
+	t.mu.Unlock()
+	goroutine.Go("sse/stream", func() { t.connectSSE(ctx) })
 	return nil
 }
 
+// SetHTTPTimeouts tunes the timeouts of the HTTP server in server mode and
+// of the requests made in client mode, see transport.HTTPTimeouts. It must
+// be called before Start.
+func (t *SSETransport) SetHTTPTimeouts(timeouts transport.HTTPTimeouts) {
+	t.timeouts = timeouts
+}
+
+// newHTTPClient returns the client to make requests with in client mode:
+// the default one unless timeouts set the connection's
+func newHTTPClient(timeouts transport.HTTPTimeouts) *http.Client {
+	if timeouts.DialTimeout <= 0 && timeouts.ResponseHeaderTimeout <= 0 && timeouts.IdleConnTimeout <= 0 {
+		return http.DefaultClient
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if timeouts.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: timeouts.DialTimeout, KeepAlive: 30 * time.Second}
+		tr.DialContext = dialer.DialContext
+	}
+	if timeouts.ResponseHeaderTimeout > 0 {
+		tr.ResponseHeaderTimeout = timeouts.ResponseHeaderTimeout
+	}
+	if timeouts.IdleConnTimeout > 0 {
+		tr.IdleConnTimeout = timeouts.IdleConnTimeout
+	}
+	return &http.Client{Transport: tr}
+}
+
+// Defaults of SetReconnect
+const (
+	DefaultReconnectRetries = 5
+	DefaultReconnectBackoff = 250 * time.Millisecond
+)
+
+// maxReconnectBackoff caps the wait between reconnection attempts
+const maxReconnectBackoff = 30 * time.Second
+
+// SetReconnect sets how the event stream is reconnected in client mode when
+// connecting fails or the stream ends, as while the server restarts: up to
+// retries times in a row, waiting backoff before the first retry and
+// doubling it each time. Each failed attempt is reported as a
+// *transport.ConnectError; after the last, the transport closes with its
+// error. Retries start over once the stream connects. It defaults to
+// DefaultReconnectRetries and DefaultReconnectBackoff; retries 0 gives up
+// after one failure. It must be called before Start.
+func (t *SSETransport) SetReconnect(retries int, backoff time.Duration) {
+	t.reconnectRetries = retries
+	t.reconnectBackoff = backoff
+}
+
+// SetKeepAlive sets how long the event stream may sit idle before a
+// keep-alive comment is sent, so intermediaries don't close the connection.
+// A failed keep-alive ends the client's stream. d <= 0 disables them. It
+// applies to streams opened after the call.
+func (t *SSETransport) SetKeepAlive(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keepAlive = d
+}
+
+// SetWriteTimeout limits each message sent to timeout, on top of the
+// deadline of the context it is sent with: posting it in client mode, and
+// waiting for room in the client's queue in server mode. In server mode it
+// also limits each write to the event stream, after which the client is
+// taken to be stuck and disconnected. It defaults to 10 seconds; timeout <= 0
+// disables it. It must be called before Start.
+func (t *SSETransport) SetWriteTimeout(timeout time.Duration) {
+	t.writeTimeout = timeout
+}
+
+// SetSlowClientHook runs hook when highWater messages are queued for the
+// connected client, letting it coalesce notifications or end the session
+// instead of making senders wait. Senders wait once twice as many are
+// queued. It applies to streams opened after the call.
+func (t *SSETransport) SetSlowClientHook(highWater int, hook transport.SlowClientHook) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.highWater = highWater
+	t.slowClient = hook
+}
+
+// Use adds middleware around the server's HTTP handlers, e.g. for
+// authentication, logging, or recovery. Middleware added first runs first.
+// It must be called before Start. Middleware that wraps the
+// http.ResponseWriter should provide Unwrap so the event stream can still
+// flush.
+func (t *SSETransport) Use(mw ...func(http.Handler) http.Handler) {
+	t.middleware = append(t.middleware, mw...)
+}
+
 // BoundAddr returns the actual address the SSE server is listening on.
 func (t *SSETransport) BoundAddr() string {
 	return t.boundAddr
 }
 
-// connectSSE tries a single SSE connection to /events in client mode.
-// We intentionally do NOT shut down the entire transport if it fails.
+// errStreamEnded is the error of an event stream the server ended
+var errStreamEnded = errors.New("event stream ended")
+
+// connectSSE connects the event stream in client mode and reads it until
+// ctx is done, reconnecting as SetReconnect says when connecting fails or
+// the stream ends
 func (t *SSETransport) connectSSE(ctx context.Context) {
+	failures := 0
+	backoff := t.reconnectBackoff
+	for {
+		connected, err := t.readStream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			failures, backoff = 0, t.reconnectBackoff
+		}
+		failures++
+		final := failures > t.reconnectRetries
+		t.router.ReportError(&transport.ConnectError{Attempt: failures, Final: final, Err: err})
+		if final {
+			t.Log(ctx, logger.LevelError, "Giving up on the SSE stream: %v", err)
+			t.setConnectionErr(err)
+			t.streamConnected()
+			t.shut(transport.ClosedByPeer, err)
+			return
+		}
+
+		t.Log(ctx, logger.LevelWarn, "SSE stream failed, reconnecting in %s: %v", backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, maxReconnectBackoff)
+	}
+}
+
+// readStream connects the event stream and reads it until it ends,
+// returning why and whether it connected
+func (t *SSETransport) readStream(ctx context.Context) (connected bool, err error) {
 	serverURL := strings.Replace(t.endpoint, "/send", "/events", 1)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", serverURL, nil)
 	if err != nil {
-		t.Logf("Failed to create SSE request: %v", err)
-		t.setConnectionErr(err)
-		return
+		return false, fmt.Errorf("failed to create SSE request: %w", err)
+	}
+	t.authorize(req)
+	if t.compression {
+		req.Header.Set("Accept-Encoding", acceptedEncodings)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := t.httpClient.Do(req)
 	if err != nil {
-		t.Logf("Failed to connect to SSE: %v", err)
-		t.setConnectionErr(err)
-		return
+		return false, fmt.Errorf("failed to connect to SSE: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		errMsg := fmt.Errorf("failed to connect to SSE: status code %d", resp.StatusCode)
-		t.Logf(errMsg.Error())
-		t.setConnectionErr(errMsg)
-		return
+		return false, fmt.Errorf("failed to connect to SSE: status code %d", resp.StatusCode)
+	}
+	body, err := decompress(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return false, fmt.Errorf("failed to read SSE: %w", err)
+	}
+	if t.compression {
+		t.mu.Lock()
+		t.postEncoding = negotiateEncoding(resp.Header.Get("Accept-Encoding"))
+		t.mu.Unlock()
 	}
 
 	// If we reach here, SSE connected successfully. Process the stream.
-	t.processSSE(resp.Body)
+	t.Log(ctx, logger.LevelDebug, "SSE stream connected")
+	t.streamConnected()
+	if err := t.processSSE(body); err != nil {
+		return true, err
+	}
+	return true, errStreamEnded
 }
 
-// processSSE reads lines from SSE response body, parsing JSON messages.
-func (t *SSETransport) processSSE(r io.Reader) {
-	scanner := bufio.NewScanner(r)
-	var buffer bytes.Buffer
+// streamConnected signals that the attempt to connect the event stream in
+// client mode is over, successful unless a connection error is set
+func (t *SSETransport) streamConnected() {
+	t.readyOnce.Do(func() {
+		close(t.streamReady)
+	})
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// Ready returns a channel closed once the event stream is connected, or
+// failed to, in client mode; WaitForConnection says which. In server mode it
+// returns a closed channel.
+func (t *SSETransport) Ready() <-chan struct{} {
+	if t.streamReady == nil {
+		ready := make(chan struct{})
+		close(ready)
+		return ready
+	}
+	return t.streamReady
+}
 
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-			buffer.WriteString(data)
-			continue
+// WaitForConnection waits until the event stream carrying the server's
+// messages is connected in client mode, returning the error that failed it
+// if it did. Responses and notifications the server sends before then are
+// lost. In server mode it returns nil at once.
+func (t *SSETransport) WaitForConnection(ctx context.Context) error {
+	select {
+	case <-t.Ready():
+		return t.getConnectionErr()
+	case <-t.done:
+		if err := t.getConnectionErr(); err != nil {
+			return err
 		}
+		return fmt.Errorf("transport closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-		// blank line indicates end of SSE event
-		if line == "" && buffer.Len() > 0 {
+// SetBufferUntilConnected makes sends in client mode wait for the event
+// stream to be connected, see WaitForConnection, so nothing the server
+// answers is lost. Sends still fail once their context is done. It must be
+// called before Start.
+func (t *SSETransport) SetBufferUntilConnected(buffer bool) {
+	t.bufferSends = buffer
+}
+
+// processSSE reads events from the SSE response body, parsing JSON
+// messages, until it ends
+func (t *SSETransport) processSSE(r io.Reader) error {
+	return readEvents(r, func(data []byte) {
+		msgs, err := transport.SplitBatch(data)
+		if err != nil {
+			msgs = []json.RawMessage{data}
+		}
+		for _, raw := range msgs {
 			var msg types.Message
-			if err := json.Unmarshal(buffer.Bytes(), &msg); err != nil {
-				t.Logf("Failed to unmarshal SSE message: %v", err)
-			} else {
-				t.router.Handle(context.Background(), &msg) // pass a BG context
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				t.Log(context.Background(), logger.LevelWarn, "Failed to unmarshal SSE message: %v", err)
+				t.countError()
+				// data is reused for the next event
+				t.router.ReportError(&transport.DecodeError{Data: append([]byte(nil), raw...), Err: err})
+				continue
+			}
+			t.countIn(len(raw), &msg)
+			t.router.Handle(context.Background(), &msg) // pass a BG context
+		}
+	})
+}
+
+// scanEventLines is a bufio.SplitFunc for event stream lines, which may end
+// in CRLF, LF, or a lone CR
+func scanEventLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case i < 0:
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	case data[i] == '\n':
+		return i + 1, data[:i], nil
+	case i+1 < len(data):
+		if data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	case atEOF:
+		return i + 1, data[:i], nil
+	}
+	// A CR at the end of the buffer may be the first half of a CRLF
+	return 0, nil, nil
+}
+
+// maxEventLine bounds a single line of the event stream, well above
+// bufio.Scanner's 64KB default so large results are not cut off
+const maxEventLine = 16 << 20
+
+// readEvents parses a text/event-stream, calling handle with the data of each
+// event. Data lines are joined with newlines and the space after "data:" is
+// optional; comments and other fields are ignored. An event not terminated by
+// a blank line is discarded, as the stream ended mid-event.
+func readEvents(r io.Reader, handle func(data []byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventLine)
+	scanner.Split(scanEventLines)
+
+	var buffer bytes.Buffer
+	hasData := false
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		// blank line indicates end of SSE event
+		if len(line) == 0 {
+			if hasData {
+				handle(buffer.Bytes())
 			}
 			buffer.Reset()
+			hasData = false
+			continue
+		}
+
+		field, value, found := bytes.Cut(line, []byte(":"))
+		if !found {
+			value = nil
+		}
+		if string(field) != "data" {
+			continue
 		}
+		value = bytes.TrimPrefix(value, []byte(" "))
+		if hasData {
+			buffer.WriteByte('\n')
+		}
+		buffer.Write(value)
+		hasData = true
 	}
-	if err := scanner.Err(); err != nil {
-		t.Logf("SSE scanner error: %v", err)
+	return scanner.Err()
+}
+
+// eventBufferSize is the size of the buffered writer for the event stream
+const eventBufferSize = 4096
+
+// DefaultKeepAlive is the keep-alive interval of a new SSE server
+const DefaultKeepAlive = 15 * time.Second
+
+// eventWriteTimeout is how long a write to the event stream may block before
+// the client is considered stuck and disconnected
+const eventWriteTimeout = 10 * time.Second
+
+var (
+	dataPrefix   = []byte("data: ")
+	eventEnd     = []byte("\n")
+	keepAliveMsg = []byte(": keepalive\n\n")
+)
+
+// writeEvent writes data to w as one event. Each line of data, split on CRLF,
+// LF, or a lone CR, gets its own data field, so readEvents on the other end
+// gets the lines back joined with newlines.
+func writeEvent(w *bufio.Writer, data []byte) error {
+	for {
+		i := bytes.IndexAny(data, "\r\n")
+		line := data
+		if i >= 0 {
+			line = data[:i]
+		}
+		w.Write(dataPrefix)
+		w.Write(line)
+		w.WriteByte('\n')
+		if i < 0 {
+			break
+		}
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			i++
+		}
+		data = data[i+1:]
 	}
+	_, err := w.Write(eventEnd)
+	return err
 }
 
 // setConnectionErr safely sets a client-side connection error
@@ -177,54 +543,201 @@ func (t *SSETransport) getConnectionErr() error {
 
 // Send sends a message through the transport
 func (t *SSETransport) Send(ctx context.Context, msg *types.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	err = t.write(ctx, msg.Method, data)
+	t.countOut(len(data), msg, err)
+	return err
+}
+
+// WriteNotification implements transport.NotificationWriter
+func (t *SSETransport) WriteNotification(ctx context.Context, method string, params json.RawMessage) error {
+	// The frame outlives this call in server mode, queued for the event
+	// stream, so it gets its own buffer
+	data, err := transport.AppendNotification(make([]byte, 0, len(method)+len(params)+48), method, params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	err = t.write(ctx, method, data)
+	t.countOut(len(data), nil, err)
+	return err
+}
+
+// WriteBatch implements transport.BatchWriter, sending the batch as one
+// event in server mode and one POST in client mode
+func (t *SSETransport) WriteBatch(ctx context.Context, msgs []json.RawMessage) error {
+	data := transport.AppendBatch(nil, msgs)
+	if err := t.write(ctx, "batch", data); err != nil {
+		t.countOut(0, nil, err)
+		return err
+	}
+	for _, msg := range msgs {
+		t.countOut(len(msg), nil, nil)
+	}
+	return nil
+}
+
+// write posts an encoded message for method to the server in client mode
+// and queues it for the event stream in server mode, giving up with a
+// transport.WriteTimeoutError once the deadline of ctx or the write timeout
+// passes
+func (t *SSETransport) write(ctx context.Context, method string, data []byte) error {
+	ctx, cancel := transport.WriteContext(ctx, t.writeTimeout)
+	defer cancel()
+	var err error
 	if t.httpServer == nil {
-		// CLIENT mode
-		if cErr := t.getConnectionErr(); cErr != nil {
-			return cErr
-		}
-		data, err := json.Marshal(msg)
-		if err != nil {
-			return fmt.Errorf("failed to marshal message: %w", err)
-		}
+		ctx, cancel := transport.WriteContext(ctx, t.timeouts.RequestTimeout)
+		defer cancel()
+		err = transport.WriteError(ctx, method, t.post(ctx, data))
+	} else {
+		err = t.enqueue(ctx, data)
+	}
+	return transport.WriteError(ctx, method, err)
+}
 
-		req, err := http.NewRequestWithContext(ctx, "POST", t.endpoint, bytes.NewReader(data))
-		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
+// countIn counts a received message of n bytes for the transport and the
+// connected session
+func (t *SSETransport) countIn(n int, msg *types.Message) {
+	session := t.Session()
+	t.stats.CountIn(n)
+	if session != nil {
+		session.CountIn(n)
+	}
+	if msg.Error != nil {
+		t.countError()
+	}
+}
+
+// countOut counts a message of n bytes that was sent, or an error if err is
+// set. msg is nil for notifications written with WriteNotification.
+func (t *SSETransport) countOut(n int, msg *types.Message, err error) {
+	if err != nil {
+		t.countError()
+		return
+	}
+	session := t.Session()
+	t.stats.CountOut(n)
+	if session != nil {
+		session.CountOut(n)
+	}
+	if msg != nil && msg.Error != nil {
+		t.countError()
+	}
+}
+
+// countError counts an error for the transport and the connected session
+func (t *SSETransport) countError() {
+	session := t.Session()
+	t.stats.CountError()
+	if session != nil {
+		session.CountError()
+	}
+}
+
+// Stats implements transport.StatsSource
+func (t *SSETransport) Stats() mcp.SessionStats {
+	return t.stats.Stats()
+}
+
+// post sends an encoded message to the server in client mode
+func (t *SSETransport) post(ctx context.Context, data []byte) error {
+	if t.bufferSends {
+		if err := t.WaitForConnection(ctx); err != nil {
+			return err
 		}
-		req.Header.Set("Content-Type", "application/json")
+	}
+	if cErr := t.getConnectionErr(); cErr != nil {
+		return cErr
+	}
 
-		resp, err := http.DefaultClient.Do(req)
+	t.mu.Lock()
+	encoding := t.postEncoding
+	t.mu.Unlock()
+	if encoding != "" && len(data) >= minCompressSize {
+		compressed, err := compress(data, encoding)
 		if err != nil {
-			return fmt.Errorf("failed to send message: %w", err)
+			return fmt.Errorf("failed to compress message: %w", err)
 		}
-		defer resp.Body.Close()
+		data = compressed
+	} else {
+		encoding = ""
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		}
-		return nil
+	req, err := http.NewRequestWithContext(ctx, "POST", t.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
 	}
+	t.authorize(req)
 
-	// SERVER mode
-	data, err := json.Marshal(msg)
+	resp, err := t.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
+	return nil
+}
 
+// enqueue queues an encoded message for the connected client in server mode.
+// When the queue is full it waits for room rather than dropping the message,
+// so the client sees messages in the order they were queued. A queue that
+// reaches the high-water mark runs the slow client hook.
+func (t *SSETransport) enqueue(ctx context.Context, data []byte) error {
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	connected, gone, box, session, hook := t.connected, t.gone, t.outbox, t.session, t.slowClient
+	t.mu.Unlock()
 
-	if !t.connected {
+	if !connected {
 		return fmt.Errorf("no client connected")
 	}
-	select {
-	case t.client <- data:
-		return nil
-	default:
-		return fmt.Errorf("client message buffer full")
+	for {
+		queued, wait, crossed, err := box.push(data)
+		if err != nil {
+			return err
+		}
+		if wait == nil {
+			if crossed && hook != nil {
+				return t.handleSlowClient(ctx, box, session, queued, hook)
+			}
+			return nil
+		}
+		select {
+		case <-wait:
+		case <-box.ended:
+			return errSlowClient
+		case <-gone:
+			return fmt.Errorf("client disconnected")
+		case <-t.done:
+			return fmt.Errorf("transport closed")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 }
 
+// handleSlowClient applies the action hook picks for session, whose queue
+// just reached the high-water mark with queued messages
+func (t *SSETransport) handleSlowClient(ctx context.Context, box *outbox, session *mcp.Session, queued int, hook transport.SlowClientHook) error {
+	switch action := hook(session, queued); action {
+	case transport.SlowClientCoalesce:
+		t.Log(ctx, logger.LevelInfo, "Coalescing notifications for slow client: %d messages queued", queued)
+		box.coalesce()
+	case transport.SlowClientDisconnect:
+		box.end(fmt.Sprintf("%d messages queued", queued))
+		return errSlowClient
+	}
+	return nil
+}
+
 // GetRouter returns the message router
 func (t *SSETransport) GetRouter() *transport.MessageRouter {
 	return t.router
@@ -232,11 +745,13 @@ func (t *SSETransport) GetRouter() *transport.MessageRouter {
 
 // Close gracefully shuts down the server
 func (t *SSETransport) Close() error {
-	select {
-	case <-t.done:
+	return t.CloseWithError(nil)
+}
+
+// CloseWithError implements transport.CloseReporter
+func (t *SSETransport) CloseWithError(err error) error {
+	if !t.shut(transport.ClosedLocally, err) {
 		return nil
-	default:
-		close(t.done)
 	}
 	if t.httpServer != nil {
 		_ = t.httpServer.Close()
@@ -247,16 +762,48 @@ func (t *SSETransport) Close() error {
 	return nil
 }
 
+// shut records reason and err and closes done, ending the event stream in
+// client mode, unless the transport is already closed. It reports whether
+// it closed it.
+func (t *SSETransport) shut(reason transport.CloseReason, err error) bool {
+	t.closed.Set(reason, err)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return false
+	default:
+		close(t.done)
+	}
+	if t.cancelStream != nil {
+		t.cancelStream()
+	}
+	return true
+}
+
 // Done returns a channel that is closed when the transport is closed
 func (t *SSETransport) Done() <-chan struct{} {
 	return t.done
 }
 
+// DoneReason implements transport.CloseReporter
+func (t *SSETransport) DoneReason() transport.CloseReason {
+	return t.closed.Reason(t.done)
+}
+
+// Err implements transport.CloseReporter
+func (t *SSETransport) Err() error {
+	return t.closed.Err(t.done)
+}
+
 // Logf logs a formatted message
 func (t *SSETransport) Logf(format string, args ...interface{}) {
-	if t.logger != nil {
-		t.logger.Logf(format, args...)
-	}
+	t.Log(context.Background(), logger.LevelInfo, format, args...)
+}
+
+// Log logs a formatted message at level
+func (t *SSETransport) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
+	logger.Log(ctx, t.logger, level, format, args...)
 }
 
 // SetLogger sets the logger
@@ -267,6 +814,11 @@ func (t *SSETransport) SetLogger(l logger.Logger) {
 
 // handleSSE is the handler for /events. Only one client at a time is allowed.
 func (t *SSETransport) handleSSE(w http.ResponseWriter, r *http.Request) {
+	principal, ok := t.authenticate(w, r)
+	if !ok {
+		return
+	}
+
 	t.mu.Lock()
 	if t.connected {
 		t.mu.Unlock()
@@ -274,14 +826,26 @@ func (t *SSETransport) handleSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	t.connected = true
+	t.session = &mcp.Session{ID: newSessionID(), Principal: principal}
+	t.gone = make(chan struct{})
+	t.outbox = newOutbox(t.highWater)
+	box, gone, keepAlive := t.outbox, t.gone, t.keepAlive
 	t.mu.Unlock()
+	maxAge := t.timeouts.MaxConnectionAge
 
 	t.Logf("Client connected")
 
 	defer func() {
 		t.mu.Lock()
+		session, callbacks := t.session, t.sessionEnd
 		t.connected = false
+		t.session = nil
+		t.outbox = nil
+		close(t.gone)
 		t.mu.Unlock()
+		for _, callback := range callbacks {
+			callback(session)
+		}
 		t.Logf("Client disconnected")
 	}()
 
@@ -289,38 +853,235 @@ func (t *SSETransport) handleSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	t.origins.setAllowOrigin(w.Header(), r.Header.Get("Origin"))
+	var encoding string
+	if t.compression {
+		encoding = negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Accept-Encoding", acceptedEncodings)
+		w.Header().Add("Vary", "Accept-Encoding")
+		if encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+	}
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
+	// Flushing sends the headers now; the controller also finds a Flusher
+	// behind middleware that wraps w and provides Unwrap
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's read and write timeouts, which are
+	// meant for the requests carrying messages; writes to it get their own
+	// deadlines
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+	if err := rc.Flush(); err != nil {
 		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
 		return
 	}
+	stream := &eventStream{rc: rc}
+	if encoding != "" {
+		// The compressed stream starts with its header, which the client
+		// reads before any event
+		stream.z = newCompressor(w, encoding)
+		defer stream.z.Close()
+		stream.bw = bufio.NewWriterSize(stream.z, eventBufferSize)
+		if err := stream.flush(); err != nil {
+			return
+		}
+	} else {
+		stream.bw = bufio.NewWriterSize(w, eventBufferSize)
+	}
+
+	// A session ended for falling behind is most likely stuck in a write,
+	// which the expired deadline fails right away
+	go func() {
+		select {
+		case <-box.ended:
+			_ = rc.SetWriteDeadline(time.Now())
+		case <-gone:
+		}
+	}()
+
+	// Keep-alives go out once the stream has been idle for keepAlive, so
+	// the timer restarts after every write. With keep-alives off idleC stays
+	// nil and never fires.
+	var idle *time.Timer
+	var idleC <-chan time.Time
+	if keepAlive > 0 {
+		idle = time.NewTimer(keepAlive)
+		defer idle.Stop()
+		idleC = idle.C
+	}
+	resetIdle := func() {
+		if idle == nil {
+			return
+		}
+		if !idle.Stop() {
+			select {
+			case <-idleC:
+			default:
+			}
+		}
+		idle.Reset(keepAlive)
+	}
+
+	// A stream past its maximum age ends, and the client reconnects
+	var ageC <-chan time.Time
+	if maxAge > 0 {
+		age := time.NewTimer(maxAge)
+		defer age.Stop()
+		ageC = age.C
+	}
 
-	// Stream SSE messages from t.client channel
+	// Stream the messages queued in the session's outbox
 	for {
 		select {
 		case <-t.done:
 			return
+		case <-ageC:
+			t.Log(r.Context(), logger.LevelInfo, "Ending SSE stream after its maximum age of %s", maxAge)
+			return
 		case <-r.Context().Done():
 			// The client disconnected
 			return
-		case data := <-t.client:
-			fmt.Fprintf(w, "data: %s\n\n", data)
-			flusher.Flush()
+		case <-box.ended:
+			t.Log(r.Context(), logger.LevelWarn, "Disconnecting slow client: %s", box.reason)
+			return
+		case <-box.ready:
+			if err := t.writeEvents(stream, box.take()); err != nil {
+				t.Log(r.Context(), logger.LevelWarn, "Failed to write SSE event: %v", err)
+				return
+			}
+			resetIdle()
+		case <-idleC:
+			if err := t.writeKeepAlive(stream); err != nil {
+				t.Log(r.Context(), logger.LevelWarn, "Failed to write SSE keep-alive: %v", err)
+				return
+			}
+			idle.Reset(keepAlive)
+		}
+	}
+}
+
+// eventStream is the response carrying the events of a session, buffered
+// and, when negotiated, compressed
+type eventStream struct {
+	rc *http.ResponseController
+	bw *bufio.Writer
+	z  compressor
+}
+
+// flush sends what was written to the client
+func (s *eventStream) flush() error {
+	if err := s.bw.Flush(); err != nil {
+		return err
+	}
+	if s.z != nil {
+		if err := s.z.Flush(); err != nil {
+			return err
+		}
+	}
+	return s.rc.Flush()
+}
+
+// writeKeepAlive writes a comment to the event stream, which readers ignore
+func (t *SSETransport) writeKeepAlive(stream *eventStream) error {
+	if err := t.setWriteDeadline(stream.rc); err != nil {
+		return err
+	}
+	if _, err := stream.bw.Write(keepAliveMsg); err != nil {
+		return err
+	}
+	return stream.flush()
+}
+
+// setWriteDeadline bounds the next writes to the event stream by the write
+// timeout
+func (t *SSETransport) setWriteDeadline(rc *http.ResponseController) error {
+	if t.writeTimeout <= 0 {
+		return nil
+	}
+	err := rc.SetWriteDeadline(time.Now().Add(t.writeTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// writeEvents writes the queued messages to the event stream and flushes
+// them to the client. A client that does not take the events within the
+// write timeout fails the write rather than blocking the stream.
+func (t *SSETransport) writeEvents(stream *eventStream, queued [][]byte) error {
+	if err := t.setWriteDeadline(stream.rc); err != nil {
+		return err
+	}
+	for _, data := range queued {
+		if err := writeEvent(stream.bw, data); err != nil {
+			return err
 		}
 	}
+	return stream.flush()
 }
 
 // handleSend is the handler for /send. It receives an HTTP POST JSON message from the client
 // and routes it to the server's message router.
 func (t *SSETransport) handleSend(w http.ResponseWriter, r *http.Request) {
-	var msg types.Message
-	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid message: %v", err), http.StatusBadRequest)
+	principal, ok := t.authenticate(w, r)
+	if !ok {
+		return
+	}
+	if session := t.Session(); principal != nil && session != nil && session.Principal != nil && session.Principal.ID != principal.ID {
+		http.Error(w, "Principal does not match session", http.StatusForbidden)
 		return
 	}
 
-	t.router.Handle(r.Context(), &msg)
+	if !decompressRequest(w, r) {
+		t.countError()
+		return
+	}
+	msgs, sizes, err := decodeMessages(http.MaxBytesReader(w, r.Body, maxEventLine))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid message: %v", err), http.StatusBadRequest)
+		t.countError()
+		t.router.ReportError(&transport.DecodeError{Err: err})
+		return
+	}
+	for i, msg := range msgs {
+		t.countIn(sizes[i], msg)
+		t.router.Handle(r.Context(), msg)
+	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// decodeMessages decodes a request body holding one JSON message or a batch
+// of them, returning the size of each
+func decodeMessages(r io.Reader) ([]*types.Message, []int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	raws, err := transport.SplitBatch(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	msgs := make([]*types.Message, len(raws))
+	sizes := make([]int, len(raws))
+	for i, raw := range raws {
+		if msgs[i], err = decodeMessage(bytes.NewReader(raw)); err != nil {
+			return nil, nil, err
+		}
+		sizes[i] = len(raw)
+	}
+	return msgs, sizes, nil
+}
+
+// decodeMessage decodes a request body holding exactly one JSON message
+func decodeMessage(r io.Reader) (*types.Message, error) {
+	dec := json.NewDecoder(r)
+	var msg types.Message
+	if err := dec.Decode(&msg); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after message")
+	}
+	return &msg, nil
+}
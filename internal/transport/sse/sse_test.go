@@ -1,14 +1,28 @@
 package sse
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
+func TestMain(m *testing.M) {
+	testutil.VerifyTestMain(m)
+}
+
 func TestSSETransport(t *testing.T) {
 	tests := []struct {
 		name string
@@ -18,6 +32,15 @@ func TestSSETransport(t *testing.T) {
 		{"TestMessageExchange", testMessageExchange},
 		{"TestReconnection", testReconnection},
 		{"TestServerClose", testServerClose},
+		{"TestStuckClient", testStuckClient},
+		{"TestSlowClientHook", testSlowClientHook},
+		{"TestKeepAlive", testKeepAlive},
+		{"TestMiddleware", testMiddleware},
+		{"TestOrderedBurst", testOrderedBurst},
+		{"TestWaitForConnection", testWaitForConnection},
+		{"TestStreamReconnect", testStreamReconnect},
+		{"TestHTTPTimeouts", testHTTPTimeouts},
+		{"TestCompression", testCompression},
 	}
 
 	for _, tt := range tests {
@@ -225,6 +248,9 @@ func testReconnection(t *testing.T) {
 		t.Fatalf("Failed to start second client: %v", err)
 	}
 	defer client2.Close()
+	if err := client2.WaitForConnection(ctx); err != nil {
+		t.Fatalf("Second client failed to connect: %v", err)
+	}
 
 	// Try to send message
 	testMsg := testutil.CreateTestMessage(t, &types.ID{Num: 1}, "test", nil)
@@ -274,3 +300,577 @@ func testServerClose(t *testing.T) {
 		t.Error("Expected error sending after server close, got none")
 	}
 }
+
+func testStuckClient(t *testing.T) {
+	ctx := context.Background()
+
+	// Create server transport with a short write timeout
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetLogger(testutil.NewTestLogger(t))
+	serverTransport.SetWriteTimeout(100 * time.Millisecond)
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	// Open the event stream but never read from it
+	conn, err := net.Dial("tcp", serverTransport.BoundAddr())
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET /events HTTP/1.1\r\nHost: %s\r\n\r\n", serverTransport.BoundAddr())
+
+	result := json.RawMessage(`"` + strings.Repeat("x", 64*1024) + `"`)
+	msg := &types.Message{JSONRPC: types.JSONRPCVersion, ID: &types.ID{Num: 1}, Result: &result}
+
+	// Wait for the client to be connected
+	deadline := time.Now().Add(5 * time.Second)
+	for serverTransport.Send(ctx, msg) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Client never connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Send until the socket buffers fill and the stuck client is dropped
+	for time.Now().Before(deadline) {
+		if err := serverTransport.Send(ctx, msg); err != nil && err.Error() == "no client connected" {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("Expected stuck client to be disconnected")
+}
+
+func testSlowClientHook(t *testing.T) {
+	ctx := context.Background()
+
+	// The write timeout is left long, so only the hook drops the client
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetLogger(testutil.NewTestLogger(t))
+	var calls atomic.Int32
+	serverTransport.SetSlowClientHook(4, func(session *mcp.Session, queued int) transport.SlowClientAction {
+		calls.Add(1)
+		if session == nil || queued != 4 {
+			t.Errorf("Hook called with %v, %d; want the session and 4", session, queued)
+		}
+		return transport.SlowClientDisconnect
+	})
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	// Open the event stream but never read from it
+	conn, err := net.Dial("tcp", serverTransport.BoundAddr())
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET /events HTTP/1.1\r\nHost: %s\r\n\r\n", serverTransport.BoundAddr())
+
+	result := json.RawMessage(`"` + strings.Repeat("x", 64*1024) + `"`)
+	msg := &types.Message{JSONRPC: types.JSONRPCVersion, ID: &types.ID{Num: 1}, Result: &result}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for serverTransport.Send(ctx, msg) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Client never connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Send until the socket buffers fill and the queue reaches the mark
+	for {
+		err := serverTransport.Send(ctx, msg)
+		if errors.Is(err, errSlowClient) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Send() error = %v, want the slow client error", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Slow client was never dropped")
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Hook called %d times, want 1", calls.Load())
+	}
+
+	// The stuck write is abandoned and the session ends well before the
+	// write timeout
+	for time.Now().Before(deadline) {
+		if err := serverTransport.Send(ctx, msg); err != nil && err.Error() == "no client connected" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Slow client's session did not end")
+}
+
+func testKeepAlive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Create server transport with a short keep-alive interval
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetLogger(testutil.NewTestLogger(t))
+	serverTransport.SetKeepAlive(50 * time.Millisecond)
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://"+serverTransport.BoundAddr()+"/events", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The idle stream should carry keep-alive comments
+	reader := bufio.NewReader(resp.Body)
+	for i := 0; i < 2; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		if line != ": keepalive\n" {
+			t.Fatalf("Expected keep-alive comment, got %q", line)
+		}
+		if blank, err := reader.ReadString('\n'); err != nil || blank != "\n" {
+			t.Fatalf("Expected blank line after keep-alive, got %q (%v)", blank, err)
+		}
+	}
+}
+
+// headerWriter is a middleware ResponseWriter that exposes the one it wraps
+type headerWriter struct {
+	http.ResponseWriter
+}
+
+func (w headerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func testMiddleware(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var order []string
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "auth")
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	headers := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "headers")
+			w.Header().Set("X-Test", "yes")
+			next.ServeHTTP(headerWriter{w}, r)
+		})
+	}
+
+	// Create server transport with middleware
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetLogger(testutil.NewTestLogger(t))
+	serverTransport.SetKeepAlive(50 * time.Millisecond)
+	serverTransport.Use(auth, headers)
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	get := func(token string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, "GET", "http://"+serverTransport.BoundAddr()+"/events", nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		return resp
+	}
+
+	resp := get("")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", resp.StatusCode)
+	}
+
+	resp = get("secret")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 with token, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Test") != "yes" {
+		t.Errorf("Expected header set by middleware, got %q", resp.Header.Get("X-Test"))
+	}
+
+	// The stream still flushes through the wrapped writer
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != ": keepalive\n" {
+		t.Errorf("Expected keep-alive comment, got %q (%v)", line, err)
+	}
+
+	want := []string{"auth", "auth", "headers"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected middleware order %v, got %v", want, order)
+	}
+}
+
+func testOrderedBurst(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetLogger(testutil.NewTestLogger(t))
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	clientTransport := NewSSEClient(serverTransport.BoundAddr())
+	clientTransport.GetRouter().EnableOrdering()
+	if err := clientTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer clientTransport.Close()
+
+	// Wait for the client to be connected
+	notification := func(n int) *types.Message {
+		params := json.RawMessage(fmt.Sprintf(`{"n":%d}`, n))
+		return &types.Message{JSONRPC: types.JSONRPCVersion, Method: "test/tick", Params: &params}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for serverTransport.Send(ctx, notification(0)) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Client never connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Far more messages than the queues hold, interleaving responses, must
+	// all arrive and in order
+	const total = 500
+	go func() {
+		for n := 1; n < total; n++ {
+			msg := notification(n)
+			if n%10 == 0 {
+				result := json.RawMessage(fmt.Sprintf(`{"n":%d}`, n))
+				msg = &types.Message{JSONRPC: types.JSONRPCVersion, ID: &types.ID{Num: uint64(n)}, Result: &result}
+			}
+			if err := serverTransport.Send(ctx, msg); err != nil {
+				t.Errorf("Send %d failed: %v", n, err)
+				return
+			}
+		}
+	}()
+
+	for want := 0; want < total; want++ {
+		select {
+		case msg := <-clientTransport.GetRouter().Inbound:
+			raw := msg.Params
+			if msg.Method == "" {
+				raw = msg.Result
+			}
+			var got struct{ N int }
+			if err := json.Unmarshal(*raw, &got); err != nil {
+				t.Fatalf("Invalid message: %v", err)
+			}
+			if got.N != want {
+				t.Fatalf("Expected message %d, got %d", want, got.N)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timeout waiting for message %d", want)
+		}
+	}
+}
+
+func testWaitForConnection(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	logger := testutil.NewTestLogger(t)
+
+	// The connected transports log nothing, as their goroutines outlive
+	// the test
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	clientTransport := NewSSEClient(serverTransport.BoundAddr())
+	if err := clientTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer clientTransport.Close()
+
+	if err := clientTransport.WaitForConnection(ctx); err != nil {
+		t.Fatalf("WaitForConnection error: %v", err)
+	}
+	// Without waiting, the server may have no client to send to yet
+	msg := &types.Message{JSONRPC: types.JSONRPCVersion, Method: "notifications/test"}
+	if err := serverTransport.Send(ctx, msg); err != nil {
+		t.Errorf("Server send after the client connected: %v", err)
+	}
+
+	// A stream that fails to connect fails held sends too
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	failing := NewSSEClient(addr)
+	failing.SetLogger(logger)
+	failing.SetBufferUntilConnected(true)
+	failing.SetReconnect(0, 0)
+	if err := failing.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer failing.Close()
+	if err := failing.Send(ctx, msg); err == nil {
+		t.Error("Held send succeeded without an event stream")
+	}
+	if err := failing.WaitForConnection(ctx); err == nil {
+		t.Error("WaitForConnection succeeded without an event stream")
+	}
+}
+
+func testStreamReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The connected transports log nothing, as their goroutines outlive
+	// the test
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	addr := serverTransport.BoundAddr()
+
+	clientTransport := NewSSEClient(addr)
+	clientTransport.SetReconnect(4, 20*time.Millisecond)
+	if err := clientTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer clientTransport.Close()
+	if err := clientTransport.WaitForConnection(ctx); err != nil {
+		t.Fatalf("WaitForConnection error: %v", err)
+	}
+
+	// The server restarts on the same address
+	serverTransport.Close()
+	restarted := NewSSEServer(addr)
+	if err := restarted.Start(ctx); err != nil {
+		t.Fatalf("Failed to restart server: %v", err)
+	}
+	defer restarted.Close()
+
+	msg := &types.Message{JSONRPC: types.JSONRPCVersion, Method: "notifications/test"}
+	for restarted.Send(ctx, msg) != nil {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Client never reconnected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	select {
+	case err := <-clientTransport.GetRouter().Errors:
+		var connErr *transport.ConnectError
+		if !errors.As(err, &connErr) || connErr.Final {
+			t.Errorf("Reported error = %v, want a connect error", err)
+		}
+	default:
+		t.Error("Lost stream was not reported")
+	}
+
+	// A server that stays away makes the client give up and close
+	restarted.Close()
+	select {
+	case <-clientTransport.Done():
+	case <-ctx.Done():
+		t.Fatal("Client did not give up")
+	}
+	if clientTransport.DoneReason() != transport.ClosedByPeer || clientTransport.Err() == nil {
+		t.Errorf("Client closed with %v, %v", clientTransport.DoneReason(), clientTransport.Err())
+	}
+	if err := clientTransport.Send(ctx, msg); err == nil {
+		t.Error("Send succeeded after the client gave up")
+	}
+}
+
+func testHTTPTimeouts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The connected transports log nothing, as their goroutines outlive
+	// the test
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetHTTPTimeouts(transport.HTTPTimeouts{
+		ReadTimeout:      50 * time.Millisecond,
+		WriteTimeout:     50 * time.Millisecond,
+		MaxConnectionAge: 300 * time.Millisecond,
+	})
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	clientTransport := NewSSEClient(serverTransport.BoundAddr())
+	clientTransport.SetHTTPTimeouts(transport.HTTPTimeouts{DialTimeout: time.Second, RequestTimeout: time.Second})
+	clientTransport.SetReconnect(3, 10*time.Millisecond)
+	if err := clientTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer clientTransport.Close()
+	if err := clientTransport.WaitForConnection(ctx); err != nil {
+		t.Fatalf("WaitForConnection error: %v", err)
+	}
+
+	// The stream outlives the server's read and write timeouts
+	time.Sleep(150 * time.Millisecond)
+	msg := &types.Message{JSONRPC: types.JSONRPCVersion, Method: "notifications/test"}
+	if err := serverTransport.Send(ctx, msg); err != nil {
+		t.Fatalf("Send past the server's timeouts: %v", err)
+	}
+	select {
+	case err := <-clientTransport.GetRouter().Errors:
+		t.Fatalf("Stream failed within its maximum age: %v", err)
+	default:
+	}
+
+	// but not its maximum age, after which the client reconnects
+	select {
+	case err := <-clientTransport.GetRouter().Errors:
+		var connErr *transport.ConnectError
+		if !errors.As(err, &connErr) || connErr.Final {
+			t.Errorf("Reported error = %v, want a connect error", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("Stream did not end at its maximum age")
+	}
+	for serverTransport.Send(ctx, msg) != nil {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Client never reconnected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func testCompression(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The connected transports log nothing, as their goroutines outlive
+	// the test
+	serverTransport := NewSSEServer("127.0.0.1:0")
+	serverTransport.SetCompression(true)
+	if err := serverTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer serverTransport.Close()
+
+	// The stream is compressed for a client accepting it
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://"+serverTransport.BoundAddr()+"/events", nil)
+	req.Header.Set("Accept-Encoding", "br, deflate;q=0.5, gzip;q=0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to get the event stream: %v", err)
+	}
+	if resp.Header.Get("Content-Encoding") != encodingDeflate || resp.Header.Get("Accept-Encoding") != acceptedEncodings {
+		t.Errorf("Stream headers = %v", resp.Header)
+	}
+	resp.Body.Close()
+
+	// and bodies in unknown codings are refused
+	req, _ = http.NewRequestWithContext(ctx, "POST", "http://"+serverTransport.BoundAddr()+"/send", strings.NewReader("{}"))
+	req.Header.Set("Content-Encoding", "br")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("Post in an unknown coding: status %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	clientTransport := NewSSEClient(serverTransport.BoundAddr())
+	clientTransport.SetCompression(true)
+	if err := clientTransport.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer clientTransport.Close()
+	if err := clientTransport.WaitForConnection(ctx); err != nil {
+		t.Fatalf("WaitForConnection error: %v", err)
+	}
+
+	params := json.RawMessage(fmt.Sprintf(`{"text":%q}`, strings.Repeat("compressible ", 1000)))
+	msg := &types.Message{JSONRPC: types.JSONRPCVersion, Method: "notifications/test", Params: &params}
+	if err := clientTransport.Send(ctx, msg); err != nil {
+		t.Fatalf("Client send: %v", err)
+	}
+	select {
+	case got := <-serverTransport.GetRouter().Notifications:
+		if got.Params == nil || string(*got.Params) != string(params) {
+			t.Error("Server received different params")
+		}
+	case <-ctx.Done():
+		t.Fatal("Server did not receive the compressed message")
+	}
+
+	if err := serverTransport.Send(ctx, msg); err != nil {
+		t.Fatalf("Server send: %v", err)
+	}
+	select {
+	case got := <-clientTransport.GetRouter().Notifications:
+		if got.Params == nil || string(*got.Params) != string(params) {
+			t.Error("Client received different params")
+		}
+	case <-ctx.Done():
+		t.Fatal("Client did not receive the compressed event")
+	}
+}
+
+// implements reports whether v implements the interface I
+func implements[I any](v interface{}) bool {
+	_, ok := v.(I)
+	return ok
+}
+
+// TestOptionalInterfaces checks that the transport implements the optional
+// interfaces it is configured through
+func TestOptionalInterfaces(t *testing.T) {
+	var tr interface{} = &SSETransport{}
+	for name, ok := range map[string]bool{
+		"KeepAliveSetter":      implements[transport.KeepAliveSetter](tr),
+		"HTTPMiddlewareUser":   implements[transport.HTTPMiddlewareUser](tr),
+		"OriginChecker":        implements[transport.OriginChecker](tr),
+		"BearerTokenSetter":    implements[transport.BearerTokenSetter](tr),
+		"AuthenticatorSetter":  implements[transport.AuthenticatorSetter](tr),
+		"SlowClientHookSetter": implements[transport.SlowClientHookSetter](tr),
+		"ConnectionWaiter":     implements[transport.ConnectionWaiter](tr),
+		"Reconnector":          implements[transport.Reconnector](tr),
+		"HTTPTimeoutsSetter":   implements[transport.HTTPTimeoutsSetter](tr),
+		"CompressionSetter":    implements[transport.CompressionSetter](tr),
+	} {
+		if !ok {
+			t.Errorf("SSETransport does not implement transport.%s", name)
+		}
+	}
+}
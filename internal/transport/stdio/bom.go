@@ -0,0 +1,32 @@
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// utf8BOM is the byte order mark some Windows programs, PowerShell among
+// them, write at the start of redirected output
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// bomSkipper reads r without a byte order mark at its start. It looks for
+// the mark on the first Read rather than when created, which would block.
+type bomSkipper struct {
+	r       *bufio.Reader
+	checked bool
+}
+
+func newBOMSkipper(r io.Reader) *bomSkipper {
+	return &bomSkipper{r: bufio.NewReader(r)}
+}
+
+func (s *bomSkipper) Read(p []byte) (int, error) {
+	if !s.checked {
+		s.checked = true
+		if head, _ := s.r.Peek(len(utf8BOM)); bytes.Equal(head, utf8BOM) {
+			s.r.Discard(len(utf8BOM))
+		}
+	}
+	return s.r.Read(p)
+}
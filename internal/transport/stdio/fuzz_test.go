@@ -0,0 +1,63 @@
+package stdio
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// readOnly adapts a reader to the io.ReadWriteCloser jsonrpc2 streams expect
+type readOnly struct {
+	io.Reader
+}
+
+func (readOnly) Write(p []byte) (int, error) { return len(p), nil }
+func (readOnly) Close() error                { return nil }
+
+func FuzzDecodeFrames(f *testing.F) {
+	seeds := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" + `{"jsonrpc":"2.0","id":"a","method":"ping","params":null}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":1,"result":{}}`,
+		`{"jsonrpc":"2.0","id":1.5,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":null,"method":"ping"}`,
+		`{"jsonrpc":"2.0","method":""}`,
+		`{"method":1}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`,
+		`{"jsonrpc":"2.0","id":1,"method":"ping"`,
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		stream := jsonrpc2.NewPlainObjectStream(readOnly{bytes.NewReader(data)})
+		defer stream.Close()
+
+		// Bound the frames read so inputs of tiny objects stay fast
+		for i := 0; i < 64; i++ {
+			var req jsonrpc2.Request
+			if err := stream.ReadObject(&req); err != nil {
+				return
+			}
+
+			msg := toMessage(&req)
+			if !req.Notif && (msg.ID == nil || *msg.ID != req.ID) {
+				t.Fatalf("Request %+v converted without its ID: %+v", req, msg)
+			}
+			if req.Notif && msg.ID != nil {
+				t.Fatalf("Notification %+v converted with an ID: %+v", req, msg)
+			}
+			if err := msg.Validate(); err != nil {
+				continue
+			}
+			if _, err := json.Marshal(msg); err != nil {
+				t.Fatalf("Marshal of valid message %+v failed: %v", msg, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+)
+
+// SigningKeyEnv names the environment variable through which a host passes
+// the hex-encoded frame signing key to a server it spawns
+const SigningKeyEnv = "MCP_STDIO_SIGNING_KEY"
+
+// sigField is the member appended to each signed frame, holding the
+// hex-encoded HMAC-SHA256 of the frame without it
+const sigField = `,"_sig":"`
+
+// sigLen is the length of the hex-encoded signature
+const sigLen = 2 * sha256.Size
+
+// maxSignedFrame bounds a single signed frame
+const maxSignedFrame = 16 << 20
+
+// NewSigningKey returns a random key for frame signing
+func NewSigningKey() ([]byte, error) {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SigningKeyFromEnv returns the key a host passed in SigningKeyEnv and
+// removes the variable, so processes this one spawns do not inherit it. It
+// returns nil if the variable is unset.
+func SigningKeyFromEnv() ([]byte, error) {
+	v, ok := os.LookupEnv(SigningKeyEnv)
+	if !ok {
+		return nil, nil
+	}
+	os.Unsetenv(SigningKeyEnv)
+	key, err := hex.DecodeString(v)
+	if err != nil || len(key) == 0 {
+		return nil, errors.New("invalid " + SigningKeyEnv)
+	}
+	return key, nil
+}
+
+// signFrame appends to dst the frame, a JSON object optionally followed by a
+// newline, with its signature added as a final "_sig" member
+func signFrame(dst []byte, key []byte, frame []byte) ([]byte, error) {
+	body := bytes.TrimSuffix(frame, []byte("\n"))
+	if len(body) < 3 || body[0] != '{' || body[len(body)-1] != '}' {
+		return nil, errors.New("cannot sign frame that is not a non-empty JSON object")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	var sig [sha256.Size]byte
+
+	dst = append(dst, body[:len(body)-1]...)
+	dst = append(dst, sigField...)
+	dst = hex.AppendEncode(dst, mac.Sum(sig[:0]))
+	return append(dst, "\"}\n"...), nil
+}
+
+// verifyFrame checks the signature of a frame written by signFrame and
+// returns the frame as it was before signing
+func verifyFrame(key []byte, frame []byte) ([]byte, bool) {
+	frame = bytes.TrimRight(frame, "\r\n")
+	n := len(frame) - len(sigField) - sigLen - 2
+	if n < 2 || string(frame[n:n+len(sigField)]) != sigField || string(frame[len(frame)-2:]) != `"}` {
+		return nil, false
+	}
+	got := make([]byte, sha256.Size)
+	if _, err := hex.Decode(got, frame[n+len(sigField):len(frame)-2]); err != nil {
+		return nil, false
+	}
+
+	body := append(frame[:n:n], '}')
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), got) {
+		return nil, false
+	}
+	return append(body, '\n'), true
+}
+
+// verifyingReader passes on the frames of a newline-delimited stream whose
+// signatures check out, with the signatures removed, and drops the rest
+type verifyingReader struct {
+	r       *bufio.Reader
+	key     []byte
+	pending []byte
+	logf    func(format string, args ...interface{})
+}
+
+func newVerifyingReader(r io.Reader, key []byte, logf func(string, ...interface{})) *verifyingReader {
+	return &verifyingReader{r: bufio.NewReader(r), key: key, logf: logf}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	for len(v.pending) == 0 {
+		line, err := v.readLine()
+		if len(bytes.TrimSpace(line)) > 0 {
+			if frame, ok := verifyFrame(v.key, line); ok {
+				v.pending = frame
+			} else {
+				v.logf("Dropping frame with missing or invalid signature")
+			}
+		}
+		if err != nil && len(v.pending) == 0 {
+			return 0, err
+		}
+		if err != nil {
+			break
+		}
+	}
+	n := copy(p, v.pending)
+	v.pending = v.pending[n:]
+	return n, nil
+}
+
+// readLine reads up to and including the next newline, failing on lines
+// longer than maxSignedFrame
+func (v *verifyingReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := v.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxSignedFrame {
+			return nil, errors.New("signed frame too large")
+		}
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
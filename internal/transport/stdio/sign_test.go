@@ -0,0 +1,143 @@
+package stdio
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestSignFrame(t *testing.T) {
+	key := []byte("k")
+	frame := []byte(`{"jsonrpc":"2.0","method":"ping"}` + "\n")
+
+	signed, err := signFrame(nil, key, frame)
+	if err != nil {
+		t.Fatalf("signFrame() error: %v", err)
+	}
+	if !json.Valid(signed) || !bytes.HasSuffix(signed, []byte("\n")) {
+		t.Fatalf("Expected a JSON frame ending in a newline, got %q", signed)
+	}
+
+	got, ok := verifyFrame(key, signed)
+	if !ok || !bytes.Equal(got, frame) {
+		t.Errorf("verifyFrame() = %q, %v; want %q, true", got, ok, frame)
+	}
+	if _, ok := verifyFrame([]byte("other"), signed); ok {
+		t.Error("Expected frame to fail verification under another key")
+	}
+	tampered := bytes.Replace(signed, []byte("ping"), []byte("pong"), 1)
+	if _, ok := verifyFrame(key, tampered); ok {
+		t.Error("Expected tampered frame to fail verification")
+	}
+	if _, ok := verifyFrame(key, frame); ok {
+		t.Error("Expected unsigned frame to fail verification")
+	}
+
+	if _, err := signFrame(nil, key, []byte("{}")); err == nil {
+		t.Error("Expected error signing an empty object")
+	}
+}
+
+func TestVerifyingReader(t *testing.T) {
+	key := []byte("k")
+	var stream bytes.Buffer
+	for _, f := range []string{`{"a":1}`, `{"b":2}`} {
+		signed, err := signFrame(nil, key, []byte(f))
+		if err != nil {
+			t.Fatalf("signFrame() error: %v", err)
+		}
+		stream.Write(signed)
+		// An injected frame between the signed ones
+		stream.WriteString(`{"injected":true}` + "\n")
+	}
+
+	var dropped int
+	r := newVerifyingReader(&stream, key, func(string, ...interface{}) { dropped++ })
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if want := "{\"a\":1}\n{\"b\":2}\n"; string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if dropped != 2 {
+		t.Errorf("Expected 2 dropped frames, got %d", dropped)
+	}
+}
+
+func TestSignedTransports(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger := testutil.NewTestLogger(t)
+	key, err := NewSigningKey()
+	if err != nil {
+		t.Fatalf("NewSigningKey() error: %v", err)
+	}
+
+	// Pipes between host and server; another process injects frames into
+	// the server's output
+	serverIn, hostOut := io.Pipe()
+	hostIn, serverOutW := io.Pipe()
+	server := NewTransport(serverIn, serverOutW)
+	host := NewTransport(hostIn, hostOut)
+	for _, tr := range []*Transport{server, host} {
+		tr.SetLogger(logger)
+		tr.SetSigningKey(key)
+		if err := tr.Start(ctx); err != nil {
+			t.Fatalf("Failed to start transport: %v", err)
+		}
+		defer tr.Close()
+	}
+
+	go func() {
+		_, _ = io.WriteString(serverOutW, `{"jsonrpc":"2.0","method":"injected"}`+"\n")
+		_ = server.WriteNotification(ctx, "notifications/genuine", json.RawMessage(`{"n":1}`))
+	}()
+
+	select {
+	case msg := <-host.GetRouter().Notifications:
+		if msg.Method != "notifications/genuine" {
+			t.Errorf("Expected the signed notification, got %q", msg.Method)
+		}
+	case <-ctx.Done():
+		t.Fatal("Timeout waiting for notification")
+	}
+
+	// Requests and replies made through jsonrpc2 are signed too
+	go func() {
+		select {
+		case req := <-server.GetRouter().Requests:
+			result := json.RawMessage(`{}`)
+			_ = server.Send(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, ID: req.ID, Result: &result})
+		case <-ctx.Done():
+		}
+	}()
+	if err := host.Send(ctx, testutil.CreateTestMessage(t, &types.ID{Num: 1}, "ping", nil)); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+}
+
+func TestSigningKeyFromEnv(t *testing.T) {
+	t.Setenv(SigningKeyEnv, hex.EncodeToString([]byte("secret")))
+	key, err := SigningKeyFromEnv()
+	if err != nil || string(key) != "secret" {
+		t.Fatalf("SigningKeyFromEnv() = %q, %v", key, err)
+	}
+	if key, err := SigningKeyFromEnv(); key != nil || err != nil {
+		t.Errorf("Expected variable to be removed, got %q, %v", key, err)
+	}
+
+	t.Setenv(SigningKeyEnv, "not hex")
+	if _, err := SigningKeyFromEnv(); err == nil || !strings.Contains(err.Error(), SigningKeyEnv) {
+		t.Errorf("Expected error naming %s, got %v", SigningKeyEnv, err)
+	}
+}
@@ -3,11 +3,15 @@ package stdio
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"sync"
+	"time"
 
-	"github.com/dwrtz/mcp-go/internal/transport"
+	"github.com/dwrtz/mcp-go/internal/goroutine"
 	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 	"github.com/dwrtz/mcp-go/pkg/types"
 	"github.com/sourcegraph/jsonrpc2"
 )
@@ -16,14 +20,43 @@ import (
 type stdioStream struct {
 	in  io.ReadCloser
 	out io.WriteCloser
+
+	// Reads come from r, which is in without a leading byte order mark or,
+	// with signing, a verifyingReader over that. Line endings may be CRLF,
+	// as from Windows programs writing in text mode: the decoder skips the
+	// carriage returns between frames, and the verifier trims them.
+	r io.Reader
+	// key signs each written frame when set
+	key []byte
+
+	// Shared with WriteNotification; jsonrpc2 writes each frame in one
+	// Write, so holding it per Write keeps frames whole
+	mu *sync.Mutex
+
+	// stats counts each frame written
+	stats *mcp.Counters
 }
 
 func (s stdioStream) Read(p []byte) (int, error) {
-	return s.in.Read(p)
+	return s.r.Read(p)
 }
 
 func (s stdioStream) Write(p []byte) (int, error) {
-	return s.out.Write(p)
+	frame := p
+	if s.key != nil {
+		var err error
+		if frame, err = signFrame(nil, s.key, p); err != nil {
+			return 0, err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.out.Write(frame); err != nil {
+		s.stats.CountError()
+		return 0, err
+	}
+	s.stats.CountOut(len(frame))
+	return len(p), nil
 }
 
 func (s stdioStream) Close() error {
@@ -35,6 +68,49 @@ func (s stdioStream) Close() error {
 	return errOut
 }
 
+// objectStream reads and writes plain JSON-RPC objects like
+// jsonrpc2.NewPlainObjectStream. It counts the size of each object read, and
+// passes the error that ends reading to failed, since jsonrpc2 does not
+// report why a connection closed.
+type objectStream struct {
+	conn    io.Closer
+	decoder *json.Decoder
+	encoder *json.Encoder
+	failed  func(error)
+	stats   *mcp.Counters
+}
+
+func newObjectStream(conn io.ReadWriteCloser, failed func(error), stats *mcp.Counters) *objectStream {
+	return &objectStream{
+		conn:    conn,
+		decoder: json.NewDecoder(conn),
+		encoder: json.NewEncoder(conn),
+		failed:  failed,
+		stats:   stats,
+	}
+}
+
+func (s *objectStream) ReadObject(v interface{}) error {
+	start := s.decoder.InputOffset()
+	if err := s.decoder.Decode(v); err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.stats.CountError()
+		}
+		s.failed(err)
+		return err
+	}
+	s.stats.CountIn(int(s.decoder.InputOffset() - start))
+	return nil
+}
+
+func (s *objectStream) WriteObject(v interface{}) error {
+	return s.encoder.Encode(v)
+}
+
+func (s *objectStream) Close() error {
+	return s.conn.Close()
+}
+
 // Transport is a Transport implementation that reads from an io.ReadCloser
 // and writes to an io.WriteCloser using the jsonrpc2 library.
 type Transport struct {
@@ -42,12 +118,31 @@ type Transport struct {
 	conn   *jsonrpc2.Conn
 	done   chan struct{}
 
-	wg     sync.WaitGroup
-	mu     sync.Mutex
-	logger *logger.Logger
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	writeMu sync.Mutex
+	logger  *logger.Logger
 
 	stdin  io.ReadCloser
 	stdout io.WriteCloser
+	stream stdioStream
+
+	// signingKey, when set, signs outgoing frames and drops incoming ones
+	// without a valid signature
+	signingKey []byte
+
+	// writeTimeout limits each write when positive, see SetWriteTimeout
+	writeTimeout time.Duration
+
+	// closed records why the transport closed
+	closed transport.CloseState
+
+	// calls holds the IDs of requests whose responses are routed as they
+	// are read, in order with the notifications around them, guarded by mu
+	calls map[jsonrpc2.ID]struct{}
+
+	// stats counts the messages read and written
+	stats mcp.Counters
 }
 
 // NewTransport constructs a transport from a read/write pair (usually pipes).
@@ -58,6 +153,7 @@ func NewTransport(stdin io.ReadCloser, stdout io.WriteCloser) *Transport {
 		logger: nil,
 		stdin:  stdin,
 		stdout: stdout,
+		calls:  make(map[jsonrpc2.ID]struct{}),
 	}
 }
 
@@ -67,51 +163,92 @@ func (t *Transport) Start(ctx context.Context) error {
 	defer t.mu.Unlock()
 
 	// Create JSON-RPC stream over stdin/stdout
-	stream := jsonrpc2.NewPlainObjectStream(stdioStream{in: t.stdin, out: t.stdout})
+	in := newBOMSkipper(t.stdin)
+	t.stream = stdioStream{in: t.stdin, out: t.stdout, r: in, mu: &t.writeMu, stats: &t.stats}
+	if t.signingKey != nil {
+		t.stream.key = t.signingKey
+		t.stream.r = newVerifyingReader(in, t.signingKey, func(format string, args ...interface{}) {
+			t.Log(context.Background(), logger.LevelWarn, format, args...)
+		})
+	}
+	stream := newObjectStream(t.stream, t.readFailed, &t.stats)
 
 	// Create the JSON-RPC handler
 	handler := jsonRPCHandler{transport: t}
 
 	// Create the connection
-	t.conn = jsonrpc2.NewConn(ctx, stream, &handler)
+	t.conn = jsonrpc2.NewConn(ctx, stream, &handler, jsonrpc2.OnRecv(func(req *jsonrpc2.Request, resp *jsonrpc2.Response) {
+		t.routeResponse(ctx, resp)
+	}))
 
-	// Start a goroutine to watch for disconnection or context cancellation
+	// Start a goroutine to watch for disconnection or context cancellation.
+	// It closes without waiting, as Close waits for it.
 	t.wg.Add(1)
-	go func() {
+	goroutine.Go("stdio/watch", func() {
 		defer t.wg.Done()
 		select {
 		case <-t.conn.DisconnectNotify():
-			t.Close() // triggers t.conn.Close() inside
 		case <-ctx.Done():
-			t.Close()
 		}
-	}()
+		t.shut(nil)
+	})
 
 	return nil
 }
 
 // Send sends a single JSON-RPC message. If it’s a request, we wait for a response.
 func (t *Transport) Send(ctx context.Context, msg *types.Message) error {
-	t.Logf("Sending message: %+v", msg)
+	t.Log(ctx, logger.LevelDebug, "Sending message: %+v", msg)
 
+	// Hold the lock only to read conn; jsonrpc2.Conn is safe for concurrent use,
+	// and holding it across a Call would block replies to requests the peer
+	// sends while answering ours
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	conn := t.conn
+	t.mu.Unlock()
 
-	if t.conn == nil {
+	if conn == nil {
 		return types.NewError(types.InternalError, "transport not started")
 	}
 
 	// If msg.Method is non-empty, this is either a request or notification:
 	if msg.Method != "" {
+		if msg.ID != nil && (msg.ID.Num != 0 || msg.ID.Str != "") {
+			// The response is routed by routeResponse when it is read
+			id := *msg.ID
+			t.mu.Lock()
+			t.calls[id] = struct{}{}
+			t.mu.Unlock()
+			var call jsonrpc2.Waiter
+			err := t.write(ctx, msg.Method, func() (err error) {
+				call, err = conn.DispatchCall(ctx, msg.Method, msg.Params, jsonrpc2.PickID(id))
+				return err
+			})
+			if err == nil {
+				err = call.Wait(ctx, nil)
+			}
+			if err != nil {
+				t.mu.Lock()
+				delete(t.calls, id)
+				t.mu.Unlock()
+				return toError(err)
+			}
+			return nil
+		}
 		if msg.ID != nil {
+			// jsonrpc2 takes a zero ID as unset and picks its own, so the
+			// response is routed here, after the call
 			var rawResult json.RawMessage
-			err := t.conn.Call(ctx, msg.Method, msg.Params, &rawResult)
-			if err != nil {
-				// Convert jsonrpc2.Error => types.ErrorResponse
-				if rpcErr, ok := err.(*jsonrpc2.Error); ok {
-					return types.NewError(int(rpcErr.Code), rpcErr.Message, rpcErr.Data)
-				}
+			var call jsonrpc2.Waiter
+			err := t.write(ctx, msg.Method, func() (err error) {
+				call, err = conn.DispatchCall(ctx, msg.Method, msg.Params)
 				return err
+			})
+			if err == nil {
+				err = call.Wait(ctx, &rawResult)
+			}
+			if err != nil {
+				return toError(err)
 			}
 
 			// Synthesize a response message for our router
@@ -124,7 +261,9 @@ func (t *Transport) Send(ctx context.Context, msg *types.Message) error {
 			return nil
 		}
 		// Otherwise it's a notification
-		return t.conn.Notify(ctx, msg.Method, msg.Params)
+		return t.write(ctx, msg.Method, func() error {
+			return conn.Notify(ctx, msg.Method, msg.Params)
+		})
 	}
 
 	// If no Method, it's a response
@@ -139,15 +278,145 @@ func (t *Transport) Send(ctx context.Context, msg *types.Message) error {
 			raw := json.RawMessage(data)
 			rawData = &raw
 		}
-		return t.conn.ReplyWithError(ctx, *msg.ID, &jsonrpc2.Error{
-			Code:    int64(msg.Error.Code),
-			Message: msg.Error.Message,
-			Data:    rawData,
+		err := t.write(ctx, "", func() error {
+			return conn.ReplyWithError(ctx, *msg.ID, &jsonrpc2.Error{
+				Code:    int64(msg.Error.Code),
+				Message: msg.Error.Message,
+				Data:    rawData,
+			})
 		})
+		if err == nil {
+			t.stats.CountError()
+		}
+		return err
 	}
 
 	// Otherwise, normal result
-	return t.conn.Reply(ctx, *msg.ID, msg.Result)
+	return t.write(ctx, "", func() error {
+		return conn.Reply(ctx, *msg.ID, msg.Result)
+	})
+}
+
+// SetWriteTimeout limits each message written to timeout, on top of the
+// deadline of the context it is sent with, so a peer that stops reading
+// cannot block senders indefinitely. It must be called before Start.
+func (t *Transport) SetWriteTimeout(timeout time.Duration) {
+	t.writeTimeout = timeout
+}
+
+// write runs op, which writes the message for method, giving up with a
+// transport.WriteTimeoutError once the deadline of ctx or the write timeout
+// passes. jsonrpc2 writes without a context, so op runs on its own: a write
+// given up on still completes whole if the peer catches up, and the writes
+// queued behind it wait for it.
+func (t *Transport) write(ctx context.Context, method string, op func() error) error {
+	ctx, cancel := transport.WriteContext(ctx, t.writeTimeout)
+	defer cancel()
+	if ctx.Done() == nil {
+		return op()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return transport.WriteError(ctx, method, ctx.Err())
+	}
+}
+
+// toError converts a jsonrpc2.Error from a call into a types.ErrorResponse
+func toError(err error) error {
+	if rpcErr, ok := err.(*jsonrpc2.Error); ok {
+		return types.NewError(int(rpcErr.Code), rpcErr.Message, rpcErr.Data)
+	}
+	return err
+}
+
+// routeResponse routes a successful response to a call made by Send as soon
+// as jsonrpc2 reads it, before any message that follows it. Failed calls are
+// reported by Send instead.
+func (t *Transport) routeResponse(ctx context.Context, resp *jsonrpc2.Response) {
+	if resp == nil {
+		return
+	}
+	if resp.Error != nil {
+		t.stats.CountError()
+	}
+	t.mu.Lock()
+	_, ok := t.calls[resp.ID]
+	delete(t.calls, resp.ID)
+	t.mu.Unlock()
+	if !ok || resp.Error != nil {
+		return
+	}
+
+	result := resp.Result
+	if result == nil {
+		null := json.RawMessage("null")
+		result = &null
+	}
+	id := resp.ID
+	t.router.Handle(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, ID: &id, Result: result})
+}
+
+// maxPooledFrame is the largest notification buffer kept for reuse
+const maxPooledFrame = 64 << 10
+
+// framePool holds buffers for notification frames, which are written before
+// WriteNotification returns
+var framePool = sync.Pool{
+	New: func() interface{} { return new([]byte) },
+}
+
+// WriteNotification implements transport.NotificationWriter
+func (t *Transport) WriteNotification(ctx context.Context, method string, params json.RawMessage) error {
+	t.Log(ctx, logger.LevelDebug, "Sending notification: %s", method)
+
+	t.mu.Lock()
+	conn := t.conn
+	t.mu.Unlock()
+	if conn == nil {
+		return types.NewError(types.InternalError, "transport not started")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	buf := framePool.Get().(*[]byte)
+	frame, err := transport.AppendNotification((*buf)[:0], method, params)
+	if err != nil {
+		framePool.Put(buf)
+		return err
+	}
+	frame = append(frame, '\n')
+	*buf = frame
+
+	select {
+	case <-t.done:
+		framePool.Put(buf)
+		return jsonrpc2.ErrClosed
+	default:
+	}
+	return t.write(ctx, method, func() error {
+		_, err := t.stream.Write(frame)
+		// Only now, as a write given up on goes on using the buffer
+		if cap(*buf) <= maxPooledFrame {
+			framePool.Put(buf)
+		}
+		return err
+	})
+}
+
+// SetSigningKey turns on frame signing. Every frame written carries an
+// HMAC-SHA256 of its content under key, and frames read without a valid one
+// are dropped, so a host can tell its spawned server's output from frames
+// injected by another process. Both ends must share the key; a host passes
+// it to the server in SigningKeyEnv. It must be called before Start.
+func (t *Transport) SetSigningKey(key []byte) {
+	t.signingKey = key
 }
 
 // GetRouter returns this transport's MessageRouter
@@ -157,24 +426,46 @@ func (t *Transport) GetRouter() *transport.MessageRouter {
 
 // Close closes the connection and signals done, but also waits for the goroutine.
 func (t *Transport) Close() error {
+	return t.CloseWithError(nil)
+}
+
+// CloseWithError implements transport.CloseReporter
+func (t *Transport) CloseWithError(err error) error {
+	if !t.shut(err) {
+		return nil
+	}
+	// Wait for the read-loop goroutine to finish so no more logs occur after return
+	t.wg.Wait()
+
+	return nil
+}
+
+// shut closes the connection and signals done, reporting false if the
+// transport was already closed
+func (t *Transport) shut(err error) bool {
+	t.closed.Set(transport.ClosedLocally, err)
+
 	t.mu.Lock()
+	defer t.mu.Unlock()
 	select {
 	case <-t.done:
-		// Already closed
-		t.mu.Unlock()
-		return nil
+		return false
 	default:
 		close(t.done)
 	}
 	if t.conn != nil {
 		_ = t.conn.Close() // forcibly kill
+	} else {
+		// Never started: the streams are still ours to close
+		_ = t.stdin.Close()
+		_ = t.stdout.Close()
 	}
-	t.mu.Unlock()
-
-	// Wait for the read-loop goroutine to finish so no more logs occur after return
-	t.wg.Wait()
+	return true
+}
 
-	return nil
+// Stats implements transport.StatsSource
+func (t *Transport) Stats() mcp.SessionStats {
+	return t.stats.Stats()
 }
 
 // Done is closed once this transport is fully closed
@@ -182,10 +473,34 @@ func (t *Transport) Done() <-chan struct{} {
 	return t.done
 }
 
+// DoneReason implements transport.CloseReporter
+func (t *Transport) DoneReason() transport.CloseReason {
+	return t.closed.Reason(t.done)
+}
+
+// Err implements transport.CloseReporter
+func (t *Transport) Err() error {
+	return t.closed.Err(t.done)
+}
+
+// readFailed records the end of the input as a close by the peer: clean at
+// EOF, and with err otherwise. After a local close it has no effect.
+func (t *Transport) readFailed(err error) {
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	t.closed.Set(transport.ClosedByPeer, err)
+}
+
 // Logf logs if we have a logger
 func (t *Transport) Logf(format string, args ...interface{}) {
+	t.Log(context.Background(), logger.LevelInfo, format, args...)
+}
+
+// Log logs at level if we have a logger
+func (t *Transport) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
 	if t.logger != nil {
-		(*t.logger).Logf(format, args...)
+		logger.Log(ctx, *t.logger, level, format, args...)
 	}
 }
 
@@ -201,8 +516,12 @@ type jsonRPCHandler struct {
 }
 
 func (h *jsonRPCHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
-	h.transport.Logf("Received message: %+v", req)
+	h.transport.Log(ctx, logger.LevelDebug, "Received message: %+v", req)
+	h.transport.router.Handle(ctx, toMessage(req))
+}
 
+// toMessage converts a request decoded by jsonrpc2 into a Message
+func toMessage(req *jsonrpc2.Request) *types.Message {
 	msg := &types.Message{
 		JSONRPC: types.JSONRPCVersion,
 		Method:  req.Method,
@@ -210,8 +529,8 @@ func (h *jsonRPCHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *j
 	}
 	if !req.Notif {
 		// If it's not a notification, it has an ID
-		msg.ID = &req.ID
+		id := req.ID
+		msg.ID = &id
 	}
-
-	h.transport.router.Handle(ctx, msg)
+	return msg
 }
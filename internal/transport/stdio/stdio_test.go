@@ -0,0 +1,216 @@
+package stdio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/transport"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestMain(m *testing.M) {
+	testutil.VerifyTestMain(m)
+}
+
+func TestCloseReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		peer       func(w *io.PipeWriter)
+		wantReason transport.CloseReason
+		wantErr    func(error) bool
+	}{
+		{
+			name:       "peer hangs up",
+			peer:       func(w *io.PipeWriter) { w.Close() },
+			wantReason: transport.ClosedByPeer,
+			wantErr:    func(err error) bool { return errors.Is(err, transport.ErrPeerClosed) },
+		},
+		{
+			name: "peer sends garbage",
+			peer: func(w *io.PipeWriter) {
+				_, _ = io.WriteString(w, "not json\n")
+			},
+			wantReason: transport.ClosedByPeer,
+			wantErr: func(err error) bool {
+				return err != nil && !errors.Is(err, transport.ErrPeerClosed)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			in, peerOut := io.Pipe()
+			_, out := io.Pipe()
+			tr := NewTransport(in, out)
+			tr.SetLogger(testutil.NewTestLogger(t))
+			if err := tr.Start(ctx); err != nil {
+				t.Fatalf("Failed to start transport: %v", err)
+			}
+			defer tr.Close()
+			if tr.DoneReason() != transport.NotClosed || tr.Err() != nil {
+				t.Fatalf("Open transport reports %v, %v", tr.DoneReason(), tr.Err())
+			}
+
+			go tt.peer(peerOut)
+			select {
+			case <-tr.Done():
+			case <-ctx.Done():
+				t.Fatal("Timeout waiting for transport to close")
+			}
+			if tr.DoneReason() != tt.wantReason || !tt.wantErr(tr.Err()) {
+				t.Errorf("Closed with %v, %v", tr.DoneReason(), tr.Err())
+			}
+
+			// Closing again doesn't change the reason
+			tr.CloseWithError(errors.New("later"))
+			if tr.DoneReason() != tt.wantReason {
+				t.Errorf("DoneReason() = %v after a later close", tr.DoneReason())
+			}
+		})
+	}
+
+	t.Run("local", func(t *testing.T) {
+		in, _ := io.Pipe()
+		_, out := io.Pipe()
+		tr := NewTransport(in, out)
+		if err := tr.Start(context.Background()); err != nil {
+			t.Fatalf("Failed to start transport: %v", err)
+		}
+		cause := errors.New("shutting down")
+		tr.CloseWithError(cause)
+		if tr.DoneReason() != transport.ClosedLocally || tr.Err() != cause {
+			t.Errorf("Closed with %v, %v; want local close with cause", tr.DoneReason(), tr.Err())
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	aIn, bOut := io.Pipe()
+	bIn, aOut := io.Pipe()
+	a, b := NewTransport(aIn, aOut), NewTransport(bIn, bOut)
+	for _, tr := range []*Transport{a, b} {
+		if err := tr.Start(ctx); err != nil {
+			t.Fatalf("Failed to start transport: %v", err)
+		}
+		defer tr.Close()
+	}
+
+	notification := &types.Message{JSONRPC: types.JSONRPCVersion, Method: "notifications/test"}
+	if err := a.Send(ctx, notification); err != nil {
+		t.Fatalf("Failed to send notification: %v", err)
+	}
+	select {
+	case <-b.GetRouter().Notifications:
+	case <-ctx.Done():
+		t.Fatal("Timeout waiting for notification")
+	}
+
+	// An error response counts as an error on both sides
+	id := types.ID{Num: 7}
+	reply := &types.Message{JSONRPC: types.JSONRPCVersion, ID: &id, Error: types.NewError(types.InvalidParams, "bad")}
+	if err := b.Send(ctx, reply); err != nil {
+		t.Fatalf("Failed to send error response: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for a.Stats().Errors == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Each side sent and received one message
+	for name, got := range map[string]mcp.SessionStats{"sender": a.Stats(), "receiver": b.Stats()} {
+		if got.MessagesIn != 1 || got.MessagesOut != 1 || got.BytesIn == 0 || got.BytesOut == 0 || got.Errors != 1 {
+			t.Errorf("%s stats = %+v, want one message each way and one error", name, got)
+		}
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	in, _ := io.Pipe()
+	peerIn, out := io.Pipe()
+	tr := NewTransport(in, out)
+	tr.SetLogger(testutil.NewTestLogger(t))
+	tr.SetWriteTimeout(time.Second)
+	if err := tr.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start transport: %v", err)
+	}
+	defer tr.Close()
+
+	// The peer does not read, so writes block until their deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	msg := &types.Message{JSONRPC: types.JSONRPCVersion, Method: "notifications/test"}
+	err := tr.Send(ctx, msg)
+	var timeout *transport.WriteTimeoutError
+	if !errors.As(err, &timeout) || timeout.Method != msg.Method || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send to a blocked pipe = %v, want a write timeout", err)
+	}
+
+	// The write timeout applies without a deadline
+	start := time.Now()
+	err = tr.WriteNotification(context.Background(), "notifications/test", nil)
+	if !errors.As(err, &timeout) || time.Since(start) < time.Second {
+		t.Fatalf("WriteNotification to a blocked pipe = %v after %v", err, time.Since(start))
+	}
+
+	// Writes given up on complete once the peer reads, and later ones follow
+	go io.Copy(io.Discard, peerIn)
+	if err := tr.Send(context.Background(), msg); err != nil {
+		t.Errorf("Send after the peer caught up = %v", err)
+	}
+}
+
+func TestWindowsFraming(t *testing.T) {
+	key, _ := NewSigningKey()
+	for name, key := range map[string][]byte{"plain": nil, "signed": key} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			in, peerOut := io.Pipe()
+			_, out := io.Pipe()
+			tr := NewTransport(in, out)
+			tr.SetLogger(testutil.NewTestLogger(t))
+			if key != nil {
+				tr.SetSigningKey(key)
+			}
+			if err := tr.Start(ctx); err != nil {
+				t.Fatalf("Failed to start transport: %v", err)
+			}
+			defer tr.Close()
+
+			// A byte order mark, then frames ending in CRLF
+			stream := []byte("\xEF\xBB\xBF")
+			for _, method := range []string{"notifications/first", "notifications/second"} {
+				frame := []byte(`{"jsonrpc":"2.0","method":"` + method + `"}` + "\n")
+				if key != nil {
+					frame, _ = signFrame(nil, key, frame)
+				}
+				stream = append(stream, frame[:len(frame)-1]...)
+				stream = append(stream, "\r\n"...)
+			}
+			go peerOut.Write(stream)
+
+			for _, want := range []string{"notifications/first", "notifications/second"} {
+				select {
+				case msg := <-tr.GetRouter().Notifications:
+					if msg.Method != want {
+						t.Errorf("Received %s, want %s", msg.Method, want)
+					}
+				case <-ctx.Done():
+					t.Fatalf("Timeout waiting for %s", want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+// Package tcp implements a transport over a plain TCP connection, for
+// servers reachable over a container network without HTTP. Each message is
+// a frame of a 4-byte big-endian length followed by that many bytes of JSON.
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/transport"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// MaxFrameSize is the largest frame read; a longer one ends the connection
+const MaxFrameSize = 16 << 20
+
+// headerSize is the size of the length prefix of a frame
+const headerSize = 4
+
+// errNotConnected is returned by sends while no peer is connected
+var errNotConnected = errors.New("no client connected")
+
+// Transport implements Transport over TCP. In server mode it accepts one
+// client at a time, each connection being a session; further connections
+// are closed until the client disconnects. In client mode the connection
+// closing closes the transport and its router.
+type Transport struct {
+	router *transport.MessageRouter
+	done   chan struct{}
+	logger logger.Logger
+
+	// addr is the address to listen on or dial, and in server mode the
+	// bound address once started
+	addr   string
+	server bool
+
+	listener net.Listener
+
+	mu sync.Mutex
+	// conn is the connected peer, guarded by mu
+	conn net.Conn
+	// session describes conn in server mode, guarded by mu
+	session *mcp.Session
+	// sessionEnd is called with each session that ends, guarded by mu
+	sessionEnd []func(*mcp.Session)
+
+	// writeMu keeps frames whole
+	writeMu sync.Mutex
+
+	// closed records why the transport closed
+	closed transport.CloseState
+	// stats counts the traffic of every session
+	stats mcp.Counters
+}
+
+// NewTCPServer creates a transport in server mode listening on addr. If addr
+// has port 0, a free port is picked; see BoundAddr.
+func NewTCPServer(addr string) *Transport {
+	return &Transport{
+		router: transport.NewMessageRouter(),
+		done:   make(chan struct{}),
+		addr:   addr,
+		server: true,
+	}
+}
+
+// NewTCPClient creates a transport in client mode connecting to addr
+func NewTCPClient(addr string) *Transport {
+	return &Transport{
+		router: transport.NewMessageRouter(),
+		done:   make(chan struct{}),
+		addr:   addr,
+	}
+}
+
+// Start listens for clients in server mode, or connects to the server in
+// client mode
+func (t *Transport) Start(ctx context.Context) error {
+	if !t.server {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", t.addr)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", t.addr, err)
+		}
+		t.mu.Lock()
+		t.conn = conn
+		t.mu.Unlock()
+		// Once the connection ends nothing more can arrive, so the router
+		// is closed, failing requests still waiting for responses. Only
+		// this goroutine routes messages, so none is routed after.
+		goroutine.Go("tcp/read", func() {
+			err := t.read(ctx, conn)
+			t.closed.Set(transport.ClosedByPeer, err)
+			t.Close()
+			t.router.Close()
+		})
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", t.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", t.addr, err)
+	}
+	t.mu.Lock()
+	t.listener = ln
+	t.addr = ln.Addr().String()
+	t.mu.Unlock()
+	goroutine.Go("tcp/accept", func() { t.accept(ctx, ln) })
+	return nil
+}
+
+// BoundAddr returns the address the server listens on, with the port picked
+// for port 0 once started
+func (t *Transport) BoundAddr() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.addr
+}
+
+// accept serves the clients connecting to ln until the transport closes
+func (t *Transport) accept(ctx context.Context, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-t.done:
+			default:
+				t.Log(ctx, logger.LevelError, "TCP accept error: %v", err)
+			}
+			return
+		}
+
+		t.mu.Lock()
+		if t.conn != nil {
+			t.mu.Unlock()
+			t.Log(ctx, logger.LevelWarn, "Refusing %s: client already connected", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		t.conn = conn
+		t.session = &mcp.Session{ID: newSessionID()}
+		t.mu.Unlock()
+
+		goroutine.Go("tcp/serve", func() { t.serve(ctx, conn) })
+	}
+}
+
+// serve reads the messages of one client, then ends its session
+func (t *Transport) serve(ctx context.Context, conn net.Conn) {
+	t.Logf("Client connected from %s", conn.RemoteAddr())
+	err := t.read(ctx, conn)
+	conn.Close()
+
+	t.mu.Lock()
+	session, callbacks := t.session, t.sessionEnd
+	t.conn = nil
+	t.session = nil
+	t.mu.Unlock()
+	for _, callback := range callbacks {
+		callback(session)
+	}
+	if err != nil {
+		t.Log(ctx, logger.LevelWarn, "Client disconnected: %v", err)
+		return
+	}
+	t.Logf("Client disconnected")
+}
+
+// read routes the messages read from conn until it closes. It returns nil
+// at a clean end of the connection.
+func (t *Transport) read(ctx context.Context, conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	for {
+		data, err := readFrame(r)
+		if err != nil {
+			select {
+			case <-t.done:
+				return nil
+			default:
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			t.countError()
+			return err
+		}
+
+		msgs, err := transport.SplitBatch(data)
+		if err != nil {
+			t.countError()
+			t.router.ReportError(&transport.DecodeError{Data: data, Err: err})
+			continue
+		}
+		for i, raw := range msgs {
+			var msg types.Message
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				t.countError()
+				t.router.ReportError(&transport.DecodeError{Data: raw, Err: err})
+				continue
+			}
+			t.countIn(frameShare(i, raw), &msg)
+			t.router.Handle(ctx, &msg)
+		}
+	}
+}
+
+// frameShare is the number of bytes of a frame counted for its ith message:
+// the message, and the header for the first
+func frameShare(i int, msg []byte) int {
+	if i == 0 {
+		return headerSize + len(msg)
+	}
+	return len(msg)
+}
+
+// readFrame reads one frame's JSON
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > MaxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds the limit of %d", n, MaxFrameSize)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// appendFrame appends a frame holding data to dst
+func appendFrame(dst, data []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(data)))
+	return append(dst, data...)
+}
+
+// Send sends a message to the connected peer
+func (t *Transport) Send(ctx context.Context, msg *types.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	err = t.write(ctx, appendFrame(make([]byte, 0, headerSize+len(data)), data))
+	t.countOut(headerSize+len(data), msg, err)
+	return err
+}
+
+// WriteNotification implements transport.NotificationWriter
+func (t *Transport) WriteNotification(ctx context.Context, method string, params json.RawMessage) error {
+	frame := make([]byte, headerSize, headerSize+len(method)+len(params)+48)
+	frame, err := transport.AppendNotification(frame, method, params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-headerSize))
+	err = t.write(ctx, frame)
+	t.countOut(len(frame), nil, err)
+	return err
+}
+
+// WriteBatch implements transport.BatchWriter
+func (t *Transport) WriteBatch(ctx context.Context, msgs []json.RawMessage) error {
+	size := headerSize + len(msgs) + 1
+	for _, msg := range msgs {
+		size += len(msg)
+	}
+	frame := transport.AppendBatch(make([]byte, headerSize, size), msgs)
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-headerSize))
+	if err := t.write(ctx, frame); err != nil {
+		t.countOut(0, nil, err)
+		return err
+	}
+	for i, msg := range msgs {
+		t.countOut(frameShare(i, msg), nil, nil)
+	}
+	return nil
+}
+
+// write writes a frame to the connected peer, giving up when ctx ends
+func (t *Transport) write(ctx context.Context, frame []byte) error {
+	t.mu.Lock()
+	conn := t.conn
+	t.mu.Unlock()
+	if conn == nil {
+		return errNotConnected
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+		defer conn.SetWriteDeadline(time.Time{})
+	}
+	_, err := conn.Write(frame)
+	return err
+}
+
+// countIn counts a received message of n bytes for the transport and the
+// connected session
+func (t *Transport) countIn(n int, msg *types.Message) {
+	t.stats.CountIn(n)
+	if session := t.Session(); session != nil {
+		session.CountIn(n)
+	}
+	if msg.Error != nil {
+		t.countError()
+	}
+}
+
+// countOut counts a message of n bytes that was sent, or an error if err is
+// set. msg is nil for notifications written with WriteNotification.
+func (t *Transport) countOut(n int, msg *types.Message, err error) {
+	if err != nil {
+		t.countError()
+		return
+	}
+	t.stats.CountOut(n)
+	if session := t.Session(); session != nil {
+		session.CountOut(n)
+	}
+	if msg != nil && msg.Error != nil {
+		t.countError()
+	}
+}
+
+// countError counts an error for the transport and the connected session
+func (t *Transport) countError() {
+	t.stats.CountError()
+	if session := t.Session(); session != nil {
+		session.CountError()
+	}
+}
+
+// Stats implements transport.StatsSource
+func (t *Transport) Stats() mcp.SessionStats {
+	return t.stats.Stats()
+}
+
+// Session implements transport.SessionSource. It describes the connected
+// client in server mode, and is nil in client mode.
+func (t *Transport) Session() *mcp.Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.session
+}
+
+// OnSessionEnd registers a callback invoked with the session of each client
+// that disconnects. It implements transport.SessionEndNotifier.
+func (t *Transport) OnSessionEnd(callback func(*mcp.Session)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionEnd = append(t.sessionEnd, callback)
+}
+
+// GetRouter returns the message router
+func (t *Transport) GetRouter() *transport.MessageRouter {
+	return t.router
+}
+
+// Close closes the listener and any connection
+func (t *Transport) Close() error {
+	return t.CloseWithError(nil)
+}
+
+// CloseWithError implements transport.CloseReporter
+func (t *Transport) CloseWithError(err error) error {
+	t.closed.Set(transport.ClosedLocally, err)
+	t.mu.Lock()
+	select {
+	case <-t.done:
+		t.mu.Unlock()
+		return nil
+	default:
+		close(t.done)
+	}
+	if t.listener != nil {
+		_ = t.listener.Close()
+	}
+	if t.conn != nil {
+		_ = t.conn.Close()
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// Done returns a channel that is closed when the transport is closed
+func (t *Transport) Done() <-chan struct{} {
+	return t.done
+}
+
+// DoneReason implements transport.CloseReporter
+func (t *Transport) DoneReason() transport.CloseReason {
+	return t.closed.Reason(t.done)
+}
+
+// Err implements transport.CloseReporter
+func (t *Transport) Err() error {
+	return t.closed.Err(t.done)
+}
+
+// Logf logs a formatted message
+func (t *Transport) Logf(format string, args ...interface{}) {
+	t.Log(context.Background(), logger.LevelInfo, format, args...)
+}
+
+// Log logs a formatted message at level
+func (t *Transport) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
+	logger.Log(ctx, t.logger, level, format, args...)
+}
+
+// SetLogger sets the logger
+func (t *Transport) SetLogger(l logger.Logger) {
+	t.logger = l
+	t.router.SetLogger(l)
+}
+
+// newSessionID returns a random session ID
+func newSessionID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,210 @@
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/transport"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestMain(m *testing.M) {
+	testutil.VerifyTestMain(m)
+}
+
+func startPair(t *testing.T, ctx context.Context) (*Transport, *Transport) {
+	t.Helper()
+	logger := testutil.NewTestLogger(t)
+
+	server := NewTCPServer("127.0.0.1:0")
+	server.SetLogger(logger)
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	client := NewTCPClient(server.BoundAddr())
+	client.SetLogger(logger)
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func receive(t *testing.T, ctx context.Context, ch <-chan *types.Message) *types.Message {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for a message")
+		return nil
+	}
+}
+
+func TestMessageExchange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server, client := startPair(t, ctx)
+
+	id := types.ID{Num: 1}
+	if err := client.Send(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, ID: &id, Method: "ping"}); err != nil {
+		t.Fatalf("Send request: %v", err)
+	}
+	req := receive(t, ctx, server.GetRouter().Requests)
+	if req.Method != "ping" || req.ID == nil || req.ID.Num != 1 {
+		t.Fatalf("Server got %+v, want ping request 1", req)
+	}
+	if server.Session() == nil {
+		t.Error("Server has no session for the connected client")
+	}
+
+	result := json.RawMessage(`{}`)
+	if err := server.Send(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, ID: req.ID, Result: &result}); err != nil {
+		t.Fatalf("Send response: %v", err)
+	}
+	if resp := receive(t, ctx, client.GetRouter().Responses); resp.ID == nil || resp.ID.Num != 1 {
+		t.Fatalf("Client got %+v, want response 1", resp)
+	}
+
+	if err := server.WriteNotification(ctx, "notifications/test", json.RawMessage(`{"n":1}`)); err != nil {
+		t.Fatalf("WriteNotification: %v", err)
+	}
+	notif := receive(t, ctx, client.GetRouter().Notifications)
+	if notif.Method != "notifications/test" || notif.Params == nil || string(*notif.Params) != `{"n":1}` {
+		t.Fatalf("Client got %+v, want the test notification", notif)
+	}
+
+	if stats := server.Stats(); stats.MessagesIn != 1 || stats.MessagesOut != 2 {
+		t.Errorf("Server stats = %+v, want 1 message in and 2 out", stats)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server, client := startPair(t, ctx)
+
+	// Wait for the server to take the connection
+	for server.Session() == nil {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for the session")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	batch := []json.RawMessage{
+		json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/first"}`),
+		json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/second"}`),
+	}
+	if err := server.WriteBatch(ctx, batch); err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	for _, method := range []string{"notifications/first", "notifications/second"} {
+		if notif := receive(t, ctx, client.GetRouter().Notifications); notif.Method != method {
+			t.Errorf("Client got %s, want %s", notif.Method, method)
+		}
+	}
+	if stats := client.Stats(); stats.MessagesIn != 2 {
+		t.Errorf("Client stats = %+v, want 2 messages in", stats)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server, client := startPair(t, ctx)
+
+	ended := make(chan *mcp.Session, 1)
+	server.OnSessionEnd(func(s *mcp.Session) { ended <- s })
+
+	// Wait for the server to take the connection
+	for server.Session() == nil {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for the session")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	first := server.Session()
+
+	// A second client is refused while the first is connected
+	conn, err := net.Dial("tcp", server.BoundAddr())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("Second client read error = %v, want EOF", err)
+	}
+	conn.Close()
+
+	client.Close()
+	select {
+	case s := <-ended:
+		if s != first {
+			t.Errorf("Ended session %v, want %v", s, first)
+		}
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for the session to end")
+	}
+
+	// The server keeps listening for the next client
+	next := NewTCPClient(server.BoundAddr())
+	if err := next.Start(ctx); err != nil {
+		t.Fatalf("Failed to reconnect: %v", err)
+	}
+	defer next.Close()
+	if err := next.WriteNotification(ctx, "notifications/test", nil); err != nil {
+		t.Fatalf("WriteNotification: %v", err)
+	}
+	receive(t, ctx, server.GetRouter().Notifications)
+	if s := server.Session(); s == nil || s == first {
+		t.Errorf("Session after reconnecting = %v, want a new session", s)
+	}
+}
+
+func TestPeerClose(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server, client := startPair(t, ctx)
+
+	server.Close()
+	select {
+	case <-client.Done():
+	case <-ctx.Done():
+		t.Fatal("Client did not close with the server")
+	}
+	if reason := client.DoneReason(); reason != transport.ClosedByPeer {
+		t.Errorf("DoneReason = %v, want ClosedByPeer", reason)
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	frame := appendFrame(nil, []byte(`{"jsonrpc":"2.0"}`))
+	data, err := readFrame(bytes.NewReader(frame))
+	if err != nil || string(data) != `{"jsonrpc":"2.0"}` {
+		t.Errorf("readFrame = %q, %v", data, err)
+	}
+
+	if _, err := readFrame(bytes.NewReader(frame[:len(frame)-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Truncated frame error = %v, want ErrUnexpectedEOF", err)
+	}
+
+	var huge [headerSize]byte
+	binary.BigEndian.PutUint32(huge[:], MaxFrameSize+1)
+	if _, err := readFrame(bytes.NewReader(huge[:])); err == nil {
+		t.Error("Oversized frame was accepted")
+	}
+}
@@ -0,0 +1,81 @@
+// Package auth maps the credentials of requests to HTTP transports to the
+// principal behind them, so servers can authorize and audit per user.
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNoCredentials is returned when a request carries no credentials
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned when credentials are not recognized
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated identity behind a session
+type Principal struct {
+	// ID identifies the user or service, e.g. for audit logs
+	ID string
+	// Attributes holds extra facts about the principal, such as roles
+	Attributes map[string]string
+}
+
+// Authenticator maps the credentials of an HTTP request to a principal. An
+// error, or a nil principal, refuses the request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+// Authenticate calls f(r)
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// StaticKeys returns an Authenticator for a fixed set of API keys, mapping
+// each key to the ID of its principal. The key is read from an
+// "Authorization: Bearer <key>" header or, failing that, an X-API-Key header.
+func StaticKeys(keys map[string]string) Authenticator {
+	entries := make([]staticKey, 0, len(keys))
+	for key, id := range keys {
+		entries = append(entries, staticKey{key: []byte(key), id: id})
+	}
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		key := APIKey(r)
+		if key == "" {
+			return nil, ErrNoCredentials
+		}
+		// Compare against every key so timing does not reveal which matched
+		var found *staticKey
+		for i := range entries {
+			if subtle.ConstantTimeCompare([]byte(key), entries[i].key) == 1 {
+				found = &entries[i]
+			}
+		}
+		if found == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return &Principal{ID: found.id}, nil
+	})
+}
+
+// staticKey is one entry of a StaticKeys authenticator
+type staticKey struct {
+	key []byte
+	id  string
+}
+
+// APIKey returns the key a request carries as a bearer token or in an
+// X-API-Key header, or "" if it has none
+func APIKey(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStaticKeys(t *testing.T) {
+	a := StaticKeys(map[string]string{"k-alice": "alice", "k-bob": "bob"})
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		wantID  string
+		wantErr error
+	}{
+		{"bearer", "Authorization", "Bearer k-alice", "alice", nil},
+		{"bearer lowercase", "Authorization", "bearer k-bob", "bob", nil},
+		{"api key header", "X-API-Key", "k-bob", "bob", nil},
+		{"unknown key", "X-API-Key", "k-eve", "", ErrInvalidCredentials},
+		{"other scheme", "Authorization", "Basic k-alice", "", ErrNoCredentials},
+		{"none", "", "", "", ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/events", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			p, err := a.Authenticate(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && (p == nil || p.ID != tt.wantID) {
+				t.Errorf("Expected principal %q, got %+v", tt.wantID, p)
+			}
+		})
+	}
+}
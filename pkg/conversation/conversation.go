@@ -0,0 +1,234 @@
+// Package conversation assembles the messages of sampling requests: it
+// accumulates the user, assistant and tool turns of a conversation and emits
+// a types.CreateMessageRequest that fits the model's token budget.
+package conversation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Defaults of a Conversation
+const (
+	DefaultMaxTokens   = 1024
+	DefaultImageTokens = 1000
+)
+
+// ErrOverBudget is returned by Request when the turns that cannot be dropped
+// do not fit the budget
+var ErrOverBudget = errors.New("conversation exceeds the token budget")
+
+// Tokenizer counts the tokens of a text as the model would
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc adapts a function to a Tokenizer
+type TokenizerFunc func(text string) int
+
+// CountTokens implements Tokenizer
+func (f TokenizerFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// EstimateTokens is the default Tokenizer: a rough count of one token per
+// four bytes, rounded up, which is close for English text with most models
+var EstimateTokens = TokenizerFunc(func(text string) int {
+	return (len(text) + 3) / 4
+})
+
+// turn is messages added together, kept or dropped as one
+type turn struct {
+	messages []types.SamplingMessage
+	tokens   int
+	pinned   bool
+}
+
+// Conversation accumulates the turns of a conversation with a model. When
+// the turns do not fit the budget, Request drops the oldest ones, keeping the
+// newest turn and those pinned. A Conversation is not safe for concurrent use.
+type Conversation struct {
+	systemPrompt string
+	turns        []turn
+	tokenizer    Tokenizer
+	budget       int
+	maxTokens    int
+	imageTokens  int
+}
+
+// Option configures a Conversation
+type Option func(*Conversation)
+
+// WithSystemPrompt sets the system prompt, which always counts against the
+// budget
+func WithSystemPrompt(prompt string) Option {
+	return func(c *Conversation) {
+		c.systemPrompt = prompt
+	}
+}
+
+// WithBudget sets the model's context window: the system prompt, the
+// messages and MaxTokens of completion must fit in tokens. Without it nothing
+// is dropped.
+func WithBudget(tokens int) Option {
+	return func(c *Conversation) {
+		c.budget = tokens
+	}
+}
+
+// WithMaxTokens sets how many tokens the model may sample, DefaultMaxTokens
+// unless set
+func WithMaxTokens(tokens int) Option {
+	return func(c *Conversation) {
+		c.maxTokens = tokens
+	}
+}
+
+// WithTokenizer counts tokens with t instead of EstimateTokens
+func WithTokenizer(t Tokenizer) Option {
+	return func(c *Conversation) {
+		c.tokenizer = t
+	}
+}
+
+// WithImageTokens sets what an image counts against the budget,
+// DefaultImageTokens unless set
+func WithImageTokens(tokens int) Option {
+	return func(c *Conversation) {
+		c.imageTokens = tokens
+	}
+}
+
+// New creates an empty conversation
+func New(opts ...Option) *Conversation {
+	c := &Conversation{
+		tokenizer:   EstimateTokens,
+		maxTokens:   DefaultMaxTokens,
+		imageTokens: DefaultImageTokens,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// User adds a turn of the user saying text
+func (c *Conversation) User(text string) *Conversation {
+	return c.Add(types.RoleUser, types.TextContent{Type: "text", Text: text})
+}
+
+// Assistant adds a turn of the model saying text, such as its previous reply
+func (c *Conversation) Assistant(text string) *Conversation {
+	return c.Add(types.RoleAssistant, types.TextContent{Type: "text", Text: text})
+}
+
+// Add adds a turn of a message from role per content item. Sampling messages
+// hold text or images, see types.CreateMessageRequest.Validate.
+func (c *Conversation) Add(role types.Role, content ...types.MessageContent) *Conversation {
+	messages := make([]types.SamplingMessage, len(content))
+	for i, item := range content {
+		messages[i] = types.SamplingMessage{Role: role, Content: item}
+	}
+	return c.add(messages)
+}
+
+// ToolResult adds a user turn reporting the result of calling the tool name,
+// introduced by a line saying whether it failed, followed by the content
+// meant for the model, see types.CallToolResult.SamplingMessages
+func (c *Conversation) ToolResult(name string, result *types.CallToolResult) *Conversation {
+	intro := fmt.Sprintf("Tool %s returned:", name)
+	if result.IsError {
+		intro = fmt.Sprintf("Tool %s failed:", name)
+	}
+	messages := []types.SamplingMessage{{Role: types.RoleUser, Content: types.TextContent{Type: "text", Text: intro}}}
+	messages = append(messages, result.SamplingMessages(types.RoleUser)...)
+	return c.add(messages)
+}
+
+// Pin keeps the last turn added from being dropped to fit the budget, as
+// for the turn stating the task
+func (c *Conversation) Pin() *Conversation {
+	if len(c.turns) > 0 {
+		c.turns[len(c.turns)-1].pinned = true
+	}
+	return c
+}
+
+// add appends a turn of messages
+func (c *Conversation) add(messages []types.SamplingMessage) *Conversation {
+	t := turn{messages: messages}
+	for _, m := range messages {
+		t.tokens += c.count(m.Content)
+	}
+	c.turns = append(c.turns, t)
+	return c
+}
+
+// count returns the tokens of a content item
+func (c *Conversation) count(content types.MessageContent) int {
+	switch content := content.(type) {
+	case types.TextContent:
+		return c.tokenizer.CountTokens(content.Text)
+	case *types.TextContent:
+		return c.tokenizer.CountTokens(content.Text)
+	case types.ImageContent, *types.ImageContent:
+		return c.imageTokens
+	}
+	return 0
+}
+
+// Len returns the number of turns added
+func (c *Conversation) Len() int {
+	return len(c.turns)
+}
+
+// Tokens returns the tokens of the system prompt and all turns added
+func (c *Conversation) Tokens() int {
+	tokens := c.tokenizer.CountTokens(c.systemPrompt)
+	for _, t := range c.turns {
+		tokens += t.tokens
+	}
+	return tokens
+}
+
+// Request returns a sampling request holding the turns that fit the budget,
+// the oldest unpinned ones dropped first, and the system prompt and
+// MaxTokens set. Other fields, such as ModelPreferences, are left for the
+// caller to fill in. Returns ErrOverBudget if the system prompt, the pinned
+// turns and the newest turn do not fit, or the request's validation error,
+// e.g. for an empty conversation.
+func (c *Conversation) Request() (*types.CreateMessageRequest, error) {
+	keep := make([]bool, len(c.turns))
+	tokens := c.Tokens()
+	for i := range c.turns {
+		keep[i] = true
+	}
+	if c.budget > 0 {
+		available := c.budget - c.maxTokens
+		for i := 0; i < len(c.turns)-1 && tokens > available; i++ {
+			if !c.turns[i].pinned {
+				keep[i] = false
+				tokens -= c.turns[i].tokens
+			}
+		}
+		if tokens > available {
+			return nil, fmt.Errorf("%w: %d tokens needed, %d available", ErrOverBudget, tokens, available)
+		}
+	}
+
+	req := &types.CreateMessageRequest{
+		SystemPrompt: c.systemPrompt,
+		MaxTokens:    c.maxTokens,
+	}
+	for i, t := range c.turns {
+		if keep[i] {
+			req.Messages = append(req.Messages, t.messages...)
+		}
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,112 @@
+package conversation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// words counts a token per word
+var words = TokenizerFunc(func(text string) int {
+	return len(strings.Fields(text))
+})
+
+// texts returns the role and text of each message, "image" for images
+func texts(messages []types.SamplingMessage) []string {
+	var out []string
+	for _, m := range messages {
+		switch c := m.Content.(type) {
+		case types.TextContent:
+			out = append(out, string(m.Role)+": "+c.Text)
+		default:
+			out = append(out, string(m.Role)+": image")
+		}
+	}
+	return out
+}
+
+func TestConversation(t *testing.T) {
+	c := New(WithSystemPrompt("be brief"), WithTokenizer(words), WithMaxTokens(100))
+	c.User("summarize the file").Pin().
+		Assistant("calling read").
+		ToolResult("read", &types.CallToolResult{Content: []interface{}{
+			types.TextContent{Type: "text", Text: "file contents"},
+			types.TextContent{Type: "text", Text: "for the user only", Annotations: &types.Annotations{Audience: []types.Role{types.RoleUser}}},
+		}}).
+		Add(types.RoleUser, types.ImageContent{Type: "image", Data: "aGk=", MimeType: "image/png"})
+
+	if c.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", c.Len())
+	}
+	// 2 + 3 + 2 + 3 + 2 + DefaultImageTokens
+	if want := 12 + DefaultImageTokens; c.Tokens() != want {
+		t.Errorf("Tokens() = %d, want %d", c.Tokens(), want)
+	}
+
+	req, err := c.Request()
+	if err != nil {
+		t.Fatalf("Request() error: %v", err)
+	}
+	if req.SystemPrompt != "be brief" || req.MaxTokens != 100 {
+		t.Errorf("Request() = %+v", req)
+	}
+	got := strings.Join(texts(req.Messages), "|")
+	want := "user: summarize the file|assistant: calling read|user: Tool read returned:|user: file contents|user: image"
+	if got != want {
+		t.Errorf("Messages = %s, want %s", got, want)
+	}
+
+	failed := New().ToolResult("read", &types.CallToolResult{IsError: true, Content: []interface{}{types.TextContent{Type: "text", Text: "no such file"}}})
+	req, err = failed.Request()
+	if err != nil || texts(req.Messages)[0] != "user: Tool read failed:" {
+		t.Errorf("Request() of a failed call = %+v, %v", req, err)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	newConversation := func(budget int) *Conversation {
+		c := New(WithSystemPrompt("system"), WithTokenizer(words), WithMaxTokens(10), WithBudget(budget))
+		c.User("the task at hand").Pin()
+		c.Assistant("first reply here")
+		c.User("more detail please")
+		c.Assistant("second reply")
+		c.User("last question")
+		return c
+	}
+
+	// 1 for the system prompt, 4 pinned, 3, 3 and 2 to drop, 2 newest and
+	// 10 to sample
+	for budget, want := range map[int]string{
+		25: "user: the task at hand|assistant: first reply here|user: more detail please|assistant: second reply|user: last question",
+		24: "user: the task at hand|user: more detail please|assistant: second reply|user: last question",
+		21: "user: the task at hand|assistant: second reply|user: last question",
+		18: "user: the task at hand|user: last question",
+		17: "user: the task at hand|user: last question",
+	} {
+		req, err := newConversation(budget).Request()
+		if err != nil {
+			t.Errorf("Request() with budget %d error: %v", budget, err)
+			continue
+		}
+		if got := strings.Join(texts(req.Messages), "|"); got != want {
+			t.Errorf("Messages with budget %d = %s, want %s", budget, got, want)
+		}
+	}
+
+	if _, err := newConversation(16).Request(); !errors.Is(err, ErrOverBudget) {
+		t.Errorf("Request() over budget error = %v, want ErrOverBudget", err)
+	}
+	if _, err := New().Request(); err == nil {
+		t.Error("Request() of an empty conversation succeeded")
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	for text, want := range map[string]int{"": 0, "a": 1, "abcd": 1, "abcde": 2} {
+		if got := EstimateTokens.CountTokens(text); got != want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package linediff computes and applies line-based diffs of text, as sent in
+// resource update notifications under types.PushDiff.
+package linediff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Edit replaces Delete lines, starting at line Start of the original text,
+// with the lines of Insert. Lines keep their line endings.
+type Edit struct {
+	Start  int      `json:"start"`
+	Delete int      `json:"delete,omitempty"`
+	Insert []string `json:"insert,omitempty"`
+}
+
+// Lines splits text into lines, each keeping its line ending. A final line
+// without one is kept as is.
+func Lines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Diff returns the edits turning old into new, or nil if they are equal. The
+// lines between their common prefix and suffix are replaced, so appending to
+// text, as to a log, yields just the appended lines.
+func Diff(old, new string) []Edit {
+	if old == new {
+		return nil
+	}
+	a, b := Lines(old), Lines(new)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return []Edit{{
+		Start:  prefix,
+		Delete: len(a) - prefix - suffix,
+		Insert: b[prefix : len(b)-suffix],
+	}}
+}
+
+// Apply applies edits to text. Edits must be sorted by Start and must not
+// overlap.
+func Apply(text string, edits []Edit) (string, error) {
+	lines := Lines(text)
+	var sb strings.Builder
+	next := 0
+	for _, e := range edits {
+		if e.Start < next || e.Delete < 0 || e.Start+e.Delete > len(lines) {
+			return "", fmt.Errorf("edit of lines %d-%d does not apply to %d lines", e.Start, e.Start+e.Delete, len(lines))
+		}
+		for _, line := range lines[next:e.Start] {
+			sb.WriteString(line)
+		}
+		for _, line := range e.Insert {
+			sb.WriteString(line)
+		}
+		next = e.Start + e.Delete
+	}
+	for _, line := range lines[next:] {
+		sb.WriteString(line)
+	}
+	return sb.String(), nil
+}
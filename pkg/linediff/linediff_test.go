@@ -0,0 +1,42 @@
+package linediff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []Edit
+	}{
+		{"equal", "a\nb\n", "a\nb\n", nil},
+		{"append", "a\nb\n", "a\nb\nc\nd\n", []Edit{{Start: 2, Insert: []string{"c\n", "d\n"}}}},
+		{"replace", "a\nb\nc\n", "a\nx\nc\n", []Edit{{Start: 1, Delete: 1, Insert: []string{"x\n"}}}},
+		{"delete", "a\nb\nc\n", "a\nc\n", []Edit{{Start: 1, Delete: 1, Insert: []string{}}}},
+		{"unterminated", "a\nb", "a\nbc", []Edit{{Start: 1, Delete: 1, Insert: []string{"bc"}}}},
+		{"from empty", "", "a\n", []Edit{{Start: 0, Insert: []string{"a\n"}}}},
+	}
+	for _, tt := range tests {
+		edits := Diff(tt.old, tt.new)
+		if !reflect.DeepEqual(edits, tt.want) {
+			t.Errorf("%s: Diff() = %+v, want %+v", tt.name, edits, tt.want)
+		}
+		if got, err := Apply(tt.old, edits); err != nil || got != tt.new {
+			t.Errorf("%s: Apply() = %q, %v, want %q", tt.name, got, err, tt.new)
+		}
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	for _, edits := range [][]Edit{
+		{{Start: 3}},
+		{{Start: 1, Delete: 2}},
+		{{Start: 1}, {Start: 0}},
+	} {
+		if _, err := Apply("a\nb\n", edits); err == nil {
+			t.Errorf("Apply(%+v) succeeded, want error", edits)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -14,6 +15,60 @@ type Logger interface {
 	Logf(format string, args ...interface{})
 }
 
+// Level is the severity of a log message
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// LeveledLogger is a Logger that also takes the level of each message and
+// the context it was logged in, which may carry the session it concerns.
+// Logf logs at LevelInfo.
+type LeveledLogger interface {
+	Logger
+	Log(ctx context.Context, level Level, format string, args ...interface{})
+}
+
+// Log logs a formatted message through l at level. Loggers that are not
+// LeveledLoggers get every message through Logf. A nil l logs nothing.
+func Log(ctx context.Context, l Logger, level Level, format string, args ...interface{}) {
+	switch l := l.(type) {
+	case nil:
+	case LeveledLogger:
+		l.Log(ctx, level, format, args...)
+	default:
+		l.Logf(format, args...)
+	}
+}
+
+// linePrefix returns the start of a line logged with prefix at level; info,
+// the level of Logf, is left unmarked
+func linePrefix(prefix string, level Level) string {
+	if level == LevelInfo {
+		return fmt.Sprintf("[%s] ", prefix)
+	}
+	return fmt.Sprintf("[%s] %s: ", prefix, strings.ToUpper(level.String()))
+}
+
 // NoopLogger implements Logger with no-op operations
 type NoopLogger struct{}
 
@@ -25,9 +80,13 @@ func NewNoopLogger() *NoopLogger {
 // Logf logs a formatted message
 func (l *NoopLogger) Logf(format string, args ...interface{}) {}
 
-// StderrLogger implements Logger using stderr
+// Log implements LeveledLogger
+func (l *NoopLogger) Log(ctx context.Context, level Level, format string, args ...interface{}) {}
+
+// StderrLogger implements LeveledLogger using stderr
 type StderrLogger struct {
 	prefix string
+	level  Level
 	mu     sync.Mutex
 }
 
@@ -36,19 +95,35 @@ func NewStderrLogger(prefix string) *StderrLogger {
 	return &StderrLogger{prefix: prefix}
 }
 
+// SetLevel drops messages below level. All messages are logged by default.
+func (l *StderrLogger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
 // Logf logs a formatted message
 func (l *StderrLogger) Logf(format string, args ...interface{}) {
+	l.Log(context.Background(), LevelInfo, format, args...)
+}
+
+// Log implements LeveledLogger
+func (l *StderrLogger) Log(ctx context.Context, level Level, format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if level < l.level {
+		return
+	}
 
-	fmt.Fprintf(os.Stderr, "[%s] ", l.prefix)
+	fmt.Fprint(os.Stderr, linePrefix(l.prefix, level))
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 }
 
-// FileLogger implements Logger using a file
+// FileLogger implements LeveledLogger using a file
 type FileLogger struct {
 	file   *os.File
 	prefix string
+	level  Level
 	mu     sync.Mutex
 }
 
@@ -74,12 +149,27 @@ func NewFileLogger(filepath string, prefix string) (*FileLogger, error) {
 	}, nil
 }
 
+// SetLevel drops messages below level. All messages are logged by default.
+func (l *FileLogger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
 // Logf logs a formatted message
 func (l *FileLogger) Logf(format string, args ...interface{}) {
+	l.Log(context.Background(), LevelInfo, format, args...)
+}
+
+// Log implements LeveledLogger
+func (l *FileLogger) Log(ctx context.Context, level Level, format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if level < l.level {
+		return
+	}
 
-	fmt.Fprintf(l.file, "[%s] ", l.prefix)
+	fmt.Fprint(l.file, linePrefix(l.prefix, level))
 	fmt.Fprintf(l.file, format+"\n", args...)
 }
 
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// plainLogger is a Logger without levels
+type plainLogger struct {
+	lines []string
+}
+
+func (l *plainLogger) Logf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestLog(t *testing.T) {
+	ctx := context.Background()
+
+	// Loggers without levels get every message unchanged
+	plain := &plainLogger{}
+	Log(ctx, plain, LevelDebug, "debug %d", 1)
+	Log(ctx, plain, LevelError, "error %d", 2)
+	if len(plain.lines) != 2 || plain.lines[0] != "debug 1" || plain.lines[1] != "error 2" {
+		t.Errorf("Plain logger got %q", plain.lines)
+	}
+
+	// A nil logger logs nothing
+	Log(ctx, nil, LevelInfo, "dropped")
+
+	dir := t.TempDir()
+	fl, err := NewFileLogger(filepath.Join(dir, "test.log"), "test")
+	if err != nil {
+		t.Fatalf("Failed to create file logger: %v", err)
+	}
+	fl.SetLevel(LevelInfo)
+	Log(ctx, fl, LevelDebug, "hidden")
+	fl.Logf("shown %s", "info")
+	Log(ctx, fl, LevelWarn, "shown warning")
+	if err := fl.Close(); err != nil {
+		t.Fatalf("Failed to close file logger: %v", err)
+	}
+
+	// The file is named after the process
+	got, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("test.%d.log", os.Getpid())))
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	want := "[test] shown info\n[test] WARN: shown warning\n"
+	if string(got) != want {
+		t.Errorf("Log file = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,247 @@
+// Package manifest declares server tools, prompts, and static resources in a
+// JSON or YAML file. Tools are bound to handlers registered in code, so
+// descriptions and schemas can be adjusted without recompiling the server.
+//
+// A YAML manifest looks like:
+//
+//	tools:
+//	  - name: search
+//	    description: Search the index
+//	    handler: index_search # defaults to the tool name
+//	    inputSchema:
+//	      type: object
+//	      properties:
+//	        query: {type: string}
+//	      required: [query]
+//	prompts:
+//	  - name: review
+//	    arguments:
+//	      - {name: file, required: true}
+//	    messages:
+//	      - role: user
+//	        text: Review {{file}} for bugs.
+//	resources:
+//	  - {uri: "file:///README.md", name: README}
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Manifest lists declared tools, prompts, and resources
+type Manifest struct {
+	Tools             []Tool                   `json:"tools,omitempty"`
+	Prompts           []Prompt                 `json:"prompts,omitempty"`
+	Resources         []types.Resource         `json:"resources,omitempty"`
+	ResourceTemplates []types.ResourceTemplate `json:"resourceTemplates,omitempty"`
+}
+
+// Tool is a declared tool
+type Tool struct {
+	types.Tool
+
+	// Handler names the handler the tool is bound to; empty means the tool's name
+	Handler string `json:"handler,omitempty"`
+}
+
+// Prompt is a declared prompt. In the text of its messages, {{name}} is
+// replaced by the argument of that name.
+type Prompt struct {
+	types.Prompt
+
+	Messages []PromptMessage `json:"messages"`
+}
+
+// PromptMessage is a message template of a declared prompt
+type PromptMessage struct {
+	Role types.Role `json:"role"`
+	Text string     `json:"text"`
+}
+
+// Render fills in the prompt's messages with args
+func (p Prompt) Render(args map[string]string) (*types.GetPromptResult, error) {
+	for _, arg := range p.Arguments {
+		if _, ok := args[arg.Name]; arg.Required && !ok {
+			return nil, fmt.Errorf("missing required argument %q", arg.Name)
+		}
+	}
+
+	replacements := make([]string, 0, 2*len(p.Arguments))
+	for _, arg := range p.Arguments {
+		replacements = append(replacements, "{{"+arg.Name+"}}", args[arg.Name])
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	result := &types.GetPromptResult{Description: p.Description}
+	for _, msg := range p.Messages {
+		result.Messages = append(result.Messages, types.PromptMessage{
+			Role:    msg.Role,
+			Content: types.TextContent{Type: "text", Text: replacer.Replace(msg.Text)},
+		})
+	}
+	return result, nil
+}
+
+// Load reads a manifest file, choosing the format by its extension: .json,
+// or .yaml and .yml
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return ParseJSON(data)
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	default:
+		return nil, fmt.Errorf("unsupported manifest format %q", ext)
+	}
+}
+
+// ParseJSON parses a JSON manifest
+func ParseJSON(data []byte) (*Manifest, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ParseYAML parses a YAML manifest. It has the same fields as the JSON form.
+func ParseYAML(data []byte) (*Manifest, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	// Go through JSON so both forms share the field names and schema types
+	converted, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return ParseJSON(converted)
+}
+
+// Merge combines manifests, e.g. one per file. Names and URIs must stay unique.
+func Merge(manifests ...*Manifest) (*Manifest, error) {
+	merged := &Manifest{}
+	for _, m := range manifests {
+		merged.Tools = append(merged.Tools, m.Tools...)
+		merged.Prompts = append(merged.Prompts, m.Prompts...)
+		merged.Resources = append(merged.Resources, m.Resources...)
+		merged.ResourceTemplates = append(merged.ResourceTemplates, m.ResourceTemplates...)
+	}
+	if err := merged.validate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// validate checks that names and URIs are unique, tools have object schemas,
+// and prompts have messages
+func (m *Manifest) validate() error {
+	seen := make(map[string]bool)
+	for i := range m.Tools {
+		t := &m.Tools[i]
+		if t.Name == "" {
+			return fmt.Errorf("invalid manifest: tool %d has no name", i)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("invalid manifest: duplicate tool %q", t.Name)
+		}
+		seen[t.Name] = true
+
+		if t.InputSchema.Type == "" {
+			t.InputSchema.Type = "object"
+		}
+		if t.InputSchema.Type != "object" {
+			return fmt.Errorf("invalid manifest: tool %q input schema must be an object, not %q", t.Name, t.InputSchema.Type)
+		}
+		for _, req := range t.InputSchema.Required {
+			if _, ok := t.InputSchema.Properties[req]; !ok {
+				return fmt.Errorf("invalid manifest: tool %q requires undeclared property %q", t.Name, req)
+			}
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i, p := range m.Prompts {
+		if p.Name == "" {
+			return fmt.Errorf("invalid manifest: prompt %d has no name", i)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("invalid manifest: duplicate prompt %q", p.Name)
+		}
+		seen[p.Name] = true
+
+		if len(p.Messages) == 0 {
+			return fmt.Errorf("invalid manifest: prompt %q has no messages", p.Name)
+		}
+		for _, msg := range p.Messages {
+			if msg.Role != types.RoleUser && msg.Role != types.RoleAssistant {
+				return fmt.Errorf("invalid manifest: prompt %q has a message with role %q", p.Name, msg.Role)
+			}
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i, r := range m.Resources {
+		if r.URI == "" || r.Name == "" {
+			return fmt.Errorf("invalid manifest: resource %d needs a uri and a name", i)
+		}
+		if seen[r.URI] {
+			return fmt.Errorf("invalid manifest: duplicate resource %q", r.URI)
+		}
+		seen[r.URI] = true
+	}
+	return nil
+}
+
+// Bind pairs each declared tool with its handler. It fails if a tool names a
+// handler that is missing, so a manifest can't silently drop a tool.
+func (m *Manifest) Bind(handlers map[string]types.ToolHandler) ([]types.McpTool, error) {
+	tools := make([]types.McpTool, 0, len(m.Tools))
+	for _, t := range m.Tools {
+		name := t.Handler
+		if name == "" {
+			name = t.Name
+		}
+		handler, ok := handlers[name]
+		if !ok {
+			return nil, fmt.Errorf("no handler %q for tool %q", name, t.Name)
+		}
+		tools = append(tools, &boundTool{def: t.Tool, handler: handler})
+	}
+	return tools, nil
+}
+
+// boundTool is a declared tool with its handler
+type boundTool struct {
+	def     types.Tool
+	handler types.ToolHandler
+}
+
+func (t *boundTool) GetName() string               { return t.def.Name }
+func (t *boundTool) GetDescription() string        { return t.def.Description }
+func (t *boundTool) GetDefinition() types.Tool     { return t.def }
+func (t *boundTool) GetHandler() types.ToolHandler { return t.handler }
+
+// Handler adapts a typed function to a ToolHandler, decoding the arguments
+// into T the way types.NewTool does
+func Handler[T any](fn types.TypedToolHandler[T]) types.ToolHandler {
+	return types.NewTool[T]("", "", fn).GetHandler()
+}
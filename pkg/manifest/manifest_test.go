@@ -0,0 +1,134 @@
+package manifest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/manifest"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+const yamlManifest = `
+tools:
+  - name: search
+    description: Search the index
+    handler: index_search
+    annotations:
+      readOnlyHint: true
+    inputSchema:
+      type: object
+      properties:
+        query: {type: string, description: Text to look for}
+      required: [query]
+  - name: ping
+    description: Check the index is up
+`
+
+const jsonManifest = `{
+  "tools": [
+    {
+      "name": "search",
+      "description": "Search the index",
+      "handler": "index_search",
+      "annotations": {"readOnlyHint": true},
+      "inputSchema": {
+        "type": "object",
+        "properties": {"query": {"type": "string", "description": "Text to look for"}},
+        "required": ["query"]
+      }
+    },
+    {"name": "ping", "description": "Check the index is up"}
+  ]
+}`
+
+type SearchInput struct {
+	Query string `json:"query"`
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	var loaded []*manifest.Manifest
+	for name, content := range map[string]string{"tools.yaml": yamlManifest, "tools.json": jsonManifest} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		m, err := manifest.Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) error: %v", name, err)
+		}
+		loaded = append(loaded, m)
+	}
+	if !reflect.DeepEqual(loaded[0], loaded[1]) {
+		t.Errorf("YAML and JSON manifests differ:\n%+v\n%+v", loaded[0], loaded[1])
+	}
+
+	m := loaded[0]
+	if len(m.Tools) != 2 {
+		t.Fatalf("Tools = %+v, want 2", m.Tools)
+	}
+	search := m.Tools[0]
+	if search.Handler != "index_search" || !search.Annotations.ReadOnly() || search.InputSchema.Required[0] != "query" {
+		t.Errorf("Unexpected search tool: %+v", search)
+	}
+	if m.Tools[1].InputSchema.Type != "object" {
+		t.Errorf("Schema type defaults to %q, want object", m.Tools[1].InputSchema.Type)
+	}
+
+	if _, err := manifest.Load(filepath.Join(dir, "tools.toml")); err == nil {
+		t.Error("Load() of a missing file succeeded")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unnamed":           `{"tools": [{"description": "x"}]}`,
+		"duplicate":         `{"tools": [{"name": "a"}, {"name": "a"}]}`,
+		"non-object schema": `{"tools": [{"name": "a", "inputSchema": {"type": "string"}}]}`,
+		"undeclared":        `{"tools": [{"name": "a", "inputSchema": {"required": ["q"]}}]}`,
+		"unknown field":     `{"tools": [{"name": "a", "descripton": "typo"}]}`,
+	}
+	for name, data := range tests {
+		if _, err := manifest.ParseJSON([]byte(data)); err == nil {
+			t.Errorf("%s: ParseJSON() succeeded", name)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	m, err := manifest.ParseYAML([]byte(yamlManifest))
+	if err != nil {
+		t.Fatalf("ParseYAML() error: %v", err)
+	}
+
+	handlers := map[string]types.ToolHandler{
+		"index_search": manifest.Handler(func(ctx context.Context, in SearchInput) (*types.CallToolResult, error) {
+			return &types.CallToolResult{Content: []interface{}{types.TextContent{Type: "text", Text: "found " + in.Query}}}, nil
+		}),
+	}
+	if _, err := m.Bind(handlers); err == nil || !strings.Contains(err.Error(), `"ping"`) {
+		t.Errorf("Bind() error = %v, want missing handler for ping", err)
+	}
+
+	handlers["ping"] = func(ctx context.Context, _ map[string]interface{}) (*types.CallToolResult, error) {
+		return &types.CallToolResult{}, nil
+	}
+	tools, err := m.Bind(handlers)
+	if err != nil {
+		t.Fatalf("Bind() error: %v", err)
+	}
+	if def := tools[0].GetDefinition(); def.Name != "search" || def.Description != "Search the index" {
+		t.Errorf("Definition = %+v, want the declared search tool", def)
+	}
+	result, err := tools[0].GetHandler()(context.Background(), map[string]interface{}{"query": "go"})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if text := result.Content[0].(types.TextContent).Text; text != "found go" {
+		t.Errorf("handler returned %q, want %q", text, "found go")
+	}
+}
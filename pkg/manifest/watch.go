@@ -0,0 +1,186 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Watcher keeps a server's catalogs in sync with manifest files. Each reload
+// parses and binds every file before touching the server, so a broken edit
+// leaves the previous catalogs in place; the catalogs that changed are then
+// swapped whole, firing their list_changed notifications.
+type Watcher struct {
+	server   *server.Server
+	handlers map[string]types.ToolHandler
+	paths    []string
+
+	mu      sync.Mutex
+	applied *Manifest
+	prompts map[string]Prompt
+	stamps  map[string]fileStamp
+	onError func(error)
+}
+
+// fileStamp identifies a version of a file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher creates a Watcher applying the manifest files at paths to s,
+// binding tools to handlers
+func NewWatcher(s *server.Server, handlers map[string]types.ToolHandler, paths ...string) *Watcher {
+	return &Watcher{
+		server:   s,
+		handlers: handlers,
+		paths:    paths,
+		applied:  &Manifest{},
+		stamps:   make(map[string]fileStamp),
+	}
+}
+
+// OnReloadError sets a function called when a reload started by Run fails
+func (w *Watcher) OnReloadError(fn func(error)) {
+	w.mu.Lock()
+	w.onError = fn
+	w.mu.Unlock()
+}
+
+// Reload reads the manifest files and applies the catalogs that changed
+func (w *Watcher) Reload(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Remember the files before parsing them, so a broken edit is reported
+	// once rather than on every poll
+	stamps := make(map[string]fileStamp, len(w.paths))
+	for _, path := range w.paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	w.stamps = stamps
+
+	manifests := make([]*Manifest, 0, len(w.paths))
+	for _, path := range w.paths {
+		m, err := Load(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		manifests = append(manifests, m)
+	}
+	next, err := Merge(manifests...)
+	if err != nil {
+		return err
+	}
+	tools, err := next.Bind(w.handlers)
+	if err != nil {
+		return err
+	}
+
+	prev := w.applied
+	if !reflect.DeepEqual(prev.Tools, next.Tools) {
+		if err := w.server.SetTools(ctx, tools); err != nil {
+			return fmt.Errorf("failed to apply tools: %w", err)
+		}
+	}
+	if !reflect.DeepEqual(prev.Prompts, next.Prompts) {
+		if err := w.applyPrompts(ctx, next.Prompts); err != nil {
+			return fmt.Errorf("failed to apply prompts: %w", err)
+		}
+	}
+	if !reflect.DeepEqual(prev.ResourceTemplates, next.ResourceTemplates) {
+		w.server.SetResourceTemplates(ctx, next.ResourceTemplates)
+	}
+	if !reflect.DeepEqual(prev.Resources, next.Resources) {
+		if err := w.server.SetResources(ctx, next.Resources); err != nil {
+			return fmt.Errorf("failed to apply resources: %w", err)
+		}
+	}
+	w.applied = next
+	return nil
+}
+
+// applyPrompts swaps the prompt list and the templates its getters render.
+// The caller must hold w.mu.
+func (w *Watcher) applyPrompts(ctx context.Context, prompts []Prompt) error {
+	byName := make(map[string]Prompt, len(prompts))
+	defs := make([]types.Prompt, 0, len(prompts))
+	for _, p := range prompts {
+		byName[p.Name] = p
+		defs = append(defs, p.Prompt)
+		if _, registered := w.prompts[p.Name]; !registered {
+			w.server.RegisterPromptGetter(p.Name, w.promptGetter(p.Name))
+		}
+	}
+	// Keep getters of removed prompts registered; they fail until the
+	// prompt is declared again
+	for name, p := range w.prompts {
+		if _, ok := byName[name]; !ok {
+			byName[name] = Prompt{Prompt: types.Prompt{Name: p.Name}}
+		}
+	}
+	w.prompts = byName
+	return w.server.SetPrompts(ctx, defs)
+}
+
+// promptGetter renders the current template of the named prompt
+func (w *Watcher) promptGetter(name string) func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+	return func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+		w.mu.Lock()
+		p := w.prompts[name]
+		w.mu.Unlock()
+		if len(p.Messages) == 0 {
+			return nil, fmt.Errorf("no prompt found with name: %s", name)
+		}
+		return p.Render(args)
+	}
+}
+
+// Run checks the manifest files every interval until ctx is done, reloading
+// when one of them changed. Call Reload first to apply the initial catalogs.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !w.changed() {
+			continue
+		}
+		if err := w.Reload(ctx); err != nil {
+			w.mu.Lock()
+			onError := w.onError
+			w.mu.Unlock()
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// changed reports whether any manifest file differs from the last reload
+func (w *Watcher) changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, path := range w.paths {
+		var stamp fileStamp
+		if info, err := os.Stat(path); err == nil {
+			stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		if w.stamps[path] != stamp {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package manifest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/mock"
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/manifest"
+	"github.com/dwrtz/mcp-go/pkg/mcp/client"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+const initialCatalog = `
+tools:
+  - name: search
+    description: Search the index
+prompts:
+  - name: review
+    arguments:
+      - {name: file, required: true}
+    messages:
+      - role: user
+        text: Review {{file}}.
+`
+
+const updatedCatalog = `
+tools:
+  - name: search
+    description: Search the index
+  - name: reindex
+    description: Rebuild the index
+prompts:
+  - name: review
+    arguments:
+      - {name: file, required: true}
+    messages:
+      - role: user
+        text: Review {{file}} for bugs.
+`
+
+func TestWatcher(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithTools(),
+		server.WithPrompts(nil),
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "catalog.yaml")
+	write := func(content string, age time.Duration) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Distinct modification times, whatever the filesystem's resolution
+		mtime := time.Now().Add(age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	noop := func(ctx context.Context, _ map[string]interface{}) (*types.CallToolResult, error) {
+		return &types.CallToolResult{}, nil
+	}
+	handlers := map[string]types.ToolHandler{"search": noop, "reindex": noop}
+
+	write(initialCatalog, -time.Hour)
+	w := manifest.NewWatcher(s, handlers, path)
+	errs := make(chan error, 1)
+	w.OnReloadError(func(err error) { errs <- err })
+	if err := w.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("ListTools() = %+v, %v; want the search tool", tools, err)
+	}
+	prompt, err := c.GetPrompt(ctx, "review", map[string]string{"file": "main.go"})
+	if err != nil {
+		t.Fatalf("GetPrompt() error: %v", err)
+	}
+	if text := prompt.Messages[0].Content.(types.TextContent).Text; text != "Review main.go." {
+		t.Errorf("Prompt text = %q", text)
+	}
+
+	promptsChanged := make(chan struct{}, 1)
+	c.OnPromptListChanged(func() {
+		select {
+		case promptsChanged <- struct{}{}:
+		default:
+		}
+	})
+	go w.Run(ctx, 10*time.Millisecond)
+
+	// A broken edit is reported and leaves the catalogs in place
+	write("tools: [{description: unnamed}]", -30*time.Minute)
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("Reload error was nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for reload error")
+	}
+	if tools, err := c.ListTools(ctx); err != nil || len(tools) != 1 {
+		t.Errorf("ListTools() after broken edit = %+v, %v; want the previous catalog", tools, err)
+	}
+
+	write(updatedCatalog, 0)
+	select {
+	case <-promptsChanged:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for prompts list_changed")
+	}
+	if tools, err := c.ListTools(ctx); err != nil || len(tools) != 2 {
+		t.Errorf("ListTools() after reload = %+v, %v; want two tools", tools, err)
+	}
+	prompt, err = c.GetPrompt(ctx, "review", map[string]string{"file": "main.go"})
+	if err != nil {
+		t.Fatalf("GetPrompt() error: %v", err)
+	}
+	if text := prompt.Messages[0].Content.(types.TextContent).Text; text != "Review main.go for bugs." {
+		t.Errorf("Prompt text after reload = %q", text)
+	}
+}
@@ -0,0 +1,203 @@
+package mcp_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/mock"
+	"github.com/dwrtz/mcp-go/internal/transport/stdio"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp/client"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/transport"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Run benchmarks with e.g.
+//
+//	go test ./pkg/mcp -run '^$' -bench . -benchmem -count 5 > new.txt
+//
+// and compare against a baseline with go run ./cmd/benchcmp old.txt new.txt,
+// or use make bench-baseline and make bench-compare
+
+// benchTransport connects a server and client with opts over one transport
+type benchTransport struct {
+	name    string
+	connect func(b *testing.B, opts ...server.Option) (*client.Client, *server.Server, func())
+}
+
+var benchTransports = []benchTransport{
+	{"InMemory", connectInMemory},
+	{"Stdio", connectStdio},
+	{"SSE", connectSse},
+}
+
+var benchResourceSizes = []int{1 << 10, 64 << 10, 1 << 20}
+
+func connectInMemory(b *testing.B, opts ...server.Option) (*client.Client, *server.Server, func()) {
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger.NewNoopLogger())
+	return connectTransports(b, serverTransport, clientTransport, opts...)
+}
+
+func connectStdio(b *testing.B, opts ...server.Option) (*client.Client, *server.Server, func()) {
+	clientRead, serverWrite := io.Pipe()
+	serverRead, clientWrite := io.Pipe()
+	serverTransport := stdio.NewTransport(serverRead, serverWrite)
+	clientTransport := stdio.NewTransport(clientRead, clientWrite)
+	return connectTransports(b, serverTransport, clientTransport, opts...)
+}
+
+func connectTransports(b *testing.B, serverTransport, clientTransport transport.Transport, opts ...server.Option) (*client.Client, *server.Server, func()) {
+	b.Helper()
+	ctx := context.Background()
+
+	s := server.NewServer(serverTransport, opts...)
+	c := client.NewClient(clientTransport)
+	if err := s.Start(ctx); err != nil {
+		b.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		b.Fatalf("Failed to start client: %v", err)
+	}
+	if err := c.Initialize(ctx); err != nil {
+		b.Fatalf("Failed to initialize: %v", err)
+	}
+	return c, s, func() {
+		c.Close()
+		s.Close()
+	}
+}
+
+func connectSse(b *testing.B, opts ...server.Option) (*client.Client, *server.Server, func()) {
+	b.Helper()
+	ctx := context.Background()
+
+	s := server.NewSseServer("127.0.0.1:0", opts...)
+	if err := s.Start(ctx); err != nil {
+		b.Fatalf("Failed to start server: %v", err)
+	}
+	// The event stream connects in the background, so an initialize sent
+	// before it is up gets no response and is retried
+	c, err := client.NewSseClient(ctx, s.BoundAddr(),
+		client.WithInitializeTimeout(time.Second),
+		client.WithInitializeRetries(5, 50*time.Millisecond),
+	)
+	if err != nil {
+		s.Close()
+		b.Fatalf("Failed to start client: %v", err)
+	}
+	if err := c.Initialize(ctx); err != nil {
+		c.Close()
+		s.Close()
+		b.Fatalf("Failed to initialize: %v", err)
+	}
+	return c, s, func() {
+		c.Close()
+		s.Close()
+	}
+}
+
+func benchEchoTool() types.McpTool {
+	return types.NewTool[EchoInput](
+		"echo_tool",
+		"Echoes back the provided input",
+		func(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+			return &types.CallToolResult{
+				Content: []interface{}{
+					types.TextContent{Type: "text", Text: input.Value},
+				},
+			}, nil
+		},
+	)
+}
+
+func BenchmarkPing(b *testing.B) {
+	for _, bt := range benchTransports {
+		b.Run(bt.name, func(b *testing.B) {
+			c, _, cleanup := bt.connect(b)
+			defer cleanup()
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := c.Ping(ctx); err != nil {
+					b.Fatalf("Ping() error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCallTool(b *testing.B) {
+	args := map[string]interface{}{"value": "hello"}
+	for _, bt := range benchTransports {
+		b.Run(bt.name, func(b *testing.B) {
+			c, _, cleanup := bt.connect(b, server.WithTools(benchEchoTool()))
+			defer cleanup()
+			ctx := context.Background()
+
+			b.Run("Serial", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := c.CallTool(ctx, "echo_tool", args); err != nil {
+						b.Fatalf("CallTool() error: %v", err)
+					}
+				}
+			})
+
+			// Concurrent callers share the one connection, as a host would
+			b.Run("Parallel", func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetParallelism(4)
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if _, err := c.CallTool(ctx, "echo_tool", args); err != nil {
+							b.Errorf("CallTool() error: %v", err)
+							return
+						}
+					}
+				})
+			})
+		})
+	}
+}
+
+func BenchmarkReadResource(b *testing.B) {
+	for _, bt := range benchTransports {
+		b.Run(bt.name, func(b *testing.B) {
+			for _, size := range benchResourceSizes {
+				b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+					text := strings.Repeat("x", size)
+					c, s, cleanup := bt.connect(b, server.WithResources(
+						[]types.Resource{{URI: "file:///large.txt", Name: "Large File", MimeType: "text/plain"}},
+						nil,
+					))
+					defer cleanup()
+					s.RegisterContentHandler("file://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+						return []types.ResourceContent{
+							types.TextResourceContents{
+								ResourceContents: types.ResourceContents{URI: uri, MimeType: "text/plain"},
+								Text:             text,
+							},
+						}, nil
+					})
+					ctx := context.Background()
+
+					b.SetBytes(int64(size))
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						if _, err := c.ReadResource(ctx, "file:///large.txt"); err != nil {
+							b.Fatalf("ReadResource() error: %v", err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// defaultReadParallelism is the number of concurrent reads ReadResources uses
+// unless WithReadParallelism is given
+const defaultReadParallelism = 4
+
+// ResourceReadResult is the outcome of reading one resource in a batch
+type ResourceReadResult struct {
+	Contents []types.ResourceContent
+	Err      error
+}
+
+// ReadOption configures ReadResources
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	parallelism int
+}
+
+// WithReadParallelism limits how many resources/read requests are in flight at once
+func WithReadParallelism(n int) ReadOption {
+	return func(o *readOptions) {
+		o.parallelism = n
+	}
+}
+
+// ReadResources reads many resources concurrently and returns the results
+// keyed by URI. A failure to read one resource is reported in its result and
+// does not affect the others. Duplicate URIs are read once.
+// Returns an error if the server does not support resources.
+func (c *Client) ReadResources(ctx context.Context, uris []string, opts ...ReadOption) (map[string]ResourceReadResult, error) {
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return nil, err
+	}
+
+	o := readOptions{parallelism: defaultReadParallelism}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.parallelism < 1 {
+		o.parallelism = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]ResourceReadResult, len(uris))
+		slots   = make(chan struct{}, o.parallelism)
+	)
+	for _, uri := range uris {
+		mu.Lock()
+		_, seen := results[uri]
+		if !seen {
+			results[uri] = ResourceReadResult{}
+		}
+		mu.Unlock()
+		if seen {
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			results[uri] = ResourceReadResult{Err: ctx.Err()}
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(uri string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			contents, err := fc.Read(ctx, uri)
+			mu.Lock()
+			results[uri] = ResourceReadResult{Contents: contents, Err: notFound(err, ErrResourceNotFound)}
+			mu.Unlock()
+		}(uri)
+	}
+	wg.Wait()
+
+	return results, nil
+}
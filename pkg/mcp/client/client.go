@@ -2,9 +2,12 @@ package client
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
+	"sync"
+	"time"
 
 	"github.com/dwrtz/mcp-go/internal/base"
 	"github.com/dwrtz/mcp-go/internal/client/prompts"
@@ -12,15 +15,19 @@ import (
 	"github.com/dwrtz/mcp-go/internal/client/roots"
 	"github.com/dwrtz/mcp-go/internal/client/sampling"
 	"github.com/dwrtz/mcp-go/internal/client/tools"
-	"github.com/dwrtz/mcp-go/internal/transport"
+	"github.com/dwrtz/mcp-go/internal/goroutine"
 	"github.com/dwrtz/mcp-go/internal/transport/sse"
 	"github.com/dwrtz/mcp-go/internal/transport/stdio"
+	"github.com/dwrtz/mcp-go/internal/transport/tcp"
 	"github.com/dwrtz/mcp-go/pkg/logger"
 	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
-// NewDefaultClient creates an MCP client with default settings
+// NewDefaultClient creates an MCP client with default settings, talking
+// over stdio to the server program at connectString, which it starts. Close
+// stops the server gracefully, see WithStopTimeout.
 func NewDefaultClient(ctx context.Context, connectString string, opts ...Option) (*Client, error) {
 	// Validate connectString
 	if connectString == "" {
@@ -29,7 +36,7 @@ func NewDefaultClient(ctx context.Context, connectString string, opts ...Option)
 
 	// 1. Start child process
 	cmd := exec.Command(connectString)
-	cmd.Stderr = os.Stderr
+	configureProcess(cmd)
 
 	// 2. Create pipes for stdio
 	serverOut, err := cmd.StdoutPipe()
@@ -41,20 +48,31 @@ func NewDefaultClient(ctx context.Context, connectString string, opts ...Option)
 		return nil, fmt.Errorf("failed to create stdin pipe for server: %w", err)
 	}
 
-	// 3. Start the process
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start server process: %w", err)
-	}
-
-	// 4. Create the stdio transport
+	// 3. Create the stdio transport
 	t := stdio.NewTransport(serverOut, serverIn)
 
-	// 5. Create the client with the user's options
+	// 4. Create the client with the user's options
 	c := NewClient(t, opts...)
-	c.cmd = cmd
+	if c.signFrames {
+		key, err := stdio.NewSigningKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create signing key: %w", err)
+		}
+		t.SetSigningKey(key)
+		cmd.Env = append(os.Environ(), stdio.SigningKeyEnv+"="+hex.EncodeToString(key))
+	}
+
+	// 5. Start the process
+	process, err := startServerProcess(cmd, c.stopTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start server process: %w", err)
+	}
+	c.process = process
+	c.base.Log(ctx, logger.LevelDebug, "Started server process %s (pid %d)", connectString, process.PID())
 	// 6. Start the transport
 	if err := c.Start(ctx); err != nil {
-		cmd.Process.Kill()
+		process.group.kill()
+		process.group.close()
 		return nil, fmt.Errorf("failed to start client: %w", err)
 	}
 
@@ -78,10 +96,22 @@ func NewSseClient(ctx context.Context, serverAddr string, opts ...Option) (*Clie
 	return c, nil
 }
 
+// NewTCPClient creates an MCP client connected over plain TCP to the server
+// at `serverAddr` (e.g. "localhost:9000"), see server.NewTCPServer
+func NewTCPClient(ctx context.Context, serverAddr string, opts ...Option) (*Client, error) {
+	c := NewClient(tcp.NewTCPClient(serverAddr), opts...)
+	if err := c.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start TCP client: %w", err)
+	}
+	return c, nil
+}
+
 // Client represents a Model Context Protocol client
 type Client struct {
 	base *base.Base
-	cmd  *exec.Cmd
+
+	// The server process started by NewDefaultClient, if any
+	process *ServerProcess
 
 	// Feature-specific clients
 	roots     *roots.Client
@@ -92,12 +122,62 @@ type Client struct {
 
 	// Client capabilities
 	capabilities types.ClientCapabilities
+
+	// Replaces the default validation of roots passed to SetRoots
+	rootValidator types.RootValidator
+
+	// Reports each handled sampling request
+	samplingUsageHook func(types.SamplingRecord)
+
+	// Sampling context inclusion, see WithSamplingContext
+	contextPolicy   ContextPolicy
+	contextMaxBytes int
+	contextSources  []ContextSource
+
+	// Server capabilities from the latest initialization, plus any implied
+	// by notifications since. Guards the feature clients too.
+	mu                 sync.RWMutex
+	initialized        bool
+	serverCapabilities types.ServerCapabilities
+	serverInfo         types.Implementation
+
+	// Connection state
+	stateMu   sync.Mutex
+	state     ConnectionState
+	stateSubs *base.Subscribers[StateChange]
+	closeErr  error
+
+	// Log messages from the server, see logging.go
+	logSubs *base.Subscribers[types.LoggingMessageNotification]
+
+	// Recent notifications, see replay.go
+	replay *replayBuffer
+
+	// Initialization settings
+	initTimeout time.Duration
+	initRetries int
+	initBackoff time.Duration
+	onHandshake func(HandshakeState)
+
+	// Sign stdio frames with a key shared with the spawned server
+	signFrames bool
+	// stopTimeout bounds each step of stopping the server process, see
+	// WithStopTimeout
+	stopTimeout time.Duration
 }
 
+// Unsubscribe removes a callback registered with one of the On* methods.
+// Multiple callbacks may be registered for the same notification; each is
+// invoked in registration order, and a panic in one does not affect the others.
+type Unsubscribe func()
+
 // Option is a function that configures a Client
 type Option func(*Client)
 
-// WithLogger sets the logger for the client
+// WithLogger sets the logger for the client and its transport. The server
+// process of NewDefaultClient is started after the options run, so its
+// start and everything the transport logs go through l whatever the order of
+// the options.
 func WithLogger(l logger.Logger) Option {
 	return func(c *Client) {
 		c.base.SetLogger(l)
@@ -114,6 +194,16 @@ func WithRoots(initialRoots []types.Root) Option {
 	}
 }
 
+// WithRootValidator replaces the validation SetRoots applies to each root,
+// e.g. types.RootSchemes(urischeme.File, urischeme.Git) for hosts that agreed
+// on git roots with their servers. By default only file:// roots are accepted.
+// Requires WithRoots.
+func WithRootValidator(validate types.RootValidator) Option {
+	return func(c *Client) {
+		c.rootValidator = validate
+	}
+}
+
 // WithSampling enables sampling functionality on the client
 func WithSampling(handler types.SamplingHandler) Option {
 	return func(c *Client) {
@@ -122,77 +212,176 @@ func WithSampling(handler types.SamplingHandler) Option {
 	}
 }
 
+// WithSamplingUsageHook sets a function called after each sampling request the
+// client handles, reporting the model used and the tokens the sampling handler
+// recorded in CreateMessageResult.Usage. Requires WithSampling.
+func WithSamplingUsageHook(hook func(types.SamplingRecord)) Option {
+	return func(c *Client) {
+		c.samplingUsageHook = hook
+	}
+}
+
+// WithLateResponseHandler sets a function called with responses that arrive
+// after the request's context was done, e.g. a slow tool call that timed out.
+// Such responses are dropped whether or not a handler is set.
+func WithLateResponseHandler(handler func(*types.Message)) Option {
+	return func(c *Client) {
+		c.base.SetLateResponseHandler(handler)
+	}
+}
+
+// WithResourceContentPush opts in to the experimental
+// types.ExperimentalResourceContentPush capability, asking servers that
+// support it to include updated contents, a hash, or a patch in update
+// notifications. Use WatchResource to have them applied, or
+// OnResourceUpdateNotification to receive them.
+func WithResourceContentPush() Option {
+	return func(c *Client) {
+		if c.capabilities.Experimental == nil {
+			c.capabilities.Experimental = make(map[string]interface{})
+		}
+		c.capabilities.Experimental[types.ExperimentalResourceContentPush] = map[string]interface{}{}
+	}
+}
+
+// WithPromptStreaming opts in to the experimental
+// types.ExperimentalPromptStreaming capability, asking servers that support
+// it to send the messages of long prompts as they are generated. Use
+// StreamPrompt to receive them.
+func WithPromptStreaming() Option {
+	return func(c *Client) {
+		if c.capabilities.Experimental == nil {
+			c.capabilities.Experimental = make(map[string]interface{})
+		}
+		c.capabilities.Experimental[types.ExperimentalPromptStreaming] = map[string]interface{}{}
+	}
+}
+
+// WithBearerAuth sends token as "Authorization: Bearer <token>" with every
+// request to an SSE server set up with the server's WithBearerAuth. It has no
+// effect on stdio clients.
+func WithBearerAuth(token string) Option {
+	return func(c *Client) {
+		c.base.SetBearerToken(token)
+	}
+}
+
+// WithWriteTimeout fails the write of any message that blocks for longer
+// than timeout, as to a server that stops reading, with a
+// *transport.WriteTimeoutError, on top of the deadline of the context it is
+// sent with. Such errors match context.DeadlineExceeded, so timed-out
+// initialize attempts are retried, see WithInitializeRetries. It has no
+// effect on TCP clients.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.base.SetWriteTimeout(timeout)
+	}
+}
+
+// WithBufferUntilConnected holds the messages an SSE client sends until its
+// event stream is connected, see WaitForConnection, so that nothing the
+// server sends in reply is lost. Sends still fail once their context is
+// done. It has no effect on other clients, which are connected once started.
+func WithBufferUntilConnected() Option {
+	return func(c *Client) {
+		c.base.SetBufferUntilConnected(true)
+	}
+}
+
+// WithHTTPTimeouts sets the dial, response header, request and idle
+// connection timeouts of an SSE client's HTTP requests. See
+// transport.HTTPTimeouts; fields for servers are ignored. It has no effect on
+// other clients.
+func WithHTTPTimeouts(timeouts transport.HTTPTimeouts) Option {
+	return func(c *Client) {
+		c.base.SetHTTPTimeouts(timeouts)
+	}
+}
+
+// WithCompression asks an SSE server for a compressed event stream, and
+// compresses messages of at least 1KiB once the server says it accepts them.
+// It has no effect on other clients.
+func WithCompression() Option {
+	return func(c *Client) {
+		c.base.SetCompression(true)
+	}
+}
+
+// WithReconnect sets how an SSE client reconnects its event stream when
+// connecting fails or the stream ends, as while the server restarts: up to
+// retries times in a row, waiting backoff before the first retry and
+// doubling it each time. Each failed attempt is passed to the OnError
+// callbacks as a *transport.ConnectError; after the last, the client closes
+// with its error. The default is 5 retries from 250ms. It has no effect on
+// other clients.
+func WithReconnect(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.base.SetReconnect(retries, backoff)
+	}
+}
+
+// WithSignedStdio makes NewDefaultClient pass the server process a fresh key
+// in MCP_STDIO_SIGNING_KEY and sign every frame with it, dropping frames from
+// the server that are not signed with it. This proves the frames come from
+// the spawned server rather than another process writing to the pipe. The
+// server must support signing, as servers from NewDefaultServer do. It has
+// no effect on clients created otherwise.
+func WithSignedStdio() Option {
+	return func(c *Client) {
+		c.signFrames = true
+	}
+}
+
 // NewClient creates a new MCP client
 func NewClient(transport transport.Transport, opts ...Option) *Client {
+	b := base.NewBase(transport)
 	c := &Client{
-		base:         base.NewBase(transport),
+		base:         b,
 		capabilities: types.ClientCapabilities{},
+		state:        StateConnecting,
+		stateSubs:    base.NewSubscribers[StateChange](b),
+		logSubs:      base.NewSubscribers[types.LoggingMessageNotification](b),
+		replay:       newReplayBuffer(),
+		stopTimeout:  DefaultStopTimeout,
 	}
+	base.HandleNotification(b, methods.Message, c.handleLogMessage)
+	b.SetNotificationObserver(c.replay.record)
 
 	// Apply options
 	for _, opt := range opts {
 		opt(c)
 	}
-
-	return c
-}
-
-// Initialize initiates the connection with the server
-func (c *Client) Initialize(ctx context.Context) error {
-	// Create initialization request
-	req := &types.InitializeRequest{
-		ProtocolVersion: types.LatestProtocolVersion,
-		Capabilities:    c.capabilities,
-		ClientInfo: types.Implementation{
-			Name:    "mcp-go",
-			Version: "0.1.0",
-		},
+	if c.roots != nil && c.rootValidator != nil {
+		c.roots.SetValidator(c.rootValidator)
 	}
-
-	// Send initialize request
-	resp, err := c.base.SendRequest(ctx, methods.Initialize, req)
-	if err != nil {
-		return fmt.Errorf("initialization failed: %w", err)
+	if c.sampling != nil && c.samplingUsageHook != nil {
+		c.sampling.SetUsageHook(c.samplingUsageHook)
 	}
-
-	// Parse server response
-	var result types.InitializeResult
-	if err := resp.UnmarshalResult(&result); err != nil {
-		return fmt.Errorf("failed to parse initialization response: %w", err)
+	if c.sampling != nil && c.contextPolicy != nil {
+		c.sampling.SetContextAssembler(c.assembleSamplingContext)
 	}
 
-	// Verify protocol version compatibility
-	if result.ProtocolVersion != types.LatestProtocolVersion {
-		return fmt.Errorf("server protocol version %s not supported", result.ProtocolVersion)
-	}
+	return c
+}
+
+// completeInitialize sets up feature clients from the server's capabilities
+// and sends the initialized notification
+func (c *Client) completeInitialize(ctx context.Context, attempt int, result *types.InitializeResult) error {
+	c.mu.Lock()
+	c.serverCapabilities = result.Capabilities
+	c.serverInfo = result.ServerInfo
+	c.initialized = true
+	c.mu.Unlock()
 
-	// Initialize feature-specific clients based on server capabilities
+	// Create feature-specific clients so their notifications are handled
 	if result.Capabilities.Resources != nil {
-		c.resources = resources.NewClient(c.base)
-		c.OnResourceListChanged(func() {
-			// default noop
-			c.base.Logf("from server: %s", methods.ResourceListChanged)
-		})
-		c.OnResourceUpdated(func(uri string) {
-			// default noop
-			c.base.Logf("from server: %s %s", methods.ResourceUpdated, uri)
-		})
+		c.resourcesHandle()
 	}
-
 	if result.Capabilities.Prompts != nil {
-		c.prompts = prompts.NewClient(c.base)
-		c.OnPromptListChanged(func() {
-			// default noop
-			c.base.Logf("from server: %s", methods.PromptsChanged)
-		})
+		c.promptsHandle()
 	}
-
 	if result.Capabilities.Tools != nil {
-		c.tools = tools.NewClient(c.base)
-		c.OnToolListChanged(func() {
-			// default noop
-			c.base.Logf("from server: %s", methods.ToolsChanged)
-		})
+		c.toolsHandle()
 	}
 
 	// Send initialized notification
@@ -200,25 +389,65 @@ func (c *Client) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to send initialized notification: %w", err)
 	}
 
+	if c.onHandshake != nil {
+		c.onHandshake(HandshakeState{Attempt: attempt, Phase: HandshakeComplete, Result: result})
+	}
+	c.setState(StateReady)
 	return nil
 }
 
-// Start begins processing messages
+// Start begins processing messages in the background, see Run
 func (c *Client) Start(ctx context.Context) error {
-	return c.base.Start(ctx)
+	if err := c.base.Start(ctx); err != nil {
+		return err
+	}
+	goroutine.Go("client/watch", c.watchDone)
+	return nil
+}
+
+// Run starts the client and blocks until it shuts down, returning why: the
+// error given to CloseWithError, nil for Close, ctx.Err() if ctx is done
+// first, or the connection's Err if the server closed it, such as
+// transport.ErrPeerClosed.
+func (c *Client) Run(ctx context.Context) error {
+	if err := c.base.Start(ctx); err != nil {
+		return err
+	}
+	c.watchDone()
+	return c.base.Wait()
 }
 
 // Close shuts down the client
 func (c *Client) Close() error {
-	_ = c.base.Close()
-	if c.cmd != nil && c.cmd.Process != nil {
-		c.cmd.Process.Kill()
-		c.cmd.Wait()
-		c.cmd = nil
+	return c.CloseWithError(nil)
+}
+
+// CloseWithError shuts down the client, recording err as the reason the
+// connection closed. A nil err is a clean close.
+func (c *Client) CloseWithError(err error) error {
+	_ = c.base.CloseWithError(err)
+	c.setClosed(c.base.Err())
+	if c.process != nil {
+		stopProcess(c.process.group, c.process.exited, c.stopTimeout)
 	}
 	return nil
 }
 
+// WaitForConnection waits until the client can receive the server's
+// messages, returning the error that prevented it if any. SSE clients
+// connect their event stream in the background after Start, and what the
+// server sends before it is connected is lost; other clients are connected
+// once started. Initialize waits for it.
+func (c *Client) WaitForConnection(ctx context.Context) error {
+	return c.base.WaitForConnection(ctx)
+}
+
+// Ping checks that the server is responsive. It returns nil once the server
+// answers the ping request.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.base.Ping(ctx)
+}
+
 // SupportsRoots returns whether the client supports roots functionality
 func (c *Client) SupportsRoots() bool {
 	return c.roots != nil
@@ -226,17 +455,23 @@ func (c *Client) SupportsRoots() bool {
 
 // SupportsResources returns whether the server supports resources functionality
 func (c *Client) SupportsResources() bool {
-	return c.resources != nil
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.serverCapabilities.Resources != nil
 }
 
 // SupportsPrompts returns whether the server supports prompts functionality
 func (c *Client) SupportsPrompts() bool {
-	return c.prompts != nil
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.serverCapabilities.Prompts != nil
 }
 
 // SupportsTools returns whether the server supports tools functionality
 func (c *Client) SupportsTools() bool {
-	return c.tools != nil
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.serverCapabilities.Tools != nil
 }
 
 // SupportsSampling returns whether the client supports sampling functionality
@@ -244,71 +479,187 @@ func (c *Client) SupportsSampling() bool {
 	return c.sampling != nil
 }
 
+// ServerCapabilities returns the capabilities the server reported during
+// initialization
+func (c *Client) ServerCapabilities() types.ServerCapabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.serverCapabilities
+}
+
+// ServerInfo returns the name and version the server reported during the most
+// recent initialization
+func (c *Client) ServerInfo() types.Implementation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.serverInfo
+}
+
+// CheckCompatibility reports which of the required features the server does
+// not support. Call it after Initialize.
+func (c *Client) CheckCompatibility(required ...types.Feature) *types.CompatibilityReport {
+	return c.ServerCapabilities().CheckCompatibility(required...)
+}
+
 // Resource Methods
 
 // ListResources returns a list of all available resources from the server.
 // Returns an error if the server does not support resources.
 func (c *Client) ListResources(ctx context.Context) ([]types.Resource, error) {
-	if !c.SupportsResources() {
-		return nil, types.NewError(types.MethodNotFound, "resources not supported")
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.resources.List(ctx)
+	return fc.List(ctx)
+}
+
+// SearchResources returns the resources passing filter. Servers advertising
+// types.ExperimentalResourceFilter filter the list themselves; for others the
+// full list is fetched and filtered here. Returns an error if the server does
+// not support resources.
+func (c *Client) SearchResources(ctx context.Context, filter types.ResourceFilter) ([]types.Resource, error) {
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	_, serverSide := c.serverCapabilities.Experimental[types.ExperimentalResourceFilter]
+	c.mu.RUnlock()
+	if serverSide {
+		return fc.Search(ctx, filter)
+	}
+
+	resources, err := fc.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []types.Resource
+	for _, r := range resources {
+		if filter.Matches(r) {
+			matched = append(matched, r)
+		}
+	}
+	return matched, nil
+}
+
+// StatResource returns the listing of the resource at uri, with its name,
+// MIME type and, if the server gives them, its size and modification time,
+// so a host can decide whether to read it. Servers advertising
+// types.ExperimentalResourceFilter are asked for it alone; from others the
+// full list is fetched. Returns an error if the server does not support
+// resources, or ErrResourceNotFound if it does not list the resource, as
+// for resources only served through templates.
+func (c *Client) StatResource(ctx context.Context, uri string) (*types.Resource, error) {
+	resources, err := c.SearchResources(ctx, types.ResourceFilter{URIPrefix: uri})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range resources {
+		if r.URI == uri {
+			return &r, nil
+		}
+	}
+	return nil, fmt.Errorf("resource %s not listed: %w", uri, ErrResourceNotFound)
 }
 
 // ReadResource retrieves the contents of a specific resource identified by its URI.
 // Returns the resource contents, which can be either text or binary data.
-// Returns an error if the server does not support resources or if the resource cannot be read.
+// Returns an error if the server does not support resources or if the resource cannot be read,
+// ErrResourceNotFound if the server has no such resource.
 func (c *Client) ReadResource(ctx context.Context, uri string) ([]types.ResourceContent, error) {
-	if !c.SupportsResources() {
-		return nil, types.NewError(types.MethodNotFound, "resources not supported")
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.resources.Read(ctx, uri)
+	contents, err := fc.Read(ctx, uri)
+	return contents, notFound(err, ErrResourceNotFound)
 }
 
 // ListResourceTemplates returns a list of available resource templates from the server.
 // Templates can be used to construct valid resource URIs.
 // Returns an error if the server does not support resources.
 func (c *Client) ListResourceTemplates(ctx context.Context) ([]types.ResourceTemplate, error) {
-	if !c.SupportsResources() {
-		return nil, types.NewError(types.MethodNotFound, "resources not supported")
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.resources.ListTemplates(ctx)
+	return fc.ListTemplates(ctx)
 }
 
 // SubscribeResource subscribes to updates for a specific resource identified by its URI.
 // The client will receive notifications through OnResourceUpdated when the resource changes.
 // Returns an error if the server does not support resources or subscriptions.
 func (c *Client) SubscribeResource(ctx context.Context, uri string) error {
-	if !c.SupportsResources() {
-		return types.NewError(types.MethodNotFound, "resources not supported")
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return err
 	}
-	return c.resources.Subscribe(ctx, uri)
+	return fc.Subscribe(ctx, uri)
+}
+
+// SubscribeResourcePattern subscribes to every resource matching a URI
+// pattern such as "file:///project/**" (see urischeme.Match). Notifications
+// name the matching resource that changed. Returns an error if the server does
+// not advertise types.ExperimentalResourceSubscribePatterns. Use
+// UnsubscribeResource with the same pattern to stop.
+func (c *Client) SubscribeResourcePattern(ctx context.Context, pattern string) error {
+	c.mu.RLock()
+	_, ok := c.serverCapabilities.Experimental[types.ExperimentalResourceSubscribePatterns]
+	c.mu.RUnlock()
+	if !ok {
+		return types.NewError(types.MethodNotFound, "resource subscription patterns not supported")
+	}
+	return c.SubscribeResource(ctx, pattern)
 }
 
 // UnsubscribeResource removes a subscription for a specific resource.
 // Returns an error if the server does not support resources or if the subscription cannot be removed.
 func (c *Client) UnsubscribeResource(ctx context.Context, uri string) error {
-	if !c.SupportsResources() {
-		return types.NewError(types.MethodNotFound, "resources not supported")
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return err
+	}
+	return fc.Unsubscribe(ctx, uri)
+}
+
+// WatchResource subscribes to a resource and keeps its contents current,
+// calling callback with the initial contents and again after each update.
+// Contents and patches pushed under WithResourceContentPush are applied
+// without reading the resource; otherwise it is read after each update. The
+// returned function stops watching and unsubscribes. Returns an error if the
+// server does not support resources or the resource cannot be read,
+// ErrResourceNotFound if the server has no such resource.
+func (c *Client) WatchResource(ctx context.Context, uri string, callback func([]types.ResourceContent)) (Unsubscribe, error) {
+	fc, err := c.resourcesClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.resources.Unsubscribe(ctx, uri)
+	stop, err := fc.Watch(ctx, uri, callback)
+	if err != nil {
+		return nil, notFound(err, ErrResourceNotFound)
+	}
+	return stop, nil
 }
 
 // OnResourceUpdated registers a callback that will be invoked when a subscribed resource changes.
 // The callback receives the URI of the updated resource.
-// No-op if the server does not support resources.
-func (c *Client) OnResourceUpdated(callback func(uri string)) {
-	if c.SupportsResources() {
-		c.resources.OnResourceUpdated(callback)
-	}
+// Callbacks may be registered before Initialize.
+func (c *Client) OnResourceUpdated(callback func(uri string)) Unsubscribe {
+	return c.resourcesHandle().OnResourceUpdated(callback)
+}
+
+// OnResourceUpdateNotification registers a callback that receives each resource update
+// notification in full. With WithResourceContentPush, the notification may carry the updated
+// contents or their hash. Callbacks may be registered before Initialize.
+func (c *Client) OnResourceUpdateNotification(callback func(types.ResourceUpdatedNotification)) Unsubscribe {
+	return c.resourcesHandle().OnResourceUpdateNotification(callback)
 }
 
 // OnResourceListChanged registers a callback that will be invoked when the list of available
-// resources changes on the server. No-op if the server does not support resources.
-func (c *Client) OnResourceListChanged(callback func()) {
-	if c.SupportsResources() {
-		c.resources.OnResourceListChanged(callback)
-	}
+// resources changes on the server. Callbacks may be registered before Initialize.
+func (c *Client) OnResourceListChanged(callback func()) Unsubscribe {
+	return c.resourcesHandle().OnResourceListChanged(callback)
 }
 
 // Prompt Methods
@@ -316,28 +667,94 @@ func (c *Client) OnResourceListChanged(callback func()) {
 // ListPrompts returns a list of all available prompts from the server.
 // Returns an error if the server does not support prompts.
 func (c *Client) ListPrompts(ctx context.Context) ([]types.Prompt, error) {
-	if !c.SupportsPrompts() {
-		return nil, types.NewError(types.MethodNotFound, "prompts not supported")
+	fc, err := c.promptsClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.prompts.List(ctx)
+	return fc.List(ctx)
+}
+
+// LookupPrompt returns the listing of a prompt, including its version and
+// deprecation, e.g. to warn before using a deprecated prompt. Returns an
+// error if the server does not support prompts, or ErrPromptNotFound if it
+// does not list the prompt.
+func (c *Client) LookupPrompt(ctx context.Context, name string) (*types.Prompt, error) {
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range prompts {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt %s not listed: %w", name, ErrPromptNotFound)
+}
+
+// PromptVersions returns the listings of a prompt and its versions served as
+// "name@version" (see types.VersionedPromptName), the prompt itself first if
+// listed. Returns an error if the server does not support prompts.
+func (c *Client) PromptVersions(ctx context.Context, name string) ([]types.Prompt, error) {
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var versions []types.Prompt
+	for _, p := range prompts {
+		switch {
+		case p.Name == name:
+			versions = append([]types.Prompt{p}, versions...)
+		case types.IsPromptVersion(p.Name, name):
+			versions = append(versions, p)
+		}
+	}
+	return versions, nil
 }
 
 // GetPrompt retrieves a specific prompt by name, with optional arguments for templating.
 // Returns the prompt content and any associated messages.
-// Returns an error if the server does not support prompts or if the prompt cannot be found.
+// Returns an error if the server does not support prompts, or ErrPromptNotFound if the prompt cannot be found.
 func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*types.GetPromptResult, error) {
-	if !c.SupportsPrompts() {
-		return nil, types.NewError(types.MethodNotFound, "prompts not supported")
+	fc, err := c.promptsClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.prompts.Get(ctx, name, arguments)
+	result, err := fc.Get(ctx, name, arguments)
+	return result, notFound(err, ErrPromptNotFound)
 }
 
-// OnPromptListChanged registers a callback that will be invoked when the list of available
-// prompts changes on the server. No-op if the server does not support prompts.
-func (c *Client) OnPromptListChanged(callback func()) {
-	if c.SupportsPrompts() {
-		c.prompts.OnPromptListChanged(callback)
+// StreamPrompt retrieves a prompt like GetPrompt, passing its messages to
+// onMessages as they are generated when the server streams them (see
+// WithPromptStreaming), and otherwise all at once. The returned result holds
+// all messages. onMessages may run on the goroutine handling incoming
+// notifications, so it must not block or make requests. Returns an error if
+// the server does not support prompts, or ErrPromptNotFound if the prompt
+// cannot be found.
+func (c *Client) StreamPrompt(ctx context.Context, name string, arguments map[string]string, onMessages func([]types.PromptMessage)) (*types.GetPromptResult, error) {
+	fc, err := c.promptsClient()
+	if err != nil {
+		return nil, err
+	}
+	result, err := fc.Stream(ctx, name, arguments, onMessages)
+	return result, notFound(err, ErrPromptNotFound)
+}
+
+// CompletePromptArgument requests suggested values for an argument of a
+// prompt, given its value so far. Returns an error if the server does not
+// support prompts, or ErrPromptNotFound if the prompt cannot be found.
+func (c *Client) CompletePromptArgument(ctx context.Context, name, argument, value string) (*types.Completion, error) {
+	fc, err := c.promptsClient()
+	if err != nil {
+		return nil, err
 	}
+	completion, err := fc.Complete(ctx, name, argument, value)
+	return completion, notFound(err, ErrPromptNotFound)
+}
+
+// OnPromptListChanged registers a callback that will be invoked when the list of available
+// prompts changes on the server. Callbacks may be registered before Initialize.
+func (c *Client) OnPromptListChanged(callback func()) Unsubscribe {
+	return c.promptsHandle().OnPromptListChanged(callback)
 }
 
 // Tool Methods
@@ -345,34 +762,83 @@ func (c *Client) OnPromptListChanged(callback func()) {
 // ListTools returns a list of all available tools from the server.
 // Returns an error if the server does not support tools.
 func (c *Client) ListTools(ctx context.Context) ([]types.Tool, error) {
-	if !c.SupportsTools() {
-		return nil, types.NewError(types.MethodNotFound, "tools not supported")
+	fc, err := c.toolsClient()
+	if err != nil {
+		return nil, err
+	}
+	return fc.List(ctx)
+}
+
+// ListToolsFiltered returns the tools whose annotations pass filter, e.g.
+// only read-only tools for auto-approval. Servers advertising
+// types.ExperimentalToolFilter filter the list themselves; for others the full
+// list is fetched and filtered here. Returns an error if the server does not
+// support tools.
+func (c *Client) ListToolsFiltered(ctx context.Context, filter types.ToolFilter) ([]types.Tool, error) {
+	fc, err := c.toolsClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.tools.List(ctx)
+
+	c.mu.RLock()
+	_, serverSide := c.serverCapabilities.Experimental[types.ExperimentalToolFilter]
+	c.mu.RUnlock()
+	if serverSide {
+		return fc.ListFiltered(ctx, filter)
+	}
+
+	tools, err := fc.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []types.Tool
+	for _, t := range tools {
+		if filter.Matches(t) {
+			matched = append(matched, t)
+		}
+	}
+	return matched, nil
 }
 
 // CallTool invokes a specific tool by name with the provided arguments.
 // Returns the tool's execution result or an error if the tool cannot be called.
-// Returns an error if the server does not support tools.
+// Returns an error if the server does not support tools, or ErrToolNotFound if it has no such tool.
 func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*types.CallToolResult, error) {
-	if !c.SupportsTools() {
-		return nil, types.NewError(types.MethodNotFound, "tools not supported")
+	fc, err := c.toolsClient()
+	if err != nil {
+		return nil, err
 	}
-	return c.tools.Call(ctx, name, arguments)
+	result, err := fc.Call(ctx, name, arguments)
+	return result, notFound(err, ErrToolNotFound)
 }
 
-// OnToolListChanged registers a callback that will be invoked when the list of available
-// tools changes on the server. No-op if the server does not support tools.
-func (c *Client) OnToolListChanged(callback func()) {
-	if c.SupportsTools() {
-		c.tools.OnToolListChanged(callback)
+// CallToolStructured calls a tool like CallTool and decodes the structured
+// content of its result into out, see types.CallToolResult.DecodeStructuredContent.
+// An error result is returned with an error, leaving out untouched.
+func (c *Client) CallToolStructured(ctx context.Context, name string, arguments map[string]interface{}, out interface{}) (*types.CallToolResult, error) {
+	result, err := c.CallTool(ctx, name, arguments)
+	if err != nil {
+		return nil, err
 	}
+	if result.IsError {
+		return result, fmt.Errorf("tool %s returned an error result", name)
+	}
+	if err := result.DecodeStructuredContent(out); err != nil {
+		return result, fmt.Errorf("failed to decode result of tool %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// OnToolListChanged registers a callback that will be invoked when the list of available
+// tools changes on the server. Callbacks may be registered before Initialize.
+func (c *Client) OnToolListChanged(callback func()) Unsubscribe {
+	return c.toolsHandle().OnToolListChanged(callback)
 }
 
 // Root Methods
 
 // SetRoots updates the list of root directories that the client exposes to the server.
-// Each root must be a valid file:// URI.
+// Each root must pass the WithRootValidator validator, which by default accepts only file:// URIs.
 // Returns an error if the client does not support roots or if any root is invalid.
 func (c *Client) SetRoots(ctx context.Context, roots []types.Root) error {
 	if !c.SupportsRoots() {
@@ -380,3 +846,13 @@ func (c *Client) SetRoots(ctx context.Context, roots []types.Root) error {
 	}
 	return c.roots.SetRoots(ctx, roots)
 }
+
+// SamplingUsage returns the aggregate usage of the sampling requests the client
+// has handled for its server, for metering LLM spend per connection. It is
+// zero if sampling is not enabled.
+func (c *Client) SamplingUsage() types.SamplingTotals {
+	if !c.SupportsSampling() {
+		return types.SamplingTotals{}
+	}
+	return c.sampling.Usage()
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Errors reported, wrapping the server's *types.ErrorResponse, when the
+// server does not have the tool, prompt or resource a request names, e.g. a
+// tool removed since it was listed. Test for them with errors.Is; errors.As
+// still finds the server's response.
+var (
+	ErrToolNotFound     = errors.New("tool not found")
+	ErrPromptNotFound   = errors.New("prompt not found")
+	ErrResourceNotFound = errors.New("resource not found")
+)
+
+// notFoundMessages are the messages, in lowercase, of servers that did not
+// find what was asked for, this package's and others'
+var notFoundMessages = map[error][]string{
+	ErrToolNotFound:     {"no handler found for tool", "unknown tool", "tool not found"},
+	ErrPromptNotFound:   {"no prompt found", "unknown prompt", "prompt not found"},
+	ErrResourceNotFound: {"no handler found for uri", "unknown resource", "resource not found"},
+}
+
+// notFoundError is an error holding a response meaning kind
+type notFoundError struct {
+	kind error
+	err  error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+// notFound returns err as kind, one of the errors above, if it is the
+// server's response that it has no such thing, and err otherwise
+func notFound(err error, kind error) error {
+	var resp *types.ErrorResponse
+	if !errors.As(err, &resp) {
+		return err
+	}
+	if kind == ErrResourceNotFound && resp.Code == types.ResourceNotFound {
+		return &notFoundError{kind: kind, err: err}
+	}
+	// A method the server does not support is not a missing item
+	if resp.Code == types.MethodNotFound {
+		return err
+	}
+	message := strings.ToLower(resp.Message)
+	for _, m := range notFoundMessages[kind] {
+		if strings.Contains(message, m) {
+			return &notFoundError{kind: kind, err: err}
+		}
+	}
+	return err
+}
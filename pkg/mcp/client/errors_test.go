@@ -0,0 +1,42 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestNotFound(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		kind error
+		want bool
+	}{
+		{types.NewError(types.InvalidParams, "no handler found for tool: x"), ErrToolNotFound, true},
+		{types.NewError(types.InvalidParams, "Unknown tool: x"), ErrToolNotFound, true},
+		{types.NewError(types.InternalError, "no prompt found with name: x"), ErrPromptNotFound, true},
+		{types.NewError(types.ResourceNotFound, "missing"), ErrResourceNotFound, true},
+		{fmt.Errorf("reading: %w", types.NewError(types.InvalidParams, "Resource not found: x")), ErrResourceNotFound, true},
+		{types.NewError(types.ResourceNotFound, "missing"), ErrToolNotFound, false},
+		{types.NewError(types.MethodNotFound, "unknown tool"), ErrToolNotFound, false},
+		{types.NewError(types.InvalidParams, "invalid arguments"), ErrToolNotFound, false},
+		{errors.New("unknown tool"), ErrToolNotFound, false},
+	} {
+		err := notFound(tc.err, tc.kind)
+		if errors.Is(err, tc.kind) != tc.want {
+			t.Errorf("notFound(%v, %v) = %v, want matching %v", tc.err, tc.kind, err, tc.want)
+		}
+		var resp *types.ErrorResponse
+		if errors.As(tc.err, &resp) && !errors.As(err, &resp) {
+			t.Errorf("notFound(%v) lost the error response", tc.err)
+		}
+		if err.Error() != tc.err.Error() && tc.want {
+			t.Errorf("notFound(%v) = %q, want the message kept", tc.err, err.Error())
+		}
+	}
+	if notFound(nil, ErrToolNotFound) != nil {
+		t.Error("notFound(nil) != nil")
+	}
+}
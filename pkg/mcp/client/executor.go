@@ -0,0 +1,55 @@
+package client
+
+// CallbackExecutor runs notification callbacks such as those registered with
+// OnResourceUpdated. Executors that run callbacks asynchronously do not
+// preserve delivery order.
+type CallbackExecutor interface {
+	Execute(callback func())
+}
+
+// CallbackExecutorFunc adapts a function to a CallbackExecutor
+type CallbackExecutorFunc func(callback func())
+
+// Execute calls f(callback)
+func (f CallbackExecutorFunc) Execute(callback func()) {
+	f(callback)
+}
+
+// InlineExecutor runs callbacks on the goroutine handling the notification.
+// This is the default; callbacks for one notification run one after another.
+func InlineExecutor() CallbackExecutor {
+	return CallbackExecutorFunc(func(callback func()) {
+		callback()
+	})
+}
+
+// GoroutineExecutor runs each callback on its own goroutine
+func GoroutineExecutor() CallbackExecutor {
+	return CallbackExecutorFunc(func(callback func()) {
+		go callback()
+	})
+}
+
+// PoolExecutor runs callbacks on goroutines, at most size at a time. When all
+// slots are busy, further callbacks wait for one to free up. A size of 1
+// runs callbacks one at a time.
+func PoolExecutor(size int) CallbackExecutor {
+	if size < 1 {
+		size = 1
+	}
+	slots := make(chan struct{}, size)
+	return CallbackExecutorFunc(func(callback func()) {
+		slots <- struct{}{}
+		go func() {
+			defer func() { <-slots }()
+			callback()
+		}()
+	})
+}
+
+// WithCallbackExecutor sets how notification callbacks are delivered
+func WithCallbackExecutor(e CallbackExecutor) Option {
+	return func(c *Client) {
+		c.base.SetCallbackExecutor(e.Execute)
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dwrtz/mcp-go/internal/client/prompts"
+	"github.com/dwrtz/mcp-go/internal/client/resources"
+	"github.com/dwrtz/mcp-go/internal/client/tools"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// ErrNotInitialized is returned by server feature methods called before Initialize
+var ErrNotInitialized = errors.New("client not initialized")
+
+// Feature clients are created on first use and live as long as the Client, so
+// callbacks registered on them survive re-initialization. Whether a feature is
+// available is decided by the most recent server capabilities instead.
+
+// checkFeature returns an error if the server feature is currently unavailable
+func (c *Client) checkFeature(name string, supported func(types.ServerCapabilities) bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.initialized {
+		return ErrNotInitialized
+	}
+	if !supported(c.serverCapabilities) {
+		return types.NewError(types.MethodNotFound, name+" not supported")
+	}
+	return nil
+}
+
+// noteCapability records a capability implied by a notification the server
+// sent, e.g. a tools/list_changed from a server that did not advertise tools
+func (c *Client) noteCapability(method string, apply func(*types.ServerCapabilities) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.initialized && apply(&c.serverCapabilities) {
+		c.base.Log(context.Background(), logger.LevelDebug, "Server capabilities updated after %s", method)
+	}
+}
+
+func (c *Client) resourcesHandle() *resources.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resources == nil {
+		c.resources = resources.NewClient(c.base)
+		c.resources.OnResourceListChanged(func() {
+			c.base.Log(context.Background(), logger.LevelDebug, "from server: %s", methods.ResourceListChanged)
+			c.noteCapability(methods.ResourceListChanged, func(caps *types.ServerCapabilities) bool {
+				if caps.Resources != nil {
+					return false
+				}
+				caps.Resources = &types.ResourcesServerCapabilities{ListChanged: true}
+				return true
+			})
+		})
+		c.resources.OnResourceUpdated(func(uri string) {
+			c.base.Log(context.Background(), logger.LevelDebug, "from server: %s %s", methods.ResourceUpdated, uri)
+		})
+	}
+	return c.resources
+}
+
+func (c *Client) promptsHandle() *prompts.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prompts == nil {
+		c.prompts = prompts.NewClient(c.base)
+		c.prompts.OnPromptListChanged(func() {
+			c.base.Log(context.Background(), logger.LevelDebug, "from server: %s", methods.PromptsChanged)
+			c.noteCapability(methods.PromptsChanged, func(caps *types.ServerCapabilities) bool {
+				if caps.Prompts != nil {
+					return false
+				}
+				caps.Prompts = &types.PromptsServerCapabilities{ListChanged: true}
+				return true
+			})
+		})
+	}
+	return c.prompts
+}
+
+func (c *Client) toolsHandle() *tools.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tools == nil {
+		c.tools = tools.NewClient(c.base)
+		c.tools.OnToolListChanged(func() {
+			c.base.Log(context.Background(), logger.LevelDebug, "from server: %s", methods.ToolsChanged)
+			c.noteCapability(methods.ToolsChanged, func(caps *types.ServerCapabilities) bool {
+				if caps.Tools != nil {
+					return false
+				}
+				caps.Tools = &types.ToolsServerCapabilities{ListChanged: true}
+				return true
+			})
+		})
+	}
+	return c.tools
+}
+
+func (c *Client) resourcesClient() (*resources.Client, error) {
+	if err := c.checkFeature("resources", func(caps types.ServerCapabilities) bool { return caps.Resources != nil }); err != nil {
+		return nil, err
+	}
+	return c.resourcesHandle(), nil
+}
+
+func (c *Client) promptsClient() (*prompts.Client, error) {
+	if err := c.checkFeature("prompts", func(caps types.ServerCapabilities) bool { return caps.Prompts != nil }); err != nil {
+		return nil, err
+	}
+	return c.promptsHandle(), nil
+}
+
+func (c *Client) toolsClient() (*tools.Client, error) {
+	if err := c.checkFeature("tools", func(caps types.ServerCapabilities) bool { return caps.Tools != nil }); err != nil {
+		return nil, err
+	}
+	return c.toolsHandle(), nil
+}
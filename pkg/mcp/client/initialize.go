@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// ErrInitializeTimeout is returned by Initialize when the server does not
+// answer the initialize request within the configured timeout on any attempt
+var ErrInitializeTimeout = errors.New("initialize timed out")
+
+// HandshakePhase identifies how far an initialization attempt progressed
+type HandshakePhase string
+
+const (
+	// HandshakeRequestSent means the initialize request was sent
+	HandshakeRequestSent HandshakePhase = "request_sent"
+
+	// HandshakeTimedOut means the attempt timed out waiting for a response
+	HandshakeTimedOut HandshakePhase = "timed_out"
+
+	// HandshakeFailed means the attempt failed and will not be retried
+	HandshakeFailed HandshakePhase = "failed"
+
+	// HandshakeResponseReceived means the server answered the initialize request
+	HandshakeResponseReceived HandshakePhase = "response_received"
+
+	// HandshakeComplete means the initialized notification was sent
+	HandshakeComplete HandshakePhase = "complete"
+)
+
+// HandshakeState is a snapshot of an initialization attempt, passed to the
+// handshake hook for diagnostics
+type HandshakeState struct {
+	Attempt int
+	Phase   HandshakePhase
+	Elapsed time.Duration
+
+	// Result is set once the server has answered
+	Result *types.InitializeResult
+
+	// Err is set when the attempt timed out or failed
+	Err error
+}
+
+// WithInitializeTimeout bounds how long each initialize attempt waits for the
+// server's response. Zero, the default, waits until the context is done.
+func WithInitializeTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.initTimeout = timeout
+	}
+}
+
+// WithInitializeRetries retries timed-out initialize attempts up to retries
+// times, waiting backoff before the first retry and doubling it each time.
+// Only timeouts are retried; other failures are returned immediately.
+func WithInitializeRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.initRetries = retries
+		c.initBackoff = backoff
+	}
+}
+
+// WithHandshakeHook registers a function called at each step of
+// initialization, making it possible to see where a handshake stalled
+func WithHandshakeHook(hook func(HandshakeState)) Option {
+	return func(c *Client) {
+		c.onHandshake = hook
+	}
+}
+
+// Initialize initiates the connection with the server. It may be called again
+// to renegotiate; feature availability then follows the latest result, and
+// registered callbacks are kept.
+func (c *Client) Initialize(ctx context.Context) error {
+	if err := c.WaitForConnection(ctx); err != nil {
+		return fmt.Errorf("initialization failed: %w", err)
+	}
+
+	backoff := c.initBackoff
+	var lastErr error
+	for attempt := 1; attempt <= c.initRetries+1; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return fmt.Errorf("initialization failed: %w", ctx.Err())
+			}
+			backoff *= 2
+		}
+
+		result, err := c.sendInitialize(ctx, attempt)
+		if err == nil {
+			return c.completeInitialize(ctx, attempt, result)
+		}
+		if !errors.Is(err, ErrInitializeTimeout) {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("initialization failed after %d attempts: %w", c.initRetries+1, lastErr)
+}
+
+// sendInitialize performs one initialize request/response exchange
+func (c *Client) sendInitialize(ctx context.Context, attempt int) (*types.InitializeResult, error) {
+	start := time.Now()
+	report := func(phase HandshakePhase, result *types.InitializeResult, err error) {
+		if c.onHandshake != nil {
+			c.onHandshake(HandshakeState{
+				Attempt: attempt,
+				Phase:   phase,
+				Elapsed: time.Since(start),
+				Result:  result,
+				Err:     err,
+			})
+		}
+	}
+
+	attemptCtx := ctx
+	if c.initTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.initTimeout)
+		defer cancel()
+	}
+
+	// Create initialization request
+	req := &types.InitializeRequest{
+		ProtocolVersion: types.LatestProtocolVersion,
+		Capabilities:    c.capabilities,
+		ClientInfo: types.Implementation{
+			Name:    "mcp-go",
+			Version: "0.1.0",
+		},
+	}
+
+	// Send initialize request
+	report(HandshakeRequestSent, nil, nil)
+	resp, err := c.base.SendRequest(attemptCtx, methods.Initialize, req)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%w after %s", ErrInitializeTimeout, c.initTimeout)
+			report(HandshakeTimedOut, nil, err)
+			return nil, err
+		}
+		report(HandshakeFailed, nil, err)
+		return nil, fmt.Errorf("initialization failed: %w", err)
+	}
+
+	// Parse server response
+	var result types.InitializeResult
+	if err := resp.UnmarshalResult(&result); err != nil {
+		err = fmt.Errorf("failed to parse initialization response: %w", err)
+		report(HandshakeFailed, nil, err)
+		return nil, err
+	}
+	report(HandshakeResponseReceived, &result, nil)
+
+	// Verify protocol version compatibility
+	if result.ProtocolVersion != types.LatestProtocolVersion {
+		err := fmt.Errorf("server protocol version %s not supported", result.ProtocolVersion)
+		report(HandshakeFailed, &result, err)
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// SupportsLogging returns whether the server sends log messages
+func (c *Client) SupportsLogging() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.serverCapabilities.Logging != nil
+}
+
+// SetLogLevel asks the server to send only log messages of at least level.
+// Returns an error if the server does not support logging.
+func (c *Client) SetLogLevel(ctx context.Context, level types.LoggingLevel) error {
+	if !c.SupportsLogging() {
+		return types.NewError(types.MethodNotFound, "logging not supported")
+	}
+	_, err := c.base.SendRequest(ctx, methods.SetLogLevel, &types.SetLevelRequest{
+		Method: methods.SetLogLevel,
+		Level:  level,
+	})
+	return err
+}
+
+// OnLogMessage registers a callback that will be invoked with each log
+// message the server sends. Callbacks may be registered before Initialize.
+func (c *Client) OnLogMessage(callback func(types.LoggingMessageNotification)) Unsubscribe {
+	return Unsubscribe(c.logSubs.Add(callback))
+}
+
+// handleLogMessage passes a log message to the OnLogMessage callbacks
+func (c *Client) handleLogMessage(ctx context.Context, n types.LoggingMessageNotification) {
+	c.logSubs.Publish(n)
+}
@@ -0,0 +1,33 @@
+package client
+
+import "time"
+
+// PendingRequest describes a request sent to the server that has not been answered
+type PendingRequest struct {
+	ID     uint64
+	Method string
+	Age    time.Duration
+}
+
+// PendingRequests returns the requests awaiting a response from the server,
+// oldest first. Useful for finding what a stuck host is waiting on.
+func (c *Client) PendingRequests() []PendingRequest {
+	now := time.Now()
+	pending := c.base.PendingRequests()
+	reqs := make([]PendingRequest, len(pending))
+	for i, req := range pending {
+		reqs[i] = PendingRequest{
+			ID:     req.ID,
+			Method: req.Method,
+			Age:    now.Sub(req.Started),
+		}
+	}
+	return reqs
+}
+
+// CancelAll cancels every request awaiting a response from the server. The
+// cancelled calls return an error wrapping context.Canceled; the connection
+// stays open.
+func (c *Client) CancelAll() {
+	c.base.CancelAll()
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+)
+
+// DefaultStopTimeout is how long a server process started by
+// NewDefaultClient is given at each step of stopping, see WithStopTimeout
+const DefaultStopTimeout = 2 * time.Second
+
+// Bounds of what ServerProcess keeps of the server's stderr
+const (
+	StderrTailLines = 20
+	stderrTailBytes = 4 << 10
+)
+
+// WithStopTimeout sets how long Close waits for the server process started
+// by NewDefaultClient to exit: once after closing its input, once more
+// after asking it and the processes it started to stop, with SIGTERM or on
+// Windows CTRL_BREAK, and once more after killing them, before giving up
+// on its exit status. It has no effect on other clients.
+func WithStopTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.stopTimeout = timeout
+	}
+}
+
+// ServerProcess is the server process started by NewDefaultClient, see
+// Client.ServerProcess
+type ServerProcess struct {
+	cmd    *exec.Cmd
+	group  *processGroup
+	stderr *tailWriter
+
+	// exited is closed once the process has exited and err is set
+	exited chan struct{}
+	err    error
+}
+
+// startServerProcess starts cmd, passing its stderr on to ours, and waits
+// for it in the background. waitDelay bounds how long waiting goes on for
+// output the process leaves open once it has exited.
+func startServerProcess(cmd *exec.Cmd, waitDelay time.Duration) (*ServerProcess, error) {
+	p := &ServerProcess{
+		cmd:    cmd,
+		stderr: &tailWriter{out: os.Stderr},
+		exited: make(chan struct{}),
+	}
+	cmd.Stderr = p.stderr
+	cmd.WaitDelay = waitDelay
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p.group = newProcessGroup(cmd.Process)
+	goroutine.Go("client/process", func() {
+		if err := cmd.Wait(); err != nil {
+			p.err = &ServerExitError{ExitCode: cmd.ProcessState.ExitCode(), Stderr: p.stderr.String(), Err: err}
+		}
+		close(p.exited)
+	})
+	return p, nil
+}
+
+// PID returns the ID of the process
+func (p *ServerProcess) PID() int {
+	return p.cmd.Process.Pid
+}
+
+// Exited returns a channel closed once the process has exited
+func (p *ServerProcess) Exited() <-chan struct{} {
+	return p.exited
+}
+
+// ExitCode returns the exit code of the process, or -1 while it runs or if
+// a signal ended it
+func (p *ServerProcess) ExitCode() int {
+	select {
+	case <-p.exited:
+		return p.cmd.ProcessState.ExitCode()
+	default:
+		return -1
+	}
+}
+
+// Err returns nil while the process runs or once it has exited cleanly,
+// and a *ServerExitError if it failed
+func (p *ServerProcess) Err() error {
+	select {
+	case <-p.exited:
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// StderrTail returns the last StderrTailLines lines the process wrote to
+// stderr
+func (p *ServerProcess) StderrTail() string {
+	return p.stderr.String()
+}
+
+// ServerExitError reports a server process that exited with an error, with
+// the last lines it wrote to stderr, which usually say why
+type ServerExitError struct {
+	// ExitCode is the process's exit code, or -1 if a signal ended it or
+	// waiting for it failed
+	ExitCode int
+	// Stderr is the tail of the process's stderr, see StderrTailLines
+	Stderr string
+	// Err is the error waiting for the process returned, usually an
+	// *exec.ExitError
+	Err error
+}
+
+func (e *ServerExitError) Error() string {
+	msg := fmt.Sprintf("server process failed: %v", e.Err)
+	var exitErr *exec.ExitError
+	if errors.As(e.Err, &exitErr) && e.ExitCode >= 0 {
+		msg = fmt.Sprintf("server process failed with exit code %d", e.ExitCode)
+	}
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *ServerExitError) Unwrap() error {
+	return e.Err
+}
+
+// ServerProcess returns the server process started by NewDefaultClient, or
+// nil for clients of other servers. When the server closes the connection
+// and exits with an error, the client closes with its *ServerExitError,
+// which shows why it failed rather than only that the connection closed.
+func (c *Client) ServerProcess() *ServerProcess {
+	return c.process
+}
+
+// tailWriter keeps the last lines written to it, passing writes on to out.
+// Errors of out are ignored, so a host with no stderr, such as a GUI
+// program on Windows, does not stall the server.
+type tailWriter struct {
+	out io.Writer
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	if w.out != nil {
+		_, _ = w.out.Write(p)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+
+	// Cut everything before the last lines, a final line without a
+	// newline counting as one
+	cut := len(w.buf) - stderrTailBytes
+	end := len(w.buf)
+	if end > 0 && w.buf[end-1] == '\n' {
+		end--
+	}
+	for lines := 0; lines < StderrTailLines; lines++ {
+		i := bytes.LastIndexByte(w.buf[:end], '\n')
+		if i < 0 {
+			end = -1
+			break
+		}
+		end = i
+	}
+	if end >= 0 && end+1 > cut {
+		cut = end + 1
+	}
+	if cut > 0 {
+		w.buf = append(w.buf[:0], w.buf[cut:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the lines kept, without the final newline
+func (w *tailWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(bytes.TrimRight(w.buf, "\r\n"))
+}
+
+// stopProcess stops the server process leading g, whose input is already
+// closed, with exited closed once it has exited and been waited for. A
+// server that does not exit by itself within timeout is asked to, and the
+// group is killed if it still has not after another timeout. Processes the
+// server started that outlive it are killed too. A server that cannot be
+// killed is left after a last timeout, rather than block Close.
+func stopProcess(g *processGroup, exited <-chan struct{}, timeout time.Duration) {
+	defer g.close()
+	select {
+	case <-exited:
+		g.kill()
+		return
+	case <-time.After(timeout):
+	}
+	if err := g.interrupt(); err == nil {
+		select {
+		case <-exited:
+			g.kill()
+			return
+		case <-time.After(timeout):
+		}
+	}
+	g.kill()
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+	}
+}
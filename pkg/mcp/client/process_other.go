@@ -0,0 +1,34 @@
+//go:build !unix && !windows
+
+package client
+
+import (
+	"os"
+	"os/exec"
+)
+
+// configureProcess prepares the server process before it starts
+func configureProcess(cmd *exec.Cmd) {}
+
+// processGroup is a server process; processes it starts are not tracked
+type processGroup struct {
+	p *os.Process
+}
+
+// newProcessGroup returns the group of p
+func newProcessGroup(p *os.Process) *processGroup {
+	return &processGroup{p: p}
+}
+
+// interrupt asks the process to stop
+func (g *processGroup) interrupt() error {
+	return g.p.Signal(os.Interrupt)
+}
+
+// kill kills the process
+func (g *processGroup) kill() error {
+	return g.p.Kill()
+}
+
+// close releases the group
+func (g *processGroup) close() {}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{}
+	w.Write([]byte("first\nsecond"))
+	w.Write([]byte(" half\r\n"))
+	if got := w.String(); got != "first\nsecond half" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for i := 0; i < 2*StderrTailLines; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+	lines := strings.Split(w.String(), "\n")
+	if len(lines) != StderrTailLines || lines[len(lines)-1] != fmt.Sprintf("line %d", 2*StderrTailLines-1) {
+		t.Errorf("Kept %d lines ending with %q", len(lines), lines[len(lines)-1])
+	}
+
+	w.Write([]byte(strings.Repeat("x", 2*stderrTailBytes)))
+	if got := w.String(); len(got) != stderrTailBytes {
+		t.Errorf("Kept %d bytes of a long line, want %d", len(got), stderrTailBytes)
+	}
+}
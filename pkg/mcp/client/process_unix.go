@@ -0,0 +1,39 @@
+//go:build unix
+
+package client
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess starts the server process in a process group of its
+// own, which the processes it starts join, so they are stopped with it
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processGroup is a server process and the processes it started, the
+// members of its process group
+type processGroup struct {
+	pid int
+}
+
+// newProcessGroup returns the group of p, started by configureProcess
+func newProcessGroup(p *os.Process) *processGroup {
+	return &processGroup{pid: p.Pid}
+}
+
+// interrupt asks the group to stop with SIGTERM
+func (g *processGroup) interrupt() error {
+	return syscall.Kill(-g.pid, syscall.SIGTERM)
+}
+
+// kill kills what is left of the group
+func (g *processGroup) kill() error {
+	return syscall.Kill(-g.pid, syscall.SIGKILL)
+}
+
+// close releases the group
+func (g *processGroup) close() {}
@@ -0,0 +1,122 @@
+//go:build unix
+
+package client
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startProcess starts the shell script with its input closed, and returns
+// it with a channel closed once it has exited and the read end of its
+// output, which the processes it starts share
+func startProcess(t *testing.T, script string) (*exec.Cmd, <-chan struct{}, *os.File) {
+	t.Helper()
+	cmd := exec.Command("/bin/sh", "-c", script)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("StdinPipe error: %v", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe error: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	cmd.Stdout = w
+	configureProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start %q: %v", script, err)
+	}
+	w.Close()
+	stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	return cmd, exited, r
+}
+
+func TestStopProcess(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		// signal is what ended the process, or -1 for a clean exit
+		signal syscall.Signal
+	}{
+		{"exits on end of input", "cat", -1},
+		{"stops on SIGTERM", "exec sleep 10", syscall.SIGTERM},
+		{"ignores SIGTERM", `trap "" TERM; exec sleep 10`, syscall.SIGKILL},
+		{"leaves a child behind", "sleep 10 & exec cat", -1},
+		{"child ignores SIGTERM", `(trap "" TERM; exec sleep 10) & exec sleep 10`, syscall.SIGTERM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, exited, out := startProcess(t, tt.script)
+			start := time.Now()
+			stopProcess(newProcessGroup(cmd.Process), exited, 50*time.Millisecond)
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Stopping took %v", elapsed)
+			}
+			status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+			switch {
+			case tt.signal < 0 && (status.Signaled() || status.ExitStatus() != 0):
+				t.Errorf("Process ended with %v, want a clean exit", cmd.ProcessState)
+			case tt.signal >= 0 && (!status.Signaled() || status.Signal() != tt.signal):
+				t.Errorf("Process ended with %v, want %v", cmd.ProcessState, tt.signal)
+			}
+
+			// The output reaches its end once every process the server
+			// started is gone too
+			done := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, out)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Error("Processes started by the server outlived it")
+			}
+		})
+	}
+}
+
+func TestServerProcess(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", `read line; echo "starting" >&2; echo "config missing" >&2; exit 2`)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("StdinPipe error: %v", err)
+	}
+	configureProcess(cmd)
+	p, err := startServerProcess(cmd, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to start process: %v", err)
+	}
+	if p.PID() <= 0 || p.ExitCode() != -1 || p.Err() != nil {
+		t.Errorf("Running process: PID %d, exit code %d, error %v", p.PID(), p.ExitCode(), p.Err())
+	}
+
+	stdin.Write([]byte("go\n"))
+	select {
+	case <-p.Exited():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Process did not exit")
+	}
+	if p.ExitCode() != 2 || p.StderrTail() != "starting\nconfig missing" {
+		t.Errorf("Exited process: exit code %d, stderr %q", p.ExitCode(), p.StderrTail())
+	}
+	var exitErr *ServerExitError
+	if !errors.As(p.Err(), &exitErr) || exitErr.ExitCode != 2 {
+		t.Fatalf("Err() = %v, want a ServerExitError", p.Err())
+	}
+	if want := "server process failed with exit code 2: starting\nconfig missing"; exitErr.Error() != want {
+		t.Errorf("Error() = %q, want %q", exitErr.Error(), want)
+	}
+	stopProcess(p.group, p.Exited(), time.Second)
+}
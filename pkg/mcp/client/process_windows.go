@@ -0,0 +1,96 @@
+//go:build windows
+
+package client
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleWindow         = kernel32.NewProc("GetConsoleWindow")
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+// Flags and access rights not in package syscall
+const (
+	createNoWindow   = 0x08000000
+	processSetQuota  = 0x0100
+	processTerminate = 0x0001
+)
+
+// configureProcess starts the server in a process group of its own, so
+// CTRL_BREAK reaches it alone and a Ctrl+C in the host's console does not
+// stop it behind the client's back. A host without a console, such as a GUI
+// program, starts it without one too, where Windows would otherwise open a
+// console window for it.
+func configureProcess(cmd *exec.Cmd) {
+	flags := uint32(syscall.CREATE_NEW_PROCESS_GROUP)
+	if window, _, _ := procGetConsoleWindow.Call(); window == 0 {
+		flags |= createNoWindow
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: flags}
+}
+
+// processGroup is a server process and the processes it starts, which
+// join its job object
+type processGroup struct {
+	p   *os.Process
+	job syscall.Handle
+}
+
+// newProcessGroup puts p in a new job object. Processes p started before
+// are not in it; without a job, as when p's job forbids nesting, the group
+// is p alone.
+func newProcessGroup(p *os.Process) *processGroup {
+	g := &processGroup{p: p}
+	job, _, _ := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return g
+	}
+	h, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(p.Pid))
+	if err != nil {
+		syscall.CloseHandle(syscall.Handle(job))
+		return g
+	}
+	defer syscall.CloseHandle(h)
+	if ok, _, _ := procAssignProcessToJobObject.Call(job, uintptr(h)); ok == 0 {
+		syscall.CloseHandle(syscall.Handle(job))
+		return g
+	}
+	g.job = syscall.Handle(job)
+	return g
+}
+
+// interrupt sends CTRL_BREAK to the server's process group, which only
+// works if it shares the host's console
+func (g *processGroup) interrupt() error {
+	if ok, _, err := procGenerateConsoleCtrlEvent.Call(syscall.CTRL_BREAK_EVENT, uintptr(g.p.Pid)); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// kill terminates what is left of the job
+func (g *processGroup) kill() error {
+	if g.job == 0 {
+		return g.p.Kill()
+	}
+	if ok, _, err := procTerminateJobObject.Call(uintptr(g.job), 1); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// close releases the job object
+func (g *processGroup) close() {
+	if g.job != 0 {
+		syscall.CloseHandle(g.job)
+		g.job = 0
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Defaults of the notification replay buffer, see WithNotificationReplay
+const (
+	defaultReplaySize = 32
+	// maxReplayMethods bounds the methods kept, so a server inventing
+	// methods cannot grow the buffer without limit
+	maxReplayMethods = 64
+)
+
+// RecordedNotification is a notification kept for RecentNotifications
+type RecordedNotification struct {
+	Method   string
+	Params   json.RawMessage
+	Received time.Time
+}
+
+// WithNotificationReplay keeps the last size notifications of each method
+// for RecentNotifications, instead of the default 32. 0 keeps none.
+func WithNotificationReplay(size int) Option {
+	return func(c *Client) {
+		c.replay.setSize(size)
+	}
+}
+
+// RecentNotifications returns the last n notifications of method received
+// since the client started, oldest first, or all of those kept if n <= 0.
+// It lets a UI attaching to a running client show recent activity.
+func (c *Client) RecentNotifications(method string, n int) []RecordedNotification {
+	return c.replay.recent(method, n)
+}
+
+// replayBuffer keeps the recent notifications of each method in a ring
+type replayBuffer struct {
+	mu    sync.Mutex
+	size  int
+	rings map[string]*ring
+}
+
+// ring holds the last notifications of one method; next is where the next
+// one goes once it is full
+type ring struct {
+	items []RecordedNotification
+	next  int
+}
+
+func newReplayBuffer() *replayBuffer {
+	return &replayBuffer{size: defaultReplaySize, rings: make(map[string]*ring)}
+}
+
+func (r *replayBuffer) setSize(size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.size = size
+	r.rings = make(map[string]*ring)
+}
+
+// record keeps msg, a notification received
+func (r *replayBuffer) record(msg *types.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size <= 0 {
+		return
+	}
+	rg, ok := r.rings[msg.Method]
+	if !ok {
+		if len(r.rings) >= maxReplayMethods {
+			return
+		}
+		rg = &ring{}
+		r.rings[msg.Method] = rg
+	}
+
+	n := RecordedNotification{Method: msg.Method, Received: time.Now()}
+	if msg.Params != nil {
+		n.Params = append(json.RawMessage(nil), *msg.Params...)
+	}
+	if len(rg.items) < r.size {
+		rg.items = append(rg.items, n)
+		return
+	}
+	rg.items[rg.next] = n
+	rg.next = (rg.next + 1) % r.size
+}
+
+// recent returns the last n notifications of method, oldest first
+func (r *replayBuffer) recent(method string, n int) []RecordedNotification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rg, ok := r.rings[method]
+	if !ok {
+		return nil
+	}
+	ordered := append(append([]RecordedNotification(nil), rg.items[rg.next:]...), rg.items[:rg.next]...)
+	if n > 0 && n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
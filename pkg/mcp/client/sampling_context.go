@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// ContextPolicy decides whether a resource from the named server may be
+// included as context in a sampling request. requester is the server that
+// sent the request.
+type ContextPolicy func(ctx context.Context, req *types.CreateMessageRequest, requester, source string, resource types.Resource) bool
+
+// ContextSource is another server whose resources may be included when a
+// sampling request asks for context from all servers
+type ContextSource struct {
+	Name   string
+	Client *Client
+}
+
+// WithSamplingContext honors the includeContext field of sampling requests.
+// For "thisServer" the resources of the requesting server are considered, for
+// "allServers" also those of the sources set with SetContextSources. Each
+// text resource the policy allows is read and appended to the request's
+// system prompt before the sampling handler is called; resources that would
+// take the appended text past maxBytes are left out (maxBytes <= 0 means no
+// limit). Requires WithSampling.
+func WithSamplingContext(policy ContextPolicy, maxBytes int) Option {
+	return func(c *Client) {
+		c.contextPolicy = policy
+		c.contextMaxBytes = maxBytes
+	}
+}
+
+// SetContextSources sets the other servers considered for sampling requests
+// that ask for context from all servers
+func (c *Client) SetContextSources(sources ...ContextSource) {
+	c.mu.Lock()
+	c.contextSources = append([]ContextSource{}, sources...)
+	c.mu.Unlock()
+}
+
+// assembleSamplingContext appends the context req asks for to its system prompt
+func (c *Client) assembleSamplingContext(ctx context.Context, req *types.CreateMessageRequest) error {
+	c.mu.RLock()
+	requester := c.serverInfo.Name
+	sources := []ContextSource{{Name: requester, Client: c}}
+	if req.IncludeContext == "allServers" {
+		sources = append(sources, c.contextSources...)
+	}
+	c.mu.RUnlock()
+
+	var b strings.Builder
+	remaining := c.contextMaxBytes
+	for _, src := range sources {
+		section := src.Client.contextSection(ctx, req, requester, src.Name, c.contextPolicy, &remaining, c.contextMaxBytes > 0)
+		if section != "" {
+			b.WriteString(section)
+		}
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+
+	if req.SystemPrompt != "" {
+		req.SystemPrompt += "\n\n"
+	}
+	req.SystemPrompt += strings.TrimSuffix(b.String(), "\n")
+	return nil
+}
+
+// contextSection reads the resources of c's server that policy allows and
+// formats them, consuming the byte budget when limited is set
+func (c *Client) contextSection(ctx context.Context, req *types.CreateMessageRequest, requester, source string, policy ContextPolicy, remaining *int, limited bool) string {
+	if !c.SupportsResources() {
+		return ""
+	}
+	resources, err := c.ListResources(ctx)
+	if err != nil {
+		c.base.Log(ctx, logger.LevelWarn, "Failed to list resources of %s for sampling context: %v", source, err)
+		return ""
+	}
+
+	var allowed []types.Resource
+	for _, r := range resources {
+		if policy(ctx, req, requester, source, r) {
+			allowed = append(allowed, r)
+		}
+	}
+	if len(allowed) == 0 {
+		return ""
+	}
+
+	// Read the allowed resources concurrently, keeping list order
+	texts := make([]string, len(allowed))
+	var wg sync.WaitGroup
+	for i, r := range allowed {
+		wg.Add(1)
+		go func(i int, uri string) {
+			defer wg.Done()
+			contents, err := c.ReadResource(ctx, uri)
+			if err != nil {
+				c.base.Log(ctx, logger.LevelWarn, "Failed to read %s for sampling context: %v", uri, err)
+				return
+			}
+			var parts []string
+			for _, content := range contents {
+				if text, ok := content.(types.TextResourceContents); ok {
+					parts = append(parts, text.Text)
+				}
+			}
+			texts[i] = strings.Join(parts, "\n")
+		}(i, r.URI)
+	}
+	wg.Wait()
+
+	var b strings.Builder
+	for i, r := range allowed {
+		if texts[i] == "" {
+			continue
+		}
+		entry := fmt.Sprintf("--- %s\n%s\n", r.URI, texts[i])
+		if limited {
+			if len(entry) > *remaining {
+				continue
+			}
+			*remaining -= len(entry)
+		}
+		b.WriteString(entry)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Context from server %q:\n%s", source, b.String())
+}
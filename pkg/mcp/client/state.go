@@ -0,0 +1,128 @@
+package client
+
+import (
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/transport"
+)
+
+// ConnectionState describes the lifecycle of a Client's connection
+type ConnectionState string
+
+const (
+	// StateConnecting means the client has not completed initialization
+	StateConnecting ConnectionState = "connecting"
+
+	// StateReady means initialization completed and requests may be sent
+	StateReady ConnectionState = "ready"
+
+	// StateClosed means the client was closed or the transport disconnected
+	StateClosed ConnectionState = "closed"
+)
+
+// StateChange describes a transition between connection states
+type StateChange struct {
+	From ConnectionState
+	To   ConnectionState
+
+	// Reason and Err say why the connection closed, when To is StateClosed.
+	// A server that shut down cleanly is ClosedByPeer with
+	// transport.ErrPeerClosed; one that crashed leaves another error.
+	Reason transport.CloseReason
+	Err    error
+}
+
+// Done returns a channel that is closed once the client has shut down,
+// after its transport closed
+func (c *Client) Done() <-chan struct{} {
+	return c.base.Done()
+}
+
+// State returns the current connection state
+func (c *Client) State() ConnectionState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// OnStateChange registers a callback invoked whenever the connection state
+// changes. Callbacks are delivered through the client's callback executor.
+func (c *Client) OnStateChange(callback func(StateChange)) Unsubscribe {
+	return c.stateSubs.Add(callback)
+}
+
+// DoneReason says which side closed the connection, or NotClosed
+func (c *Client) DoneReason() transport.CloseReason {
+	return c.base.DoneReason()
+}
+
+// Err returns why the connection closed, or nil while it is open. When the
+// server process started by NewDefaultClient exits with an error, Err
+// reports its exit status.
+func (c *Client) Err() error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.closeErr != nil {
+		return c.closeErr
+	}
+	return c.base.Err()
+}
+
+// OnError registers a callback for errors that have no caller to return them
+// to: messages from the server that could not be decoded
+// (*transport.DecodeError), handlers that panicked
+// (*transport.HandlerPanicError), and responses that could not be sent
+// (*transport.SendError). Callbacks are delivered through the client's
+// callback executor.
+func (c *Client) OnError(callback func(error)) Unsubscribe {
+	return c.base.OnError(callback)
+}
+
+// setState moves to a new state and notifies subscribers. Closed is final.
+func (c *Client) setState(to ConnectionState) {
+	c.transition(StateChange{To: to})
+}
+
+// setClosed moves to the closed state, recording why the connection closed
+func (c *Client) setClosed(err error) {
+	c.transition(StateChange{To: StateClosed, Reason: c.base.DoneReason(), Err: err})
+}
+
+// transition applies change, filling in its From state
+func (c *Client) transition(change StateChange) {
+	c.stateMu.Lock()
+	change.From = c.state
+	if change.From == change.To || change.From == StateClosed {
+		c.stateMu.Unlock()
+		return
+	}
+	c.state = change.To
+	if change.To == StateClosed {
+		c.closeErr = change.Err
+	}
+	c.stateMu.Unlock()
+
+	c.stateSubs.Publish(change)
+}
+
+// watchDone marks the client closed when the transport shuts down
+func (c *Client) watchDone() {
+	<-c.base.Done()
+	err := c.base.Err()
+	if c.base.DoneReason() == transport.ClosedByPeer && c.process != nil {
+		// The server's exit status tells a crash from a clean shutdown,
+		// if it exits soon after closing its output
+		select {
+		case <-c.process.Exited():
+			if perr := c.process.Err(); perr != nil {
+				err = perr
+			}
+		case <-time.After(processExitWait):
+		}
+	}
+	c.setClosed(err)
+}
+
+// processExitWait is how long a server process that closed the connection
+// is given to exit before its status is ignored
+const processExitWait = time.Second
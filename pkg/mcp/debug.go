@@ -0,0 +1,28 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+)
+
+// DebugGoroutines describes the live goroutines of this module's clients,
+// servers and transports, grouped by role, such as "base/messages" or
+// "sse/stream", and stack, for finding goroutines that outlive what started
+// them. Goroutines of handlers inherit the role of the one that started
+// them.
+func DebugGoroutines() string {
+	groups, err := goroutine.Live()
+	if err != nil {
+		return fmt.Sprintf("failed to list goroutines: %v\n", err)
+	}
+	var b strings.Builder
+	for _, g := range groups {
+		fmt.Fprintf(&b, "%d %s\n", g.Count, g.Role)
+		for _, frame := range g.Stack {
+			fmt.Fprintf(&b, "\t%s\n", frame)
+		}
+	}
+	return b.String()
+}
@@ -1,19 +1,34 @@
 package mcp_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/dwrtz/mcp-go/internal/base"
 	"github.com/dwrtz/mcp-go/internal/mock"
 	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/mcp/client"
 	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/subscriptions"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
+func TestMain(m *testing.M) {
+	testutil.VerifyTestMain(m)
+}
+
 // Input types for tools
 type EchoInput struct {
 	Value string `json:"value" jsonschema:"description=Value to echo back,required"`
@@ -118,12 +133,6 @@ func setupClientServer(t *testing.T) (*client.Client, *server.Server, context.Co
 			},
 		}),
 		client.WithSampling(func(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
-			if len(req.Messages) == 0 {
-				return nil, types.NewError(types.InvalidParams, "messages array cannot be empty")
-			}
-			if req.MaxTokens <= 0 {
-				return nil, types.NewError(types.InvalidParams, "maxTokens must be positive")
-			}
 			return &types.CreateMessageResult{
 				Role: types.RoleAssistant,
 				Content: types.TextContent{
@@ -286,6 +295,96 @@ func TestClientServerIntegration(t *testing.T) {
 			t.Errorf("Unexpected sampling response: %+v", result.Content)
 		}
 	})
+
+	t.Run("TestReadResources", func(t *testing.T) {
+		uris := []string{"file:///example.txt", "file:///missing.txt", "file:///example.txt"}
+		results, err := c.ReadResources(ctx, uris, client.WithReadParallelism(2))
+		if err != nil {
+			t.Fatalf("ReadResources() error: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		if r := results["file:///example.txt"]; r.Err != nil || len(r.Contents) != 1 {
+			t.Errorf("Unexpected result for example.txt: %+v", r)
+		}
+		if r := results["file:///missing.txt"]; r.Err == nil {
+			t.Error("Expected error for missing.txt")
+		}
+	})
+
+	t.Run("TestPing", func(t *testing.T) {
+		if err := c.Ping(ctx); err != nil {
+			t.Errorf("Client.Ping() error: %v", err)
+		}
+		if err := s.Ping(ctx); err != nil {
+			t.Errorf("Server.Ping() error: %v", err)
+		}
+	})
+
+	t.Run("TestExportCatalog", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := s.ExportCatalog(&buf, server.CatalogJSON); err != nil {
+			t.Fatalf("ExportCatalog(json) error: %v", err)
+		}
+		var catalog server.Catalog
+		if err := json.Unmarshal(buf.Bytes(), &catalog); err != nil {
+			t.Fatalf("Failed to decode catalog: %v", err)
+		}
+		if len(catalog.Tools) != 1 || catalog.Tools[0].Name != "echo_tool" {
+			t.Errorf("Unexpected catalog tools: %+v", catalog.Tools)
+		}
+		if len(catalog.Prompts) != 1 || catalog.Prompts[0].Name != "example_prompt" {
+			t.Errorf("Unexpected catalog prompts: %+v", catalog.Prompts)
+		}
+		if len(catalog.ResourceTemplates) != 1 {
+			t.Errorf("Unexpected catalog templates: %+v", catalog.ResourceTemplates)
+		}
+
+		buf.Reset()
+		if err := s.ExportCatalog(&buf, server.CatalogMarkdown); err != nil {
+			t.Fatalf("ExportCatalog(markdown) error: %v", err)
+		}
+		md := buf.String()
+		for _, want := range []string{"## Tools", "### `echo_tool`", "\"value\"", "| `arg1` | yes |", "`file:///example/{name}.txt`"} {
+			if !strings.Contains(md, want) {
+				t.Errorf("Markdown catalog missing %q:\n%s", want, md)
+			}
+		}
+
+		if err := s.ExportCatalog(&buf, "yaml"); err == nil {
+			t.Error("Expected error for unsupported catalog format")
+		}
+	})
+
+	t.Run("TestRequestMeta", func(t *testing.T) {
+		s.RegisterPromptGetter("meta_prompt", func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+			meta := mcp.PeerMeta(ctx)
+			return &types.GetPromptResult{
+				Description: fmt.Sprintf("%v/%v", meta["correlationId"], meta["experiment"]),
+				Messages:    []types.PromptMessage{},
+			}, nil
+		})
+
+		metaCtx := mcp.WithMeta(ctx, "correlationId", "abc-123")
+		metaCtx = mcp.WithMeta(metaCtx, "experiment", true)
+		result, err := c.GetPrompt(metaCtx, "meta_prompt", nil)
+		if err != nil {
+			t.Fatalf("GetPrompt() error: %v", err)
+		}
+		if result.Description != "abc-123/true" {
+			t.Errorf("Expected peer meta abc-123/true, got %q", result.Description)
+		}
+
+		// Meta is only attached to requests sent with the context
+		result, err = c.GetPrompt(ctx, "meta_prompt", nil)
+		if err != nil {
+			t.Fatalf("GetPrompt() error: %v", err)
+		}
+		if result.Description != "<nil>/<nil>" {
+			t.Errorf("Expected no peer meta, got %q", result.Description)
+		}
+	})
 }
 
 func TestConcurrentUsage(t *testing.T) {
@@ -437,12 +536,6 @@ func setupSseClientServer(t *testing.T) (*client.Client, *server.Server, context
 			},
 		}),
 		client.WithSampling(func(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
-			if len(req.Messages) == 0 {
-				return nil, types.NewError(types.InvalidParams, "messages array cannot be empty")
-			}
-			if req.MaxTokens <= 0 {
-				return nil, types.NewError(types.InvalidParams, "maxTokens must be positive")
-			}
 			return &types.CreateMessageResult{
 				Role: types.RoleAssistant,
 				Content: types.TextContent{
@@ -650,3 +743,2495 @@ func TestSseConcurrentUsage(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestCallbackExecutor(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithResources(nil, nil),
+	)
+	c := client.NewClient(clientTransport,
+		client.WithLogger(logger),
+		client.WithCallbackExecutor(client.PoolExecutor(1)),
+	)
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Client initialization failed: %v", err)
+	}
+
+	// With a pool of one, a blocked callback holds back the next one
+	started := make(chan struct{})
+	release := make(chan struct{})
+	delivered := make(chan string, 1)
+	c.OnResourceUpdated(func(uri string) {
+		if uri == "file:///slow.txt" {
+			close(started)
+			<-release
+			return
+		}
+		delivered <- uri
+	})
+
+	for _, uri := range []string{"file:///slow.txt", "file:///fast.txt"} {
+		if err := c.SubscribeResource(ctx, uri); err != nil {
+			t.Fatalf("SubscribeResource() error: %v", err)
+		}
+		if err := s.NotifyResourceUpdated(ctx, uri); err != nil {
+			t.Fatalf("NotifyResourceUpdated() error: %v", err)
+		}
+		if uri == "file:///slow.txt" {
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for first callback")
+			}
+		}
+	}
+
+	select {
+	case uri := <-delivered:
+		t.Fatalf("Callback for %s ran while the pool was full", uri)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case uri := <-delivered:
+		if uri != "file:///fast.txt" {
+			t.Errorf("Unexpected URI: %s", uri)
+		}
+	case <-time.After(time.Second):
+		t.Error("Timeout waiting for queued callback")
+	}
+}
+
+func TestInitializeTimeoutAndRetry(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	// A server that ignores the first initialize request
+	srv := base.NewBase(serverTransport)
+	stall := make(chan struct{})
+	defer close(stall)
+	var calls int32
+	srv.RegisterRequestHandler(methods.Initialize, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-stall
+			return nil, fmt.Errorf("stalled")
+		}
+		return &types.InitializeResult{
+			ProtocolVersion: types.LatestProtocolVersion,
+			ServerInfo:      types.Implementation{Name: "slow", Version: "1.0"},
+		}, nil
+	})
+
+	var mu sync.Mutex
+	var phases []client.HandshakePhase
+	c := client.NewClient(clientTransport,
+		client.WithLogger(logger),
+		client.WithInitializeTimeout(50*time.Millisecond),
+		client.WithInitializeRetries(1, 10*time.Millisecond),
+		client.WithHandshakeHook(func(state client.HandshakeState) {
+			mu.Lock()
+			phases = append(phases, state.Phase)
+			mu.Unlock()
+		}),
+	)
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		srv.Close()
+	}()
+
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []client.HandshakePhase{
+		client.HandshakeRequestSent, client.HandshakeTimedOut,
+		client.HandshakeRequestSent, client.HandshakeResponseReceived, client.HandshakeComplete,
+	}
+	if fmt.Sprint(phases) != fmt.Sprint(want) {
+		t.Errorf("Handshake phases = %v, want %v", phases, want)
+	}
+}
+
+func TestInitializeTimeout(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	// A server that never answers
+	srv := base.NewBase(serverTransport)
+	stall := make(chan struct{})
+	defer close(stall)
+	srv.RegisterRequestHandler(methods.Initialize, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		<-stall
+		return nil, fmt.Errorf("stalled")
+	})
+
+	c := client.NewClient(clientTransport,
+		client.WithLogger(logger),
+		client.WithInitializeTimeout(20*time.Millisecond),
+		client.WithInitializeRetries(2, time.Millisecond),
+	)
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		srv.Close()
+	}()
+
+	err := c.Initialize(ctx)
+	if !errors.Is(err, client.ErrInitializeTimeout) {
+		t.Fatalf("Initialize() error = %v, want ErrInitializeTimeout", err)
+	}
+	if c.SupportsTools() || c.SupportsResources() {
+		t.Error("Feature clients created despite failed initialization")
+	}
+}
+
+func TestClientFeatureReevaluation(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	// A server that only advertises prompts from the second handshake on
+	srv := base.NewBase(serverTransport)
+	var inits int32
+	srv.RegisterRequestHandler(methods.Initialize, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		result := &types.InitializeResult{
+			ProtocolVersion: types.LatestProtocolVersion,
+			ServerInfo:      types.Implementation{Name: "changing", Version: "1.0"},
+		}
+		if atomic.AddInt32(&inits, 1) > 1 {
+			result.Capabilities.Prompts = &types.PromptsServerCapabilities{}
+		}
+		return result, nil
+	})
+	srv.RegisterRequestHandler(methods.ListTools, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		return &types.ListToolsResult{Tools: []types.Tool{}}, nil
+	})
+
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		srv.Close()
+	}()
+
+	if _, err := c.ListTools(ctx); !errors.Is(err, client.ErrNotInitialized) {
+		t.Errorf("ListTools() before Initialize error = %v, want ErrNotInitialized", err)
+	}
+
+	// Callbacks registered before Initialize are kept
+	toolsChanged := make(chan struct{}, 1)
+	c.OnToolListChanged(func() {
+		toolsChanged <- struct{}{}
+	})
+
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	if c.SupportsTools() || c.SupportsPrompts() {
+		t.Fatalf("Unexpected capabilities: %+v", c.ServerCapabilities())
+	}
+	if _, err := c.ListTools(ctx); err == nil {
+		t.Error("Expected error listing tools from a server without tools")
+	}
+
+	// A tools/list_changed notification implies the server has tools
+	if err := srv.SendNotification(ctx, methods.ToolsChanged, nil); err != nil {
+		t.Fatalf("SendNotification() error: %v", err)
+	}
+	select {
+	case <-toolsChanged:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for tools/list_changed callback")
+	}
+	if !c.SupportsTools() {
+		t.Error("Expected tools to be supported after tools/list_changed")
+	}
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Errorf("ListTools() error: %v", err)
+	}
+
+	// Re-initializing picks up the new capabilities without a new Client
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("second Initialize() error: %v", err)
+	}
+	if !c.SupportsPrompts() {
+		t.Error("Expected prompts to be supported after re-initialization")
+	}
+}
+
+func TestClientConnectionState(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	s := server.NewServer(serverTransport, server.WithLogger(logger))
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	changes := make(chan client.StateChange, 4)
+	c.OnStateChange(func(change client.StateChange) {
+		changes <- change
+	})
+
+	if c.State() != client.StateConnecting {
+		t.Errorf("Initial state = %s, want %s", c.State(), client.StateConnecting)
+	}
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer s.Close()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	if c.State() != client.StateReady {
+		t.Errorf("State after Initialize = %s, want %s", c.State(), client.StateReady)
+	}
+
+	c.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done() not closed after Close()")
+	}
+	if c.State() != client.StateClosed {
+		t.Errorf("State after Close = %s, want %s", c.State(), client.StateClosed)
+	}
+
+	want := []client.StateChange{
+		{From: client.StateConnecting, To: client.StateReady},
+		{From: client.StateReady, To: client.StateClosed, Reason: transport.ClosedLocally, Err: transport.ErrClosed},
+	}
+	for _, w := range want {
+		select {
+		case got := <-changes:
+			if got != w {
+				t.Errorf("State change = %+v, want %+v", got, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for state change %+v", w)
+		}
+	}
+	select {
+	case extra := <-changes:
+		t.Errorf("Unexpected state change %+v", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOnInitialized(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport, server.WithLogger(logger))
+	c := client.NewClient(clientTransport, client.WithLogger(logger),
+		client.WithRoots([]types.Root{{URI: "file:///project", Name: "Project"}}))
+
+	type result struct {
+		session *mcp.Session
+		client  string
+		roots   []types.Root
+		err     error
+	}
+	done := make(chan result, 1)
+	s.OnInitialized(func(session *mcp.Session, req types.InitializeRequest) {
+		// Roots are usable once the handshake completes
+		roots, err := s.ListRoots(context.Background())
+		done <- result{session, req.ClientInfo.Name, roots, err}
+	})
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer s.Close()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer c.Close()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	select {
+	case got := <-done:
+		if got.err != nil {
+			t.Fatalf("ListRoots() in hook error: %v", got.err)
+		}
+		if got.session != nil {
+			t.Errorf("Expected no session over stdio, got %+v", got.session)
+		}
+		if got.client != "mcp-go" {
+			t.Errorf("Client name = %q, want mcp-go", got.client)
+		}
+		if len(got.roots) != 1 || got.roots[0].URI != "file:///project" {
+			t.Errorf("Unexpected roots %+v", got.roots)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnInitialized hook not called")
+	}
+}
+
+func TestClientFeaturesPerSession(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	s := server.NewSseServer("127.0.0.1:0", server.WithLogger(logger))
+	sessions := make(chan *mcp.Session, 2)
+	s.OnInitialized(func(session *mcp.Session, _ types.InitializeRequest) {
+		sessions <- session
+	})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	// connect initializes a client over its own event stream, which ends
+	// when the returned function is called
+	connect := func(opts ...client.Option) (*mcp.Session, func()) {
+		ctx, cancel := context.WithCancel(context.Background())
+		c, err := client.NewSseClient(ctx, s.BoundAddr(), opts...)
+		if err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		// Replies are lost until the event stream is open
+		deadline := time.Now().Add(time.Second)
+		for s.Ping(ctx) != nil && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if err := c.Initialize(ctx); err != nil {
+			t.Fatalf("Initialize() error: %v", err)
+		}
+		select {
+		case session := <-sessions:
+			return session, func() { cancel(); c.Close() }
+		case <-time.After(time.Second):
+			t.Fatal("OnInitialized hook not called")
+		}
+		return nil, nil
+	}
+
+	withRoots, disconnect := connect(client.WithLogger(logger),
+		client.WithRoots([]types.Root{{URI: "file:///project", Name: "Project"}}))
+	if !s.SupportsRoots() {
+		t.Fatal("Expected roots from the first client")
+	}
+	roots, err := s.ListRoots(mcp.WithSession(context.Background(), withRoots))
+	if err != nil || len(roots) != 1 {
+		t.Fatalf("ListRoots() = %v, %v", roots, err)
+	}
+
+	// The first client's features go with its session
+	disconnect()
+	deadline := time.Now().Add(time.Second)
+	for s.SupportsRoots() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if s.SupportsRoots() {
+		t.Fatal("Expected roots to end with the first session")
+	}
+
+	withoutRoots, disconnect := connect(client.WithLogger(logger))
+	defer disconnect()
+	if withoutRoots.ID == withRoots.ID {
+		t.Fatalf("Expected a new session, got %q again", withoutRoots.ID)
+	}
+	if s.SupportsRoots() {
+		t.Error("Expected no roots from the second client")
+	}
+	if _, err := s.ListRoots(mcp.WithSession(context.Background(), withoutRoots)); err == nil {
+		t.Error("Expected ListRoots() to fail for a client without roots")
+	}
+}
+
+func TestCloseReasons(t *testing.T) {
+	type closeEvent struct {
+		reason transport.CloseReason
+		err    error
+	}
+	start := func(t *testing.T) (*client.Client, <-chan client.StateChange, *server.Server, <-chan closeEvent) {
+		logger := testutil.NewTestLogger(t)
+		serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+		s := server.NewServer(serverTransport, server.WithLogger(logger))
+		c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+		changes := make(chan client.StateChange, 4)
+		c.OnStateChange(func(change client.StateChange) {
+			changes <- change
+		})
+		serverClosed := make(chan closeEvent, 1)
+		s.OnClose(func(reason transport.CloseReason, err error) {
+			serverClosed <- closeEvent{reason, err}
+		})
+
+		ctx := context.Background()
+		if err := s.Start(ctx); err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		if err := c.Start(ctx); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		if c.DoneReason() != transport.NotClosed || c.Err() != nil {
+			t.Errorf("Open client reports %v, %v", c.DoneReason(), c.Err())
+		}
+		return c, changes, s, serverClosed
+	}
+	closedChange := func(t *testing.T, changes <-chan client.StateChange) client.StateChange {
+		t.Helper()
+		for {
+			select {
+			case change := <-changes:
+				if change.To == client.StateClosed {
+					return change
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for client to close")
+			}
+		}
+	}
+	serverEvent := func(t *testing.T, events <-chan closeEvent) closeEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for server to close")
+		}
+		return closeEvent{}
+	}
+
+	t.Run("client closes with error", func(t *testing.T) {
+		c, changes, _, serverClosed := start(t)
+		cause := errors.New("shutting down")
+		c.CloseWithError(cause)
+
+		change := closedChange(t, changes)
+		if change.Reason != transport.ClosedLocally || change.Err != cause {
+			t.Errorf("Client closed with %v, %v; want local close with cause", change.Reason, change.Err)
+		}
+		if !errors.Is(c.Err(), cause) {
+			t.Errorf("Client Err() = %v, want %v", c.Err(), cause)
+		}
+		event := serverEvent(t, serverClosed)
+		if event.reason != transport.ClosedByPeer || !errors.Is(event.err, transport.ErrPeerClosed) {
+			t.Errorf("Server closed with %v, %v; want clean close by peer", event.reason, event.err)
+		}
+	})
+
+	t.Run("server closes", func(t *testing.T) {
+		c, changes, s, serverClosed := start(t)
+		s.Close()
+
+		event := serverEvent(t, serverClosed)
+		if event.reason != transport.ClosedLocally || !errors.Is(event.err, transport.ErrClosed) {
+			t.Errorf("Server closed with %v, %v; want local close", event.reason, event.err)
+		}
+		change := closedChange(t, changes)
+		if change.Reason != transport.ClosedByPeer || !errors.Is(change.Err, transport.ErrPeerClosed) {
+			t.Errorf("Client closed with %v, %v; want clean close by peer", change.Reason, change.Err)
+		}
+		if c.DoneReason() != transport.ClosedByPeer {
+			t.Errorf("Client DoneReason() = %v, want %v", c.DoneReason(), transport.ClosedByPeer)
+		}
+	})
+}
+
+func TestResourceContentPush(t *testing.T) {
+	const uri = "file:///small.txt"
+
+	tests := []struct {
+		name         string
+		optIn        bool
+		mode         types.ContentPushMode
+		maxSize      int
+		wantContents bool
+		wantHash     bool
+	}{
+		{name: "contents", optIn: true, mode: types.PushContents, maxSize: 1024, wantContents: true},
+		{name: "contents too large", optIn: true, mode: types.PushContents, maxSize: 2},
+		{name: "hash", optIn: true, mode: types.PushHash, wantHash: true},
+		{name: "client did not opt in", mode: types.PushContents},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := testutil.NewTestLogger(t)
+			serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+			s := server.NewServer(serverTransport,
+				server.WithLogger(logger),
+				server.WithResources(nil, nil),
+				server.WithResourceContentPush(tt.mode, tt.maxSize),
+			)
+			s.RegisterContentHandler("file://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+				return []types.ResourceContent{
+					types.TextResourceContents{
+						ResourceContents: types.ResourceContents{URI: uri, MimeType: "text/plain"},
+						Text:             "updated",
+					},
+				}, nil
+			})
+
+			opts := []client.Option{client.WithLogger(logger)}
+			if tt.optIn {
+				opts = append(opts, client.WithResourceContentPush())
+			}
+			c := client.NewClient(clientTransport, opts...)
+
+			ctx := context.Background()
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			if err := c.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			defer func() {
+				c.Close()
+				s.Close()
+			}()
+			if err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error: %v", err)
+			}
+
+			updates := make(chan types.ResourceUpdatedNotification, 1)
+			c.OnResourceUpdateNotification(func(n types.ResourceUpdatedNotification) {
+				updates <- n
+			})
+			if err := c.SubscribeResource(ctx, uri); err != nil {
+				t.Fatalf("SubscribeResource() error: %v", err)
+			}
+			if err := s.NotifyResourceUpdated(ctx, uri); err != nil {
+				t.Fatalf("NotifyResourceUpdated() error: %v", err)
+			}
+
+			var n types.ResourceUpdatedNotification
+			select {
+			case n = <-updates:
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for update notification")
+			}
+
+			if n.URI != uri {
+				t.Errorf("URI = %s, want %s", n.URI, uri)
+			}
+			if got := len(n.Contents) == 1; got != tt.wantContents {
+				t.Errorf("Contents = %+v, want contents: %v", n.Contents, tt.wantContents)
+			} else if got {
+				if txt, ok := n.Contents[0].(types.TextResourceContents); !ok || txt.Text != "updated" {
+					t.Errorf("Unexpected pushed contents: %+v", n.Contents[0])
+				}
+			}
+			if (n.Hash != "") != tt.wantHash {
+				t.Errorf("Hash = %q, want hash: %v", n.Hash, tt.wantHash)
+			}
+			if tt.wantHash {
+				contents, err := c.ReadResource(ctx, uri)
+				if err != nil {
+					t.Fatalf("ReadResource() error: %v", err)
+				}
+				if hash, _ := types.HashResourceContents(contents); hash != n.Hash {
+					t.Errorf("Hash = %s, want hash of read contents %s", n.Hash, hash)
+				}
+			}
+
+			// Contents go only with notifications for sessions that opted in
+			if !tt.wantContents {
+				return
+			}
+			other := mcp.WithSession(ctx, &mcp.Session{ID: "other"})
+			if err := s.NotifyResourceUpdated(other, uri); err != nil {
+				t.Fatalf("NotifyResourceUpdated() error: %v", err)
+			}
+			select {
+			case n = <-updates:
+				if len(n.Contents) != 0 {
+					t.Errorf("Contents pushed for a session that did not opt in: %+v", n.Contents)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for update notification")
+			}
+		})
+	}
+}
+
+func TestPendingRequestsAndCancelAll(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	stall := make(chan struct{})
+	defer close(stall)
+	slowTool := types.NewTool[EchoInput](
+		"slow_tool",
+		"Never finishes on its own",
+		func(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+			<-stall
+			return &types.CallToolResult{}, nil
+		},
+	)
+
+	s := server.NewServer(serverTransport, server.WithLogger(logger), server.WithTools(slowTool))
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	if pending := c.PendingRequests(); len(pending) != 0 {
+		t.Fatalf("Expected no pending requests, got %+v", pending)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.CallTool(ctx, "slow_tool", map[string]interface{}{"value": "x"})
+		done <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	var pending []client.PendingRequest
+	for len(pending) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		pending = c.PendingRequests()
+	}
+	if len(pending) != 1 || pending[0].Method != methods.CallTool {
+		t.Fatalf("Expected one pending tools/call, got %+v", pending)
+	}
+
+	c.CancelAll()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected cancelled CallTool to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("CallTool not cancelled")
+	}
+	if pending := c.PendingRequests(); len(pending) != 0 {
+		t.Errorf("Expected no pending requests after CancelAll, got %+v", pending)
+	}
+
+	// The connection remains usable
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("Ping() after CancelAll error: %v", err)
+	}
+}
+
+func TestSamplingIncludeContext(t *testing.T) {
+	ctx := context.Background()
+
+	// newResourceServer connects a client to a server exposing text resources
+	newResourceServer := func(t *testing.T, files map[string]string, opts ...client.Option) (*server.Server, *client.Client) {
+		logger := testutil.NewTestLogger(t)
+		serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+		var resources []types.Resource
+		for uri := range files {
+			resources = append(resources, types.Resource{URI: uri, Name: uri, MimeType: "text/plain"})
+		}
+		s := server.NewServer(serverTransport,
+			server.WithLogger(logger),
+			server.WithResources(resources, nil),
+		)
+		s.RegisterContentHandler("file://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+			return []types.ResourceContent{
+				types.TextResourceContents{ResourceContents: types.ResourceContents{URI: uri}, Text: files[uri]},
+			}, nil
+		})
+
+		c := client.NewClient(clientTransport, append([]client.Option{client.WithLogger(logger)}, opts...)...)
+		if err := s.Start(ctx); err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		if err := c.Start(ctx); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		t.Cleanup(func() {
+			c.Close()
+			s.Close()
+		})
+		if err := c.Initialize(ctx); err != nil {
+			t.Fatalf("Initialize() error: %v", err)
+		}
+		return s, c
+	}
+
+	// Only resources named public may be shared, and never with "untrusted"
+	policy := func(ctx context.Context, req *types.CreateMessageRequest, requester, source string, r types.Resource) bool {
+		return strings.Contains(r.URI, "public") && requester != "untrusted"
+	}
+	echoSystemPrompt := func(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
+		return &types.CreateMessageResult{
+			Role:    types.RoleAssistant,
+			Content: types.TextContent{Type: "text", Text: req.SystemPrompt},
+			Model:   "echo",
+		}, nil
+	}
+
+	s, c := newResourceServer(t, map[string]string{
+		"file:///public.txt":  "shared notes",
+		"file:///private.txt": "secret notes",
+	}, client.WithSampling(echoSystemPrompt), client.WithSamplingContext(policy, 0))
+	_, other := newResourceServer(t, map[string]string{
+		"file:///public-wiki.txt": "wiki page",
+	})
+	c.SetContextSources(client.ContextSource{Name: "wiki", Client: other})
+
+	tests := []struct {
+		includeContext string
+		want           []string
+		notWant        []string
+	}{
+		{includeContext: "none", want: []string{"Be brief."}, notWant: []string{"shared notes", "wiki page"}},
+		{includeContext: "thisServer", want: []string{"Be brief.\n\nContext from server \"mcp-go\"", "shared notes"}, notWant: []string{"secret notes", "wiki page"}},
+		{includeContext: "allServers", want: []string{"shared notes", "Context from server \"wiki\"", "wiki page"}, notWant: []string{"secret notes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.includeContext, func(t *testing.T) {
+			result, err := s.CreateMessage(ctx, &types.CreateMessageRequest{
+				Messages:       []types.SamplingMessage{{Role: types.RoleUser, Content: types.TextContent{Type: "text", Text: "hi"}}},
+				SystemPrompt:   "Be brief.",
+				IncludeContext: tt.includeContext,
+				MaxTokens:      10,
+			})
+			if err != nil {
+				t.Fatalf("CreateMessage() error: %v", err)
+			}
+			prompt := result.Content.(types.TextContent).Text
+			for _, want := range tt.want {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("System prompt missing %q:\n%s", want, prompt)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(prompt, notWant) {
+					t.Errorf("System prompt unexpectedly contains %q:\n%s", notWant, prompt)
+				}
+			}
+		})
+	}
+}
+
+func TestToolResultLimit(t *testing.T) {
+	output := strings.Repeat("x", 100)
+	bigTool := types.NewTool[struct{}](
+		"big_tool",
+		"Returns a large result",
+		func(ctx context.Context, _ struct{}) (*types.CallToolResult, error) {
+			return &types.CallToolResult{
+				Content: []interface{}{types.TextContent{Type: "text", Text: output}},
+			}, nil
+		},
+	)
+
+	tests := []struct {
+		name       string
+		truncation types.ResultTruncation
+		wantError  bool
+		wantSpill  bool
+	}{
+		{name: "truncate", truncation: types.TruncateResult},
+		{name: "reject", truncation: types.RejectResult, wantError: true},
+		{name: "spill", truncation: types.SpillResult, wantSpill: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := testutil.NewTestLogger(t)
+			serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+			s := server.NewServer(serverTransport,
+				server.WithLogger(logger),
+				server.WithResources(nil, nil),
+				server.WithTools(bigTool),
+				server.WithToolResultLimit(10, tt.truncation),
+			)
+			c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+			ctx := context.Background()
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			if err := c.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			defer func() {
+				c.Close()
+				s.Close()
+			}()
+			if err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error: %v", err)
+			}
+
+			result, err := c.CallTool(ctx, "big_tool", nil)
+			if err != nil {
+				t.Fatalf("CallTool() error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Errorf("IsError = %v, want %v", result.IsError, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+
+			if len(result.Content) != 2 {
+				t.Fatalf("Content = %+v, want truncated text and marker", result.Content)
+			}
+			if text := result.Content[0].(map[string]interface{})["text"]; text != output[:10] {
+				t.Errorf("Truncated text = %v, want %s", text, output[:10])
+			}
+			marker, _ := result.Content[1].(map[string]interface{})["text"].(string)
+			if !strings.HasPrefix(marker, "[truncated 90 bytes") {
+				t.Errorf("Marker = %q, want it to report 90 truncated bytes", marker)
+			}
+
+			_, uri, spilled := strings.Cut(strings.TrimSuffix(marker, "]"), "full output at ")
+			if spilled != tt.wantSpill {
+				t.Fatalf("Marker = %q, want spill link: %v", marker, tt.wantSpill)
+			}
+			if !spilled {
+				return
+			}
+			contents, err := c.ReadResource(ctx, uri)
+			if err != nil {
+				t.Fatalf("ReadResource(%s) error: %v", uri, err)
+			}
+			if txt, ok := contents[0].(types.TextResourceContents); !ok || txt.Text != output {
+				t.Errorf("Spilled contents = %+v, want full output", contents[0])
+			}
+		})
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	var tools []types.McpTool
+	var resources []types.Resource
+	for i := 0; i < 7; i++ {
+		tools = append(tools, types.NewTool[struct{}](
+			fmt.Sprintf("tool_%d", i),
+			"A test tool",
+			func(ctx context.Context, _ struct{}) (*types.CallToolResult, error) {
+				return &types.CallToolResult{}, nil
+			},
+		))
+		resources = append(resources, types.Resource{URI: fmt.Sprintf("file:///r%d.txt", i), Name: fmt.Sprintf("r%d", i)})
+	}
+
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithTools(tools...),
+		server.WithResources(resources, nil),
+		server.WithPageSize(3),
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	listed, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error: %v", err)
+	}
+	if len(listed) != len(tools) {
+		t.Fatalf("ListTools() returned %d tools, want %d", len(listed), len(tools))
+	}
+	for i, tool := range listed {
+		if want := fmt.Sprintf("tool_%d", i); tool.Name != want {
+			t.Errorf("Tool %d = %s, want %s", i, tool.Name, want)
+		}
+	}
+
+	listedResources, err := c.ListResources(ctx)
+	if err != nil {
+		t.Fatalf("ListResources() error: %v", err)
+	}
+	if len(listedResources) != len(resources) {
+		t.Errorf("ListResources() returned %d resources, want %d", len(listedResources), len(resources))
+	}
+}
+
+func TestListPaginationSignedCursors(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	var tools []types.McpTool
+	var resources []types.Resource
+	for i := 0; i < 7; i++ {
+		tools = append(tools, types.NewTool[struct{}](
+			fmt.Sprintf("tool_%d", i),
+			"A test tool",
+			func(ctx context.Context, _ struct{}) (*types.CallToolResult, error) {
+				return &types.CallToolResult{}, nil
+			},
+		))
+		resources = append(resources, types.Resource{URI: fmt.Sprintf("file:///r%d.txt", i), Name: fmt.Sprintf("r%d", i)})
+	}
+
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithTools(tools...),
+		server.WithResources(resources, nil),
+		server.WithPageSize(3),
+		server.WithSignedCursors(nil, time.Minute),
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	// The client follows signed cursors through every page
+	listed, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error: %v", err)
+	}
+	if len(listed) != len(tools) {
+		t.Fatalf("ListTools() returned %d tools, want %d", len(listed), len(tools))
+	}
+	for i, tool := range listed {
+		if want := fmt.Sprintf("tool_%d", i); tool.Name != want {
+			t.Errorf("Tool %d = %s, want %s", i, tool.Name, want)
+		}
+	}
+
+	listedResources, err := c.ListResources(ctx)
+	if err != nil {
+		t.Fatalf("ListResources() error: %v", err)
+	}
+	if len(listedResources) != len(resources) {
+		t.Errorf("ListResources() returned %d resources, want %d", len(listedResources), len(resources))
+	}
+}
+
+func TestSubscriptionPatterns(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithResources(nil, nil),
+		server.WithSubscriptionPatterns(),
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	updates := make(chan string, 4)
+	c.OnResourceUpdateNotification(func(n types.ResourceUpdatedNotification) {
+		updates <- n.URI
+	})
+	if err := c.SubscribeResourcePattern(ctx, "file:///project/**"); err != nil {
+		t.Fatalf("SubscribeResourcePattern() error: %v", err)
+	}
+
+	for _, uri := range []string{"file:///elsewhere/a.go", "file:///project/src/main.go"} {
+		if err := s.NotifyResourceUpdated(ctx, uri); err != nil {
+			t.Fatalf("NotifyResourceUpdated(%s) error: %v", uri, err)
+		}
+	}
+	select {
+	case uri := <-updates:
+		if uri != "file:///project/src/main.go" {
+			t.Errorf("Update for %s, want only file:///project/src/main.go", uri)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for update notification")
+	}
+
+	if err := c.UnsubscribeResource(ctx, "file:///project/**"); err != nil {
+		t.Fatalf("UnsubscribeResource() error: %v", err)
+	}
+	if err := s.NotifyResourceUpdated(ctx, "file:///project/src/main.go"); err != nil {
+		t.Fatalf("NotifyResourceUpdated() error: %v", err)
+	}
+	select {
+	case uri := <-updates:
+		t.Errorf("Unexpected update for %s after unsubscribing", uri)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscriptionPatternsNotSupported(t *testing.T) {
+	c, _, ctx, cleanup := setupClientServer(t)
+	defer cleanup()
+
+	err := c.SubscribeResourcePattern(ctx, "file:///project/**")
+	var resp *types.ErrorResponse
+	if !errors.As(err, &resp) || resp.Code != types.MethodNotFound {
+		t.Errorf("SubscribeResourcePattern() error = %v, want MethodNotFound", err)
+	}
+}
+
+func TestSearchResources(t *testing.T) {
+	resources := []types.Resource{
+		{URI: "file:///project/src/main.go", Name: "main.go", MimeType: "text/x-go"},
+		{URI: "file:///project/docs/guide.md", Name: "Guide", MimeType: "text/markdown"},
+		{URI: "file:///project/assets/logo.png", Name: "Logo", MimeType: "image/png"},
+	}
+
+	for _, serverSide := range []bool{true, false} {
+		t.Run(fmt.Sprintf("server side %v", serverSide), func(t *testing.T) {
+			logger := testutil.NewTestLogger(t)
+			serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+			opts := []server.Option{server.WithLogger(logger), server.WithResources(resources, nil)}
+			if serverSide {
+				opts = append(opts, server.WithResourceFiltering())
+			}
+			s := server.NewServer(serverTransport, opts...)
+			c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+			ctx := context.Background()
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			if err := c.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			defer func() {
+				c.Close()
+				s.Close()
+			}()
+			if err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error: %v", err)
+			}
+
+			found, err := c.SearchResources(ctx, types.ResourceFilter{MimeType: "text/*", Query: "GUI"})
+			if err != nil {
+				t.Fatalf("SearchResources() error: %v", err)
+			}
+			if len(found) != 1 || found[0].URI != "file:///project/docs/guide.md" {
+				t.Errorf("SearchResources() = %+v, want only the guide", found)
+			}
+		})
+	}
+}
+
+func TestListToolsFiltered(t *testing.T) {
+	yes := true
+	noop := func(ctx context.Context, _ struct{}) (*types.CallToolResult, error) {
+		return &types.CallToolResult{}, nil
+	}
+	tools := []types.McpTool{
+		types.NewTool[struct{}]("read_file", "Reads a file", noop).
+			WithAnnotations(types.ToolAnnotations{ReadOnlyHint: &yes}),
+		types.NewTool[struct{}]("delete_file", "Deletes a file", noop),
+	}
+
+	for _, serverSide := range []bool{true, false} {
+		t.Run(fmt.Sprintf("server side %v", serverSide), func(t *testing.T) {
+			logger := testutil.NewTestLogger(t)
+			serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+			opts := []server.Option{server.WithLogger(logger), server.WithTools(tools...)}
+			if serverSide {
+				opts = append(opts, server.WithToolFiltering())
+			}
+			s := server.NewServer(serverTransport, opts...)
+			c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+			ctx := context.Background()
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			if err := c.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			defer func() {
+				c.Close()
+				s.Close()
+			}()
+			if err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error: %v", err)
+			}
+
+			found, err := c.ListToolsFiltered(ctx, types.ToolFilter{ReadOnly: &yes})
+			if err != nil {
+				t.Fatalf("ListToolsFiltered() error: %v", err)
+			}
+			if len(found) != 1 || found[0].Name != "read_file" || !found[0].Annotations.ReadOnly() {
+				t.Errorf("ListToolsFiltered() = %+v, want only read_file", found)
+			}
+		})
+	}
+}
+
+func TestSessionStats(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	s := server.NewSseServer("127.0.0.1:0", server.WithLogger(logger))
+	sessions := make(chan *mcp.Session, 1)
+	s.OnInitialized(func(session *mcp.Session, _ types.InitializeRequest) {
+		sessions <- session
+	})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := client.NewSseClient(ctx, s.BoundAddr())
+	if err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer c.Close()
+	// Replies are lost until the event stream is open
+	deadline := time.Now().Add(time.Second)
+	for s.Ping(ctx) != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	var session *mcp.Session
+	select {
+	case session = <-sessions:
+	case <-time.After(time.Second):
+		t.Fatal("OnInitialized hook not called")
+	}
+
+	// The response is counted once it is queued, which may be just after
+	// the client reads it
+	waitFor := func(cond func(mcp.SessionStats) bool) mcp.SessionStats {
+		deadline := time.Now().Add(time.Second)
+		stats := session.Stats()
+		for !cond(stats) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			stats = session.Stats()
+		}
+		return stats
+	}
+	before := waitFor(func(st mcp.SessionStats) bool { return st.MessagesOut >= 2 })
+	// initialize, initialized, and the reply to a ping from the server
+	if before.MessagesIn < 3 || before.MessagesOut < 2 || before.BytesIn == 0 || before.BytesOut == 0 {
+		t.Fatalf("Session stats after initialize = %+v", before)
+	}
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error: %v", err)
+	}
+	after := waitFor(func(st mcp.SessionStats) bool { return st.MessagesOut > before.MessagesOut })
+	if after.MessagesIn != before.MessagesIn+1 || after.MessagesOut != before.MessagesOut+1 {
+		t.Errorf("Ping changed session stats from %+v to %+v", before, after)
+	}
+	if after.BytesIn <= before.BytesIn || after.BytesOut <= before.BytesOut {
+		t.Errorf("Ping did not add bytes: %+v to %+v", before, after)
+	}
+
+	// The server's stats cover the session's
+	total := s.Stats()
+	if total.MessagesIn < after.MessagesIn || total.MessagesOut < after.MessagesOut ||
+		total.BytesIn < after.BytesIn || total.BytesOut < after.BytesOut || total.Errors < after.Errors {
+		t.Errorf("Server stats %+v do not cover session stats %+v", total, after)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	type refusal struct {
+		limit  server.QuotaLimit
+		method string
+	}
+	start := func(t *testing.T, quota server.Quota) (*client.Client, <-chan refusal, func()) {
+		logger := testutil.NewTestLogger(t)
+		serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+		s := server.NewServer(serverTransport, server.WithLogger(logger), server.WithQuota(quota))
+		c := client.NewClient(clientTransport, client.WithLogger(logger))
+		refused := make(chan refusal, 4)
+		s.OnQuotaExceeded(func(_ *mcp.Session, limit server.QuotaLimit, method string) {
+			refused <- refusal{limit, method}
+		})
+
+		ctx := context.Background()
+		if err := s.Start(ctx); err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		if err := c.Start(ctx); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		if err := c.Initialize(ctx); err != nil {
+			t.Fatalf("Initialize() error: %v", err)
+		}
+		return c, refused, func() { c.Close(); s.Close() }
+	}
+	expectRefusal := func(t *testing.T, err error, refused <-chan refusal, want server.QuotaLimit) {
+		t.Helper()
+		var mcpErr *types.ErrorResponse
+		if !errors.As(err, &mcpErr) || mcpErr.Code != types.QuotaExceeded {
+			t.Fatalf("Expected a quota error, got %v", err)
+		}
+		select {
+		case r := <-refused:
+			if r.limit != want || r.method != methods.Ping {
+				t.Errorf("OnQuotaExceeded got %v for %s, want %v for %s", r.limit, r.method, want, methods.Ping)
+			}
+		default:
+			t.Error("OnQuotaExceeded hook not run")
+		}
+	}
+
+	t.Run("requests", func(t *testing.T) {
+		c, refused, cleanup := start(t, server.Quota{RequestsPerMinute: 2})
+		defer cleanup()
+		ctx := context.Background()
+		for i := 0; i < 2; i++ {
+			if err := c.Ping(ctx); err != nil {
+				t.Fatalf("Ping() %d error: %v", i, err)
+			}
+		}
+		expectRefusal(t, c.Ping(ctx), refused, server.QuotaRequests)
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		c, refused, cleanup := start(t, server.Quota{BytesPerDay: 1})
+		defer cleanup()
+		// initialize is allowed, but its traffic uses up the quota
+		expectRefusal(t, c.Ping(context.Background()), refused, server.QuotaBytes)
+	})
+}
+
+func TestJobs(t *testing.T) {
+	release := make(chan struct{})
+	slowTool := types.NewTool[struct {
+		Hold bool `json:"hold"`
+	}](
+		"slow_tool",
+		"Runs in the background until released, or cancelled if held",
+		func(ctx context.Context, input struct {
+			Hold bool `json:"hold"`
+		}) (*types.CallToolResult, error) {
+			return server.StartJob(ctx, func(ctx context.Context, job *server.Job) (*types.CallToolResult, error) {
+				job.Progress(ctx, 1, 2, "halfway")
+				wait := release
+				if input.Hold {
+					wait = nil
+				}
+				select {
+				case <-wait:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				return &types.CallToolResult{
+					Content: []interface{}{types.TextContent{Type: "text", Text: "done"}},
+				}, nil
+			})
+		},
+	)
+
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithResources(nil, nil),
+		server.WithTools(slowTool),
+		server.WithJobs(time.Minute),
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	decode := func(result *types.CallToolResult) types.JobInfo {
+		t.Helper()
+		text, _ := result.Content[0].(map[string]interface{})["text"].(string)
+		var info types.JobInfo
+		if err := json.Unmarshal([]byte(text), &info); err != nil {
+			t.Fatalf("Job result %q is not a JobInfo: %v", text, err)
+		}
+		return info
+	}
+	start := func(hold bool) types.JobInfo {
+		t.Helper()
+		result, err := c.CallTool(ctx, "slow_tool", map[string]interface{}{"hold": hold})
+		if err != nil {
+			t.Fatalf("CallTool() error: %v", err)
+		}
+		info := decode(result)
+		if info.ID == "" || info.Tool != "slow_tool" || info.ResultURI == "" {
+			t.Fatalf("Job handle = %+v", info)
+		}
+		return info
+	}
+	// waitFor polls the job status tool until cond holds
+	waitFor := func(id string, cond func(types.JobInfo) bool) types.JobInfo {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			result, err := c.CallTool(ctx, types.JobStatusTool, map[string]interface{}{"jobId": id})
+			if err != nil {
+				t.Fatalf("%s error: %v", types.JobStatusTool, err)
+			}
+			info := decode(result)
+			if cond(info) || time.Now().After(deadline) {
+				return info
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	job := start(false)
+	info := waitFor(job.ID, func(info types.JobInfo) bool { return info.Progress == 1 })
+	if info.Status != types.JobRunning || info.Total != 2 || info.Message != "halfway" {
+		t.Errorf("Running job = %+v", info)
+	}
+	close(release)
+	info = waitFor(job.ID, func(info types.JobInfo) bool { return info.Status != types.JobRunning })
+	if info.Status != types.JobCompleted || info.Result == nil || len(info.Result.Content) != 1 {
+		t.Fatalf("Finished job = %+v", info)
+	}
+
+	// The job can also be read as a resource
+	contents, err := c.ReadResource(ctx, job.ResultURI)
+	if err != nil {
+		t.Fatalf("ReadResource(%s) error: %v", job.ResultURI, err)
+	}
+	if txt, ok := contents[0].(types.TextResourceContents); !ok || !strings.Contains(txt.Text, `"status":"completed"`) {
+		t.Errorf("Job resource = %+v", contents[0])
+	}
+
+	// Cancelling a job ends its context
+	job = start(true)
+	if _, err := c.CallTool(ctx, types.JobCancelTool, map[string]interface{}{"jobId": job.ID}); err != nil {
+		t.Fatalf("%s error: %v", types.JobCancelTool, err)
+	}
+	info = waitFor(job.ID, func(info types.JobInfo) bool { return info.Status != types.JobRunning })
+	if info.Status != types.JobCancelled {
+		t.Errorf("Cancelled job = %+v", info)
+	}
+
+	if _, err := c.CallTool(ctx, types.JobStatusTool, map[string]interface{}{"jobId": "missing"}); err == nil {
+		t.Error("Expected an error for an unknown job")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithResources([]types.Resource{{URI: "file:///watched.txt", Name: "Watched"}}, nil),
+		server.WithChangeWindow(20*time.Millisecond),
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	updates := make(chan string)
+	s.Watch(server.ResourceUpdates(updates))
+	polled := make(chan struct{}, 1)
+	s.Watch(server.Every(5*time.Millisecond, func(ctx context.Context, changes *server.Changes) {
+		changes.ResourceListChanged()
+		// Tools are not offered, so this is dropped
+		changes.ToolListChanged()
+		select {
+		case polled <- struct{}{}:
+		default:
+		}
+	}))
+
+	var mu sync.Mutex
+	var updated []string
+	listChanges := 0
+	c.OnResourceUpdated(func(uri string) {
+		mu.Lock()
+		updated = append(updated, uri)
+		mu.Unlock()
+	})
+	c.OnResourceListChanged(func() {
+		mu.Lock()
+		listChanges++
+		mu.Unlock()
+	})
+	c.OnToolListChanged(func() {
+		t.Error("Tool list change published without tools")
+	})
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer c.Close()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	if err := c.SubscribeResource(ctx, "file:///watched.txt"); err != nil {
+		t.Fatalf("SubscribeResource() error: %v", err)
+	}
+
+	// A burst of updates to one resource is published once
+	for i := 0; i < 5; i++ {
+		updates <- "file:///watched.txt"
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := len(updated) > 0 && listChanges > 0
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	if len(updated) != 1 {
+		t.Errorf("Updates = %v, want one for the burst", updated)
+	}
+	// The ticker reports every 5ms, coalesced into one per window
+	if listChanges == 0 || listChanges > 10 {
+		t.Errorf("Resource list changes = %d, want a few", listChanges)
+	}
+	mu.Unlock()
+
+	// Watchers stop with the server
+	s.Close()
+	deadline = time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-polled:
+		case <-time.After(20 * time.Millisecond):
+			return
+		}
+	}
+	t.Error("Watcher still running after Close")
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	c, s, ctx, cleanup := setupClientServer(t)
+	defer cleanup()
+
+	if err := c.SubscribeResource(ctx, "file:///example.txt"); err != nil {
+		t.Fatalf("SubscribeResource() error: %v", err)
+	}
+	current, err := s.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	data, err := json.Marshal(current)
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	var snap server.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %v", err)
+	}
+	if len(snap.Subscriptions) != 1 || snap.Subscriptions[0] != "file:///example.txt" {
+		t.Errorf("Snapshot subscriptions = %v", snap.Subscriptions)
+	}
+
+	// A replica registers the same tool handler with an older description
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	echoTool := types.NewTool[EchoInput]("echo_tool", "Old description",
+		func(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+			return &types.CallToolResult{Content: []interface{}{types.TextContent{Type: "text", Text: input.Value}}}, nil
+		})
+	replica := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithResources(nil, nil),
+		server.WithPrompts(nil),
+		server.WithTools(echoTool),
+	)
+	if err := replica.Restore(ctx, &snap); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+
+	updated := make(chan string, 1)
+	rc := client.NewClient(clientTransport, client.WithLogger(logger))
+	rc.OnResourceUpdated(func(uri string) {
+		updated <- uri
+	})
+	if err := replica.Start(ctx); err != nil {
+		t.Fatalf("Failed to start replica: %v", err)
+	}
+	defer replica.Close()
+	if err := rc.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer rc.Close()
+	if err := rc.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	resources, err := rc.ListResources(ctx)
+	if err != nil || len(resources) != 1 || resources[0].URI != "file:///example.txt" {
+		t.Errorf("ListResources() = %v, %v", resources, err)
+	}
+	prompts, err := rc.ListPrompts(ctx)
+	if err != nil || len(prompts) != 1 || prompts[0].Name != "example_prompt" {
+		t.Errorf("ListPrompts() = %v, %v", prompts, err)
+	}
+	tools, err := rc.ListTools(ctx)
+	if err != nil || len(tools) != 1 || tools[0].Description != "Echoes back the provided input" {
+		t.Errorf("ListTools() = %v, %v", tools, err)
+	}
+	if _, err := rc.CallTool(ctx, "echo_tool", map[string]interface{}{"value": "hi"}); err != nil {
+		t.Errorf("CallTool() error: %v", err)
+	}
+
+	// The subscription carried over without the client subscribing again
+	if err := replica.NotifyResourceUpdated(ctx, "file:///example.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated() error: %v", err)
+	}
+	select {
+	case uri := <-updated:
+		if uri != "file:///example.txt" {
+			t.Errorf("Updated URI = %q", uri)
+		}
+	case <-time.After(time.Second):
+		t.Error("No update for the restored subscription")
+	}
+
+	// Snapshots of another version are refused
+	if err := replica.Restore(ctx, &server.Snapshot{Version: 99}); err == nil {
+		t.Error("Restore() of an unknown version succeeded")
+	}
+}
+
+func TestSubscriptionStore(t *testing.T) {
+	ctx := context.Background()
+	store := subscriptions.NewMemoryStore()
+	newReplica := func() (*server.Server, *client.Client) {
+		logger := testutil.NewTestLogger(t)
+		serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+		s := server.NewServer(serverTransport,
+			server.WithLogger(logger),
+			server.WithSubscriptionStore(store),
+			server.WithResources([]types.Resource{{URI: "file:///shared.txt", Name: "Shared"}}, nil),
+		)
+		c := client.NewClient(clientTransport, client.WithLogger(logger))
+		return s, c
+	}
+
+	first, firstClient := newReplica()
+	second, secondClient := newReplica()
+	updated := make(chan string, 1)
+	secondClient.OnResourceUpdated(func(uri string) {
+		updated <- uri
+	})
+	for _, pair := range []struct {
+		s *server.Server
+		c *client.Client
+	}{{first, firstClient}, {second, secondClient}} {
+		if err := pair.s.Start(ctx); err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		defer pair.s.Close()
+		if err := pair.c.Start(ctx); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		defer pair.c.Close()
+		if err := pair.c.Initialize(ctx); err != nil {
+			t.Fatalf("Initialize() error: %v", err)
+		}
+	}
+
+	// A subscription through one replica is seen by the other
+	if err := firstClient.SubscribeResource(ctx, "file:///shared.txt"); err != nil {
+		t.Fatalf("SubscribeResource() error: %v", err)
+	}
+	if err := second.NotifyResourceUpdated(ctx, "file:///shared.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated() error: %v", err)
+	}
+	select {
+	case uri := <-updated:
+		if uri != "file:///shared.txt" {
+			t.Errorf("Updated URI = %q", uri)
+		}
+	case <-time.After(time.Second):
+		t.Error("No update from the replica sharing the subscription")
+	}
+
+	// And so is unsubscribing
+	if err := firstClient.UnsubscribeResource(ctx, "file:///shared.txt"); err != nil {
+		t.Fatalf("UnsubscribeResource() error: %v", err)
+	}
+	if err := second.NotifyResourceUpdated(ctx, "file:///shared.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated() error: %v", err)
+	}
+	select {
+	case uri := <-updated:
+		t.Errorf("Update of %q after unsubscribing", uri)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTextNormalization(t *testing.T) {
+	contents := map[string]types.TextResourceContents{
+		"file:///latin1.txt": {
+			ResourceContents: types.ResourceContents{URI: "file:///latin1.txt", MimeType: "text/plain; charset=iso-8859-1"},
+			Text:             "caf\xe9",
+		},
+		"file:///binary.txt": {
+			ResourceContents: types.ResourceContents{URI: "file:///binary.txt", MimeType: "text/plain"},
+			Text:             "\x81\x00\x8d",
+		},
+	}
+
+	for _, blobFallback := range []bool{false, true} {
+		t.Run(fmt.Sprintf("blob fallback %v", blobFallback), func(t *testing.T) {
+			logger := testutil.NewTestLogger(t)
+			serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+			s := server.NewServer(serverTransport,
+				server.WithLogger(logger),
+				server.WithResources(nil, nil),
+				server.WithTextNormalization(blobFallback),
+			)
+			s.RegisterContentHandler("file://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+				return []types.ResourceContent{contents[uri]}, nil
+			})
+			c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+			ctx := context.Background()
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			if err := c.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			defer func() {
+				c.Close()
+				s.Close()
+			}()
+			if err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error: %v", err)
+			}
+
+			// Text in a declared charset is converted
+			read, err := c.ReadResource(ctx, "file:///latin1.txt")
+			if err != nil {
+				t.Fatalf("ReadResource() error: %v", err)
+			}
+			text, ok := read[0].(types.TextResourceContents)
+			if !ok || text.Text != "café" || text.MimeType != "text/plain; charset=utf-8" {
+				t.Errorf("Latin-1 contents = %+v, want UTF-8 text", read[0])
+			}
+
+			// Undecodable text is a blob or has its invalid bytes replaced
+			read, err = c.ReadResource(ctx, "file:///binary.txt")
+			if err != nil {
+				t.Fatalf("ReadResource() error: %v", err)
+			}
+			if blobFallback {
+				blob, ok := read[0].(types.BlobResourceContents)
+				if !ok || blob.Blob != base64.StdEncoding.EncodeToString([]byte("\x81\x00\x8d")) {
+					t.Errorf("Undecodable contents = %+v, want the original bytes as a blob", read[0])
+				}
+				return
+			}
+			if text, ok := read[0].(types.TextResourceContents); !ok || text.Text != "\uFFFD\x00\uFFFD" {
+				t.Errorf("Undecodable contents = %+v, want replaced text", read[0])
+			}
+		})
+	}
+}
+
+func TestResourceDiffPush(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithResources([]types.Resource{{URI: "file:///app.log", Name: "Log"}}, nil),
+		server.WithResourceContentPush(types.PushDiff, 0),
+	)
+	var mu sync.Mutex
+	log := strings.Repeat("starting up\n", 20)
+	s.RegisterContentHandler("file://", func(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []types.ResourceContent{types.TextResourceContents{
+			ResourceContents: types.ResourceContents{URI: uri, MimeType: "text/plain"},
+			Text:             log,
+		}}, nil
+	})
+	c := client.NewClient(clientTransport, client.WithLogger(logger), client.WithResourceContentPush())
+
+	notifs := make(chan types.ResourceUpdatedNotification, 10)
+	c.OnResourceUpdateNotification(func(notif types.ResourceUpdatedNotification) {
+		notifs <- notif
+	})
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	texts := make(chan string, 10)
+	stop, err := c.WatchResource(ctx, "file:///app.log", func(contents []types.ResourceContent) {
+		texts <- contents[0].(types.TextResourceContents).Text
+	})
+	if err != nil {
+		t.Fatalf("WatchResource() error: %v", err)
+	}
+	defer stop()
+
+	expect := func(want string) types.ResourceUpdatedNotification {
+		t.Helper()
+		select {
+		case text := <-texts:
+			if text != want {
+				t.Errorf("Watched text = %q, want %q", text, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Watch not updated")
+		}
+		select {
+		case notif := <-notifs:
+			return notif
+		default:
+			return types.ResourceUpdatedNotification{}
+		}
+	}
+	expect(log)
+
+	// The first notification has no base to diff against
+	appendLine := func(line string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		log += line
+		return log
+	}
+	want := appendLine("request 1\n")
+	if err := s.NotifyResourceUpdated(ctx, "file:///app.log"); err != nil {
+		t.Fatalf("NotifyResourceUpdated() error: %v", err)
+	}
+	if notif := expect(want); notif.Contents == nil || notif.Patch != nil {
+		t.Errorf("First notification = %+v, want contents", notif)
+	}
+
+	// Later ones carry only the appended lines
+	want = appendLine("request 2\n")
+	if err := s.NotifyResourceUpdated(ctx, "file:///app.log"); err != nil {
+		t.Fatalf("NotifyResourceUpdated() error: %v", err)
+	}
+	notif := expect(want)
+	if notif.Contents != nil || notif.Patch == nil {
+		t.Fatalf("Second notification = %+v, want a patch", notif)
+	}
+	if edits := notif.Patch.Edits; len(edits) != 1 || len(edits[0].Insert) != 1 || edits[0].Insert[0] != "request 2\n" {
+		t.Errorf("Patch edits = %+v, want the appended line", edits)
+	}
+}
+
+func TestPromptCompletions(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithPrompts([]types.Prompt{{
+			Name:      "review",
+			Arguments: []types.PromptArgument{{Name: "language"}, {Name: "focus"}},
+		}}),
+	)
+	languages := []string{"go", "python", "rust", "typescript"}
+	s.RegisterPromptGetterWithCompletions("review",
+		func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+			return &types.GetPromptResult{}, nil
+		},
+		map[string]types.CompletionFunc{
+			"language": func(ctx context.Context, value string) ([]string, error) {
+				var matches []string
+				for _, l := range languages {
+					if strings.HasPrefix(l, value) {
+						matches = append(matches, l)
+					}
+				}
+				return matches, nil
+			},
+		},
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	completion, err := c.CompletePromptArgument(ctx, "review", "language", "py")
+	if err != nil {
+		t.Fatalf("CompletePromptArgument() error: %v", err)
+	}
+	if len(completion.Values) != 1 || completion.Values[0] != "python" || completion.HasMore {
+		t.Errorf("Completion = %+v, want python", completion)
+	}
+
+	// Arguments without a completion function get no suggestions
+	completion, err = c.CompletePromptArgument(ctx, "review", "focus", "")
+	if err != nil {
+		t.Fatalf("CompletePromptArgument() error: %v", err)
+	}
+	if len(completion.Values) != 0 {
+		t.Errorf("Completion = %+v, want no values", completion)
+	}
+
+	if _, err := c.CompletePromptArgument(ctx, "missing", "language", ""); err == nil {
+		t.Error("CompletePromptArgument() of an unknown prompt succeeded")
+	}
+}
+
+func TestPromptStreaming(t *testing.T) {
+	message := func(text string) types.PromptMessage {
+		return types.PromptMessage{Role: types.RoleUser, Content: types.TextContent{Type: "text", Text: text}}
+	}
+
+	for _, optIn := range []bool{true, false} {
+		t.Run(fmt.Sprintf("client opted in %v", optIn), func(t *testing.T) {
+			logger := testutil.NewTestLogger(t)
+			serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+			s := server.NewServer(serverTransport,
+				server.WithLogger(logger),
+				server.WithPrompts([]types.Prompt{{Name: "long"}}),
+				server.WithPromptStreaming(),
+			)
+			// The rest of the prompt is only generated once the client has
+			// the first message, if it streams
+			received := make(chan struct{}, 1)
+			s.RegisterPromptStreamer("long", func(ctx context.Context, args map[string]string) (<-chan types.PromptChunk, error) {
+				chunks := make(chan types.PromptChunk)
+				go func() {
+					defer close(chunks)
+					chunks <- types.PromptChunk{Message: message("first")}
+					if optIn {
+						select {
+						case <-received:
+						case <-time.After(time.Second):
+							chunks <- types.PromptChunk{Err: errors.New("first message not streamed")}
+							return
+						}
+					}
+					chunks <- types.PromptChunk{Message: message("second")}
+					chunks <- types.PromptChunk{Message: message("third")}
+				}()
+				return chunks, nil
+			})
+			opts := []client.Option{client.WithLogger(logger)}
+			if optIn {
+				opts = append(opts, client.WithPromptStreaming())
+			}
+			c := client.NewClient(clientTransport, opts...)
+
+			ctx := context.Background()
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			if err := c.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			defer func() {
+				c.Close()
+				s.Close()
+			}()
+			if err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error: %v", err)
+			}
+
+			var batches [][]types.PromptMessage
+			result, err := c.StreamPrompt(ctx, "long", nil, func(messages []types.PromptMessage) {
+				batches = append(batches, messages)
+				select {
+				case received <- struct{}{}:
+				default:
+				}
+			})
+			if err != nil {
+				t.Fatalf("StreamPrompt() error: %v", err)
+			}
+
+			var texts []string
+			for _, m := range result.Messages {
+				texts = append(texts, m.Content.(types.TextContent).Text)
+			}
+			if strings.Join(texts, ",") != "first,second,third" {
+				t.Errorf("Messages = %v, want first, second, third", texts)
+			}
+			if optIn && len(batches) < 2 {
+				t.Errorf("Messages arrived in %d batches, want the first on its own", len(batches))
+			}
+			if !optIn && len(batches) != 1 {
+				t.Errorf("Messages arrived in %d batches, want all at once", len(batches))
+			}
+		})
+	}
+}
+
+func TestPromptVersions(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithPrompts([]types.Prompt{{Name: "review"}, {Name: "other"}}),
+	)
+	getter := func(text string) func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+		return func(ctx context.Context, args map[string]string) (*types.GetPromptResult, error) {
+			return &types.GetPromptResult{Messages: []types.PromptMessage{{
+				Role:    types.RoleUser,
+				Content: types.TextContent{Type: "text", Text: text},
+			}}}, nil
+		}
+	}
+	ctx := context.Background()
+	err := s.RegisterPromptVersions(ctx,
+		server.PromptVersion{Prompt: types.Prompt{Name: "review", Meta: &types.PromptMeta{Version: "1"}}, Getter: getter("v1")},
+		server.PromptVersion{Prompt: types.Prompt{Name: "review", Meta: &types.PromptMeta{Version: "2"}}, Getter: getter("v2")},
+	)
+	if err != nil {
+		t.Fatalf("RegisterPromptVersions() error: %v", err)
+	}
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	versions, err := c.PromptVersions(ctx, "review")
+	if err != nil {
+		t.Fatalf("PromptVersions() error: %v", err)
+	}
+	var got []string
+	for _, p := range versions {
+		got = append(got, fmt.Sprintf("%s %s %q", p.Name, p.Version(), p.Deprecated()))
+	}
+	want := []string{`review 2 ""`, `review@1 1 "superseded by version 2"`, `review@2 2 ""`}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("PromptVersions() = %v, want %v", got, want)
+	}
+
+	// Old versions stay available, flagged for hosts to warn about
+	prompt, err := c.LookupPrompt(ctx, "review@1")
+	if err != nil {
+		t.Fatalf("LookupPrompt() error: %v", err)
+	}
+	if prompt.Deprecated() == "" {
+		t.Errorf("Version 1 = %+v, want it deprecated", prompt)
+	}
+	for name, text := range map[string]string{"review": "v2", "review@1": "v1"} {
+		result, err := c.GetPrompt(ctx, name, nil)
+		if err != nil {
+			t.Fatalf("GetPrompt(%s) error: %v", name, err)
+		}
+		if got := result.Messages[0].Content.(types.TextContent).Text; got != text {
+			t.Errorf("GetPrompt(%s) = %q, want %q", name, got, text)
+		}
+	}
+
+	// The metadata travels in _meta
+	data, err := json.Marshal(prompt)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if !strings.Contains(string(data), `"_meta":{"version":"1","deprecated":"superseded by version 2"}`) {
+		t.Errorf("Prompt JSON = %s, want version and deprecation in _meta", data)
+	}
+}
+
+func TestToolDeprecation(t *testing.T) {
+	search := func(ctx context.Context, _ struct{}) (*types.CallToolResult, error) {
+		return &types.CallToolResult{Content: []interface{}{types.TextContent{Type: "text", Text: "found"}}}, nil
+	}
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport,
+		server.WithLogger(logger),
+		server.WithTools(
+			types.NewTool[struct{}]("search", "Searches", search),
+			types.NewTool[struct{}]("find", "Searches", search).WithDeprecation("it only matches whole words", "search"),
+		),
+		server.WithToolDeprecationWarnings(),
+	)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error: %v", err)
+	}
+	for _, tool := range tools {
+		deprecated := tool.Deprecated() != "" && tool.ReplacedBy() == "search"
+		if deprecated != (tool.Name == "find") {
+			t.Errorf("Tool %s deprecated %q, replaced by %q", tool.Name, tool.Deprecated(), tool.ReplacedBy())
+		}
+	}
+
+	texts := func(name string) []string {
+		t.Helper()
+		result, err := c.CallTool(ctx, name, nil)
+		if err != nil {
+			t.Fatalf("CallTool(%s) error: %v", name, err)
+		}
+		var texts []string
+		for _, content := range result.Content {
+			text, _ := content.(map[string]interface{})["text"].(string)
+			texts = append(texts, text)
+		}
+		return texts
+	}
+	if got := texts("search"); len(got) != 1 {
+		t.Errorf("search content = %q, want the result alone", got)
+	}
+	got := texts("find")
+	want := "Warning: tool find is deprecated: it only matches whole words. Use search instead."
+	if len(got) != 2 || got[0] != want || got[1] != "found" {
+		t.Errorf("find content = %q, want the warning and the result", got)
+	}
+}
+
+func TestTCPClientServer(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	echoTool := types.NewTool[EchoInput](
+		"echo_tool",
+		"Echoes back the provided input",
+		func(ctx context.Context, input EchoInput) (*types.CallToolResult, error) {
+			return &types.CallToolResult{
+				Content: []interface{}{types.TextContent{Type: "text", Text: "Echo: " + input.Value}},
+			}, nil
+		},
+	)
+	s := server.NewTCPServer("127.0.0.1:0", server.WithLogger(logger), server.WithTools(echoTool))
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	// Clients connect one after another to the same server. The server
+	// refuses a client until it has seen the previous one go, so connecting
+	// is retried.
+	connect := func() *client.Client {
+		for {
+			c, err := client.NewTCPClient(ctx, s.BoundAddr(), client.WithLogger(logger))
+			if err == nil {
+				if err = c.Initialize(ctx); err == nil {
+					return c
+				}
+				c.Close()
+			}
+			if ctx.Err() != nil {
+				t.Fatalf("Failed to connect: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		c := connect()
+		result, err := c.CallTool(ctx, "echo_tool", map[string]interface{}{"value": "hello"})
+		if err != nil {
+			t.Fatalf("CallTool error: %v", err)
+		}
+		if text := result.Content[0].(map[string]interface{})["text"]; text != "Echo: hello" {
+			t.Errorf("Unexpected result: %v", text)
+		}
+		c.Close()
+	}
+}
+
+func TestStructuredToolResult(t *testing.T) {
+	type operands struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+	type sum struct {
+		Total int `json:"total"`
+	}
+	sumTool := types.NewTool[operands]("sum", "Adds two numbers", func(ctx context.Context, input operands) (*types.CallToolResult, error) {
+		return types.NewStructuredResult(sum{Total: input.A + input.B})
+	})
+
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport, server.WithLogger(logger), server.WithTools(sumTool))
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	var got sum
+	result, err := c.CallToolStructured(ctx, "sum", map[string]interface{}{"a": 2, "b": 3}, &got)
+	if err != nil {
+		t.Fatalf("CallToolStructured() error: %v", err)
+	}
+	if got.Total != 5 {
+		t.Errorf("Total = %d, want 5", got.Total)
+	}
+	if text := result.Content[0].(map[string]interface{})["text"]; text != `{"total":5}` {
+		t.Errorf("Mirrored text = %v", text)
+	}
+}
+
+func TestRecentNotifications(t *testing.T) {
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport, server.WithLogger(logger), server.WithTools())
+	c := client.NewClient(clientTransport, client.WithLogger(logger), client.WithNotificationReplay(2))
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() {
+		c.Close()
+		s.Close()
+	}()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+
+	before := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := s.SetTools(ctx, nil); err != nil {
+			t.Fatalf("SetTools() error: %v", err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	var recent []client.RecordedNotification
+	for len(recent) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		recent = c.RecentNotifications(methods.ToolsChanged, 0)
+	}
+	if len(recent) != 2 {
+		t.Fatalf("Kept %d notifications, want 2", len(recent))
+	}
+	if recent[0].Method != methods.ToolsChanged || recent[0].Received.Before(before) || recent[1].Received.Before(recent[0].Received) {
+		t.Errorf("Recent notifications = %+v", recent)
+	}
+	if last := c.RecentNotifications(methods.ToolsChanged, 1); len(last) != 1 || last[0].Received != recent[1].Received {
+		t.Errorf("Last notification = %+v, want %+v", last, recent[1])
+	}
+	if other := c.RecentNotifications(methods.Message, 0); len(other) != 0 {
+		t.Errorf("Kept %d log messages, want none", len(other))
+	}
+}
+
+func TestDefaultServerLogger(t *testing.T) {
+	// The invalid key is reported through the logger, although the option
+	// setting it comes after the others
+	t.Setenv("MCP_STDIO_SIGNING_KEY", "not hex")
+	logger := testutil.NewTestLogger(t)
+	server.NewDefaultServer(server.WithPageSize(10), server.WithLogger(logger))
+	if !strings.Contains(logger.String(), "WARN: Ignoring frame signing key") {
+		t.Errorf("Logged %q, want a warning about the signing key", logger.String())
+	}
+}
+
+func TestServerStartLifecycle(t *testing.T) {
+	testutil.CheckGoroutines(t)
+
+	serverTransport, clientTransport := mock.NewMockPipeTransports(testutil.NewTestLogger(t))
+	if err := clientTransport.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start client transport: %v", err)
+	}
+	defer clientTransport.Close()
+	s := server.NewServer(serverTransport)
+	watching := make(chan struct{}, 2)
+	s.Watch(func(ctx context.Context, changes *server.Changes) {
+		watching <- struct{}{}
+		<-ctx.Done()
+	})
+
+	// A context already done starts nothing
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Start(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Start with a canceled context = %v, want context.Canceled", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := s.Start(ctx); err != nil {
+			t.Fatalf("Start #%d error: %v", i+1, err)
+		}
+	}
+	<-watching
+	select {
+	case <-watching:
+		t.Error("Second Start ran the watchers again")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	closed := make(chan struct{}, 2)
+	s.OnClose(func(transport.CloseReason, error) { closed <- struct{}{} })
+	if err := s.Close(); err != nil {
+		t.Errorf("Close error: %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnClose callbacks did not run")
+	}
+
+	// A server that shut down cannot be reused
+	if err := s.Start(ctx); !errors.Is(err, transport.ErrClosed) {
+		t.Errorf("Start after Close = %v, want transport.ErrClosed", err)
+	}
+	if err := s.Run(ctx); !errors.Is(err, transport.ErrClosed) {
+		t.Errorf("Run after Close = %v, want transport.ErrClosed", err)
+	}
+}
+
+func TestDebugGoroutines(t *testing.T) {
+	c, s, _, cleanup := setupClientServer(t)
+	dump := mcp.DebugGoroutines()
+	for _, role := range []string{"base/messages", "base/notifications", "base/run", "stdio/watch", "server/wait", "client/watch"} {
+		if !strings.Contains(dump, " "+role+"\n") {
+			t.Errorf("Dump lacks %s:\n%s", role, dump)
+		}
+	}
+	if !strings.Contains(dump, "handleMessages") {
+		t.Errorf("Dump lacks stacks:\n%s", dump)
+	}
+
+	cleanup()
+	<-c.Done()
+	<-s.Done()
+	deadline := time.Now().Add(2 * time.Second)
+	for dump = mcp.DebugGoroutines(); dump != "" && time.Now().Before(deadline); dump = mcp.DebugGoroutines() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if dump != "" {
+		t.Errorf("Goroutines left after closing:\n%s", dump)
+	}
+}
+
+func TestNotFoundErrors(t *testing.T) {
+	c, _, ctx, cleanup := setupClientServer(t)
+	defer cleanup()
+
+	var resp *types.ErrorResponse
+	_, err := c.CallTool(ctx, "removed_tool", nil)
+	if !errors.Is(err, client.ErrToolNotFound) || !errors.As(err, &resp) || resp.Code != types.InvalidParams {
+		t.Errorf("CallTool of a missing tool = %v", err)
+	}
+	if _, err := c.GetPrompt(ctx, "removed_prompt", nil); !errors.Is(err, client.ErrPromptNotFound) {
+		t.Errorf("GetPrompt of a missing prompt = %v", err)
+	}
+	if _, err := c.LookupPrompt(ctx, "removed_prompt"); !errors.Is(err, client.ErrPromptNotFound) {
+		t.Errorf("LookupPrompt of a missing prompt = %v", err)
+	}
+	// The handler's own error says so
+	if _, err := c.ReadResource(ctx, "file:///missing.txt"); !errors.Is(err, client.ErrResourceNotFound) {
+		t.Errorf("ReadResource of a missing resource = %v", err)
+	}
+	results, err := c.ReadResources(ctx, []string{"file:///missing.txt"})
+	if err != nil || !errors.Is(results["file:///missing.txt"].Err, client.ErrResourceNotFound) {
+		t.Errorf("ReadResources of a missing resource = %+v, %v", results, err)
+	}
+
+	_, err = c.CallTool(ctx, "echo_tool", map[string]interface{}{"value": 3})
+	if err == nil || errors.Is(err, client.ErrToolNotFound) {
+		t.Errorf("CallTool with invalid arguments = %v, want an error other than ErrToolNotFound", err)
+	}
+}
+
+func TestStatResource(t *testing.T) {
+	size := int64(1234)
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	resources := []types.Resource{
+		{URI: "file:///project/main.go", Name: "main.go", MimeType: "text/x-go", Size: &size, LastModified: &modified},
+		{URI: "file:///project/main.go.orig", Name: "main.go.orig", MimeType: "text/plain"},
+	}
+
+	for _, serverSide := range []bool{true, false} {
+		t.Run(fmt.Sprintf("server side %v", serverSide), func(t *testing.T) {
+			logger := testutil.NewTestLogger(t)
+			serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+
+			opts := []server.Option{server.WithLogger(logger), server.WithResources(resources, nil)}
+			if serverSide {
+				opts = append(opts, server.WithResourceFiltering())
+			}
+			s := server.NewServer(serverTransport, opts...)
+			c := client.NewClient(clientTransport, client.WithLogger(logger))
+
+			ctx := context.Background()
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			if err := c.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			defer func() {
+				c.Close()
+				s.Close()
+			}()
+			if err := c.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error: %v", err)
+			}
+
+			r, err := c.StatResource(ctx, "file:///project/main.go")
+			if err != nil {
+				t.Fatalf("StatResource() error: %v", err)
+			}
+			if r.Name != "main.go" || r.MimeType != "text/x-go" || r.Size == nil || *r.Size != size ||
+				r.LastModified == nil || !r.LastModified.Equal(modified) {
+				t.Errorf("StatResource() = %+v", r)
+			}
+
+			if r, err := c.StatResource(ctx, "file:///project/main.go.orig"); err != nil || r.Size != nil || r.LastModified != nil {
+				t.Errorf("StatResource() of a resource without size = %+v, %v", r, err)
+			}
+			if _, err := c.StatResource(ctx, "file:///project/missing.go"); !errors.Is(err, client.ErrResourceNotFound) {
+				t.Errorf("StatResource() of a missing resource = %v, want ErrResourceNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Package mcp holds helpers shared by MCP clients and servers
+package mcp
+
+import "context"
+
+type outgoingMetaKey struct{}
+type peerMetaKey struct{}
+
+// WithMeta returns a context that adds key to the _meta of every request sent
+// with it, e.g. a correlation ID or an experiment flag. Keys set by the
+// library itself, such as progressToken, take precedence.
+func WithMeta(ctx context.Context, key string, value interface{}) context.Context {
+	prev := Meta(ctx)
+	meta := make(map[string]interface{}, len(prev)+1)
+	for k, v := range prev {
+		meta[k] = v
+	}
+	meta[key] = value
+	return context.WithValue(ctx, outgoingMetaKey{}, meta)
+}
+
+// Meta returns the _meta fields added to ctx with WithMeta. The result must
+// not be modified.
+func Meta(ctx context.Context) map[string]interface{} {
+	meta, _ := ctx.Value(outgoingMetaKey{}).(map[string]interface{})
+	return meta
+}
+
+// WithPeerMeta returns a context carrying the _meta of a request received from
+// the peer. It is called before request handlers run.
+func WithPeerMeta(ctx context.Context, meta map[string]interface{}) context.Context {
+	return context.WithValue(ctx, peerMetaKey{}, meta)
+}
+
+// PeerMeta returns the _meta the peer sent with the request being handled,
+// or nil if there was none
+func PeerMeta(ctx context.Context) map[string]interface{} {
+	meta, _ := ctx.Value(peerMetaKey{}).(map[string]interface{})
+	return meta
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// CatalogFormat selects the output format of ExportCatalog
+type CatalogFormat string
+
+const (
+	// CatalogJSON exports the catalog as indented JSON
+	CatalogJSON CatalogFormat = "json"
+
+	// CatalogMarkdown exports the catalog as Markdown documentation
+	CatalogMarkdown CatalogFormat = "markdown"
+)
+
+// Catalog describes everything a server offers
+type Catalog struct {
+	Server            types.Implementation     `json:"server"`
+	Tools             []types.Tool             `json:"tools,omitempty"`
+	Prompts           []types.Prompt           `json:"prompts,omitempty"`
+	ResourceTemplates []types.ResourceTemplate `json:"resourceTemplates,omitempty"`
+}
+
+// Catalog returns the currently registered tools, prompts, and resource templates
+func (s *Server) Catalog(ctx context.Context) (*Catalog, error) {
+	c := &Catalog{Server: s.info}
+	if s.SupportsTools() {
+		c.Tools = s.tools.Tools()
+	}
+	if s.SupportsPrompts() {
+		c.Prompts = s.prompts.Prompts()
+	}
+	if s.SupportsResources() {
+		templates, err := s.resources.Templates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.ResourceTemplates = templates
+	}
+	return c, nil
+}
+
+// ExportCatalog writes the server's catalog to w in the given format, making
+// it easy to publish documentation of what the server offers.
+func (s *Server) ExportCatalog(w io.Writer, format CatalogFormat) error {
+	c, err := s.Catalog(context.Background())
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case CatalogJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	case CatalogMarkdown:
+		return writeMarkdownCatalog(w, c)
+	default:
+		return fmt.Errorf("unsupported catalog format: %q", format)
+	}
+}
+
+func writeMarkdownCatalog(w io.Writer, c *Catalog) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s %s\n", c.Server.Name, c.Server.Version)
+
+	if len(c.Tools) > 0 {
+		sb.WriteString("\n## Tools\n")
+		for _, tool := range c.Tools {
+			fmt.Fprintf(&sb, "\n### `%s`\n\n", tool.Name)
+			if tool.Description != "" {
+				fmt.Fprintf(&sb, "%s\n\n", tool.Description)
+			}
+			schema, err := json.MarshalIndent(tool.InputSchema, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal schema for tool %s: %w", tool.Name, err)
+			}
+			fmt.Fprintf(&sb, "Input schema:\n\n```json\n%s\n```\n", schema)
+		}
+	}
+
+	if len(c.Prompts) > 0 {
+		sb.WriteString("\n## Prompts\n")
+		for _, prompt := range c.Prompts {
+			fmt.Fprintf(&sb, "\n### `%s`\n\n", prompt.Name)
+			if prompt.Description != "" {
+				fmt.Fprintf(&sb, "%s\n\n", prompt.Description)
+			}
+			if len(prompt.Arguments) == 0 {
+				sb.WriteString("No arguments.\n")
+				continue
+			}
+			sb.WriteString("| Argument | Required | Description |\n|---|---|---|\n")
+			for _, arg := range prompt.Arguments {
+				fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", arg.Name, yesNo(arg.Required), escapeCell(arg.Description))
+			}
+		}
+	}
+
+	if len(c.ResourceTemplates) > 0 {
+		sb.WriteString("\n## Resource templates\n\n")
+		sb.WriteString("| URI template | Name | MIME type | Description |\n|---|---|---|---|\n")
+		for _, t := range c.ResourceTemplates {
+			fmt.Fprintf(&sb, "| `%s` | %s | %s | %s |\n",
+				t.URITemplate, escapeCell(t.Name), t.MimeType, escapeCell(t.Description))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// escapeCell makes text safe for use in a Markdown table cell
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/internal/server/resources"
+	"github.com/dwrtz/mcp-go/internal/server/tools"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// jobPrefix is the URI prefix of job resources
+const jobPrefix = "job://"
+
+// ErrJobsDisabled is returned by StartJob outside a tool call of a server
+// created WithJobs
+var ErrJobsDisabled = errors.New("background jobs not enabled")
+
+type jobsKey struct{}
+
+// jobStarter starts jobs for the tool call whose context carries it
+type jobStarter struct {
+	jobs *jobs
+	tool string
+}
+
+// WithJobs lets tools run in the background with StartJob, and adds the
+// types.JobStatusTool and types.JobCancelTool tools. Finished jobs are kept
+// for retention before they are forgotten. With WithResources, each job can
+// also be read as a job:// resource. Running jobs are cancelled when the
+// server closes. Requires WithTools.
+func WithJobs(retention time.Duration) Option {
+	return func(s *Server) {
+		s.jobs = &jobs{base: s.base, retention: retention, running: make(map[string]*Job)}
+	}
+}
+
+// StartJob runs run in the background for the tool call being handled and
+// returns the result the tool should return right away: the job's
+// types.JobInfo as JSON text. The job's context keeps the values of ctx but
+// not its cancellation; it is cancelled by types.JobCancelTool and when the
+// server closes. It returns ErrJobsDisabled unless ctx is that of a tool call
+// on a server created WithJobs.
+func StartJob(ctx context.Context, run func(ctx context.Context, job *Job) (*types.CallToolResult, error)) (*types.CallToolResult, error) {
+	starter, ok := ctx.Value(jobsKey{}).(jobStarter)
+	if !ok {
+		return nil, ErrJobsDisabled
+	}
+	job := starter.jobs.start(ctx, starter.tool, run)
+	return jobResult(job.Info())
+}
+
+// Job is a tool call running in the background, see StartJob
+type Job struct {
+	base   *base.Base
+	cancel context.CancelFunc
+	done   chan struct{}
+	// token is the progress token of the call that started the job, if any
+	token types.ProgressToken
+
+	mu   sync.Mutex
+	info types.JobInfo
+}
+
+// ID returns the job's ID
+func (j *Job) ID() string {
+	return j.info.ID
+}
+
+// Info returns the job's current state
+func (j *Job) Info() types.JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.info
+}
+
+// Done returns a channel closed when the job has finished
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Progress records the job's progress toward total, 0 if unknown, with an
+// optional message. When the tool call asked for progress, it is also sent
+// to the client as a progress notification.
+func (j *Job) Progress(ctx context.Context, progress, total float64, message string) {
+	j.mu.Lock()
+	j.info.Progress, j.info.Total, j.info.Message = progress, total, message
+	j.mu.Unlock()
+
+	if j.token == nil {
+		return
+	}
+	notif := types.ProgressNotification{ProgressToken: j.token, Progress: progress, Total: total}
+	if err := j.base.SendNotification(ctx, methods.Progress, notif); err != nil {
+		j.base.Log(ctx, logger.LevelWarn, "Failed to send progress of job %s: %v", j.info.ID, err)
+	}
+}
+
+// finish records the outcome of the job
+func (j *Job) finish(ctx context.Context, result *types.CallToolResult, err error) {
+	j.mu.Lock()
+	switch {
+	case ctx.Err() != nil:
+		j.info.Status = types.JobCancelled
+	case err != nil:
+		j.info.Status = types.JobFailed
+		j.info.Error = err.Error()
+	default:
+		j.info.Status = types.JobCompleted
+		j.info.Result = result
+	}
+	j.mu.Unlock()
+	close(j.done)
+}
+
+// jobs tracks the background jobs of a server
+type jobs struct {
+	base      *base.Base
+	retention time.Duration
+	// resources is set when jobs can be read as resources
+	resources bool
+
+	mu      sync.Mutex
+	running map[string]*Job
+	closed  bool
+}
+
+// install makes jobs available to the tool calls of t and serves them from
+// res, if not nil
+func (js *jobs) install(t *tools.Server, res *resources.Server) {
+	t.SetCallContext(func(ctx context.Context, tool string) context.Context {
+		return context.WithValue(ctx, jobsKey{}, jobStarter{jobs: js, tool: tool})
+	})
+	t.SetBuiltins(js.tools())
+	if res != nil {
+		js.resources = true
+		res.RegisterContentHandler(jobPrefix, js.read)
+	}
+}
+
+func (js *jobs) start(ctx context.Context, tool string, run func(ctx context.Context, job *Job) (*types.CallToolResult, error)) *Job {
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	job := &Job{
+		base:   js.base,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		token:  mcp.PeerMeta(ctx)["progressToken"],
+		info:   types.JobInfo{ID: newJobID(), Tool: tool, Status: types.JobRunning},
+	}
+	if js.resources {
+		job.info.ResultURI = jobPrefix + job.info.ID
+	}
+
+	js.mu.Lock()
+	js.running[job.info.ID] = job
+	if js.closed {
+		cancel()
+	}
+	js.mu.Unlock()
+
+	goroutine.Go("server/job", func() {
+		defer cancel()
+		result, err := run(jobCtx, job)
+		job.finish(jobCtx, result, err)
+		time.AfterFunc(js.retention, func() {
+			js.mu.Lock()
+			delete(js.running, job.info.ID)
+			js.mu.Unlock()
+		})
+	})
+	return job
+}
+
+// get returns the job with id
+func (js *jobs) get(id string) (*Job, error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	job, ok := js.running[id]
+	if !ok {
+		return nil, fmt.Errorf("job not found or expired: %s", id)
+	}
+	return job, nil
+}
+
+// cancelAll cancels every job, including those started later
+func (js *jobs) cancelAll() {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.closed = true
+	for _, job := range js.running {
+		job.cancel()
+	}
+}
+
+// jobRequest is the input of the job tools
+type jobRequest struct {
+	JobID string `json:"jobId" jsonschema:"description=ID of the job,required"`
+}
+
+// tools returns the job status and cancel tools
+func (js *jobs) tools() []types.McpTool {
+	readOnly := true
+	status := types.NewTool[jobRequest](types.JobStatusTool,
+		"Returns the status of a background job, with its result once it has completed",
+		func(ctx context.Context, req jobRequest) (*types.CallToolResult, error) {
+			job, err := js.get(req.JobID)
+			if err != nil {
+				return nil, err
+			}
+			return jobResult(job.Info())
+		}).WithAnnotations(types.ToolAnnotations{ReadOnlyHint: &readOnly})
+	cancel := types.NewTool[jobRequest](types.JobCancelTool,
+		"Cancels a running background job, which stops once it notices",
+		func(ctx context.Context, req jobRequest) (*types.CallToolResult, error) {
+			job, err := js.get(req.JobID)
+			if err != nil {
+				return nil, err
+			}
+			job.cancel()
+			return jobResult(job.Info())
+		})
+	return []types.McpTool{status, cancel}
+}
+
+func (js *jobs) read(_ context.Context, uri string) ([]types.ResourceContent, error) {
+	job, err := js.get(uri[len(jobPrefix):])
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(job.Info())
+	if err != nil {
+		return nil, err
+	}
+	return []types.ResourceContent{types.TextResourceContents{
+		ResourceContents: types.ResourceContents{URI: uri, MimeType: "application/json"},
+		Text:             string(data),
+	}}, nil
+}
+
+// jobResult returns info as the JSON text of a tool result
+func jobResult(info types.JobInfo) (*types.CallToolResult, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	return &types.CallToolResult{
+		Content: []interface{}{types.TextContent{Type: "text", Text: string(data)}},
+	}, nil
+}
+
+func newJobID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// WithLogging enables sending log messages to clients with LogMessage. Each
+// client gets every message until it asks for a minimum level with
+// logging/setLevel.
+func WithLogging() Option {
+	return func(s *Server) {
+		s.capabilities.Logging = &types.LoggingServerCapabilities{}
+		s.logging = true
+	}
+}
+
+// SupportsLogging returns whether log messages can be sent to clients
+func (s *Server) SupportsLogging() bool {
+	return s.logging
+}
+
+// LogMessage sends a log message of level, from the named logger (may be
+// ""), to the client of the session in ctx, or the connected client when
+// ctx has none. Messages below the level the client set are dropped. data
+// may be any JSON-serializable value. Requires WithLogging.
+func (s *Server) LogMessage(ctx context.Context, level types.LoggingLevel, logger string, data interface{}) error {
+	if !s.logging {
+		return types.NewError(types.MethodNotFound, "logging not supported")
+	}
+	s.sessionsMu.RLock()
+	min := types.LoggingDebug
+	if f, ok := s.sessions[s.sessionID(ctx)]; ok && f.logLevel != "" {
+		min = f.logLevel
+	}
+	s.sessionsMu.RUnlock()
+	if level.Severity() < min.Severity() {
+		return nil
+	}
+	return s.base.SendNotification(ctx, methods.Message, types.LoggingMessageNotification{
+		Method: methods.Message,
+		Level:  level,
+		Logger: logger,
+		Data:   data,
+	})
+}
+
+// handleSetLogLevel records the least severe level of log messages the
+// client of the session wants
+func (s *Server) handleSetLogLevel(ctx context.Context, req types.SetLevelRequest) (*types.SetLevelResult, error) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	id := s.sessionID(ctx)
+	f, ok := s.sessions[id]
+	if !ok {
+		f = &clientFeatures{}
+		s.sessions[id] = f
+	}
+	f.logLevel = req.Level
+	return &types.SetLevelResult{}, nil
+}
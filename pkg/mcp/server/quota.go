@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Quota limits what each session may use of a server. Zero fields are
+// unlimited.
+type Quota struct {
+	// RequestsPerMinute caps the requests a session may make each minute
+	RequestsPerMinute int
+	// BytesPerDay caps the bytes a session may send and receive each day,
+	// as counted in its mcp.SessionStats
+	BytesPerDay uint64
+}
+
+// QuotaLimit names the part of a Quota a session exceeded
+type QuotaLimit string
+
+const (
+	QuotaRequests QuotaLimit = "requests"
+	QuotaBytes    QuotaLimit = "bytes"
+)
+
+// quotaState is a session's use of its quota in the current windows
+type quotaState struct {
+	requestsStart time.Time
+	requests      int
+	bytesStart    time.Time
+	// bytesBase is the session's byte count when the day began
+	bytesBase uint64
+}
+
+// quotas tracks the quota use of each session, keyed by session ID
+type quotas struct {
+	mu       sync.Mutex
+	quota    Quota
+	sessions map[string]*quotaState
+	exceeded []func(*mcp.Session, QuotaLimit, string)
+}
+
+// WithQuota limits each session to quota. Requests over it are answered
+// with a types.QuotaExceeded error, and OnQuotaExceeded hooks are run.
+// initialize is always allowed, so clients can connect.
+func WithQuota(quota Quota) Option {
+	return func(s *Server) {
+		s.quotas.quota = quota
+		s.base.SetRequestGate(s.checkQuota)
+	}
+}
+
+// OnQuotaExceeded registers a hook run with the session, the limit it
+// exceeded, and the method of each request refused by WithQuota. session is
+// nil for transports without sessions.
+func (s *Server) OnQuotaExceeded(hook func(session *mcp.Session, limit QuotaLimit, method string)) {
+	s.quotas.mu.Lock()
+	defer s.quotas.mu.Unlock()
+	s.quotas.exceeded = append(s.quotas.exceeded, hook)
+}
+
+// checkQuota refuses requests over the quota of the session in ctx
+func (s *Server) checkQuota(ctx context.Context, method string) error {
+	if method == methods.Initialize {
+		return nil
+	}
+	session := mcp.SessionFromContext(ctx)
+	var stats mcp.SessionStats
+	if session != nil {
+		stats = session.Stats()
+	} else {
+		stats = s.base.Stats()
+	}
+	used := stats.BytesIn + stats.BytesOut
+
+	limit, retryAfter := s.quotas.take(s.sessionID(ctx), used, time.Now())
+	if limit == "" {
+		return nil
+	}
+
+	s.quotas.mu.Lock()
+	hooks := s.quotas.exceeded
+	s.quotas.mu.Unlock()
+	for _, hook := range hooks {
+		hook(session, limit, method)
+	}
+
+	return types.NewError(types.QuotaExceeded,
+		fmt.Sprintf("%s quota exceeded, retry in %v", limit, retryAfter),
+		types.QuotaExceededData{Limit: string(limit), RetryAfter: int((retryAfter + time.Second - 1) / time.Second)})
+}
+
+// take counts a request of the session with id, which has used bytes so far,
+// and returns the limit it exceeds with the time until that limit resets, or
+// "" if it is within its quota
+func (q *quotas) take(id string, bytes uint64, now time.Time) (QuotaLimit, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st, ok := q.sessions[id]
+	if !ok {
+		st = &quotaState{requestsStart: now, bytesStart: now}
+		q.sessions[id] = st
+	}
+
+	if now.Sub(st.bytesStart) >= 24*time.Hour {
+		st.bytesStart, st.bytesBase = now, bytes
+	}
+	if q.quota.BytesPerDay > 0 && bytes-st.bytesBase > q.quota.BytesPerDay {
+		return QuotaBytes, st.bytesStart.Add(24 * time.Hour).Sub(now)
+	}
+
+	if now.Sub(st.requestsStart) >= time.Minute {
+		st.requestsStart, st.requests = now, 0
+	}
+	if q.quota.RequestsPerMinute > 0 && st.requests >= q.quota.RequestsPerMinute {
+		return QuotaRequests, st.requestsStart.Add(time.Minute).Sub(now)
+	}
+	st.requests++
+	return "", 0
+}
+
+// end forgets the quota use of the session with id
+func (q *quotas) end(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.sessions, id)
+}
@@ -2,33 +2,52 @@ package server
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
 	"fmt"
+	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/internal/paging"
 	"github.com/dwrtz/mcp-go/internal/server/prompts"
 	"github.com/dwrtz/mcp-go/internal/server/resources"
-	"github.com/dwrtz/mcp-go/internal/server/roots"
-	"github.com/dwrtz/mcp-go/internal/server/sampling"
 	"github.com/dwrtz/mcp-go/internal/server/tools"
-	"github.com/dwrtz/mcp-go/internal/transport"
 	"github.com/dwrtz/mcp-go/internal/transport/sse"
 	"github.com/dwrtz/mcp-go/internal/transport/stdio"
+	"github.com/dwrtz/mcp-go/internal/transport/tcp"
+	"github.com/dwrtz/mcp-go/pkg/auth"
 	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
 	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/transport"
 	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
 )
 
-// NewDefaultServer creates an MCP server with default settings
+// NewDefaultServer creates an MCP server with default settings. If the host
+// passed a key in MCP_STDIO_SIGNING_KEY, frames are signed with it, see the
+// client's WithSignedStdio. Without WithLogger, warnings go to stderr.
 func NewDefaultServer(opts ...Option) *Server {
-
-	// Create transport
+	// The transport is created first, as NewServer needs it. WithLogger
+	// replaces its stderr logger as the options run, before Start, so the
+	// transport logs through the logger from its first message.
 	t := stdio.NewTransport(os.Stdin, os.Stdout)
-
-	// Create server
+	stderr := logger.NewStderrLogger("")
+	stderr.SetLevel(logger.LevelWarn)
+	t.SetLogger(stderr)
 	s := NewServer(t, opts...)
 
+	// Sign frames if the host passed a key
+	key, err := stdio.SigningKeyFromEnv()
+	if err != nil {
+		s.base.Log(context.Background(), logger.LevelWarn, "Ignoring frame signing key: %v", err)
+	}
+	if key != nil {
+		t.SetSigningKey(key)
+	}
 	return s
 }
 
@@ -41,6 +60,13 @@ func NewSseServer(listenAddr string, opts ...Option) *Server {
 	return NewServer(t, opts...)
 }
 
+// NewTCPServer creates an MCP server listening on `listenAddr` (e.g. ":9000")
+// for plain TCP connections carrying length-prefixed JSON frames, one client
+// at a time. It suits servers deployed as sidecars on a container network.
+func NewTCPServer(listenAddr string, opts ...Option) *Server {
+	return NewServer(tcp.NewTCPServer(listenAddr), opts...)
+}
+
 // If you need the actual bound address after Start():
 func (s *Server) BoundAddr() string {
 	return s.base.BoundAddr()
@@ -49,25 +75,102 @@ func (s *Server) BoundAddr() string {
 // Server represents a Model Context Protocol server
 type Server struct {
 	base *base.Base
+	// started is set by the first successful start, guarded by startMu
+	startMu sync.Mutex
+	started bool
 
 	// Feature-specific servers
-	roots     *roots.Server
 	resources *resources.Server
 	prompts   *prompts.Server
 	tools     *tools.Server
-	sampling  *sampling.Server
+
+	// Features offered by each client at initialization, keyed by session
+	// ID, see sessions.go
+	sessionsMu     sync.RWMutex
+	sessions       map[string]*clientFeatures
+	rootsCallbacks []func()
+
+	// Quota use of each session, see quota.go
+	quotas quotas
+
+	// Allowed URI schemes for resources and subscriptions
+	schemes *urischeme.Registry
+
+	// Applied to roots listed by the client; nil accepts any root
+	rootValidator types.RootValidator
+
+	// Size limit for tool call results, applied after all options
+	toolResultLimit tools.ResultLimit
+	toolOutputs     *toolOutputs
+
+	// Memoizes idempotent tool results, applied after all options
+	toolCache *tools.ResultCache
+
+	// Background jobs started by tools, see jobs.go
+	jobs *jobs
+
+	// Watchers and the changes they report, see watch.go. Watchers added
+	// before Start wait in watchers until watchCtx is set.
+	changes  *Changes
+	watchMu  sync.Mutex
+	watchCtx context.Context
+	watchers []Watcher
+
+	// Entries per list page, applied after all options; <= 0 disables paging
+	pageSize int
+
+	// Signs list cursors, applied after all options; nil leaves them plain
+	cursorSigner *paging.Signer
 
 	// Server capabilities
 	capabilities types.ServerCapabilities
 
+	// Converts text resource contents to UTF-8, applied after all options
+	textNormalization *bool
+
+	// Experimental content push for resource update notifications
+	pushMode    types.ContentPushMode
+	pushMaxSize int
+
+	// Experimental streaming of prompt messages
+	promptStreaming bool
+
+	// Send log messages to clients, see logging.go
+	logging bool
+
+	// Accept wildcard subscriptions and list filters, applied after all options
+	subscriptionPatterns bool
+	resourceFilter       bool
+	toolFilter           bool
+
+	// Warn in the results of deprecated tools, applied after all options
+	toolDeprecationWarnings bool
+
+	// Holds resource subscriptions, applied after all options; nil keeps
+	// them in memory
+	subscriptionStore types.SubscriptionStore
+
 	// Server info
 	info types.Implementation
+
+	// The hooks run once a client completes the handshake, see
+	// OnInitialized
+	initMu        sync.Mutex
+	onInitialized []func(*mcp.Session, types.InitializeRequest)
+
+	// Called once the transport closes, see OnClose
+	closeMu   sync.Mutex
+	onClose   []func(transport.CloseReason, error)
+	closeSeen bool
 }
 
 // Option is a function that configures a Server
 type Option func(*Server)
 
-// WithLogger sets the logger for the server
+// WithLogger sets the logger for the server and its transport. It is
+// forwarded to the transport as the option runs, before the server starts,
+// so the transport logs through l from its first message whatever the order
+// of the options.
 func WithLogger(l logger.Logger) Option {
 	return func(s *Server) {
 		s.base.SetLogger(l)
@@ -82,6 +185,99 @@ func WithResources(initialResources []types.Resource, initialTemplates []types.R
 			ListChanged: true,
 		}
 		s.resources = resources.NewServer(s.base, initialResources, initialTemplates)
+		s.resources.SetSchemeRegistry(s.schemes)
+	}
+}
+
+// WithResourceContentPush makes resource update notifications carry the
+// updated contents (when at most maxSize bytes; maxSize <= 0 means no limit),
+// a hash of them, or with types.PushDiff a line diff against the previous
+// notification, saving clients a read after each notification. It is
+// advertised as the experimental capability types.ExperimentalResourceContentPush
+// and only used with clients that declare the same capability. Requires
+// WithResources.
+func WithResourceContentPush(mode types.ContentPushMode, maxSize int) Option {
+	return func(s *Server) {
+		s.pushMode = mode
+		s.pushMaxSize = maxSize
+		if s.capabilities.Experimental == nil {
+			s.capabilities.Experimental = make(map[string]interface{})
+		}
+		s.capabilities.Experimental[types.ExperimentalResourceContentPush] = map[string]interface{}{
+			"mode": string(mode),
+		}
+	}
+}
+
+// WithTextNormalization converts the text contents of resource reads to
+// UTF-8, decoding the charset their MIME type declares or a byte order mark,
+// so garbled text does not reach models. Text that cannot be decoded is
+// returned as a blob if blobFallback is set, and with invalid bytes replaced
+// otherwise. Requires WithResources.
+func WithTextNormalization(blobFallback bool) Option {
+	return func(s *Server) {
+		s.textNormalization = &blobFallback
+	}
+}
+
+// WithSubscriptionPatterns lets clients subscribe to URI patterns such as
+// "file:///project/**" (see urischeme.Match), receiving update notifications
+// for every matching resource. It is advertised as the experimental
+// capability types.ExperimentalResourceSubscribePatterns. Pattern
+// subscriptions are not passed on to resource providers; call
+// NotifyResourceUpdated for their changes. Requires WithResources.
+func WithSubscriptionPatterns() Option {
+	return func(s *Server) {
+		s.subscriptionPatterns = true
+		if s.capabilities.Experimental == nil {
+			s.capabilities.Experimental = make(map[string]interface{})
+		}
+		s.capabilities.Experimental[types.ExperimentalResourceSubscribePatterns] = map[string]interface{}{}
+	}
+}
+
+// WithSubscriptionStore keeps resource subscriptions in store instead of in
+// memory, see the subscriptions package. Replicas of a server sharing a
+// store, such as SSE servers behind a load balancer, share subscriptions, so
+// clients that reconnect to another replica stay subscribed. Provider
+// watches are only started by the replica a client subscribed through.
+// Requires WithResources.
+func WithSubscriptionStore(store types.SubscriptionStore) Option {
+	return func(s *Server) {
+		s.subscriptionStore = store
+	}
+}
+
+// WithResourceFiltering makes resources/list honor the filter of requests
+// (by MIME type, URI prefix, and name), sparing clients of large servers from
+// pulling every resource. It is advertised as the experimental capability
+// types.ExperimentalResourceFilter. Requires WithResources.
+func WithResourceFiltering() Option {
+	return func(s *Server) {
+		s.resourceFilter = true
+		if s.capabilities.Experimental == nil {
+			s.capabilities.Experimental = make(map[string]interface{})
+		}
+		s.capabilities.Experimental[types.ExperimentalResourceFilter] = map[string]interface{}{}
+	}
+}
+
+// WithURISchemes allows additional URI schemes for resources and subscriptions.
+// The file, http, https, and git schemes are allowed by default.
+func WithURISchemes(schemes ...urischeme.Scheme) Option {
+	return func(s *Server) {
+		for _, scheme := range schemes {
+			s.schemes.Register(scheme)
+		}
+	}
+}
+
+// WithRootValidator makes ListRoots fail if the client lists a root the
+// validator rejects, e.g. types.DefaultRootValidator to insist on file://
+// roots. Roots are not validated by default.
+func WithRootValidator(validate types.RootValidator) Option {
+	return func(s *Server) {
+		s.rootValidator = validate
 	}
 }
 
@@ -95,6 +291,21 @@ func WithPrompts(initialPrompts []types.Prompt) Option {
 	}
 }
 
+// WithPromptStreaming sends the messages of prompts registered with
+// RegisterPromptStreamer as they are generated, instead of once the prompt is
+// complete. It is advertised as the experimental capability
+// types.ExperimentalPromptStreaming and only used with clients that declare
+// the same capability and send a progress token. Requires WithPrompts.
+func WithPromptStreaming() Option {
+	return func(s *Server) {
+		s.promptStreaming = true
+		if s.capabilities.Experimental == nil {
+			s.capabilities.Experimental = make(map[string]interface{})
+		}
+		s.capabilities.Experimental[types.ExperimentalPromptStreaming] = map[string]interface{}{}
+	}
+}
+
 // WithTools enables tools functionality on the server
 func WithTools(initialTools ...types.McpTool) Option {
 	return func(s *Server) {
@@ -105,64 +316,425 @@ func WithTools(initialTools ...types.McpTool) Option {
 	}
 }
 
+// WithToolResultLimit caps the content of each tool call result at maxBytes.
+// Oversized results are handled according to truncation: types.TruncateResult
+// cuts the content and appends a marker, types.RejectResult replaces the
+// result with an error, and types.SpillResult also keeps the full text as a
+// temporary tool-output:// resource named in the marker. Spilling requires
+// WithResources and falls back to truncation without it. Requires WithTools.
+func WithToolResultLimit(maxBytes int, truncation types.ResultTruncation) Option {
+	return func(s *Server) {
+		s.toolResultLimit = tools.ResultLimit{MaxBytes: maxBytes, Truncation: truncation}
+	}
+}
+
+// WithToolResultCache memoizes the results of tools annotated as idempotent,
+// keyed by tool name and arguments, for ttl. At most maxEntries results of
+// at most maxBytes of content in total are kept, evicting the least recently
+// used; limits <= 0 are unbounded. Error results are not cached, and a
+// request whose _meta sets types.NoCacheMetaKey to true runs the tool anew.
+// Setting the tools clears the cache. Requires WithTools.
+func WithToolResultCache(ttl time.Duration, maxEntries, maxBytes int) Option {
+	return func(s *Server) {
+		s.toolCache = tools.NewResultCache(ttl, maxEntries, maxBytes)
+	}
+}
+
+// WithToolDeprecationWarnings adds a text block to the start of the results
+// of tools marked deprecated (see types.TypedTool.WithDeprecation), saying so
+// and naming the replacement, so models move off them. Calls of deprecated
+// tools are logged either way. Requires WithTools.
+func WithToolDeprecationWarnings() Option {
+	return func(s *Server) {
+		s.toolDeprecationWarnings = true
+	}
+}
+
+// WithPageSize splits tools/list, prompts/list, resources/list, and
+// resources/templates/list results into pages of n entries. Cursors name the
+// last entry of a page, so paging stays consistent while the lists change.
+func WithPageSize(n int) Option {
+	return func(s *Server) {
+		s.pageSize = n
+	}
+}
+
+// WithSignedCursors makes list cursors tamper-evident and expiring: they are
+// signed with an HMAC of key and rejected as invalid params ttl after they
+// were issued (never if ttl <= 0), so clients list again from the start.
+// Replicas that serve the same lists should share key; a nil key is replaced
+// by a random one, so cursors don't outlive the server. Used with
+// WithPageSize.
+func WithSignedCursors(key []byte, ttl time.Duration) Option {
+	return func(s *Server) {
+		if key == nil {
+			key = make([]byte, 32)
+			_, _ = rand.Read(key)
+		}
+		s.cursorSigner = paging.NewSigner(key, ttl)
+	}
+}
+
+// WithToolFiltering makes tools/list honor the filter of requests, letting
+// hosts list only the tools whose annotations match, e.g. read-only ones. It
+// is advertised as the experimental capability types.ExperimentalToolFilter.
+// Requires WithTools.
+func WithToolFiltering() Option {
+	return func(s *Server) {
+		s.toolFilter = true
+		if s.capabilities.Experimental == nil {
+			s.capabilities.Experimental = make(map[string]interface{})
+		}
+		s.capabilities.Experimental[types.ExperimentalToolFilter] = map[string]interface{}{}
+	}
+}
+
+// WithKeepAlive sets how long an SSE event stream may sit idle before the
+// server sends a keep-alive comment, so proxies don't close the connection
+// and a dead client is noticed when the write fails. The default is
+// 15 seconds; d <= 0 disables keep-alives. It has no effect on
+// stdio servers.
+func WithKeepAlive(d time.Duration) Option {
+	return func(s *Server) {
+		s.base.SetKeepAlive(d)
+	}
+}
+
+// WithNotificationBatching coalesces the notifications a server sends
+// within window of each other, e.g. 10ms, into one JSON-RPC batch, cutting
+// the frames and syscalls of chatty servers. A notification waits at most
+// window, and never behind a later response. It has no effect on stdio
+// servers, whose framing does not carry batches.
+func WithNotificationBatching(window time.Duration) Option {
+	return func(s *Server) {
+		s.base.SetNotificationBatching(window)
+	}
+}
+
+// WithWriteTimeout fails the write of any message that blocks for longer
+// than timeout, as to a client that stops reading, with a
+// *transport.WriteTimeoutError, on top of the deadline of the context it is
+// sent with. On SSE servers it also disconnects a client whose event stream
+// blocks that long; it defaults to 10 seconds there. It has no effect on TCP
+// servers.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.base.SetWriteTimeout(timeout)
+	}
+}
+
+// WithHTTPTimeouts sets the read, header, write and idle timeouts of an SSE
+// server's http.Server, and the age after which its event stream is ended
+// so the client reconnects. See transport.HTTPTimeouts; fields for clients
+// are ignored. It has no effect on stdio servers.
+func WithHTTPTimeouts(timeouts transport.HTTPTimeouts) Option {
+	return func(s *Server) {
+		s.base.SetHTTPTimeouts(timeouts)
+	}
+}
+
+// WithCompression compresses the event stream of SSE clients that accept
+// gzip or deflate, and tells them the server accepts compressed messages.
+// Compressed messages are decoded whether or not it is set. It has no effect
+// on stdio servers.
+func WithCompression() Option {
+	return func(s *Server) {
+		s.base.SetCompression(true)
+	}
+}
+
+// WithSlowClientHook runs hook when an SSE client falls behind by highWater
+// queued messages, so a slow consumer gets its notifications coalesced or
+// its session ended, with the reason logged, instead of holding up the
+// server's sends. Sends wait once twice highWater messages are queued. It
+// has no effect on stdio servers.
+func WithSlowClientHook(highWater int, hook transport.SlowClientHook) Option {
+	return func(s *Server) {
+		s.base.SetSlowClientHook(highWater, hook)
+	}
+}
+
+// WithHTTPMiddleware wraps the HTTP handlers of an SSE server with standard
+// net/http middleware, e.g. for authentication, logging, or recovery.
+// Middleware from earlier options runs first. Middleware that wraps the
+// http.ResponseWriter should provide Unwrap so the event stream can still
+// flush. It has no effect on stdio servers.
+func WithHTTPMiddleware(mw func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		s.base.UseHTTPMiddleware(mw)
+	}
+}
+
+// WithAllowedOrigins lets browser pages on the given origins, e.g.
+// "https://app.example.com", reach an SSE server. By default a request
+// carrying an Origin header is refused with 403 unless the origin is on a
+// loopback host, which guards local servers against DNS rebinding; requests
+// without one, as sent by non-browser clients, are always accepted. It has no
+// effect on stdio servers.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(s *Server) {
+		s.base.SetAllowedOrigins(origins...)
+	}
+}
+
+// WithoutOriginCheck turns off the Origin check of an SSE server, for trusted
+// deployments where a proxy or WithHTTPMiddleware already vets requests.
+func WithoutOriginCheck() Option {
+	return func(s *Server) {
+		s.base.DisableOriginCheck()
+	}
+}
+
+// WithBearerAuth requires every request to an SSE server to carry token as
+// "Authorization: Bearer <token>", refusing others with 401. It suits
+// internal deployments where clients share a secret; pair it with the
+// client's WithBearerAuth. It has no effect on stdio servers.
+func WithBearerAuth(token string) Option {
+	return func(s *Server) {
+		s.base.SetBearerToken(token)
+	}
+}
+
+// WithAuthenticator makes an SSE server authenticate every request with a,
+// e.g. auth.StaticKeys. The principal is kept on the session, which handlers
+// get with mcp.SessionFromContext for per-user authorization and audit.
+// Requests a refuses get 401, and messages from a principal other than the
+// one that opened the event stream get 403. It has no effect on stdio
+// servers.
+func WithAuthenticator(a auth.Authenticator) Option {
+	return func(s *Server) {
+		s.base.SetAuthenticator(a)
+	}
+}
+
 // NewServer creates a new MCP server
 func NewServer(transport transport.Transport, opts ...Option) *Server {
 	s := &Server{
-		base: base.NewBase(transport),
+		base:     base.NewBase(transport),
+		schemes:  urischeme.Default.Clone(),
+		sessions: make(map[string]*clientFeatures),
+		quotas:   quotas{sessions: make(map[string]*quotaState)},
 		info: types.Implementation{
 			Name:    "mcp-go",
 			Version: "0.1.0",
 		},
 	}
 
+	s.changes = newChanges(s)
+
 	// Apply options
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	if s.resources != nil {
+		s.resources.SetSubscriptionPatterns(s.subscriptionPatterns)
+		if s.subscriptionStore != nil {
+			s.resources.SetSubscriptionStore(s.subscriptionStore)
+		}
+		if s.textNormalization != nil {
+			s.resources.SetTextNormalization(*s.textNormalization)
+		}
+		s.resources.SetFiltering(s.resourceFilter)
+		if s.pushMode != "" {
+			s.resources.SetContentPush(s.pushMode, s.pushMaxSize, func(ctx context.Context) bool {
+				return s.features(ctx).contentPush
+			})
+		}
+	}
+
+	if s.prompts != nil && s.promptStreaming {
+		s.prompts.SetStreaming(func(ctx context.Context) bool {
+			return s.features(ctx).promptStreaming
+		})
+	}
+
+	if s.tools != nil {
+		s.tools.SetFiltering(s.toolFilter)
+		s.tools.SetDeprecationWarnings(s.toolDeprecationWarnings)
+	}
+
+	if s.pageSize > 0 {
+		if s.tools != nil {
+			s.tools.SetPageSize(s.pageSize)
+		}
+		if s.prompts != nil {
+			s.prompts.SetPageSize(s.pageSize)
+		}
+		if s.resources != nil {
+			s.resources.SetPageSize(s.pageSize)
+		}
+	}
+
+	if s.cursorSigner != nil {
+		if s.tools != nil {
+			s.tools.SetCursorSigner(s.cursorSigner)
+		}
+		if s.prompts != nil {
+			s.prompts.SetCursorSigner(s.cursorSigner)
+		}
+		if s.resources != nil {
+			s.resources.SetCursorSigner(s.cursorSigner)
+		}
+	}
+
+	if s.tools != nil && s.toolCache != nil {
+		s.tools.SetCache(s.toolCache)
+	}
+
+	if s.tools != nil && s.jobs != nil {
+		s.jobs.install(s.tools, s.resources)
+		s.base.OnShutdown(s.jobs.cancelAll)
+	}
+
+	if s.tools != nil && s.toolResultLimit.MaxBytes > 0 {
+		limit := s.toolResultLimit
+		if limit.Truncation == types.SpillResult && s.resources != nil {
+			s.toolOutputs = newToolOutputs(s.resources)
+			limit.Spill = s.toolOutputs.store
+		}
+		s.tools.SetResultLimit(limit)
+	}
+
 	// Register initialization handler
-	s.base.RegisterRequestHandler(methods.Initialize, s.handleInitialize)
-	s.base.RegisterNotificationHandler(methods.Initialized, s.handleInitialized)
+	base.HandleRequest(s.base, methods.Initialize, s.handleInitialize)
+	base.HandleNotification(s.base, methods.Initialized, s.handleInitialized)
+	base.HandleNotification(s.base, methods.RootsChanged, s.handleRootsChanged)
+	if s.logging {
+		base.HandleRequest(s.base, methods.SetLogLevel, s.handleSetLogLevel)
+	}
+	s.base.OnSessionEnd(s.endSession)
 
 	return s
 }
 
-// Start begins processing messages but also makes sure that the server's ctx
-// is canceled if the transport closes, so you can shut down everything automatically.
+// Start begins processing messages in the background, see Run. Once the
+// server shuts down, however that started, the watchers stop and the OnClose
+// callbacks run. Starting a started server does nothing; a server that has
+// shut down cannot be reused and returns transport.ErrClosed, and a ctx
+// already done starts nothing and is returned.
 func (s *Server) Start(ctx context.Context) error {
-	// Create a child context we can cancel if the transport closes:
-	serverCtx, cancelFunc := context.WithCancel(ctx)
+	started, err := s.start(ctx)
+	if err != nil {
+		return err
+	}
+	if started {
+		goroutine.Go("server/wait", func() { s.wait() })
+	}
+	return nil
+}
 
-	// Start the underlying base (which spins up its own goroutine)
-	if err := s.base.Start(serverCtx); err != nil {
-		cancelFunc()
-		return fmt.Errorf("failed to start base transport: %w", err)
+// Run starts the server and blocks until it shuts down, returning why: the
+// error given to CloseWithError, nil for Close, ctx.Err() if ctx is done
+// first, or the connection's Err if the client closed it, such as
+// transport.ErrPeerClosed.
+func (s *Server) Run(ctx context.Context) error {
+	if _, err := s.start(ctx); err != nil {
+		return err
 	}
+	return s.wait()
+}
 
-	// Watch for transport closure. When that happens, we cancel serverCtx.
-	go func() {
-		<-s.base.GetRouter().Done() // transport closed
-		s.Close()
-		cancelFunc()
-	}()
+// start starts the base and the watchers, which stop at shutdown, reporting
+// whether this call started them
+func (s *Server) start(ctx context.Context) (bool, error) {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.started {
+		// Nothing to do while running; ErrClosed once shut down
+		return false, s.base.Start(ctx)
+	}
+	if err := s.base.Start(ctx); err != nil {
+		return false, fmt.Errorf("failed to start base transport: %w", err)
+	}
+	// Run at once if the base already shut down, so the watchers stop
+	watchCtx, cancel := context.WithCancel(ctx)
+	s.base.OnShutdown(cancel)
+	s.startWatchers(watchCtx)
+	s.started = true
+	return true, nil
+}
 
-	// We return immediately; background goroutines handle the requests.
-	return nil
+// wait waits for the server to shut down, then runs the OnClose callbacks
+func (s *Server) wait() error {
+	err := s.base.Wait()
+	s.closed(s.base.DoneReason(), s.base.Err())
+	return err
 }
 
-// Close shuts down the server
+// Close shuts down the server, see CloseWithError
 func (s *Server) Close() error {
 	return s.base.Close()
 }
 
-// Done returns a channel that is closed when the transport is closed
+// CloseWithError shuts down the server, recording err as the reason the
+// connection closed. A nil err is a clean close. Requests arriving are
+// refused, running handlers and jobs are canceled, held notifications are
+// sent, and the transport is closed before Done is closed; the OnClose
+// callbacks run after.
+func (s *Server) CloseWithError(err error) error {
+	return s.base.CloseWithError(err)
+}
+
+// Done returns a channel that is closed once the server has shut down,
+// after its transport closed
 func (s *Server) Done() <-chan struct{} {
 	return s.base.Done()
 }
 
-// SupportsRoots returns whether the client supports roots functionality
+// DoneReason says which side closed the connection, or NotClosed
+func (s *Server) DoneReason() transport.CloseReason {
+	return s.base.DoneReason()
+}
+
+// Err returns why the connection closed, or nil while it is open. A client
+// that disconnected cleanly is reported as transport.ErrPeerClosed.
+func (s *Server) Err() error {
+	return s.base.Err()
+}
+
+// OnClose registers a callback invoked once the transport of a started
+// server closes, with which side closed it and why. Callbacks registered
+// after that are not invoked.
+func (s *Server) OnClose(callback func(reason transport.CloseReason, err error)) {
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	if !s.closeSeen {
+		s.onClose = append(s.onClose, callback)
+	}
+}
+
+// OnError registers a callback for errors that have no caller to return them
+// to: messages from the client that could not be decoded
+// (*transport.DecodeError), handlers that panicked
+// (*transport.HandlerPanicError), and responses that could not be sent
+// (*transport.SendError).
+func (s *Server) OnError(callback func(error)) {
+	s.base.OnError(callback)
+}
+
+// Ping checks that the connected client is responsive. It returns nil once
+// the client answers the ping request.
+func (s *Server) Ping(ctx context.Context) error {
+	return s.base.Ping(ctx)
+}
+
+// closed invokes the OnClose callbacks
+func (s *Server) closed(reason transport.CloseReason, err error) {
+	s.closeMu.Lock()
+	callbacks := s.onClose
+	s.onClose, s.closeSeen = nil, true
+	s.closeMu.Unlock()
+
+	for _, callback := range callbacks {
+		callback(reason, err)
+	}
+}
+
+// SupportsRoots returns whether the connected client supports roots
+// functionality
 func (s *Server) SupportsRoots() bool {
-	return s.roots != nil
+	return s.features(context.Background()).roots != nil
 }
 
 // SupportsResources returns whether the server supports resources functionality
@@ -180,39 +752,20 @@ func (s *Server) SupportsTools() bool {
 	return s.tools != nil
 }
 
-// SupportsSampling returns whether the client supports sampling functionality
+// SupportsSampling returns whether the connected client supports sampling
+// functionality
 func (s *Server) SupportsSampling() bool {
-	return s.sampling != nil
+	return s.features(context.Background()).sampling != nil
 }
 
 // handleInitialize handles the initialize request from clients
-func (s *Server) handleInitialize(ctx context.Context, params *json.RawMessage) (interface{}, error) {
-	if params == nil {
-		return nil, types.NewError(types.InvalidParams, "missing params")
-	}
-
-	var req types.InitializeRequest
-	if err := json.Unmarshal(*params, &req); err != nil {
-		return nil, fmt.Errorf("failed to parse initialize request: %w", err)
-	}
-
+func (s *Server) handleInitialize(ctx context.Context, req types.InitializeRequest) (*types.InitializeResult, error) {
 	// Verify protocol version compatibility
 	if req.ProtocolVersion != types.LatestProtocolVersion {
 		return nil, fmt.Errorf("client protocol version %s not supported", req.ProtocolVersion)
 	}
 
-	// Initialize roots and sampling server if client supports it
-	if req.Capabilities.Roots != nil {
-		s.roots = roots.NewServer(s.base)
-		s.OnRootsChanged(func() {
-			// default noop
-			s.base.Logf("from client: %s", methods.RootsChanged)
-		})
-	}
-
-	if req.Capabilities.Sampling != nil {
-		s.sampling = sampling.NewServer(s.base)
-	}
+	s.setFeatures(ctx, s.newFeatures(req))
 
 	return &types.InitializeResult{
 		ProtocolVersion: types.LatestProtocolVersion,
@@ -221,9 +774,34 @@ func (s *Server) handleInitialize(ctx context.Context, params *json.RawMessage)
 	}, nil
 }
 
-// handleInitialized handles the initialized notification from clients
-func (s *Server) handleInitialized(ctx context.Context, params json.RawMessage) {
-	// Nothing to do here, but we need to handle the notification
+// handleInitialized handles the initialized notification from clients,
+// which completes the handshake
+func (s *Server) handleInitialized(ctx context.Context, _ types.InitializedNotification) {
+	req := s.features(ctx).init
+	s.initMu.Lock()
+	hooks := s.onInitialized
+	s.initMu.Unlock()
+
+	if req == nil {
+		s.base.Log(ctx, logger.LevelWarn, "Ignoring %s before %s", methods.Initialized, methods.Initialize)
+		return
+	}
+	session := mcp.SessionFromContext(ctx)
+	for _, hook := range hooks {
+		hook(session, *req)
+	}
+}
+
+// OnInitialized registers a hook run each time a client completes the
+// handshake, with the session it connected on (nil for transports without
+// sessions, as with stdio) and its initialize request. Features that depend
+// on client capabilities, such as ListRoots, are ready when it runs. Hooks
+// run in order and may make requests to the client; later notifications from
+// the client wait for them.
+func (s *Server) OnInitialized(hook func(session *mcp.Session, req types.InitializeRequest)) {
+	s.initMu.Lock()
+	defer s.initMu.Unlock()
+	s.onInitialized = append(s.onInitialized, hook)
 }
 
 // Resource Methods
@@ -252,6 +830,17 @@ func (s *Server) RegisterContentHandler(uriPrefix string, handler resources.Cont
 	}
 }
 
+// AddResourceProvider mounts a resource provider at the given URI prefix.
+// The provider's resources and templates are included in list results, and reads
+// and subscriptions for URIs under the prefix are routed to it.
+// Returns an error if resources are not supported.
+func (s *Server) AddResourceProvider(ctx context.Context, uriPrefix string, provider types.ResourceProvider) error {
+	if !s.SupportsResources() {
+		return types.NewError(types.MethodNotFound, "resources not supported")
+	}
+	return s.resources.AddProvider(ctx, uriPrefix, provider)
+}
+
 // NotifyResourceUpdated notifies subscribed clients that a resource has changed.
 // Returns an error if resources are not supported or if notification fails.
 func (s *Server) NotifyResourceUpdated(ctx context.Context, uri string) error {
@@ -280,6 +869,51 @@ func (s *Server) RegisterPromptGetter(name string, getter prompts.PromptGetter)
 	}
 }
 
+// PromptVersion is one version of a prompt, see RegisterPromptVersions
+type PromptVersion struct {
+	// Prompt has the prompt's name and version set
+	Prompt types.Prompt
+	Getter prompts.PromptGetter
+}
+
+// RegisterPromptVersions lists and registers the versions of a prompt,
+// oldest first, and notifies connected clients. The newest is served under
+// the prompt's name, and every version also as "name@version" (see
+// types.VersionedPromptName), so clients can pin one. Older versions not
+// marked deprecated are marked as superseded by the newest. Returns an error
+// if prompts are not supported or the versions do not share a name.
+func (s *Server) RegisterPromptVersions(ctx context.Context, versions ...PromptVersion) error {
+	if !s.SupportsPrompts() {
+		return types.NewError(types.MethodNotFound, "prompts not supported")
+	}
+	listed := make([]types.Prompt, len(versions))
+	getters := make([]prompts.PromptGetter, len(versions))
+	for i, v := range versions {
+		listed[i], getters[i] = v.Prompt, v.Getter
+	}
+	return s.prompts.RegisterVersions(ctx, listed, getters)
+}
+
+// RegisterPromptStreamer registers a handler that generates prompt contents
+// incrementally, sending messages on the channel it returns. With
+// WithPromptStreaming, clients that opted in receive the messages as they are
+// generated; others get them once the prompt is complete.
+func (s *Server) RegisterPromptStreamer(name string, streamer prompts.PromptStreamer) {
+	if s.SupportsPrompts() {
+		s.prompts.RegisterPromptStreamer(name, streamer)
+	}
+}
+
+// RegisterPromptGetterWithCompletions registers a handler for retrieving
+// prompt contents, like RegisterPromptGetter, along with completion functions
+// suggesting values for the prompt's arguments, keyed by argument name.
+// Clients request suggestions with completion/complete.
+func (s *Server) RegisterPromptGetterWithCompletions(name string, getter prompts.PromptGetter, completions map[string]types.CompletionFunc) {
+	if s.SupportsPrompts() {
+		s.prompts.RegisterPromptGetterWithCompletions(name, getter, completions)
+	}
+}
+
 // Tool Methods
 
 // SetTools updates the list of available tools and notifies connected clients.
@@ -293,30 +927,34 @@ func (s *Server) SetTools(ctx context.Context, newTools []types.McpTool) error {
 
 // Root Methods
 
-// ListRoots requests the list of available roots from the connected client.
-// Returns an error if roots are not supported by the client.
+// ListRoots requests the list of available roots from the client of the
+// session in ctx, or the connected client when ctx has none. Returns an
+// error if roots are not supported by the client.
 func (s *Server) ListRoots(ctx context.Context) ([]types.Root, error) {
-	if !s.SupportsRoots() {
+	roots := s.features(ctx).roots
+	if roots == nil {
 		return nil, types.NewError(types.MethodNotFound, "roots not supported")
 	}
-	return s.roots.ListRoots(ctx)
+	return roots.ListRoots(ctx)
 }
 
-// OnRootsChanged registers a callback for when the client's root list changes.
-// The callback is not invoked if roots are not supported.
+// OnRootsChanged registers a callback for when a client's root list changes.
+// The callback is not invoked for clients that do not support roots.
 func (s *Server) OnRootsChanged(callback func()) {
-	if s.SupportsRoots() {
-		s.roots.OnRootsChanged(callback)
-	}
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.rootsCallbacks = append(s.rootsCallbacks, callback)
 }
 
 // Sampling Methods
 
-// CreateMessage requests a sample from the language model.
+// CreateMessage requests a sample from the language model of the client of
+// the session in ctx, or the connected client when ctx has none.
 // Returns an error if sampling is not supported.
 func (s *Server) CreateMessage(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
-	if !s.SupportsSampling() {
+	sampling := s.features(ctx).sampling
+	if sampling == nil {
 		return nil, types.NewError(types.MethodNotFound, "sampling not supported")
 	}
-	return s.sampling.CreateMessage(ctx, req)
+	return sampling.CreateMessage(ctx, req)
 }
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+
+	"github.com/dwrtz/mcp-go/internal/server/roots"
+	"github.com/dwrtz/mcp-go/internal/server/sampling"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// clientFeatures are the features that need a client's support, as offered
+// by the client of one session when it initialized. Nil fields are not
+// supported.
+type clientFeatures struct {
+	// init is the session's initialize request, nil until it is received
+	init *types.InitializeRequest
+
+	roots    *roots.Server
+	sampling *sampling.Server
+
+	// contentPush is set when the client accepts contents in resource
+	// update notifications, see WithResourceContentPush
+	contentPush bool
+	// promptStreaming is set when the client accepts prompt messages as
+	// they are generated, see WithPromptStreaming
+	promptStreaming bool
+
+	// logLevel is the least severe level of log messages sent to the
+	// client, set by logging/setLevel; see logging.go
+	logLevel types.LoggingLevel
+}
+
+// newFeatures returns the features of a session whose client initialized
+// with req
+func (s *Server) newFeatures(req types.InitializeRequest) *clientFeatures {
+	f := &clientFeatures{init: &req}
+	if req.Capabilities.Roots != nil {
+		f.roots = roots.NewServer(s.base)
+		f.roots.SetValidator(s.rootValidator)
+	}
+	if req.Capabilities.Sampling != nil {
+		f.sampling = sampling.NewServer(s.base)
+	}
+	// Contents are pushed, and prompts streamed, only to clients that opted
+	// in
+	_, f.contentPush = req.Capabilities.Experimental[types.ExperimentalResourceContentPush]
+	_, f.promptStreaming = req.Capabilities.Experimental[types.ExperimentalPromptStreaming]
+	return f
+}
+
+// sessionID returns the ID of the session in ctx, or of the transport's
+// current session when ctx has none. It is "" for transports without
+// sessions, whose single client is kept under that ID.
+func (s *Server) sessionID(ctx context.Context) string {
+	session := mcp.SessionFromContext(ctx)
+	if session == nil {
+		session = s.base.Session()
+	}
+	if session == nil {
+		return ""
+	}
+	return session.ID
+}
+
+// features returns the client features of the session in ctx, never nil
+func (s *Server) features(ctx context.Context) *clientFeatures {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	if f, ok := s.sessions[s.sessionID(ctx)]; ok {
+		return f
+	}
+	return &clientFeatures{}
+}
+
+// setFeatures records the client features of the session in ctx, replacing
+// those from any earlier initialization
+func (s *Server) setFeatures(ctx context.Context, f *clientFeatures) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.sessions[s.sessionID(ctx)] = f
+}
+
+// endSession forgets the client features and quota use of a session that
+// ended
+func (s *Server) endSession(session *mcp.Session) {
+	s.quotas.end(session.ID)
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	delete(s.sessions, session.ID)
+}
+
+// handleRootsChanged runs the OnRootsChanged callbacks when a client that
+// supports roots reports a change
+func (s *Server) handleRootsChanged(ctx context.Context, _ types.RootsListChangedNotification) {
+	s.base.Log(ctx, logger.LevelDebug, "from client: %s", methods.RootsChanged)
+	if s.features(ctx).roots == nil {
+		return
+	}
+	s.sessionsMu.RLock()
+	callbacks := s.rootsCallbacks
+	s.sessionsMu.RUnlock()
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// Stats returns the traffic of every session the server has had. The
+// traffic of the session a request arrived on is in the Stats of its
+// mcp.Session.
+func (s *Server) Stats() mcp.SessionStats {
+	return s.base.Stats()
+}
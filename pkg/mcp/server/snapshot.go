@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// snapshotVersion is the version of the Snapshot format
+const snapshotVersion = 1
+
+// Snapshot is the state of a server that can be carried over to a restarted
+// server or a new replica, see Server.Snapshot. It encodes as JSON.
+// Resources and templates of providers are not included, as the providers
+// list them again; handlers and getters are not included, as they are code.
+type Snapshot struct {
+	Version           int                      `json:"version"`
+	Resources         []types.Resource         `json:"resources,omitempty"`
+	ResourceTemplates []types.ResourceTemplate `json:"resourceTemplates,omitempty"`
+	Prompts           []types.Prompt           `json:"prompts,omitempty"`
+	// Tools are the definitions of the registered tools
+	Tools []types.Tool `json:"tools,omitempty"`
+	// Subscriptions are the subscribed resource URIs and patterns
+	Subscriptions []string `json:"subscriptions,omitempty"`
+}
+
+// Snapshot returns the server's current catalogs and resource subscriptions
+func (s *Server) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap := &Snapshot{Version: snapshotVersion}
+	if s.SupportsResources() {
+		snap.Resources, snap.ResourceTemplates = s.resources.Static()
+		subs, err := s.resources.Subscriptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		snap.Subscriptions = subs
+	}
+	if s.SupportsPrompts() {
+		snap.Prompts = s.prompts.Prompts()
+	}
+	if s.SupportsTools() {
+		snap.Tools = s.tools.Tools()
+	}
+	return snap, nil
+}
+
+// Restore sets the catalogs of snap and subscribes to its subscriptions, so
+// clients that reconnect keep receiving the updates they subscribed to.
+// Tools cannot be restored, as their handlers are code: the definitions in
+// snap replace those of the tools already set with the same name. Parts of
+// snap for features the server does not support are ignored.
+func (s *Server) Restore(ctx context.Context, snap *Snapshot) error {
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snap.Version)
+	}
+	if s.SupportsResources() {
+		s.resources.SetTemplates(ctx, snap.ResourceTemplates)
+		if err := s.resources.SetResources(ctx, snap.Resources); err != nil {
+			return err
+		}
+		if err := s.resources.Resubscribe(ctx, snap.Subscriptions); err != nil {
+			return err
+		}
+	}
+	if s.SupportsPrompts() {
+		if err := s.prompts.SetPrompts(ctx, snap.Prompts); err != nil {
+			return err
+		}
+	}
+	if s.SupportsTools() {
+		if err := s.tools.SetDefinitions(ctx, snap.Tools); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/server/resources"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// toolOutputPrefix is the URI prefix of spilled tool results
+const toolOutputPrefix = "tool-output://"
+
+// toolOutputTTL is how long a spilled tool result can be read
+var toolOutputTTL = 10 * time.Minute
+
+// toolOutputs keeps the full text of truncated tool results readable as
+// resources for a while
+type toolOutputs struct {
+	mu    sync.Mutex
+	next  int
+	texts map[string]string
+}
+
+// newToolOutputs creates the store and serves its entries from res
+func newToolOutputs(res *resources.Server) *toolOutputs {
+	o := &toolOutputs{texts: make(map[string]string)}
+	res.RegisterContentHandler(toolOutputPrefix, o.read)
+	return o
+}
+
+// store keeps text and returns the URI it can be read from until it expires
+func (o *toolOutputs) store(_ context.Context, tool string, text string) (string, error) {
+	o.mu.Lock()
+	o.next++
+	uri := fmt.Sprintf("%sresults/%s/%d", toolOutputPrefix, url.PathEscape(tool), o.next)
+	o.texts[uri] = text
+	o.mu.Unlock()
+
+	time.AfterFunc(toolOutputTTL, func() {
+		o.mu.Lock()
+		delete(o.texts, uri)
+		o.mu.Unlock()
+	})
+	return uri, nil
+}
+
+func (o *toolOutputs) read(_ context.Context, uri string) ([]types.ResourceContent, error) {
+	o.mu.Lock()
+	text, ok := o.texts[uri]
+	o.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("tool output not found or expired: %s", uri)
+	}
+	return []types.ResourceContent{types.TextResourceContents{
+		ResourceContents: types.ResourceContents{URI: uri, MimeType: "text/plain"},
+		Text:             text,
+	}}, nil
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+)
+
+// defaultChangeWindow is how long changes are collected before they are
+// published, see WithChangeWindow
+const defaultChangeWindow = 50 * time.Millisecond
+
+// Watcher watches something the server publishes, reporting its changes
+// until ctx is done, which happens when the server closes
+type Watcher func(ctx context.Context, changes *Changes)
+
+// Changes collects the changes watchers report and publishes them as
+// notifications. Changes reported within the change window of each other are
+// coalesced, so each resource or list is notified at most once per window.
+// It is safe for concurrent use.
+type Changes struct {
+	s      *Server
+	window time.Duration
+	kick   chan struct{}
+
+	mu sync.Mutex
+	// uris are the updated resources in the order first reported
+	uris    []string
+	pending map[string]bool
+	// lists are the list_changed notifications to send
+	lists map[string]bool
+}
+
+// WithChangeWindow sets how long the changes reported by watchers are
+// collected before they are published. The default is 50ms.
+func WithChangeWindow(d time.Duration) Option {
+	return func(s *Server) {
+		s.changes.window = d
+	}
+}
+
+// Watch runs watcher in its own goroutine once the server starts, and
+// publishes the changes it reports. The watcher's context is cancelled when
+// the server closes; changes still pending then are dropped.
+func (s *Server) Watch(watcher Watcher) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watchCtx == nil {
+		s.watchers = append(s.watchers, watcher)
+		return
+	}
+	ctx := s.watchCtx
+	goroutine.Go("server/watcher", func() { watcher(ctx, s.changes) })
+}
+
+// startWatchers runs the watchers added so far and publishes their changes
+// until ctx is done
+func (s *Server) startWatchers(ctx context.Context) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	s.watchCtx = ctx
+	goroutine.Go("server/changes", func() { s.changes.run(ctx) })
+	for _, watcher := range s.watchers {
+		goroutine.Go("server/watcher", func() { watcher(ctx, s.changes) })
+	}
+	s.watchers = nil
+}
+
+// Every returns a watcher that calls poll every interval
+func Every(interval time.Duration, poll func(ctx context.Context, changes *Changes)) Watcher {
+	return func(ctx context.Context, changes *Changes) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				poll(ctx, changes)
+			}
+		}
+	}
+}
+
+// ResourceUpdates returns a watcher that reports each URI received from
+// uris as updated, until uris is closed
+func ResourceUpdates(uris <-chan string) Watcher {
+	return func(ctx context.Context, changes *Changes) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case uri, ok := <-uris:
+				if !ok {
+					return
+				}
+				changes.ResourceUpdated(uri)
+			}
+		}
+	}
+}
+
+func newChanges(s *Server) *Changes {
+	return &Changes{
+		s:       s,
+		window:  defaultChangeWindow,
+		kick:    make(chan struct{}, 1),
+		pending: make(map[string]bool),
+		lists:   make(map[string]bool),
+	}
+}
+
+// ResourceUpdated reports that the resource at uri changed. It is published
+// to the clients subscribed to it.
+func (c *Changes) ResourceUpdated(uri string) {
+	c.mu.Lock()
+	if !c.pending[uri] {
+		c.pending[uri] = true
+		c.uris = append(c.uris, uri)
+	}
+	c.mu.Unlock()
+	c.wake()
+}
+
+// ResourceListChanged reports that the list of resources changed
+func (c *Changes) ResourceListChanged() {
+	c.listChanged(methods.ResourceListChanged)
+}
+
+// PromptListChanged reports that the list of prompts changed
+func (c *Changes) PromptListChanged() {
+	c.listChanged(methods.PromptsChanged)
+}
+
+// ToolListChanged reports that the list of tools changed
+func (c *Changes) ToolListChanged() {
+	c.listChanged(methods.ToolsChanged)
+}
+
+func (c *Changes) listChanged(method string) {
+	c.mu.Lock()
+	c.lists[method] = true
+	c.mu.Unlock()
+	c.wake()
+}
+
+func (c *Changes) wake() {
+	select {
+	case c.kick <- struct{}{}:
+	default:
+	}
+}
+
+// run publishes the changes reported within each window until ctx is done
+func (c *Changes) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.kick:
+		}
+		timer := time.NewTimer(c.window)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		c.publish(ctx)
+	}
+}
+
+// publish sends the pending changes the server supports
+func (c *Changes) publish(ctx context.Context) {
+	c.mu.Lock()
+	uris, lists := c.uris, c.lists
+	c.uris, c.pending, c.lists = nil, make(map[string]bool), make(map[string]bool)
+	c.mu.Unlock()
+
+	for _, uri := range uris {
+		if err := c.s.NotifyResourceUpdated(ctx, uri); err != nil {
+			c.s.base.Log(ctx, logger.LevelWarn, "Failed to publish update of %s: %v", uri, err)
+		}
+	}
+	// A fixed order keeps the notifications deterministic
+	for _, method := range []string{methods.ResourceListChanged, methods.PromptsChanged, methods.ToolsChanged} {
+		if !lists[method] {
+			continue
+		}
+		if !c.supports(method) {
+			c.s.base.Log(ctx, logger.LevelDebug, "Not publishing %s for an unsupported feature", method)
+			continue
+		}
+		if err := c.s.base.SendNotification(ctx, method, nil); err != nil {
+			c.s.base.Log(ctx, logger.LevelWarn, "Failed to publish %s: %v", method, err)
+		}
+	}
+}
+
+func (c *Changes) supports(method string) bool {
+	switch method {
+	case methods.ResourceListChanged:
+		return c.s.SupportsResources()
+	case methods.PromptsChanged:
+		return c.s.SupportsPrompts()
+	case methods.ToolsChanged:
+		return c.s.SupportsTools()
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/dwrtz/mcp-go/pkg/auth"
+)
+
+type sessionKey struct{}
+
+// Session describes the connection a request arrived on
+type Session struct {
+	// ID is unique to the connection
+	ID string
+	// Principal is who the connection authenticated as, or nil when the
+	// transport does not authenticate
+	Principal *auth.Principal
+
+	// Counters count the session's traffic as the transport carries it;
+	// Stats returns them, e.g. for per-client quotas
+	Counters
+}
+
+// WithSession returns a context carrying the session of the request being
+// handled. It is called before request handlers run.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFromContext returns the session of the request being handled, or
+// nil if the transport has no sessions, as with stdio
+func SessionFromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
@@ -0,0 +1,53 @@
+package mcp
+
+import "sync/atomic"
+
+// SessionStats counts the traffic of a session, or of every session a
+// server has had
+type SessionStats struct {
+	MessagesIn  uint64
+	MessagesOut uint64
+	BytesIn     uint64
+	BytesOut    uint64
+	// Errors counts error responses sent and received, and messages that
+	// could not be decoded or sent
+	Errors uint64
+}
+
+// Counters accumulates SessionStats. It is safe for concurrent use and the
+// zero value is ready to use.
+type Counters struct {
+	messagesIn  atomic.Uint64
+	messagesOut atomic.Uint64
+	bytesIn     atomic.Uint64
+	bytesOut    atomic.Uint64
+	errors      atomic.Uint64
+}
+
+// CountIn counts a received message of n bytes
+func (c *Counters) CountIn(n int) {
+	c.messagesIn.Add(1)
+	c.bytesIn.Add(uint64(n))
+}
+
+// CountOut counts a sent message of n bytes
+func (c *Counters) CountOut(n int) {
+	c.messagesOut.Add(1)
+	c.bytesOut.Add(uint64(n))
+}
+
+// CountError counts an error
+func (c *Counters) CountError() {
+	c.errors.Add(1)
+}
+
+// Stats returns the counts so far
+func (c *Counters) Stats() SessionStats {
+	return SessionStats{
+		MessagesIn:  c.messagesIn.Load(),
+		MessagesOut: c.messagesOut.Load(),
+		BytesIn:     c.bytesIn.Load(),
+		BytesOut:    c.bytesOut.Load(),
+		Errors:      c.errors.Load(),
+	}
+}
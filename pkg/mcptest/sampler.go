@@ -0,0 +1,184 @@
+// Package mcptest provides helpers for testing code built on MCP clients and
+// servers.
+package mcptest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// DefaultModel is the model name scripted replies report unless they set one
+const DefaultModel = "mcptest-model"
+
+// Reply is one scripted response to a sampling request
+type Reply struct {
+	// Text is returned as the assistant's text content
+	Text string
+
+	// Model overrides DefaultModel
+	Model string
+
+	// StopReason defaults to "endTurn"
+	StopReason string
+
+	// Delay is waited before replying; the request fails early if its
+	// context is done
+	Delay time.Duration
+
+	// Err, if set, is returned instead of a result
+	Err error
+}
+
+// Text returns a reply with the given text
+func Text(text string) Reply {
+	return Reply{Text: text}
+}
+
+// Fail returns a reply that fails with err
+func Fail(err error) Reply {
+	return Reply{Err: err}
+}
+
+// Rule answers requests whose last message matches a pattern
+type Rule struct {
+	pattern *regexp.Regexp
+	replies []Reply
+	next    int
+}
+
+// Reply sets the replies the rule gives, in order. Once they are used up the
+// last one repeats.
+func (r *Rule) Reply(replies ...Reply) *Rule {
+	r.replies = replies
+	r.next = 0
+	return r
+}
+
+// Sampler is a scripted sampling handler. Each request is answered by the
+// first rule whose pattern matches the text of its last message, or else by
+// the next reply in the sequence queued with Then. Requests nothing answers
+// fail.
+type Sampler struct {
+	mu       sync.Mutex
+	rules    []*Rule
+	queue    []Reply
+	requests []*types.CreateMessageRequest
+}
+
+// NewSampler creates a sampler with no script
+func NewSampler() *Sampler {
+	return &Sampler{}
+}
+
+// When adds a rule for requests whose last message matches pattern, a
+// regular expression. It panics if pattern does not compile.
+func (s *Sampler) When(pattern string) *Rule {
+	r := &Rule{pattern: regexp.MustCompile(pattern)}
+	s.mu.Lock()
+	s.rules = append(s.rules, r)
+	s.mu.Unlock()
+	return r
+}
+
+// Then queues replies for requests no rule matches, each used once
+func (s *Sampler) Then(replies ...Reply) *Sampler {
+	s.mu.Lock()
+	s.queue = append(s.queue, replies...)
+	s.mu.Unlock()
+	return s
+}
+
+// Requests returns the requests handled so far, in order
+func (s *Sampler) Requests() []*types.CreateMessageRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*types.CreateMessageRequest(nil), s.requests...)
+}
+
+// Handler returns the sampling handler, e.g. for client.WithSampling
+func (s *Sampler) Handler() types.SamplingHandler {
+	return s.handle
+}
+
+func (s *Sampler) handle(ctx context.Context, req *types.CreateMessageRequest) (*types.CreateMessageResult, error) {
+	reply, err := s.pick(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Delay > 0 {
+		timer := time.NewTimer(reply.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if reply.Err != nil {
+		return nil, reply.Err
+	}
+
+	model := reply.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	stopReason := reply.StopReason
+	if stopReason == "" {
+		stopReason = "endTurn"
+	}
+	return &types.CreateMessageResult{
+		Role: types.RoleAssistant,
+		Content: types.TextContent{
+			Type: "text",
+			Text: reply.Text,
+		},
+		Model:      model,
+		StopReason: stopReason,
+	}, nil
+}
+
+func (s *Sampler) pick(req *types.CreateMessageRequest) (Reply, error) {
+	text := lastText(req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	for _, r := range s.rules {
+		if len(r.replies) == 0 || !r.pattern.MatchString(text) {
+			continue
+		}
+		reply := r.replies[r.next]
+		if r.next < len(r.replies)-1 {
+			r.next++
+		}
+		return reply, nil
+	}
+	if len(s.queue) > 0 {
+		reply := s.queue[0]
+		s.queue = s.queue[1:]
+		return reply, nil
+	}
+	return Reply{}, types.NewError(types.InternalError, fmt.Sprintf("no scripted reply for %q", text))
+}
+
+// lastText returns the text of the request's last message, or "" if it is
+// not text
+func lastText(req *types.CreateMessageRequest) string {
+	if len(req.Messages) == 0 {
+		return ""
+	}
+	switch c := req.Messages[len(req.Messages)-1].Content.(type) {
+	case types.TextContent:
+		return c.Text
+	case *types.TextContent:
+		return c.Text
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package mcptest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/mcptest"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func request(text string) *types.CreateMessageRequest {
+	return &types.CreateMessageRequest{
+		Messages: []types.SamplingMessage{
+			{Role: types.RoleUser, Content: types.TextContent{Type: "text", Text: text}},
+		},
+		MaxTokens: 50,
+	}
+}
+
+func replyText(t *testing.T, result *types.CreateMessageResult) string {
+	t.Helper()
+	txt, ok := result.Content.(types.TextContent)
+	if !ok {
+		t.Fatalf("Expected text content, got %T", result.Content)
+	}
+	return txt.Text
+}
+
+func TestSampler(t *testing.T) {
+	ctx := context.Background()
+	errOverloaded := errors.New("overloaded")
+
+	s := mcptest.NewSampler()
+	s.When(`(?i)weather`).Reply(mcptest.Text("sunny"), mcptest.Text("rainy"))
+	s.When(`^fail`).Reply(mcptest.Fail(errOverloaded))
+	s.Then(mcptest.Text("first"), mcptest.Reply{Text: "second", Model: "other"})
+	handle := s.Handler()
+
+	t.Run("RulesInOrderThenRepeat", func(t *testing.T) {
+		for _, want := range []string{"sunny", "rainy", "rainy"} {
+			result, err := handle(ctx, request("What's the Weather?"))
+			if err != nil {
+				t.Fatalf("handler error: %v", err)
+			}
+			if got := replyText(t, result); got != want {
+				t.Errorf("Expected %q, got %q", want, got)
+			}
+			if result.Model != mcptest.DefaultModel || result.StopReason != "endTurn" {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		if _, err := handle(ctx, request("fail please")); !errors.Is(err, errOverloaded) {
+			t.Errorf("Expected scripted error, got %v", err)
+		}
+	})
+
+	t.Run("Sequence", func(t *testing.T) {
+		result, err := handle(ctx, request("anything"))
+		if err != nil || replyText(t, result) != "first" {
+			t.Fatalf("Expected first queued reply, got %+v, %v", result, err)
+		}
+		result, err = handle(ctx, request("anything"))
+		if err != nil || replyText(t, result) != "second" || result.Model != "other" {
+			t.Fatalf("Expected second queued reply, got %+v, %v", result, err)
+		}
+		if _, err := handle(ctx, request("anything")); err == nil {
+			t.Error("Expected error once the script is exhausted")
+		}
+	})
+
+	if got := len(s.Requests()); got != 7 {
+		t.Errorf("Expected 7 recorded requests, got %d", got)
+	}
+}
+
+func TestSamplerDelay(t *testing.T) {
+	s := mcptest.NewSampler()
+	s.Then(mcptest.Reply{Text: "slow", Delay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.Handler()(ctx, request("hi")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
@@ -31,6 +31,10 @@ const (
 	GetPrompt      = "prompts/get"
 	PromptsChanged = "notifications/prompts/list_changed"
 
+	// Experimental: messages of a prompt being streamed, see
+	// types.ExperimentalPromptStreaming
+	PromptPartialResult = "notifications/prompts/partialResult"
+
 	// Server methods - Tools
 	ListTools    = "tools/list"
 	CallTool     = "tools/call"
@@ -0,0 +1,149 @@
+package mimetype
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUndecodable is returned by DecodeText for data that is not text in a
+// supported charset
+var ErrUndecodable = errors.New("text cannot be decoded")
+
+// windows1252 maps bytes 0x80-0x9F of Windows-1252 to runes; 0 marks the
+// bytes it leaves undefined. The other bytes map as in ISO-8859-1.
+var windows1252 = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+// DecodeText converts text data to UTF-8. The charset comes from a byte
+// order mark, else the charset parameter of contentType, else it is UTF-8
+// if data is valid UTF-8 and Windows-1252 otherwise. Supported charsets are
+// UTF-8, US-ASCII, ISO-8859-1, Windows-1252, and UTF-16. Data with NUL bytes
+// that is not UTF-16, in another charset, or invalid in its charset fails
+// with ErrUndecodable.
+func DecodeText(data []byte, contentType string) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xEF\xBB\xBF")):
+		return decodeUTF8(data[3:])
+	case bytes.HasPrefix(data, []byte("\xFF\xFE")):
+		return decodeUTF16(data[2:], false)
+	case bytes.HasPrefix(data, []byte("\xFE\xFF")):
+		return decodeUTF16(data[2:], true)
+	}
+
+	charset := ""
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = strings.ToLower(params["charset"])
+	}
+	switch charset {
+	case "utf-8", "utf8":
+		return decodeUTF8(data)
+	case "us-ascii", "ascii":
+		if !isASCII(data) {
+			return "", fmt.Errorf("%w: non-ASCII bytes in US-ASCII text", ErrUndecodable)
+		}
+		return string(data), nil
+	case "iso-8859-1", "latin1", "iso_8859-1", "l1":
+		return decodeLatin1(data)
+	case "windows-1252", "cp1252":
+		return decodeWindows1252(data)
+	case "utf-16", "utf-16be":
+		return decodeUTF16(data, true)
+	case "utf-16le":
+		return decodeUTF16(data, false)
+	case "":
+		if utf8.Valid(data) {
+			return decodeUTF8(data)
+		}
+		return decodeWindows1252(data)
+	}
+	return "", fmt.Errorf("%w: unsupported charset %q", ErrUndecodable, charset)
+}
+
+// WithUTF8Charset returns contentType with its charset parameter, if any,
+// set to utf-8, as for text converted by DecodeText
+func WithUTF8Charset(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["charset"] == "" {
+		return contentType
+	}
+	params["charset"] = "utf-8"
+	return mime.FormatMediaType(mediaType, params)
+}
+
+func decodeUTF8(data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%w: invalid UTF-8", ErrUndecodable)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("%w: NUL bytes in text", ErrUndecodable)
+	}
+	return string(data), nil
+}
+
+func decodeLatin1(data []byte) (string, error) {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("%w: NUL bytes in text", ErrUndecodable)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes), nil
+}
+
+func decodeWindows1252(data []byte) (string, error) {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("%w: NUL bytes in text", ErrUndecodable)
+	}
+	var sb strings.Builder
+	sb.Grow(len(data))
+	for _, b := range data {
+		r := rune(b)
+		if b >= 0x80 && b <= 0x9F {
+			if r = windows1252[b-0x80]; r == 0 {
+				return "", fmt.Errorf("%w: byte %#x undefined in Windows-1252", ErrUndecodable, b)
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), nil
+}
+
+func decodeUTF16(data []byte, bigEndian bool) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("%w: odd length of UTF-16 text", ErrUndecodable)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	runes := utf16.Decode(units)
+	for _, r := range runes {
+		if r == utf8.RuneError {
+			return "", fmt.Errorf("%w: invalid UTF-16", ErrUndecodable)
+		}
+	}
+	return string(runes), nil
+}
+
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= 0x80 || b == 0 {
+			return false
+		}
+	}
+	return true
+}
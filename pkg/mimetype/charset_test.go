@@ -0,0 +1,56 @@
+package mimetype
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		want        string
+		wantErr     bool
+	}{
+		{"utf-8", []byte("caf\xc3\xa9"), "", "café", false},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFcaf\xc3\xa9"), "text/plain", "café", false},
+		{"utf-16le bom", []byte("\xFF\xFEh\x00i\x00"), "", "hi", false},
+		{"utf-16be bom", []byte("\xFE\xFF\x00h\x00i"), "text/plain; charset=iso-8859-1", "hi", false},
+		{"utf-16le", []byte("h\x00i\x00"), "text/plain; charset=UTF-16LE", "hi", false},
+		{"latin1", []byte("caf\xe9"), "text/plain; charset=ISO-8859-1", "café", false},
+		{"windows-1252", []byte("\x93quoted\x94 \x80"), "text/plain; charset=windows-1252", "“quoted” €", false},
+		{"undeclared windows-1252", []byte("caf\xe9"), "text/plain", "café", false},
+		{"ascii", []byte("plain"), "text/plain; charset=us-ascii", "plain", false},
+		{"invalid utf-8", []byte("caf\xe9"), "text/plain; charset=utf-8", "", true},
+		{"non-ascii", []byte("caf\xe9"), "text/plain; charset=us-ascii", "", true},
+		{"undefined windows-1252", []byte("\x81"), "", "", true},
+		{"nul bytes", []byte("a\x00b"), "", "", true},
+		{"odd utf-16", []byte("\xFF\xFEh"), "", "", true},
+		{"unsupported charset", []byte("text"), "text/plain; charset=shift_jis", "", true},
+	}
+	for _, tt := range tests {
+		got, err := DecodeText(tt.data, tt.contentType)
+		if tt.wantErr {
+			if !errors.Is(err, ErrUndecodable) {
+				t.Errorf("%s: DecodeText() error = %v, want ErrUndecodable", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: DecodeText() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestWithUTF8Charset(t *testing.T) {
+	for contentType, want := range map[string]string{
+		"text/plain; charset=ISO-8859-1": "text/plain; charset=utf-8",
+		"text/plain":                     "text/plain",
+		"":                               "",
+	} {
+		if got := WithUTF8Charset(contentType); got != want {
+			t.Errorf("WithUTF8Charset(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+}
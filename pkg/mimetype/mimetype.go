@@ -0,0 +1,90 @@
+// Package mimetype detects the MIME types of resources from their names and
+// contents, so providers need not be told them.
+package mimetype
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Default is the MIME type of content that cannot be identified
+const Default = "application/octet-stream"
+
+// extensions covers common source and text formats missing from the standard
+// library's table, so detection does not depend on the system's mime.types
+var extensions = map[string]string{
+	".txt":  "text/plain",
+	".md":   "text/markdown",
+	".csv":  "text/csv",
+	".tsv":  "text/tab-separated-values",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".toml": "application/toml",
+	".go":   "text/x-go",
+	".py":   "text/x-python",
+	".rs":   "text/x-rust",
+	".ts":   "text/typescript",
+	".sh":   "application/x-sh",
+	".sql":  "application/sql",
+}
+
+// ByExtension returns the MIME type of name, a path or URI, from its
+// extension, or "" if the extension is unknown. Parameters such as the
+// charset are dropped.
+func ByExtension(name string) string {
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	ext := strings.ToLower(path.Ext(name))
+	if ext == "" {
+		return ""
+	}
+	if t, ok := extensions[ext]; ok {
+		return t
+	}
+	return mediaType(mime.TypeByExtension(ext))
+}
+
+// Detect returns the MIME type of data named name, a path or URI. The
+// extension of name is used if known, otherwise data is sniffed with
+// http.DetectContentType.
+func Detect(name string, data []byte) string {
+	if t := ByExtension(name); t != "" {
+		return t
+	}
+	return mediaType(http.DetectContentType(data))
+}
+
+// IsText reports whether content of mimeType is text, and so is better
+// served as text than as a blob
+func IsText(mimeType string) bool {
+	mimeType = mediaType(mimeType)
+	switch {
+	case strings.HasPrefix(mimeType, "text/"):
+		return true
+	case mimeType == "application/json",
+		mimeType == "application/xml",
+		mimeType == "application/javascript",
+		mimeType == "application/yaml",
+		mimeType == "application/toml",
+		mimeType == "application/sql",
+		mimeType == "application/x-sh",
+		strings.HasSuffix(mimeType, "+json"),
+		strings.HasSuffix(mimeType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// mediaType strips the parameters from a MIME type
+func mediaType(t string) string {
+	if t == "" {
+		return ""
+	}
+	if parsed, _, err := mime.ParseMediaType(t); err == nil {
+		return parsed
+	}
+	return t
+}
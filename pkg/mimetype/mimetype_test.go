@@ -0,0 +1,43 @@
+package mimetype
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"README.md", nil, "text/markdown"},
+		{"file:///src/main.GO", nil, "text/x-go"},
+		{"https://example.com/data.json?v=2", nil, "application/json"},
+		{"page.html", nil, "text/html"},
+		{"image", png, "image/png"},
+		{"notes", []byte("plain words"), "text/plain"},
+		{"blob", []byte{0, 1, 2, 3}, Default},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.name, tt.data); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := ByExtension("Makefile"); got != "" {
+		t.Errorf("ByExtension() without extension = %q, want empty", got)
+	}
+}
+
+func TestIsText(t *testing.T) {
+	for mimeType, want := range map[string]bool{
+		"text/plain; charset=utf-8": true,
+		"application/json":          true,
+		"application/vnd.api+json":  true,
+		"application/yaml":          true,
+		"image/png":                 false,
+		Default:                     false,
+	} {
+		if got := IsText(mimeType); got != want {
+			t.Errorf("IsText(%q) = %v, want %v", mimeType, got, want)
+		}
+	}
+}
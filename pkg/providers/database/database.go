@@ -0,0 +1,366 @@
+// Package database provides a resource provider exposing the tables of a SQL
+// database as resources, along with a tool for running queries. It is built on
+// database/sql, so any driver can be used.
+//
+// Tables are addressed as db://{schema}/{table}. The contents of a table are
+// returned as CSV by default; append ?format=json or ?format=text to select
+// another representation.
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Scheme is the URI scheme used for database resources
+const Scheme = "db"
+
+// Format is a representation of query results
+type Format string
+
+const (
+	// FormatCSV renders rows as CSV with a header line
+	FormatCSV Format = "csv"
+
+	// FormatJSON renders rows as a JSON array of objects
+	FormatJSON Format = "json"
+
+	// FormatText renders rows as an aligned plain-text table
+	FormatText Format = "text"
+)
+
+// mimeTypes maps formats to the MIME type of their contents
+var mimeTypes = map[Format]string{
+	FormatCSV:  "text/csv",
+	FormatJSON: "application/json",
+	FormatText: "text/plain",
+}
+
+// Dialect describes how to introspect and query a particular database
+type Dialect struct {
+	// TablesQuery returns one row of (schema, table) per exposed table
+	TablesQuery string
+
+	// QuoteIdent quotes a schema or table identifier
+	QuoteIdent func(name string) string
+}
+
+// Postgres is the dialect for PostgreSQL
+var Postgres = Dialect{
+	TablesQuery: `SELECT table_schema, table_name FROM information_schema.tables
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2`,
+	QuoteIdent: quoteDouble,
+}
+
+// MySQL is the dialect for MySQL and MariaDB
+var MySQL = Dialect{
+	TablesQuery: `SELECT table_schema, table_name FROM information_schema.tables
+		WHERE table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys') ORDER BY 1, 2`,
+	QuoteIdent: func(name string) string {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	},
+}
+
+// SQLite is the dialect for SQLite. All tables are reported in the "main" schema.
+var SQLite = Dialect{
+	TablesQuery: `SELECT 'main', name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`,
+	QuoteIdent: quoteDouble,
+}
+
+func quoteDouble(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Provider is a types.ResourceProvider backed by a SQL database
+type Provider struct {
+	db      *sql.DB
+	dialect Dialect
+	format  Format
+	maxRows int
+}
+
+// Option configures a Provider
+type Option func(*Provider)
+
+// WithFormat sets the default format for table contents
+func WithFormat(f Format) Option {
+	return func(p *Provider) {
+		p.format = f
+	}
+}
+
+// WithMaxRows limits the number of rows returned by reads and queries.
+// The default is 100.
+func WithMaxRows(n int) Option {
+	return func(p *Provider) {
+		p.maxRows = n
+	}
+}
+
+// NewProvider creates a Provider for db using the given dialect
+func NewProvider(db *sql.DB, dialect Dialect, opts ...Option) *Provider {
+	p := &Provider{
+		db:      db,
+		dialect: dialect,
+		format:  FormatCSV,
+		maxRows: 100,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// TableURI returns the resource URI of a table
+func TableURI(schema, table string) string {
+	return (&url.URL{Scheme: Scheme, Host: schema, Path: "/" + table}).String()
+}
+
+// List implements types.ResourceProvider, returning one resource per table
+func (p *Provider) List(ctx context.Context) ([]types.Resource, error) {
+	rows, err := p.db.QueryContext(ctx, p.dialect.TablesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var resources []types.Resource
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		resources = append(resources, types.Resource{
+			URI:         TableURI(schema, table),
+			Name:        schema + "." + table,
+			Description: fmt.Sprintf("Rows of table %s.%s", schema, table),
+			MimeType:    mimeTypes[p.format],
+		})
+	}
+	return resources, rows.Err()
+}
+
+// Templates implements types.ResourceProvider
+func (p *Provider) Templates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	return []types.ResourceTemplate{
+		{
+			URITemplate: Scheme + "://{schema}/{table}{?format}",
+			Name:        "Database table",
+			Description: "Rows of a database table as csv, json, or text",
+		},
+	}, nil
+}
+
+// Read implements types.ResourceProvider, returning up to the configured
+// maximum number of rows of the table identified by uri
+func (p *Provider) Read(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+	schema, table, format, err := p.parseURI(uri)
+	if err != nil {
+		return nil, types.NewError(types.InvalidParams, err.Error())
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s.%s LIMIT %d",
+		p.dialect.QuoteIdent(schema), p.dialect.QuoteIdent(table), p.maxRows)
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s.%s: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	text, err := render(rows, format, p.maxRows)
+	if err != nil {
+		return nil, err
+	}
+
+	return []types.ResourceContent{
+		types.TextResourceContents{
+			ResourceContents: types.ResourceContents{
+				URI:      uri,
+				MimeType: mimeTypes[format],
+			},
+			Text: text,
+		},
+	}, nil
+}
+
+// Subscribe implements types.ResourceProvider. Table changes are not
+// detected, so the returned cancel function is a no-op.
+func (p *Provider) Subscribe(ctx context.Context, uri string, notify types.ResourceUpdateFunc) (func(), error) {
+	return func() {}, nil
+}
+
+// parseURI splits a table URI into schema, table, and format
+func (p *Provider) parseURI(uri string) (schema, table string, format Format, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", "", fmt.Errorf("malformed URI %q: %w", uri, err)
+	}
+	table = strings.Trim(u.Path, "/")
+	if u.Scheme != Scheme || u.Host == "" || table == "" || strings.Contains(table, "/") {
+		return "", "", "", fmt.Errorf("expected URI of the form %s://schema/table, got %q", Scheme, uri)
+	}
+
+	format = p.format
+	if f := u.Query().Get("format"); f != "" {
+		format = Format(f)
+		if _, ok := mimeTypes[format]; !ok {
+			return "", "", "", fmt.Errorf("unsupported format %q", f)
+		}
+	}
+	return u.Host, table, format, nil
+}
+
+// QueryInput is the input of the query tool
+type QueryInput struct {
+	SQL    string `json:"sql" jsonschema:"description=SQL query to run,required"`
+	Format string `json:"format,omitempty" jsonschema:"description=Result format (csv or json or text),enum=csv,enum=json,enum=text"`
+}
+
+// NewQueryTool returns a tool named name that runs SQL queries against the
+// provider's database inside a read-only transaction
+func (p *Provider) NewQueryTool(name string) types.McpTool {
+	return types.NewTool[QueryInput](name, "Run a read-only SQL query and return the resulting rows",
+		func(ctx context.Context, input QueryInput) (*types.CallToolResult, error) {
+			format := p.format
+			if input.Format != "" {
+				format = Format(input.Format)
+			}
+			if _, ok := mimeTypes[format]; !ok {
+				return toolError(fmt.Sprintf("unsupported format %q", input.Format)), nil
+			}
+
+			text, err := p.query(ctx, input.SQL, format)
+			if err != nil {
+				return toolError(err.Error()), nil
+			}
+			return &types.CallToolResult{
+				Content: []interface{}{
+					types.TextContent{Type: "text", Text: text},
+				},
+			}, nil
+		})
+}
+
+// query runs sqlText in a transaction that is always rolled back, read-only
+// if the driver supports it
+func (p *Provider) query(ctx context.Context, sqlText string, format Format) (string, error) {
+	tx, err := p.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		// Drivers without read-only transactions refuse the option
+		tx, err = p.db.BeginTx(ctx, nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, sqlText)
+	if err != nil {
+		return "", fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	return render(rows, format, p.maxRows)
+}
+
+func toolError(msg string) *types.CallToolResult {
+	return &types.CallToolResult{
+		Content: []interface{}{
+			types.TextContent{Type: "text", Text: msg},
+		},
+		IsError: true,
+	}
+}
+
+// render formats up to maxRows rows in the given format
+func render(rows *sql.Rows, format Format, maxRows int) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	var records [][]interface{}
+	for len(records) < maxRows && rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", fmt.Errorf("failed to scan row: %w", err)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		records = append(records, values)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatJSON:
+		objects := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			obj := make(map[string]interface{}, len(columns))
+			for i, col := range columns {
+				obj[col] = record[i]
+			}
+			objects = append(objects, obj)
+		}
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(objects); err != nil {
+			return "", err
+		}
+	case FormatText:
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+		for _, record := range records {
+			fmt.Fprintln(w, strings.Join(stringify(record), "\t"))
+		}
+		if err := w.Flush(); err != nil {
+			return "", err
+		}
+	default:
+		w := csv.NewWriter(&buf)
+		if err := w.Write(columns); err != nil {
+			return "", err
+		}
+		for _, record := range records {
+			if err := w.Write(stringify(record)); err != nil {
+				return "", err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+func stringify(record []interface{}) []string {
+	out := make([]string, len(record))
+	for i, v := range record {
+		if v == nil {
+			out[i] = "NULL"
+			continue
+		}
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
@@ -0,0 +1,245 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/providers/database"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// fakeDriver answers a fixed set of queries so the provider can be tested
+// without a real database
+type fakeDriver struct {
+	results map[string]fakeRows
+	// rejectReadOnly refuses read-only transactions, as some drivers do
+	rejectReadOnly bool
+	// began counts the transactions begun
+	began int
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	pos     int
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly && c.d.rejectReadOnly {
+		return nil, fmt.Errorf("read-only transactions not supported")
+	}
+	c.d.began++
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("exec not supported")
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	for prefix, rows := range s.c.d.results {
+		if strings.HasPrefix(strings.TrimSpace(s.query), prefix) {
+			r := rows
+			return &r, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected query: %s", s.query)
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.pos])
+	r.pos++
+	return nil
+}
+
+var fakeResults = map[string]fakeRows{
+	"SELECT 'main', name FROM sqlite_master": {
+		columns: []string{"schema", "name"},
+		values:  [][]driver.Value{{"main", "users"}},
+	},
+	`SELECT * FROM "main"."users" LIMIT 100`: {
+		columns: []string{"id", "name"},
+		values:  [][]driver.Value{{int64(1), "alice"}, {int64(2), []byte("bob")}},
+	},
+	"SELECT name FROM users": {
+		columns: []string{"name"},
+		values:  [][]driver.Value{{"alice"}, {nil}},
+	},
+}
+
+var (
+	registered    = false
+	noReadOnlyDrv = &fakeDriver{results: fakeResults, rejectReadOnly: true}
+)
+
+func openFakeDB(t *testing.T) *sql.DB {
+	return openDB(t, "fake")
+}
+
+// openDB opens a database of the fake driver name: "fake", or
+// "fake-no-read-only" refusing read-only transactions
+func openDB(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	if !registered {
+		sql.Register("fake", &fakeDriver{results: fakeResults})
+		sql.Register("fake-no-read-only", noReadOnlyDrv)
+		registered = true
+	}
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("sql.Open() error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestProvider_ListAndRead(t *testing.T) {
+	ctx := context.Background()
+	p := database.NewProvider(openFakeDB(t), database.SQLite)
+
+	resources, err := p.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(resources) != 1 || resources[0].URI != "db://main/users" || resources[0].MimeType != "text/csv" {
+		t.Fatalf("Unexpected resources: %+v", resources)
+	}
+
+	tests := []struct {
+		name     string
+		uri      string
+		wantMime string
+		wantText string
+		wantErr  bool
+	}{
+		{
+			name:     "default csv format",
+			uri:      "db://main/users",
+			wantMime: "text/csv",
+			wantText: "id,name\n1,alice\n2,bob\n",
+		},
+		{
+			name:     "json format",
+			uri:      "db://main/users?format=json",
+			wantMime: "application/json",
+			wantText: "[\n  {\n    \"id\": 1,\n    \"name\": \"alice\"\n  },\n  {\n    \"id\": 2,\n    \"name\": \"bob\"\n  }\n]\n",
+		},
+		{
+			name:    "unsupported format",
+			uri:     "db://main/users?format=xml",
+			wantErr: true,
+		},
+		{
+			name:    "missing table",
+			uri:     "db://main",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents, err := p.Read(ctx, tt.uri)
+			if tt.wantErr {
+				mcpErr, ok := err.(*types.ErrorResponse)
+				if !ok || mcpErr.Code != types.InvalidParams {
+					t.Fatalf("Expected InvalidParams error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error: %v", err)
+			}
+			txt, ok := contents[0].(types.TextResourceContents)
+			if !ok {
+				t.Fatalf("Expected TextResourceContents, got %T", contents[0])
+			}
+			if txt.MimeType != tt.wantMime {
+				t.Errorf("MimeType = %q, want %q", txt.MimeType, tt.wantMime)
+			}
+			if txt.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", txt.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestProvider_QueryTool(t *testing.T) {
+	ctx := context.Background()
+	p := database.NewProvider(openFakeDB(t), database.SQLite, database.WithFormat(database.FormatText))
+	tool := p.NewQueryTool("query")
+
+	def := tool.GetDefinition()
+	if def.Name != "query" || len(def.InputSchema.Required) != 1 || def.InputSchema.Required[0] != "sql" {
+		t.Errorf("Unexpected tool definition: %+v", def)
+	}
+
+	result, err := tool.GetHandler()(ctx, map[string]interface{}{"sql": "SELECT name FROM users"})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Unexpected tool error: %+v", result.Content)
+	}
+	if txt := result.Content[0].(types.TextContent).Text; txt != "name\nalice\nNULL\n" {
+		t.Errorf("Unexpected query output: %q", txt)
+	}
+
+	result, err = tool.GetHandler()(ctx, map[string]interface{}{"sql": "DROP TABLE users"})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected tool error for failing query")
+	}
+}
+
+func TestProvider_QueryToolWithoutReadOnly(t *testing.T) {
+	p := database.NewProvider(openDB(t, "fake-no-read-only"), database.SQLite, database.WithFormat(database.FormatCSV))
+	result, err := p.NewQueryTool("query").GetHandler()(context.Background(), map[string]interface{}{"sql": "SELECT name FROM users"})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Unexpected tool error: %+v", result.Content)
+	}
+	if txt := result.Content[0].(types.TextContent).Text; txt != "name\nalice\nNULL\n" {
+		t.Errorf("Unexpected query output: %q", txt)
+	}
+	if noReadOnlyDrv.began != 1 {
+		t.Errorf("Began %d transactions, want 1", noReadOnlyDrv.began)
+	}
+}
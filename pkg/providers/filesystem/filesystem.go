@@ -0,0 +1,177 @@
+// Package filesystem provides a resource provider serving the files below a
+// local directory as file:// resources. MIME types are detected from file
+// names and contents, see the mimetype package.
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dwrtz/mcp-go/pkg/mimetype"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// sniffSize is how much of a file is read to detect its MIME type, as much
+// as http.DetectContentType considers
+const sniffSize = 512
+
+// Provider is a types.ResourceProvider backed by a local directory
+type Provider struct {
+	dir    string
+	prefix string
+}
+
+// NewProvider creates a provider serving the files below dir. Mount it at
+// Prefix.
+func NewProvider(dir string) (*Provider, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	abs, err = filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &Provider{dir: abs, prefix: fileURI(abs) + "/"}, nil
+}
+
+// Prefix returns the file:// URI prefix of the provider's resources
+func (p *Provider) Prefix() string {
+	return p.prefix
+}
+
+// List implements types.ResourceProvider. Every regular file is listed,
+// sorted by path; hidden files and directories are skipped.
+func (p *Provider) List(ctx context.Context) ([]types.Resource, error) {
+	var resources []types.Resource
+	err := filepath.WalkDir(p.dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file != p.dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(p.dir, file)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed since the directory was read
+			return nil
+		} else if err != nil {
+			return err
+		}
+		size, modified := info.Size(), info.ModTime()
+		resources = append(resources, types.Resource{
+			URI:          fileURI(file),
+			Name:         filepath.ToSlash(rel),
+			MimeType:     detect(file),
+			Size:         &size,
+			LastModified: &modified,
+		})
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", p.dir, err)
+	}
+	return resources, nil
+}
+
+// Templates implements types.ResourceProvider. The provider has none.
+func (p *Provider) Templates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	return nil, nil
+}
+
+// Read implements types.ResourceProvider. Text files are converted to UTF-8
+// and returned as text contents; other files, and text that cannot be
+// decoded, are returned as blobs.
+func (p *Provider) Read(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+	file, err := p.path(uri)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, types.NewError(types.InvalidParams, fmt.Sprintf("failed to read %s: %v", uri, err))
+	}
+
+	mimeType := mimetype.Detect(file, data)
+	if mimetype.IsText(mimeType) {
+		if text, err := mimetype.DecodeText(data, ""); err == nil {
+			return []types.ResourceContent{types.TextResourceContents{
+				ResourceContents: types.ResourceContents{URI: uri, MimeType: mimeType},
+				Text:             text,
+			}}, nil
+		}
+	}
+	return []types.ResourceContent{types.NewBlobContents(uri, mimeType, data)}, nil
+}
+
+// Subscribe implements types.ResourceProvider. Changes to files are not
+// detected, so the returned cancel function is a no-op.
+func (p *Provider) Subscribe(ctx context.Context, uri string, notify types.ResourceUpdateFunc) (func(), error) {
+	return func() {}, nil
+}
+
+// path maps uri to a file below the directory, refusing URIs outside it,
+// including through symlinks
+func (p *Provider) path(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", types.NewError(types.InvalidParams, "invalid file URI: "+uri)
+	}
+	file, err := filepath.EvalSymlinks(filepath.FromSlash(path.Clean(u.Path)))
+	if err != nil {
+		return "", types.NewError(types.InvalidParams, "resource not found: "+uri)
+	}
+	rel, err := filepath.Rel(p.dir, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", types.NewError(types.InvalidParams, "resource outside the served directory: "+uri)
+	}
+	return file, nil
+}
+
+// detect returns the MIME type of file from its name, or else from its first
+// bytes
+func detect(file string) string {
+	if t := mimetype.ByExtension(file); t != "" {
+		return t
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return mimetype.Default
+	}
+	defer f.Close()
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return mimetype.Default
+	}
+	return mimetype.Detect("", head[:n])
+}
+
+func fileURI(file string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+}
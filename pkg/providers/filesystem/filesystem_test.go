@@ -0,0 +1,91 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestProvider(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"README.md":       "# Hello",
+		"src/main.go":     "package main",
+		"bin/tool":        "\x7fELF\x00\x00\x00",
+		".git/config":     "hidden",
+		"data/noext.html": "<html></html>",
+	}
+	for name, contents := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewProvider(dir)
+	if err != nil {
+		t.Fatalf("NewProvider() error: %v", err)
+	}
+	ctx := context.Background()
+
+	resources, err := p.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	mimeTypes := make(map[string]string)
+	for _, r := range resources {
+		mimeTypes[r.Name] = r.MimeType
+		if r.Size == nil || *r.Size != int64(len(files[r.Name])) || r.LastModified == nil || r.LastModified.IsZero() {
+			t.Errorf("Listing of %s lacks its size or modification time: %+v", r.Name, r)
+		}
+	}
+	want := map[string]string{
+		"README.md":       "text/markdown",
+		"src/main.go":     "text/x-go",
+		"bin/tool":        "application/octet-stream",
+		"data/noext.html": "text/html",
+	}
+	if len(mimeTypes) != len(want) {
+		t.Errorf("List() = %v, want %v", mimeTypes, want)
+	}
+	for name, mimeType := range want {
+		if mimeTypes[name] != mimeType {
+			t.Errorf("MIME type of %s = %q, want %q", name, mimeTypes[name], mimeType)
+		}
+	}
+
+	contents, err := p.Read(ctx, p.Prefix()+"README.md")
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if text, ok := contents[0].(types.TextResourceContents); !ok || text.Text != "# Hello" || text.MimeType != "text/markdown" {
+		t.Errorf("Read() of text = %+v", contents[0])
+	}
+	contents, err = p.Read(ctx, p.Prefix()+"bin/tool")
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if _, ok := contents[0].(types.BlobResourceContents); !ok {
+		t.Errorf("Read() of binary = %T, want blob", contents[0])
+	}
+
+	// Files outside the directory are refused, also through symlinks
+	for _, uri := range []string{p.Prefix() + "../" + filepath.Base(dir), p.Prefix() + "escape.txt", fileURI(outside)} {
+		if _, err := p.Read(ctx, uri); err == nil {
+			t.Errorf("Read(%s) succeeded outside the directory", uri)
+		}
+	}
+}
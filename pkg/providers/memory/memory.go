@@ -0,0 +1,182 @@
+// Package memory provides an in-memory resource provider.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/mimetype"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// entry is a stored resource and its contents
+type entry struct {
+	resource types.Resource
+	content  types.ResourceContent
+}
+
+// Provider is a types.ResourceProvider backed by a map
+type Provider struct {
+	mu        sync.RWMutex
+	entries   map[string]entry
+	templates []types.ResourceTemplate
+
+	watchers map[string]map[int]types.ResourceUpdateFunc
+	nextID   int
+}
+
+// NewProvider creates an empty Provider
+func NewProvider() *Provider {
+	return &Provider{
+		entries:  make(map[string]entry),
+		watchers: make(map[string]map[int]types.ResourceUpdateFunc),
+	}
+}
+
+// SetText stores a text resource, notifying any watchers of its URI. An
+// empty MimeType is detected from the URI's extension, defaulting to
+// text/plain. Size and LastModified default to the text's length and now.
+func (p *Provider) SetText(r types.Resource, text string) {
+	stamp(&r, len(text))
+	if r.MimeType == "" {
+		r.MimeType = mimetype.ByExtension(r.URI)
+	}
+	if r.MimeType == "" {
+		r.MimeType = "text/plain"
+	}
+	p.set(r, types.TextResourceContents{
+		ResourceContents: types.ResourceContents{
+			URI:      r.URI,
+			MimeType: r.MimeType,
+		},
+		Text: text,
+	})
+}
+
+// SetBlob stores a binary resource, notifying any watchers of its URI. An
+// empty MimeType is detected from the URI's extension or the data. Size and
+// LastModified default to the data's length and now.
+func (p *Provider) SetBlob(r types.Resource, data []byte) {
+	stamp(&r, len(data))
+	if r.MimeType == "" {
+		r.MimeType = mimetype.Detect(r.URI, data)
+	}
+	p.set(r, types.NewBlobContents(r.URI, r.MimeType, data))
+}
+
+// stamp sets the size and modification time of r unless given
+func stamp(r *types.Resource, size int) {
+	if r.Size == nil {
+		n := int64(size)
+		r.Size = &n
+	}
+	if r.LastModified == nil {
+		now := time.Now()
+		r.LastModified = &now
+	}
+}
+
+// Delete removes a resource, notifying any watchers of its URI. It returns
+// false if the resource did not exist.
+func (p *Provider) Delete(uri string) bool {
+	p.mu.Lock()
+	_, ok := p.entries[uri]
+	delete(p.entries, uri)
+	var watchers []types.ResourceUpdateFunc
+	if ok {
+		watchers = p.watchersOf(uri)
+	}
+	p.mu.Unlock()
+
+	for _, notify := range watchers {
+		notify(uri)
+	}
+	return ok
+}
+
+// SetTemplates replaces the provider's resource templates
+func (p *Provider) SetTemplates(templates []types.ResourceTemplate) {
+	p.mu.Lock()
+	p.templates = templates
+	p.mu.Unlock()
+}
+
+// List implements types.ResourceProvider. Resources are sorted by URI.
+func (p *Provider) List(ctx context.Context) ([]types.Resource, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	resources := make([]types.Resource, 0, len(p.entries))
+	for _, e := range p.entries {
+		resources = append(resources, e.resource)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].URI < resources[j].URI
+	})
+	return resources, nil
+}
+
+// Templates implements types.ResourceProvider
+func (p *Provider) Templates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]types.ResourceTemplate{}, p.templates...), nil
+}
+
+// Read implements types.ResourceProvider
+func (p *Provider) Read(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+	p.mu.RLock()
+	e, ok := p.entries[uri]
+	p.mu.RUnlock()
+
+	if !ok {
+		return nil, types.NewError(types.InvalidParams, "resource not found: "+uri)
+	}
+	return []types.ResourceContent{e.content}, nil
+}
+
+// Subscribe implements types.ResourceProvider. notify is called after every
+// SetText, SetBlob, or Delete of uri until the returned cancel function is
+// called.
+func (p *Provider) Subscribe(ctx context.Context, uri string, notify types.ResourceUpdateFunc) (func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := p.nextID
+	p.nextID++
+	if p.watchers[uri] == nil {
+		p.watchers[uri] = make(map[int]types.ResourceUpdateFunc)
+	}
+	p.watchers[uri][id] = notify
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.watchers[uri], id)
+		if len(p.watchers[uri]) == 0 {
+			delete(p.watchers, uri)
+		}
+	}, nil
+}
+
+func (p *Provider) set(r types.Resource, content types.ResourceContent) {
+	p.mu.Lock()
+	p.entries[r.URI] = entry{resource: r, content: content}
+	watchers := p.watchersOf(r.URI)
+	p.mu.Unlock()
+
+	for _, notify := range watchers {
+		notify(r.URI)
+	}
+}
+
+// watchersOf returns the watchers of uri. The caller must hold p.mu.
+func (p *Provider) watchersOf(uri string) []types.ResourceUpdateFunc {
+	watchers := make([]types.ResourceUpdateFunc, 0, len(p.watchers[uri]))
+	for _, notify := range p.watchers[uri] {
+		watchers = append(watchers, notify)
+	}
+	return watchers
+}
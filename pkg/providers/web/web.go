@@ -0,0 +1,325 @@
+// Package web provides a resource provider that serves http:// and https://
+// URIs by fetching them from upstream servers. Only URIs matching an explicit
+// allowlist are fetched, responses are size-limited, and results are cached.
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/mimetype"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+const (
+	defaultMaxSize      = 1 << 20 // 1 MiB
+	defaultCacheTTL     = time.Minute
+	defaultCacheEntries = 256
+)
+
+// allowedPrefix is an allowlist entry: URIs on exactly this scheme, host
+// and port, with a path at or below path
+type allowedPrefix struct {
+	scheme string
+	host   string
+	path   string
+}
+
+// cacheEntry is a cached upstream response
+type cacheEntry struct {
+	contents []types.ResourceContent
+	expires  time.Time
+}
+
+// Provider is a types.ResourceProvider backed by HTTP GET requests
+type Provider struct {
+	client       *http.Client
+	allowed      []allowedPrefix
+	maxSize      int64
+	ttl          time.Duration
+	cacheEntries int
+	resources    []types.Resource
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// Option configures a Provider
+type Option func(*Provider)
+
+// WithHTTPClient sets the client used for upstream requests
+func WithHTTPClient(c *http.Client) Option {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// WithMaxSize sets the maximum accepted response size in bytes. The default is 1 MiB.
+func WithMaxSize(n int64) Option {
+	return func(p *Provider) {
+		p.maxSize = n
+	}
+}
+
+// WithCacheTTL sets how long responses are cached. A zero TTL disables caching.
+// The default is one minute.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.ttl = ttl
+	}
+}
+
+// WithCacheSize sets how many responses are cached at most. The default is
+// 256.
+func WithCacheSize(n int) Option {
+	return func(p *Provider) {
+		p.cacheEntries = n
+	}
+}
+
+// WithResources sets the resources advertised in resources/list
+func WithResources(resources ...types.Resource) Option {
+	return func(p *Provider) {
+		p.resources = resources
+	}
+}
+
+// NewProvider creates a Provider that only fetches URIs under one of the
+// allowed prefixes (e.g. "https://example.com/docs/"), redirects included.
+// A URI is under a prefix if it has the same scheme, host and port, and its
+// path, with dot segments resolved, is the prefix's path or below it, a
+// whole segment at a time: "https://example.com/docs" allows
+// "https://example.com/docs/a" but not "https://example.com/docs2". URIs
+// with user information are refused, and prefixes that are not http or
+// https URLs allow nothing.
+func NewProvider(allowed []string, opts ...Option) *Provider {
+	p := &Provider{
+		client:       http.DefaultClient,
+		maxSize:      defaultMaxSize,
+		ttl:          defaultCacheTTL,
+		cacheEntries: defaultCacheEntries,
+		cache:        make(map[string]cacheEntry),
+	}
+	for _, prefix := range allowed {
+		if u, err := clean(prefix); err == nil {
+			p.allowed = append(p.allowed, allowedPrefix{scheme: u.Scheme, host: u.Host, path: strings.TrimSuffix(u.Path, "/")})
+		}
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// The client is copied to check redirects before following them
+	client := p.client
+	c := *client
+	c.CheckRedirect = func(r *http.Request, via []*http.Request) error {
+		if _, ok := p.allowedURL(r.URL.String()); !ok {
+			return fmt.Errorf("redirect to %s is not allowed", r.URL.Redacted())
+		}
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(r, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	p.client = &c
+	return p
+}
+
+// clean parses an http or https URL without user information, lowercasing
+// its scheme and host, making its port explicit and resolving the dot
+// segments of its path
+func clean(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	port := u.Port()
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return nil, fmt.Errorf("%s is not an http or https URL", raw)
+	case u.User != nil:
+		return nil, fmt.Errorf("%s has user information", raw)
+	case u.Hostname() == "":
+		return nil, fmt.Errorf("%s has no host", raw)
+	case port == "" && u.Scheme == "http":
+		port = "80"
+	case port == "":
+		port = "443"
+	}
+	u.Host = net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+
+	cleaned := path.Clean("/" + u.Path)
+	if strings.HasSuffix(u.Path, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	u.Path, u.RawPath = cleaned, ""
+	u.Fragment, u.RawFragment = "", ""
+	return u, nil
+}
+
+// List implements types.ResourceProvider, returning the configured resources
+func (p *Provider) List(ctx context.Context) ([]types.Resource, error) {
+	return p.resources, nil
+}
+
+// Templates implements types.ResourceProvider. Web resources have no templates.
+func (p *Provider) Templates(ctx context.Context) ([]types.ResourceTemplate, error) {
+	return nil, nil
+}
+
+// Read implements types.ResourceProvider by fetching uri from upstream
+func (p *Provider) Read(ctx context.Context, uri string) ([]types.ResourceContent, error) {
+	// What is fetched is the cleaned URL that was checked
+	target, ok := p.allowedURL(uri)
+	if !ok {
+		return nil, types.NewError(types.InvalidParams, "URI not allowed: "+uri)
+	}
+
+	if contents, ok := p.cached(target); ok {
+		return contents, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, types.NewError(types.InvalidParams, fmt.Sprintf("invalid URI %s: %v", uri, err))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", uri, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status code %d", uri, resp.StatusCode)
+	}
+	if resp.ContentLength > p.maxSize {
+		return nil, fmt.Errorf("resource %s exceeds maximum size of %d bytes", uri, p.maxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", uri, err)
+	}
+	if int64(len(data)) > p.maxSize {
+		return nil, fmt.Errorf("resource %s exceeds maximum size of %d bytes", uri, p.maxSize)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mimeType = mimetype.Detect(uri, data)
+	}
+
+	// Text is converted to UTF-8 from the charset of the response; text
+	// that cannot be is returned as a blob
+	var content types.ResourceContent = types.NewBlobContents(uri, mimeType, data)
+	if mimetype.IsText(mimeType) {
+		if text, err := mimetype.DecodeText(data, contentType); err == nil {
+			content = types.TextResourceContents{
+				ResourceContents: types.ResourceContents{
+					URI:      uri,
+					MimeType: mimeType,
+				},
+				Text: text,
+			}
+		}
+	}
+
+	contents := []types.ResourceContent{content}
+	p.store(target, contents)
+	return contents, nil
+}
+
+// Subscribe implements types.ResourceProvider. Upstream changes are not
+// detected, so the returned cancel function is a no-op.
+func (p *Provider) Subscribe(ctx context.Context, uri string, notify types.ResourceUpdateFunc) (func(), error) {
+	return func() {}, nil
+}
+
+// Invalidate drops uri from the cache
+func (p *Provider) Invalidate(uri string) {
+	u, err := clean(uri)
+	if err != nil {
+		return
+	}
+	p.mu.Lock()
+	delete(p.cache, u.String())
+	p.mu.Unlock()
+}
+
+// allowedURL returns uri cleaned, see clean, and whether it is under an
+// allowed prefix
+func (p *Provider) allowedURL(uri string) (string, bool) {
+	u, err := clean(uri)
+	if err != nil {
+		return "", false
+	}
+	for _, a := range p.allowed {
+		if u.Scheme != a.scheme || u.Host != a.host {
+			continue
+		}
+		if a.path == "" || u.Path == a.path || strings.HasPrefix(u.Path, a.path+"/") {
+			return u.String(), true
+		}
+	}
+	return "", false
+}
+
+func (p *Provider) cached(uri string) ([]types.ResourceContent, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.cache[uri]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(p.cache, uri)
+		return nil, false
+	}
+	return entry.contents, true
+}
+
+// store caches contents, making room by dropping the expired entries, then
+// those expiring first
+func (p *Provider) store(uri string, contents []types.ResourceContent) {
+	if p.ttl <= 0 || p.cacheEntries <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.cache[uri]; !ok && len(p.cache) >= p.cacheEntries {
+		now := time.Now()
+		for key, entry := range p.cache {
+			if now.After(entry.expires) {
+				delete(p.cache, key)
+			}
+		}
+		for len(p.cache) >= p.cacheEntries {
+			var oldest string
+			for key, entry := range p.cache {
+				if oldest == "" || entry.expires.Before(p.cache[oldest].expires) {
+					oldest = key
+				}
+			}
+			delete(p.cache, oldest)
+		}
+	}
+	p.cache[uri] = cacheEntry{contents: contents, expires: time.Now().Add(p.ttl)}
+}
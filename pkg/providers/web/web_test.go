@@ -0,0 +1,172 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/providers/web"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestProvider_Read(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch r.URL.Path {
+		case "/docs/page.txt":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("hello"))
+		case "/docs/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G'})
+		case "/docs/big.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("x", 64)))
+		case "/docs/escape":
+			http.Redirect(w, r, "/private/secret", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	p := web.NewProvider([]string{upstream.URL + "/docs/"}, web.WithMaxSize(32))
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantText string
+		wantBlob bool
+		wantErr  bool
+	}{
+		{name: "text resource", path: "/docs/page.txt", wantText: "hello"},
+		{name: "binary resource", path: "/docs/logo.png", wantBlob: true},
+		{name: "too large", path: "/docs/big.txt", wantErr: true},
+		{name: "not found upstream", path: "/docs/missing", wantErr: true},
+		{name: "outside allowlist", path: "/private/secret", wantErr: true},
+		{name: "redirect outside allowlist", path: "/docs/escape", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents, err := p.Read(ctx, upstream.URL+tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			switch c := contents[0].(type) {
+			case types.TextResourceContents:
+				if tt.wantBlob {
+					t.Fatalf("Expected blob contents, got text")
+				}
+				if c.Text != tt.wantText || c.MimeType != "text/plain" {
+					t.Errorf("Unexpected text contents: %+v", c)
+				}
+			case types.BlobResourceContents:
+				if !tt.wantBlob {
+					t.Fatalf("Expected text contents, got blob")
+				}
+				if c.MimeType != "image/png" {
+					t.Errorf("Unexpected MIME type: %s", c.MimeType)
+				}
+			}
+		})
+	}
+
+	// Cached reads don't reach upstream
+	before := atomic.LoadInt32(&hits)
+	if _, err := p.Read(ctx, upstream.URL+"/docs/page.txt"); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if after := atomic.LoadInt32(&hits); after != before {
+		t.Errorf("Expected cached read, upstream hits went from %d to %d", before, after)
+	}
+
+	p.Invalidate(upstream.URL + "/docs/page.txt")
+	if _, err := p.Read(ctx, upstream.URL+"/docs/page.txt"); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if after := atomic.LoadInt32(&hits); after != before+1 {
+		t.Errorf("Expected upstream fetch after Invalidate, hits went from %d to %d", before, after)
+	}
+}
+
+func TestProvider_Allowlist(t *testing.T) {
+	var outside int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&outside, 1)
+		w.Write([]byte("other"))
+	}))
+	defer other.Close()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs/away":
+			http.Redirect(w, r, other.URL+"/x", http.StatusFound)
+		case "/admin", "/docs2/page":
+			atomic.AddInt32(&outside, 1)
+			w.Write([]byte("secret"))
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer upstream.Close()
+
+	p := web.NewProvider([]string{upstream.URL + "/docs"})
+	ctx := context.Background()
+	host := strings.TrimPrefix(upstream.URL, "http://")
+
+	for _, uri := range []string{
+		upstream.URL + "/docs",
+		upstream.URL + "/docs/a/b",
+		upstream.URL + "/docs/a/../b",
+		"HTTP://" + strings.ToUpper(host) + "/docs/page",
+	} {
+		if _, err := p.Read(ctx, uri); err != nil {
+			t.Errorf("Read(%s) error: %v", uri, err)
+		}
+	}
+	for _, uri := range []string{
+		upstream.URL + "/docs/../admin",
+		upstream.URL + "/docs/%2e%2e/admin",
+		upstream.URL + "/docs2/page",
+		"http://user@" + host + "/docs/page",
+		"http://" + host + ".evil.example/docs/page",
+		"ftp://" + host + "/docs/page",
+		upstream.URL + "/docs/away",
+	} {
+		if _, err := p.Read(ctx, uri); err == nil {
+			t.Errorf("Read(%s) succeeded", uri)
+		}
+	}
+	if n := atomic.LoadInt32(&outside); n != 0 {
+		t.Errorf("%d requests reached URIs outside the allowlist", n)
+	}
+}
+
+func TestProvider_CacheSize(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	p := web.NewProvider([]string{upstream.URL + "/"}, web.WithCacheSize(2))
+	ctx := context.Background()
+	for _, path := range []string{"/a", "/b", "/c", "/c", "/a"} {
+		if _, err := p.Read(ctx, upstream.URL+path); err != nil {
+			t.Fatalf("Read(%s) error: %v", path, err)
+		}
+	}
+	// /a was evicted to make room for /c and fetched again
+	if n := atomic.LoadInt32(&hits); n != 4 {
+		t.Errorf("Upstream hits = %d, want 4", n)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package sandbox
+
+import "fmt"
+
+func limitMemory(maxBytes uint64, name string, args []string) (string, []string, error) {
+	return "", nil, fmt.Errorf("%w: memory limit", ErrUnsupported)
+}
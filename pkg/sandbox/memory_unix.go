@@ -0,0 +1,16 @@
+//go:build unix
+
+package sandbox
+
+import "strconv"
+
+// limitScript sets the address space limit, in KiB, given as $0, and runs
+// the command in the remaining arguments in place of the shell
+const limitScript = `ulimit -v "$0" && exec "$@"`
+
+// limitMemory returns the command running name with args under a limit of
+// maxBytes of address space
+func limitMemory(maxBytes uint64, name string, args []string) (string, []string, error) {
+	kib := (maxBytes + 1023) / 1024
+	return "/bin/sh", append([]string{"-c", limitScript, strconv.FormatUint(kib, 10), name}, args...), nil
+}
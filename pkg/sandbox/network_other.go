@@ -0,0 +1,12 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func denyNetwork(cmd *exec.Cmd) error {
+	return fmt.Errorf("%w: network denial", ErrUnsupported)
+}
@@ -0,0 +1,50 @@
+// Package sandbox runs the subprocesses of tools under their execution
+// policy, see types.ToolExecutionPolicy. Tools that shell out build their
+// commands with Command instead of exec.CommandContext:
+//
+//	cmd, err := sandbox.Command(ctx, "grep", "-r", pattern, dir)
+//
+// The policy comes from ctx, where the server puts it for tools registered
+// with one. The command is killed once ctx ends, which includes the policy's
+// timeout. MaxMemory caps the address space of the process with ulimit -v,
+// and a denied network runs it in a network namespace of its own, with no
+// interfaces up; both are unsupported on some systems, where Command fails
+// rather than run the process unconfined.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// ErrUnsupported is returned by Command for policies the system cannot
+// enforce
+var ErrUnsupported = errors.New("execution policy not supported on this system")
+
+// Command returns a command running name with args under the execution
+// policy in ctx, if any
+func Command(ctx context.Context, name string, args ...string) (*exec.Cmd, error) {
+	policy, _ := types.ExecutionPolicyFromContext(ctx)
+	return CommandWithPolicy(ctx, policy, name, args...)
+}
+
+// CommandWithPolicy returns a command running name with args under policy.
+// Its Timeout is not applied; ctx should carry it.
+func CommandWithPolicy(ctx context.Context, policy types.ToolExecutionPolicy, name string, args ...string) (*exec.Cmd, error) {
+	if policy.MaxMemory > 0 {
+		var err error
+		if name, args, err = limitMemory(policy.MaxMemory, name, args); err != nil {
+			return nil, err
+		}
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	if policy.Network == types.NetworkDenied {
+		if err := denyNetwork(cmd); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	cmd, err := Command(types.WithExecutionPolicy(ctx, types.ToolExecutionPolicy{}), "sleep", "10")
+	if err != nil {
+		t.Fatalf("Command error: %v", err)
+	}
+	start := time.Now()
+	if err := cmd.Run(); err == nil {
+		t.Error("Command outlived its context")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Command was killed after %v", elapsed)
+	}
+}
+
+func TestCommandMaxMemory(t *testing.T) {
+	policy := types.ToolExecutionPolicy{MaxMemory: 64 << 20}
+	cmd, err := CommandWithPolicy(context.Background(), policy, "/bin/sh", "-c", "ulimit -v")
+	if err != nil {
+		t.Fatalf("Command error: %v", err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "65536" {
+		t.Errorf("ulimit -v = %s, want 65536", got)
+	}
+}
+
+func TestCommandNetworkDenied(t *testing.T) {
+	policy := types.ToolExecutionPolicy{Network: types.NetworkDenied}
+	cmd, err := CommandWithPolicy(context.Background(), policy, "/bin/cat", "/proc/net/dev")
+	if err != nil {
+		t.Fatalf("Command error: %v", err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Skipf("Network namespaces unavailable: %v", err)
+	}
+	// A new namespace has only the loopback interface
+	for _, line := range strings.Split(string(out), "\n")[2:] {
+		if name, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && name != "lo" {
+			t.Errorf("Command sees interface %s", name)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore is a types.SubscriptionStore kept in a JSON file, so
+// subscriptions survive restarts. The file is read on every call, so
+// processes on one host sharing it see each other's subscriptions; writes
+// replace the file atomically, but concurrent writes from several processes
+// may lose one another's changes.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore creates a store kept in the file at path, which is created
+// on the first subscription
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Add records that subscriber subscribed to uri
+func (f *FileStore) Add(_ context.Context, uri, subscriber string) error {
+	return f.update(func(subs map[string][]string) {
+		subs[uri] = add(subs[uri], subscriber)
+	})
+}
+
+// Remove deletes the subscription of subscriber to uri
+func (f *FileStore) Remove(_ context.Context, uri, subscriber string) error {
+	return f.update(func(subs map[string][]string) {
+		remove(subs, uri, subscriber)
+	})
+}
+
+// Subscribers returns the subscribers to uri
+func (f *FileStore) Subscribers(_ context.Context, uri string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return subs[uri], nil
+}
+
+// URIs returns the subscribed URIs, sorted
+func (f *FileStore) URIs(_ context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(subs), nil
+}
+
+// update applies change to the stored subscriptions and saves them
+func (f *FileStore) update(change func(subs map[string][]string)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs, err := f.load()
+	if err != nil {
+		return err
+	}
+	change(subs)
+	return f.save(subs)
+}
+
+// load reads the stored subscriptions; a missing file holds none. The caller
+// must hold f.mu.
+func (f *FileStore) load() (map[string][]string, error) {
+	subs := make(map[string][]string)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return subs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subscriptions: %w", err)
+	}
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("failed to parse subscriptions in %s: %w", f.path, err)
+	}
+	return subs, nil
+}
+
+// save writes subs to a temporary file and renames it over the store's file.
+// The caller must hold f.mu.
+func (f *FileStore) save(subs map[string][]string) error {
+	data, err := json.MarshalIndent(subs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save subscriptions: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save subscriptions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save subscriptions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to save subscriptions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Package subscriptions provides stores for the resource subscriptions of a
+// server, see types.SubscriptionStore.
+package subscriptions
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a types.SubscriptionStore backed by a map. It is the
+// default store of a resources server; its subscriptions are lost when the
+// process exits.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	subs map[string][]string // URI -> subscriber IDs
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{subs: make(map[string][]string)}
+}
+
+// Add records that subscriber subscribed to uri
+func (m *MemoryStore) Add(_ context.Context, uri, subscriber string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[uri] = add(m.subs[uri], subscriber)
+	return nil
+}
+
+// Remove deletes the subscription of subscriber to uri
+func (m *MemoryStore) Remove(_ context.Context, uri, subscriber string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	remove(m.subs, uri, subscriber)
+	return nil
+}
+
+// Subscribers returns the subscribers to uri
+func (m *MemoryStore) Subscribers(_ context.Context, uri string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.subs[uri]...), nil
+}
+
+// URIs returns the subscribed URIs, sorted
+func (m *MemoryStore) URIs(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return sortedKeys(m.subs), nil
+}
+
+// add appends subscriber to subscribers unless it is already there
+func add(subscribers []string, subscriber string) []string {
+	for _, s := range subscribers {
+		if s == subscriber {
+			return subscribers
+		}
+	}
+	return append(subscribers, subscriber)
+}
+
+// remove deletes subscriber from the subscribers of uri in subs, and uri once
+// it has none
+func remove(subs map[string][]string, uri, subscriber string) {
+	var kept []string
+	for _, s := range subs[uri] {
+		if s != subscriber {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		delete(subs, uri)
+		return
+	}
+	subs[uri] = kept
+}
+
+func sortedKeys(subs map[string][]string) []string {
+	uris := make([]string, 0, len(subs))
+	for uri := range subs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
@@ -0,0 +1,64 @@
+package subscriptions
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	stores := map[string]func() types.SubscriptionStore{
+		"memory": func() types.SubscriptionStore { return NewMemoryStore() },
+		"file":   func() types.SubscriptionStore { return NewFileStore(path) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore()
+
+			if subs, err := store.Subscribers(ctx, "file:///a.txt"); err != nil || len(subs) != 0 {
+				t.Errorf("Subscribers() of an empty store = %v, %v", subs, err)
+			}
+			for _, sub := range [][2]string{{"file:///b.txt", "one"}, {"file:///a.txt", "one"}, {"file:///a.txt", "two"}} {
+				if err := store.Add(ctx, sub[0], sub[1]); err != nil {
+					t.Fatalf("Add() error: %v", err)
+				}
+			}
+			if subs, err := store.Subscribers(ctx, "file:///a.txt"); err != nil || !reflect.DeepEqual(subs, []string{"one", "two"}) {
+				t.Errorf("Subscribers() = %v, %v", subs, err)
+			}
+			if uris, err := store.URIs(ctx); err != nil || !reflect.DeepEqual(uris, []string{"file:///a.txt", "file:///b.txt"}) {
+				t.Errorf("URIs() = %v, %v", uris, err)
+			}
+
+			// Subscribing again stores nothing new, and unsubscribing one
+			// session leaves the others subscribed
+			if err := store.Add(ctx, "file:///a.txt", "one"); err != nil {
+				t.Fatalf("Add() error: %v", err)
+			}
+			if err := store.Remove(ctx, "file:///a.txt", "two"); err != nil {
+				t.Fatalf("Remove() error: %v", err)
+			}
+			if subs, err := store.Subscribers(ctx, "file:///a.txt"); err != nil || !reflect.DeepEqual(subs, []string{"one"}) {
+				t.Errorf("Subscribers() after Remove() of another = %v, %v", subs, err)
+			}
+
+			if err := store.Remove(ctx, "file:///a.txt", "one"); err != nil {
+				t.Fatalf("Remove() error: %v", err)
+			}
+			if uris, err := store.URIs(ctx); err != nil || !reflect.DeepEqual(uris, []string{"file:///b.txt"}) {
+				t.Errorf("URIs() after Remove() = %v, %v", uris, err)
+			}
+		})
+	}
+
+	// A file store sees the subscriptions saved by another on the same file
+	other := NewFileStore(path)
+	if uris, err := other.URIs(context.Background()); err != nil || !reflect.DeepEqual(uris, []string{"file:///b.txt"}) {
+		t.Errorf("URIs() of a second file store = %v, %v", uris, err)
+	}
+}
@@ -0,0 +1,240 @@
+// Package toolprovider lets a server offer tools contributed by separately
+// built and versioned provider binaries, each isolated in its own process.
+// A provider is an ordinary MCP server on stdio offering tools, so any MCP
+// server can be one; the aggregating server lists its tools with its own and
+// forwards their calls.
+package toolprovider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/pkg/mcp/client"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Provider is a connection to a tool provider
+type Provider struct {
+	client *client.Client
+
+	mu    sync.Mutex
+	tools []types.Tool
+	// changed is called after the tool list changes, see Aggregator
+	changed func()
+}
+
+// Start runs the provider binary at path and lists its tools. The process is
+// stopped by Close.
+func Start(ctx context.Context, path string, opts ...client.Option) (*Provider, error) {
+	c, err := client.NewDefaultClient(ctx, path, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start provider %s: %w", path, err)
+	}
+	if err := c.Initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize provider %s: %w", path, err)
+	}
+	p, err := NewProvider(ctx, c)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("provider %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// NewProvider uses c, an initialized client of a provider reached by other
+// means, such as another transport
+func NewProvider(ctx context.Context, c *client.Client) (*Provider, error) {
+	if !c.SupportsTools() {
+		return nil, types.NewError(types.MethodNotFound, "provider does not offer tools")
+	}
+	p := &Provider{client: c}
+	if err := p.refresh(ctx); err != nil {
+		return nil, err
+	}
+	c.OnToolListChanged(func() {
+		if err := p.refresh(context.Background()); err != nil {
+			return
+		}
+		p.mu.Lock()
+		changed := p.changed
+		p.mu.Unlock()
+		if changed != nil {
+			changed()
+		}
+	})
+	return p, nil
+}
+
+// refresh lists the provider's tools again
+func (p *Provider) refresh(ctx context.Context) error {
+	tools, err := p.client.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+	p.mu.Lock()
+	p.tools = tools
+	p.mu.Unlock()
+	return nil
+}
+
+// Info returns the name and version the provider reported
+func (p *Provider) Info() types.Implementation {
+	return p.client.ServerInfo()
+}
+
+// Tools returns the provider's tools, whose calls are forwarded to it
+func (p *Provider) Tools() []types.McpTool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tools := make([]types.McpTool, len(p.tools))
+	for i, def := range p.tools {
+		tools[i] = &remoteTool{def: def, client: p.client}
+	}
+	return tools
+}
+
+// Done is closed once the connection to the provider ends, as when its
+// process exits
+func (p *Provider) Done() <-chan struct{} {
+	return p.client.Done()
+}
+
+// Close ends the connection, stopping a process started by Start
+func (p *Provider) Close() error {
+	return p.client.Close()
+}
+
+// remoteTool is a tool of a provider
+type remoteTool struct {
+	def    types.Tool
+	client *client.Client
+}
+
+func (t *remoteTool) GetName() string           { return t.def.Name }
+func (t *remoteTool) GetDescription() string    { return t.def.Description }
+func (t *remoteTool) GetDefinition() types.Tool { return t.def }
+
+func (t *remoteTool) GetHandler() types.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*types.CallToolResult, error) {
+		return t.client.CallTool(ctx, t.def.Name, arguments)
+	}
+}
+
+// Aggregator sets a server's tools to its own followed by those of its
+// providers, updating them as providers come, go, and change their tools.
+// Tool names must be unique: Add refuses a provider whose tools clash with
+// those offered, and a clashing tool that a provider adds later is left out.
+type Aggregator struct {
+	server *server.Server
+	own    []types.McpTool
+
+	mu        sync.Mutex
+	providers []*Provider
+
+	// updateMu keeps updates in order
+	updateMu sync.Mutex
+}
+
+// NewAggregator creates an aggregator for s, which must have been created
+// with server.WithTools(own...), offering own as well as the providers' tools
+func NewAggregator(s *server.Server, own ...types.McpTool) *Aggregator {
+	return &Aggregator{server: s, own: own}
+}
+
+// Add offers the tools of p. p is removed once its connection ends.
+func (a *Aggregator) Add(ctx context.Context, p *Provider) error {
+	a.mu.Lock()
+	names := make(map[string]bool)
+	for _, tool := range a.toolsLocked() {
+		names[tool.GetName()] = true
+	}
+	for _, tool := range p.Tools() {
+		if names[tool.GetName()] {
+			a.mu.Unlock()
+			return fmt.Errorf("provider %s: tool %s is already offered", p.Info().Name, tool.GetName())
+		}
+	}
+	a.providers = append(a.providers, p)
+	a.mu.Unlock()
+
+	p.mu.Lock()
+	p.changed = func() { a.update(context.Background()) }
+	p.mu.Unlock()
+	go func() {
+		<-p.Done()
+		a.Remove(context.Background(), p)
+	}()
+	return a.update(ctx)
+}
+
+// Remove withdraws the tools of p without closing it
+func (a *Aggregator) Remove(ctx context.Context, p *Provider) error {
+	a.mu.Lock()
+	found := false
+	for i, added := range a.providers {
+		if added == p {
+			a.providers = append(a.providers[:i:i], a.providers[i+1:]...)
+			found = true
+			break
+		}
+	}
+	a.mu.Unlock()
+	if !found {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.changed = nil
+	p.mu.Unlock()
+	return a.update(ctx)
+}
+
+// Providers returns the providers added, in order
+func (a *Aggregator) Providers() []*Provider {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*Provider(nil), a.providers...)
+}
+
+// Close closes every provider
+func (a *Aggregator) Close() error {
+	var firstErr error
+	for _, p := range a.Providers() {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// update sets the server's tools
+func (a *Aggregator) update(ctx context.Context) error {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	a.mu.Lock()
+	tools := a.toolsLocked()
+	a.mu.Unlock()
+	return a.server.SetTools(ctx, tools)
+}
+
+// toolsLocked returns the aggregated tools, leaving out those whose names
+// were taken earlier. The caller must hold a.mu.
+func (a *Aggregator) toolsLocked() []types.McpTool {
+	tools := append([]types.McpTool(nil), a.own...)
+	names := make(map[string]bool)
+	for _, tool := range a.own {
+		names[tool.GetName()] = true
+	}
+	for _, p := range a.providers {
+		for _, tool := range p.Tools() {
+			if !names[tool.GetName()] {
+				names[tool.GetName()] = true
+				tools = append(tools, tool)
+			}
+		}
+	}
+	return tools
+}
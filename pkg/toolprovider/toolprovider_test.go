@@ -0,0 +1,131 @@
+package toolprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/mock"
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/mcp/client"
+	"github.com/dwrtz/mcp-go/pkg/mcp/server"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+type textInput struct {
+	Text string `json:"text" jsonschema:"required"`
+}
+
+func textTool(name, prefix string) types.McpTool {
+	return types.NewTool(name, "Returns its text with a prefix", func(ctx context.Context, in textInput) (*types.CallToolResult, error) {
+		return &types.CallToolResult{Content: []interface{}{types.TextContent{Type: "text", Text: prefix + in.Text}}}, nil
+	})
+}
+
+// connect starts s and returns an initialized client of it
+func connect(t *testing.T, ctx context.Context, opts ...server.Option) (*server.Server, *client.Client) {
+	t.Helper()
+	logger := testutil.NewTestLogger(t)
+	serverTransport, clientTransport := mock.NewMockPipeTransports(logger)
+	s := server.NewServer(serverTransport, append([]server.Option{server.WithLogger(logger)}, opts...)...)
+	c := client.NewClient(clientTransport, client.WithLogger(logger))
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		s.Close()
+	})
+	return s, c
+}
+
+func toolNames(t *testing.T, ctx context.Context, c *client.Client) []string {
+	t.Helper()
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools error: %v", err)
+	}
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return names
+}
+
+func waitForTools(t *testing.T, ctx context.Context, c *client.Client, want ...string) {
+	t.Helper()
+	for {
+		got := toolNames(t, ctx, c)
+		if len(got) == len(want) {
+			match := true
+			for i := range got {
+				match = match && got[i] == want[i]
+			}
+			if match {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Tools = %v, want %v", got, want)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	own := textTool("own", "own: ")
+	hub, front := connect(t, ctx, server.WithTools(own))
+	agg := NewAggregator(hub, own)
+
+	providerServer, providerClient := connect(t, ctx, server.WithTools(textTool("upper", "provider: ")))
+	p, err := NewProvider(ctx, providerClient)
+	if err != nil {
+		t.Fatalf("NewProvider error: %v", err)
+	}
+	if err := agg.Add(ctx, p); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+	waitForTools(t, ctx, front, "own", "upper")
+
+	// Calls are forwarded to the provider
+	result, err := front.CallTool(ctx, "upper", map[string]interface{}{"text": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool error: %v", err)
+	}
+	if text := result.Content[0].(map[string]interface{})["text"]; text != "provider: hi" {
+		t.Errorf("Result = %v, want the provider's", text)
+	}
+
+	// A provider clashing with the tools offered is refused
+	_, clashClient := connect(t, ctx, server.WithTools(textTool("own", "clash: ")))
+	clash, err := NewProvider(ctx, clashClient)
+	if err != nil {
+		t.Fatalf("NewProvider error: %v", err)
+	}
+	if err := agg.Add(ctx, clash); err == nil {
+		t.Error("Provider with a clashing tool was added")
+	}
+
+	// Changes to the provider's tools are followed
+	if err := providerServer.SetTools(ctx, []types.McpTool{textTool("upper", "p: "), textTool("lower", "p: ")}); err != nil {
+		t.Fatalf("SetTools error: %v", err)
+	}
+	waitForTools(t, ctx, front, "own", "upper", "lower")
+
+	// The tools go once the provider does
+	p.Close()
+	waitForTools(t, ctx, front, "own")
+	if n := len(agg.Providers()); n != 0 {
+		t.Errorf("%d providers left, want 0", n)
+	}
+}
@@ -0,0 +1,308 @@
+// Package tools provides ready-made tools for servers
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/sandbox"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Defaults of a CommandTool
+const (
+	DefaultCommandTimeout   = 30 * time.Second
+	DefaultCommandMaxOutput = 64 << 10
+)
+
+// CommandTool is a tool running a command, see NewCommandTool
+type CommandTool struct {
+	name        string
+	description string
+	argv        []string
+	params      params
+
+	allowed   []string
+	dir       string
+	maxOutput int
+	policy    types.ToolExecutionPolicy
+}
+
+// CommandOption configures a CommandTool
+type CommandOption func(*CommandTool)
+
+// WithAllowedExecutables restricts the command to the executables given,
+// by name or path, which lets the template's first element hold
+// placeholders. Names are looked up in PATH when each call runs, and
+// relative paths are taken from the command's directory.
+func WithAllowedExecutables(executables ...string) CommandOption {
+	return func(t *CommandTool) {
+		t.allowed = append(t.allowed, executables...)
+	}
+}
+
+// WithParameterDescription describes the argument name in the input schema
+func WithParameterDescription(name, description string) CommandOption {
+	return func(t *CommandTool) {
+		if p := t.params.find(name); p != nil {
+			p.description = description
+		}
+	}
+}
+
+// WithCommandDir runs the command in dir
+func WithCommandDir(dir string) CommandOption {
+	return func(t *CommandTool) {
+		t.dir = dir
+	}
+}
+
+// WithMaxOutput keeps at most maxBytes of each of the command's stdout and
+// stderr
+func WithMaxOutput(maxBytes int) CommandOption {
+	return func(t *CommandTool) {
+		t.maxOutput = maxBytes
+	}
+}
+
+// WithCommandTimeout kills the command after timeout
+func WithCommandTimeout(timeout time.Duration) CommandOption {
+	return func(t *CommandTool) {
+		t.policy.Timeout = timeout
+	}
+}
+
+// WithCommandPolicy runs the command under policy, which replaces the
+// timeout unless it sets none
+func WithCommandPolicy(policy types.ToolExecutionPolicy) CommandOption {
+	return func(t *CommandTool) {
+		if policy.Timeout == 0 {
+			policy.Timeout = t.policy.Timeout
+		}
+		t.policy = policy
+	}
+}
+
+// NewCommandTool creates a tool running the command argv. Each element of
+// argv may hold placeholders {name}, replaced by the string argument name,
+// which is required; an element that is only {name?} is left out when the
+// optional argument name is missing or empty. The tool's input schema lists
+// the arguments named.
+//
+// The command runs without a shell, each element being one argument whatever
+// it holds, and an element may begin with "-" once filled in only if the
+// template's does, so values cannot pass as an option. The first element names the
+// executable and may only hold placeholders with WithAllowedExecutables.
+// Calls run for at most DefaultCommandTimeout and keep
+// DefaultCommandMaxOutput bytes of output, unless set otherwise; a command
+// failing or timing out gives an error result with its output.
+func NewCommandTool(name, description string, argv []string, opts ...CommandOption) (*CommandTool, error) {
+	if len(argv) == 0 {
+		return nil, errors.New("command tool needs a command")
+	}
+	for _, arg := range argv {
+		for _, m := range placeholder.FindAllStringSubmatch(arg, -1) {
+			if m[2] == "?" && m[0] != arg {
+				return nil, fmt.Errorf("optional argument %s must be a whole element", m[1])
+			}
+		}
+	}
+	t := &CommandTool{
+		name:        name,
+		description: description,
+		argv:        argv,
+		params:      parseParams(argv...),
+		maxOutput:   DefaultCommandMaxOutput,
+		policy:      types.ToolExecutionPolicy{Timeout: DefaultCommandTimeout},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if len(t.allowed) == 0 {
+		if placeholder.MatchString(argv[0]) {
+			return nil, errors.New("templated executable needs allowed executables")
+		}
+	} else if !placeholder.MatchString(argv[0]) && !t.isAllowed(argv[0]) {
+		return nil, fmt.Errorf("executable %s is not allowed", argv[0])
+	}
+	return t, nil
+}
+
+// GetName implements types.McpTool
+func (t *CommandTool) GetName() string {
+	return t.name
+}
+
+// GetDescription implements types.McpTool
+func (t *CommandTool) GetDescription() string {
+	return t.description
+}
+
+// GetDefinition implements types.McpTool, with a string property for each
+// argument of the template
+func (t *CommandTool) GetDefinition() types.Tool {
+	return types.Tool{
+		Name:        t.name,
+		Description: t.description,
+		InputSchema: t.params.schema(),
+	}
+}
+
+// ExecutionPolicy implements types.PolicyTool
+func (t *CommandTool) ExecutionPolicy() *types.ToolExecutionPolicy {
+	policy := t.policy
+	return &policy
+}
+
+// GetHandler implements types.McpTool
+func (t *CommandTool) GetHandler() types.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*types.CallToolResult, error) {
+		argv, err := t.expand(arguments)
+		if err != nil {
+			return nil, types.NewError(types.InvalidParams, err.Error())
+		}
+		if len(t.allowed) > 0 && !t.isAllowed(argv[0]) {
+			return nil, types.NewError(types.InvalidParams, fmt.Sprintf("executable %s is not allowed", argv[0]))
+		}
+
+		if t.policy.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t.policy.Timeout)
+			defer cancel()
+		}
+		cmd, err := sandbox.CommandWithPolicy(ctx, t.policy, argv[0], argv[1:]...)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Dir = t.dir
+		stdout := &limitedBuffer{max: t.maxOutput}
+		stderr := &limitedBuffer{max: t.maxOutput}
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+
+		runErr := cmd.Run()
+		text := stdout.String()
+		if stderr.Len() > 0 {
+			text = appendLine(text, "stderr:\n"+stderr.String())
+		}
+
+		result := &types.CallToolResult{}
+		var exitErr *exec.ExitError
+		switch {
+		case runErr == nil:
+		case ctx.Err() == context.DeadlineExceeded:
+			result.IsError = true
+			text = appendLine(text, fmt.Sprintf("command timed out after %s", t.policy.Timeout))
+		case errors.As(runErr, &exitErr):
+			result.IsError = true
+			text = appendLine(text, fmt.Sprintf("command failed: %v", runErr))
+		default:
+			return nil, fmt.Errorf("failed to run %s: %w", argv[0], runErr)
+		}
+		result.Content = []interface{}{types.TextContent{Type: "text", Text: text}}
+		return result, nil
+	}
+}
+
+// expand fills the template with arguments
+func (t *CommandTool) expand(arguments map[string]interface{}) ([]string, error) {
+	values, err := t.params.values(arguments)
+	if err != nil {
+		return nil, err
+	}
+	argv := make([]string, 0, len(t.argv))
+	for _, arg := range t.argv {
+		if m := placeholder.FindStringSubmatch(arg); m != nil && m[0] == arg && m[2] == "?" && values[m[1]] == "" {
+			continue
+		}
+		expanded, err := expand(arg, values, func(name, value string, offset int) (string, error) {
+			return value, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(expanded, "-") && !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("arguments may not make %s begin with -", arg)
+		}
+		argv = append(argv, expanded)
+	}
+	if argv[0] == "" {
+		return nil, errors.New("empty executable")
+	}
+	return argv, nil
+}
+
+// isAllowed reports whether executable is one of those allowed, comparing
+// the paths that names are found at
+func (t *CommandTool) isAllowed(executable string) bool {
+	path, err := resolve(executable, t.dir)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range t.allowed {
+		if p, err := resolve(allowed, t.dir); err == nil && p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the absolute path of the executable, looking names up in
+// PATH and taking relative paths from dir, as the command runs there
+func resolve(executable, dir string) (string, error) {
+	if dir != "" && !filepath.IsAbs(executable) && filepath.Base(executable) != executable {
+		executable = filepath.Join(dir, executable)
+	}
+	path, err := exec.LookPath(executable)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// appendLine appends line to text on a line of its own
+func appendLine(text, line string) string {
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text + line
+}
+
+// limitedBuffer keeps the first max bytes written to it and counts the rest
+type limitedBuffer struct {
+	buf     bytes.Buffer
+	max     int
+	dropped int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	keep := b.max - b.buf.Len()
+	if keep < 0 {
+		keep = 0
+	}
+	if keep > len(p) {
+		keep = len(p)
+	}
+	b.buf.Write(p[:keep])
+	b.dropped += len(p) - keep
+	return len(p), nil
+}
+
+// Len returns the number of bytes written
+func (b *limitedBuffer) Len() int {
+	return b.buf.Len() + b.dropped
+}
+
+// String returns the bytes kept, noting how many were dropped
+func (b *limitedBuffer) String() string {
+	if b.dropped == 0 {
+		return b.buf.String()
+	}
+	return appendLine(b.buf.String(), fmt.Sprintf("[truncated %d bytes]", b.dropped))
+}
@@ -0,0 +1,143 @@
+//go:build unix
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func call(t *testing.T, tool *CommandTool, arguments map[string]interface{}) (*types.CallToolResult, error) {
+	t.Helper()
+	return tool.GetHandler()(context.Background(), arguments)
+}
+
+func TestCommandTool(t *testing.T) {
+	tool, err := NewCommandTool("echo", "Echoes", []string{"echo", "{greeting},", "{name}", "{suffix?}"},
+		WithParameterDescription("name", "Who to greet"))
+	if err != nil {
+		t.Fatalf("NewCommandTool error: %v", err)
+	}
+
+	def := tool.GetDefinition()
+	if len(def.InputSchema.Properties) != 3 || strings.Join(def.InputSchema.Required, ",") != "greeting,name" {
+		t.Errorf("Input schema = %+v", def.InputSchema)
+	}
+	if prop := def.InputSchema.Properties["name"].(map[string]interface{}); prop["description"] != "Who to greet" {
+		t.Errorf("name property = %v", prop)
+	}
+
+	result, err := call(t, tool, map[string]interface{}{"greeting": "hello", "name": "world; rm -rf /"})
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if result.IsError || text(result) != "hello, world; rm -rf /\n" {
+		t.Errorf("Result = %+v", result)
+	}
+
+	result, err = call(t, tool, map[string]interface{}{"greeting": "hi", "name": "you", "suffix": "!"})
+	if err != nil || text(result) != "hi, you !\n" {
+		t.Errorf("Result with optional argument = %+v, %v", result, err)
+	}
+
+	if _, err := call(t, tool, map[string]interface{}{"greeting": "hi"}); err == nil {
+		t.Error("Call without a required argument succeeded")
+	}
+	if _, err := call(t, tool, map[string]interface{}{"greeting": "hi", "name": 3}); err == nil {
+		t.Error("Call with a non-string argument succeeded")
+	}
+	if _, err := call(t, tool, map[string]interface{}{"greeting": "hi", "name": "-n"}); err == nil {
+		t.Error("Argument passing as an option was accepted")
+	}
+
+	joined, err := NewCommandTool("join", "Joins", []string{"echo", "{a}{b}", "--", "x{a}"})
+	if err != nil {
+		t.Fatalf("NewCommandTool error: %v", err)
+	}
+	if _, err := call(t, joined, map[string]interface{}{"a": "", "b": "-rf"}); err == nil {
+		t.Error("Arguments joined into an option were accepted")
+	}
+	if result, err := call(t, joined, map[string]interface{}{"a": "a", "b": "-rf"}); err != nil || text(result) != "a-rf -- xa\n" {
+		t.Errorf("Call with - inside an element = %+v, %v", result, err)
+	}
+}
+
+func TestCommandToolFailure(t *testing.T) {
+	tool, err := NewCommandTool("fail", "Fails", []string{"/bin/sh", "-c", "echo out; echo err >&2; exit 3"})
+	if err != nil {
+		t.Fatalf("NewCommandTool error: %v", err)
+	}
+	result, err := call(t, tool, nil)
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if !result.IsError || text(result) != "out\nstderr:\nerr\ncommand failed: exit status 3" {
+		t.Errorf("Result = %q, IsError %v", text(result), result.IsError)
+	}
+}
+
+func TestCommandToolLimits(t *testing.T) {
+	tool, err := NewCommandTool("big", "Prints a lot", []string{"/bin/sh", "-c", "printf 0123456789"},
+		WithMaxOutput(4))
+	if err != nil {
+		t.Fatalf("NewCommandTool error: %v", err)
+	}
+	result, err := call(t, tool, nil)
+	if err != nil || text(result) != "0123\n[truncated 6 bytes]" {
+		t.Errorf("Truncated result = %+v, %v", result, err)
+	}
+
+	tool, err = NewCommandTool("slow", "Sleeps", []string{"sleep", "10"}, WithCommandTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewCommandTool error: %v", err)
+	}
+	if p := tool.ExecutionPolicy(); p.Timeout != 50*time.Millisecond {
+		t.Errorf("Policy timeout = %v", p.Timeout)
+	}
+	result, err = call(t, tool, nil)
+	if err != nil || !result.IsError || text(result) != "command timed out after 50ms" {
+		t.Errorf("Timed out result = %+v, %v", result, err)
+	}
+}
+
+func TestCommandToolAllowlist(t *testing.T) {
+	if _, err := NewCommandTool("run", "Runs", []string{"{program}"}); err == nil {
+		t.Error("Templated executable without an allowlist was accepted")
+	}
+	if _, err := NewCommandTool("run", "Runs", []string{"cat"}, WithAllowedExecutables("echo")); err == nil {
+		t.Error("Executable outside the allowlist was accepted")
+	}
+
+	tool, err := NewCommandTool("run", "Runs", []string{"{program}", "ok"}, WithAllowedExecutables("echo", "true"))
+	if err != nil {
+		t.Fatalf("NewCommandTool error: %v", err)
+	}
+	if result, err := call(t, tool, map[string]interface{}{"program": "echo"}); err != nil || text(result) != "ok\n" {
+		t.Errorf("Allowed call = %+v, %v", result, err)
+	}
+	if _, err := call(t, tool, map[string]interface{}{"program": "rm"}); err == nil {
+		t.Error("Executable outside the allowlist was run")
+	}
+
+	// Relative paths are those of the command's directory
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\necho ran\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tool, err = NewCommandTool("run", "Runs", []string{"{program}"}, WithAllowedExecutables("./run.sh"), WithCommandDir(dir))
+	if err != nil {
+		t.Fatalf("NewCommandTool error: %v", err)
+	}
+	if result, err := call(t, tool, map[string]interface{}{"program": "./run.sh"}); err != nil || text(result) != "ran\n" {
+		t.Errorf("Allowed call in the command's directory = %+v, %v", result, err)
+	}
+	if _, err := call(t, tool, map[string]interface{}{"program": "./command_test.go"}); err == nil {
+		t.Error("Executable outside the command's directory was run")
+	}
+}
@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Defaults of an HTTPTool
+const (
+	DefaultHTTPTimeout     = 30 * time.Second
+	DefaultHTTPMaxResponse = 64 << 10
+)
+
+// HTTPRequest is the template of the request an HTTPTool makes. Any field
+// but Method may hold placeholders, see NewHTTPTool.
+type HTTPRequest struct {
+	// Method defaults to GET
+	Method string
+	URL    string
+	Header map[string]string
+	Body   string
+}
+
+// HTTPTool is a tool making an HTTP request, see NewHTTPTool
+type HTTPTool struct {
+	name        string
+	description string
+	req         HTTPRequest
+	params      params
+
+	allowedHosts []string
+	client       *http.Client
+	maxResponse  int
+	timeout      time.Duration
+}
+
+// HTTPOption configures an HTTPTool
+type HTTPOption func(*HTTPTool)
+
+// WithHTTPParameter sets the JSON Schema type of the argument name,
+// "string", "number", "integer" or "boolean", and describes it in the input
+// schema
+func WithHTTPParameter(name, typ, description string) HTTPOption {
+	return func(t *HTTPTool) {
+		if p := t.params.find(name); p != nil {
+			p.typ = typ
+			p.description = description
+		}
+	}
+}
+
+// WithAllowedHosts restricts requests, redirects included, to the hosts
+// given, which lets the URL template's host hold placeholders. A host
+// "*.example.com" allows the subdomains of example.com; a host with a port
+// only allows that port.
+func WithAllowedHosts(hosts ...string) HTTPOption {
+	return func(t *HTTPTool) {
+		t.allowedHosts = append(t.allowedHosts, hosts...)
+	}
+}
+
+// WithMaxResponse keeps at most maxBytes of the response body
+func WithMaxResponse(maxBytes int) HTTPOption {
+	return func(t *HTTPTool) {
+		t.maxResponse = maxBytes
+	}
+}
+
+// WithHTTPTimeout fails requests taking longer than timeout
+func WithHTTPTimeout(timeout time.Duration) HTTPOption {
+	return func(t *HTTPTool) {
+		t.timeout = timeout
+	}
+}
+
+// WithHTTPClient makes requests with client instead of a default client
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(t *HTTPTool) {
+		t.client = client
+	}
+}
+
+// NewHTTPTool creates a tool making the request req. The URL, header values
+// and body may hold placeholders {name}, replaced by the argument name,
+// which is required, or {name?} for an optional one replaced by nothing when
+// missing. Arguments are strings unless WithHTTPParameter says otherwise,
+// and the tool's input schema lists them.
+//
+// Values are escaped for where they go: as a path segment or query
+// component in the URL, as JSON string content in a JSON body and as a
+// form value in a form body. Header values may not hold line breaks. The
+// URL's host may only hold placeholders with WithAllowedHosts; without it,
+// requests and redirects are limited to that host. Calls time out after
+// DefaultHTTPTimeout and keep DefaultHTTPMaxResponse bytes of the response,
+// unless set otherwise. A failed request or an error status gives an error
+// result.
+func NewHTTPTool(name, description string, req HTTPRequest, opts ...HTTPOption) (*HTTPTool, error) {
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	templates := []string{req.URL, req.Body}
+	for _, v := range req.Header {
+		templates = append(templates, v)
+	}
+	t := &HTTPTool{
+		name:        name,
+		description: description,
+		req:         req,
+		params:      parseParams(templates...),
+		maxResponse: DefaultHTTPMaxResponse,
+		timeout:     DefaultHTTPTimeout,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	for _, p := range t.params {
+		if _, ok := format(p.typ, zero(p.typ)); !ok {
+			return nil, fmt.Errorf("argument %s has unknown type %s", p.name, p.typ)
+		}
+	}
+
+	scheme, rest, ok := strings.Cut(req.URL, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return nil, fmt.Errorf("URL %s is not http or https", req.URL)
+	}
+	host := rest
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if placeholder.MatchString(host) {
+		if len(t.allowedHosts) == 0 {
+			return nil, errors.New("templated host needs allowed hosts")
+		}
+	} else if len(t.allowedHosts) == 0 {
+		t.allowedHosts = []string{host}
+	} else if !t.isAllowed(&url.URL{Host: host}) {
+		return nil, fmt.Errorf("host %s is not allowed", host)
+	}
+
+	client := http.DefaultClient
+	if t.client != nil {
+		client = t.client
+	}
+	// The client is copied to check redirects without changing the original
+	c := *client
+	c.CheckRedirect = func(r *http.Request, via []*http.Request) error {
+		if !t.isAllowed(r.URL) {
+			return fmt.Errorf("redirect to host %s is not allowed", r.URL.Host)
+		}
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(r, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	t.client = &c
+	return t, nil
+}
+
+// zero returns a value of the JSON Schema type typ, as decoded from JSON
+func zero(typ string) interface{} {
+	switch typ {
+	case "number", "integer":
+		return float64(0)
+	case "boolean":
+		return false
+	}
+	return ""
+}
+
+// GetName implements types.McpTool
+func (t *HTTPTool) GetName() string {
+	return t.name
+}
+
+// GetDescription implements types.McpTool
+func (t *HTTPTool) GetDescription() string {
+	return t.description
+}
+
+// GetDefinition implements types.McpTool, with a property for each argument
+// of the template
+func (t *HTTPTool) GetDefinition() types.Tool {
+	return types.Tool{
+		Name:        t.name,
+		Description: t.description,
+		InputSchema: t.params.schema(),
+	}
+}
+
+// ExecutionPolicy implements types.PolicyTool
+func (t *HTTPTool) ExecutionPolicy() *types.ToolExecutionPolicy {
+	return &types.ToolExecutionPolicy{Timeout: t.timeout}
+}
+
+// GetHandler implements types.McpTool
+func (t *HTTPTool) GetHandler() types.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*types.CallToolResult, error) {
+		if t.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t.timeout)
+			defer cancel()
+		}
+		req, err := t.newRequest(ctx, arguments)
+		if err != nil {
+			return nil, types.NewError(types.InvalidParams, err.Error())
+		}
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return errorResult(fmt.Sprintf("request timed out after %s", t.timeout)), nil
+			}
+			return errorResult(fmt.Sprintf("request failed: %v", err)), nil
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, int64(t.maxResponse)+1))
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to read response: %v", err)), nil
+		}
+		text := string(body)
+		if len(body) > t.maxResponse {
+			text = appendLine(string(body[:t.maxResponse]), fmt.Sprintf("[truncated after %d bytes]", t.maxResponse))
+		}
+		if resp.StatusCode >= 400 {
+			return errorResult(appendLine("HTTP "+resp.Status, text)), nil
+		}
+		return &types.CallToolResult{
+			Content: []interface{}{types.TextContent{Type: "text", Text: text}},
+		}, nil
+	}
+}
+
+// newRequest fills the template with arguments
+func (t *HTTPTool) newRequest(ctx context.Context, arguments map[string]interface{}) (*http.Request, error) {
+	values, err := t.params.values(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.IndexByte(t.req.URL, '?')
+	rawURL, err := expand(t.req.URL, values, func(name, value string, offset int) (string, error) {
+		if query >= 0 && offset > query {
+			return url.QueryEscape(value), nil
+		}
+		return url.PathEscape(value), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if !t.isAllowed(u) {
+		return nil, fmt.Errorf("host %s is not allowed", u.Host)
+	}
+
+	header := make(http.Header)
+	for key, tmpl := range t.req.Header {
+		value, err := expand(tmpl, values, func(name, value string, offset int) (string, error) {
+			if strings.ContainsAny(value, "\r\n") {
+				return "", fmt.Errorf("argument %s may not hold line breaks", name)
+			}
+			return value, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		header.Set(key, value)
+	}
+
+	var body io.Reader
+	if t.req.Body != "" {
+		escape := escapeBody(header.Get("Content-Type"))
+		data, err := expand(t.req.Body, values, func(name, value string, offset int) (string, error) {
+			return escape(value), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, t.req.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	return req, nil
+}
+
+// escapeBody returns how values are escaped in a body of contentType
+func escapeBody(contentType string) func(string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return func(s string) string {
+			data, _ := json.Marshal(s)
+			return string(data[1 : len(data)-1])
+		}
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		return url.QueryEscape
+	}
+	return func(s string) string { return s }
+}
+
+// isAllowed reports whether the host of u is allowed
+func (t *HTTPTool) isAllowed(u *url.URL) bool {
+	hostname := strings.ToLower(u.Hostname())
+	for _, allowed := range t.allowedHosts {
+		allowed = strings.ToLower(allowed)
+		host := hostname
+		if strings.Contains(allowed, ":") {
+			host = strings.ToLower(u.Host)
+		}
+		if host == allowed {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorResult returns an error result with text
+func errorResult(text string) *types.CallToolResult {
+	return &types.CallToolResult{
+		Content: []interface{}{types.TextContent{Type: "text", Text: text}},
+		IsError: true,
+	}
+}
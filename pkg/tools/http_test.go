@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// text returns the text of a result from a handler
+func text(result *types.CallToolResult) string {
+	return result.Content[0].(types.TextContent).Text
+}
+
+func callHTTP(t *testing.T, tool *HTTPTool, arguments map[string]interface{}) (*types.CallToolResult, error) {
+	t.Helper()
+	return tool.GetHandler()(context.Background(), arguments)
+}
+
+func TestHTTPTool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + r.URL.EscapedPath() + "?" + r.URL.RawQuery + " " + r.Header.Get("X-Token") + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	tool, err := NewHTTPTool("post", "Posts", HTTPRequest{
+		Method: http.MethodPost,
+		URL:    srv.URL + "/items/{id}?q={query}&limit={limit?}",
+		Header: map[string]string{"Content-Type": "application/json", "X-Token": "{token}"},
+		Body:   `{"note":"{note}"}`,
+	}, WithHTTPParameter("id", "integer", "Item ID"))
+	if err != nil {
+		t.Fatalf("NewHTTPTool error: %v", err)
+	}
+
+	def := tool.GetDefinition()
+	if strings.Join(def.InputSchema.Required, ",") != "id,query,note,token" {
+		t.Errorf("Required = %v", def.InputSchema.Required)
+	}
+	if prop := def.InputSchema.Properties["id"].(map[string]interface{}); prop["type"] != "integer" || prop["description"] != "Item ID" {
+		t.Errorf("id property = %v", prop)
+	}
+
+	result, err := callHTTP(t, tool, map[string]interface{}{
+		"id": float64(7), "query": "a&b=c", "note": `say "hi"`, "token": "secret",
+	})
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	want := `POST /items/7?q=a%26b%3Dc&limit= secret {"note":"say \"hi\""}`
+	if result.IsError || text(result) != want {
+		t.Errorf("Result = %q, want %q", text(result), want)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"id": 1.5, "query": "", "note": "", "token": ""},
+		{"id": float64(1), "note": "", "token": ""},
+		{"id": float64(1), "query": "", "note": "", "token": "x\r\nX-Evil: 1"},
+	} {
+		if _, err := callHTTP(t, tool, args); err == nil {
+			t.Errorf("Call with %v succeeded", args)
+		}
+	}
+}
+
+func TestHTTPToolErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.Error(w, "no such thing", http.StatusNotFound)
+		case "/big":
+			w.Write([]byte("0123456789"))
+		case "/slow":
+			<-r.Context().Done()
+		case "/away":
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	tool, err := NewHTTPTool("get", "Gets", HTTPRequest{URL: srv.URL + "/{path}"},
+		WithMaxResponse(4), WithHTTPTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewHTTPTool error: %v", err)
+	}
+	for path, want := range map[string]string{
+		"missing": "HTTP 404 Not Found\nno s\n[truncated after 4 bytes]",
+		"slow":    "request timed out after 50ms",
+	} {
+		result, err := callHTTP(t, tool, map[string]interface{}{"path": path})
+		if err != nil || !result.IsError || text(result) != want {
+			t.Errorf("%s: result = %+v, %v, want %q", path, result, err, want)
+		}
+	}
+
+	result, err := callHTTP(t, tool, map[string]interface{}{"path": "big"})
+	if err != nil || result.IsError || text(result) != "0123\n[truncated after 4 bytes]" {
+		t.Errorf("big: result = %+v, %v", result, err)
+	}
+
+	result, err = callHTTP(t, tool, map[string]interface{}{"path": "away"})
+	if err != nil || !result.IsError || !strings.Contains(text(result), "not allowed") {
+		t.Errorf("Redirect to another host: result = %+v, %v", result, err)
+	}
+}
+
+func TestHTTPToolHosts(t *testing.T) {
+	if _, err := NewHTTPTool("get", "Gets", HTTPRequest{URL: "https://{host}/"}); err == nil {
+		t.Error("Templated host without allowed hosts was accepted")
+	}
+	if _, err := NewHTTPTool("get", "Gets", HTTPRequest{URL: "file:///etc/passwd"}); err == nil {
+		t.Error("Non-HTTP URL was accepted")
+	}
+	if _, err := NewHTTPTool("get", "Gets", HTTPRequest{URL: "https://example.org/"}, WithAllowedHosts("example.com")); err == nil {
+		t.Error("Host outside the allowlist was accepted")
+	}
+
+	tool, err := NewHTTPTool("get", "Gets", HTTPRequest{URL: "https://{host}/"}, WithAllowedHosts("*.example.com"))
+	if err != nil {
+		t.Fatalf("NewHTTPTool error: %v", err)
+	}
+	if _, err := callHTTP(t, tool, map[string]interface{}{"host": "evil.org"}); err == nil {
+		t.Error("Request to a host outside the allowlist succeeded")
+	}
+	req, err := tool.newRequest(context.Background(), map[string]interface{}{"host": "api.example.com"})
+	if err != nil || req.URL.String() != "https://api.example.com/" {
+		t.Errorf("Request to an allowed host = %v, %v", req, err)
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// placeholder matches {name} in a template, or {name?} for an optional
+// argument
+var placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(\??)\}`)
+
+// param is an argument named in the templates of a tool
+type param struct {
+	name        string
+	typ         string
+	description string
+	optional    bool
+}
+
+// params are the arguments of a tool, in the order they first appear
+type params []param
+
+// parseParams collects the arguments named in templates, which are strings
+// unless set otherwise
+func parseParams(templates ...string) params {
+	var ps params
+	seen := make(map[string]bool)
+	for _, tmpl := range templates {
+		for _, m := range placeholder.FindAllStringSubmatch(tmpl, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				ps = append(ps, param{name: m[1], typ: "string", optional: m[2] == "?"})
+			}
+		}
+	}
+	return ps
+}
+
+// find returns the argument name, or nil
+func (ps params) find(name string) *param {
+	for i := range ps {
+		if ps[i].name == name {
+			return &ps[i]
+		}
+	}
+	return nil
+}
+
+// schema returns the input schema listing the arguments
+func (ps params) schema() types.ToolInputSchema {
+	props := make(map[string]interface{})
+	var required []string
+	for _, p := range ps {
+		prop := map[string]interface{}{"type": p.typ}
+		if p.description != "" {
+			prop["description"] = p.description
+		}
+		props[p.name] = prop
+		if !p.optional {
+			required = append(required, p.name)
+		}
+	}
+	return types.ToolInputSchema{Type: "object", Properties: props, Required: required}
+}
+
+// values checks arguments against the schema and returns them as text.
+// Optional arguments that are missing are left out.
+func (ps params) values(arguments map[string]interface{}) (map[string]string, error) {
+	values := make(map[string]string, len(ps))
+	for _, p := range ps {
+		raw, ok := arguments[p.name]
+		if !ok || raw == nil {
+			if !p.optional {
+				return nil, fmt.Errorf("missing argument %s", p.name)
+			}
+			continue
+		}
+		s, ok := format(p.typ, raw)
+		if !ok {
+			return nil, fmt.Errorf("argument %s must be of type %s", p.name, p.typ)
+		}
+		if strings.ContainsRune(s, 0) {
+			return nil, fmt.Errorf("argument %s holds a NUL byte", p.name)
+		}
+		values[p.name] = s
+	}
+	return values, nil
+}
+
+// format returns the text of v, a value of the JSON Schema type typ, and
+// whether it is of that type
+func format(typ string, v interface{}) (string, bool) {
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		return s, ok
+	case "number":
+		f, ok := v.(float64)
+		return strconv.FormatFloat(f, 'f', -1, 64), ok
+	case "integer":
+		f, ok := v.(float64)
+		return strconv.FormatFloat(f, 'f', -1, 64), ok && f == math.Trunc(f)
+	case "boolean":
+		b, ok := v.(bool)
+		return strconv.FormatBool(b), ok
+	}
+	return "", false
+}
+
+// expand replaces the placeholders in tmpl with values, passed through
+// escape. escape is also given the offset of the placeholder in tmpl.
+func expand(tmpl string, values map[string]string, escape func(name, value string, offset int) (string, error)) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholder.FindAllStringSubmatchIndex(tmpl, -1) {
+		b.WriteString(tmpl[last:loc[0]])
+		name := tmpl[loc[2]:loc[3]]
+		value, err := escape(name, values[name], loc[0])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		last = loc[1]
+	}
+	b.WriteString(tmpl[last:])
+	return b.String(), nil
+}
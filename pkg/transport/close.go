@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+)
+
+// CloseReason says which side ended a transport
+type CloseReason int
+
+const (
+	// NotClosed means the transport is still open
+	NotClosed CloseReason = iota
+	// ClosedLocally means Close or CloseWithError was called on this side
+	ClosedLocally
+	// ClosedByPeer means the peer ended the connection or it failed
+	ClosedByPeer
+)
+
+func (r CloseReason) String() string {
+	switch r {
+	case NotClosed:
+		return "not closed"
+	case ClosedLocally:
+		return "closed locally"
+	case ClosedByPeer:
+		return "closed by peer"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	// ErrClosed is the Err of a transport closed with Close
+	ErrClosed = errors.New("transport closed")
+
+	// ErrPeerClosed is the Err of a transport whose peer ended the
+	// connection cleanly. A peer that crashed or broke the protocol leaves
+	// the error that ended the connection instead.
+	ErrPeerClosed = errors.New("peer closed the connection")
+)
+
+// CloseReporter is implemented by transports that record why they closed.
+// Once Done is closed, DoneReason says which side closed the transport and
+// Err why; before that they return NotClosed and nil.
+type CloseReporter interface {
+	// CloseWithError closes the transport like Close, recording err as the
+	// reason. A nil err is a clean close, reported as ErrClosed.
+	CloseWithError(err error) error
+	DoneReason() CloseReason
+	Err() error
+}
+
+// CloseState records the first reason a transport closed, for implementing
+// CloseReporter. The zero value is ready to use.
+type CloseState struct {
+	mu     sync.Mutex
+	reason CloseReason
+	err    error
+}
+
+// Set records reason and err unless a reason was already recorded, and
+// reports whether it did. A nil err is recorded as ErrClosed or
+// ErrPeerClosed, according to reason.
+func (s *CloseState) Set(reason CloseReason, err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason != NotClosed {
+		return false
+	}
+	if err == nil {
+		err = ErrClosed
+		if reason == ClosedByPeer {
+			err = ErrPeerClosed
+		}
+	}
+	s.reason, s.err = reason, err
+	return true
+}
+
+// Reason returns the recorded reason. A transport whose done channel is
+// closed without a recorded reason is taken to have been closed by its peer.
+func (s *CloseState) Reason(done <-chan struct{}) CloseReason {
+	reason, _ := s.get(done)
+	return reason
+}
+
+// Err returns the recorded error, following the same rules as Reason
+func (s *CloseState) Err(done <-chan struct{}) error {
+	_, err := s.get(done)
+	return err
+}
+
+func (s *CloseState) get(done <-chan struct{}) (CloseReason, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason != NotClosed {
+		return s.reason, s.err
+	}
+	select {
+	case <-done:
+		return ClosedByPeer, ErrPeerClosed
+	default:
+		return NotClosed, nil
+	}
+}
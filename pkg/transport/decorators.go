@@ -0,0 +1,383 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/goroutine"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// relay is the common part of the decorators: it owns the router handed to
+// the client or server and feeds it the messages the wrapped transport
+// receives, passing each through incoming first
+type relay struct {
+	inner  Transport
+	router *MessageRouter
+
+	// incoming sees each received message and routes it, or not, with
+	// deliver
+	incoming func(ctx context.Context, msg *types.Message, deliver func())
+
+	done chan struct{}
+	once sync.Once
+
+	// closed records why a wrapped transport that is not a CloseReporter
+	// closed
+	closed CloseState
+}
+
+func newRelay(inner Transport) relay {
+	// Relayed in arrival order; the relay's own router orders them again
+	// for its consumer if asked to
+	inner.GetRouter().EnableOrdering()
+	return relay{
+		inner:  inner,
+		router: NewMessageRouter(),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start starts the wrapped transport and relays what it receives
+func (r *relay) Start(ctx context.Context) error {
+	if err := r.inner.Start(ctx); err != nil {
+		return err
+	}
+	goroutine.Go("transport/forward", func() { r.forward(ctx) })
+	return nil
+}
+
+// forward moves messages from the wrapped transport's router to ours
+func (r *relay) forward(ctx context.Context) {
+	in := r.inner.GetRouter()
+	for {
+		var msg *types.Message
+		var ok bool
+		select {
+		case msg, ok = <-in.Requests:
+		case msg = <-in.Inbound:
+			ok = true
+		case err, errOK := <-in.Errors:
+			if !errOK {
+				return
+			}
+			r.router.ReportError(err)
+			continue
+		case <-in.Done():
+			return
+		case <-r.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+		if !ok {
+			return
+		}
+		if r.incoming == nil {
+			r.router.Handle(ctx, msg)
+			continue
+		}
+		r.incoming(ctx, msg, func() { r.router.Handle(ctx, msg) })
+	}
+}
+
+// GetRouter returns the router fed by the relay
+func (r *relay) GetRouter() *MessageRouter {
+	return r.router
+}
+
+// Close closes the wrapped transport
+func (r *relay) Close() error {
+	return r.CloseWithError(nil)
+}
+
+// CloseWithError implements CloseReporter, recording err as the reason the
+// wrapped transport closed
+func (r *relay) CloseWithError(err error) error {
+	r.once.Do(func() { close(r.done) })
+	if cr, ok := r.inner.(CloseReporter); ok {
+		return cr.CloseWithError(err)
+	}
+	select {
+	case <-r.inner.Done():
+	default:
+		r.closed.Set(ClosedLocally, err)
+	}
+	return r.inner.Close()
+}
+
+// Done returns the wrapped transport's done channel
+func (r *relay) Done() <-chan struct{} {
+	return r.inner.Done()
+}
+
+// DoneReason implements CloseReporter
+func (r *relay) DoneReason() CloseReason {
+	if cr, ok := r.inner.(CloseReporter); ok {
+		return cr.DoneReason()
+	}
+	return r.closed.Reason(r.inner.Done())
+}
+
+// Err implements CloseReporter
+func (r *relay) Err() error {
+	if cr, ok := r.inner.(CloseReporter); ok {
+		return cr.Err()
+	}
+	return r.closed.Err(r.inner.Done())
+}
+
+// Logf logs through the wrapped transport
+func (r *relay) Logf(format string, args ...interface{}) {
+	r.inner.Logf(format, args...)
+}
+
+// Log logs at level through the wrapped transport
+func (r *relay) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
+	logger.Log(ctx, r.inner, level, format, args...)
+}
+
+// SetLogger sets the logger of the wrapped transport and the relay
+func (r *relay) SetLogger(l logger.Logger) {
+	r.inner.SetLogger(l)
+	r.router.SetLogger(l)
+}
+
+// Session implements SessionSource for wrapped transports that do
+func (r *relay) Session() *mcp.Session {
+	if src, ok := r.inner.(SessionSource); ok {
+		return src.Session()
+	}
+	return nil
+}
+
+// Stats implements StatsSource for wrapped transports that do
+func (r *relay) Stats() mcp.SessionStats {
+	if src, ok := r.inner.(StatsSource); ok {
+		return src.Stats()
+	}
+	return mcp.SessionStats{}
+}
+
+// OnSessionEnd implements SessionEndNotifier for wrapped transports that do
+func (r *relay) OnSessionEnd(callback func(*mcp.Session)) {
+	if n, ok := r.inner.(SessionEndNotifier); ok {
+		n.OnSessionEnd(callback)
+	}
+}
+
+// describe summarizes a message for logs, e.g. "request ping id=1"
+func describe(msg *types.Message) string {
+	var parts []string
+	switch {
+	case msg.Method == "":
+		parts = append(parts, "response")
+	case msg.ID == nil:
+		parts = append(parts, "notification", msg.Method)
+	default:
+		parts = append(parts, "request", msg.Method)
+	}
+	if msg.ID != nil {
+		parts = append(parts, "id="+msg.ID.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// LoggingTransport logs every message a transport sends and receives, for
+// debugging integrations
+type LoggingTransport struct {
+	relay
+	logger logger.Logger
+}
+
+// NewLoggingTransport wraps inner, logging its traffic to l
+func NewLoggingTransport(inner Transport, l logger.Logger) *LoggingTransport {
+	t := &LoggingTransport{relay: newRelay(inner), logger: l}
+	t.incoming = func(ctx context.Context, msg *types.Message, deliver func()) {
+		t.logger.Logf("<- %s", describe(msg))
+		deliver()
+	}
+	return t
+}
+
+// Send logs and sends msg
+func (t *LoggingTransport) Send(ctx context.Context, msg *types.Message) error {
+	start := time.Now()
+	err := t.inner.Send(ctx, msg)
+	if err != nil {
+		logger.Log(ctx, t.logger, logger.LevelWarn, "-> %s failed after %v: %v", describe(msg), time.Since(start), err)
+		return err
+	}
+	t.logger.Logf("-> %s (%v)", describe(msg), time.Since(start))
+	return nil
+}
+
+// MethodMetrics counts the messages of one method
+type MethodMetrics struct {
+	Sent       int64
+	Received   int64
+	SendErrors int64
+}
+
+// Metrics is a snapshot of a MetricsTransport's counters. Responses are
+// counted under the method "".
+type Metrics struct {
+	Sent       int64
+	Received   int64
+	SendErrors int64
+	// SendTime is the total time spent in Send; for requests over stdio it
+	// includes waiting for the response
+	SendTime time.Duration
+	ByMethod map[string]MethodMetrics
+}
+
+// MetricsTransport counts the messages a transport sends and receives
+type MetricsTransport struct {
+	relay
+
+	mu      sync.Mutex
+	metrics Metrics
+}
+
+// NewMetricsTransport wraps inner, counting its traffic
+func NewMetricsTransport(inner Transport) *MetricsTransport {
+	t := &MetricsTransport{relay: newRelay(inner)}
+	t.metrics.ByMethod = make(map[string]MethodMetrics)
+	t.incoming = func(ctx context.Context, msg *types.Message, deliver func()) {
+		t.mu.Lock()
+		t.metrics.Received++
+		m := t.metrics.ByMethod[msg.Method]
+		m.Received++
+		t.metrics.ByMethod[msg.Method] = m
+		t.mu.Unlock()
+		deliver()
+	}
+	return t
+}
+
+// Send sends msg and counts it
+func (t *MetricsTransport) Send(ctx context.Context, msg *types.Message) error {
+	start := time.Now()
+	err := t.inner.Send(ctx, msg)
+	elapsed := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics.Sent++
+	t.metrics.SendTime += elapsed
+	m := t.metrics.ByMethod[msg.Method]
+	m.Sent++
+	if err != nil {
+		t.metrics.SendErrors++
+		m.SendErrors++
+	}
+	t.metrics.ByMethod[msg.Method] = m
+	return err
+}
+
+// Metrics returns a snapshot of the counters
+func (t *MetricsTransport) Metrics() Metrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snapshot := t.metrics
+	snapshot.ByMethod = make(map[string]MethodMetrics, len(t.metrics.ByMethod))
+	for k, v := range t.metrics.ByMethod {
+		snapshot.ByMethod[k] = v
+	}
+	return snapshot
+}
+
+// ErrInjectedFault is returned by Send when a FaultInjectingTransport fails
+// it on purpose
+var ErrInjectedFault = errors.New("injected transport fault")
+
+// FaultConfig sets how often a FaultInjectingTransport misbehaves. Rates are
+// probabilities between 0 and 1, applied to each message independently.
+type FaultConfig struct {
+	// DropRate is the chance a message, sent or received, is silently lost
+	DropRate float64
+	// ErrorRate is the chance Send fails with ErrInjectedFault
+	ErrorRate float64
+	// DelayRate is the chance a message is held back for Delay. Delayed
+	// received messages may overtake others.
+	DelayRate float64
+	Delay     time.Duration
+	// Seed makes the faults reproducible; 0 picks a random seed
+	Seed int64
+}
+
+// FaultInjectingTransport drops, delays, and fails messages at configurable
+// rates, for chaos testing MCP integrations
+type FaultInjectingTransport struct {
+	relay
+	config FaultConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewFaultInjectingTransport wraps inner, injecting faults per config
+func NewFaultInjectingTransport(inner Transport, config FaultConfig) *FaultInjectingTransport {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t := &FaultInjectingTransport{
+		relay:  newRelay(inner),
+		config: config,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+	t.incoming = func(ctx context.Context, msg *types.Message, deliver func()) {
+		if t.chance(t.config.DropRate) {
+			t.Log(ctx, logger.LevelDebug, "Fault injection: dropping received %s", msg.Method)
+			return
+		}
+		if t.chance(t.config.DelayRate) {
+			go func() {
+				select {
+				case <-time.After(t.config.Delay):
+					deliver()
+				case <-ctx.Done():
+				}
+			}()
+			return
+		}
+		deliver()
+	}
+	return t
+}
+
+// chance reports true with probability p
+func (t *FaultInjectingTransport) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rng.Float64() < p
+}
+
+// Send sends msg unless a fault is injected
+func (t *FaultInjectingTransport) Send(ctx context.Context, msg *types.Message) error {
+	if t.chance(t.config.ErrorRate) {
+		return ErrInjectedFault
+	}
+	if t.chance(t.config.DropRate) {
+		t.Log(ctx, logger.LevelDebug, "Fault injection: dropping sent %s", msg.Method)
+		return nil
+	}
+	if t.chance(t.config.DelayRate) {
+		select {
+		case <-time.After(t.config.Delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return t.inner.Send(ctx, msg)
+}
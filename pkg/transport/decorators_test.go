@@ -0,0 +1,218 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dwrtz/mcp-go/internal/base"
+	"github.com/dwrtz/mcp-go/internal/mock"
+	"github.com/dwrtz/mcp-go/internal/testutil"
+	"github.com/dwrtz/mcp-go/pkg/methods"
+	"github.com/dwrtz/mcp-go/pkg/transport"
+)
+
+var (
+	_ transport.Transport = (*transport.LoggingTransport)(nil)
+	_ transport.Transport = (*transport.MetricsTransport)(nil)
+	_ transport.Transport = (*transport.FaultInjectingTransport)(nil)
+	_ transport.Transport = (*transport.EncryptedTransport)(nil)
+)
+
+// recordingLogger keeps the lines logged to it
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Logf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+// pingPair starts a server and client over mock pipes, with the server's
+// transport passed through wrap, and returns the client
+func pingPair(t *testing.T, wrap func(transport.Transport) transport.Transport) *base.Base {
+	t.Helper()
+	serverTransport, clientTransport := mock.NewMockPipeTransports(testutil.NewTestLogger(t))
+	srv := base.NewBase(wrap(serverTransport))
+	cli := base.NewBase(clientTransport)
+	srv.RegisterRequestHandler(methods.Ping, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		return struct{}{}, nil
+	})
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	if err := cli.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+func TestLoggingTransport(t *testing.T) {
+	logs := &recordingLogger{}
+	cli := pingPair(t, func(inner transport.Transport) transport.Transport {
+		return transport.NewLoggingTransport(inner, logs)
+	})
+
+	if _, err := cli.SendRequest(context.Background(), methods.Ping, nil); err != nil {
+		t.Fatalf("SendRequest error: %v", err)
+	}
+	// The reply is logged once sent, which may be after the client has it
+	for _, want := range []string{"<- request ping id=", "-> response id="} {
+		deadline := time.Now().Add(time.Second)
+		for !strings.Contains(logs.String(), want) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := logs.String(); !strings.Contains(got, want) {
+			t.Errorf("Expected log containing %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestMetricsTransport(t *testing.T) {
+	var mt *transport.MetricsTransport
+	cli := pingPair(t, func(inner transport.Transport) transport.Transport {
+		mt = transport.NewMetricsTransport(inner)
+		return mt
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := cli.SendRequest(context.Background(), methods.Ping, nil); err != nil {
+			t.Fatalf("SendRequest error: %v", err)
+		}
+	}
+	// The last reply is counted once sent, which may be after the client
+	// has it
+	deadline := time.Now().Add(time.Second)
+	for mt.Metrics().Sent < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	m := mt.Metrics()
+	if m.Received != 3 || m.ByMethod[methods.Ping].Received != 3 {
+		t.Errorf("Expected 3 pings received, got %+v", m)
+	}
+	if m.Sent != 3 || m.ByMethod[""].Sent != 3 || m.SendErrors != 0 {
+		t.Errorf("Expected 3 responses sent, got %+v", m)
+	}
+}
+
+func TestFaultInjectingTransport(t *testing.T) {
+	t.Run("errors", func(t *testing.T) {
+		peer, inner := mock.NewMockPipeTransports(testutil.NewTestLogger(t))
+		defer peer.Close()
+		ft := transport.NewFaultInjectingTransport(inner, transport.FaultConfig{ErrorRate: 1})
+		defer ft.Close()
+		err := ft.Send(context.Background(), testutil.CreateTestMessage(t, nil, methods.Ping, nil))
+		if !errors.Is(err, transport.ErrInjectedFault) {
+			t.Errorf("Expected ErrInjectedFault, got %v", err)
+		}
+	})
+
+	t.Run("drops", func(t *testing.T) {
+		cli := pingPair(t, func(inner transport.Transport) transport.Transport {
+			return transport.NewFaultInjectingTransport(inner, transport.FaultConfig{DropRate: 1})
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if _, err := cli.SendRequest(ctx, methods.Ping, nil); err == nil {
+			t.Error("Expected dropped request to time out")
+		}
+	})
+
+	t.Run("delays", func(t *testing.T) {
+		cli := pingPair(t, func(inner transport.Transport) transport.Transport {
+			return transport.NewFaultInjectingTransport(inner, transport.FaultConfig{DelayRate: 1, Delay: 50 * time.Millisecond, Seed: 1})
+		})
+		start := time.Now()
+		if _, err := cli.SendRequest(context.Background(), methods.Ping, nil); err != nil {
+			t.Fatalf("SendRequest error: %v", err)
+		}
+		// Delayed once on receipt and once on reply
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("Expected at least 100ms of injected delay, got %v", elapsed)
+		}
+	})
+}
+
+// encryptedPair starts a server and client over mock pipes, each encrypting
+// with its key, and returns the client and what went over the server's pipe
+func encryptedPair(t *testing.T, serverKey, clientKey []byte) (*base.Base, *recordingLogger) {
+	t.Helper()
+	serverTransport, clientTransport := mock.NewMockPipeTransports(testutil.NewTestLogger(t))
+	wire := &recordingLogger{}
+	serverEnc, err := transport.NewEncryptedTransport(transport.NewLoggingTransport(serverTransport, wire), serverKey)
+	if err != nil {
+		t.Fatalf("NewEncryptedTransport error: %v", err)
+	}
+	clientEnc, err := transport.NewEncryptedTransport(clientTransport, clientKey)
+	if err != nil {
+		t.Fatalf("NewEncryptedTransport error: %v", err)
+	}
+	srv := base.NewBase(serverEnc)
+	cli := base.NewBase(clientEnc)
+	srv.RegisterRequestHandler(methods.Ping, func(ctx context.Context, params *json.RawMessage) (interface{}, error) {
+		return struct{}{}, nil
+	})
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	if err := cli.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli, wire
+}
+
+func TestEncryptedTransport(t *testing.T) {
+	key, err := transport.NewEncryptionKey()
+	if err != nil {
+		t.Fatalf("NewEncryptionKey error: %v", err)
+	}
+	if _, err := transport.NewEncryptedTransport(nil, key[:8]); err == nil {
+		t.Error("Short key was accepted")
+	}
+
+	t.Run("same key", func(t *testing.T) {
+		cli, wire := encryptedPair(t, key, key)
+		for i := 0; i < 3; i++ {
+			if _, err := cli.SendRequest(context.Background(), methods.Ping, nil); err != nil {
+				t.Fatalf("SendRequest error: %v", err)
+			}
+		}
+		// Only envelopes cross the wire
+		got := wire.String()
+		if strings.Contains(got, methods.Ping) || strings.Contains(got, "response") || !strings.Contains(got, "$/encrypted/message") {
+			t.Errorf("Traffic not encrypted:\n%s", got)
+		}
+	})
+
+	t.Run("different keys", func(t *testing.T) {
+		other, _ := transport.NewEncryptionKey()
+		cli, _ := encryptedPair(t, key, other)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if _, err := cli.SendRequest(ctx, methods.Ping, nil); err == nil {
+			t.Error("Ping succeeded with different keys")
+		}
+	})
+}
@@ -0,0 +1,408 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Methods of the envelopes an EncryptedTransport exchanges with its peer
+const (
+	encryptedHello   = "$/encrypted/hello"
+	encryptedMessage = "$/encrypted/message"
+)
+
+// MinEncryptionKeySize is the shortest key NewEncryptedTransport accepts
+const MinEncryptionKeySize = 16
+
+// helloNonceSize is the size of the random nonce sent with each ephemeral key
+const helloNonceSize = 16
+
+// replayWindow is how far behind the newest message received an older one
+// may arrive and still be accepted
+const replayWindow = 64
+
+// ErrDecrypt is reported for a received message that is not encrypted with
+// the session's key, was altered or was received before
+var ErrDecrypt = errors.New("message failed to decrypt")
+
+// NewEncryptionKey returns a random key for NewEncryptedTransport
+func NewEncryptionKey() ([]byte, error) {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// encryptedHelloParams carries a peer's ephemeral X25519 key and nonce,
+// authenticated with the shared key. Peer is the key of ours the hello
+// answers, if any.
+type encryptedHelloParams struct {
+	Key   []byte `json:"key"`
+	Nonce []byte `json:"nonce"`
+	Peer  []byte `json:"peer,omitempty"`
+	MAC   []byte `json:"mac"`
+}
+
+// encryptedMessageParams carries a sealed message; Seq is its nonce
+type encryptedMessageParams struct {
+	Seq  uint64 `json:"seq"`
+	Data []byte `json:"data"`
+}
+
+// cryptoSession holds the keys agreed with one ephemeral key of the peer
+type cryptoSession struct {
+	peerKey []byte
+	send    cipher.AEAD
+	recv    cipher.AEAD
+	seq     uint64
+
+	// Replay protection: the highest sequence number received and a bitmap
+	// of those received below it, bit i standing for highest-1-i
+	highest uint64
+	seen    uint64
+	any     bool
+}
+
+// EncryptedTransport encrypts and authenticates every message a transport
+// carries with a key shared by both peers, for links without TLS such as
+// stdio piped through other programs or unix sockets other users can reach.
+// Both peers must wrap their transport with the same key.
+//
+// The peers first exchange ephemeral X25519 keys and random nonces,
+// authenticated with the shared key, and derive a pair of AES-GCM keys from
+// them, one for each direction, so recorded traffic stays secret even if the
+// shared key later leaks. A peer announcing a new ephemeral key, as one
+// restarting does, starts a new session, for which this side generates a
+// new key too: no ephemeral key serves two sessions, and a greeting with a
+// key used before, as an attacker replaying an old one would send, is
+// refused. Messages are numbered, and one received twice or altered is
+// dropped and reported as ErrDecrypt, as are messages that are not
+// encrypted.
+//
+// Send waits for the handshake, which completes once each peer has heard
+// from the other. A peer that is not reachable when the transport starts,
+// such as an SSE server's client, is greeted when its own greeting arrives.
+type EncryptedTransport struct {
+	relay
+	key []byte
+
+	mu sync.Mutex
+	// priv is our ephemeral key, sent as pub with nonce; paired is set once
+	// a session uses it
+	priv   *ecdh.PrivateKey
+	pub    []byte
+	nonce  []byte
+	paired bool
+	// spent holds the keys, ours and the peer's, that were used already;
+	// greetings with them are refused
+	spent   map[string]bool
+	session *cryptoSession
+	// ready is closed once there is a session
+	ready chan struct{}
+}
+
+// NewEncryptedTransport wraps inner, encrypting its traffic with key, a
+// secret of at least MinEncryptionKeySize bytes shared with the peer, see
+// NewEncryptionKey
+func NewEncryptedTransport(inner Transport, key []byte) (*EncryptedTransport, error) {
+	if len(key) < MinEncryptionKeySize {
+		return nil, fmt.Errorf("encryption key must be at least %d bytes", MinEncryptionKeySize)
+	}
+	t := &EncryptedTransport{
+		relay: newRelay(inner),
+		key:   append([]byte(nil), key...),
+		spent: make(map[string]bool),
+		ready: make(chan struct{}),
+	}
+	if err := t.newKey(); err != nil {
+		return nil, err
+	}
+	t.incoming = func(ctx context.Context, msg *types.Message, deliver func()) {
+		t.receive(ctx, msg)
+	}
+	return t, nil
+}
+
+// Start starts the wrapped transport and greets the peer. A greeting that
+// cannot be sent yet is not an error: the peer's own greeting is answered.
+func (t *EncryptedTransport) Start(ctx context.Context) error {
+	if err := t.relay.Start(ctx); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	hello := t.newHello(nil)
+	t.mu.Unlock()
+	if err := t.hello(ctx, hello); err != nil {
+		t.Log(ctx, logger.LevelDebug, "Encryption handshake deferred: %v", err)
+	}
+	return nil
+}
+
+// newKey replaces our ephemeral key and nonce. The caller holds mu, or has
+// the transport to itself.
+func (t *EncryptedTransport) newKey() error {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, helloNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	t.priv, t.pub, t.nonce, t.paired = priv, priv.PublicKey().Bytes(), nonce, false
+	t.spent[string(t.pub)] = true
+	return nil
+}
+
+// newHello returns the greeting carrying our key, answering peer if not
+// nil. The caller holds mu.
+func (t *EncryptedTransport) newHello(peer []byte) encryptedHelloParams {
+	return encryptedHelloParams{Key: t.pub, Nonce: t.nonce, Peer: peer, MAC: t.helloMAC(t.pub, t.nonce, peer)}
+}
+
+// hello sends the peer a greeting
+func (t *EncryptedTransport) hello(ctx context.Context, hello encryptedHelloParams) error {
+	params, err := json.Marshal(hello)
+	if err != nil {
+		return err
+	}
+	raw := json.RawMessage(params)
+	return t.inner.Send(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, Method: encryptedHello, Params: &raw})
+}
+
+// helloMAC authenticates a greeting with the shared key. Key and nonce have
+// fixed sizes, so the fields cannot run into each other.
+func (t *EncryptedTransport) helloMAC(pub, nonce, peer []byte) []byte {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(encryptedHello))
+	mac.Write(pub)
+	mac.Write(nonce)
+	mac.Write(peer)
+	return mac.Sum(nil)
+}
+
+// Send encrypts msg and sends it once the handshake is done
+func (t *EncryptedTransport) Send(ctx context.Context, msg *types.Message) error {
+	plain, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-t.ready:
+	case <-t.inner.Done():
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	t.mu.Lock()
+	s := t.session
+	seq := s.seq
+	s.seq++
+	t.mu.Unlock()
+
+	params, err := json.Marshal(encryptedMessageParams{Seq: seq, Data: s.send.Seal(nil, nonce(seq), plain, nil)})
+	if err != nil {
+		return err
+	}
+	raw := json.RawMessage(params)
+	return t.inner.Send(ctx, &types.Message{JSONRPC: types.JSONRPCVersion, Method: encryptedMessage, Params: &raw})
+}
+
+// receive handles a message from the wrapped transport, routing the
+// messages it decrypts
+func (t *EncryptedTransport) receive(ctx context.Context, msg *types.Message) {
+	var err error
+	switch {
+	case msg.Method == encryptedHello && msg.Params != nil:
+		err = t.receiveHello(ctx, *msg.Params)
+	case msg.Method == encryptedMessage && msg.Params != nil:
+		var inner *types.Message
+		if inner, err = t.open(*msg.Params); err == nil {
+			t.router.Handle(ctx, inner)
+		}
+	default:
+		err = fmt.Errorf("%w: %s is not encrypted", ErrDecrypt, describe(msg))
+	}
+	if err != nil {
+		t.Log(ctx, logger.LevelWarn, "Dropping message: %v", err)
+		t.router.ReportError(err)
+	}
+}
+
+// receiveHello starts a session with the peer's ephemeral key. A greeting
+// answering our current, unused key completes the exchange; any other pairs
+// the peer's key with a fresh key of ours, if the current one is used, and
+// is answered so the peer can do the same. The current session's key is
+// ignored, and keys used before are refused.
+func (t *EncryptedTransport) receiveHello(ctx context.Context, params json.RawMessage) error {
+	var hello encryptedHelloParams
+	if err := json.Unmarshal(params, &hello); err != nil {
+		return fmt.Errorf("%w: invalid handshake: %v", ErrDecrypt, err)
+	}
+	if len(hello.Nonce) != helloNonceSize || !hmac.Equal(hello.MAC, t.helloMAC(hello.Key, hello.Nonce, hello.Peer)) {
+		return fmt.Errorf("%w: handshake not authenticated by the shared key", ErrDecrypt)
+	}
+
+	t.mu.Lock()
+	if t.session != nil && bytes.Equal(t.session.peerKey, hello.Key) {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.spent[string(hello.Key)] {
+		t.mu.Unlock()
+		return fmt.Errorf("%w: handshake with a key used before", ErrDecrypt)
+	}
+	answered := !t.paired && bytes.Equal(hello.Peer, t.pub)
+	if t.paired {
+		if err := t.newKey(); err != nil {
+			t.mu.Unlock()
+			return err
+		}
+	}
+	s, err := t.newSession(hello.Key, hello.Nonce)
+	if err != nil {
+		t.mu.Unlock()
+		return fmt.Errorf("%w: invalid handshake: %v", ErrDecrypt, err)
+	}
+	t.spent[string(hello.Key)] = true
+	t.paired = true
+	first := t.session == nil
+	t.session = s
+	if first {
+		close(t.ready)
+	}
+	reply := t.newHello(hello.Key)
+	t.mu.Unlock()
+
+	if answered {
+		return nil
+	}
+	return t.hello(ctx, reply)
+}
+
+// newSession derives the keys shared with the owner of peerKey and
+// peerNonce. The caller holds mu.
+func (t *EncryptedTransport) newSession(peerKey, peerNonce []byte) (*cryptoSession, error) {
+	peer, err := ecdh.X25519().NewPublicKey(peerKey)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := t.priv.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both sides mix the shared key, the agreed secret and the two
+	// ephemeral keys and nonces, in the same order, into the session's
+	// secret, and derive from it the key of each direction from its
+	// sender's key
+	lo, loNonce, hi, hiNonce := t.pub, t.nonce, peerKey, peerNonce
+	if bytes.Compare(lo, hi) > 0 {
+		lo, loNonce, hi, hiNonce = hi, hiNonce, lo, loNonce
+	}
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write(shared)
+	mac.Write(lo)
+	mac.Write(loNonce)
+	mac.Write(hi)
+	mac.Write(hiNonce)
+	secret := mac.Sum(nil)
+
+	send, err := directionCipher(secret, t.pub)
+	if err != nil {
+		return nil, err
+	}
+	recv, err := directionCipher(secret, peerKey)
+	if err != nil {
+		return nil, err
+	}
+	return &cryptoSession{peerKey: peerKey, send: send, recv: recv}, nil
+}
+
+// directionCipher returns the AES-256-GCM cipher of messages sent by the
+// owner of sender
+func directionCipher(secret, sender []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encryptedMessage))
+	mac.Write(sender)
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce returns the GCM nonce of the message numbered seq
+func nonce(seq uint64) []byte {
+	n := make([]byte, 12)
+	binary.BigEndian.PutUint64(n[4:], seq)
+	return n
+}
+
+// open decrypts a sealed message, refusing one received before
+func (t *EncryptedTransport) open(params json.RawMessage) (*types.Message, error) {
+	var sealed encryptedMessageParams
+	if err := json.Unmarshal(params, &sealed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.session
+	if s == nil {
+		return nil, fmt.Errorf("%w: message before handshake", ErrDecrypt)
+	}
+	plain, err := s.recv.Open(nil, nonce(sealed.Seq), sealed.Data, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	if !s.accept(sealed.Seq) {
+		return nil, fmt.Errorf("%w: message %d replayed", ErrDecrypt, sealed.Seq)
+	}
+
+	var msg types.Message
+	if err := json.Unmarshal(plain, &msg); err != nil {
+		return nil, fmt.Errorf("invalid decrypted message: %w", err)
+	}
+	return &msg, nil
+}
+
+// accept records seq as received, reporting false if it was already or is
+// too old to tell
+func (s *cryptoSession) accept(seq uint64) bool {
+	switch {
+	case !s.any || seq > s.highest:
+		shift := seq - s.highest
+		if !s.any || shift > replayWindow {
+			s.seen = 0
+		} else {
+			s.seen = s.seen<<shift | 1<<(shift-1)
+		}
+		s.highest, s.any = seq, true
+		return true
+	case seq == s.highest || s.highest-seq > replayWindow:
+		return false
+	}
+	bit := uint64(1) << (s.highest - seq - 1)
+	if s.seen&bit != 0 {
+		return false
+	}
+	s.seen |= bit
+	return true
+}
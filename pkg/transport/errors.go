@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// The errors below are reported on MessageRouter.Errors for problems that
+// have no caller to return them to. Clients and servers pass them to their
+// OnError callbacks.
+
+// DecodeError reports a received message that could not be decoded or is
+// not a valid JSON-RPC message. The message is dropped.
+type DecodeError struct {
+	// Data is the raw message, when the transport has it
+	Data []byte
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("invalid message received: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// HandlerPanicError reports a request or notification handler that
+// panicked. Requests are answered with an internal error.
+type HandlerPanicError struct {
+	Method string
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the handler goroutine's stack when it panicked
+	Stack []byte
+}
+
+func (e *HandlerPanicError) Error() string {
+	return fmt.Sprintf("handler for %s panicked: %v", e.Method, e.Value)
+}
+
+// SendError reports a message that could not be sent, such as the response
+// to a request from the peer
+type SendError struct {
+	Method string
+	// ID is the ID of the request or response, nil for notifications
+	ID  *types.ID
+	Err error
+}
+
+func (e *SendError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("failed to send response %v: %v", e.ID, e.Err)
+	}
+	return fmt.Sprintf("failed to send %s: %v", e.Method, e.Err)
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectError reports a failed attempt to connect to the peer, such as an
+// SSE client's event stream failing to connect or ending. Attempt counts the
+// failures in a row from 1. The transport retries unless Final is set, in
+// which case it closes with Err.
+type ConnectError struct {
+	Attempt int
+	Final   bool
+	Err     error
+}
+
+func (e *ConnectError) Error() string {
+	if e.Final {
+		return fmt.Sprintf("connection attempt %d failed, giving up: %v", e.Attempt, e.Err)
+	}
+	return fmt.Sprintf("connection attempt %d failed: %v", e.Attempt, e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// WriteTimeoutError is returned by Send when a message could not be written
+// before the deadline of the context it was sent with, or the transport's
+// write timeout, as when the peer stops reading a pipe. The message may
+// still be written once the peer catches up, so only messages that are safe
+// to repeat should be retried. It matches context.DeadlineExceeded with
+// errors.Is.
+type WriteTimeoutError struct {
+	// Method is that of the request or notification, empty for responses
+	Method string
+	Err    error
+}
+
+func (e *WriteTimeoutError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("write of response timed out: %v", e.Err)
+	}
+	return fmt.Sprintf("write of %s timed out: %v", e.Method, e.Err)
+}
+
+func (e *WriteTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches context.DeadlineExceeded, whatever the error the write failed
+// with
+func (e *WriteTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// Timeout reports true, like net.Error
+func (e *WriteTimeoutError) Timeout() bool {
+	return true
+}
+
+// WriteContext returns the context to write a message with: ctx, limited to
+// timeout when positive
+func WriteContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WriteError returns err, from writing a message for method with ctx, as a
+// WriteTimeoutError if ctx's deadline passed
+func WriteError(ctx context.Context, method string, err error) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	var timeout *WriteTimeoutError
+	if errors.As(err, &timeout) {
+		return err
+	}
+	return &WriteTimeoutError{Method: method, Err: err}
+}
@@ -0,0 +1,43 @@
+package transport
+
+import "github.com/dwrtz/mcp-go/pkg/mcp"
+
+// SlowClientAction is what a transport does about a session whose outbound
+// queue reached its high-water mark, see SlowClientHook
+type SlowClientAction int
+
+const (
+	// SlowClientWait keeps queueing; senders wait once the queue is full
+	SlowClientWait SlowClientAction = iota
+	// SlowClientCoalesce replaces queued notifications with later ones of
+	// the same kind until the queue drains: list changes and updates of the
+	// same resource are sent once, and progress only the latest
+	SlowClientCoalesce
+	// SlowClientDisconnect ends the session, dropping what it has queued
+	SlowClientDisconnect
+)
+
+func (a SlowClientAction) String() string {
+	switch a {
+	case SlowClientWait:
+		return "wait"
+	case SlowClientCoalesce:
+		return "coalesce"
+	case SlowClientDisconnect:
+		return "disconnect"
+	default:
+		return "unknown"
+	}
+}
+
+// SlowClientHook decides what to do about session, which has queued
+// messages waiting for its client. It runs once each time the queue reaches
+// the high-water mark after having drained.
+type SlowClientHook func(session *mcp.Session, queued int) SlowClientAction
+
+// SlowClientHookSetter is implemented by transports queuing messages for
+// their clients, such as SSE, that can run a SlowClientHook when a queue
+// reaches highWater messages
+type SlowClientHookSetter interface {
+	SetSlowClientHook(highWater int, hook SlowClientHook)
+}
@@ -0,0 +1,36 @@
+package transport
+
+import "time"
+
+// HTTPTimeouts tunes the timeouts of a transport over HTTP, such as SSE, for
+// deployments behind proxies. A zero field keeps the transport's default,
+// which is no limit unless said otherwise.
+type HTTPTimeouts struct {
+	// ReadTimeout, ReadHeaderTimeout, WriteTimeout and IdleTimeout set those
+	// of the server's http.Server. They apply to the requests carrying
+	// messages; a long-lived event stream is not cut short by them, and its
+	// writes are limited by the transport's write timeout instead.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// MaxConnectionAge makes the server end an event stream once it has
+	// lasted this long, so clients reconnect, possibly to another instance
+	MaxConnectionAge time.Duration
+
+	// DialTimeout limits how long a client takes to connect to the server
+	DialTimeout time.Duration
+	// ResponseHeaderTimeout limits how long a client waits for the headers
+	// of each response, that of the event stream included
+	ResponseHeaderTimeout time.Duration
+	// RequestTimeout limits each request a client makes to send a message
+	RequestTimeout time.Duration
+	// IdleConnTimeout closes a client's idle keep-alive connections
+	IdleConnTimeout time.Duration
+}
+
+// HTTPTimeoutsSetter is implemented by transports over HTTP, such as SSE,
+// whose timeouts can be tuned
+type HTTPTimeoutsSetter interface {
+	SetHTTPTimeouts(timeouts HTTPTimeouts)
+}
@@ -0,0 +1,380 @@
+// Package transport defines the interface MCP clients and servers use to
+// exchange messages, so custom transports can be plugged into
+// client.NewClient and server.NewServer.
+//
+// A transport sends messages with Send and passes every message it receives
+// to the Handle method of its MessageRouter, which sorts requests, responses,
+// and notifications onto channels for the client or server. Close must close
+// the channel returned by Done. Transports may also implement
+// NotificationWriter, BatchWriter, SessionSource, SessionEndNotifier,
+// StatsSource, and CloseReporter.
+//
+// Ordering: a transport passes the messages of one session to Handle in the
+// order the peer sent them, and sends each session's messages in the order
+// Send is called. Clients and servers enable ordering on their router, so
+// the responses and notifications they receive are dispatched in that order
+// and Handle waits for room instead of dropping them.
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/auth"
+	"github.com/dwrtz/mcp-go/pkg/logger"
+	"github.com/dwrtz/mcp-go/pkg/mcp"
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// MessageHandler handles incoming MCP messages by routing them to appropriate channels
+type MessageHandler interface {
+	// Handle processes an incoming message
+	Handle(ctx context.Context, msg *types.Message)
+}
+
+// Transport defines the interface for MCP message transport
+type Transport interface {
+	// Start begins listening for messages
+	Start(ctx context.Context) error
+
+	// Send sends a message through the transport
+	Send(ctx context.Context, msg *types.Message) error
+
+	// GetRouter returns the message router
+	GetRouter() *MessageRouter
+
+	// Close shuts down the transport
+	Close() error
+
+	// Done returns a channel that is closed when the transport is closed
+	Done() <-chan struct{}
+
+	// Logf logs a formatted message. Transports that also implement
+	// logger.LeveledLogger are given the level of each message.
+	Logf(format string, args ...interface{})
+
+	// SetLogger sets the logger for the transport
+	SetLogger(l logger.Logger)
+}
+
+// NotificationWriter is implemented by transports that can frame a
+// notification around params that are already encoded, sparing them the
+// second marshal of going through Send as a Message. params may be nil.
+type NotificationWriter interface {
+	WriteNotification(ctx context.Context, method string, params json.RawMessage) error
+}
+
+// BatchWriter is implemented by transports that can send several encoded
+// messages in one frame as a JSON-RPC batch. Their peers must accept
+// batches, passing each message in it to Handle in order, see SplitBatch. A
+// single message is sent on its own.
+type BatchWriter interface {
+	WriteBatch(ctx context.Context, msgs []json.RawMessage) error
+}
+
+// SessionSource is implemented by transports that track the session requests
+// arrive on, so handlers can see who they act for
+type SessionSource interface {
+	Session() *mcp.Session
+}
+
+// SessionEndNotifier is implemented by transports whose sessions can end
+// while the transport stays open, so state kept per session can be released
+type SessionEndNotifier interface {
+	OnSessionEnd(callback func(*mcp.Session))
+}
+
+// StatsSource is implemented by transports that count the messages and bytes
+// they carry. Stats covers every session the transport has had; a transport
+// with sessions also counts each session's traffic on its Session.
+type StatsSource interface {
+	Stats() mcp.SessionStats
+}
+
+// WriteTimeoutSetter is implemented by transports that can limit how long
+// writing a message may block, failing it with a WriteTimeoutError
+type WriteTimeoutSetter interface {
+	SetWriteTimeout(timeout time.Duration)
+}
+
+// KeepAliveSetter is implemented by transports holding a stream open while
+// idle, such as SSE, that can send keep-alives on it so intermediaries don't
+// close it
+type KeepAliveSetter interface {
+	SetKeepAlive(d time.Duration)
+}
+
+// ConnectionWaiter is implemented by transports connected some time after
+// they start, such as an SSE client whose event stream connects on its own.
+// WaitForConnection returns once they are, or with the error that failed
+// connecting; SetBufferUntilConnected makes sends wait for it.
+type ConnectionWaiter interface {
+	WaitForConnection(ctx context.Context) error
+	SetBufferUntilConnected(buffer bool)
+}
+
+// Reconnector is implemented by transports that reconnect on their own,
+// such as an SSE client's event stream, trying up to retries times in a row
+// and waiting backoff, doubled each time, between attempts
+type Reconnector interface {
+	SetReconnect(retries int, backoff time.Duration)
+}
+
+// HTTPMiddlewareUser is implemented by transports serving HTTP, such as SSE,
+// that can wrap their handlers in middleware
+type HTTPMiddlewareUser interface {
+	Use(mw ...func(http.Handler) http.Handler)
+}
+
+// OriginChecker is implemented by transports serving HTTP, such as SSE,
+// that refuse requests from browser origins other than loopback ones unless
+// allowed
+type OriginChecker interface {
+	SetAllowedOrigins(origins ...string)
+	DisableOriginCheck()
+}
+
+// BearerTokenSetter is implemented by transports over HTTP, such as SSE,
+// that can authorize requests with a shared secret
+type BearerTokenSetter interface {
+	SetBearerToken(token string)
+}
+
+// AuthenticatorSetter is implemented by transports over HTTP, such as SSE,
+// that can authenticate the requests they serve
+type AuthenticatorSetter interface {
+	SetAuthenticator(a auth.Authenticator)
+}
+
+// CompressionSetter is implemented by transports that can compress what
+// they carry, such as SSE over HTTP
+type CompressionSetter interface {
+	SetCompression(enabled bool)
+}
+
+// AppendNotification appends the JSON-RPC notification for method and params
+// to dst, as Send would encode it
+func AppendNotification(dst []byte, method string, params json.RawMessage) ([]byte, error) {
+	dst = append(dst, `{"jsonrpc":"`+types.JSONRPCVersion+`","method":`...)
+	if plainString(method) {
+		dst = append(dst, '"')
+		dst = append(dst, method...)
+		dst = append(dst, '"')
+	} else {
+		quoted, err := json.Marshal(method)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, quoted...)
+	}
+	if params != nil {
+		dst = append(dst, `,"params":`...)
+		dst = append(dst, params...)
+	}
+	return append(dst, '}'), nil
+}
+
+// AppendBatch appends msgs, encoded messages, to dst as a JSON-RPC batch,
+// or the message alone if there is only one
+func AppendBatch(dst []byte, msgs []json.RawMessage) []byte {
+	if len(msgs) == 1 {
+		return append(dst, msgs[0]...)
+	}
+	dst = append(dst, '[')
+	for i, msg := range msgs {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, msg...)
+	}
+	return append(dst, ']')
+}
+
+// SplitBatch returns the messages in data, a JSON-RPC batch or a single
+// message
+func SplitBatch(data []byte) ([]json.RawMessage, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return []json.RawMessage{data}, nil
+	}
+	var msgs []json.RawMessage
+	if err := json.Unmarshal(trimmed, &msgs); err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, errors.New("empty batch")
+	}
+	return msgs, nil
+}
+
+// plainString reports whether s encodes to JSON without escapes
+func plainString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			return false
+		}
+	}
+	return true
+}
+
+// MessageRouter handles routing of messages to appropriate channels
+type MessageRouter struct {
+	// Channels for incoming messages
+	Requests      chan *types.Message
+	Responses     chan *types.Message
+	Notifications chan *types.Message
+	Errors        chan error
+
+	// Inbound carries responses and notifications together, in the order
+	// they were handled, once EnableOrdering has been called. It is never
+	// closed; consumers stop on Done.
+	Inbound chan *types.Message
+	ordered atomic.Bool
+
+	// Control channels; closeMu keeps ReportError from sending on Errors
+	// as Close closes it
+	done    chan struct{}
+	once    sync.Once
+	closeMu sync.RWMutex
+
+	logger *logger.Logger
+}
+
+const defaultChannelSize = 10
+
+// NewMessageRouter creates a new MessageRouter
+func NewMessageRouter() *MessageRouter {
+	return &MessageRouter{
+		Requests:      make(chan *types.Message, defaultChannelSize),
+		Responses:     make(chan *types.Message, defaultChannelSize),
+		Notifications: make(chan *types.Message, defaultChannelSize),
+		Errors:        make(chan error, defaultChannelSize),
+		Inbound:       make(chan *types.Message, defaultChannelSize),
+		done:          make(chan struct{}),
+		logger:        nil,
+	}
+}
+
+// Logf logs a formatted message
+func (r *MessageRouter) Logf(format string, args ...interface{}) {
+	r.Log(context.Background(), logger.LevelInfo, format, args...)
+}
+
+// Log logs a formatted message at level
+func (r *MessageRouter) Log(ctx context.Context, level logger.Level, format string, args ...interface{}) {
+	if r.logger != nil {
+		logger.Log(ctx, *r.logger, level, format, args...)
+	}
+}
+
+// SetLogger sets the logger for the transport
+func (r *MessageRouter) SetLogger(l logger.Logger) {
+	r.logger = &l
+}
+
+// EnableOrdering routes responses and notifications to Inbound instead of
+// Responses and Notifications, so a consumer sees them in the order the peer
+// sent them, which separate channels cannot promise. Handle then waits for
+// room on Inbound rather than dropping messages. It must be called before
+// messages arrive.
+func (r *MessageRouter) EnableOrdering() {
+	r.ordered.Store(true)
+}
+
+// Handle implements MessageHandler.Handle
+func (r *MessageRouter) Handle(ctx context.Context, msg *types.Message) {
+	if msg == nil {
+		r.Log(ctx, logger.LevelWarn, "Received nil message")
+		return
+	}
+
+	if err := msg.Validate(); err != nil {
+		r.Log(ctx, logger.LevelWarn, "Invalid message: %v", err)
+		r.ReportError(&DecodeError{Err: err})
+		return
+	}
+
+	// Route based on message type
+	select {
+	case <-r.done:
+		r.Log(ctx, logger.LevelDebug, "Router closed, dropping message")
+		return
+	case <-ctx.Done():
+		r.Log(ctx, logger.LevelDebug, "Context cancelled while routing message")
+		return
+	default:
+		if r.ordered.Load() && (msg.Method == "" || msg.ID == nil) {
+			// Responses and notifications share the ordered channel
+			select {
+			case r.Inbound <- msg:
+			case <-r.done:
+				r.Log(ctx, logger.LevelDebug, "Router closed, dropping message")
+			case <-ctx.Done():
+				r.Log(ctx, logger.LevelDebug, "Context cancelled while routing message")
+			}
+		} else if msg.Method == "" {
+			// This is a response
+			select {
+			case r.Responses <- msg:
+			default:
+				r.Log(ctx, logger.LevelWarn, "Response channel full, dropping message")
+			}
+		} else if msg.ID == nil {
+			// This is a notification
+			select {
+			case r.Notifications <- msg:
+			default:
+				r.Log(ctx, logger.LevelWarn, "Notification channel full, dropping message")
+			}
+		} else {
+			// This is a request
+			select {
+			case r.Requests <- msg:
+			default:
+				r.Log(ctx, logger.LevelWarn, "Request channel full, dropping message")
+			}
+		}
+	}
+}
+
+// ReportError passes err to the consumer of Errors. It does not block: the
+// error is dropped if Errors is full or the router is closed.
+func (r *MessageRouter) ReportError(err error) {
+	r.closeMu.RLock()
+	defer r.closeMu.RUnlock()
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	select {
+	case r.Errors <- err:
+	default:
+		r.Log(context.Background(), logger.LevelWarn, "Error channel full, dropping error: %v", err)
+	}
+}
+
+// Done returns a channel that's closed when the router is shutting down
+func (r *MessageRouter) Done() <-chan struct{} {
+	return r.done
+}
+
+// Close closes the router and its channels
+func (r *MessageRouter) Close() {
+	r.closeMu.Lock()
+	defer r.closeMu.Unlock()
+	r.once.Do(func() {
+		close(r.done)
+		close(r.Requests)
+		close(r.Responses)
+		close(r.Notifications)
+		close(r.Errors)
+	})
+}
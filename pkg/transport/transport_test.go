@@ -1,8 +1,10 @@
 package transport
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -11,6 +13,10 @@ import (
 	"github.com/dwrtz/mcp-go/pkg/types"
 )
 
+func TestMain(m *testing.M) {
+	testutil.VerifyTestMain(m)
+}
+
 func TestNewMessageRouter(t *testing.T) {
 	router := NewMessageRouter()
 	router.SetLogger(testutil.NewTestLogger(t))
@@ -322,3 +328,162 @@ func TestMessageRouter_ConcurrentHandling(t *testing.T) {
 		t.Fatal("Timeout waiting for concurrent message handling")
 	}
 }
+
+func TestAppendNotification_MatchesMarshal(t *testing.T) {
+	params := json.RawMessage(`{"uri":"file:///a.txt"}`)
+	tests := []struct {
+		name   string
+		method string
+		params json.RawMessage
+	}{
+		{"with params", "notifications/resources/updated", params},
+		{"without params", "notifications/initialized", nil},
+		{"escaped method", "odd\"<method>\n", params},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &types.Message{JSONRPC: types.JSONRPCVersion, Method: tt.method}
+			if tt.params != nil {
+				p := tt.params
+				msg.Params = &p
+			}
+			want, err := json.Marshal(msg)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			got, err := AppendNotification(nil, tt.method, tt.params)
+			if err != nil {
+				t.Fatalf("AppendNotification failed: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	msgs := []json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`{"b":2}`)}
+	data := AppendBatch(nil, msgs)
+	if string(data) != `[{"a":1},{"b":2}]` {
+		t.Errorf("AppendBatch = %s", data)
+	}
+	if single := AppendBatch(nil, msgs[:1]); string(single) != `{"a":1}` {
+		t.Errorf("AppendBatch of one = %s", single)
+	}
+
+	got, err := SplitBatch(data)
+	if err != nil || len(got) != 2 || string(got[1]) != `{"b":2}` {
+		t.Errorf("SplitBatch = %s, %v", got, err)
+	}
+	got, err = SplitBatch([]byte(` {"a":1}`))
+	if err != nil || len(got) != 1 {
+		t.Errorf("SplitBatch of one message = %s, %v", got, err)
+	}
+	for _, bad := range []string{`[]`, `[{"a":1}`} {
+		if _, err := SplitBatch([]byte(bad)); err == nil {
+			t.Errorf("SplitBatch(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	s := &cryptoSession{}
+	for _, step := range []struct {
+		seq  uint64
+		want bool
+	}{
+		{0, true}, {0, false}, {2, true}, {1, true}, {1, false}, {2, false},
+		{100, true}, {40, true}, {36, true}, {35, false}, {40, false}, {99, true}, {200, true}, {100, false},
+	} {
+		if got := s.accept(step.seq); got != step.want {
+			t.Errorf("accept(%d) = %v, want %v", step.seq, got, step.want)
+		}
+	}
+}
+
+// helloTransport records what an EncryptedTransport sends, for handshakes
+// driven by hand
+type helloTransport struct {
+	Transport
+	router *MessageRouter
+	sent   []*types.Message
+}
+
+func (h *helloTransport) GetRouter() *MessageRouter { return h.router }
+
+func (h *helloTransport) Send(ctx context.Context, msg *types.Message) error {
+	h.sent = append(h.sent, msg)
+	return nil
+}
+
+func TestEncryptedHandshakeReplay(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{7}, MinEncryptionKeySize)
+	newPeer := func() (*EncryptedTransport, *helloTransport) {
+		inner := &helloTransport{router: NewMessageRouter()}
+		et, err := NewEncryptedTransport(inner, key)
+		if err != nil {
+			t.Fatalf("NewEncryptedTransport() error: %v", err)
+		}
+		return et, inner
+	}
+	// greet delivers the greeting of from to to and the answer back,
+	// returning the greeting
+	greet := func(from *EncryptedTransport, fromInner *helloTransport, to *EncryptedTransport, toInner *helloTransport) json.RawMessage {
+		if len(fromInner.sent) == 0 {
+			from.mu.Lock()
+			hello := from.newHello(nil)
+			from.mu.Unlock()
+			if err := from.hello(ctx, hello); err != nil {
+				t.Fatal(err)
+			}
+		}
+		hello := *fromInner.sent[len(fromInner.sent)-1].Params
+		sent := len(toInner.sent)
+		if err := to.receiveHello(ctx, hello); err != nil {
+			t.Fatalf("receiveHello() error: %v", err)
+		}
+		if len(toInner.sent) != sent+1 {
+			t.Fatal("greeting not answered")
+		}
+		if err := from.receiveHello(ctx, *toInner.sent[sent].Params); err != nil {
+			t.Fatalf("receiveHello() of the answer error: %v", err)
+		}
+		return hello
+	}
+	// paired checks that a and b share the keys of a session
+	paired := func(a, b *EncryptedTransport) {
+		sealed := a.session.send.Seal(nil, nonce(0), []byte("x"), nil)
+		if _, err := b.session.recv.Open(nil, nonce(0), sealed, nil); err != nil {
+			t.Fatalf("sessions do not share keys: %v", err)
+		}
+	}
+
+	server, serverInner := newPeer()
+	client, clientInner := newPeer()
+	old := greet(client, clientInner, server, serverInner)
+	paired(client, server)
+	firstKey := server.session.peerKey
+
+	// The client restarts with a new key, and the server rekeys for it
+	client, clientInner = newPeer()
+	serverPub := server.pub
+	greet(client, clientInner, server, serverInner)
+	paired(client, server)
+	if bytes.Equal(server.pub, serverPub) {
+		t.Error("server kept its ephemeral key for a new session")
+	}
+
+	// The first greeting, replayed, is refused and changes nothing
+	session, sent := server.session, len(serverInner.sent)
+	if err := server.receiveHello(ctx, old); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("receiveHello() of a replayed greeting error = %v, want ErrDecrypt", err)
+	}
+	if server.session != session || len(serverInner.sent) != sent || bytes.Equal(server.session.peerKey, firstKey) {
+		t.Error("replayed greeting changed the session")
+	}
+	paired(client, server)
+}
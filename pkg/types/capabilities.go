@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 // ClientCapabilities represents the capabilities a client supports
 type ClientCapabilities struct {
 	// Experimental features support
@@ -66,3 +71,109 @@ type ToolsServerCapabilities struct {
 	// Whether the server supports notifications for changes to the tool list
 	ListChanged bool `json:"listChanged,omitempty"`
 }
+
+// Feature names a server capability a client may depend on
+type Feature string
+
+// Features that can be required of a server. Sub-features such as
+// FeatureResourcesSubscribe imply their parent feature.
+const (
+	FeatureLogging              Feature = "logging"
+	FeaturePrompts              Feature = "prompts"
+	FeaturePromptsListChanged   Feature = "prompts.listChanged"
+	FeatureResources            Feature = "resources"
+	FeatureResourcesSubscribe   Feature = "resources.subscribe"
+	FeatureResourcesListChanged Feature = "resources.listChanged"
+	FeatureTools                Feature = "tools"
+	FeatureToolsListChanged     Feature = "tools.listChanged"
+)
+
+// ExperimentalFeature returns the Feature for an experimental capability
+func ExperimentalFeature(name string) Feature {
+	return Feature("experimental." + name)
+}
+
+// Incompatibility describes a required feature the server does not offer
+type Incompatibility struct {
+	Feature Feature `json:"feature"`
+	Reason  string  `json:"reason"`
+}
+
+// CompatibilityReport is the result of checking required features against
+// server capabilities
+type CompatibilityReport struct {
+	Missing []Incompatibility `json:"missing,omitempty"`
+}
+
+// Compatible reports whether every required feature is supported
+func (r *CompatibilityReport) Compatible() bool {
+	return len(r.Missing) == 0
+}
+
+// Err returns nil if the server is compatible, otherwise an error listing
+// every missing feature
+func (r *CompatibilityReport) Err() error {
+	if r.Compatible() {
+		return nil
+	}
+	reasons := make([]string, len(r.Missing))
+	for i, m := range r.Missing {
+		reasons[i] = m.Reason
+	}
+	return fmt.Errorf("server is incompatible: %s", strings.Join(reasons, "; "))
+}
+
+// CheckCompatibility compares the required features against the server
+// capabilities and reports every feature that is missing
+func (c ServerCapabilities) CheckCompatibility(required ...Feature) *CompatibilityReport {
+	report := &CompatibilityReport{}
+	for _, f := range required {
+		if reason := c.missingReason(f); reason != "" {
+			report.Missing = append(report.Missing, Incompatibility{Feature: f, Reason: reason})
+		}
+	}
+	return report
+}
+
+// missingReason returns why f is unsupported, or "" if it is supported
+func (c ServerCapabilities) missingReason(f Feature) string {
+	switch f {
+	case FeatureLogging:
+		if c.Logging == nil {
+			return "server does not support logging"
+		}
+	case FeaturePrompts, FeaturePromptsListChanged:
+		if c.Prompts == nil {
+			return "server does not support prompts"
+		}
+		if f == FeaturePromptsListChanged && !c.Prompts.ListChanged {
+			return "server does not send prompt list change notifications"
+		}
+	case FeatureResources, FeatureResourcesSubscribe, FeatureResourcesListChanged:
+		if c.Resources == nil {
+			return "server does not support resources"
+		}
+		if f == FeatureResourcesSubscribe && !c.Resources.Subscribe {
+			return "server does not support resource subscriptions"
+		}
+		if f == FeatureResourcesListChanged && !c.Resources.ListChanged {
+			return "server does not send resource list change notifications"
+		}
+	case FeatureTools, FeatureToolsListChanged:
+		if c.Tools == nil {
+			return "server does not support tools"
+		}
+		if f == FeatureToolsListChanged && !c.Tools.ListChanged {
+			return "server does not send tool list change notifications"
+		}
+	default:
+		name, ok := strings.CutPrefix(string(f), "experimental.")
+		if !ok {
+			return fmt.Sprintf("unknown feature %q", f)
+		}
+		if _, ok := c.Experimental[name]; !ok {
+			return fmt.Sprintf("server does not support experimental feature %q", name)
+		}
+	}
+	return ""
+}
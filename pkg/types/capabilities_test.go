@@ -0,0 +1,70 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestServerCapabilities_CheckCompatibility(t *testing.T) {
+	caps := types.ServerCapabilities{
+		Experimental: map[string]interface{}{"batching": true},
+		Resources:    &types.ResourcesServerCapabilities{ListChanged: true},
+		Tools:        &types.ToolsServerCapabilities{},
+	}
+
+	tests := []struct {
+		name        string
+		required    []types.Feature
+		wantMissing []types.Feature
+	}{
+		{
+			name:     "nothing required",
+			required: nil,
+		},
+		{
+			name:     "supported features",
+			required: []types.Feature{types.FeatureTools, types.FeatureResources, types.FeatureResourcesListChanged},
+		},
+		{
+			name:        "missing sub-feature",
+			required:    []types.Feature{types.FeatureResourcesSubscribe},
+			wantMissing: []types.Feature{types.FeatureResourcesSubscribe},
+		},
+		{
+			name:        "missing features are all reported",
+			required:    []types.Feature{types.FeaturePrompts, types.FeatureTools, types.FeatureToolsListChanged, types.FeatureLogging},
+			wantMissing: []types.Feature{types.FeaturePrompts, types.FeatureToolsListChanged, types.FeatureLogging},
+		},
+		{
+			name:        "experimental features",
+			required:    []types.Feature{types.ExperimentalFeature("batching"), types.ExperimentalFeature("streaming")},
+			wantMissing: []types.Feature{types.ExperimentalFeature("streaming")},
+		},
+		{
+			name:        "unknown feature",
+			required:    []types.Feature{"bogus"},
+			wantMissing: []types.Feature{"bogus"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := caps.CheckCompatibility(tt.required...)
+			if report.Compatible() != (len(tt.wantMissing) == 0) {
+				t.Errorf("Compatible() = %v, missing %+v", report.Compatible(), report.Missing)
+			}
+			if (report.Err() != nil) != (len(tt.wantMissing) != 0) {
+				t.Errorf("Err() = %v", report.Err())
+			}
+			if len(report.Missing) != len(tt.wantMissing) {
+				t.Fatalf("Missing = %+v, want %v", report.Missing, tt.wantMissing)
+			}
+			for i, m := range report.Missing {
+				if m.Feature != tt.wantMissing[i] || m.Reason == "" {
+					t.Errorf("Missing[%d] = %+v, want feature %s", i, m, tt.wantMissing[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"context"
+	"errors"
+)
+
+// Reference types of a CompleteRequest
+const (
+	// PromptReference refers to a prompt by name
+	PromptReference = "ref/prompt"
+
+	// ResourceReference refers to a resource template by URI
+	ResourceReference = "ref/resource"
+)
+
+// MaxCompletionValues is the most values a completion result may hold
+const MaxCompletionValues = 100
+
+// CompletionReference identifies what a CompleteRequest completes an
+// argument of
+type CompletionReference struct {
+	Type string `json:"type"`
+
+	// Name is the prompt name of a PromptReference
+	Name string `json:"name,omitempty"`
+
+	// URI is the resource template URI of a ResourceReference
+	URI string `json:"uri,omitempty"`
+}
+
+// CompletionArgument is the argument being completed and its value so far
+type CompletionArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CompleteRequest represents a request for completions of an argument
+type CompleteRequest struct {
+	Method   string              `json:"method"`
+	Ref      CompletionReference `json:"ref"`
+	Argument CompletionArgument  `json:"argument"`
+}
+
+// Validate checks that the request has a reference and names an argument
+func (r *CompleteRequest) Validate() error {
+	switch {
+	case r.Ref.Type != PromptReference && r.Ref.Type != ResourceReference:
+		return errors.New("ref type must be ref/prompt or ref/resource")
+	case r.Argument.Name == "":
+		return errors.New("argument name is required")
+	}
+	return nil
+}
+
+// Completion holds suggested values for an argument
+type Completion struct {
+	// Values are at most MaxCompletionValues suggestions
+	Values []string `json:"values"`
+
+	// Total is the number of suggestions available, if known
+	Total int `json:"total,omitempty"`
+
+	// HasMore reports that there are more suggestions than Values
+	HasMore bool `json:"hasMore,omitempty"`
+}
+
+// CompleteResult represents the response to a completion/complete request
+type CompleteResult struct {
+	Completion Completion `json:"completion"`
+}
+
+// CompletionFunc returns suggested values for an argument, given its value
+// so far
+type CompletionFunc func(ctx context.Context, value string) ([]string, error)
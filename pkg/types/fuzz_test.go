@@ -0,0 +1,106 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func FuzzMessageValidate(f *testing.F) {
+	seeds := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":"abc","method":"tools/call","params":{"name":"echo","arguments":{}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":1,"result":{}}`,
+		`{"jsonrpc":"2.0","id":1,"result":null}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"not found"}}`,
+		`{"jsonrpc":"2.0","id":null,"result":{}}`,
+		`{"jsonrpc":"1.0","id":1,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":1.5,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":{},"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"ping","result":{}}`,
+		`{"jsonrpc":"2.0","id":1,"result":{},"error":{"code":1,"message":""}}`,
+		`[]`,
+		`null`,
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var msg types.Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return
+		}
+		if err := msg.Validate(); err != nil {
+			return
+		}
+
+		// A valid message must survive being sent on
+		encoded, err := json.Marshal(&msg)
+		if err != nil {
+			t.Fatalf("Marshal of valid message %s failed: %v", data, err)
+		}
+		var decoded types.Message
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("Unmarshal of %s failed: %v", encoded, err)
+		}
+		if err := decoded.Validate(); err != nil {
+			t.Fatalf("Message %s no longer valid after round trip as %s: %v", data, encoded, err)
+		}
+		if decoded.Method != msg.Method || (decoded.ID == nil) != (msg.ID == nil) {
+			t.Fatalf("Round trip of %s changed the message to %s", data, encoded)
+		}
+	})
+}
+
+func FuzzReadResourceResult(f *testing.F) {
+	seeds := []string{
+		`{"contents":[{"uri":"file:///a.txt","mimeType":"text/plain","text":"hello"}]}`,
+		`{"contents":[{"uri":"file:///a.bin","blob":"aGVsbG8="}]}`,
+		`{"contents":[{"uri":"file:///a.txt","text":""}]}`,
+		`{"contents":[{"uri":"file:///a","text":"x","blob":"eA=="}]}`,
+		`{"contents":[null]}`,
+		`{"contents":[{"uri":"file:///a","blob":false}]}`,
+		`{"contents":[]}`,
+		`{"contents":null}`,
+		`{}`,
+		`null`,
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var result types.ReadResourceResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return
+		}
+		for i, c := range result.Contents {
+			switch c.(type) {
+			case types.TextResourceContents, types.BlobResourceContents:
+			default:
+				t.Fatalf("contents[%d] of %s decoded as %T", i, data, c)
+			}
+		}
+
+		encoded, err := json.Marshal(&result)
+		if err != nil {
+			t.Fatalf("Marshal of %s failed: %v", data, err)
+		}
+		var decoded types.ReadResourceResult
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("Round trip of %s as %s failed: %v", data, encoded, err)
+		}
+		if len(decoded.Contents) != len(result.Contents) {
+			t.Fatalf("Round trip of %s as %s changed the contents", data, encoded)
+		}
+		for i := range result.Contents {
+			if decoded.Contents[i] != result.Contents[i] {
+				t.Fatalf("Round trip of %s as %s changed contents[%d] from %+v to %+v",
+					data, encoded, i, result.Contents[i], decoded.Contents[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package types
+
+// Tools a server with background jobs adds to its tool list; both take the
+// job's ID as the jobId argument
+const (
+	// JobStatusTool returns the JobInfo of a job
+	JobStatusTool = "job_status"
+	// JobCancelTool cancels a running job and returns its JobInfo
+	JobCancelTool = "job_cancel"
+)
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// JobInfo describes a tool call running in the background. A tool that starts
+// a job returns its JobInfo as JSON text right away; the job's status and
+// result can then be read with JobStatusTool or from ResultURI.
+type JobInfo struct {
+	ID     string    `json:"id"`
+	Tool   string    `json:"tool"`
+	Status JobStatus `json:"status"`
+
+	// Progress and Total are the latest progress the job reported
+	Progress float64 `json:"progress,omitempty"`
+	Total    float64 `json:"total,omitempty"`
+	Message  string  `json:"message,omitempty"`
+
+	// ResultURI is the resource holding this JobInfo, when the server
+	// offers resources
+	ResultURI string `json:"resultUri,omitempty"`
+
+	// Result is the tool result of a completed job
+	Result *CallToolResult `json:"result,omitempty"`
+	// Error describes why a job failed
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,64 @@
+package types
+
+import "fmt"
+
+// LoggingLevel is the severity of a log message sent to the client, as in
+// syslog
+type LoggingLevel string
+
+// Logging levels, from least to most severe
+const (
+	LoggingDebug     LoggingLevel = "debug"
+	LoggingInfo      LoggingLevel = "info"
+	LoggingNotice    LoggingLevel = "notice"
+	LoggingWarning   LoggingLevel = "warning"
+	LoggingError     LoggingLevel = "error"
+	LoggingCritical  LoggingLevel = "critical"
+	LoggingAlert     LoggingLevel = "alert"
+	LoggingEmergency LoggingLevel = "emergency"
+)
+
+var loggingLevels = []LoggingLevel{
+	LoggingDebug, LoggingInfo, LoggingNotice, LoggingWarning,
+	LoggingError, LoggingCritical, LoggingAlert, LoggingEmergency,
+}
+
+// Severity orders the levels from 0 for debug up; it is -1 for unknown
+// levels
+func (l LoggingLevel) Severity() int {
+	for i, level := range loggingLevels {
+		if level == l {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetLevelRequest represents a request for log messages of at least Level
+type SetLevelRequest struct {
+	Method string       `json:"method"`
+	Level  LoggingLevel `json:"level"`
+}
+
+// Validate checks that the request names a known level
+func (r *SetLevelRequest) Validate() error {
+	if r.Level.Severity() < 0 {
+		return fmt.Errorf("unknown logging level %q", r.Level)
+	}
+	return nil
+}
+
+// SetLevelResult represents the response to a logging/setLevel request
+type SetLevelResult struct{}
+
+// LoggingMessageNotification carries a log message from the server
+type LoggingMessageNotification struct {
+	Method string       `json:"method"`
+	Level  LoggingLevel `json:"level"`
+
+	// Logger optionally names the source of the message
+	Logger string `json:"logger,omitempty"`
+
+	// Data is any JSON-serializable message or details
+	Data interface{} `json:"data"`
+}
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// NetworkAccess says whether a tool's subprocesses may use the network
+type NetworkAccess string
+
+const (
+	// NetworkAllowed leaves the network reachable; the default
+	NetworkAllowed NetworkAccess = ""
+
+	// NetworkDenied runs subprocesses without network access
+	NetworkDenied NetworkAccess = "deny"
+)
+
+// ToolExecutionPolicy limits the execution of a tool, see
+// TypedTool.WithExecutionPolicy. The server enforces Timeout on every call;
+// MaxMemory and Network apply to the subprocesses the tool starts with
+// package sandbox.
+type ToolExecutionPolicy struct {
+	// Timeout bounds each call; 0 leaves calls unbounded
+	Timeout time.Duration
+
+	// MaxMemory is the most address space, in bytes, each subprocess may
+	// use; 0 is unlimited
+	MaxMemory uint64
+
+	// Network says whether subprocesses may use the network
+	Network NetworkAccess
+}
+
+// PolicyTool is implemented by tools with an execution policy
+type PolicyTool interface {
+	ExecutionPolicy() *ToolExecutionPolicy
+}
+
+type executionPolicyKey struct{}
+
+// WithExecutionPolicy returns a context carrying policy, as the server
+// passes to the handlers of tools with one
+func WithExecutionPolicy(ctx context.Context, policy ToolExecutionPolicy) context.Context {
+	return context.WithValue(ctx, executionPolicyKey{}, policy)
+}
+
+// ExecutionPolicyFromContext returns the execution policy in ctx, if any
+func ExecutionPolicyFromContext(ctx context.Context) (ToolExecutionPolicy, bool) {
+	policy, ok := ctx.Value(executionPolicyKey{}).(ToolExecutionPolicy)
+	return policy, ok
+}
@@ -2,7 +2,9 @@ package types
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // Prompt represents a prompt or prompt template
@@ -15,6 +17,48 @@ type Prompt struct {
 
 	// Optional arguments for templating
 	Arguments []PromptArgument `json:"arguments,omitempty"`
+
+	// Optional version and deprecation, carried in _meta as the
+	// specification has no fields for them
+	Meta *PromptMeta `json:"_meta,omitempty"`
+}
+
+// PromptMeta is the _meta of a Prompt
+type PromptMeta struct {
+	// Version of the prompt
+	Version string `json:"version,omitempty"`
+
+	// Deprecated, if set, marks the prompt as deprecated and says why or
+	// what to use instead
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// Version returns the prompt's version, or "" if it has none
+func (p Prompt) Version() string {
+	if p.Meta == nil {
+		return ""
+	}
+	return p.Meta.Version
+}
+
+// Deprecated returns why the prompt is deprecated, or "" if it is not
+func (p Prompt) Deprecated() string {
+	if p.Meta == nil {
+		return ""
+	}
+	return p.Meta.Deprecated
+}
+
+// VersionedPromptName returns the name under which a version of a prompt is
+// served alongside its latest version: "name@version"
+func VersionedPromptName(name, version string) string {
+	return name + "@" + version
+}
+
+// IsPromptVersion reports whether prompt is name or one of its versions, as
+// named by VersionedPromptName
+func IsPromptVersion(prompt, name string) bool {
+	return prompt == name || strings.HasPrefix(prompt, name+"@")
 }
 
 // PromptArgument describes an argument a prompt can accept
@@ -42,8 +86,10 @@ type MessageContent interface {
 
 // TextContent represents text provided to/from an LLM
 type TextContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type        string       `json:"type"`
+	Text        string       `json:"text"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+	Meta        ContentMeta  `json:"_meta,omitempty"`
 }
 
 func (t TextContent) contentType() string {
@@ -52,9 +98,11 @@ func (t TextContent) contentType() string {
 
 // ImageContent represents an image provided to/from an LLM
 type ImageContent struct {
-	Type     string `json:"type"`
-	Data     string `json:"data"` // base64-encoded
-	MimeType string `json:"mimeType"`
+	Type        string       `json:"type"`
+	Data        string       `json:"data"` // base64-encoded
+	MimeType    string       `json:"mimeType"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+	Meta        ContentMeta  `json:"_meta,omitempty"`
 }
 
 func (i ImageContent) contentType() string {
@@ -63,8 +111,10 @@ func (i ImageContent) contentType() string {
 
 // EmbeddedResource represents a resource embedded in a prompt
 type EmbeddedResource struct {
-	Type     string           `json:"type"`
-	Resource ResourceContents `json:"resource"`
+	Type        string           `json:"type"`
+	Resource    ResourceContents `json:"resource"`
+	Annotations *Annotations     `json:"annotations,omitempty"`
+	Meta        ContentMeta      `json:"_meta,omitempty"`
 }
 
 func (e EmbeddedResource) contentType() string {
@@ -81,42 +131,47 @@ func (m *PromptMessage) UnmarshalJSON(data []byte) error {
 		Alias: (*Alias)(m),
 	}
 
-	if err := json.Unmarshal(data, &aux); err != nil {
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	content, err := unmarshalMessageContent(aux.Content)
+	if err != nil {
 		return err
 	}
+	m.Content = content
+	return nil
+}
 
-	// Unmarshal content based on type
+// unmarshalMessageContent unmarshals a content item based on its type
+func unmarshalMessageContent(data []byte) (MessageContent, error) {
 	var contentType struct {
 		Type string `json:"type"`
 	}
-	if err := json.Unmarshal(aux.Content, &contentType); err != nil {
-		return err
+	if err := json.Unmarshal(data, &contentType); err != nil {
+		return nil, err
 	}
 
 	switch contentType.Type {
 	case "text":
 		var text TextContent
-		if err := json.Unmarshal(aux.Content, &text); err != nil {
-			return err
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
 		}
-		m.Content = text
+		return text, nil
 	case "image":
 		var img ImageContent
-		if err := json.Unmarshal(aux.Content, &img); err != nil {
-			return err
+		if err := json.Unmarshal(data, &img); err != nil {
+			return nil, err
 		}
-		m.Content = img
+		return img, nil
 	case "resource":
 		var res EmbeddedResource
-		if err := json.Unmarshal(aux.Content, &res); err != nil {
-			return err
+		if err := json.Unmarshal(data, &res); err != nil {
+			return nil, err
 		}
-		m.Content = res
-	default:
-		return fmt.Errorf("unknown content type: %s", contentType.Type)
+		return res, nil
 	}
-
-	return nil
+	return nil, fmt.Errorf("unknown content type: %s", contentType.Type)
 }
 
 // MarshalJSON marshals a PromptMessage
@@ -150,10 +205,48 @@ type GetPromptRequest struct {
 	Arguments map[string]string `json:"arguments,omitempty"`
 }
 
+// Validate checks that the request names a prompt
+func (r *GetPromptRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
 // GetPromptResult represents the response to a prompts/get request
 type GetPromptResult struct {
 	Description string          `json:"description,omitempty"`
 	Messages    []PromptMessage `json:"messages"`
+
+	// Streamed is the number of messages sent ahead of the result under
+	// ExperimentalPromptStreaming; Messages holds those after them
+	Streamed int `json:"streamed,omitempty"`
+
+	Meta *ResultMeta `json:"_meta,omitempty"`
+}
+
+// ExperimentalPromptStreaming is the experimental capability under which
+// servers send the messages of a prompt as they are generated, in
+// notifications/prompts/partialResult notifications tied to the progress
+// token of the prompts/get request. The result then holds only the messages
+// not sent that way, and their number. The client opts in by declaring it.
+const ExperimentalPromptStreaming = "promptStreaming"
+
+// PromptPartialResultNotification carries messages of a prompt being streamed
+// under ExperimentalPromptStreaming
+type PromptPartialResultNotification struct {
+	ProgressToken ProgressToken `json:"progressToken"`
+
+	// Offset is the index of the first of Messages within the prompt
+	Offset   int             `json:"offset"`
+	Messages []PromptMessage `json:"messages"`
+}
+
+// PromptChunk is a part of a streamed prompt: a message, or the error that
+// ends the stream
+type PromptChunk struct {
+	Message PromptMessage
+	Err     error
 }
 
 // PromptListChangedNotification represents a notification that the prompt list has changed
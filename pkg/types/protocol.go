@@ -33,6 +33,15 @@ type ID = jsonrpc2.ID // This is typically a string or number
 // ProgressToken represents a token for tracking progress of long-running operations
 type ProgressToken interface{} // string or number
 
+// ProgressNotification reports the progress of the request that asked for it
+// with ProgressToken in its _meta
+type ProgressNotification struct {
+	ProgressToken ProgressToken `json:"progressToken"`
+	Progress      float64       `json:"progress"`
+	// Total is the progress at completion, 0 if unknown
+	Total float64 `json:"total,omitempty"`
+}
+
 // Cursor represents an opaque token for pagination
 type Cursor string
 
@@ -66,6 +75,33 @@ type Result struct {
 // ResultMeta contains metadata for results
 type ResultMeta map[string]interface{}
 
+// ContentMeta contains metadata for content items
+type ContentMeta map[string]interface{}
+
+// Annotations are hints to the client about how to use a content item
+type Annotations struct {
+	// Audience lists who the content is for; empty means everyone
+	Audience []Role `json:"audience,omitempty"`
+
+	// Priority is how important the content is, from 0 (entirely optional)
+	// to 1 (effectively required)
+	Priority *float64 `json:"priority,omitempty"`
+}
+
+// IsFor reports whether the content is for role, as content without an
+// audience is for everyone
+func (a *Annotations) IsFor(role Role) bool {
+	if a == nil || len(a.Audience) == 0 {
+		return true
+	}
+	for _, r := range a.Audience {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // Implementation describes the name and version of an MCP implementation
 type Implementation struct {
 	Name    string `json:"name"`
@@ -82,6 +118,27 @@ type Message struct {
 	Error   *ErrorResponse   `json:"error,omitempty"`
 }
 
+// UnmarshalJSON implements json.Unmarshaler for Message, keeping a null
+// result, which is a valid response, distinct from a missing one
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type alias Message
+	tmp := &struct {
+		Result json.RawMessage `json:"result"`
+		*alias
+	}{
+		alias: (*alias)(m),
+	}
+
+	if err := json.Unmarshal(data, tmp); err != nil {
+		return err
+	}
+	if tmp.Result != nil {
+		result := tmp.Result
+		m.Result = &result
+	}
+	return nil
+}
+
 // ErrorResponse represents a JSON-RPC 2.0 error response
 type ErrorResponse struct {
 	Code    int         `json:"code"`
@@ -115,6 +172,26 @@ const (
 	InternalError  = -32603
 )
 
+// Implementation-defined server error codes, in the range JSON-RPC reserves
+// for them
+const (
+	// QuotaExceeded answers requests over a session's quota; its data is a
+	// QuotaExceededData
+	QuotaExceeded = -32029
+
+	// ResourceNotFound answers a read of a resource the server does not
+	// have, as the MCP specification suggests
+	ResourceNotFound = -32002
+)
+
+// QuotaExceededData is the data of a QuotaExceeded error
+type QuotaExceededData struct {
+	// Limit names the quota that was exceeded, e.g. "requests" or "bytes"
+	Limit string `json:"limit"`
+	// RetryAfter is how many seconds remain until the quota resets
+	RetryAfter int `json:"retryAfter"`
+}
+
 // PaginatedRequest represents a request that supports pagination
 type PaginatedRequest struct {
 	Cursor *Cursor `json:"cursor,omitempty"`
@@ -175,6 +252,14 @@ type InitializeRequest struct {
 	ClientInfo      Implementation     `json:"clientInfo"`
 }
 
+// Validate checks that the request states a protocol version
+func (r *InitializeRequest) Validate() error {
+	if r.ProtocolVersion == "" {
+		return errors.New("protocolVersion is required")
+	}
+	return nil
+}
+
 // InitializeResult represents the server's response to initialization
 type InitializeResult struct {
 	// The version of MCP that the server will use
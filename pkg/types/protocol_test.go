@@ -136,6 +136,27 @@ func TestMessage_Validate(t *testing.T) {
 	}
 }
 
+func TestMessage_UnmarshalNullResult(t *testing.T) {
+	var msg types.Message
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`), &msg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if msg.Result == nil || string(*msg.Result) != "null" {
+		t.Fatalf("Expected null result to be kept, got %v", msg.Result)
+	}
+	if err := msg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	msg = types.Message{}
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","id":1}`), &msg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if msg.Result != nil {
+		t.Errorf("Expected no result, got %s", *msg.Result)
+	}
+}
+
 func jsonPtr(s string) *json.RawMessage {
 	rm := json.RawMessage(s)
 	return &rm
@@ -1,9 +1,19 @@
 package types
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/linediff"
+	"github.com/dwrtz/mcp-go/pkg/mimetype"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
 )
 
 // Resource represents a known resource that the server can read
@@ -19,6 +29,12 @@ type Resource struct {
 
 	// Optional MIME type
 	MimeType string `json:"mimeType,omitempty"`
+
+	// Optional size of the contents in bytes, before any encoding
+	Size *int64 `json:"size,omitempty"`
+
+	// Optional time the contents last changed
+	LastModified *time.Time `json:"lastModified,omitempty"`
 }
 
 // ResourceContents represents the contents of a specific resource
@@ -46,8 +62,13 @@ type BlobResourceContents struct {
 
 func (BlobResourceContents) isResourceContent() {}
 
-// NewBlobContents creates a new BlobResourceContents from raw binary data
+// NewBlobContents creates a new BlobResourceContents from raw binary data. An
+// empty mimeType is detected from the URI's extension or the data, see
+// mimetype.Detect.
 func NewBlobContents(uri string, mimeType string, data []byte) BlobResourceContents {
+	if mimeType == "" {
+		mimeType = mimetype.Detect(uri, data)
+	}
 	return BlobResourceContents{
 		ResourceContents: ResourceContents{
 			URI:      uri,
@@ -81,6 +102,49 @@ type ResourceTemplate struct {
 type ListResourcesRequest struct {
 	Method string  `json:"method"`
 	Cursor *Cursor `json:"cursor,omitempty"`
+
+	// Filter is only honored by servers advertising ExperimentalResourceFilter
+	Filter *ResourceFilter `json:"filter,omitempty"`
+}
+
+// ExperimentalResourceFilter is the experimental capability under which
+// servers honor the filter of resources/list requests
+const ExperimentalResourceFilter = "resourceFilter"
+
+// ResourceFilter narrows a resources/list request. Empty fields match every
+// resource.
+type ResourceFilter struct {
+	// MimeType matches exactly or, as in "text/*", by type
+	MimeType string `json:"mimeType,omitempty"`
+
+	// URIPrefix matches resources whose URI starts with it
+	URIPrefix string `json:"uriPrefix,omitempty"`
+
+	// Query matches resources whose name contains it, ignoring case
+	Query string `json:"query,omitempty"`
+}
+
+// Matches reports whether r passes the filter
+func (f *ResourceFilter) Matches(r Resource) bool {
+	if f == nil {
+		return true
+	}
+	if f.MimeType != "" {
+		if typ, ok := strings.CutSuffix(f.MimeType, "/*"); ok {
+			if !strings.EqualFold(strings.SplitN(r.MimeType, "/", 2)[0], typ) {
+				return false
+			}
+		} else if !strings.EqualFold(r.MimeType, f.MimeType) {
+			return false
+		}
+	}
+	if f.URIPrefix != "" && !urischeme.HasPrefix(r.URI, f.URIPrefix) {
+		return false
+	}
+	if f.Query != "" && !strings.Contains(strings.ToLower(r.Name), strings.ToLower(f.Query)) {
+		return false
+	}
+	return true
 }
 
 // ListResourcesResult represents the response to a resources/list request
@@ -107,6 +171,11 @@ type ReadResourceRequest struct {
 	URI    string `json:"uri"`
 }
 
+// Validate checks that the request names a resource
+func (r *ReadResourceRequest) Validate() error {
+	return requireURI(r.URI)
+}
+
 // ResourceContent is an interface each content struct implements.
 type ResourceContent interface {
 	// Just a sentinel method so these types can be recognized as resource contents.
@@ -116,6 +185,7 @@ type ResourceContent interface {
 // ReadResourceResult represents the response to a resources/read request
 type ReadResourceResult struct {
 	Contents []ResourceContent `json:"contents"` // Can be TextResourceContents or BlobResourceContents
+	Meta     *ResultMeta       `json:"_meta,omitempty"`
 }
 
 // UnmarshalJSON implements json.Unmarshaler for ReadResourceResult
@@ -129,18 +199,27 @@ func (r *ReadResourceResult) UnmarshalJSON(data []byte) error {
 		alias: (*alias)(r),
 	}
 
-	if err := json.Unmarshal(data, &tmp); err != nil {
+	if err := json.Unmarshal(data, tmp); err != nil {
 		return err
 	}
 
-	// We'll clear out r.Contents so we can rebuild it below.
-	r.Contents = make([]ResourceContent, 0, len(tmp.Contents))
+	contents, err := unmarshalResourceContents(tmp.Contents)
+	if err != nil {
+		return err
+	}
+	r.Contents = contents
+	return nil
+}
+
+// unmarshalResourceContents decodes each raw content as text or blob contents
+func unmarshalResourceContents(raws []json.RawMessage) ([]ResourceContent, error) {
+	contents := make([]ResourceContent, 0, len(raws))
 
-	for _, raw := range tmp.Contents {
+	for _, raw := range raws {
 		// Quick approach: decode into a map and see if "blob" or "text" is present.
 		var objMap map[string]interface{}
 		if err := json.Unmarshal(raw, &objMap); err != nil {
-			return err
+			return nil, err
 		}
 
 		switch {
@@ -148,24 +227,24 @@ func (r *ReadResourceResult) UnmarshalJSON(data []byte) error {
 		case objMap["blob"] != nil:
 			var blobC BlobResourceContents
 			if err := json.Unmarshal(raw, &blobC); err != nil {
-				return err
+				return nil, err
 			}
-			r.Contents = append(r.Contents, blobC)
+			contents = append(contents, blobC)
 
 		// If there's a "text" key, treat it as TextResourceContents
 		case objMap["text"] != nil:
 			var textC TextResourceContents
 			if err := json.Unmarshal(raw, &textC); err != nil {
-				return err
+				return nil, err
 			}
-			r.Contents = append(r.Contents, textC)
+			contents = append(contents, textC)
 
 		default:
-			return fmt.Errorf("couldn't guess resource type: neither 'blob' nor 'text' found")
+			return nil, fmt.Errorf("couldn't guess resource type: neither 'blob' nor 'text' found")
 		}
 	}
 
-	return nil
+	return contents, nil
 }
 
 // ResourceListChangedNotification represents a notification that the resource list has changed
@@ -179,14 +258,200 @@ type SubscribeRequest struct {
 	URI    string `json:"uri"`
 }
 
+// Validate checks that the request names a resource
+func (r *SubscribeRequest) Validate() error {
+	return requireURI(r.URI)
+}
+
 // UnsubscribeRequest represents a request to unsubscribe from resource changes
 type UnsubscribeRequest struct {
 	Method string `json:"method"`
 	URI    string `json:"uri"`
 }
 
+// Validate checks that the request names a resource
+func (r *UnsubscribeRequest) Validate() error {
+	return requireURI(r.URI)
+}
+
+func requireURI(uri string) error {
+	if uri == "" {
+		return errors.New("uri is required")
+	}
+	return nil
+}
+
+// ExperimentalResourceContentPush is the experimental capability under which
+// servers include updated contents, or a hash of them, in resource update
+// notifications. The client opts in by declaring it; the server advertises
+// the mode it uses.
+const ExperimentalResourceContentPush = "resourceContentPush"
+
+// ExperimentalResourceSubscribePatterns is the experimental capability under
+// which servers accept resources/subscribe URIs with wildcards, such as
+// "file:///project/**", and send update notifications for every matching
+// resource
+const ExperimentalResourceSubscribePatterns = "resourceSubscribePatterns"
+
+// ContentPushMode selects what a server includes in resource update
+// notifications under ExperimentalResourceContentPush
+type ContentPushMode string
+
+const (
+	// PushContents includes the updated contents, if small enough
+	PushContents ContentPushMode = "contents"
+
+	// PushHash includes a hash of the updated contents
+	PushHash ContentPushMode = "hash"
+
+	// PushDiff includes a hash of the updated contents and a line diff
+	// against the contents of the previous notification, or the contents
+	// themselves when the diff would not be smaller. It suits large,
+	// log-like text resources.
+	PushDiff ContentPushMode = "diff"
+)
+
 // ResourceUpdatedNotification represents a notification that a resource has been updated
 type ResourceUpdatedNotification struct {
 	Method string `json:"method"`
 	URI    string `json:"uri"`
+
+	// Contents, Hash and Patch are only set when the client opted in to
+	// ExperimentalResourceContentPush
+	Contents []ResourceContent `json:"contents,omitempty"`
+	Hash     string            `json:"hash,omitempty"`
+	Patch    *ResourcePatch    `json:"patch,omitempty"`
+}
+
+// ResourcePatch is a line diff of a text resource, sent in update
+// notifications under PushDiff
+type ResourcePatch struct {
+	// Base is the hash, as by HashResourceContents, of the contents the
+	// edits apply to
+	Base  string          `json:"base"`
+	Edits []linediff.Edit `json:"edits"`
+}
+
+// DiffResourceContents returns the patch turning base into contents. Both
+// must be a single text content of the same resource and MIME type;
+// otherwise, or if they are equal, ok is false.
+func DiffResourceContents(base, contents []ResourceContent) (patch *ResourcePatch, ok bool) {
+	if len(base) != 1 || len(contents) != 1 {
+		return nil, false
+	}
+	from, ok1 := base[0].(TextResourceContents)
+	to, ok2 := contents[0].(TextResourceContents)
+	if !ok1 || !ok2 || from.ResourceContents != to.ResourceContents || from.Text == to.Text {
+		return nil, false
+	}
+	hash, err := HashResourceContents(base)
+	if err != nil {
+		return nil, false
+	}
+	return &ResourcePatch{Base: hash, Edits: linediff.Diff(from.Text, to.Text)}, true
+}
+
+// Apply returns contents with the patch applied. It fails if contents are not
+// those the patch is based on.
+func (p *ResourcePatch) Apply(contents []ResourceContent) ([]ResourceContent, error) {
+	if len(contents) != 1 {
+		return nil, errors.New("patch applies only to a single text content")
+	}
+	text, ok := contents[0].(TextResourceContents)
+	if !ok {
+		return nil, errors.New("patch applies only to a single text content")
+	}
+	hash, err := HashResourceContents(contents)
+	if err != nil {
+		return nil, err
+	}
+	if hash != p.Base {
+		return nil, errors.New("patch is based on other contents")
+	}
+	patched, err := linediff.Apply(text.Text, p.Edits)
+	if err != nil {
+		return nil, err
+	}
+	text.Text = patched
+	return []ResourceContent{text}, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for ResourceUpdatedNotification
+func (n *ResourceUpdatedNotification) UnmarshalJSON(data []byte) error {
+	type alias ResourceUpdatedNotification
+	tmp := &struct {
+		Contents []json.RawMessage `json:"contents,omitempty"`
+		*alias
+	}{
+		alias: (*alias)(n),
+	}
+
+	if err := json.Unmarshal(data, tmp); err != nil {
+		return err
+	}
+	if len(tmp.Contents) == 0 {
+		n.Contents = nil
+		return nil
+	}
+
+	contents, err := unmarshalResourceContents(tmp.Contents)
+	if err != nil {
+		return err
+	}
+	n.Contents = contents
+	return nil
+}
+
+// HashResourceContents returns a hex-encoded SHA-256 digest of contents, as
+// sent in ResourceUpdatedNotification.Hash
+func HashResourceContents(contents []ResourceContent) (string, error) {
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ResourceUpdateFunc is called by a ResourceProvider when a subscribed resource changes
+type ResourceUpdateFunc func(uri string)
+
+// ResourceProvider supplies resources from a backing store such as a database,
+// an object store, or memory. A resources server can host several providers,
+// each mounted at its own URI prefix.
+type ResourceProvider interface {
+	// List returns the resources currently offered by the provider
+	List(ctx context.Context) ([]Resource, error)
+
+	// Templates returns the resource templates offered by the provider
+	Templates(ctx context.Context) ([]ResourceTemplate, error)
+
+	// Read returns the contents of the resource identified by uri
+	Read(ctx context.Context, uri string) ([]ResourceContent, error)
+
+	// Subscribe starts watching a resource and calls notify whenever it changes.
+	// The returned cancel function stops watching. Providers that cannot detect
+	// changes may return a no-op cancel function.
+	Subscribe(ctx context.Context, uri string, notify ResourceUpdateFunc) (cancel func(), err error)
+}
+
+// SubscriptionStore holds the resource subscriptions of a server. Replicas of
+// a server that share a store see each other's subscriptions, so updates
+// published by any of them reach clients subscribed through another.
+// Implementations must be safe for concurrent use.
+type SubscriptionStore interface {
+	// Add records that subscriber, the ID of the session that subscribed,
+	// subscribed to uri, which may be a pattern. Adding a subscription that
+	// is already stored does nothing.
+	Add(ctx context.Context, uri, subscriber string) error
+
+	// Remove deletes the subscription of subscriber to uri. Once none are
+	// left, uri is no longer subscribed.
+	Remove(ctx context.Context, uri, subscriber string) error
+
+	// Subscribers returns the subscribers to uri, none if it is not subscribed
+	Subscribers(ctx context.Context, uri string) ([]string, error)
+
+	// URIs returns the subscribed URIs and patterns, sorted
+	URIs(ctx context.Context) ([]string, error)
 }
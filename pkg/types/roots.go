@@ -3,23 +3,47 @@ package types
 import (
 	"fmt"
 	"strings"
+
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
 )
 
 // Root represents a root directory or file that the server can operate on
 type Root struct {
-	// URI identifying the root. Must start with file:// for now
+	// URI identifying the root. file:// unless both sides agree on other schemes
 	URI string `json:"uri"`
 
 	// Optional name for the root
 	Name string `json:"name,omitempty"`
 }
 
-// Validate checks if the root follows spec requirements
-func (r *Root) Validate() error {
-	if !strings.HasPrefix(r.URI, "file://") {
-		return fmt.Errorf("root URI must start with file://")
+// RootValidator checks a root before it is exposed to or accepted from the peer
+type RootValidator func(root Root) error
+
+// DefaultRootValidator accepts only file:// roots, as the spec recommends
+var DefaultRootValidator = RootSchemes(urischeme.File)
+
+// RootSchemes returns a RootValidator accepting roots whose URIs use one of
+// the given schemes and pass that scheme's validator
+func RootSchemes(schemes ...urischeme.Scheme) RootValidator {
+	registry := urischeme.NewRegistry(schemes...)
+	prefixes := registry.Schemes()
+	for i, name := range prefixes {
+		prefixes[i] = name + "://"
 	}
-	return nil
+	return func(root Root) error {
+		if !registry.Allowed(urischeme.SchemeOf(root.URI)) {
+			return fmt.Errorf("root URI must start with %s", strings.Join(prefixes, " or "))
+		}
+		if err := registry.Validate(root.URI); err != nil {
+			return fmt.Errorf("invalid root URI: %w", err)
+		}
+		return nil
+	}
+}
+
+// Validate checks the root with DefaultRootValidator
+func (r *Root) Validate() error {
+	return DefaultRootValidator(*r)
 }
 
 // ListRootsRequest represents a request to list available roots
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // ModelPreferences represents server preferences for model selection
@@ -35,12 +36,100 @@ type CreateMessageRequest struct {
 	Metadata         interface{}       `json:"metadata,omitempty"`
 }
 
+// MaxTemperature is the highest sampling temperature Validate accepts
+const MaxTemperature = 2.0
+
+// Validate checks the request before it is sent or handled, returning a
+// *ValidationError listing every invalid field
+func (r *CreateMessageRequest) Validate() error {
+	verr := &ValidationError{}
+	if len(r.Messages) == 0 {
+		verr.add("messages", "must not be empty")
+	}
+	for i, m := range r.Messages {
+		field := fmt.Sprintf("messages[%d]", i)
+		if m.Role != RoleUser && m.Role != RoleAssistant {
+			verr.add(field+".role", "unknown role %q", m.Role)
+		}
+		switch m.Content.(type) {
+		case TextContent, *TextContent, ImageContent, *ImageContent:
+		case nil:
+			verr.add(field+".content", "is required")
+		default:
+			verr.add(field+".content", "unsupported content type %T", m.Content)
+		}
+	}
+	if r.MaxTokens <= 0 {
+		verr.add("maxTokens", "must be positive")
+	}
+	if r.Temperature < 0 || r.Temperature > MaxTemperature {
+		verr.add("temperature", "must be between 0 and %g", MaxTemperature)
+	}
+	switch r.IncludeContext {
+	case "", "none", "thisServer", "allServers":
+	default:
+		verr.add("includeContext", "must be none, thisServer, or allServers")
+	}
+	if p := r.ModelPreferences; p != nil {
+		priorities := []struct {
+			field string
+			value float64
+		}{
+			{"costPriority", p.CostPriority},
+			{"speedPriority", p.SpeedPriority},
+			{"intelligencePriority", p.IntelligencePriority},
+		}
+		for _, pr := range priorities {
+			if pr.value < 0 || pr.value > 1 {
+				verr.add("modelPreferences."+pr.field, "must be between 0 and 1")
+			}
+		}
+	}
+	return verr.err()
+}
+
 // CreateMessageResult represents the response from a sampling request
 type CreateMessageResult struct {
 	Role       Role           `json:"role"`
 	Content    MessageContent `json:"content"` // Using the same MessageContent interface from prompts
 	Model      string         `json:"model"`
 	StopReason string         `json:"stopReason,omitempty"`
+
+	// Usage is set by sampling handlers for the host's metering; it is not
+	// sent to the server
+	Usage *SamplingUsage `json:"-"`
+}
+
+// SamplingUsage reports the tokens a sampling request consumed
+type SamplingUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// SamplingRecord describes one sampling request handled by a client
+type SamplingRecord struct {
+	Model    string
+	Usage    SamplingUsage
+	Duration time.Duration
+
+	// Err is set if the handler failed
+	Err error
+}
+
+// ModelUsage aggregates the sampling requests served by one model
+type ModelUsage struct {
+	Requests int
+	SamplingUsage
+}
+
+// SamplingTotals aggregates the sampling requests handled by a client
+type SamplingTotals struct {
+	Requests int
+	Failures int
+	SamplingUsage
+
+	// ByModel breaks down successful requests by the model that served them
+	ByModel map[string]ModelUsage
 }
 
 // SamplingMessage represents a message in a sampling request
@@ -59,7 +148,7 @@ func (m *SamplingMessage) UnmarshalJSON(data []byte) error {
 		Alias: (*Alias)(m),
 	}
 
-	if err := json.Unmarshal(data, &aux); err != nil {
+	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
 
@@ -113,7 +202,7 @@ func (r *CreateMessageResult) UnmarshalJSON(data []byte) error {
 		Alias: (*Alias)(r),
 	}
 
-	if err := json.Unmarshal(data, &aux); err != nil {
+	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
 
@@ -0,0 +1,92 @@
+package types_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestCreateMessageRequestValidate(t *testing.T) {
+	valid := []types.SamplingMessage{{Role: types.RoleUser, Content: types.TextContent{Type: "text", Text: "hi"}}}
+
+	tests := []struct {
+		name       string
+		req        types.CreateMessageRequest
+		wantFields []string
+	}{
+		{
+			name: "valid",
+			req:  types.CreateMessageRequest{Messages: valid, MaxTokens: 10, Temperature: 0.7, IncludeContext: "thisServer"},
+		},
+		{
+			name:       "empty",
+			req:        types.CreateMessageRequest{},
+			wantFields: []string{"messages", "maxTokens"},
+		},
+		{
+			name: "bad message",
+			req: types.CreateMessageRequest{
+				Messages:  []types.SamplingMessage{{Role: "system", Content: types.EmbeddedResource{}}},
+				MaxTokens: 10,
+			},
+			wantFields: []string{"messages[0].role", "messages[0].content"},
+		},
+		{
+			name:       "out of range",
+			req:        types.CreateMessageRequest{Messages: valid, MaxTokens: 10, Temperature: 3, IncludeContext: "everything"},
+			wantFields: []string{"temperature", "includeContext"},
+		},
+		{
+			name: "bad priorities",
+			req: types.CreateMessageRequest{
+				Messages:         valid,
+				MaxTokens:        10,
+				ModelPreferences: &types.ModelPreferences{CostPriority: -0.1, IntelligencePriority: 1.5},
+			},
+			wantFields: []string{"modelPreferences.costPriority", "modelPreferences.intelligencePriority"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("Validate() error: %v", err)
+				}
+				return
+			}
+			var verr *types.ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *types.ValidationError", err)
+			}
+			var got []string
+			for _, f := range verr.Fields {
+				got = append(got, f.Field)
+			}
+			if !reflect.DeepEqual(got, tt.wantFields) {
+				t.Errorf("Invalid fields = %v, want %v", got, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestInvalidParamsError(t *testing.T) {
+	verr := &types.ValidationError{Fields: []types.FieldError{{Field: "maxTokens", Message: "must be positive"}}}
+	err := types.InvalidParamsError("sampling/createMessage", verr)
+	if err.Code != types.InvalidParams {
+		t.Errorf("Code = %d, want %d", err.Code, types.InvalidParams)
+	}
+	if err.Message != "invalid sampling/createMessage params: maxTokens: must be positive" {
+		t.Errorf("Unexpected message %q", err.Message)
+	}
+	if !reflect.DeepEqual(err.Data, verr.Fields) {
+		t.Errorf("Data = %+v, want %+v", err.Data, verr.Fields)
+	}
+
+	if err := types.InvalidParamsError("tools/call", errors.New("name is required")); err.Data != nil {
+		t.Errorf("Expected no data for plain errors, got %+v", err.Data)
+	}
+}
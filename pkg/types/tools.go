@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/invopop/jsonschema"
@@ -25,12 +26,127 @@ type Tool struct {
 
 	// JSON Schema defining expected parameters
 	InputSchema ToolInputSchema `json:"inputSchema"`
+
+	// Optional hints about the tool's behavior
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+
+	// Optional deprecation, carried in _meta as the specification has no
+	// fields for it
+	Meta *ToolMeta `json:"_meta,omitempty"`
+}
+
+// ToolMeta is the _meta of a Tool
+type ToolMeta struct {
+	// Deprecated, if set, marks the tool as deprecated and says why
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// ReplacedBy optionally names the tool to use instead
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+// Deprecated returns why the tool is deprecated, or "" if it is not
+func (t Tool) Deprecated() string {
+	if t.Meta == nil {
+		return ""
+	}
+	return t.Meta.Deprecated
+}
+
+// ReplacedBy returns the name of the tool replacing a deprecated tool, or ""
+// if none is named
+func (t Tool) ReplacedBy() string {
+	if t.Meta == nil {
+		return ""
+	}
+	return t.Meta.ReplacedBy
+}
+
+// ToolAnnotations are hints about a tool's behavior. They are not
+// guaranteed to be accurate and should only be trusted from trusted servers.
+type ToolAnnotations struct {
+	// Human-readable title
+	Title string `json:"title,omitempty"`
+
+	// The tool does not modify its environment (default false)
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty"`
+
+	// The tool may perform destructive updates (default true)
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+
+	// Repeated calls with the same arguments have no further effect (default false)
+	IdempotentHint *bool `json:"idempotentHint,omitempty"`
+
+	// The tool interacts with external entities (default true)
+	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+}
+
+// ReadOnly returns ReadOnlyHint, or its default if unset
+func (a *ToolAnnotations) ReadOnly() bool {
+	return a != nil && hint(a.ReadOnlyHint, false)
+}
+
+// Destructive returns DestructiveHint, or its default if unset. Read-only
+// tools are never destructive.
+func (a *ToolAnnotations) Destructive() bool {
+	if a.ReadOnly() {
+		return false
+	}
+	return a == nil || hint(a.DestructiveHint, true)
+}
+
+// Idempotent returns IdempotentHint, or its default if unset
+func (a *ToolAnnotations) Idempotent() bool {
+	return a != nil && hint(a.IdempotentHint, false)
+}
+
+// OpenWorld returns OpenWorldHint, or its default if unset
+func (a *ToolAnnotations) OpenWorld() bool {
+	return a == nil || hint(a.OpenWorldHint, true)
+}
+
+func hint(v *bool, def bool) bool {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+// ExperimentalToolFilter is the experimental capability under which servers
+// honor the filter of tools/list requests
+const ExperimentalToolFilter = "toolFilter"
+
+// ToolFilter narrows a tools/list request by annotation. Unset fields match
+// every tool; set fields match the hint's value, defaults included.
+type ToolFilter struct {
+	ReadOnly    *bool `json:"readOnly,omitempty"`
+	Destructive *bool `json:"destructive,omitempty"`
+	Idempotent  *bool `json:"idempotent,omitempty"`
+	OpenWorld   *bool `json:"openWorld,omitempty"`
+}
+
+// Matches reports whether t passes the filter
+func (f *ToolFilter) Matches(t Tool) bool {
+	if f == nil {
+		return true
+	}
+	a := t.Annotations
+	return matchHint(f.ReadOnly, a.ReadOnly()) &&
+		matchHint(f.Destructive, a.Destructive()) &&
+		matchHint(f.Idempotent, a.Idempotent()) &&
+		matchHint(f.OpenWorld, a.OpenWorld())
+}
+
+func matchHint(want *bool, got bool) bool {
+	return want == nil || *want == got
 }
 
 // ListToolsRequest represents a request to list available tools
 type ListToolsRequest struct {
 	Method string  `json:"method"`
 	Cursor *Cursor `json:"cursor,omitempty"`
+
+	// Filter is only honored by servers advertising ExperimentalToolFilter
+	Filter *ToolFilter `json:"filter,omitempty"`
 }
 
 // ListToolsResult represents the response to a tools/list request
@@ -46,12 +162,206 @@ type CallToolRequest struct {
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
 }
 
+// Validate checks that the request names a tool
+func (r *CallToolRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
 // CallToolResult represents the response from a tool call
 type CallToolResult struct {
 	Content []interface{} `json:"content"` // Can be TextContent, ImageContent, or EmbeddedResource
-	IsError bool          `json:"isError,omitempty"`
+
+	// Optional JSON value holding the result as data, see
+	// SetStructuredContent
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+
+	IsError bool `json:"isError,omitempty"`
+
+	Meta *ResultMeta `json:"_meta,omitempty"`
+}
+
+// ContentFor returns the content for role, leaving out items whose
+// annotations name another audience. Content may be typed, or decoded into
+// maps as clients receive it.
+func (r *CallToolResult) ContentFor(role Role) []interface{} {
+	var content []interface{}
+	for _, c := range r.Content {
+		if annotationsOf(c).IsFor(role) {
+			content = append(content, c)
+		}
+	}
+	return content
+}
+
+// PromptMessages returns the content meant for the model, leaving out items
+// whose annotations name only the user as audience, as prompt messages from
+// role, one per item, e.g. to feed a tool's output back into a conversation.
+// Items of unknown types are left out.
+func (r *CallToolResult) PromptMessages(role Role) []PromptMessage {
+	var messages []PromptMessage
+	for _, c := range r.ContentFor(RoleAssistant) {
+		if content, ok := messageContent(c); ok {
+			messages = append(messages, PromptMessage{Role: role, Content: content})
+		}
+	}
+	return messages
+}
+
+// SamplingMessages is like PromptMessages for sampling requests, which cannot
+// embed resources: an embedded resource becomes a text item naming it.
+func (r *CallToolResult) SamplingMessages(role Role) []SamplingMessage {
+	var messages []SamplingMessage
+	for _, m := range r.PromptMessages(role) {
+		if res, ok := m.Content.(EmbeddedResource); ok {
+			text := "[resource " + res.Resource.URI
+			if res.Resource.MimeType != "" {
+				text += " (" + res.Resource.MimeType + ")"
+			}
+			m.Content = TextContent{Type: "text", Text: text + "]", Annotations: res.Annotations, Meta: res.Meta}
+		}
+		messages = append(messages, SamplingMessage(m))
+	}
+	return messages
+}
+
+// messageContent returns a content item as a MessageContent, reporting false
+// for items of unknown types. Content may be typed, or decoded into maps as
+// clients receive it.
+func messageContent(c interface{}) (MessageContent, bool) {
+	switch c := c.(type) {
+	case TextContent:
+		return c, true
+	case *TextContent:
+		return *c, true
+	case ImageContent:
+		return c, true
+	case *ImageContent:
+		return *c, true
+	case EmbeddedResource:
+		return c, true
+	case *EmbeddedResource:
+		return *c, true
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, false
+	}
+	content, err := unmarshalMessageContent(data)
+	return content, err == nil
+}
+
+// annotationsOf returns the annotations of a content item, or nil
+func annotationsOf(c interface{}) *Annotations {
+	switch c := c.(type) {
+	case TextContent:
+		return c.Annotations
+	case *TextContent:
+		return c.Annotations
+	case ImageContent:
+		return c.Annotations
+	case *ImageContent:
+		return c.Annotations
+	case EmbeddedResource:
+		return c.Annotations
+	case *EmbeddedResource:
+		return c.Annotations
+	case map[string]interface{}:
+		raw, ok := c["annotations"]
+		if !ok {
+			return nil
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil
+		}
+		var a Annotations
+		if err := json.Unmarshal(data, &a); err != nil {
+			return nil
+		}
+		return &a
+	}
+	return nil
+}
+
+// NewStructuredResult returns a result whose structured content is v, see
+// SetStructuredContent
+func NewStructuredResult(v interface{}) (*CallToolResult, error) {
+	r := &CallToolResult{}
+	if err := r.SetStructuredContent(v); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
+// SetStructuredContent sets the structured content to v encoded as JSON and
+// appends a text block holding the same JSON, for clients that only read
+// content
+func (r *CallToolResult) SetStructuredContent(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal structured content: %w", err)
+	}
+	r.StructuredContent = data
+	r.Content = append(r.Content, TextContent{Type: "text", Text: string(data)})
+	return nil
+}
+
+// ErrNoStructuredContent is returned by DecodeStructuredContent for results
+// without structured content
+var ErrNoStructuredContent = errors.New("result has no structured content")
+
+// DecodeStructuredContent decodes the structured content into v. Results
+// of servers that do not set it are decoded from their first text block
+// holding JSON, as SetStructuredContent mirrors it.
+func (r *CallToolResult) DecodeStructuredContent(v interface{}) error {
+	if len(r.StructuredContent) > 0 {
+		return json.Unmarshal(r.StructuredContent, v)
+	}
+	for _, c := range r.Content {
+		var text string
+		switch c := c.(type) {
+		case TextContent:
+			text = c.Text
+		case *TextContent:
+			text = c.Text
+		case map[string]interface{}:
+			if c["type"] != "text" {
+				continue
+			}
+			text, _ = c["text"].(string)
+		default:
+			continue
+		}
+		if json.Valid([]byte(text)) {
+			return json.Unmarshal([]byte(text), v)
+		}
+	}
+	return ErrNoStructuredContent
+}
+
+// ResultTruncation selects what happens to tool results over the size limit
+type ResultTruncation string
+
+const (
+	// TruncateResult cuts the content to the limit and appends a marker
+	TruncateResult ResultTruncation = "truncate"
+
+	// RejectResult replaces the result with an error result
+	RejectResult ResultTruncation = "reject"
+
+	// SpillResult truncates the content and stores the full text as a
+	// temporary resource, linking to it in the marker
+	SpillResult ResultTruncation = "spill"
+)
+
+// NoCacheMetaKey is the _meta key of a tools/call request that, set to true,
+// skips the server's result cache: the tool runs and its result replaces the
+// cached one
+const NoCacheMetaKey = "noCache"
+
 // ToolListChangedNotification represents a notification that the tool list has changed
 type ToolListChangedNotification struct {
 	Method string `json:"method"`
@@ -75,6 +385,9 @@ type McpTool interface {
 type TypedTool[T any] struct {
 	name        string
 	description string
+	annotations *ToolAnnotations
+	meta        *ToolMeta
+	policy      *ToolExecutionPolicy
 	handler     TypedToolHandler[T]
 }
 
@@ -87,6 +400,31 @@ func NewTool[T any](name, description string, handler TypedToolHandler[T]) *Type
 	}
 }
 
+// WithAnnotations sets hints about the tool's behavior and returns the tool
+func (t *TypedTool[T]) WithAnnotations(annotations ToolAnnotations) *TypedTool[T] {
+	t.annotations = &annotations
+	return t
+}
+
+// WithDeprecation marks the tool as deprecated for reason, naming the tool
+// replacing it if replacement is not empty, and returns the tool
+func (t *TypedTool[T]) WithDeprecation(reason, replacement string) *TypedTool[T] {
+	t.meta = &ToolMeta{Deprecated: reason, ReplacedBy: replacement}
+	return t
+}
+
+// WithExecutionPolicy limits the execution of the tool by policy and
+// returns the tool
+func (t *TypedTool[T]) WithExecutionPolicy(policy ToolExecutionPolicy) *TypedTool[T] {
+	t.policy = &policy
+	return t
+}
+
+// ExecutionPolicy implements PolicyTool; it is nil without a policy
+func (t *TypedTool[T]) ExecutionPolicy() *ToolExecutionPolicy {
+	return t.policy
+}
+
 func (t *TypedTool[T]) GetName() string {
 	return t.name
 }
@@ -118,6 +456,8 @@ func (t *TypedTool[T]) GetDefinition() Tool {
 			Properties: props,
 			Required:   schema.Required,
 		},
+		Annotations: t.annotations,
+		Meta:        t.meta,
 	}
 }
 
@@ -0,0 +1,187 @@
+package types_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+func TestToolFilterMatches(t *testing.T) {
+	yes, no := true, false
+	unannotated := types.Tool{Name: "plain"}
+	readOnly := types.Tool{Name: "reader", Annotations: &types.ToolAnnotations{ReadOnlyHint: &yes}}
+	safeWriter := types.Tool{Name: "writer", Annotations: &types.ToolAnnotations{DestructiveHint: &no, IdempotentHint: &yes, OpenWorldHint: &no}}
+
+	tests := []struct {
+		name   string
+		filter *types.ToolFilter
+		tool   types.Tool
+		want   bool
+	}{
+		{"nil filter", nil, unannotated, true},
+		{"empty filter", &types.ToolFilter{}, unannotated, true},
+		{"read-only", &types.ToolFilter{ReadOnly: &yes}, readOnly, true},
+		{"read-only defaults false", &types.ToolFilter{ReadOnly: &yes}, unannotated, false},
+		{"destructive defaults true", &types.ToolFilter{Destructive: &no}, unannotated, false},
+		{"read-only is not destructive", &types.ToolFilter{Destructive: &no}, readOnly, true},
+		{"non-destructive", &types.ToolFilter{Destructive: &no, Idempotent: &yes}, safeWriter, true},
+		{"open world defaults true", &types.ToolFilter{OpenWorld: &yes}, unannotated, true},
+		{"closed world", &types.ToolFilter{OpenWorld: &yes}, safeWriter, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.tool); got != tt.want {
+				t.Errorf("Matches(%s) = %v, want %v", tt.tool.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuredContent(t *testing.T) {
+	type weather struct {
+		City string  `json:"city"`
+		Temp float64 `json:"temp"`
+	}
+	result, err := types.NewStructuredResult(weather{City: "Oslo", Temp: -3.5})
+	if err != nil {
+		t.Fatalf("NewStructuredResult error: %v", err)
+	}
+	want := `{"city":"Oslo","temp":-3.5}`
+	if string(result.StructuredContent) != want {
+		t.Errorf("StructuredContent = %s, want %s", result.StructuredContent, want)
+	}
+	if len(result.Content) != 1 || result.Content[0].(types.TextContent).Text != want {
+		t.Errorf("Content = %+v, want the JSON mirrored", result.Content)
+	}
+
+	// As received by a client
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received types.CallToolResult
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatal(err)
+	}
+	var got weather
+	if err := received.DecodeStructuredContent(&got); err != nil || got != (weather{City: "Oslo", Temp: -3.5}) {
+		t.Errorf("Decoded %+v, %v", got, err)
+	}
+
+	// Servers without structured content mirror it in text
+	received.StructuredContent = nil
+	received.Content = append([]interface{}{map[string]interface{}{"type": "text", "text": "Deprecated"}}, received.Content...)
+	got = weather{}
+	if err := received.DecodeStructuredContent(&got); err != nil || got.City != "Oslo" {
+		t.Errorf("Decoded from text %+v, %v", got, err)
+	}
+
+	plain := types.CallToolResult{Content: []interface{}{types.TextContent{Type: "text", Text: "hello"}}}
+	if err := plain.DecodeStructuredContent(&got); !errors.Is(err, types.ErrNoStructuredContent) {
+		t.Errorf("Decode without structured content error = %v", err)
+	}
+}
+
+func TestContentFor(t *testing.T) {
+	priority := 0.9
+	result := types.CallToolResult{
+		Content: []interface{}{
+			types.TextContent{Type: "text", Text: "both"},
+			types.TextContent{Type: "text", Text: "user", Annotations: &types.Annotations{Audience: []types.Role{types.RoleUser}}},
+			types.TextContent{
+				Type:        "text",
+				Text:        "assistant",
+				Annotations: &types.Annotations{Audience: []types.Role{types.RoleAssistant}, Priority: &priority},
+				Meta:        types.ContentMeta{"source": "cache"},
+			},
+		},
+		Meta: &types.ResultMeta{"elapsedMs": 12},
+	}
+
+	// As received by a client
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received types.CallToolResult
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatal(err)
+	}
+	if received.Meta == nil || (*received.Meta)["elapsedMs"] != float64(12) {
+		t.Errorf("Result _meta = %v", received.Meta)
+	}
+
+	for _, r := range []types.CallToolResult{result, received} {
+		var texts []string
+		for _, c := range r.ContentFor(types.RoleAssistant) {
+			data, _ := json.Marshal(c)
+			var text types.TextContent
+			_ = json.Unmarshal(data, &text)
+			texts = append(texts, text.Text)
+			if text.Text == "assistant" && (text.Annotations.Priority == nil || *text.Annotations.Priority != 0.9 || text.Meta["source"] != "cache") {
+				t.Errorf("Annotated content = %+v", text)
+			}
+		}
+		if len(texts) != 2 || texts[0] != "both" || texts[1] != "assistant" {
+			t.Errorf("Content for the assistant = %v", texts)
+		}
+	}
+}
+
+func TestResultMessages(t *testing.T) {
+	result := types.CallToolResult{
+		Content: []interface{}{
+			types.TextContent{Type: "text", Text: "for everyone"},
+			types.TextContent{Type: "text", Text: "for the user", Annotations: &types.Annotations{Audience: []types.Role{types.RoleUser}}},
+			&types.ImageContent{Type: "image", Data: "aGk=", MimeType: "image/png"},
+			types.EmbeddedResource{Type: "resource", Resource: types.ResourceContents{URI: "file:///a.txt", MimeType: "text/plain"}},
+			map[string]interface{}{"type": "audio", "data": "aGk="},
+		},
+	}
+
+	// As received by a client
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received types.CallToolResult
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []types.CallToolResult{result, received} {
+		prompt := r.PromptMessages(types.RoleUser)
+		if len(prompt) != 3 {
+			t.Fatalf("Prompt messages = %+v, want 3", prompt)
+		}
+		if text, ok := prompt[0].Content.(types.TextContent); !ok || text.Text != "for everyone" || prompt[0].Role != types.RoleUser {
+			t.Errorf("First prompt message = %+v", prompt[0])
+		}
+		if img, ok := prompt[1].Content.(types.ImageContent); !ok || img.MimeType != "image/png" {
+			t.Errorf("Second prompt message = %+v", prompt[1])
+		}
+		if res, ok := prompt[2].Content.(types.EmbeddedResource); !ok || res.Resource.URI != "file:///a.txt" {
+			t.Errorf("Third prompt message = %+v", prompt[2])
+		}
+
+		sampling := r.SamplingMessages(types.RoleAssistant)
+		if len(sampling) != 3 || sampling[0].Role != types.RoleAssistant {
+			t.Fatalf("Sampling messages = %+v", sampling)
+		}
+		if text, ok := sampling[2].Content.(types.TextContent); !ok || text.Text != "[resource file:///a.txt (text/plain)]" {
+			t.Errorf("Embedded resource as sampling content = %+v", sampling[2].Content)
+		}
+		// They make a valid request
+		data, err := json.Marshal(types.CreateMessageRequest{Messages: sampling, MaxTokens: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var req types.CreateMessageRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("Sampling messages do not round-trip: %v", err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists the invalid fields of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// add records an invalid field
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns e if any field was invalid, nil otherwise
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// InvalidParamsError converts an error from decoding or validating the params
+// of method into an InvalidParams error. The fields of a ValidationError are
+// attached as the error data.
+func InvalidParamsError(method string, err error) *ErrorResponse {
+	msg := fmt.Sprintf("invalid %s params: %v", method, err)
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return NewError(InvalidParams, msg, verr.Fields)
+	}
+	return NewError(InvalidParams, msg)
+}
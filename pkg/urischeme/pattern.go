@@ -0,0 +1,61 @@
+package urischeme
+
+import "strings"
+
+// IsPattern reports whether uri contains wildcards. In a pattern, "*" matches
+// any run of characters within a path segment and a "**" segment matches any
+// number of segments, so "file:///project/**" covers everything below
+// /project and "file:///project/*.go" the Go files directly in it.
+func IsPattern(uri string) bool {
+	return strings.Contains(uri, "*")
+}
+
+// Match reports whether uri matches pattern, comparing the scheme
+// case-insensitively. Both should be normalized first.
+func Match(pattern, uri string) bool {
+	ps, us := SchemeOf(pattern), SchemeOf(uri)
+	if ps != us {
+		return false
+	}
+	return matchSegments(strings.Split(pattern[len(ps):], "/"), strings.Split(uri[len(us):], "/"))
+}
+
+// matchSegments matches path segments, expanding "**" to any number of them
+func matchSegments(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(segs); i >= 0; i-- {
+				if matchSegments(pattern[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 || !matchSegment(pattern[0], segs[0]) {
+			return false
+		}
+		pattern, segs = pattern[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// matchSegment matches one segment, where "*" matches any run of characters
+func matchSegment(pattern, seg string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == seg
+	}
+	if !strings.HasPrefix(seg, parts[0]) {
+		return false
+	}
+	seg = seg[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(seg, part)
+		if i < 0 {
+			return false
+		}
+		seg = seg[i+len(part):]
+	}
+	return len(seg) >= len(last) && strings.HasSuffix(seg, last)
+}
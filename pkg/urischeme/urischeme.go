@@ -0,0 +1,280 @@
+// Package urischeme provides a registry of allowed URI schemes with
+// per-scheme validation and normalization rules. It is used by resources,
+// roots, and subscriptions so that URIs are checked and compared the same
+// way everywhere.
+package urischeme
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Validator checks a parsed URI for scheme-specific requirements
+type Validator func(u *url.URL) error
+
+// Normalizer rewrites a parsed URI into its canonical form
+type Normalizer func(u *url.URL) *url.URL
+
+// Scheme describes an allowed URI scheme
+type Scheme struct {
+	// Name of the scheme, without the "://" suffix (e.g. "file")
+	Name string
+
+	// Optional validator applied after parsing
+	Validate Validator
+
+	// Optional normalizer applied after validation
+	Normalize Normalizer
+}
+
+// Registry holds the set of allowed URI schemes
+type Registry struct {
+	mu      sync.RWMutex
+	schemes map[string]Scheme
+}
+
+// NewRegistry creates a registry allowing the given schemes
+func NewRegistry(schemes ...Scheme) *Registry {
+	r := &Registry{
+		schemes: make(map[string]Scheme),
+	}
+	for _, s := range schemes {
+		r.Register(s)
+	}
+	return r
+}
+
+// Register adds or replaces a scheme in the registry
+func (r *Registry) Register(s Scheme) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemes[strings.ToLower(s.Name)] = s
+}
+
+// Allow registers a scheme without any validator or normalizer if it is
+// not already registered
+func (r *Registry) Allow(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name = strings.ToLower(name)
+	if _, ok := r.schemes[name]; !ok {
+		r.schemes[name] = Scheme{Name: name}
+	}
+}
+
+// Allowed returns whether the given scheme is registered
+func (r *Registry) Allowed(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.schemes[strings.ToLower(name)]
+	return ok
+}
+
+// Schemes returns the sorted names of all registered schemes
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.schemes))
+	for name := range r.schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Clone returns an independent copy of the registry
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c := NewRegistry()
+	for name, s := range r.schemes {
+		c.schemes[name] = s
+	}
+	return c
+}
+
+// Parse parses and validates a URI, returning it in normalized form
+func (r *Registry) Parse(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("empty URI")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed URI %q: %w", raw, err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("URI %q has no scheme", raw)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	r.mu.RLock()
+	s, ok := r.schemes[u.Scheme]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("URI scheme %q is not allowed", u.Scheme)
+	}
+
+	if s.Validate != nil {
+		if err := s.Validate(u); err != nil {
+			return nil, fmt.Errorf("invalid %s URI %q: %w", u.Scheme, raw, err)
+		}
+	}
+	if s.Normalize != nil {
+		u = s.Normalize(u)
+	}
+	return u, nil
+}
+
+// Validate checks that a URI uses an allowed scheme and passes its validator
+func (r *Registry) Validate(raw string) error {
+	_, err := r.Parse(raw)
+	return err
+}
+
+// Normalize validates a URI and returns its canonical string form
+func (r *Registry) Normalize(raw string) (string, error) {
+	u, err := r.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// File is the file:// scheme. The host must be empty or "localhost", and
+// the path is cleaned.
+var File = Scheme{
+	Name: "file",
+	Validate: func(u *url.URL) error {
+		if u.Opaque != "" {
+			return fmt.Errorf("file URIs must be of the form file:///path")
+		}
+		if u.Host != "" && !strings.EqualFold(u.Host, "localhost") {
+			return fmt.Errorf("file URIs must not reference a remote host")
+		}
+		if u.Path == "" {
+			return fmt.Errorf("file URIs must have a path")
+		}
+		return nil
+	},
+	Normalize: func(u *url.URL) *url.URL {
+		n := *u
+		n.Host = ""
+		n.Path = path.Clean(u.Path)
+		return &n
+	},
+}
+
+// HTTP is the http:// scheme. The host is lowercased, the default port
+// dropped, dot segments removed from the path and the fragment dropped.
+var HTTP = Scheme{
+	Name:      "http",
+	Validate:  validateHTTP,
+	Normalize: normalizeHTTP("80"),
+}
+
+// HTTPS is the https:// scheme, normalized as HTTP
+var HTTPS = Scheme{
+	Name:      "https",
+	Validate:  validateHTTP,
+	Normalize: normalizeHTTP("443"),
+}
+
+// Git is the git:// scheme
+var Git = Scheme{
+	Name: "git",
+	Validate: func(u *url.URL) error {
+		if u.Host == "" && u.Path == "" {
+			return fmt.Errorf("git URIs must have a host or path")
+		}
+		return nil
+	},
+	Normalize: func(u *url.URL) *url.URL {
+		n := *u
+		n.Host = strings.ToLower(u.Host)
+		return &n
+	},
+}
+
+func validateHTTP(u *url.URL) error {
+	if u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+func normalizeHTTP(defaultPort string) Normalizer {
+	return func(u *url.URL) *url.URL {
+		n := *u
+		host := strings.ToLower(u.Hostname())
+		switch port := u.Port(); {
+		case port != "" && port != defaultPort:
+			host = net.JoinHostPort(host, port)
+		case strings.Contains(host, ":"):
+			host = "[" + host + "]"
+		}
+		n.Host = host
+
+		cleaned := path.Clean("/" + u.Path)
+		if strings.HasSuffix(u.Path, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		if cleaned != u.Path {
+			n.Path, n.RawPath = cleaned, ""
+		}
+		n.Fragment, n.RawFragment = "", ""
+		return &n
+	}
+}
+
+// Default is the registry used when no other registry is configured.
+// It allows the file, http, https, and git schemes.
+var Default = NewRegistry(File, HTTP, HTTPS, Git)
+
+// Register adds a scheme to the default registry
+func Register(s Scheme) {
+	Default.Register(s)
+}
+
+// Parse parses and validates a URI against the default registry
+func Parse(raw string) (*url.URL, error) {
+	return Default.Parse(raw)
+}
+
+// Validate validates a URI against the default registry
+func Validate(raw string) error {
+	return Default.Validate(raw)
+}
+
+// Normalize normalizes a URI using the default registry
+func Normalize(raw string) (string, error) {
+	return Default.Normalize(raw)
+}
+
+// SchemeOf returns the lowercased scheme of a URI or URI prefix such as
+// "file://", or "" if it has none.
+func SchemeOf(uri string) string {
+	i := strings.Index(uri, ":")
+	if i <= 0 {
+		return ""
+	}
+	return strings.ToLower(uri[:i])
+}
+
+// HasPrefix reports whether uri starts with prefix, comparing the scheme
+// case-insensitively.
+func HasPrefix(uri, prefix string) bool {
+	ps := SchemeOf(prefix)
+	if ps == "" {
+		return strings.HasPrefix(uri, prefix)
+	}
+	us := SchemeOf(uri)
+	if us != ps {
+		return false
+	}
+	return strings.HasPrefix(uri[len(us):], prefix[len(ps):])
+}
@@ -0,0 +1,186 @@
+package urischeme_test
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
+)
+
+func TestRegistry_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "file URI is unchanged",
+			uri:  "file:///project/src/main.go",
+			want: "file:///project/src/main.go",
+		},
+		{
+			name: "file URI path is cleaned",
+			uri:  "file:///project/./src/../README.md",
+			want: "file:///project/README.md",
+		},
+		{
+			name: "file URI with localhost host",
+			uri:  "file://localhost/etc/hosts",
+			want: "file:///etc/hosts",
+		},
+		{
+			name:    "file URI with remote host",
+			uri:     "file://example.com/etc/hosts",
+			wantErr: true,
+		},
+		{
+			name: "http URI host is lowercased and default port dropped",
+			uri:  "HTTP://Example.COM:80",
+			want: "http://example.com/",
+		},
+		{
+			name: "https URI keeps non-default port",
+			uri:  "https://example.com:8443/a#frag",
+			want: "https://example.com:8443/a",
+		},
+		{
+			name: "http URI keeps IPv6 brackets",
+			uri:  "http://[::1]:80/a",
+			want: "http://[::1]/a",
+		},
+		{
+			name: "https URI with IPv6 host and port",
+			uri:  "https://[FE80::1]:8443/a",
+			want: "https://[fe80::1]:8443/a",
+		},
+		{
+			name: "http URI dot segments are removed",
+			uri:  "http://example.com/docs/../admin/./%2e%2e/x/",
+			want: "http://example.com/x/",
+		},
+		{
+			name:    "http URI without host",
+			uri:     "http:///path",
+			wantErr: true,
+		},
+		{
+			name: "git URI",
+			uri:  "git://GitHub.com/dwrtz/mcp-go",
+			want: "git://github.com/dwrtz/mcp-go",
+		},
+		{
+			name:    "unknown scheme",
+			uri:     "invalid:///path",
+			wantErr: true,
+		},
+		{
+			name:    "missing scheme",
+			uri:     "/just/a/path",
+			wantErr: true,
+		},
+		{
+			name:    "empty URI",
+			uri:     "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := urischeme.Normalize(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize(%q) error = %v, wantErr %v", tt.uri, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.uri, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_CustomScheme(t *testing.T) {
+	r := urischeme.NewRegistry(urischeme.File)
+
+	if err := r.Validate("db://main/users"); err == nil {
+		t.Fatal("Expected error for unregistered scheme")
+	}
+
+	r.Register(urischeme.Scheme{
+		Name: "db",
+		Validate: func(u *url.URL) error {
+			if u.Host == "" {
+				return fmt.Errorf("missing schema name")
+			}
+			return nil
+		},
+	})
+
+	if err := r.Validate("db://main/users"); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+	if err := r.Validate("db:///users"); err == nil {
+		t.Error("Expected validator error for db URI without schema")
+	}
+
+	// The default registry must not be affected by a separate registry
+	if urischeme.Default.Allowed("db") {
+		t.Error("Custom scheme leaked into the default registry")
+	}
+
+	// Allow does not replace an existing scheme's rules
+	r.Allow("db")
+	if err := r.Validate("db:///users"); err == nil {
+		t.Error("Allow() replaced the registered db scheme")
+	}
+}
+
+func TestHasPrefix(t *testing.T) {
+	tests := []struct {
+		uri    string
+		prefix string
+		want   bool
+	}{
+		{"file:///a/b.txt", "file://", true},
+		{"FILE:///a/b.txt", "file:///a/", true},
+		{"file:///a/b.txt", "file:///b/", false},
+		{"http://example.com/", "file://", false},
+		{"file:///a/b.txt", "", true},
+		{"file:///a/b.txt", "file", true},
+	}
+
+	for _, tt := range tests {
+		if got := urischeme.HasPrefix(tt.uri, tt.prefix); got != tt.want {
+			t.Errorf("HasPrefix(%q, %q) = %v, want %v", tt.uri, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		uri     string
+		want    bool
+	}{
+		{"file:///project/**", "file:///project/src/main.go", true},
+		{"file:///project/**", "file:///project", true},
+		{"file:///project/**", "file:///projects/a.go", false},
+		{"file:///project/*.go", "file:///project/main.go", true},
+		{"file:///project/*.go", "file:///project/src/main.go", false},
+		{"file:///project/**/*_test.go", "file:///project/a/b/x_test.go", true},
+		{"file:///project/**/*_test.go", "file:///project/x_test.go", true},
+		{"file:///project/**/*_test.go", "file:///project/a/x.go", false},
+		{"file:///a/b*c*d", "file:///a/bxxcyyd", true},
+		{"file:///a/b*c*d", "file:///a/bxxd", false},
+		{"FILE:///a/*", "file:///a/b", true},
+		{"file:///a/*", "https://a/b", false},
+		{"file:///a/b", "file:///a/b", true},
+	}
+
+	for _, tt := range tests {
+		if got := urischeme.Match(tt.pattern, tt.uri); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.uri, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package wasmplugin loads tools compiled to WebAssembly, so servers can run
+// sandboxed third-party tools at runtime without recompiling. Each module
+// provides one tool and talks to the host only through JSON in its linear
+// memory:
+//
+//   - mcp_alloc(size i32) -> i32 reserves size bytes of memory for the host
+//     to write into and returns their offset.
+//   - mcp_tool() -> i64 returns the tool's definition, a types.Tool.
+//   - mcp_call(ptr i32, len i32) -> i64 runs the tool on the arguments
+//     object written at ptr and returns a types.CallToolResult.
+//   - mcp_free(ptr i32, len i32), if exported, releases memory from
+//     mcp_alloc or a returned value once the host is done with it.
+//
+// Returned values are packed into an i64 as offset<<32 | length. Modules
+// import nothing from the host; a runtime may offer WASI.
+//
+// The package does not include a WebAssembly engine, so that the module
+// does not depend on one: modules run through a Runtime the caller provides,
+// wrapping an engine of its choice. With wazero, for example, a Runtime is a
+// few lines:
+//
+//	type wazeroRuntime struct{ r wazero.Runtime }
+//
+//	func (w wazeroRuntime) Instantiate(ctx context.Context, wasm []byte) (wasmplugin.Module, error) {
+//		m, err := w.r.Instantiate(ctx, wasm)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return wazeroModule{m}, nil
+//	}
+//
+//	type wazeroModule struct{ m api.Module }
+//
+//	func (w wazeroModule) Call(ctx context.Context, name string, params ...uint64) ([]uint64, error) {
+//		f := w.m.ExportedFunction(name)
+//		if f == nil {
+//			return nil, wasmplugin.ErrMissingExport
+//		}
+//		return f.Call(ctx, params...)
+//	}
+//
+//	func (w wazeroModule) HasExport(name string) bool { return w.m.ExportedFunction(name) != nil }
+//	func (w wazeroModule) ReadMemory(offset, size uint32) ([]byte, bool) { return w.m.Memory().Read(offset, size) }
+//	func (w wazeroModule) WriteMemory(offset uint32, data []byte) bool { return w.m.Memory().Write(offset, data) }
+//	func (w wazeroModule) Close(ctx context.Context) error { return w.m.Close(ctx) }
+//
+// The tests run the host side against a module simulated in Go, not
+// compiled WebAssembly.
+package wasmplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// Exports of a plugin module, see the package documentation
+const (
+	ExportAlloc = "mcp_alloc"
+	ExportTool  = "mcp_tool"
+	ExportCall  = "mcp_call"
+	ExportFree  = "mcp_free"
+)
+
+// MaxValueSize is the largest definition or result read from a module
+const MaxValueSize = 16 << 20
+
+// ErrMissingExport is returned when a module lacks a required export
+var ErrMissingExport = errors.New("module lacks a required export")
+
+// Runtime compiles and instantiates WebAssembly modules
+type Runtime interface {
+	Instantiate(ctx context.Context, wasm []byte) (Module, error)
+}
+
+// Module is an instantiated WebAssembly module. Calls are made one at a
+// time.
+type Module interface {
+	// Call calls the exported function name. It fails with
+	// ErrMissingExport if there is none.
+	Call(ctx context.Context, name string, params ...uint64) ([]uint64, error)
+
+	// HasExport reports whether the module exports function name
+	HasExport(name string) bool
+
+	// ReadMemory returns size bytes of memory at offset; ok is false if
+	// they are out of range
+	ReadMemory(offset, size uint32) (data []byte, ok bool)
+
+	// WriteMemory writes data to memory at offset; it returns false if it
+	// is out of range
+	WriteMemory(offset uint32, data []byte) bool
+
+	// Close releases the module
+	Close(ctx context.Context) error
+}
+
+// Tool is a types.McpTool run by a WebAssembly module
+type Tool struct {
+	def types.Tool
+
+	// mu makes calls into the module one at a time
+	mu     sync.Mutex
+	module Module
+}
+
+// Load instantiates wasm with rt and reads the definition of its tool
+func Load(ctx context.Context, rt Runtime, wasm []byte) (*Tool, error) {
+	module, err := rt.Instantiate(ctx, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate plugin: %w", err)
+	}
+	for _, name := range []string{ExportAlloc, ExportTool, ExportCall} {
+		if !module.HasExport(name) {
+			module.Close(ctx)
+			return nil, fmt.Errorf("%w: %s", ErrMissingExport, name)
+		}
+	}
+
+	t := &Tool{module: module}
+	data, err := t.invoke(ctx, ExportTool)
+	if err == nil {
+		err = json.Unmarshal(data, &t.def)
+	}
+	if err == nil && t.def.Name == "" {
+		err = errors.New("tool has no name")
+	}
+	if err != nil {
+		module.Close(ctx)
+		return nil, fmt.Errorf("invalid plugin definition: %w", err)
+	}
+	if t.def.InputSchema.Type == "" {
+		t.def.InputSchema.Type = "object"
+	}
+	return t, nil
+}
+
+// LoadFile loads the plugin in the .wasm file at path
+func LoadFile(ctx context.Context, rt Runtime, path string) (*Tool, error) {
+	wasm, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := Load(ctx, rt, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// LoadDir loads every .wasm file in dir, sorted by name, for passing to
+// server.WithTools or SetTools. If one fails, those already loaded are
+// closed.
+func LoadDir(ctx context.Context, rt Runtime, dir string) ([]*Tool, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var tools []*Tool
+	for _, path := range paths {
+		t, err := LoadFile(ctx, rt, path)
+		if err != nil {
+			for _, loaded := range tools {
+				loaded.Close(ctx)
+			}
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// GetName implements types.McpTool
+func (t *Tool) GetName() string {
+	return t.def.Name
+}
+
+// GetDescription implements types.McpTool
+func (t *Tool) GetDescription() string {
+	return t.def.Description
+}
+
+// GetDefinition implements types.McpTool, returning the definition the
+// module gave
+func (t *Tool) GetDefinition() types.Tool {
+	return t.def
+}
+
+// GetHandler implements types.McpTool. The arguments are passed to the
+// module's mcp_call; a trap or an invalid result fails the call.
+func (t *Tool) GetHandler() types.ToolHandler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*types.CallToolResult, error) {
+		if arguments == nil {
+			arguments = map[string]interface{}{}
+		}
+		args, err := json.Marshal(arguments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		ptr, err := t.write(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		data, err := t.invoke(ctx, ExportCall, uint64(ptr), uint64(len(args)))
+		t.free(ctx, ptr, uint32(len(args)))
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s failed: %w", t.def.Name, err)
+		}
+
+		var result types.CallToolResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("plugin %s returned an invalid result: %w", t.def.Name, err)
+		}
+		return &result, nil
+	}
+}
+
+// Close releases the module
+func (t *Tool) Close(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.module.Close(ctx)
+}
+
+// write copies data into memory the module allocates
+func (t *Tool) write(ctx context.Context, data []byte) (uint32, error) {
+	results, err := t.module.Call(ctx, ExportAlloc, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ExportAlloc, err)
+	}
+	if len(results) != 1 {
+		return 0, fmt.Errorf("%s returned %d values", ExportAlloc, len(results))
+	}
+	ptr := uint32(results[0])
+	if !t.module.WriteMemory(ptr, data) {
+		return 0, fmt.Errorf("%s returned memory out of range", ExportAlloc)
+	}
+	return ptr, nil
+}
+
+// invoke calls the export name, which returns a packed value, and copies
+// that value out of memory
+func (t *Tool) invoke(ctx context.Context, name string, params ...uint64) ([]byte, error) {
+	results, err := t.module.Call(ctx, name, params...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("%s returned %d values", name, len(results))
+	}
+	ptr, size := uint32(results[0]>>32), uint32(results[0])
+	if size > MaxValueSize {
+		return nil, fmt.Errorf("%s returned %d bytes, over the limit of %d", name, size, MaxValueSize)
+	}
+	data, ok := t.module.ReadMemory(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("%s returned memory out of range", name)
+	}
+	// The module may reuse its memory, so the value is copied before
+	// it is freed
+	data = append([]byte(nil), data...)
+	t.free(ctx, ptr, size)
+	return data, nil
+}
+
+// free releases memory of the module if it exports mcp_free
+func (t *Tool) free(ctx context.Context, ptr, size uint32) {
+	if t.module.HasExport(ExportFree) {
+		_, _ = t.module.Call(ctx, ExportFree, uint64(ptr), uint64(size))
+	}
+}
@@ -0,0 +1,217 @@
+package wasmplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// fakeModule stands in for a compiled plugin: Go functions over a linear
+// memory with a bump allocator
+type fakeModule struct {
+	memory []byte
+	funcs  map[string]func(params []uint64) []uint64
+	freed  int
+	closed bool
+}
+
+func newFakeModule(def string, call func(args []byte) string) *fakeModule {
+	m := &fakeModule{memory: make([]byte, 0, 1<<16)}
+	m.funcs = map[string]func([]uint64) []uint64{
+		ExportAlloc: func(p []uint64) []uint64 {
+			return []uint64{uint64(m.alloc(int(p[0])))}
+		},
+		ExportTool: func([]uint64) []uint64 {
+			return []uint64{m.put(def)}
+		},
+		ExportCall: func(p []uint64) []uint64 {
+			args := m.memory[p[0] : p[0]+p[1]]
+			return []uint64{m.put(call(args))}
+		},
+		ExportFree: func([]uint64) []uint64 {
+			m.freed++
+			return nil
+		},
+	}
+	return m
+}
+
+func (m *fakeModule) alloc(size int) int {
+	ptr := len(m.memory)
+	m.memory = append(m.memory, make([]byte, size)...)
+	return ptr
+}
+
+func (m *fakeModule) put(s string) uint64 {
+	ptr := m.alloc(len(s))
+	copy(m.memory[ptr:], s)
+	return uint64(ptr)<<32 | uint64(len(s))
+}
+
+func (m *fakeModule) Call(ctx context.Context, name string, params ...uint64) ([]uint64, error) {
+	f, ok := m.funcs[name]
+	if !ok {
+		return nil, ErrMissingExport
+	}
+	return f(params), nil
+}
+
+func (m *fakeModule) HasExport(name string) bool {
+	_, ok := m.funcs[name]
+	return ok
+}
+
+func (m *fakeModule) ReadMemory(offset, size uint32) ([]byte, bool) {
+	if uint64(offset)+uint64(size) > uint64(len(m.memory)) {
+		return nil, false
+	}
+	return m.memory[offset : offset+size], true
+}
+
+func (m *fakeModule) WriteMemory(offset uint32, data []byte) bool {
+	if uint64(offset)+uint64(len(data)) > uint64(len(m.memory)) {
+		return false
+	}
+	copy(m.memory[offset:], data)
+	return true
+}
+
+func (m *fakeModule) Close(ctx context.Context) error {
+	m.closed = true
+	return nil
+}
+
+// fakeRuntime instantiates the modules registered under the contents of
+// their "wasm"
+type fakeRuntime map[string]*fakeModule
+
+func (r fakeRuntime) Instantiate(ctx context.Context, wasm []byte) (Module, error) {
+	m, ok := r[string(wasm)]
+	if !ok {
+		return nil, errors.New("invalid module")
+	}
+	return m, nil
+}
+
+const upperDef = `{"name":"upper","description":"Uppercases text","inputSchema":{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}}`
+
+func upper(args []byte) string {
+	var input struct{ Text string }
+	if err := json.Unmarshal(args, &input); err != nil {
+		return `{"content":[{"type":"text","text":"bad arguments"}],"isError":true}`
+	}
+	return fmt.Sprintf(`{"content":[{"type":"text","text":%q}]}`, strings.ToUpper(input.Text))
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	module := newFakeModule(upperDef, upper)
+	tool, err := Load(ctx, fakeRuntime{"upper": module}, []byte("upper"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	def := tool.GetDefinition()
+	if def.Name != "upper" || def.Description != "Uppercases text" || len(def.InputSchema.Required) != 1 {
+		t.Errorf("Definition = %+v", def)
+	}
+
+	result, err := tool.GetHandler()(ctx, map[string]interface{}{"text": "hello"})
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].(map[string]interface{})["text"] != "HELLO" {
+		t.Errorf("Result = %+v, want HELLO", result)
+	}
+	// The definition, the arguments, and the result are freed
+	if module.freed != 3 {
+		t.Errorf("Freed %d values, want 3", module.freed)
+	}
+
+	if err := tool.Close(ctx); err != nil || !module.closed {
+		t.Errorf("Close = %v, closed %v", err, module.closed)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	missing := newFakeModule(upperDef, upper)
+	delete(missing.funcs, ExportCall)
+	if _, err := Load(ctx, fakeRuntime{"m": missing}, []byte("m")); !errors.Is(err, ErrMissingExport) {
+		t.Errorf("Missing export error = %v, want ErrMissingExport", err)
+	}
+	if !missing.closed {
+		t.Error("Module without exports was not closed")
+	}
+
+	unnamed := newFakeModule(`{"description":"no name"}`, upper)
+	if _, err := Load(ctx, fakeRuntime{"m": unnamed}, []byte("m")); err == nil {
+		t.Error("Tool without a name was loaded")
+	}
+
+	outOfRange := newFakeModule(upperDef, upper)
+	outOfRange.funcs[ExportTool] = func([]uint64) []uint64 { return []uint64{1 << 40} }
+	if _, err := Load(ctx, fakeRuntime{"m": outOfRange}, []byte("m")); err == nil {
+		t.Error("Definition out of memory range was accepted")
+	}
+
+	if _, err := Load(ctx, fakeRuntime{}, []byte("m")); err == nil {
+		t.Error("Invalid module was loaded")
+	}
+}
+
+func TestCallInvalidResult(t *testing.T) {
+	ctx := context.Background()
+	module := newFakeModule(upperDef, func([]byte) string { return "not json" })
+	tool, err := Load(ctx, fakeRuntime{"m": module}, []byte("m"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if _, err := tool.GetHandler()(ctx, nil); err == nil {
+		t.Error("Invalid result was accepted")
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for name, content := range map[string]string{"b.wasm": "upper", "a.wasm": "echo", "notes.txt": "x"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rt := fakeRuntime{
+		"upper": newFakeModule(upperDef, upper),
+		"echo":  newFakeModule(`{"name":"echo"}`, func(args []byte) string { return string(args) }),
+	}
+
+	tools, err := LoadDir(ctx, rt, dir)
+	if err != nil {
+		t.Fatalf("LoadDir error: %v", err)
+	}
+	if len(tools) != 2 || tools[0].GetName() != "echo" || tools[1].GetName() != "upper" {
+		t.Fatalf("Loaded %v, want echo and upper", tools)
+	}
+	if tools[0].GetDefinition().InputSchema.Type != "object" {
+		t.Error("Missing input schema type was not defaulted to object")
+	}
+
+	delete(rt, "upper")
+	rt["echo"].closed = false
+	if _, err := LoadDir(ctx, rt, dir); err == nil {
+		t.Error("LoadDir with an invalid module succeeded")
+	}
+	if !rt["echo"].closed {
+		t.Error("Loaded plugin was not closed after a later one failed")
+	}
+}
+
+var _ types.McpTool = (*Tool)(nil)
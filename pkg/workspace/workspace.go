@@ -0,0 +1,316 @@
+// Package workspace presents the file:// roots exposed by an MCP client as a
+// single read-only filesystem.
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+	"github.com/dwrtz/mcp-go/pkg/urischeme"
+)
+
+// RootsLister lists the roots exposed by the client, e.g. a *server.Server
+type RootsLister interface {
+	ListRoots(ctx context.Context) ([]types.Root, error)
+}
+
+// Mount is a root as it appears in the workspace
+type Mount struct {
+	// Name is the top-level directory the root is mounted at
+	Name string
+
+	// Dir is the root's directory on the local filesystem
+	Dir string
+
+	// Root is the root as listed by the client
+	Root types.Root
+}
+
+// Workspace is an fs.FS with one top-level directory per root. Roots nested
+// inside another root are reachable through the outer one and get no mount of
+// their own. Paths that resolve outside every root, e.g. through a symlink,
+// fail with fs.ErrPermission.
+type Workspace struct {
+	mounts []Mount
+	byName map[string]Mount
+}
+
+// Load lists the client's roots and builds a workspace from them
+func Load(ctx context.Context, lister RootsLister) (*Workspace, error) {
+	roots, err := lister.ListRoots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roots: %w", err)
+	}
+	return New(roots)
+}
+
+// New builds a workspace from roots. Roots that are not file:// URIs are
+// skipped, since they have no local directory to serve.
+func New(roots []types.Root) (*Workspace, error) {
+	type candidate struct {
+		root types.Root
+		dir  string
+	}
+	var candidates []candidate
+	for _, root := range roots {
+		if urischeme.SchemeOf(root.URI) != urischeme.File.Name {
+			continue
+		}
+		u, err := urischeme.Parse(root.URI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %s: %w", root.URI, err)
+		}
+		candidates = append(candidates, candidate{root: root, dir: filepath.Clean(filepath.FromSlash(u.Path))})
+	}
+
+	// Outer roots first, so nested roots can be dropped
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].dir) < len(candidates[j].dir)
+	})
+
+	w := &Workspace{byName: make(map[string]Mount)}
+	for _, c := range candidates {
+		if w.covered(c.dir) {
+			continue
+		}
+		m := Mount{Name: w.uniqueName(mountName(c.root, c.dir)), Dir: c.dir, Root: c.root}
+		w.mounts = append(w.mounts, m)
+		w.byName[m.Name] = m
+	}
+	sort.Slice(w.mounts, func(i, j int) bool { return w.mounts[i].Name < w.mounts[j].Name })
+	return w, nil
+}
+
+// Mounts returns the workspace's mounts sorted by name
+func (w *Workspace) Mounts() []Mount {
+	return append([]Mount{}, w.mounts...)
+}
+
+// Resolve returns the workspace path of a file:// URI, or an error wrapping
+// fs.ErrPermission if the URI is outside every root
+func (w *Workspace) Resolve(uri string) (string, error) {
+	if urischeme.SchemeOf(uri) != urischeme.File.Name {
+		return "", fmt.Errorf("%s: not a file URI: %w", uri, fs.ErrInvalid)
+	}
+	u, err := urischeme.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Clean(filepath.FromSlash(u.Path))
+	for _, m := range w.mounts {
+		if rel, ok := within(m.Dir, dir); ok {
+			return path.Join(m.Name, filepath.ToSlash(rel)), nil
+		}
+	}
+	return "", fmt.Errorf("%s: outside workspace roots: %w", uri, fs.ErrPermission)
+}
+
+// Open implements fs.FS
+func (w *Workspace) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &rootDir{w: w}, nil
+	}
+
+	local, err := w.localPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapPathError(err)}
+	}
+	if !strings.Contains(name, "/") {
+		return &mountDir{File: f, name: name}, nil
+	}
+	return f, nil
+}
+
+// Stat implements fs.StatFS
+func (w *Workspace) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return rootInfo{}, nil
+	}
+
+	local, err := w.localPath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: unwrapPathError(err)}
+	}
+	if !strings.Contains(name, "/") {
+		return renamedInfo{FileInfo: info, name: name}, nil
+	}
+	return info, nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (w *Workspace) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := w.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := dir.ReadDir(-1)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, err
+}
+
+// localPath maps a workspace path to the local filesystem, refusing paths
+// whose symlinks lead outside the mount
+func (w *Workspace) localPath(op, name string) (string, error) {
+	mountName, rest, _ := strings.Cut(name, "/")
+	m, ok := w.byName[mountName]
+	if !ok {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	local := filepath.Join(m.Dir, filepath.FromSlash(rest))
+	resolved, err := filepath.EvalSymlinks(local)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: unwrapPathError(err)}
+	}
+	resolvedDir, err := filepath.EvalSymlinks(m.Dir)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: unwrapPathError(err)}
+	}
+	if _, ok := within(resolvedDir, resolved); !ok {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+	return resolved, nil
+}
+
+// covered reports whether dir is inside an existing mount
+func (w *Workspace) covered(dir string) bool {
+	for _, m := range w.mounts {
+		if _, ok := within(m.Dir, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns name, suffixed if another mount already uses it
+func (w *Workspace) uniqueName(name string) string {
+	unique := name
+	for i := 2; ; i++ {
+		if _, taken := w.byName[unique]; !taken {
+			return unique
+		}
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// mountName derives a valid path element from the root's name or directory
+func mountName(root types.Root, dir string) string {
+	name := strings.ReplaceAll(strings.TrimSpace(root.Name), "/", "_")
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	if name == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+		name = "root"
+	}
+	return name
+}
+
+// within reports whether target is dir or inside it, returning the relative path
+func within(dir, target string) (string, bool) {
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// unwrapPathError strips the local path from errors so it is not reported
+// in place of the workspace path
+func unwrapPathError(err error) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return pe.Err
+	}
+	return err
+}
+
+// rootDir is the synthetic directory listing the mounts
+type rootDir struct {
+	w      *Workspace
+	offset int
+}
+
+func (d *rootDir) Stat() (fs.FileInfo, error) { return rootInfo{}, nil }
+func (d *rootDir) Close() error               { return nil }
+
+func (d *rootDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+func (d *rootDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	for d.offset < len(d.w.mounts) && (n <= 0 || len(entries) < n) {
+		m := d.w.mounts[d.offset]
+		d.offset++
+		info, err := os.Stat(m.Dir)
+		if err != nil {
+			// A root that vanished is left out of the listing
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(renamedInfo{FileInfo: info, name: m.Name}))
+	}
+	if n > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+	return entries, nil
+}
+
+// mountDir is a mount's directory, reporting its mount name
+type mountDir struct {
+	*os.File
+	name string
+}
+
+func (d *mountDir) Stat() (fs.FileInfo, error) {
+	info, err := d.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return renamedInfo{FileInfo: info, name: d.name}, nil
+}
+
+// rootInfo describes the synthetic root directory
+type rootInfo struct{}
+
+func (rootInfo) Name() string       { return "." }
+func (rootInfo) Size() int64        { return 0 }
+func (rootInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (rootInfo) ModTime() time.Time { return time.Time{} }
+func (rootInfo) IsDir() bool        { return true }
+func (rootInfo) Sys() interface{}   { return nil }
+
+// renamedInfo reports a mount's directory under its mount name
+type renamedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i renamedInfo) Name() string { return i.name }
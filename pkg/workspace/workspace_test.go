@@ -0,0 +1,153 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dwrtz/mcp-go/pkg/types"
+)
+
+// writeFiles creates files under dir from a map of slash-separated paths to contents
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func fileURI(dir string) string {
+	return "file://" + filepath.ToSlash(dir)
+}
+
+type staticLister []types.Root
+
+func (l staticLister) ListRoots(ctx context.Context) ([]types.Root, error) {
+	return l, nil
+}
+
+func TestWorkspaceFS(t *testing.T) {
+	tmp := t.TempDir()
+	project := filepath.Join(tmp, "project")
+	docs := filepath.Join(tmp, "docs")
+	writeFiles(t, project, map[string]string{
+		"main.go":        "package main",
+		"pkg/util.go":    "package pkg",
+		"pkg/nested/x.y": "x",
+	})
+	writeFiles(t, docs, map[string]string{"index.md": "# Docs"})
+
+	w, err := Load(context.Background(), staticLister{
+		{URI: fileURI(project), Name: "Project"},
+		{URI: fileURI(filepath.Join(project, "pkg")), Name: "Nested"},
+		{URI: fileURI(docs)},
+		{URI: "git://example.com/repo.git", Name: "Remote"},
+	})
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	mounts := w.Mounts()
+	if len(mounts) != 2 || mounts[0].Name != "Project" || mounts[1].Name != "docs" {
+		t.Fatalf("Unexpected mounts: %+v", mounts)
+	}
+
+	if err := fstest.TestFS(w, "Project/main.go", "Project/pkg/util.go", "Project/pkg/nested/x.y", "docs/index.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(w, "docs/index.md")
+	if err != nil || string(data) != "# Docs" {
+		t.Errorf("ReadFile() = %q, %v", data, err)
+	}
+	if _, err := fs.Stat(w, "Nested"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected nested root to have no mount, got %v", err)
+	}
+}
+
+func TestWorkspaceResolve(t *testing.T) {
+	tmp := t.TempDir()
+	w, err := New([]types.Root{{URI: fileURI(tmp), Name: "ws"}})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		uri     string
+		want    string
+		wantErr error
+	}{
+		{"root itself", fileURI(tmp), "ws", nil},
+		{"file inside", fileURI(filepath.Join(tmp, "a", "b.txt")), "ws/a/b.txt", nil},
+		{"outside roots", fileURI(filepath.Dir(tmp)), "", fs.ErrPermission},
+		{"not a file URI", "https://example.com/a", "", fs.ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := w.Resolve(tt.uri)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Resolve(%s) error = %v, want %v", tt.uri, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve(%s) = %q, %v; want %q", tt.uri, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceSymlinkEscape(t *testing.T) {
+	tmp := t.TempDir()
+	root := filepath.Join(tmp, "root")
+	writeFiles(t, tmp, map[string]string{"secret.txt": "secret", "root/ok.txt": "ok"})
+	if err := os.Symlink(filepath.Join(tmp, "secret.txt"), filepath.Join(root, "escape.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	w, err := New([]types.Root{{URI: fileURI(root)}})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if _, err := fs.ReadFile(w, "root/ok.txt"); err != nil {
+		t.Errorf("ReadFile(ok.txt) error: %v", err)
+	}
+	if _, err := fs.ReadFile(w, "root/escape.txt"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Expected permission error for escaping symlink, got %v", err)
+	}
+	if _, err := w.Open("root/../secret.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Expected invalid path error, got %v", err)
+	}
+}
+
+func TestWorkspaceDuplicateNames(t *testing.T) {
+	tmp := t.TempDir()
+	a := filepath.Join(tmp, "a", "src")
+	b := filepath.Join(tmp, "b", "src")
+	writeFiles(t, a, map[string]string{"f": "a"})
+	writeFiles(t, b, map[string]string{"f": "b"})
+
+	w, err := New([]types.Root{{URI: fileURI(a)}, {URI: fileURI(b)}})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	for name, want := range map[string]string{"src/f": "a", "src-2/f": "b"} {
+		data, err := fs.ReadFile(w, name)
+		if err != nil || string(data) != want {
+			t.Errorf("ReadFile(%s) = %q, %v; want %q", name, data, err, want)
+		}
+	}
+}